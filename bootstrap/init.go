@@ -3,6 +3,7 @@ package bootstrap
 import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/models/scripts"
+	"github.com/cloudreve/Cloudreve/v3/pkg/antivirus"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2"
 	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
@@ -11,6 +12,8 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/crontab"
 	"github.com/cloudreve/Cloudreve/v3/pkg/email"
 	"github.com/cloudreve/Cloudreve/v3/pkg/mq"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
+	"github.com/cloudreve/Cloudreve/v3/pkg/ratelimit"
 	"github.com/cloudreve/Cloudreve/v3/pkg/task"
 	"github.com/cloudreve/Cloudreve/v3/pkg/wopi"
 	"github.com/gin-gonic/gin"
@@ -121,6 +124,24 @@ func Init(path string, statics fs.FS) {
 				wopi.Init()
 			},
 		},
+		{
+			"master",
+			func() {
+				netpolicy.Init()
+			},
+		},
+		{
+			"master",
+			func() {
+				ratelimit.Init()
+			},
+		},
+		{
+			"master",
+			func() {
+				antivirus.Init()
+			},
+		},
 	}
 
 	for _, dependency := range dependencies {
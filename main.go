@@ -17,6 +17,8 @@ import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
 	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/crontab"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/cloudreve/Cloudreve/v3/routers"
 )
@@ -149,6 +151,30 @@ func shutdown(sigChan chan os.Signal, server *http.Server) {
 		util.Log().Error("Failed to shutdown server: %s", err)
 	}
 
+	// Stop crontab jobs
+	if crontab.Cron != nil {
+		crontab.Cron.Stop()
+	}
+
+	// Wait for running tasks, interrupt and checkpoint them when grace period is over
+	taskCtx := ctx
+	if conf.SystemConfig.GracePeriod == 0 {
+		var cancel context.CancelFunc
+		taskCtx, cancel = context.WithCancel(ctx)
+		cancel()
+	}
+	task.Shutdown(taskCtx)
+
+	// Flush buffered file change journal
+	if err := model.FlushFileChanges(); err != nil {
+		util.Log().Warning("Failed to flush file changes: %s", err)
+	}
+
+	// Recalculate sizes of folders marked dirty since the last cron run
+	if err := model.FlushFolderSize(); err != nil {
+		util.Log().Warning("Failed to flush folder sizes: %s", err)
+	}
+
 	// Persist in-memory cache
 	if err := cache.Store.Persist(filepath.Join(model.GetSettingByName("temp_path"), cache.DefaultCacheFile)); err != nil {
 		util.Log().Warning("Failed to persist cache: %s", err)
@@ -9,10 +9,12 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver/oss"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver/upyun"
 	"github.com/cloudreve/Cloudreve/v3/pkg/mq"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/qiniu/go-sdk/v7/auth/qbox"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
@@ -34,7 +36,7 @@ func SignRequired(authInstance auth.Auth) gin.HandlerFunc {
 		case "PUT", "POST", "PATCH":
 			err = auth.CheckRequest(authInstance, c.Request)
 		default:
-			err = auth.CheckURI(authInstance, c.Request.URL)
+			err = auth.CheckBoundURI(authInstance, c.Request.URL, c.ClientIP())
 		}
 
 		if err != nil {
@@ -52,16 +54,85 @@ func CurrentUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
 		uid := session.Get("user_id")
+		if impersonator := session.Get("impersonator_id"); impersonator != nil && uid != nil {
+			// 发起模拟登录的管理员须仍为当前租户下的有效管理员
+			admin, err := model.GetActiveUserByID(impersonator)
+			authorized := err == nil && admin.TenantID == c.GetUint("tenant_id") && (admin.Group.ID == 1 || admin.ID == 1)
+			expires, _ := session.Get("impersonate_expires").(int64)
+			if authorized && time.Now().Unix() < expires {
+				if user, err := model.GetActiveUserByID(uid); err == nil && user.TenantID == c.GetUint("tenant_id") {
+					c.Set("user", &user)
+					c.Set("impersonator", impersonator)
+				}
+
+				// 会话活动记录在管理员名下，避免管理员的设备出现在用户的会话列表中，
+				// 同时管理员可在自己的会话列表中注销此会话
+				if err := sessionstore.Record(admin.ID, session.ID(), c.ClientIP(), c.Request.UserAgent()); err != nil {
+					util.Log().Debug("Failed to record session activity: %s", err)
+				}
+				c.Next()
+				return
+			}
+
+			// 模拟登录已过期或管理员已失去权限，恢复管理员身份
+			reason := "expired"
+			if !authorized {
+				reason = "revoked"
+			}
+			EndImpersonation(c, impersonator, uid, reason)
+			uid = impersonator
+		}
+
 		if uid != nil {
 			user, err := model.GetActiveUserByID(uid)
-			if err == nil {
+			// 会话只在用户所属租户的域名下有效
+			if err == nil && user.TenantID == c.GetUint("tenant_id") {
 				c.Set("user", &user)
+				// 记录会话活动，用于设备管理
+				if err := sessionstore.Record(user.ID, session.ID(), c.ClientIP(), c.Request.UserAgent()); err != nil {
+					util.Log().Debug("Failed to record session activity: %s", err)
+				}
 			}
 		}
 		c.Next()
 	}
 }
 
+// EndImpersonation 结束模拟登录，恢复管理员会话并记录审计日志
+func EndImpersonation(c *gin.Context, impersonator, uid interface{}, reason string) {
+	actor, _ := impersonator.(uint)
+	target, _ := uid.(uint)
+	util.SetSession(c, map[string]interface{}{"user_id": impersonator})
+	util.DeleteSession(c, "impersonator_id")
+	util.DeleteSession(c, "impersonate_expires")
+
+	log := &model.AuditLog{
+		ActorID:  actor,
+		TargetID: target,
+		Action:   model.AuditImpersonateEnd,
+		IP:       c.ClientIP(),
+		Detail:   reason,
+	}
+	if err := log.Create(); err != nil {
+		util.Log().Warning("Failed to write audit log: %s", err)
+	}
+	util.Log().Warning("Admin [ID=%d] stopped impersonating user [ID=%d] (%s).", actor, target, reason)
+}
+
+// NotImpersonating 模拟登录期间禁止访问凭证及安全相关设置
+func NotImpersonating() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if impersonator, ok := c.Get("impersonator"); ok {
+			util.Log().Warning("Admin [ID=%v] tried to access %q while impersonating user, rejected.", impersonator, c.FullPath())
+			c.JSON(200, serializer.Err(serializer.CodeNoPermissionErr, "Not allowed during impersonation", nil))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
 // AuthRequired 需要登录
 func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -94,7 +165,7 @@ func WebDAVAuth() gin.HandlerFunc {
 			return
 		}
 
-		expectedUser, err := model.GetActiveUserByEmail(username)
+		expectedUser, err := model.GetActiveUserByEmail(c.GetUint("tenant_id"), username)
 		if err != nil {
 			c.Status(http.StatusUnauthorized)
 			c.Abort()
@@ -121,6 +192,11 @@ func WebDAVAuth() gin.HandlerFunc {
 			webdav.UseProxy = false
 		}
 
+		// 记录 WebDAV 账号活动
+		if err := sessionstore.RecordWebDAV(webdav.ID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+			util.Log().Debug("Failed to record WebDAV activity: %s", err)
+		}
+
 		c.Set("user", &expectedUser)
 		c.Set("webdav", webdav)
 		c.Next()
@@ -190,6 +266,35 @@ func RemoteCallbackAuth() gin.HandlerFunc {
 	}
 }
 
+// SlaveMetaCallbackAuth 从机推送缩略图与元数据时，使用文件所在存储策略的密钥验证签名
+func SlaveMetaCallbackAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		files, err := model.GetFilesByIDs([]uint{c.MustGet("object_id").(uint)}, 0)
+		if err != nil || len(files) == 0 {
+			c.JSON(CallbackFailedStatusCode, serializer.Err(serializer.CodeFileNotFound, "", err))
+			c.Abort()
+			return
+		}
+
+		policy := files[0].GetPolicy()
+		if policy.Type != "remote" {
+			c.JSON(CallbackFailedStatusCode, serializer.Err(serializer.CodePolicyNotAllowed, "", nil))
+			c.Abort()
+			return
+		}
+
+		authInstance := auth.HMACAuth{SecretKey: []byte(policy.SecretKey)}
+		if err := auth.CheckRequest(authInstance, c.Request); err != nil {
+			c.JSON(CallbackFailedStatusCode, serializer.Err(serializer.CodeCredentialInvalid, err.Error(), err))
+			c.Abort()
+			return
+		}
+
+		c.Set("file", &files[0])
+		c.Next()
+	}
+}
+
 // QiniuCallbackAuth 七牛回调签名验证
 func QiniuCallbackAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
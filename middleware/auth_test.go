@@ -64,6 +64,90 @@ func TestCurrentUser(t *testing.T) {
 	user, _ = c.Get("user")
 	asserts.NotNil(user)
 	asserts.NoError(mock.ExpectationsWereMet())
+
+	//模拟登录中
+	c, _ = gin.CreateTestContext(rec)
+	c.Request, _ = http.NewRequest("GET", "/test", nil)
+	sessionFunc(c)
+	util.SetSession(c, map[string]interface{}{
+		"user_id":             uint(2),
+		"impersonator_id":     uint(1),
+		"impersonate_expires": time.Now().Add(time.Hour).Unix(),
+	})
+	mock.ExpectQuery("^SELECT (.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "options"}).
+		AddRow(1, "admin@cloudreve.org", "{}"))
+	mock.ExpectQuery("^SELECT (.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "options"}).
+		AddRow(2, "user@cloudreve.org", "{}"))
+	CurrentUser()(c)
+	user, _ = c.Get("user")
+	asserts.EqualValues(2, user.(*model.User).ID)
+	impersonator, _ := c.Get("impersonator")
+	asserts.Equal(uint(1), impersonator)
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	//发起模拟登录的管理员已失去权限
+	c, _ = gin.CreateTestContext(rec)
+	c.Request, _ = http.NewRequest("GET", "/test", nil)
+	sessionFunc(c)
+	util.SetSession(c, map[string]interface{}{
+		"user_id":             uint(2),
+		"impersonator_id":     uint(3),
+		"impersonate_expires": time.Now().Add(time.Hour).Unix(),
+	})
+	mock.ExpectQuery("^SELECT (.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "options"}).
+		AddRow(3, "demoted@cloudreve.org", "{}"))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)audit_logs(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("^SELECT (.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "options"}).
+		AddRow(3, "demoted@cloudreve.org", "{}"))
+	CurrentUser()(c)
+	user, _ = c.Get("user")
+	asserts.EqualValues(3, user.(*model.User).ID)
+	asserts.Nil(util.GetSession(c, "impersonator_id"))
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	//模拟登录已过期
+	c, _ = gin.CreateTestContext(rec)
+	c.Request, _ = http.NewRequest("GET", "/test", nil)
+	sessionFunc(c)
+	util.SetSession(c, map[string]interface{}{
+		"user_id":             uint(2),
+		"impersonator_id":     uint(1),
+		"impersonate_expires": time.Now().Add(-time.Second).Unix(),
+	})
+	mock.ExpectQuery("^SELECT (.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "options"}).
+		AddRow(1, "admin@cloudreve.org", "{}"))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)audit_logs(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("^SELECT (.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "options"}).
+		AddRow(1, "admin@cloudreve.org", "{}"))
+	CurrentUser()(c)
+	user, _ = c.Get("user")
+	asserts.EqualValues(1, user.(*model.User).ID)
+	asserts.Nil(util.GetSession(c, "impersonator_id"))
+	asserts.Equal(uint(1), util.GetSession(c, "user_id"))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+func TestNotImpersonating(t *testing.T) {
+	asserts := assert.New(t)
+	rec := httptest.NewRecorder()
+	testFunc := NotImpersonating()
+
+	// 正常登录
+	c, _ := gin.CreateTestContext(rec)
+	c.Request, _ = http.NewRequest("PATCH", "/test", nil)
+	testFunc(c)
+	asserts.False(c.IsAborted())
+
+	// 模拟登录中
+	c, _ = gin.CreateTestContext(rec)
+	c.Request, _ = http.NewRequest("PATCH", "/test", nil)
+	c.Set("impersonator", uint(1))
+	testFunc(c)
+	asserts.True(c.IsAborted())
 }
 
 func TestAuthRequired(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/recaptcha"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/siteverify"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/mojocn/base64Captcha"
@@ -19,9 +20,9 @@ import (
 )
 
 type req struct {
-	CaptchaCode string `json:"captchaCode"`
-	Ticket      string `json:"ticket"`
-	Randstr     string `json:"randstr"`
+	CaptchaCode string `json:"captchaCode" form:"captchaCode"`
+	Ticket      string `json:"ticket" form:"ticket"`
+	Randstr     string `json:"randstr" form:"randstr"`
 }
 
 const (
@@ -39,29 +40,38 @@ func CaptchaRequired(configName string) gin.HandlerFunc {
 			"captcha_TCaptcha_SecretId",
 			"captcha_TCaptcha_SecretKey",
 			"captcha_TCaptcha_CaptchaAppId",
-			"captcha_TCaptcha_AppSecretKey")
+			"captcha_TCaptcha_AppSecretKey",
+			"captcha_HCaptchaSecret",
+			"captcha_TurnstileSecret")
 		// 检查验证码
 		isCaptchaRequired := model.IsTrueVal(options[configName])
 
 		if isCaptchaRequired {
 			var service req
-			bodyCopy := new(bytes.Buffer)
-			_, err := io.Copy(bodyCopy, c.Request.Body)
-			if err != nil {
-				c.JSON(200, serializer.Err(serializer.CodeCaptchaError, captchaNotMatch, err))
-				c.Abort()
-				return
-			}
+			var err error
+			if c.Query("captchaCode") != "" || c.Query("ticket") != "" {
+				// 无请求正文的请求（如提交分享密码）通过 Query 参数提交验证码
+				_ = c.ShouldBindQuery(&service)
+			} else {
+				bodyCopy := new(bytes.Buffer)
+				_, err = io.Copy(bodyCopy, c.Request.Body)
+				if err != nil {
+					c.JSON(200, serializer.Err(serializer.CodeCaptchaError, captchaNotMatch, err))
+					c.Abort()
+					return
+				}
 
-			bodyData := bodyCopy.Bytes()
-			err = json.Unmarshal(bodyData, &service)
-			if err != nil {
-				c.JSON(200, serializer.Err(serializer.CodeCaptchaError, captchaNotMatch, err))
-				c.Abort()
-				return
+				bodyData := bodyCopy.Bytes()
+				err = json.Unmarshal(bodyData, &service)
+				if err != nil {
+					c.JSON(200, serializer.Err(serializer.CodeCaptchaError, captchaNotMatch, err))
+					c.Abort()
+					return
+				}
+
+				c.Request.Body = ioutil.NopCloser(bytes.NewReader(bodyData))
 			}
 
-			c.Request.Body = ioutil.NopCloser(bytes.NewReader(bodyData))
 			switch options["captcha_type"] {
 			case "normal":
 				captchaID := util.GetSession(c, "captchaID")
@@ -119,9 +129,38 @@ func CaptchaRequired(configName string) gin.HandlerFunc {
 					return
 				}
 
+				break
+			case "hcaptcha", "turnstile":
+				var verifier *siteverify.Verifier
+				if options["captcha_type"] == "hcaptcha" {
+					verifier = siteverify.NewHCaptcha(options["captcha_HCaptchaSecret"])
+				} else {
+					verifier = siteverify.NewTurnstile(options["captcha_TurnstileSecret"])
+				}
+
+				if err := verifier.Verify(service.CaptchaCode, c.ClientIP()); err != nil {
+					util.Log().Warning("%s verification failed, %s", options["captcha_type"], err)
+					c.JSON(200, serializer.Err(serializer.CodeCaptchaRefreshNeeded, captchaRefresh, nil))
+					c.Abort()
+					return
+				}
+
 				break
 			}
 		}
 		c.Next()
 	}
 }
+
+// SharePasswordCaptchaRequired 提交分享密码时检查验证码
+func SharePasswordCaptchaRequired() gin.HandlerFunc {
+	check := CaptchaRequired("share_captcha")
+	return func(c *gin.Context) {
+		if c.Query("password") == "" {
+			c.Next()
+			return
+		}
+
+		check(c)
+	}
+}
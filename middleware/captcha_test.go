@@ -175,3 +175,47 @@ func TestCaptchaRequired_Tcaptcha(t *testing.T) {
 		asserts.True(c.IsAborted())
 	}
 }
+
+func TestCaptchaRequired_SiteVerify(t *testing.T) {
+	asserts := assert.New(t)
+	rec := httptest.NewRecorder()
+
+	// 未提交令牌
+	for _, captchaType := range []string{"hcaptcha", "turnstile"} {
+		cache.SetSettings(map[string]string{
+			"login_captcha": "1",
+			"captcha_type":  captchaType,
+		}, "setting_")
+		TestFunc := CaptchaRequired("login_captcha")
+		c, _ := gin.CreateTestContext(rec)
+		c.Request, _ = http.NewRequest("POST", "/", bytes.NewReader([]byte("{}")))
+		TestFunc(c)
+		asserts.True(c.IsAborted())
+	}
+}
+
+func TestSharePasswordCaptchaRequired(t *testing.T) {
+	asserts := assert.New(t)
+	rec := httptest.NewRecorder()
+	cache.SetSettings(map[string]string{
+		"share_captcha": "1",
+		"captcha_type":  "hcaptcha",
+	}, "setting_")
+	TestFunc := SharePasswordCaptchaRequired()
+
+	// 未提交密码
+	{
+		c, _ := gin.CreateTestContext(rec)
+		c.Request, _ = http.NewRequest("GET", "/share/info/1", nil)
+		TestFunc(c)
+		asserts.False(c.IsAborted())
+	}
+
+	// 提交密码，验证码无效
+	{
+		c, _ := gin.CreateTestContext(rec)
+		c.Request, _ = http.NewRequest("GET", "/share/info/1?password=123&ticket=1", nil)
+		TestFunc(c)
+		asserts.True(c.IsAborted())
+	}
+}
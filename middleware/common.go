@@ -32,7 +32,7 @@ func HashID(IDType int) gin.HandlerFunc {
 // IsFunctionEnabled 当功能未开启时阻止访问
 func IsFunctionEnabled(key string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !model.IsTrueVal(model.GetSettingByName(key)) {
+		if !model.IsTrueVal(currentTenant(c).GetSettingByName(key)) {
 			c.JSON(200, serializer.Err(serializer.CodeFeatureNotEnabled, "This feature is not enabled", nil))
 			c.Abort()
 			return
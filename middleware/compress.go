@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/gin-gonic/gin"
+)
+
+// compressibleTypes 会被压缩的响应类型，其余类型（图片、视频、压缩包等）多已经过压缩
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/manifest+json",
+	"image/svg+xml",
+}
+
+// Compress 根据客户端支持的编码使用 brotli 或 gzip 压缩文本类响应，
+// 已压缩的内容、分段响应以及 ExcludedPaths 中的路径不压缩
+func Compress() gin.HandlerFunc {
+	if !conf.CompressionConfig.Enabled {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	gzipPool := sync.Pool{New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, conf.CompressionConfig.GzipLevel)
+		return w
+	}}
+	brotliPool := sync.Pool{New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, conf.CompressionConfig.BrotliLevel)
+	}}
+
+	return func(c *gin.Context) {
+		encoding := acceptedEncoding(c.Request)
+		if encoding == "" || c.GetHeader("Range") != "" || c.GetHeader("Upgrade") != "" || isExcludedPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding}
+		switch encoding {
+		case "br":
+			writer.newEncoder = func(w io.Writer) compressEncoder {
+				br := brotliPool.Get().(*brotli.Writer)
+				br.Reset(w)
+				return br
+			}
+			writer.release = func(e compressEncoder) { brotliPool.Put(e) }
+		default:
+			writer.newEncoder = func(w io.Writer) compressEncoder {
+				gz := gzipPool.Get().(*gzip.Writer)
+				gz.Reset(w)
+				return gz
+			}
+			writer.release = func(e compressEncoder) { gzipPool.Put(e) }
+		}
+
+		c.Writer = writer
+		defer writer.close()
+		c.Next()
+	}
+}
+
+// acceptedEncoding 返回客户端可接受的压缩编码，不支持压缩时返回空
+func acceptedEncoding(r *http.Request) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(strings.TrimSpace(params), " ", "") == "q=0" {
+			continue
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	if conf.CompressionConfig.Brotli && accepted["br"] {
+		return "br"
+	}
+
+	if accepted["gzip"] {
+		return "gzip"
+	}
+
+	return ""
+}
+
+func isExcludedPath(path string) bool {
+	for _, prefix := range conf.CompressionConfig.ExcludedPaths {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isCompressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range compressibleTypes {
+		if strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) || mediaType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+type compressEncoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressWriter 在写入第一段正文时根据响应头决定是否压缩
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding   string
+	newEncoder func(w io.Writer) compressEncoder
+	release    func(e compressEncoder)
+
+	encoder compressEncoder
+	decided bool
+}
+
+func (w *compressWriter) decide() {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" ||
+		status == http.StatusPartialContent || status == http.StatusNoContent || status == http.StatusNotModified ||
+		!isCompressibleType(header.Get("Content-Type")) {
+		return
+	}
+
+	header.Set("Content-Encoding", w.encoding)
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.encoder = w.newEncoder(w.ResponseWriter)
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(data)
+	}
+
+	return w.encoder.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Flush() {
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) close() {
+	if w.encoder == nil {
+		return
+	}
+
+	_ = w.encoder.Close()
+	w.release(w.encoder)
+	w.encoder = nil
+}
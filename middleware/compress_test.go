@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	asserts := assert.New(t)
+	r := gin.New()
+	r.Use(Compress())
+	r.GET("/api/v3/directory", func(c *gin.Context) {
+		c.JSON(200, gin.H{"data": "listing"})
+	})
+	r.GET("/api/v3/file/thumb/1", func(c *gin.Context) {
+		c.Data(200, "image/jpeg", []byte("jpeg"))
+	})
+	r.GET("/api/v3/file/download/1", func(c *gin.Context) {
+		c.Data(200, "text/plain", []byte("text"))
+	})
+	r.GET("/g/:id/*path", func(c *gin.Context) {
+		c.Data(200, "text/html", []byte("html"))
+	})
+
+	// gzip
+	{
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v3/directory", nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		r.ServeHTTP(rec, req)
+		asserts.Equal("gzip", rec.Header().Get("Content-Encoding"))
+		reader, err := gzip.NewReader(rec.Body)
+		asserts.NoError(err)
+		body, _ := io.ReadAll(reader)
+		asserts.JSONEq(`{"data":"listing"}`, string(body))
+	}
+
+	// brotli 优先
+	{
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v3/directory", nil)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+		r.ServeHTTP(rec, req)
+		asserts.Equal("br", rec.Header().Get("Content-Encoding"))
+		body, _ := io.ReadAll(brotli.NewReader(rec.Body))
+		asserts.JSONEq(`{"data":"listing"}`, string(body))
+	}
+
+	// 客户端不支持压缩
+	{
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v3/directory", nil)
+		req.Header.Set("Accept-Encoding", "gzip;q=0")
+		r.ServeHTTP(rec, req)
+		asserts.Empty(rec.Header().Get("Content-Encoding"))
+		asserts.JSONEq(`{"data":"listing"}`, rec.Body.String())
+	}
+
+	// 已压缩的内容类型
+	{
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v3/file/thumb/1", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		r.ServeHTTP(rec, req)
+		asserts.Empty(rec.Header().Get("Content-Encoding"))
+		asserts.Equal("jpeg", rec.Body.String())
+	}
+
+	// 排除的路径
+	{
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v3/file/download/1", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		r.ServeHTTP(rec, req)
+		asserts.Empty(rec.Header().Get("Content-Encoding"))
+		asserts.Equal("text", rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/g/abc/index.html", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		r.ServeHTTP(rec, req)
+		asserts.Empty(rec.Header().Get("Content-Encoding"))
+		asserts.Equal("html", rec.Body.String())
+	}
+}
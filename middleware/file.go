@@ -17,7 +17,8 @@ func ValidateSourceLink() gin.HandlerFunc {
 		}
 
 		sourceLink, err := model.GetSourceLinkByID(linkID)
-		if err != nil || sourceLink.File.ID == 0 || sourceLink.File.Name != c.Param("name") {
+		if err != nil || sourceLink.File.ID == 0 || sourceLink.File.Name != c.Param("name") ||
+			!model.IsUserInTenant(sourceLink.File.UserID, c.GetUint("tenant_id")) {
 			c.JSON(200, serializer.Err(serializer.CodeFileNotFound, "", nil))
 			c.Abort()
 			return
@@ -1,8 +1,9 @@
 package middleware
 
 import (
+	"fmt"
 	"github.com/cloudreve/Cloudreve/v3/bootstrap"
-	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/gin-gonic/gin"
 	"io/ioutil"
@@ -43,7 +44,10 @@ func FrontendFileHandler() gin.HandlerFunc {
 			strings.HasPrefix(path, "/custom") ||
 			strings.HasPrefix(path, "/dav") ||
 			strings.HasPrefix(path, "/f") ||
-			path == "/manifest.json" {
+			strings.HasPrefix(path, "/g/") ||
+			path == "/manifest.json" ||
+			path == "/healthz" ||
+			path == "/readyz" {
 			c.Next()
 			return
 		}
@@ -51,7 +55,7 @@ func FrontendFileHandler() gin.HandlerFunc {
 		// 不存在的路径和index.html均返回index.html
 		if (path == "/index.html") || (path == "/") || !bootstrap.StaticFS.Exists("/", path) {
 			// 读取、替换站点设置
-			options := model.GetSettingByNames("siteName", "siteKeywords", "siteScript",
+			options := currentTenant(c).GetSettingByNames("siteName", "siteKeywords", "siteScript",
 				"pwa_small_icon")
 			finalHTML := util.Replace(map[string]string{
 				"{siteName}":       options["siteName"],
@@ -68,6 +72,8 @@ func FrontendFileHandler() gin.HandlerFunc {
 
 		if path == "/service-worker.js" {
 			c.Header("Cache-Control", "public, no-cache")
+		} else if cacheControl := staticCacheControl(path); cacheControl != "" {
+			c.Header("Cache-Control", cacheControl)
 		}
 
 		// 存在的静态文件
@@ -75,3 +81,16 @@ func FrontendFileHandler() gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+// staticCacheControl 返回静态资源的缓存策略，未启用缓存时返回空
+func staticCacheControl(path string) string {
+	if conf.StaticConfig.Immutable && strings.HasPrefix(path, "/static/") {
+		return "public, max-age=31536000, immutable"
+	}
+
+	if conf.StaticConfig.MaxAge > 0 {
+		return fmt.Sprintf("public, max-age=%d", conf.StaticConfig.MaxAge)
+	}
+
+	return ""
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/i18n"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// Localize 为 API 错误响应补充字符串形式的错误标识，并按 Accept-Language 翻译错误消息
+func Localize() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		if lang != "" {
+			c.Header("Content-Language", lang)
+			c.Set("lang", lang)
+		}
+
+		c.Writer = &localizedWriter{ResponseWriter: c.Writer, lang: lang}
+		c.Next()
+	}
+}
+
+// localizedResponse 与 serializer.Response 结构一致，Data 保留原始内容
+type localizedResponse struct {
+	Code  int             `json:"code"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Msg   string          `json:"msg"`
+	Error string          `json:"error,omitempty"`
+	Key   string          `json:"key,omitempty"`
+}
+
+// localizedWriter 改写写入的 JSON 错误响应
+type localizedWriter struct {
+	gin.ResponseWriter
+	lang string
+}
+
+// Write 写入响应正文，只改写 code 非 0 的 JSON 响应
+func (w *localizedWriter) Write(data []byte) (int, error) {
+	if !bytes.HasPrefix(data, []byte(`{"code":`)) || bytes.HasPrefix(data, []byte(`{"code":0,`)) ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return w.ResponseWriter.Write(data)
+	}
+
+	var res localizedResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+
+	if res.Key == "" {
+		res.Key = serializer.ErrorKey(res.Code)
+	}
+
+	// 只翻译空消息或与通用消息一致的消息，具体的错误描述原样保留
+	if msg, ok := i18n.Message(w.lang, res.Key); ok && (res.Msg == "" || isGenericMessage(res.Key, res.Msg)) {
+		res.Msg = msg
+	}
+
+	localized, err := json.Marshal(res)
+	if err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+
+	if _, err := w.ResponseWriter.Write(localized); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// isGenericMessage 判断消息是否为错误标识对应的通用英文消息
+func isGenericMessage(key, msg string) bool {
+	source, ok := i18n.Message(i18n.SourceLang, key)
+	return ok && strings.TrimSuffix(source, ".") == strings.TrimSuffix(msg, ".")
+}
+
+// WriteString 实现 gin.ResponseWriter
+func (w *localizedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalize(t *testing.T) {
+	a := assert.New(t)
+	serve := func(lang string, res serializer.Response) map[string]interface{} {
+		rec := httptest.NewRecorder()
+		_, r := gin.CreateTestContext(rec)
+		r.Use(Localize())
+		r.GET("/", func(c *gin.Context) {
+			c.JSON(200, res)
+		})
+
+		req, _ := http.NewRequest("GET", "/", nil)
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		r.ServeHTTP(rec, req)
+
+		var body map[string]interface{}
+		a.NoError(json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+
+	// 成功响应不做改写
+	{
+		body := serve("zh-CN", serializer.Response{Data: map[string]interface{}{"id": 1}})
+		a.EqualValues(0, body["code"])
+		a.NotContains(body, "key")
+	}
+
+	// 未指定语言时只补充错误标识
+	{
+		body := serve("", serializer.Err(serializer.CodeFileTooLarge, "Too large", nil))
+		a.Equal("file_too_large", body["key"])
+		a.Equal("Too large", body["msg"])
+	}
+
+	// 翻译通用错误消息，保留数据
+	{
+		body := serve("zh-CN,en;q=0.8", serializer.Response{Code: serializer.CodeEditConflict, Msg: "File has been modified by another session", Data: map[string]interface{}{"etag": "1"}})
+		a.Equal("edit_conflict", body["key"])
+		a.Equal("文件已被其他会话修改", body["msg"])
+		a.Equal(map[string]interface{}{"etag": "1"}, body["data"])
+	}
+
+	// 具体的错误描述不做翻译
+	{
+		body := serve("zh-CN", serializer.Err(serializer.CodeFileTooLarge, "File size exceeds 10 MB", nil))
+		a.Equal("file_too_large", body["key"])
+		a.Equal("File size exceeds 10 MB", body["msg"])
+		body = serve("zh-CN", serializer.Err(serializer.CodeFileTooLarge, "", nil))
+		a.Equal("文件过大", body["msg"])
+	}
+
+	// 英文请求只补全空消息
+	{
+		body := serve("en", serializer.Err(serializer.CodeFileTooLarge, "", nil))
+		a.Equal("File is too large.", body["msg"])
+		body = serve("en", serializer.Err(serializer.CodeFileTooLarge, "Too large", nil))
+		a.Equal("Too large", body["msg"])
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// NetworkPolicy 根据当前用户（未登录时为游客）所在用户组的网络策略限制访问
+func NetworkPolicy() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user *model.User
+		if userCtx, ok := c.Get("user"); ok {
+			user = userCtx.(*model.User)
+		} else {
+			user = model.NewAnonymousUser()
+		}
+
+		if err := netpolicy.Check(&user.Group, c.ClientIP(), c.Request); err != nil {
+			c.JSON(200, serializer.Err(serializer.CodeNetworkRestricted, err.Error(), err))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
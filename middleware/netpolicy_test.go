@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkPolicy(t *testing.T) {
+	asserts := assert.New(t)
+	rec := httptest.NewRecorder()
+	TestFunc := NetworkPolicy()
+	user := &model.User{}
+	user.Group.OptionsSerialized.IPDenyList = []string{"10.0.0.0/8"}
+
+	// 被禁止的 IP
+	{
+		c, _ := gin.CreateTestContext(rec)
+		c.Set("user", user)
+		c.Request, _ = http.NewRequest("GET", "/api/v3/share/info/1", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		TestFunc(c)
+		asserts.True(c.IsAborted())
+	}
+
+	// 放行
+	{
+		c, _ := gin.CreateTestContext(rec)
+		c.Set("user", user)
+		c.Request, _ = http.NewRequest("GET", "/api/v3/share/info/1", nil)
+		c.Request.RemoteAddr = "192.168.1.1:1234"
+		TestFunc(c)
+		asserts.False(c.IsAborted())
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/ratelimit"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit 按站点设置中 scope 对应的规则限制请求频率，
+// 已登录用户按用户 ID 计数，游客按 IP 计数
+func RateLimit(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := "ip_" + c.ClientIP()
+		// 游客用户组
+		groupID := uint(3)
+		if userCtx, ok := c.Get("user"); ok {
+			user := userCtx.(*model.User)
+			identity = "uid_" + strconv.FormatUint(uint64(user.ID), 10)
+			groupID = user.GroupID
+		}
+
+		allowed, retryAfter, err := ratelimit.Allow(scope, identity, groupID)
+		if err != nil {
+			util.Log().Warning("Failed to check rate limit of %q: %s", scope, err)
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(429, serializer.Err(serializer.CodeTooManyRequests, "Too many requests, please try again later", nil))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SharePasswordRateLimit 仅在提交分享密码时按 share_password 规则限制请求频率，
+// 普通的分享信息查询不计入
+func SharePasswordRateLimit() gin.HandlerFunc {
+	limit := RateLimit("share_password")
+	return func(c *gin.Context) {
+		if c.Query("password") == "" {
+			c.Next()
+			return
+		}
+
+		limit(c)
+	}
+}
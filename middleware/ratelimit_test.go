@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_rate_limit_rules", `{"TestRateLimit":{"limit":1,"period":60}}`, 0)
+	TestFunc := RateLimit("TestRateLimit")
+
+	// 游客首次请求
+	{
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		TestFunc(c)
+		asserts.False(c.IsAborted())
+	}
+
+	// 游客超出限制
+	{
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		TestFunc(c)
+		asserts.True(c.IsAborted())
+		asserts.Equal(429, rec.Code)
+		asserts.NotEmpty(rec.Header().Get("Retry-After"))
+	}
+
+	// 已登录用户单独计数
+	{
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Set("user", &model.User{})
+		c.Request, _ = http.NewRequest("GET", "/", nil)
+		c.Request.RemoteAddr = "10.0.0.1:1234"
+		TestFunc(c)
+		asserts.False(c.IsAborted())
+	}
+}
+
+func TestSharePasswordRateLimit(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_rate_limit_rules", `{"share_password":{"limit":1,"period":60}}`, 0)
+	TestFunc := SharePasswordRateLimit()
+	request := func(url string) bool {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		c.Request.RemoteAddr = "10.0.0.2:1234"
+		TestFunc(c)
+		return c.IsAborted()
+	}
+
+	// 未提交密码时不计数
+	asserts.False(request("/"))
+	asserts.False(request("/"))
+
+	// 提交密码时限制频率
+	asserts.False(request("/?password=1"))
+	asserts.True(request("/?password=2"))
+	asserts.False(request("/"))
+}
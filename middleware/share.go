@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/gin-gonic/gin"
@@ -45,6 +46,17 @@ func ShareAvailable() gin.HandlerFunc {
 
 		share := model.GetShareByHashID(c.Param("id"))
 
+		// 其他租户用户创建的分享视为不存在
+		if share != nil && !share.InTenant(c.GetUint("tenant_id")) {
+			share = nil
+		}
+
+		if share != nil && !share.Disabled && !share.IsStarted() {
+			c.JSON(200, serializer.Err(serializer.CodeShareNotStarted, "", nil))
+			c.Abort()
+			return
+		}
+
 		if share == nil || !share.IsAvailable() {
 			c.JSON(200, serializer.Err(serializer.CodeShareLinkNotFound, "", nil))
 			c.Abort()
@@ -57,6 +69,30 @@ func ShareAvailable() gin.HandlerFunc {
 	}
 }
 
+// ShareBrowsable 检查分享内容是否允许访客浏览，文件收集分享不允许
+func ShareBrowsable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if share, ok := c.Get("share"); ok && !share.(*model.Share).IsUploadOnly() {
+			c.Next()
+			return
+		}
+		c.JSON(200, serializer.Err(serializer.CodeNoPermissionErr, "", nil))
+		c.Abort()
+	}
+}
+
+// ShareUploadOnly 检查分享是否为文件收集分享
+func ShareUploadOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if share, ok := c.Get("share"); ok && share.(*model.Share).IsUploadOnly() {
+			c.Next()
+			return
+		}
+		c.JSON(200, serializer.Err(serializer.CodeNoPermissionErr, "", nil))
+		c.Abort()
+	}
+}
+
 // ShareCanPreview 检查分享是否可被预览
 func ShareCanPreview() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -115,7 +151,8 @@ func BeforeShareDownload() gin.HandlerFunc {
 					return
 				}
 
-				// 对积分、下载次数进行更新
+				// 对积分、下载次数进行更新，首次下载时通知分享者
+				firstDownload := !share.WasDownloadedBy(user, c)
 				err = share.DownloadBy(user, c)
 				if err != nil {
 					c.JSON(200, serializer.Err(serializer.CodeGroupNotAllowed, err.Error(),
@@ -124,6 +161,10 @@ func BeforeShareDownload() gin.HandlerFunc {
 					return
 				}
 
+				if firstDownload {
+					notify.ShareDownloaded(share, user)
+				}
+
 				c.Next()
 				return
 			}
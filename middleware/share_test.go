@@ -35,9 +35,11 @@ func TestShareAvailable(t *testing.T) {
 		mock.ExpectQuery("SELECT(.+)shares(.+)").
 			WillReturnRows(
 				sqlmock.NewRows(
-					[]string{"id", "remain_downloads", "source_id"}).
-					AddRow(1, 1, 2),
+					[]string{"id", "remain_downloads", "source_id", "user_id"}).
+					AddRow(1, 1, 2, 1),
 			)
+		mock.ExpectQuery("SELECT(.+)users(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "group_id"}).AddRow(1, 1))
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
 		c, _ := gin.CreateTestContext(rec)
 		c.Params = []gin.Param{
@@ -49,6 +51,23 @@ func TestShareAvailable(t *testing.T) {
 		asserts.NotNil(c.Get("user"))
 		asserts.NotNil(c.Get("share"))
 	}
+
+	// 其他租户用户创建的分享
+	{
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectQuery("SELECT(.+)shares(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "remain_downloads", "user_id"}).AddRow(1, 1, 1))
+		mock.ExpectQuery("SELECT(.+)users(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "group_id", "tenant_id"}).AddRow(1, 1, 2))
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		c, _ := gin.CreateTestContext(rec)
+		c.Params = []gin.Param{
+			{"id", "x9T4"},
+		}
+		testFunc(c)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.True(c.IsAborted())
+	}
 }
 
 func TestShareCanPreview(t *testing.T) {
@@ -188,3 +207,44 @@ func TestShareOwner(t *testing.T) {
 		asserts.False(c.IsAborted())
 	}
 }
+
+func TestShareBrowsable(t *testing.T) {
+	asserts := assert.New(t)
+	rec := httptest.NewRecorder()
+	browsable := ShareBrowsable()
+	uploadOnly := ShareUploadOnly()
+
+	// 无分享上下文
+	{
+		c, _ := gin.CreateTestContext(rec)
+		browsable(c)
+		asserts.True(c.IsAborted())
+		c, _ = gin.CreateTestContext(rec)
+		uploadOnly(c)
+		asserts.True(c.IsAborted())
+	}
+
+	// 普通分享
+	{
+		c, _ := gin.CreateTestContext(rec)
+		c.Set("share", &model.Share{})
+		browsable(c)
+		asserts.False(c.IsAborted())
+		c, _ = gin.CreateTestContext(rec)
+		c.Set("share", &model.Share{})
+		uploadOnly(c)
+		asserts.True(c.IsAborted())
+	}
+
+	// 文件收集
+	{
+		c, _ := gin.CreateTestContext(rec)
+		c.Set("share", &model.Share{Type: model.ShareTypeUpload})
+		browsable(c)
+		asserts.True(c.IsAborted())
+		c, _ = gin.CreateTestContext(rec)
+		c.Set("share", &model.Share{Type: model.ShareTypeUpload})
+		uploadOnly(c)
+		asserts.False(c.IsAborted())
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// ResolveTenant 根据请求的 Host 确定所属租户，未绑定租户的域名属于默认租户
+func ResolveTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, err := model.GetTenantByDomain(c.Request.Host)
+		if err != nil {
+			util.Log().Warning("Failed to resolve tenant of %q: %s", c.Request.Host, err)
+		}
+
+		if tenant != nil {
+			c.Set("tenant", tenant)
+		}
+		c.Set("tenant_id", tenant.TenantID())
+		c.Next()
+	}
+}
+
+// currentTenant 获取请求所属的租户，默认租户返回 nil
+func currentTenant(c *gin.Context) *model.Tenant {
+	if tenant, ok := c.Get("tenant"); ok {
+		return tenant.(*model.Tenant)
+	}
+	return nil
+}
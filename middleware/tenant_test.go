@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTenant(t *testing.T) {
+	a := assert.New(t)
+	tenant := model.Tenant{Domain: "tenant.cloudreve.org"}
+	tenant.ID = 2
+	cache.Set("tenants", []model.Tenant{tenant}, 0)
+	defer cache.Deletes([]string{"tenants"}, "")
+
+	// 绑定租户的域名
+	{
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", "http://Tenant.cloudreve.org:5212/api/v3/site/config", nil)
+		ResolveTenant()(c)
+		a.EqualValues(2, c.GetUint("tenant_id"))
+		a.Equal("tenant.cloudreve.org", currentTenant(c).Domain)
+	}
+
+	// 未绑定租户的域名
+	{
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", "http://cloudreve.org/api/v3/site/config", nil)
+		ResolveTenant()(c)
+		a.EqualValues(0, c.GetUint("tenant_id"))
+		a.Nil(currentTenant(c))
+	}
+}
+
+func TestCurrentUser_Tenant(t *testing.T) {
+	a := assert.New(t)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/test", nil)
+	Session("233")(c)
+	util.SetSession(c, map[string]interface{}{"user_id": 1})
+
+	// 会话不能跨租户使用
+	c.Set("tenant_id", uint(2))
+	mock.ExpectQuery("^SELECT (.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "options", "tenant_id"}).
+		AddRow(1, "admin@cloudreve.org", "{}", 0))
+	CurrentUser()(c)
+	user, _ := c.Get("user")
+	a.Nil(user)
+	a.NoError(mock.ExpectationsWereMet())
+}
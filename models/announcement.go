@@ -0,0 +1,109 @@
+package model
+
+import (
+	"encoding/gob"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+)
+
+// 公告级别
+const (
+	AnnouncementInfo     = "info"
+	AnnouncementWarning  = "warning"
+	AnnouncementCritical = "critical"
+)
+
+// activeAnnouncementsCacheKey 有效公告列表的缓存键
+const activeAnnouncementsCacheKey = "announcements_active"
+
+// Announcement 管理员发布的站点公告
+type Announcement struct {
+	gorm.Model
+	Title     string
+	Content   string `gorm:"type:text"`
+	Severity  string
+	ExpiresAt *time.Time `gorm:"index:expires_at"`
+}
+
+// AnnouncementRead 用户已读公告的记录
+type AnnouncementRead struct {
+	ID             uint `gorm:"primary_key"`
+	AnnouncementID uint `gorm:"unique_index:announcement_user"`
+	UserID         uint `gorm:"unique_index:announcement_user"`
+	CreatedAt      time.Time
+}
+
+func init() {
+	gob.Register([]Announcement{})
+}
+
+// Create 发布公告
+func (announcement *Announcement) Create() error {
+	defer cache.Deletes([]string{activeAnnouncementsCacheKey}, "")
+	return DB.Create(announcement).Error
+}
+
+// Delete 删除公告及其已读记录
+func (announcement *Announcement) Delete() error {
+	defer cache.Deletes([]string{activeAnnouncementsCacheKey}, "")
+	if err := DB.Where("announcement_id = ?", announcement.ID).Delete(&AnnouncementRead{}).Error; err != nil {
+		return err
+	}
+	return DB.Delete(announcement).Error
+}
+
+// Expired 返回公告是否已过期
+func (announcement *Announcement) Expired(now time.Time) bool {
+	return announcement.ExpiresAt != nil && !announcement.ExpiresAt.After(now)
+}
+
+// GetAnnouncementByID 用ID获取公告
+func GetAnnouncementByID(id interface{}) (Announcement, error) {
+	var announcement Announcement
+	result := DB.First(&announcement, id)
+	return announcement, result.Error
+}
+
+// ListActiveAnnouncements 列出所有未过期的公告，结果会被缓存以便前端频繁轮询
+func ListActiveAnnouncements() []Announcement {
+	now := time.Now()
+	var res []Announcement
+	if cached, ok := cache.Get(activeAnnouncementsCacheKey); ok {
+		res = cached.([]Announcement)
+	} else {
+		DB.Where("expires_at is NULL or expires_at > ?", now).Order("id desc").Find(&res)
+		cache.Set(activeAnnouncementsCacheKey, res, 60)
+	}
+
+	// 缓存期间可能有公告过期
+	active := make([]Announcement, 0, len(res))
+	for _, announcement := range res {
+		if !announcement.Expired(now) {
+			active = append(active, announcement)
+		}
+	}
+	return active
+}
+
+// ReadAnnouncementIDs 返回用户已读的公告ID
+func ReadAnnouncementIDs(uid uint, ids []uint) map[uint]bool {
+	res := make(map[uint]bool)
+	if len(ids) == 0 {
+		return res
+	}
+
+	var reads []AnnouncementRead
+	DB.Where("user_id = ? and announcement_id in (?)", uid, ids).Find(&reads)
+	for _, read := range reads {
+		res[read.AnnouncementID] = true
+	}
+	return res
+}
+
+// MarkAnnouncementRead 将公告标记为已读，重复标记不会产生新的记录
+func MarkAnnouncementRead(uid, id uint) error {
+	read := AnnouncementRead{AnnouncementID: id, UserID: uid}
+	return DB.Where(read).FirstOrCreate(&read).Error
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListActiveAnnouncements(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Deletes([]string{activeAnnouncementsCacheKey}, "")
+	expired := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	// 从数据库读取并缓存
+	{
+		mock.ExpectQuery("SELECT(.+)announcements(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "title", "expires_at"}).AddRow(2, "b", future).AddRow(1, "a", nil))
+		res := ListActiveAnnouncements()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Len(res, 2)
+	}
+
+	// 读取缓存，过滤缓存期间过期的公告
+	{
+		cache.Set(activeAnnouncementsCacheKey, []Announcement{{Title: "a"}, {Title: "b", ExpiresAt: &expired}}, 0)
+		res := ListActiveAnnouncements()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Len(res, 1)
+		asserts.Equal("a", res[0].Title)
+	}
+
+	// 发布公告后清除缓存
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)announcements(.+)").WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+		asserts.NoError((&Announcement{Title: "c"}).Create())
+		asserts.NoError(mock.ExpectationsWereMet())
+		_, ok := cache.Get(activeAnnouncementsCacheKey)
+		asserts.False(ok)
+	}
+}
+
+func TestReadAnnouncementIDs(t *testing.T) {
+	asserts := assert.New(t)
+
+	asserts.Empty(ReadAnnouncementIDs(1, nil))
+
+	mock.ExpectQuery("SELECT(.+)announcement_reads(.+)").WithArgs(1, 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "announcement_id", "user_id"}).AddRow(1, 2, 1))
+	res := ReadAnnouncementIDs(1, []uint{1, 2})
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(map[uint]bool{2: true}, res)
+}
+
+func TestMarkAnnouncementRead(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 已读
+	{
+		mock.ExpectQuery("SELECT(.+)announcement_reads(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "announcement_id", "user_id"}).AddRow(1, 2, 1))
+		asserts.NoError(MarkAnnouncementRead(1, 2))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 未读
+	{
+		mock.ExpectQuery("SELECT(.+)announcement_reads(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "announcement_id", "user_id"}))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)announcement_reads(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(MarkAnnouncementRead(1, 2))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// AudioMeta 音频文件的元数据
+type AudioMeta struct {
+	gorm.Model
+	FileID      uint   `gorm:"unique_index:audio_file"`
+	UserID      uint   `gorm:"index:audio_user"`
+	SourceName  string `gorm:"type:text"` // 提取元数据时的物理文件，用于判断是否需要重新提取
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Genre       string
+	Year        int
+	Track       int
+	HasCover    bool
+}
+
+// AudioAlbum 音乐库中的专辑
+type AudioAlbum struct {
+	Album  string
+	Artist string
+	Count  int
+}
+
+// Save 保存音频元数据
+func (meta *AudioMeta) Save() error {
+	return DB.Save(meta).Error
+}
+
+// IsStale 返回元数据是否已不对应文件的当前内容
+func (meta *AudioMeta) IsStale(file *File) bool {
+	return meta.SourceName != file.SourceName
+}
+
+// GetAudioMetaByFileIDs 获取多个文件的音频元数据，以文件 ID 为键
+func GetAudioMetaByFileIDs(fileIDs []uint) map[uint]AudioMeta {
+	var metas []AudioMeta
+	DB.Where("file_id in (?)", fileIDs).Find(&metas)
+
+	res := make(map[uint]AudioMeta, len(metas))
+	for _, meta := range metas {
+		res[meta.FileID] = meta
+	}
+	return res
+}
+
+// SearchAudioMeta 按标题、艺术家、专辑搜索用户的音频
+func SearchAudioMeta(uid uint, keywords string, page, pageSize int) ([]AudioMeta, int) {
+	var (
+		res   []AudioMeta
+		total int
+	)
+	keywords = "%" + keywords + "%"
+	dbChain := DB.Model(&AudioMeta{}).Where("user_id = ? and (title like ? or artist like ? or album like ?)",
+		uid, keywords, keywords, keywords)
+	dbChain.Count(&total)
+	dbChain.Order("artist, album, track").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res)
+
+	return res, total
+}
+
+// ListAudioAlbums 列出用户音乐库中的专辑
+func ListAudioAlbums(uid uint) []AudioAlbum {
+	var res []AudioAlbum
+	DB.Model(&AudioMeta{}).
+		Select("album, max(album_artist) as artist, count(*) as count").
+		Where("user_id = ? and album <> ?", uid, "").
+		Group("album").Order("album").Scan(&res)
+	return res
+}
+
+// ListAudioMetaByAlbum 列出用户专辑中的音频
+func ListAudioMetaByAlbum(uid uint, album string) []AudioMeta {
+	var res []AudioMeta
+	DB.Where("user_id = ? and album = ?", uid, album).Order("track").Find(&res)
+	return res
+}
+
+// DeleteAudioMetaByFileIDs 删除多个文件的音频元数据
+func DeleteAudioMetaByFileIDs(fileIDs []uint) error {
+	return DB.Unscoped().Where("file_id in (?)", fileIDs).Delete(&AudioMeta{}).Error
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAudioMetaByFileIDs(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)audio_meta").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_id", "title"}).AddRow(1, 2, "a").AddRow(2, 3, "b"))
+	res := GetAudioMetaByFileIDs([]uint{2, 3})
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Len(res, 2)
+	asserts.Equal("b", res[3].Title)
+}
+
+func TestAudioMeta_IsStale(t *testing.T) {
+	asserts := assert.New(t)
+	meta := &AudioMeta{SourceName: "a.mp3"}
+
+	asserts.False(meta.IsStale(&File{SourceName: "a.mp3"}))
+	asserts.True(meta.IsStale(&File{SourceName: "b.mp3"}))
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// 审计事件类型
+const (
+	AuditImpersonateStart = "impersonate_start"
+	AuditImpersonateEnd   = "impersonate_end"
+)
+
+// AuditLog 管理员敏感操作的审计记录
+type AuditLog struct {
+	gorm.Model
+	ActorID  uint   `gorm:"index:actor_id"`
+	TargetID uint   `gorm:"index:target_id"`
+	Action   string `gorm:"index:action"`
+	IP       string
+	Detail   string `gorm:"type:text"`
+}
+
+// Create 创建审计记录
+func (log *AuditLog) Create() error {
+	return DB.Create(log).Error
+}
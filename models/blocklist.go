@@ -0,0 +1,224 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 屏蔽列表支持的哈希算法
+const (
+	HashAlgorithmSHA256 = "sha256"
+	HashAlgorithmMD5    = "md5"
+)
+
+// 文件内容哈希及屏蔽标记所使用的元信息
+const (
+	SHA256MetadataKey  = "hash_sha256"
+	MD5MetadataKey     = "hash_md5"
+	BlockedMetadataKey = "blocked_hash"
+
+	// ChecksumStatusMetadataKey 最近一次校验文件内容哈希的结果
+	ChecksumStatusMetadataKey = "checksum_status"
+	// ChecksumVerifiedMetadataKey 最近一次校验文件内容哈希的时间
+	ChecksumVerifiedMetadataKey = "checksum_verified_at"
+)
+
+// 文件内容哈希校验结果
+const (
+	ChecksumStatusOK        = "ok"
+	ChecksumStatusCorrupted = "corrupted"
+)
+
+// 屏蔽列表审计日志动作
+const (
+	// BlocklistActionAdd 添加屏蔽哈希
+	BlocklistActionAdd = "add"
+	// BlocklistActionRemove 移除屏蔽哈希
+	BlocklistActionRemove = "remove"
+	// BlocklistActionReject 拒绝上传命中的文件
+	BlocklistActionReject = "reject"
+	// BlocklistActionFlag 标记已存在的命中文件
+	BlocklistActionFlag = "flag"
+	// BlocklistActionDisableShare 停用命中文件的分享
+	BlocklistActionDisableShare = "disable_share"
+)
+
+// BlockedHash 被屏蔽的文件内容哈希
+type BlockedHash struct {
+	gorm.Model
+	Algorithm  string
+	Hash       string `gorm:"unique_index:hash"`
+	Reason     string `gorm:"type:text"`
+	OperatorID uint
+}
+
+// BlocklistLog 屏蔽列表审计日志
+type BlocklistLog struct {
+	gorm.Model
+	HashID     uint `gorm:"index:hash_id"`
+	Action     string
+	FileID     uint
+	UserID     uint
+	FileName   string
+	OperatorID uint
+}
+
+// metadataKey 返回哈希算法对应的文件元信息键
+func (h *BlockedHash) metadataKey() string {
+	if h.Algorithm == HashAlgorithmMD5 {
+		return MD5MetadataKey
+	}
+	return SHA256MetadataKey
+}
+
+// log 记录审计日志
+func (h *BlockedHash) log(action string, file *File, operator uint) error {
+	record := &BlocklistLog{
+		HashID:     h.ID,
+		Action:     action,
+		OperatorID: operator,
+	}
+	if file != nil {
+		record.FileID = file.ID
+		record.UserID = file.UserID
+		record.FileName = file.Name
+	}
+
+	return DB.Create(record).Error
+}
+
+// Create 添加屏蔽哈希并记录日志
+func (h *BlockedHash) Create() error {
+	h.Hash = strings.ToLower(h.Hash)
+	if err := DB.Create(h).Error; err != nil {
+		return err
+	}
+
+	return h.log(BlocklistActionAdd, nil, h.OperatorID)
+}
+
+// Delete 移除屏蔽哈希并记录日志，已被标记的文件不会自动解除标记。
+// 哈希上有唯一索引，需彻底删除记录以便之后重新添加相同哈希
+func (h *BlockedHash) Delete(operator uint) error {
+	if err := DB.Unscoped().Delete(h).Error; err != nil {
+		return err
+	}
+
+	return h.log(BlocklistActionRemove, nil, operator)
+}
+
+// Reject 记录拒绝上传命中文件的日志
+func (h *BlockedHash) Reject(file *File) error {
+	return h.log(BlocklistActionReject, file, 0)
+}
+
+// Flag 标记命中的文件，并停用其所有分享
+func (h *BlockedHash) Flag(file *File) error {
+	_, err := h.flag(file)
+	return err
+}
+
+// flag 标记命中的文件并停用其所有分享，文件已被标记时不做处理，返回是否由本次调用标记
+func (h *BlockedHash) flag(file *File) (bool, error) {
+	marked, err := h.mark(file)
+	if err != nil || !marked {
+		return false, err
+	}
+
+	if err := h.log(BlocklistActionFlag, file, h.OperatorID); err != nil {
+		return true, err
+	}
+
+	disabled, err := DisableSharesBySourceID(file.ID, false)
+	if err != nil {
+		return true, err
+	}
+
+	if disabled > 0 {
+		return true, h.log(BlocklistActionDisableShare, file, h.OperatorID)
+	}
+
+	return true, nil
+}
+
+// mark 以文件尚未被标记为条件写入屏蔽标记，避免并发标记时重复记录日志
+func (h *BlockedHash) mark(file *File) (bool, error) {
+	meta := make(map[string]string, len(file.MetadataSerialized)+1)
+	for k, v := range file.MetadataSerialized {
+		meta[k] = v
+	}
+	meta[BlockedMetadataKey] = h.Hash
+	metaValue, err := json.Marshal(meta)
+	if err != nil {
+		return false, err
+	}
+
+	flagged := "%\"" + BlockedMetadataKey + "\":\"_%"
+	result := DB.Model(&File{}).Where("id = ? and (metadata is null or metadata not like ?)", file.ID, flagged).
+		UpdateColumn("metadata", string(metaValue))
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	file.Metadata = string(metaValue)
+	file.MetadataSerialized = meta
+	return true, nil
+}
+
+// FlagExistingFiles 标记所有已记录哈希且命中的文件，返回本次新标记的文件数
+func (h *BlockedHash) FlagExistingFiles() (int, error) {
+	var files []File
+	pattern := "%\"" + h.metadataKey() + "\":\"" + h.Hash + "\"%"
+	if err := DB.Where("metadata like ?", pattern).Find(&files).Error; err != nil {
+		return 0, err
+	}
+
+	flagged := 0
+	for i := range files {
+		if files[i].IsBlocked() {
+			continue
+		}
+
+		marked, err := h.flag(&files[i])
+		if marked {
+			flagged++
+		}
+		if err != nil {
+			return flagged, err
+		}
+	}
+
+	return flagged, nil
+}
+
+// GetBlockedHashByID 根据ID获取屏蔽哈希
+func GetBlockedHashByID(id interface{}) (*BlockedHash, error) {
+	record := &BlockedHash{}
+	result := DB.Where("id = ?", id).First(record)
+	return record, result.Error
+}
+
+// HasBlockedHashes 返回屏蔽列表是否非空
+func HasBlockedHashes() bool {
+	total := 0
+	DB.Model(&BlockedHash{}).Count(&total)
+	return total > 0
+}
+
+// MatchBlockedHash 根据文件内容哈希查找命中的屏蔽记录
+func MatchBlockedHash(sha256, md5 string) (*BlockedHash, bool) {
+	record := &BlockedHash{}
+	result := DB.Where(
+		"(algorithm = ? and hash = ?) OR (algorithm = ? and hash = ?)",
+		HashAlgorithmSHA256, strings.ToLower(sha256),
+		HashAlgorithmMD5, strings.ToLower(md5),
+	).First(record)
+	return record, result.Error == nil
+}
+
+// IsBlocked 返回文件内容是否命中屏蔽列表
+func (file *File) IsBlocked() bool {
+	return file.MetadataSerialized[BlockedMetadataKey] != ""
+}
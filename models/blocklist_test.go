@@ -0,0 +1,192 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockedHash_Create(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 插入失败
+	{
+		record := &BlockedHash{Algorithm: HashAlgorithmMD5, Hash: "ABC"}
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.Error(record.Create())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 成功，并记录日志
+	{
+		record := &BlockedHash{Algorithm: HashAlgorithmMD5, Hash: "ABC"}
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, BlocklistActionAdd, 0, 0, "", 0).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(record.Create())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal("abc", record.Hash)
+	}
+}
+
+func TestBlockedHash_Delete(t *testing.T) {
+	asserts := assert.New(t)
+	record := &BlockedHash{Algorithm: HashAlgorithmMD5, Hash: "abc"}
+	record.ID = 1
+
+	// 彻底删除，不使用软删除
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE(.+)blocked_hashes(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, BlocklistActionRemove, 0, 0, "", 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(record.Delete(2))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+func TestBlockedHash_Flag(t *testing.T) {
+	asserts := assert.New(t)
+	record := &BlockedHash{Algorithm: HashAlgorithmSHA256, Hash: "abc"}
+	record.ID = 1
+	file := &File{Name: "bad.txt", UserID: 2}
+	file.ID = 3
+
+	// 存在分享
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)shares(.+)").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, BlocklistActionDisableShare, 3, 2, "bad.txt", 0).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		asserts.NoError(record.Flag(file))
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.True(file.IsBlocked())
+	}
+
+	// 停用分享失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)shares(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.Error(record.Flag(file))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestBlockedHash_FlagExistingFiles(t *testing.T) {
+	asserts := assert.New(t)
+	record := &BlockedHash{Algorithm: HashAlgorithmMD5, Hash: "abc"}
+	record.ID = 1
+
+	// 查询失败
+	{
+		mock.ExpectQuery("SELECT(.+)").WillReturnError(errors.New("error"))
+		_, err := record.FlagExistingFiles()
+		asserts.Error(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 已被标记的文件跳过
+	{
+		mock.ExpectQuery("SELECT(.+)").
+			WithArgs(`%"hash_md5":"abc"%`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(1, `{"hash_md5":"abc","blocked_hash":"abc"}`))
+		flagged, err := record.FlagExistingFiles()
+		asserts.NoError(err)
+		asserts.Equal(0, flagged)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 同时被其他请求标记的文件不计入
+	{
+		mock.ExpectQuery("SELECT(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(1, `{"hash_md5":"abc"}`))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)metadata not like").
+			WithArgs(sqlmock.AnyArg(), 1, `%"blocked_hash":"_%`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		flagged, err := record.FlagExistingFiles()
+		asserts.NoError(err)
+		asserts.Equal(0, flagged)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 新标记的文件
+	{
+		mock.ExpectQuery("SELECT(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(1, `{"hash_md5":"abc"}`))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)shares(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		flagged, err := record.FlagExistingFiles()
+		asserts.NoError(err)
+		asserts.Equal(1, flagged)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestMatchBlockedHash(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 命中
+	{
+		mock.ExpectQuery("SELECT(.+)").
+			WithArgs(HashAlgorithmSHA256, "aa", HashAlgorithmMD5, "bb").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow(1, "aa"))
+		record, ok := MatchBlockedHash("AA", "BB")
+		asserts.True(ok)
+		asserts.Equal("aa", record.Hash)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 未命中
+	{
+		mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		_, ok := MatchBlockedHash("AA", "BB")
+		asserts.False(ok)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestHasBlockedHashes(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	asserts.True(HasBlockedHashes())
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+func TestShare_IsAvailable_Disabled(t *testing.T) {
+	share := Share{Disabled: true, RemainDownloads: -1}
+	assert.False(t, share.IsAvailable())
+}
@@ -26,13 +26,30 @@ var defaultSettings = []Setting{
 	{Name: "smtpUser", Value: `no-reply@acg.blue`, Type: "mail"},
 	{Name: "smtpPass", Value: ``, Type: "mail"},
 	{Name: "smtpEncryption", Value: `0`, Type: "mail"},
+	{Name: "mail_provider", Value: `smtp`, Type: "mail"},
+	{Name: "mail_api_key", Value: ``, Type: "mail"},
+	{Name: "mail_api_secret", Value: ``, Type: "mail"},
+	{Name: "mail_api_domain", Value: ``, Type: "mail"},
+	{Name: "mail_api_region", Value: ``, Type: "mail"},
+	{Name: "mail_api_endpoint", Value: ``, Type: "mail"},
 	{Name: "maxEditSize", Value: `52428800`, Type: "file_edit"},
 	{Name: "archive_timeout", Value: `600`, Type: "timeout"},
+	{Name: "archive_resume_ttl", Value: `3600`, Type: "timeout"},
 	{Name: "download_timeout", Value: `600`, Type: "timeout"},
 	{Name: "preview_timeout", Value: `600`, Type: "timeout"},
 	{Name: "doc_preview_timeout", Value: `600`, Type: "timeout"},
 	{Name: "upload_session_timeout", Value: `86400`, Type: "timeout"},
+	{Name: "upload_session_idle_timeout", Value: `21600`, Type: "timeout"},
+	{Name: "multipart_abort_grace", Value: `3600`, Type: "timeout"},
+	{Name: "proxy_queue_wait", Value: `10`, Type: "timeout"},
+	{Name: "proxy_queue_ticket_ttl", Value: `60`, Type: "timeout"},
+	{Name: "health_probe_ttl", Value: `300`, Type: "timeout"},
+	{Name: "health_probe_timeout", Value: `10`, Type: "timeout"},
+	{Name: "presigned_upload_timeout", Value: `3600`, Type: "timeout"},
+	{Name: "presigned_upload_max_timeout", Value: `604800`, Type: "timeout"},
 	{Name: "slave_api_timeout", Value: `60`, Type: "timeout"},
+	{Name: "impersonate_timeout", Value: `1800`, Type: "timeout"},
+	{Name: "impersonate_max_timeout", Value: `14400`, Type: "timeout"},
 	{Name: "slave_node_retry", Value: `3`, Type: "slave"},
 	{Name: "slave_ping_interval", Value: `60`, Type: "slave"},
 	{Name: "slave_recover_interval", Value: `120`, Type: "slave"},
@@ -48,6 +65,11 @@ var defaultSettings = []Setting{
 	{Name: "login_captcha", Value: `0`, Type: "login"},
 	{Name: "reg_captcha", Value: `0`, Type: "login"},
 	{Name: "email_active", Value: `0`, Type: "register"},
+	{Name: "register_invite_only", Value: `0`, Type: "register"},
+	{Name: "register_approval", Value: `0`, Type: "register"},
+	{Name: "invite_user_max", Value: `0`, Type: "register"},
+	{Name: "invite_default_uses", Value: `1`, Type: "register"},
+	{Name: "user_import_invite_ttl", Value: `604800`, Type: "register"},
 	{Name: "mail_activation_template", Value: `<!DOCTYPE html PUBLIC"-//W3C//DTD XHTML 1.0 Transitional//EN""http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd"><html xmlns="http://www.w3.org/1999/xhtml"style="font-family: 'Helvetica Neue', Helvetica, Arial, sans-serif; box-sizing: border-box;
 font-size: 14px; margin: 0;"><head><meta name="viewport"content="width=device-width"/><meta http-equiv="Content-Type"content="text/html; charset=UTF-8"/><title>激活您的账户</title><style type="text/css">img{max-width:100%}body{-webkit-font-smoothing:antialiased;-webkit-text-size-adjust:none;width:100%!important;height:100%;line-height:1.6em}body{background-color:#f6f6f6}@media only screen and(max-width:640px){body{padding:0!important}h1{font-weight:800!important;margin:20px 0 5px!important}h2{font-weight:800!important;margin:20px 0 5px!important}h3{font-weight:800!important;margin:20px 0 5px!important}h4{font-weight:800!important;margin:20px 0 5px!important}h1{font-size:22px!important}h2{font-size:18px!important}h3{font-size:16px!important}.container{padding:0!important;width:100%!important}.content{padding:0!important}.content-wrap{padding:10px!important}.invoice{width:100%!important}}</style></head><body itemscope itemtype="http://schema.org/EmailMessage"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing:
 border-box; font-size: 14px; -webkit-font-smoothing: antialiased; -webkit-text-size-adjust: none; width: 100% !important; height: 100%; line-height: 1.6em; background-color: #f6f6f6; margin: 0;"bgcolor="#f6f6f6"><table class="body-wrap"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; width: 100%; background-color: #f6f6f6; margin: 0;"bgcolor="#f6f6f6"><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif;
@@ -56,6 +78,14 @@ solid #e9e9e9;"bgcolor="#fff"><tr style="font-family: 'Helvetica Neue',Helvetica
 14px; margin: 0;"><td class="alert alert-warning"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 16px; vertical-align: top; color: #fff; font-weight: 500; text-align: center; border-radius: 3px 3px 0 0; background-color: #009688; margin: 0; padding: 20px;"align="center"bgcolor="#FF9F00"valign="top">激活{siteTitle}账户</td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-wrap"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 20px;"valign="top"><table width="100%"cellpadding="0"cellspacing="0"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica
 Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top">亲爱的<strong style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;">{userName}</strong>：</td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top">感谢您注册{siteTitle},请点击下方按钮完成账户激活。</td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top"><a href="{activationUrl}"class="btn-primary"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; color: #FFF; text-decoration: none; line-height: 2em; font-weight: bold; text-align: center; cursor: pointer; display: inline-block; border-radius: 5px; text-transform: capitalize; background-color: #009688; margin: 0; border-color: #009688; border-style: solid; border-width: 10px 20px;">激活账户</a></td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top">感谢您选择{siteTitle}。</td></tr></table></td></tr></table><div class="footer"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; width: 100%; clear: both; color: #999; margin: 0; padding: 20px;"><table width="100%"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="aligncenter content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 12px; vertical-align: top; color: #999; text-align: center; margin: 0; padding: 0 0 20px;"align="center"valign="top">此邮件由系统自动发送，请不要直接回复。</td></tr></table></div></div></td><td style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0;"valign="top"></td></tr></table></body></html>`, Type: "mail_template"},
 	{Name: "forget_captcha", Value: `0`, Type: "login"},
+	{Name: "share_captcha", Value: `0`, Type: "login"},
+	{Name: "password_min_length", Value: `4`, Type: "login"},
+	{Name: "password_require_upper", Value: `0`, Type: "login"},
+	{Name: "password_require_lower", Value: `0`, Type: "login"},
+	{Name: "password_require_digit", Value: `0`, Type: "login"},
+	{Name: "password_require_symbol", Value: `0`, Type: "login"},
+	{Name: "password_max_age", Value: `0`, Type: "login"},
+	{Name: "password_breach_check", Value: `0`, Type: "login"},
 	{Name: "mail_reset_pwd_template", Value: `<!DOCTYPE html PUBLIC"-//W3C//DTD XHTML 1.0 Transitional//EN""http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd"><html xmlns="http://www.w3.org/1999/xhtml"style="font-family: 'Helvetica Neue', Helvetica, Arial, sans-serif; box-sizing: border-box;
 font-size: 14px; margin: 0;"><head><meta name="viewport"content="width=device-width"/><meta http-equiv="Content-Type"content="text/html; charset=UTF-8"/><title>重设密码</title><style type="text/css">img{max-width:100%}body{-webkit-font-smoothing:antialiased;-webkit-text-size-adjust:none;width:100%!important;height:100%;line-height:1.6em}body{background-color:#f6f6f6}@media only screen and(max-width:640px){body{padding:0!important}h1{font-weight:800!important;margin:20px 0 5px!important}h2{font-weight:800!important;margin:20px 0 5px!important}h3{font-weight:800!important;margin:20px 0 5px!important}h4{font-weight:800!important;margin:20px 0 5px!important}h1{font-size:22px!important}h2{font-size:18px!important}h3{font-size:16px!important}.container{padding:0!important;width:100%!important}.content{padding:0!important}.content-wrap{padding:10px!important}.invoice{width:100%!important}}</style></head><body itemscope itemtype="http://schema.org/EmailMessage"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing:
 border-box; font-size: 14px; -webkit-font-smoothing: antialiased; -webkit-text-size-adjust: none; width: 100% !important; height: 100%; line-height: 1.6em; background-color: #f6f6f6; margin: 0;"bgcolor="#f6f6f6"><table class="body-wrap"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; width: 100%; background-color: #f6f6f6; margin: 0;"bgcolor="#f6f6f6"><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif;
@@ -63,13 +93,88 @@ box-sizing: border-box; font-size: 14px; margin: 0;"><td style="font-family: 'He
 solid #e9e9e9;"bgcolor="#fff"><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size:
 14px; margin: 0;"><td class="alert alert-warning"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 16px; vertical-align: top; color: #fff; font-weight: 500; text-align: center; border-radius: 3px 3px 0 0; background-color: #2196F3; margin: 0; padding: 20px;"align="center"bgcolor="#FF9F00"valign="top">重设{siteTitle}密码</td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-wrap"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 20px;"valign="top"><table width="100%"cellpadding="0"cellspacing="0"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica
 Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top">亲爱的<strong style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;">{userName}</strong>：</td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top">请点击下方按钮完成密码重设。如果非你本人操作，请忽略此邮件。</td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top"><a href="{resetUrl}"class="btn-primary"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; color: #FFF; text-decoration: none; line-height: 2em; font-weight: bold; text-align: center; cursor: pointer; display: inline-block; border-radius: 5px; text-transform: capitalize; background-color: #2196F3; margin: 0; border-color: #2196F3; border-style: solid; border-width: 10px 20px;">重设密码</a></td></tr><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0; padding: 0 0 20px;"valign="top">感谢您选择{siteTitle}。</td></tr></table></td></tr></table><div class="footer"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; width: 100%; clear: both; color: #999; margin: 0; padding: 20px;"><table width="100%"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><tr style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; margin: 0;"><td class="aligncenter content-block"style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 12px; vertical-align: top; color: #999; text-align: center; margin: 0; padding: 0 0 20px;"align="center"valign="top">此邮件由系统自动发送，请不要直接回复。</td></tr></table></div></div></td><td style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; vertical-align: top; margin: 0;"valign="top"></td></tr></table></body></html>`, Type: "mail_template"},
+	{Name: "mail_quarantine_template", Value: `<!DOCTYPE html><html><head><meta charset="utf-8"><title>文件已被隔离</title></head><body style="font-family:Helvetica,Arial,sans-serif;font-size:14px;color:#333;"><p>亲爱的 <strong>{userName}</strong>：</p><p>您上传到 <a href="{siteUrl}">{siteTitle}</a> 的文件 <strong>{fileName}</strong> 被检测到可能包含恶意内容（{signature}），已被隔离，暂时无法下载或分享。</p><p>管理员审核后会决定恢复或删除该文件。如有疑问，请联系站点管理员。</p><p style="color:#999;">{siteSecTitle}</p></body></html>`, Type: "mail_template"},
+	{Name: "mail_activation_subject", Value: `【{siteTitle}】注册激活`, Type: "mail_template"},
+	{Name: "mail_reset_pwd_subject", Value: `【{siteTitle}】密码重置`, Type: "mail_template"},
+	{Name: "mail_quarantine_subject", Value: `【{siteTitle}】文件已被隔离`, Type: "mail_template"},
+	{Name: "mail_notification_subject", Value: `【{siteTitle}】{title}`, Type: "mail_template"},
+	{Name: "mail_notification_template", Value: `<!DOCTYPE html><html><head><meta charset="utf-8"><title>{title}</title></head><body style="font-family: 'Helvetica Neue',Helvetica,Arial,sans-serif; font-size: 14px; background-color: #f6f6f6; margin: 0; padding: 20px;"><div style="max-width: 600px; margin: 0 auto; background: #fff; border: 1px solid #e9e9e9; border-radius: 3px; padding: 20px;"><h2 style="margin-top: 0;">{title}</h2><p>亲爱的 <strong>{userName}</strong>：</p><p>{content}</p><p style="color: #999; font-size: 12px;">此邮件由 <a href="{siteUrl}">{siteTitle}</a> 自动发送，请勿直接回复。</p></div></body></html>`, Type: "mail_template"},
+	{Name: "mail_template_langs", Value: ``, Type: "mail_template"},
+	{Name: "quota_warning_threshold", Value: `90`, Type: "notification"},
+	{Name: "quota_warning_interval", Value: `86400`, Type: "notification"},
+	{Name: "share_slug_enabled", Value: `1`, Type: "share"},
+	{Name: "share_slug_reserved", Value: `admin,api,app,custom,download,home,login,logout,preview,report,s,search,setting,share,signup,static,user,www`, Type: "share"},
+	{Name: "share_password_backoff_after", Value: `3`, Type: "share"},
+	{Name: "share_password_backoff_base", Value: `2`, Type: "share"},
+	{Name: "share_password_lockout", Value: `900`, Type: "share"},
+	{Name: "share_password_share_max", Value: `50`, Type: "share"},
+	{Name: "share_password_notify_after", Value: `10`, Type: "share"},
+	{Name: "share_password_window", Value: `3600`, Type: "share"},
+	{Name: "share_analytics_enabled", Value: `1`, Type: "share"},
+	{Name: "share_event_retention", Value: `90`, Type: "share"},
+	{Name: "guest_upload_pending_ttl", Value: `2592000`, Type: "share"},
+	{Name: "share_save_sync_max", Value: `100`, Type: "share"},
+	{Name: "relocate_sync_max", Value: `100`, Type: "task"},
+	{Name: "archive_restore_days", Value: `1`, Type: "task"},
+	{Name: "share_embed_enabled", Value: `1`, Type: "share"},
+	{Name: "share_embed_token_ttl", Value: `604800`, Type: "share"},
+	{Name: "onlyoffice_enabled", Value: `0`, Type: "onlyoffice"},
+	{Name: "onlyoffice_endpoint", Value: ``, Type: "onlyoffice"},
+	{Name: "onlyoffice_secret", Value: ``, Type: "onlyoffice"},
+	{Name: "onlyoffice_session_timeout", Value: `36000`, Type: "onlyoffice"},
+	{Name: "file_version_max", Value: `10`, Type: "upload"},
+	{Name: "delta_block_size", Value: `1048576`, Type: "upload"},
+	{Name: "delta_signature_ttl", Value: `3600`, Type: "upload"},
+	{Name: "file_lock_ttl", Value: `3600`, Type: "upload"},
+	{Name: "file_lock_max_ttl", Value: `86400`, Type: "upload"},
+	{Name: "hls_enabled", Value: `0`, Type: "hls"},
+	{Name: "hls_ffmpeg_path", Value: `ffmpeg`, Type: "hls"},
+	{Name: "hls_exts", Value: `mkv,avi,mov,mp4,m4v,flv,wmv,ts,m2ts,mts,webm,rm,rmvb`, Type: "hls"},
+	{Name: "hls_qualities", Value: `360p,720p,1080p`, Type: "hls"},
+	{Name: "hls_segment_duration", Value: `6`, Type: "hls"},
+	{Name: "hls_max_jobs", Value: `2`, Type: "hls"},
+	{Name: "hls_wait_timeout", Value: `20`, Type: "hls"},
+	{Name: "hls_job_timeout", Value: `7200`, Type: "hls"},
+	{Name: "hls_cache_ttl", Value: `86400`, Type: "hls"},
+	{Name: "subtitle_max_size", Value: `5242880`, Type: "preview"},
+	{Name: "audio_meta_exts", Value: `mp3,flac,ogg,oga,opus`, Type: "preview"},
+	{Name: "audio_meta_batch", Value: `20`, Type: "preview"},
+	{Name: "photo_meta_exts", Value: `jpg,jpeg,tif,tiff`, Type: "preview"},
+	{Name: "photo_meta_on_upload", Value: `1`, Type: "preview"},
+	{Name: "photo_meta_batch", Value: `50`, Type: "preview"},
+	{Name: "slave_meta_on_upload", Value: `1`, Type: "preview"},
+	{Name: "photo_timeline_page_size", Value: `100`, Type: "preview"},
+	{Name: "pdf_preview_enabled", Value: `0`, Type: "preview"},
+	{Name: "pdf_preview_pdftoppm_path", Value: `pdftoppm`, Type: "preview"},
+	{Name: "pdf_preview_pdfinfo_path", Value: `pdfinfo`, Type: "preview"},
+	{Name: "pdf_preview_width", Value: `1280`, Type: "preview"},
+	{Name: "pdf_preview_max_size", Value: `0`, Type: "preview"},
+	{Name: "pdf_preview_cache_ttl", Value: `86400`, Type: "preview"},
+	{Name: "render_markdown_exts", Value: `md,markdown`, Type: "preview"},
+	{Name: "render_max_size", Value: `2097152`, Type: "preview"},
+	{Name: "render_cache_ttl", Value: `3600`, Type: "preview"},
+	{Name: "reader_max_size", Value: `209715200`, Type: "preview"},
+	{Name: "reader_session_ttl", Value: `3600`, Type: "preview"},
+	{Name: "reader_cache_ttl", Value: `86400`, Type: "preview"},
+	{Name: "traffic_retention", Value: `400`, Type: "basic"},
+	{Name: "usage_rollup_retention", Value: `400`, Type: "basic"},
+	{Name: "file_change_retention", Value: `30`, Type: "basic"},
+	{Name: "aria2_balance_strategy", Value: `RoundRobin`, Type: "aria2"},
+	{Name: "aria2_unhealthy_threshold", Value: `3`, Type: "aria2"},
 	{Name: "db_version_" + conf.RequiredDBVersion, Value: `installed`, Type: "version"},
 	{Name: "hot_share_num", Value: `10`, Type: "share"},
+	{Name: "share_report_enabled", Value: `1`, Type: "share"},
+	{Name: "share_report_interval", Value: `86400`, Type: "share"},
+	{Name: "export_ttl", Value: `604800`, Type: "timeout"},
 	{Name: "gravatar_server", Value: `https://www.gravatar.com/`, Type: "avatar"},
 	{Name: "defaultTheme", Value: `#3f51b5`, Type: "basic"},
 	{Name: "themes", Value: `{"#3f51b5":{"palette":{"primary":{"main":"#3f51b5"},"secondary":{"main":"#f50057"}}},"#2196f3":{"palette":{"primary":{"main":"#2196f3"},"secondary":{"main":"#FFC107"}}},"#673AB7":{"palette":{"primary":{"main":"#673AB7"},"secondary":{"main":"#2196F3"}}},"#E91E63":{"palette":{"primary":{"main":"#E91E63"},"secondary":{"main":"#42A5F5","contrastText":"#fff"}}},"#FF5722":{"palette":{"primary":{"main":"#FF5722"},"secondary":{"main":"#3F51B5"}}},"#FFC107":{"palette":{"primary":{"main":"#FFC107"},"secondary":{"main":"#26C6DA"}}},"#8BC34A":{"palette":{"primary":{"main":"#8BC34A","contrastText":"#fff"},"secondary":{"main":"#FF8A65","contrastText":"#fff"}}},"#009688":{"palette":{"primary":{"main":"#009688"},"secondary":{"main":"#4DD0E1","contrastText":"#fff"}}},"#607D8B":{"palette":{"primary":{"main":"#607D8B"},"secondary":{"main":"#F06292"}}},"#795548":{"palette":{"primary":{"main":"#795548"},"secondary":{"main":"#4CAF50","contrastText":"#fff"}}}}`, Type: "basic"},
 	{Name: "max_worker_num", Value: `10`, Type: "task"},
 	{Name: "max_parallel_transfer", Value: `4`, Type: "task"},
+	{Name: "task_max_retries", Value: `3`, Type: "task"},
+	{Name: "task_retry_backoff", Value: `60`, Type: "task"},
+	{Name: "task_queue_priority", Value: `{"compress":10,"decompress":10,"share_save":10,"transfer":5}`, Type: "task"},
+	{Name: "task_queue_concurrency", Value: `{"import":1,"dedup":1,"user_import":1}`, Type: "task"},
 	{Name: "secret_key", Value: util.RandStringRunes(256), Type: "auth"},
 	{Name: "temp_path", Value: "temp", Type: "path"},
 	{Name: "avatar_path", Value: "avatar", Type: "path"},
@@ -81,6 +186,24 @@ Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; verti
 	{Name: "share_view_method", Value: "list", Type: "view"},
 	{Name: "cron_garbage_collect", Value: "@hourly", Type: "cron"},
 	{Name: "cron_recycle_upload_session", Value: "@every 1h30m", Type: "cron"},
+	{Name: "cron_purge_deleted_users", Value: "@hourly", Type: "cron"},
+	{Name: "cron_flush_folder_size", Value: "@every 1m", Type: "cron"},
+	{Name: "cron_repair_folder_size", Value: "@daily", Type: "cron"},
+	{Name: "cron_flush_file_changes", Value: "@every 1m", Type: "cron"},
+	{Name: "cron_aria2_health_check", Value: "@every 1m", Type: "cron"},
+	{Name: "cron_aria2_schedule", Value: "@every 1m", Type: "cron"},
+	{Name: "cron_collect_orphans", Value: "@daily", Type: "cron"},
+	{Name: "cron_thumb_cleanup", Value: "@hourly", Type: "cron"},
+	{Name: "cron_calibrate_storage", Value: "@daily", Type: "cron"},
+	{Name: "cron_usage_report", Value: "@weekly", Type: "cron"},
+	{Name: "cron_usage_rollup", Value: "@hourly", Type: "cron"},
+	{Name: "cron_content_report", Value: "@daily", Type: "cron"},
+	{Name: "cron_policy_lifecycle", Value: "@daily", Type: "cron"},
+	{Name: "cron_archive_rehydrate", Value: "@every 30m", Type: "cron"},
+	{Name: "cron_share_expiry", Value: "@every 5m", Type: "cron"},
+	{Name: "cron_quota_grace", Value: "@hourly", Type: "cron"},
+	{Name: "cron_multipart_cleanup", Value: "@hourly", Type: "cron"},
+	{Name: "account_deletion_grace", Value: `14`, Type: "login"},
 	{Name: "authn_enabled", Value: "0", Type: "authn"},
 	{Name: "captcha_type", Value: "normal", Type: "captcha"},
 	{Name: "captcha_height", Value: "60", Type: "captcha"},
@@ -100,6 +223,10 @@ Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; verti
 	{Name: "captcha_TCaptcha_AppSecretKey", Value: "", Type: "captcha"},
 	{Name: "captcha_TCaptcha_SecretId", Value: "", Type: "captcha"},
 	{Name: "captcha_TCaptcha_SecretKey", Value: "", Type: "captcha"},
+	{Name: "captcha_HCaptchaKey", Value: "", Type: "captcha"},
+	{Name: "captcha_HCaptchaSecret", Value: "", Type: "captcha"},
+	{Name: "captcha_TurnstileKey", Value: "", Type: "captcha"},
+	{Name: "captcha_TurnstileSecret", Value: "", Type: "captcha"},
 	{Name: "thumb_width", Value: "400", Type: "thumb"},
 	{Name: "thumb_height", Value: "300", Type: "thumb"},
 	{Name: "thumb_file_suffix", Value: "._thumb", Type: "thumb"},
@@ -114,10 +241,28 @@ Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; verti
 	{Name: "thumb_vips_exts", Value: "csv,mat,img,hdr,pbm,pgm,ppm,pfm,pnm,svg,svgz,j2k,jp2,jpt,j2c,jpc,gif,png,jpg,jpeg,jpe,webp,tif,tiff,fits,fit,fts,exr,jxl,pdf,heic,heif,avif,svs,vms,vmu,ndpi,scn,mrxs,svslide,bif,raw", Type: "thumb"},
 	{Name: "thumb_ffmpeg_seek", Value: "00:00:01.00", Type: "thumb"},
 	{Name: "thumb_ffmpeg_path", Value: "ffmpeg", Type: "thumb"},
+	{Name: "thumb_ffmpeg_seek_percent", Value: "0", Type: "thumb"},
+	{Name: "thumb_ffprobe_path", Value: "ffprobe", Type: "thumb"},
 	{Name: "thumb_ffmpeg_exts", Value: "3g2,3gp,asf,asx,avi,divx,flv,m2ts,m2v,m4v,mkv,mov,mp4,mpeg,mpg,mts,mxf,ogv,rm,swf,webm,wmv", Type: "thumb"},
 	{Name: "thumb_libreoffice_path", Value: "soffice", Type: "thumb"},
 	{Name: "thumb_libreoffice_enabled", Value: "0", Type: "thumb"},
 	{Name: "thumb_libreoffice_exts", Value: "md,ods,ots,fods,uos,xlsx,xml,xls,xlt,dif,dbf,html,slk,csv,xlsm,docx,dotx,doc,dot,rtf,xlsm,xlst,xls,xlw,xlc,xlt,pptx,ppsx,potx,pomx,ppt,pps,ppm,pot,pom", Type: "thumb"},
+	{Name: "thumb_magick_enabled", Value: "0", Type: "thumb"},
+	{Name: "thumb_magick_path", Value: "convert", Type: "thumb"},
+	{Name: "thumb_magick_exts", Value: "heic,heif,avif,dng,cr2,cr3,crw,nef,nrw,arw,srf,sr2,raf,orf,rw2,pef,srw,x3f,3fr,kdc,mrw", Type: "thumb"},
+	{Name: "thumb_pdf_enabled", Value: "0", Type: "thumb"},
+	{Name: "thumb_external_enabled", Value: "0", Type: "thumb"},
+	{Name: "thumb_external_type", Value: "imaginary", Type: "thumb"},
+	{Name: "thumb_external_endpoint", Value: "", Type: "thumb"},
+	{Name: "thumb_external_exts", Value: "jpg,jpeg,png,gif,webp,tif,tiff,heic,heif,avif", Type: "thumb"},
+	{Name: "thumb_external_thumbor_key", Value: "", Type: "thumb"},
+	{Name: "thumb_external_timeout", Value: "60", Type: "thumb"},
+	{Name: "thumb_queue_enabled", Value: "0", Type: "thumb"},
+	{Name: "thumb_queue_size", Value: "1000", Type: "thumb"},
+	{Name: "thumb_queue_retry", Value: "3", Type: "thumb"},
+	{Name: "thumb_queue_retry_interval", Value: "10", Type: "thumb"},
+	{Name: "thumb_cache_max_size", Value: "0", Type: "thumb"},
+	{Name: "thumb_cache_touch_interval", Value: "3600", Type: "thumb"},
 	{Name: "thumb_proxy_enabled", Value: "0", Type: "thumb"},
 	{Name: "thumb_proxy_policy", Value: "[]", Type: "thumb"},
 	{Name: "thumb_max_src_size", Value: "31457280", Type: "thumb"},
@@ -134,6 +279,14 @@ Neue',Helvetica,Arial,sans-serif; box-sizing: border-box; font-size: 14px; verti
 	{Name: "wopi_endpoint", Value: "", Type: "wopi"},
 	{Name: "wopi_max_size", Value: "52428800", Type: "wopi"},
 	{Name: "wopi_session_timeout", Value: "36000", Type: "wopi"},
+	{Name: "geoip_provider", Value: "", Type: "geoip"},
+	{Name: "geoip_header", Value: "CF-IPCountry", Type: "geoip"},
+	{Name: "geoip_endpoint", Value: "", Type: "geoip"},
+	{Name: "antivirus_enabled", Value: "0", Type: "antivirus"},
+	{Name: "antivirus_clamd_address", Value: "tcp://127.0.0.1:3310", Type: "antivirus"},
+	{Name: "antivirus_timeout", Value: "60", Type: "antivirus"},
+	{Name: "antivirus_max_size", Value: "104857600", Type: "antivirus"},
+	{Name: "rate_limit_rules", Value: `{"login":{"limit":10,"period":60},"share_password":{"limit":10,"period":60},"download":{"limit":600,"period":3600},"report":{"limit":5,"period":3600},"share_upload":{"limit":100,"period":3600}}`, Type: "ratelimit"},
 }
 
 func InitSlaveDefaults() {
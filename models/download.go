@@ -2,6 +2,10 @@ package model
 
 import (
 	"encoding/json"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/rpc"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
@@ -11,28 +15,47 @@ import (
 // Download 离线下载队列模型
 type Download struct {
 	gorm.Model
-	Status         int    // 任务状态
-	Type           int    // 任务类型
-	Source         string `gorm:"type:text"` // 文件下载地址
-	TotalSize      uint64 // 文件大小
-	DownloadedSize uint64 // 文件大小
-	GID            string `gorm:"size:32,index:gid"` // 任务ID
-	Speed          int    // 下载速度
-	Parent         string `gorm:"type:text"`       // 存储目录
-	Attrs          string `gorm:"size:4294967295"` // 任务状态属性
-	Error          string `gorm:"type:text"`       // 错误描述
-	Dst            string `gorm:"type:text"`       // 用户文件系统存储父目录路径
-	UserID         uint   // 发起者UID
-	TaskID         uint   // 对应的转存任务ID
-	NodeID         uint   // 处理任务的节点ID
+	Status         int        // 任务状态
+	Type           int        // 任务类型
+	Source         string     `gorm:"type:text"` // 文件下载地址
+	TotalSize      uint64     // 文件大小
+	DownloadedSize uint64     // 文件大小
+	GID            string     `gorm:"size:32,index:gid"` // 任务ID
+	Speed          int        // 下载速度
+	Parent         string     `gorm:"type:text"`       // 存储目录
+	Attrs          string     `gorm:"size:4294967295"` // 任务状态属性
+	Error          string     `gorm:"type:text"`       // 错误描述
+	Dst            string     `gorm:"type:text"`       // 用户文件系统存储父目录路径
+	UserID         uint       // 发起者UID
+	TaskID         uint       // 对应的转存任务ID
+	NodeID         uint       // 处理任务的节点ID
+	SeedRatio      float64    // 做种分享率上限，0 表示不限制
+	SeedTime       int        // 做种时长上限（分钟），0 表示不限制
+	SeededAt       *time.Time // 开始做种的时间
+	PostProcess    string     `gorm:"type:text"` // 下载完成后的处理规则
 
 	// 关联模型
 	User *User `gorm:"PRELOAD:false,association_autoupdate:false"`
 
 	// 数据库忽略字段
-	StatusInfo rpc.StatusInfo `gorm:"-"`
-	Task       *Task          `gorm:"-"`
-	NodeName   string         `gorm:"-"`
+	StatusInfo            rpc.StatusInfo      `gorm:"-"`
+	Task                  *Task               `gorm:"-"`
+	NodeName              string              `gorm:"-"`
+	PostProcessSerialized DownloadPostProcess `gorm:"-"`
+}
+
+// DownloadPostProcess 离线下载完成后的处理规则
+type DownloadPostProcess struct {
+	// Extract 解压转存得到的压缩文件，解压到与压缩文件同名的目录中
+	Extract bool `json:"extract,omitempty"`
+	// Flatten 所有文件位于同一个顶层目录时，去除该目录层级
+	Flatten bool `json:"flatten,omitempty"`
+	// Include 仅转存文件名匹配任一规则的文件，规则为通配符，不区分大小写
+	Include []string `json:"include,omitempty"`
+	// Exclude 不转存文件名匹配任一规则的文件
+	Exclude []string `json:"exclude,omitempty"`
+	// MoveTo 处理完成后将结果移动到此目录，为空时保留在下载目录
+	MoveTo string `json:"move_to,omitempty"`
 }
 
 // AfterFind 找到下载任务后的钩子，处理Status结构
@@ -46,6 +69,11 @@ func (task *Download) AfterFind() (err error) {
 		task.Task, _ = GetTasksByID(task.TaskID)
 	}
 
+	// 解析下载完成后的处理规则
+	if err == nil && task.PostProcess != "" {
+		err = json.Unmarshal([]byte(task.PostProcess), &task.PostProcessSerialized)
+	}
+
 	return err
 }
 
@@ -126,3 +154,68 @@ func (task *Download) GetNodeID() uint {
 
 	return task.NodeID
 }
+
+// CountDownloadsByNode 按处理节点统计指定状态的下载任务数量
+func CountDownloadsByNode(status ...int) (map[uint]int, error) {
+	var res []struct {
+		NodeID uint
+		Count  int
+	}
+	err := DB.Model(&Download{}).Where("status in (?)", status).
+		Select("node_id, count(id) as count").Group("node_id").Scan(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int, len(res))
+	for _, r := range res {
+		counts[r.NodeID] = r.Count
+	}
+	return counts, nil
+}
+
+// SeedingLimited 返回任务是否设置了做种上限，设置后下载完成的任务会继续做种，达到上限后才转存
+func (task *Download) SeedingLimited() bool {
+	return task.SeedRatio > 0 || task.SeedTime > 0
+}
+
+// SeedingFinished 返回做种是否已达到分享率或时长上限中的任意一项
+func (task *Download) SeedingFinished(now time.Time) bool {
+	if task.SeedRatio > 0 {
+		uploaded, _ := strconv.ParseFloat(task.StatusInfo.UploadLength, 64)
+		completed, _ := strconv.ParseFloat(task.StatusInfo.CompletedLength, 64)
+		if completed > 0 && uploaded/completed >= task.SeedRatio {
+			return true
+		}
+	}
+
+	if task.SeedTime > 0 && task.SeededAt != nil {
+		return !now.Before(task.SeededAt.Add(time.Duration(task.SeedTime) * time.Minute))
+	}
+
+	return false
+}
+
+// Match 返回文件名是否通过包含、排除规则的筛选
+func (rules *DownloadPostProcess) Match(name string) bool {
+	name = strings.ToLower(name)
+	matchAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(rules.Include) > 0 && !matchAny(rules.Include) {
+		return false
+	}
+
+	return !matchAny(rules.Exclude)
+}
+
+// IsEmpty 返回是否未设置任何处理规则
+func (rules *DownloadPostProcess) IsEmpty() bool {
+	return !rules.Extract && !rules.Flatten && len(rules.Include) == 0 && len(rules.Exclude) == 0 && rules.MoveTo == ""
+}
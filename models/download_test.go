@@ -6,6 +6,7 @@ import (
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestDownload_Create(t *testing.T) {
@@ -188,3 +189,58 @@ func TestDownload_GetNodeID(t *testing.T) {
 	record.NodeID = 5
 	a.EqualValues(5, record.GetNodeID())
 }
+
+func TestDownload_SeedingFinished(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+
+	// 未设置上限
+	{
+		download := &Download{}
+		asserts.False(download.SeedingLimited())
+		asserts.False(download.SeedingFinished(now))
+	}
+
+	// 分享率
+	{
+		download := &Download{SeedRatio: 1.5}
+		download.StatusInfo.CompletedLength = "100"
+		download.StatusInfo.UploadLength = "100"
+		asserts.True(download.SeedingLimited())
+		asserts.False(download.SeedingFinished(now))
+		download.StatusInfo.UploadLength = "150"
+		asserts.True(download.SeedingFinished(now))
+	}
+
+	// 做种时长
+	{
+		seededAt := now.Add(-30 * time.Minute)
+		download := &Download{SeedTime: 60, SeededAt: &seededAt}
+		asserts.False(download.SeedingFinished(now))
+		asserts.True(download.SeedingFinished(now.Add(30 * time.Minute)))
+	}
+}
+
+func TestDownloadPostProcess_Match(t *testing.T) {
+	asserts := assert.New(t)
+
+	rules := &DownloadPostProcess{}
+	asserts.True(rules.IsEmpty())
+	asserts.True(rules.Match("a.txt"))
+
+	rules = &DownloadPostProcess{Include: []string{"*.MKV", "*.srt"}, Exclude: []string{"*sample*"}}
+	asserts.False(rules.IsEmpty())
+	asserts.True(rules.Match("movie.mkv"))
+	asserts.True(rules.Match("movie.SRT"))
+	asserts.False(rules.Match("movie.nfo"))
+	asserts.False(rules.Match("movie.sample.mkv"))
+}
+
+func TestDownload_AfterFindPostProcess(t *testing.T) {
+	asserts := assert.New(t)
+
+	download := Download{PostProcess: `{"flatten":true,"move_to":"/Movies"}`}
+	asserts.NoError(download.AfterFind())
+	asserts.True(download.PostProcessSerialized.Flatten)
+	asserts.Equal("/Movies", download.PostProcessSerialized.MoveTo)
+}
@@ -48,6 +48,9 @@ const (
 	ChecksumMetadataKey = "webdav_checksum"
 )
 
+// MediaDurationMetadataKey 音视频文件的时长（秒）
+const MediaDurationMetadataKey = "media_duration"
+
 func init() {
 	// 注册缓存用到的复杂结构
 	gob.Register(File{})
@@ -70,7 +73,15 @@ func (file *File) Create() error {
 		return err
 	}
 
-	return tx.Commit().Error
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	MarkFolderSizeDirty(file.FolderID)
+	if file.UploadSessionID == nil {
+		RecordFileChange(file.UserID, FileChangeCreate, false, file.ID, file.FolderID, file.Name)
+	}
+	return nil
 }
 
 // AfterFind 找到文件后的钩子
@@ -120,6 +131,30 @@ func (folder *Folder) GetChildFiles() ([]File, error) {
 	return files, result.Error
 }
 
+// GetChildFilesPage 按 order 排序后分页查找目录下已上传完成的子文件，limit 不大于 0 时不限制数量
+func (folder *Folder) GetChildFilesPage(order string, offset, limit int) ([]File, error) {
+	var files []File
+	dbChain := ReadDB().Where("folder_id = ? and upload_session_id is null", folder.ID).Order(order).Offset(offset)
+	if limit > 0 {
+		dbChain = dbChain.Limit(limit)
+	}
+	result := dbChain.Find(&files)
+
+	if result.Error == nil {
+		for i := 0; i < len(files); i++ {
+			files[i].Position = path.Join(folder.Position, folder.Name)
+		}
+	}
+	return files, result.Error
+}
+
+// GetChildFileNames 查找目录下所有已上传完成子文件的ID和文件名
+func (folder *Folder) GetChildFileNames() ([]File, error) {
+	var files []File
+	result := DB.Select("id, name").Where("folder_id = ? and upload_session_id is null", folder.ID).Order("name asc, id asc").Find(&files)
+	return files, result.Error
+}
+
 // GetFilesByIDs 根据文件ID批量获取文件,
 // UID为0表示忽略用户，只根据文件ID检索
 func GetFilesByIDs(ids []uint, uid uint) ([]File, error) {
@@ -142,7 +177,7 @@ func GetFilesByIDsFromTX(tx *gorm.DB, ids []uint, uid uint) ([]File, error) {
 func GetFilesByKeywords(uid uint, parents []uint, keywords ...interface{}) ([]File, error) {
 	var (
 		files      []File
-		result     = DB
+		result     = ReadDB()
 		conditions string
 	)
 
@@ -167,6 +202,14 @@ func GetFilesByKeywords(uid uint, parents []uint, keywords ...interface{}) ([]Fi
 	return files, result.Error
 }
 
+// GetOrphanFiles 检索所在目录已不存在的文件，等待审核的访客上传不属于任何目录，不视为孤立文件
+func GetOrphanFiles(limit int) ([]File, error) {
+	var files []File
+	result := DB.Where("folder_id not in (?)", DB.Model(&Folder{}).Select("id").QueryExpr()).
+		Where("user_id <> ?", 0).Limit(limit).Find(&files)
+	return files, result.Error
+}
+
 // GetChildFilesOfFolders 批量检索目录子文件
 func GetChildFilesOfFolders(folders *[]Folder) ([]File, error) {
 	// 将所有待检索目录ID抽离，以便检索文件
@@ -280,7 +323,15 @@ func DeleteFiles(files []*File, uid uint) error {
 		}
 	}
 
-	return tx.Commit().Error
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		MarkFolderSizeDirty(file.FolderID)
+		RecordFileChange(file.UserID, FileChangeDelete, false, file.ID, file.FolderID, file.Name)
+	}
+	return nil
 }
 
 // GetFilesByParentIDs 根据父目录ID查找文件
@@ -290,6 +341,54 @@ func GetFilesByParentIDs(ids []uint, uid uint) ([]File, error) {
 	return files, result.Error
 }
 
+// GetFilesByParentIDsAfter 按ID升序分页查找目录下ID大于after的已上传完成文件
+func GetFilesByParentIDsAfter(ids []uint, uid uint, after uint, limit int) ([]File, error) {
+	files := make([]File, 0, limit)
+	result := DB.Where("user_id = ? and folder_id in (?) and id > ? and upload_session_id is null", uid, ids, after).
+		Order("id asc").Limit(limit).Find(&files)
+	return files, result.Error
+}
+
+// GetFilesByUserAfter 按ID升序分页查找用户ID大于after的已上传完成文件
+func GetFilesByUserAfter(uid uint, after uint, limit int) ([]File, error) {
+	files := make([]File, 0, limit)
+	result := DB.Where("user_id = ? and id > ? and upload_session_id is null", uid, after).
+		Order("id asc").Limit(limit).Find(&files)
+	return files, result.Error
+}
+
+// CountFilesBySource 返回引用存储策略下同一物理文件的文件记录数量
+func CountFilesBySource(policyID uint, sourceName string) (int, error) {
+	count := 0
+	result := DB.Model(&File{}).Where("policy_id = ? and source_name = ?", policyID, sourceName).Count(&count)
+	return count, result.Error
+}
+
+// GetRecordedSourceNames 返回存储策略下已有文件记录的物理文件路径
+func GetRecordedSourceNames(policyID uint, sourceNames []string) (map[string]bool, error) {
+	var recorded []string
+	res := make(map[string]bool, len(sourceNames))
+	if len(sourceNames) == 0 {
+		return res, nil
+	}
+
+	result := DB.Model(&File{}).Where("policy_id = ? and source_name in (?)", policyID, sourceNames).
+		Pluck("source_name", &recorded)
+	for _, name := range recorded {
+		res[name] = true
+	}
+	return res, result.Error
+}
+
+// GetFilesAfter 按 ID 顺序分批列出已上传完成的文件，after 为上一批最后一个文件的 ID
+func GetFilesAfter(after uint, limit int) ([]File, error) {
+	var files []File
+	result := DB.Select("id, name, size, policy_id, user_id").
+		Where("id > ? and upload_session_id is null", after).
+		Order("id asc").Limit(limit).Find(&files)
+	return files, result.Error
+}
+
 // GetFilesByUploadSession 查找上传会话对应的文件
 func GetFilesByUploadSession(sessionID string, uid uint) (*File, error) {
 	file := File{}
@@ -308,10 +407,80 @@ func (file *File) Rename(new string) error {
 		}
 	}
 
-	return DB.Model(&file).Set("gorm:association_autoupdate", false).Updates(map[string]interface{}{
+	if err := DB.Model(&file).Set("gorm:association_autoupdate", false).Updates(map[string]interface{}{
 		"name":     new,
 		"metadata": file.Metadata,
-	}).Error
+	}).Error; err != nil {
+		return err
+	}
+
+	RecordFileChange(file.UserID, FileChangeRename, false, file.ID, file.FolderID, new)
+	return nil
+}
+
+// BatchRename 在同一事务中将 files、folders 分别重命名为 fileNames、folderNames 中对应ID的新名称。
+// 对象先被改为临时名称，以便批次内的对象互换名称；与批次外的对象重名时整体回滚
+func BatchRename(files []File, fileNames map[uint]string, folders []Folder, folderNames map[uint]string) error {
+	tx := DB.Begin()
+	tempName := func(id uint) string {
+		return fmt.Sprintf(".renaming_%d_%s", id, util.RandStringRunes(8))
+	}
+
+	for _, file := range files {
+		if err := tx.Model(&file).UpdateColumn("name", tempName(file.ID)).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for _, folder := range folders {
+		if err := tx.Model(&folder).UpdateColumn("name", tempName(folder.ID)).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for i := range files {
+		file := &files[i]
+		new := fileNames[file.ID]
+		if file.MetadataSerialized[ThumbStatusMetadataKey] == ThumbStatusNotAvailable &&
+			!strings.EqualFold(filepath.Ext(new), filepath.Ext(file.Name)) {
+			if err := file.resetThumb(); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		if err := tx.Model(file).Set("gorm:association_autoupdate", false).Updates(map[string]interface{}{
+			"name":     new,
+			"metadata": file.Metadata,
+		}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		file.Name = new
+	}
+
+	for i := range folders {
+		new := folderNames[folders[i].ID]
+		if err := tx.Model(&folders[i]).UpdateColumn("name", new).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+		folders[i].Name = new
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		RecordFileChange(file.UserID, FileChangeRename, false, file.ID, file.FolderID, file.Name)
+	}
+	for _, folder := range folders {
+		RecordFileChange(folder.OwnerID, FileChangeRename, true, folder.ID, folderParent(&folder), folder.Name)
+	}
+	return nil
 }
 
 // UpdatePicInfo 更新文件的图像信息
@@ -373,7 +542,13 @@ func (file *File) UpdateSize(value uint64) error {
 	}
 
 	file.Size = value
-	return tx.Commit().Error
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	MarkFolderSizeDirty(file.FolderID)
+	RecordFileChange(file.UserID, FileChangeUpdate, false, file.ID, file.FolderID, file.Name)
+	return nil
 }
 
 // UpdateSourceName 更新文件的源文件名
@@ -394,11 +569,21 @@ func (file *File) PopChunkToFile(lastModified *time.Time, picInfo string) error
 		file.UpdatedAt = *lastModified
 	}
 
-	return DB.Model(file).UpdateColumns(map[string]interface{}{
+	if err := DB.Model(file).UpdateColumns(map[string]interface{}{
 		"upload_session_id": file.UploadSessionID,
 		"updated_at":        file.UpdatedAt,
 		"pic_info":          picInfo,
-	}).Error
+	}).Error; err != nil {
+		return err
+	}
+
+	// 需审核的访客上传暂存等待审核
+	if file.IsGuestPending() {
+		return file.holdGuestUpload()
+	}
+
+	RecordFileChange(file.UserID, FileChangeCreate, false, file.ID, file.FolderID, file.Name)
+	return nil
 }
 
 // CanCopy 返回文件是否可被复制
@@ -436,6 +621,101 @@ func (file *File) resetThumb() error {
 	return err
 }
 
+// Relink 将文件记录指向 source 的物理文件，两者内容需一致。原物理文件的缩略图不再适用，需重新生成
+func (file *File) Relink(source *File) error {
+	file.SourceName = source.SourceName
+	file.PolicyID = source.PolicyID
+	delete(file.MetadataSerialized, ThumbStatusMetadataKey)
+	delete(file.MetadataSerialized, ThumbSidecarMetadataKey)
+	metaValue, err := json.Marshal(&file.MetadataSerialized)
+	if err != nil {
+		return err
+	}
+	file.Metadata = string(metaValue)
+
+	return DB.Model(file).UpdateColumns(map[string]interface{}{
+		"source_name": file.SourceName,
+		"policy_id":   file.PolicyID,
+		"metadata":    file.Metadata,
+	}).Error
+}
+
+// ReplaceWith 以上传完成的占位文件 placeholder 作为文件的新内容，原物理文件保留为历史版本，
+// 占位文件记录随后删除。占位文件已计入用户容量，替换后由历史版本继续占用，用户已用容量不变
+func (file *File) ReplaceWith(placeholder *File, author string) error {
+	tx := DB.Begin()
+	version := &FileVersion{
+		FileID:     file.ID,
+		UserID:     file.UserID,
+		Size:       file.Size,
+		SourceName: file.SourceName,
+		PolicyID:   file.PolicyID,
+		Author:     author,
+	}
+	if err := tx.Create(version).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// 原内容的缩略图与哈希不再适用
+	delete(file.MetadataSerialized, ThumbStatusMetadataKey)
+	if err := file.SetChecksums(placeholder.MetadataSerialized); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	file.SourceName = placeholder.SourceName
+	file.PolicyID = placeholder.PolicyID
+	file.Size = placeholder.Size
+	file.PicInfo = placeholder.PicInfo
+	file.UpdatedAt = placeholder.UpdatedAt
+	if err := tx.Model(file).UpdateColumns(map[string]interface{}{
+		"source_name": file.SourceName,
+		"policy_id":   file.PolicyID,
+		"size":        file.Size,
+		"pic_info":    file.PicInfo,
+		"metadata":    file.Metadata,
+		"updated_at":  file.UpdatedAt,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Unscoped().Delete(placeholder).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	MarkFolderSizeDirty(file.FolderID, placeholder.FolderID)
+	RecordFileChange(file.UserID, FileChangeUpdate, false, file.ID, file.FolderID, file.Name)
+	return nil
+}
+
+// SetChecksums 以 sums 替换文件元信息中的内容哈希并清除校验结果，不写入数据库，
+// 需随后续的文件记录更新一同保存
+func (file *File) SetChecksums(sums map[string]string) error {
+	if file.MetadataSerialized == nil {
+		file.MetadataSerialized = make(map[string]string)
+	}
+
+	for _, key := range []string{MD5MetadataKey, SHA256MetadataKey, ChecksumStatusMetadataKey, ChecksumVerifiedMetadataKey} {
+		delete(file.MetadataSerialized, key)
+	}
+	for _, key := range []string{MD5MetadataKey, SHA256MetadataKey} {
+		if sums[key] != "" {
+			file.MetadataSerialized[key] = sums[key]
+		}
+	}
+
+	metaValue, err := json.Marshal(&file.MetadataSerialized)
+	file.Metadata = string(metaValue)
+	return err
+}
+
 /*
 	实现 webdav.FileInfo 接口
 */
@@ -463,7 +743,7 @@ func (file *File) GetPosition() string {
 // `True` does not guarantee the load request will success in next step, but the client
 // should try to load and fallback to default placeholder in case error returned.
 func (file *File) ShouldLoadThumb() bool {
-	return file.MetadataSerialized[ThumbStatusMetadataKey] != ThumbStatusNotAvailable
+	return file.MetadataSerialized[ThumbStatusMetadataKey] != ThumbStatusNotAvailable && !file.IsOpaque()
 }
 
 // return sidecar thumb file name
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// 文件变更类型
+const (
+	FileChangeCreate = "create"
+	FileChangeUpdate = "update"
+	FileChangeMove   = "move"
+	FileChangeRename = "rename"
+	FileChangeDelete = "delete"
+)
+
+// FileChange 文件变更日志，ID 作为同步客户端增量拉取的游标
+type FileChange struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UserID    uint `gorm:"index:user_change"`
+	Action    string
+	IsFolder  bool
+	ObjectID  uint
+	// ParentID 变更后对象所在的父目录
+	ParentID uint
+	Name     string
+}
+
+// fileChanges 尚未写入数据库的文件变更
+var fileChanges = struct {
+	sync.Mutex
+	pending []FileChange
+	flush   sync.Mutex
+}{}
+
+// RecordFileChange 记录文件或目录的变更，变更会在下一次 FlushFileChanges 时按记录顺序写入数据库
+func RecordFileChange(uid uint, action string, isFolder bool, id, parent uint, name string) {
+	if uid == 0 || id == 0 {
+		return
+	}
+
+	fileChanges.Lock()
+	defer fileChanges.Unlock()
+	fileChanges.pending = append(fileChanges.pending, FileChange{
+		CreatedAt: time.Now(),
+		UserID:    uid,
+		Action:    action,
+		IsFolder:  isFolder,
+		ObjectID:  id,
+		ParentID:  parent,
+		Name:      name,
+	})
+}
+
+// FlushFileChanges 将暂存的文件变更写入数据库，写入失败的变更留待下次处理
+func FlushFileChanges() error {
+	fileChanges.flush.Lock()
+	defer fileChanges.flush.Unlock()
+
+	fileChanges.Lock()
+	pending := fileChanges.pending
+	fileChanges.pending = nil
+	fileChanges.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	tx := DB.Begin()
+	for i := range pending {
+		if err := tx.Create(&pending[i]).Error; err != nil {
+			tx.Rollback()
+			restoreFileChanges(pending)
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		restoreFileChanges(pending)
+		return err
+	}
+
+	return nil
+}
+
+// restoreFileChanges 将未能写入的变更放回暂存队列头部
+func restoreFileChanges(changes []FileChange) {
+	fileChanges.Lock()
+	defer fileChanges.Unlock()
+	for i := range changes {
+		changes[i].ID = 0
+	}
+	fileChanges.pending = append(changes, fileChanges.pending...)
+}
+
+// ListFileChanges 按记录顺序列出用户游标 since 之后的至多 limit 条变更
+func ListFileChanges(uid, since uint, limit int) ([]FileChange, error) {
+	var changes []FileChange
+	result := DB.Where("user_id = ? and id > ?", uid, since).Order("id asc").Limit(limit).Find(&changes)
+	return changes, result.Error
+}
+
+// GetFileChangeRange 返回变更日志中最早与最新的游标，日志为空时均为 0
+func GetFileChangeRange() (oldest, latest uint, err error) {
+	row := DB.Model(&FileChange{}).Select("COALESCE(MIN(id), 0), COALESCE(MAX(id), 0)").Row()
+	err = row.Scan(&oldest, &latest)
+	return
+}
+
+// DeleteFileChangesBefore 删除 before 之前的文件变更
+func DeleteFileChangesBefore(before time.Time) error {
+	return DB.Unscoped().Where("created_at < ?", before).Delete(&FileChange{}).Error
+}
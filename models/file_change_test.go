@@ -0,0 +1,77 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlushFileChanges(t *testing.T) {
+	asserts := assert.New(t)
+	fileChanges.pending = nil
+
+	// 无变更
+	asserts.NoError(FlushFileChanges())
+
+	// 忽略无效记录
+	RecordFileChange(0, FileChangeCreate, false, 1, 1, "a.txt")
+	RecordFileChange(1, FileChangeCreate, false, 0, 1, "a.txt")
+	asserts.Len(fileChanges.pending, 0)
+
+	// 写入失败，变更保留
+	RecordFileChange(1, FileChangeCreate, false, 1, 1, "a.txt")
+	RecordFileChange(1, FileChangeRename, true, 2, 1, "b")
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)file_changes(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT(.+)file_changes(.+)").WillReturnError(errors.New("error"))
+	mock.ExpectRollback()
+	asserts.Error(FlushFileChanges())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Len(fileChanges.pending, 2)
+	asserts.EqualValues(0, fileChanges.pending[0].ID)
+
+	// 成功
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)file_changes(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT(.+)file_changes(.+)").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	asserts.NoError(FlushFileChanges())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Len(fileChanges.pending, 0)
+}
+
+func TestListFileChanges(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)file_changes(.+)").WithArgs(1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "action"}).AddRow(11, FileChangeCreate).AddRow(12, FileChangeDelete))
+	res, err := ListFileChanges(1, 10, 2)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(res, 2)
+	asserts.EqualValues(12, res[1].ID)
+}
+
+func TestGetFileChangeRange(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)MIN(.+)MAX(.+)").WillReturnRows(sqlmock.NewRows([]string{"min", "max"}).AddRow(3, 20))
+	oldest, latest, err := GetFileChangeRange()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.EqualValues(3, oldest)
+	asserts.EqualValues(20, latest)
+}
+
+func TestDeleteFileChangesBefore(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE(.+)file_changes(.+)").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	asserts.NoError(DeleteFileChangesBefore(time.Now()))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
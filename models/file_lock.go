@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// FileLock 文件锁，持有锁期间只有提供相同令牌的请求才能修改文件
+type FileLock struct {
+	gorm.Model
+	FileID uint `gorm:"unique_index:lock_file"`
+	// UserID 加锁的用户
+	UserID uint
+	// Owner 加锁者的显示名称
+	Owner     string
+	Token     string    `json:"-"`
+	ExpiresAt time.Time `gorm:"index:lock_expires"`
+	Note      string
+}
+
+// Create 创建文件锁
+func (lock *FileLock) Create() error {
+	return DB.Create(lock).Error
+}
+
+// Refresh 延长文件锁的有效期
+func (lock *FileLock) Refresh(expires time.Time, note string) error {
+	lock.ExpiresAt = expires
+	lock.Note = note
+	return DB.Model(lock).Updates(map[string]interface{}{"expires_at": expires, "note": note}).Error
+}
+
+// Expired 返回文件锁是否已过期
+func (lock *FileLock) Expired() bool {
+	return !lock.ExpiresAt.After(time.Now())
+}
+
+// GetFileLock 根据文件 ID 获取文件锁，包括已过期的锁
+func GetFileLock(fileID uint) (*FileLock, error) {
+	var lock FileLock
+	result := DB.Where("file_id = ?", fileID).First(&lock)
+	return &lock, result.Error
+}
+
+// GetActiveFileLocks 根据文件 ID 列出尚未过期的文件锁
+func GetActiveFileLocks(fileIDs []uint) ([]FileLock, error) {
+	var locks []FileLock
+	if len(fileIDs) == 0 {
+		return locks, nil
+	}
+	result := DB.Where("file_id in (?) and expires_at > ?", fileIDs, time.Now()).Find(&locks)
+	return locks, result.Error
+}
+
+// GetActiveFileLocksByUID 列出用户加锁且尚未过期的文件锁
+func GetActiveFileLocksByUID(uid uint) ([]FileLock, error) {
+	var locks []FileLock
+	result := DB.Where("user_id = ? and expires_at > ?", uid, time.Now()).Find(&locks)
+	return locks, result.Error
+}
+
+// DeleteFileLocks 根据文件 ID 删除文件锁
+func DeleteFileLocks(fileIDs []uint) error {
+	return DB.Unscoped().Where("file_id in (?)", fileIDs).Delete(&FileLock{}).Error
+}
+
+// DeleteExpiredFileLocks 删除已过期的文件锁
+func DeleteExpiredFileLocks() error {
+	return DB.Unscoped().Where("expires_at <= ?", time.Now()).Delete(&FileLock{}).Error
+}
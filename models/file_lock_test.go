@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLock_Expired(t *testing.T) {
+	a := assert.New(t)
+	a.True((&FileLock{ExpiresAt: time.Now().Add(-time.Second)}).Expired())
+	a.False((&FileLock{ExpiresAt: time.Now().Add(time.Minute)}).Expired())
+}
+
+func TestGetActiveFileLocks(t *testing.T) {
+	a := assert.New(t)
+
+	// 空列表不查询
+	locks, err := GetActiveFileLocks(nil)
+	a.NoError(err)
+	a.Empty(locks)
+
+	mock.ExpectQuery("SELECT(.+)file_locks(.+)expires_at >").WillReturnRows(sqlmock.NewRows([]string{"id", "file_id"}).AddRow(1, 2))
+	locks, err = GetActiveFileLocks([]uint{2, 3})
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.Len(locks, 1)
+}
+
+func TestGetActiveFileLocksByUID(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)file_locks(.+)user_id = (.+)expires_at >").WillReturnRows(sqlmock.NewRows([]string{"id", "file_id"}).AddRow(1, 2))
+	locks, err := GetActiveFileLocksByUID(1)
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.Len(locks, 1)
+}
+
+func TestDeleteExpiredFileLocks(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE(.+)file_locks(.+)expires_at <=").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	a.NoError(DeleteExpiredFileLocks())
+	a.NoError(mock.ExpectationsWereMet())
+}
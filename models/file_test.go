@@ -490,6 +490,20 @@ func TestGetFilesByUploadSession(t *testing.T) {
 	a.Equal("4.txt", files.Name)
 }
 
+func TestGetFilesAfter(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectQuery("SELECT id, name, size, policy_id, user_id FROM `files`(.+)upload_session_id is null(.+)").
+		WithArgs(10).
+		WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name", "size"}).AddRow(11, "a.png", 100))
+	files, err := GetFilesAfter(10, 100)
+	a.NoError(err)
+	a.NoError(mock.ExpectationsWereMet())
+	a.Len(files, 1)
+	a.EqualValues(100, files[0].Size)
+}
+
 func TestFile_Updates(t *testing.T) {
 	asserts := assert.New(t)
 	file := File{Model: gorm.Model{ID: 1}}
@@ -783,3 +797,120 @@ func TestFile_ThumbFile(t *testing.T) {
 
 	a.Equal("test._thumb", file.ThumbFile())
 }
+
+func TestBatchRename(t *testing.T) {
+	asserts := assert.New(t)
+	files := []File{{Model: gorm.Model{ID: 1}, Name: "a.txt"}}
+	folders := []Folder{{Model: gorm.Model{ID: 2}, Name: "b"}}
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)folders(.+)").WithArgs(sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs("", "c.txt", sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)folders(.+)").WithArgs("d", 2).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		err := BatchRename(files, map[uint]string{1: "c.txt"}, folders, map[uint]string{2: "d"})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Equal("c.txt", files[0].Name)
+		asserts.Equal("d", folders[0].Name)
+	}
+
+	// 重名，整体回滚
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)folders(.+)").WithArgs(sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnError(errors.New("duplicated"))
+		mock.ExpectRollback()
+		err := BatchRename(files, map[uint]string{1: "e.txt"}, folders, map[uint]string{2: "f"})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Error(err)
+	}
+}
+
+func TestFile_SetChecksums(t *testing.T) {
+	a := assert.New(t)
+	file := &File{MetadataSerialized: map[string]string{
+		MD5MetadataKey:            "old",
+		SHA256MetadataKey:         "old",
+		ChecksumStatusMetadataKey: ChecksumStatusCorrupted,
+		"other":                   "value",
+	}}
+
+	a.NoError(file.SetChecksums(map[string]string{MD5MetadataKey: "md5"}))
+	a.Equal("md5", file.MetadataSerialized[MD5MetadataKey])
+	a.NotContains(file.MetadataSerialized, SHA256MetadataKey)
+	a.NotContains(file.MetadataSerialized, ChecksumStatusMetadataKey)
+	a.Equal("value", file.MetadataSerialized["other"])
+	a.JSONEq(`{"hash_md5":"md5","other":"value"}`, file.Metadata)
+}
+
+func TestFile_ReplaceWith(t *testing.T) {
+	a := assert.New(t)
+	file := &File{Model: gorm.Model{ID: 1}, UserID: 1, Size: 10, SourceName: "old", PolicyID: 1,
+		MetadataSerialized: map[string]string{ThumbStatusMetadataKey: ThumbStatusExist, MD5MetadataKey: "old"}}
+	placeholder := &File{Model: gorm.Model{ID: 2}, Size: 20, SourceName: "new", PolicyID: 2,
+		MetadataSerialized: map[string]string{SHA256MetadataKey: "sha"}}
+
+	// 失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)file_versions(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		a.Error(file.ReplaceWith(placeholder, "nick"))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)file_versions(.+)").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1, 1, 10, "old", 1, "nick").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("DELETE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		a.NoError(file.ReplaceWith(placeholder, "nick"))
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal("new", file.SourceName)
+		a.EqualValues(2, file.PolicyID)
+		a.EqualValues(20, file.Size)
+		a.Equal("sha", file.MetadataSerialized[SHA256MetadataKey])
+		a.NotContains(file.MetadataSerialized, MD5MetadataKey)
+		a.NotContains(file.MetadataSerialized, ThumbStatusMetadataKey)
+	}
+}
+
+func TestGetOrphanFiles(t *testing.T) {
+	a := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)files(.+)folder_id not in \\(SELECT id FROM `folders`").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	files, err := GetOrphanFiles(10)
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.Len(files, 1)
+}
+
+func TestGetRecordedSourceNames(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 空列表不查询
+	{
+		res, err := GetRecordedSourceNames(1, nil)
+		asserts.NoError(err)
+		asserts.Empty(res)
+	}
+
+	{
+		mock.ExpectQuery("SELECT(.+)source_name(.+)files(.+)policy_id = (.+)source_name in").
+			WithArgs(1, "a/1.txt", "a/2.txt").
+			WillReturnRows(sqlmock.NewRows([]string{"source_name"}).AddRow("a/1.txt"))
+		res, err := GetRecordedSourceNames(1, []string{"a/1.txt", "a/2.txt"})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.True(res["a/1.txt"])
+		asserts.False(res["a/2.txt"])
+	}
+}
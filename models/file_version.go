@@ -0,0 +1,66 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// FileVersion 文件历史版本，保存被覆盖前的物理文件
+type FileVersion struct {
+	gorm.Model
+	FileID     uint `gorm:"index:file_version"`
+	UserID     uint
+	Size       uint64
+	SourceName string `gorm:"type:text"`
+	PolicyID   uint
+	Author     string // 产生此版本的编辑者
+}
+
+// Create 创建历史版本记录
+func (version *FileVersion) Create() error {
+	return DB.Create(version).Error
+}
+
+// Delete 删除历史版本记录
+func (version *FileVersion) Delete() error {
+	return DB.Delete(version).Error
+}
+
+// ListFileVersions 列出文件的历史版本，按创建时间倒序排列
+func ListFileVersions(fileID uint) []FileVersion {
+	var versions []FileVersion
+	DB.Where("file_id = ?", fileID).Order("id desc").Find(&versions)
+	return versions
+}
+
+// ListFileVersionsByFileIDs 列出多个文件的全部历史版本
+func ListFileVersionsByFileIDs(fileIDs []uint) []FileVersion {
+	var versions []FileVersion
+	DB.Where("file_id in (?)", fileIDs).Find(&versions)
+	return versions
+}
+
+// GetFileVersion 获取文件的指定历史版本
+func GetFileVersion(id, fileID uint) (*FileVersion, error) {
+	var version FileVersion
+	result := DB.Where("id = ? and file_id = ?", id, fileID).First(&version)
+	return &version, result.Error
+}
+
+// DeleteFileVersionsByFileIDs 删除多个文件的全部历史版本记录
+func DeleteFileVersionsByFileIDs(fileIDs []uint) error {
+	return DB.Where("file_id in (?)", fileIDs).Delete(&FileVersion{}).Error
+}
+
+// IsSourceNameUsed 返回物理文件是否仍被文件记录或其他历史版本引用
+func IsSourceNameUsed(policyID uint, sourceName string, excludeVersion uint) bool {
+	var count int
+	DB.Model(&File{}).Where("policy_id = ? and source_name = ?", policyID, sourceName).Count(&count)
+	if count > 0 {
+		return true
+	}
+
+	DB.Model(&FileVersion{}).
+		Where("policy_id = ? and source_name = ? and id <> ?", policyID, sourceName, excludeVersion).
+		Count(&count)
+	return count > 0
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFileVersions(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)file_versions(.+)").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_id"}).AddRow(2, 1).AddRow(1, 1))
+	res := ListFileVersions(1)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Len(res, 2)
+	asserts.EqualValues(2, res[0].ID)
+}
+
+func TestIsSourceNameUsed(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 仍被文件引用
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(1, "a").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+		asserts.True(IsSourceNameUsed(1, "a", 2))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 仍被其他版本引用
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+		mock.ExpectQuery("SELECT(.+)file_versions(.+)").
+			WithArgs(1, "a", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+		asserts.True(IsSourceNameUsed(1, "a", 2))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 未被引用
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+		mock.ExpectQuery("SELECT(.+)file_versions(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+		asserts.False(IsSourceNameUsed(1, "a", 2))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
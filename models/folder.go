@@ -16,6 +16,8 @@ type Folder struct {
 	Name     string `gorm:"unique_index:idx_only_one_name"`
 	ParentID *uint  `gorm:"index:parent_id;unique_index:idx_only_one_name"`
 	OwnerID  uint   `gorm:"index:owner_id"`
+	Quota    uint64 // 目录及其子目录的容量上限，0 表示不限制
+	Size     uint64 // 目录及其子目录下文件总大小的缓存，由 FlushFolderSize 增量更新
 
 	// 数据库忽略字段
 	Position      string `gorm:"-"`
@@ -30,9 +32,18 @@ func (folder *Folder) Create() (uint, error) {
 		return folder.ID, err2
 	}
 
+	RecordFileChange(folder.OwnerID, FileChangeCreate, true, folder.ID, folderParent(folder), folder.Name)
 	return folder.ID, nil
 }
 
+// folderParent 返回目录的父目录ID，根目录返回 0
+func folderParent(folder *Folder) uint {
+	if folder.ParentID == nil {
+		return 0
+	}
+	return *folder.ParentID
+}
+
 // GetChild 返回folder下名为name的子目录，不存在则返回错误
 func (folder *Folder) GetChild(name string) (*Folder, error) {
 	var resFolder Folder
@@ -80,6 +91,33 @@ func (folder *Folder) GetChildFolder() ([]Folder, error) {
 	return folders, result.Error
 }
 
+// GetChildFolderPage 按 order 排序后分页查找子目录，limit 不大于 0 时不限制数量
+func (folder *Folder) GetChildFolderPage(order string, offset, limit int) ([]Folder, error) {
+	var folders []Folder
+	dbChain := ReadDB().Where("parent_id = ?", folder.ID).Order(order).Offset(offset)
+	if limit > 0 {
+		dbChain = dbChain.Limit(limit)
+	}
+	result := dbChain.Find(&folders)
+
+	if result.Error == nil {
+		for i := 0; i < len(folders); i++ {
+			folders[i].Position = path.Join(folder.Position, folder.Name)
+		}
+	}
+	return folders, result.Error
+}
+
+// CountChildren 返回目录下子目录和已上传完成子文件的数量
+func (folder *Folder) CountChildren() (folders, files int, err error) {
+	db := ReadDB()
+	if err = db.Model(&Folder{}).Where("parent_id = ?", folder.ID).Count(&folders).Error; err != nil {
+		return
+	}
+	err = db.Model(&File{}).Where("folder_id = ? and upload_session_id is null", folder.ID).Count(&files).Error
+	return
+}
+
 // GetRecursiveChildFolder 查找所有递归子目录，包括自身
 func GetRecursiveChildFolder(dirs []uint, uid uint, includeSelf bool) ([]Folder, error) {
 	folders := make([]Folder, 0, len(dirs))
@@ -134,6 +172,40 @@ func DeleteFolderByIDs(ids []uint) error {
 	return result.Error
 }
 
+// TotalSize 返回目录及其所有子目录下文件的总大小
+func (folder *Folder) TotalSize() (uint64, error) {
+	folders, err := GetRecursiveChildFolder([]uint{folder.ID}, folder.OwnerID, true)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]uint, 0, len(folders))
+	for _, child := range folders {
+		ids = append(ids, child.ID)
+	}
+
+	var size uint64
+	row := DB.Model(&File{}).Where("folder_id in (?)", ids).Select("coalesce(sum(size), 0)").Row()
+	if row == nil {
+		return 0, errors.New("failed to sum file size")
+	}
+	err = row.Scan(&size)
+	return size, err
+}
+
+// SetQuota 设定目录的容量上限
+func (folder *Folder) SetQuota(quota uint64) error {
+	folder.Quota = quota
+	return DB.Model(folder).UpdateColumn("quota", quota).Error
+}
+
+// HasFolderQuota 返回用户是否有设定了容量上限的目录
+func HasFolderQuota(uid uint) bool {
+	total := 0
+	DB.Model(&Folder{}).Where("owner_id = ? and quota > 0", uid).Count(&total)
+	return total > 0
+}
+
 // GetFoldersByIDs 根据ID和用户查找所有目录
 func GetFoldersByIDs(ids []uint, uid uint) ([]Folder, error) {
 	var folders []Folder
@@ -180,6 +252,7 @@ func (folder *Folder) MoveOrCopyFileTo(files []uint, dstFolder *Folder, isCopy b
 			}
 
 			copiedSize += oldFile.Size
+			RecordFileChange(oldFile.UserID, FileChangeCreate, false, oldFile.ID, oldFile.FolderID, oldFile.Name)
 		}
 
 	} else {
@@ -204,8 +277,20 @@ func (folder *Folder) MoveOrCopyFileTo(files []uint, dstFolder *Folder, isCopy b
 			return 0, err
 		}
 
+		MarkFolderSizeDirty(folder.ID)
+
+		// 按移动后的名称记录变更
+		var moved []File
+		if err := DB.Select("id, name").Where("id in (?) and user_id = ? and folder_id = ?", files, folder.OwnerID, dstFolder.ID).
+			Find(&moved).Error; err != nil {
+			util.Log().Warning("Failed to list moved files for change journal: %s", err)
+		}
+		for i := range moved {
+			RecordFileChange(folder.OwnerID, FileChangeMove, false, moved[i].ID, dstFolder.ID, moved[i].Name)
+		}
 	}
 
+	MarkFolderSizeDirty(dstFolder.ID)
 	return copiedSize, nil
 
 }
@@ -227,6 +312,13 @@ func (folder *Folder) CopyFolderTo(folderID uint, dstFolder *Folder) (size uint6
 
 	// 复制子目录
 	var newIDCache = make(map[uint]uint)
+	defer func() {
+		// 新目录沿用了原目录的缓存大小，仍需按实际复制结果重新统计
+		for _, id := range newIDCache {
+			MarkFolderSizeDirty(id)
+		}
+		MarkFolderSizeDirty(dstFolder.ID)
+	}()
 	for _, folder := range subFolders {
 		// 新的父目录指向
 		var newID uint
@@ -254,6 +346,7 @@ func (folder *Folder) CopyFolderTo(folderID uint, dstFolder *Folder) (size uint6
 		}
 		// 记录新的ID以便其子目录使用
 		newIDCache[oldID] = folder.ID
+		RecordFileChange(folder.OwnerID, FileChangeCreate, true, folder.ID, newID, folder.Name)
 
 	}
 
@@ -282,6 +375,7 @@ func (folder *Folder) CopyFolderTo(folderID uint, dstFolder *Folder) (size uint6
 		}
 
 		size += oldFile.Size
+		RecordFileChange(oldFile.UserID, FileChangeCreate, false, oldFile.ID, oldFile.FolderID, oldFile.Name)
 	}
 
 	return size, nil
@@ -313,14 +407,33 @@ func (folder *Folder) MoveFolderTo(dirs []uint, dstFolder *Folder) error {
 		folder.OwnerID,
 		folder.ID,
 	).Update(updates).Error
+	if err != nil {
+		return err
+	}
 
-	return err
+	MarkFolderSizeDirty(folder.ID, dstFolder.ID)
+
+	// 按移动后的名称记录变更
+	var moved []Folder
+	if err := DB.Select("id, name").Where("id in (?) and owner_id = ? and parent_id = ?", dirs, folder.OwnerID, dstFolder.ID).
+		Find(&moved).Error; err != nil {
+		util.Log().Warning("Failed to list moved folders for change journal: %s", err)
+	}
+	for i := range moved {
+		RecordFileChange(folder.OwnerID, FileChangeMove, true, moved[i].ID, dstFolder.ID, moved[i].Name)
+	}
+	return nil
 
 }
 
 // Rename 重命名目录
 func (folder *Folder) Rename(new string) error {
-	return DB.Model(&folder).UpdateColumn("name", new).Error
+	if err := DB.Model(&folder).UpdateColumn("name", new).Error; err != nil {
+		return err
+	}
+
+	RecordFileChange(folder.OwnerID, FileChangeRename, true, folder.ID, folderParent(folder), new)
+	return nil
 }
 
 /*
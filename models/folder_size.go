@@ -0,0 +1,188 @@
+package model
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// folderSize 等待重新统计大小的目录，及统计过程使用的互斥锁
+var folderSize = struct {
+	sync.Mutex
+	dirty map[uint]struct{}
+	flush sync.Mutex
+}{dirty: make(map[uint]struct{})}
+
+// MarkFolderSizeDirty 标记目录的缓存大小需要重新统计，
+// 统计结果会在下一次 FlushFolderSize 时逐级向上传递
+func MarkFolderSizeDirty(ids ...uint) {
+	folderSize.Lock()
+	defer folderSize.Unlock()
+	for _, id := range ids {
+		if id > 0 {
+			folderSize.dirty[id] = struct{}{}
+		}
+	}
+}
+
+// popFolderSizeDirty 取出并清空所有待统计的目录
+func popFolderSizeDirty() []uint {
+	folderSize.Lock()
+	defer folderSize.Unlock()
+	ids := make([]uint, 0, len(folderSize.dirty))
+	for id := range folderSize.dirty {
+		ids = append(ids, id)
+	}
+	folderSize.dirty = make(map[uint]struct{})
+	return ids
+}
+
+// FlushFolderSize 重新统计被标记目录的缓存大小，大小发生变化时继续统计其父目录。
+// 每个目录只汇总直接子文件与子目录的缓存大小，无需遍历整棵子树
+func FlushFolderSize() error {
+	folderSize.flush.Lock()
+	defer folderSize.flush.Unlock()
+
+	ids := popFolderSizeDirty()
+	for len(ids) > 0 {
+		var folders []Folder
+		if err := DB.Where("id in (?)", ids).Find(&folders).Error; err != nil {
+			MarkFolderSizeDirty(ids...)
+			return err
+		}
+
+		parents := make(map[uint]struct{})
+		for i, folder := range folders {
+			size, err := folder.directSize()
+			if err != nil {
+				// 未完成统计的目录留待下次处理
+				for _, rest := range folders[i:] {
+					MarkFolderSizeDirty(rest.ID)
+				}
+				for parent := range parents {
+					MarkFolderSizeDirty(parent)
+				}
+				return err
+			}
+
+			if size == folder.Size {
+				continue
+			}
+
+			if err := DB.Model(&folder).UpdateColumn("size", size).Error; err != nil {
+				MarkFolderSizeDirty(folder.ID)
+				continue
+			}
+
+			if folder.ParentID != nil {
+				parents[*folder.ParentID] = struct{}{}
+			}
+		}
+
+		ids = make([]uint, 0, len(parents))
+		for parent := range parents {
+			ids = append(ids, parent)
+		}
+	}
+
+	return nil
+}
+
+// directSize 返回目录下直接子文件大小与子目录缓存大小之和
+func (folder *Folder) directSize() (uint64, error) {
+	var fileSize, childSize uint64
+	row := DB.Model(&File{}).Where("folder_id = ?", folder.ID).Select("coalesce(sum(size), 0)").Row()
+	if row == nil {
+		return 0, errors.New("failed to sum file size")
+	}
+	if err := row.Scan(&fileSize); err != nil {
+		return 0, err
+	}
+
+	row = DB.Model(&Folder{}).Where("parent_id = ?", folder.ID).Select("coalesce(sum(size), 0)").Row()
+	if row == nil {
+		return 0, errors.New("failed to sum folder size")
+	}
+	if err := row.Scan(&childSize); err != nil {
+		return 0, err
+	}
+
+	return fileSize + childSize, nil
+}
+
+// RepairFolderSizes 根据文件记录重新计算所有目录的缓存大小，修正增量更新产生的偏差，
+// 返回被修正的目录数量
+func RepairFolderSizes() (int, error) {
+	folderSize.flush.Lock()
+	defer folderSize.flush.Unlock()
+
+	// 全量统计结果已包含所有待处理的变更
+	popFolderSizeDirty()
+
+	var folders []Folder
+	if err := DB.Select("id, parent_id, size").Find(&folders).Error; err != nil {
+		return 0, err
+	}
+
+	// 统计各目录下直接子文件的大小
+	fileSize := make(map[uint]uint64)
+	rows, err := DB.Model(&File{}).Select("folder_id, coalesce(sum(size), 0)").Group("folder_id").Rows()
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var (
+			id   uint
+			size uint64
+		)
+		if err := rows.Scan(&id, &size); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		fileSize[id] = size
+	}
+	rows.Close()
+
+	children := make(map[uint][]uint)
+	for _, folder := range folders {
+		if folder.ParentID != nil {
+			children[*folder.ParentID] = append(children[*folder.ParentID], folder.ID)
+		}
+	}
+
+	// 自底向上汇总子树大小
+	total := make(map[uint]uint64, len(folders))
+	var sum func(id uint, depth int) uint64
+	sum = func(id uint, depth int) uint64 {
+		if size, ok := total[id]; ok {
+			return size
+		}
+
+		size := fileSize[id]
+		// 防止异常数据中的环形引用
+		if depth < len(folders) {
+			for _, child := range children[id] {
+				size += sum(child, depth+1)
+			}
+		}
+		total[id] = size
+		return size
+	}
+
+	repaired := 0
+	for _, folder := range folders {
+		size := sum(folder.ID, 0)
+		if size == folder.Size {
+			continue
+		}
+
+		if err := DB.Model(&folder).UpdateColumn("size", size).Error; err != nil {
+			util.Log().Warning("Failed to repair size of folder %d: %s", folder.ID, err)
+			continue
+		}
+		repaired++
+	}
+
+	return repaired, nil
+}
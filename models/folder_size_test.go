@@ -0,0 +1,92 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkFolderSizeDirty(t *testing.T) {
+	asserts := assert.New(t)
+	popFolderSizeDirty()
+
+	MarkFolderSizeDirty(1, 0, 2, 1)
+	asserts.ElementsMatch([]uint{1, 2}, popFolderSizeDirty())
+	asserts.Empty(popFolderSizeDirty())
+}
+
+func TestFlushFolderSize(t *testing.T) {
+	asserts := assert.New(t)
+	popFolderSizeDirty()
+
+	// 无待统计目录
+	{
+		asserts.NoError(FlushFolderSize())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 大小变化后向上传递
+	{
+		MarkFolderSizeDirty(3)
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "size"}).AddRow(3, 2, 0))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"size"}).AddRow(10))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"size"}).AddRow(5))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)folders(.+)size").WithArgs(15, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "size"}).AddRow(2, 15))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"size"}).AddRow(0))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"size"}).AddRow(15))
+		asserts.NoError(FlushFolderSize())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Empty(popFolderSizeDirty())
+	}
+
+	// 统计失败，留待下次处理
+	{
+		MarkFolderSizeDirty(3)
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "size"}).AddRow(3, 2, 0))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(3).WillReturnError(errors.New("error"))
+		asserts.Error(FlushFolderSize())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal([]uint{3}, popFolderSizeDirty())
+	}
+}
+
+func TestRepairFolderSizes(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 目录结构：1 -> 2 -> 3，1 -> 4
+	mock.ExpectQuery("SELECT(.+)folders(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "size"}).
+			AddRow(1, nil, 30).
+			AddRow(2, 1, 10).
+			AddRow(3, 2, 10).
+			AddRow(4, 1, 0))
+	mock.ExpectQuery("SELECT(.+)files(.+)GROUP BY(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"folder_id", "size"}).
+			AddRow(1, 5).
+			AddRow(2, 5).
+			AddRow(3, 10).
+			AddRow(4, 10))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)folders(.+)size").WithArgs(15, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)folders(.+)size").WithArgs(10, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repaired, err := RepairFolderSizes()
+	asserts.NoError(err)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(2, repaired)
+}
@@ -0,0 +1,37 @@
+package model
+
+// FolderTemplate 目录模板，用于一次性创建预设的目录结构
+type FolderTemplate struct {
+	Name string `json:"name" binding:"required,max=64"`
+	// Structure 每行一个相对路径，{a,b} 展开为多个目录，{name} 替换为创建时给定的变量
+	Structure string `json:"structure" binding:"required,max=4096"`
+}
+
+// FolderTemplates 返回用户可用的目录模板，用户模板与用户组模板同名时以用户模板为准
+func (user *User) FolderTemplates() []FolderTemplate {
+	res := make([]FolderTemplate, 0, len(user.OptionsSerialized.FolderTemplates)+len(user.Group.OptionsSerialized.FolderTemplates))
+	names := make(map[string]bool)
+	for _, template := range user.OptionsSerialized.FolderTemplates {
+		names[template.Name] = true
+		res = append(res, template)
+	}
+
+	for _, template := range user.Group.OptionsSerialized.FolderTemplates {
+		if !names[template.Name] {
+			res = append(res, template)
+		}
+	}
+
+	return res
+}
+
+// FolderTemplate 根据名称查找用户可用的目录模板
+func (user *User) FolderTemplate(name string) (*FolderTemplate, bool) {
+	for _, template := range user.FolderTemplates() {
+		if template.Name == name {
+			return &template, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_FolderTemplates(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{}
+	user.OptionsSerialized.FolderTemplates = []FolderTemplate{
+		{Name: "project", Structure: "src"},
+	}
+	user.Group.OptionsSerialized.FolderTemplates = []FolderTemplate{
+		{Name: "project", Structure: "docs"},
+		{Name: "course", Structure: "notes"},
+	}
+
+	templates := user.FolderTemplates()
+	asserts.Len(templates, 2)
+	asserts.Equal("src", templates[0].Structure)
+	asserts.Equal("course", templates[1].Name)
+
+	template, ok := user.FolderTemplate("course")
+	asserts.True(ok)
+	asserts.Equal("notes", template.Structure)
+
+	_, ok = user.FolderTemplate("not_exist")
+	asserts.False(ok)
+}
@@ -79,6 +79,39 @@ func TestFolder_GetChild(t *testing.T) {
 	}
 }
 
+func TestFolder_GetChildFolderPage(t *testing.T) {
+	asserts := assert.New(t)
+	folder := &Folder{
+		Model: gorm.Model{
+			ID: 1,
+		},
+		Position: "/123",
+		Name:     "456",
+	}
+
+	mock.ExpectQuery("SELECT(.+)parent_id(.+)ORDER BY name asc, id asc LIMIT 2 OFFSET 1").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "id"}).AddRow("1", 1).AddRow("2", 2))
+	folders, err := folder.GetChildFolderPage("name asc, id asc", 1, 2)
+	asserts.NoError(err)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Len(folders, 2)
+	asserts.Equal("/123/456", folders[0].Position)
+}
+
+func TestFolder_CountChildren(t *testing.T) {
+	asserts := assert.New(t)
+	folder := &Folder{}
+	folder.ID = 1
+
+	mock.ExpectQuery("SELECT count(.+)folders(.+)").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("SELECT count(.+)files(.+)upload_session_id is null(.+)").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	folders, files, err := folder.CountChildren()
+	asserts.NoError(err)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(2, folders)
+	asserts.Equal(3, files)
+}
+
 func TestFolder_GetChildFolder(t *testing.T) {
 	asserts := assert.New(t)
 	folder := &Folder{
@@ -303,6 +336,9 @@ func TestFolder_MoveOrCopyFileTo(t *testing.T) {
 			WithArgs(10, sqlmock.AnyArg(), 1, 2, 1, 1).
 			WillReturnResult(sqlmock.NewResult(1, 2))
 		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(1, 2, 1, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a.txt").AddRow(2, "b.txt"))
 		storage, err := folder.MoveOrCopyFileTo(
 			[]uint{1, 2},
 			&dstFolder,
@@ -517,9 +553,16 @@ func TestFolder_MoveOrCopyFolderTo_Move(t *testing.T) {
 			WithArgs(10, sqlmock.AnyArg(), 1, 2, 1, 9).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(1, 2, 1, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "a").AddRow(2, "b"))
+		fileChanges.pending = nil
 		err := parFolder.MoveFolderTo([]uint{1, 2}, &dstFolder)
 		asserts.NoError(mock.ExpectationsWereMet())
 		asserts.NoError(err)
+		asserts.Len(fileChanges.pending, 2)
+		asserts.Equal("b", fileChanges.pending[1].Name)
+		fileChanges.pending = nil
 	}
 
 	// 移动自己到自己内部，失败
@@ -620,3 +663,43 @@ func TestFolder_Rename(t *testing.T) {
 		asserts.Error(err)
 	}
 }
+
+func TestFolder_TotalSize(t *testing.T) {
+	asserts := assert.New(t)
+	folder := &Folder{OwnerID: 1}
+	folder.ID = 1
+
+	mock.ExpectQuery("SELECT(.+)folders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT(.+)folders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery("SELECT(.+)folders").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT(.+)files").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"size"}).AddRow(1024))
+	size, err := folder.TotalSize()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.EqualValues(1024, size)
+}
+
+func TestFolder_SetQuota(t *testing.T) {
+	asserts := assert.New(t)
+	folder := &Folder{}
+	folder.ID = 1
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)folders(.+)quota").WithArgs(100, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(folder.SetQuota(100))
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.EqualValues(100, folder.Quota)
+}
+
+func TestHasFolderQuota(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)folders(.+)quota").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	asserts.True(HasFolderQuota(1))
+	mock.ExpectQuery("SELECT(.+)folders(.+)quota").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	asserts.False(HasFolderQuota(1))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
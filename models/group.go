@@ -15,6 +15,8 @@ type Group struct {
 	WebDAVEnabled bool
 	SpeedLimit    int
 	Options       string `json:"-" gorm:"size:4294967295"`
+	// 所属租户，0 为默认租户
+	TenantID uint `gorm:"index:group_tenant"`
 
 	// 数据库忽略字段
 	PolicyList        []uint      `gorm:"-"`
@@ -36,6 +38,27 @@ type GroupOption struct {
 	Aria2BatchSize   int                    `json:"aria2_batch,omitempty"`
 	AdvanceDelete    bool                   `json:"advance_delete,omitempty"`
 	WebDAVProxy      bool                   `json:"webdav_proxy,omitempty"`
+	IPAllowList      []string               `json:"ip_allow_list,omitempty"`      // 允许访问的 IP/CIDR
+	IPDenyList       []string               `json:"ip_deny_list,omitempty"`       // 禁止访问的 IP/CIDR
+	CountryDenyList  []string               `json:"country_deny_list,omitempty"`  // 禁止访问的国家/地区代码
+	ShareArchiveSize uint64                 `json:"share_zip_size,omitempty"`     // 分享目录整体打包下载的大小上限
+	HLS              bool                   `json:"hls,omitempty"`                // 视频转码播放
+	UploadSpeedLimit int                    `json:"upload_speed_limit,omitempty"` // 最大上传速度，单位为 字节/秒，0 表示不限制
+	TrafficLimit     uint64                 `json:"traffic_limit,omitempty"`      // 每月下载流量上限，0 表示不限制
+	Aria2Nodes       []uint                 `json:"aria2_nodes,omitempty"`        // 可用的离线下载节点，为空时可使用所有节点
+	Aria2SeedRatio   float64                `json:"aria2_seed_ratio,omitempty"`   // 离线下载完成后做种的分享率上限，也是用户可指定的最大值
+	Aria2SeedTime    int                    `json:"aria2_seed_time,omitempty"`    // 离线下载完成后做种的时长上限（分钟），也是用户可指定的最大值
+	Aria2Windows     []string               `json:"aria2_windows,omitempty"`      // 允许离线下载的时间段，如 "23:00-07:00"，为空时不限制
+	Aria2SpeedLimit  int                    `json:"aria2_speed_limit,omitempty"`  // 用户组所有离线下载任务的总下载速度上限，单位为 字节/秒，0 表示不限制
+	PreviewMaxSize   uint64                 `json:"preview_max_size,omitempty"`   // 在线预览/编辑的文件大小上限，0 表示不限制
+	PreviewDisabled  []string               `json:"preview_disabled,omitempty"`   // 禁用的在线预览类型，可选 video、office、text
+	ArchiveSize      uint64                 `json:"archive_size,omitempty"`       // 打包下载所选内容的大小上限，0 表示不限制
+	Mount            bool                   `json:"mount,omitempty"`              // 挂载个人的外部存储
+	FolderTemplates  []FolderTemplate       `json:"folder_templates,omitempty"`   // 用户组成员可用的目录模板
+	QuotaGrace       int                    `json:"quota_grace,omitempty"`        // 允许超出容量配额的百分比，0 表示不允许超额
+	QuotaGraceDays   int                    `json:"quota_grace_days,omitempty"`   // 超额宽限天数，0 表示不限制
+	QuotaOverAction  string                 `json:"quota_over_action,omitempty"`  // 宽限期结束后的限制，为空时仅禁止上传
+	Gallery          bool                   `json:"gallery,omitempty"`            // 将目录发布为站点展示
 }
 
 // GetGroupByID 用ID获取用户组
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 访客上传审核状态
+const (
+	// GuestUploadPending 等待分享者审核
+	GuestUploadPending = iota
+	// GuestUploadApproved 已通过，文件已放入目标目录
+	GuestUploadApproved
+	// GuestUploadRejected 已拒绝，文件已删除
+	GuestUploadRejected
+)
+
+// 文件收集上传文件记录的元信息
+const (
+	UploaderNameMetadataKey = "uploader_name"
+	UploadShareMetadataKey  = "upload_share"
+	// GuestPendingMetadataKey 需审核的访客上传，上传完成后暂存等待审核
+	GuestPendingMetadataKey = "guest_pending"
+)
+
+var (
+	// ErrGuestUploadConflict 目标目录下已存在同名文件
+	ErrGuestUploadConflict = errors.New("file with the same name already exists in target folder")
+	// ErrGuestUploadCapacity 分享者容量不足
+	ErrGuestUploadCapacity = errors.New("insufficient capacity")
+)
+
+// GuestUpload 需审核的访客上传记录。等待审核的文件不属于任何用户和目录，
+// 不出现在列表中也不计入分享者容量，审核通过后放入目标目录
+type GuestUpload struct {
+	gorm.Model
+	ShareID  uint `gorm:"index:guest_upload_share"`
+	OwnerID  uint // 分享者
+	FolderID uint // 目标目录
+	FileID   uint `gorm:"unique_index:guest_upload_file"`
+	FileName string
+	Size     uint64
+	Uploader string
+	Status   int
+}
+
+// IsGuestPending 返回文件是否为需审核的访客上传
+func (file *File) IsGuestPending() bool {
+	return IsTrueVal(file.MetadataSerialized[GuestPendingMetadataKey])
+}
+
+// holdGuestUpload 暂存上传完成的访客文件，将其移出目标目录并归还分享者容量
+func (file *File) holdGuestUpload() error {
+	shareID, _ := strconv.ParseUint(file.MetadataSerialized[UploadShareMetadataKey], 10, 64)
+	record := &GuestUpload{
+		ShareID:  uint(shareID),
+		OwnerID:  file.UserID,
+		FolderID: file.FolderID,
+		FileID:   file.ID,
+		FileName: file.Name,
+		Size:     file.Size,
+		Uploader: file.MetadataSerialized[UploaderNameMetadataKey],
+		Status:   GuestUploadPending,
+	}
+
+	tx := DB.Begin()
+	if err := tx.Create(record).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(file).UpdateColumns(map[string]interface{}{
+		"user_id":   0,
+		"folder_id": 0,
+	}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	owner := &User{}
+	owner.ID = record.OwnerID
+	if err := owner.ChangeStorage(tx, "-", file.Size); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	file.UserID = 0
+	file.FolderID = 0
+	MarkFolderSizeDirty(record.FolderID)
+	return nil
+}
+
+// Approve 审核通过，将文件放入目标目录并计入分享者容量
+func (record *GuestUpload) Approve() error {
+	owner, err := GetUserByID(record.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	if owner.GetRemainingCapacity() < record.Size {
+		return ErrGuestUploadCapacity
+	}
+
+	folders, err := GetFoldersByIDs([]uint{record.FolderID}, record.OwnerID)
+	if err != nil {
+		return err
+	}
+	if len(folders) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	var count int
+	if err := DB.Model(&File{}).Where("folder_id = ? and name = ?", record.FolderID, record.FileName).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrGuestUploadConflict
+	}
+
+	if err := record.claim(GuestUploadApproved, record.FolderID); err != nil {
+		return err
+	}
+
+	files, err := GetFilesByIDs([]uint{record.FileID}, record.OwnerID)
+	if err == nil && len(files) > 0 {
+		files[0].UpdateMetadata(map[string]string{GuestPendingMetadataKey: ""})
+	}
+
+	MarkFolderSizeDirty(record.FolderID)
+	RecordFileChange(record.OwnerID, FileChangeCreate, false, record.FileID, record.FolderID, record.FileName)
+	return nil
+}
+
+// Reject 拒绝访客上传，文件交还分享者以便随后删除
+func (record *GuestUpload) Reject() error {
+	return record.claim(GuestUploadRejected, 0)
+}
+
+// Reopen 撤销拒绝，文件重新暂存等待审核并归还分享者容量，用于拒绝后删除文件失败时
+func (record *GuestUpload) Reopen() error {
+	tx := DB.Begin()
+	result := tx.Model(&File{}).Where("id = ? and user_id = ?", record.FileID, record.OwnerID).UpdateColumns(map[string]interface{}{
+		"user_id":   0,
+		"folder_id": 0,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	// 文件已被删除，容量已随之扣除
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return nil
+	}
+
+	owner := &User{}
+	owner.ID = record.OwnerID
+	if err := owner.ChangeStorage(tx, "-", record.Size); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(record).Update("status", GuestUploadPending).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// claim 将暂存的文件交还分享者并更新审核状态
+func (record *GuestUpload) claim(status int, folderID uint) error {
+	tx := DB.Begin()
+	result := tx.Model(&File{}).Where("id = ? and user_id = ?", record.FileID, 0).UpdateColumns(map[string]interface{}{
+		"user_id":   record.OwnerID,
+		"folder_id": folderID,
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return gorm.ErrRecordNotFound
+	}
+
+	owner := &User{}
+	owner.ID = record.OwnerID
+	if err := owner.ChangeStorage(tx, "+", record.Size); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Model(record).Update("status", status).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// GetGuestUploadsByShare 列出分享中给定状态的访客上传
+func GetGuestUploadsByShare(shareID uint, status int) ([]GuestUpload, error) {
+	var records []GuestUpload
+	result := DB.Where("share_id = ? and status = ?", shareID, status).Order("id desc").Find(&records)
+	return records, result.Error
+}
+
+// GetGuestUploadByID 根据 ID 获取分享中等待审核的访客上传
+func GetGuestUploadByID(id, shareID uint) (*GuestUpload, error) {
+	var record GuestUpload
+	result := DB.Where("id = ? and share_id = ? and status = ?", id, shareID, GuestUploadPending).First(&record)
+	return &record, result.Error
+}
+
+// GetStaleGuestUploads 列出不再可能被审核的访客上传：所属分享已删除或已过期，或等待时间早于 before
+func GetStaleGuestUploads(now, before time.Time, limit int) ([]GuestUpload, error) {
+	var records []GuestUpload
+	available := DB.Model(&Share{}).Select("id").Where("expires is null or expires > ?", now).QueryExpr()
+	result := DB.Where("status = ?", GuestUploadPending).
+		Where("share_id not in (?) or created_at < ?", available, before).
+		Order("id asc").Limit(limit).Find(&records)
+	return records, result.Error
+}
@@ -0,0 +1,170 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile_PopChunkToFile_GuestPending(t *testing.T) {
+	asserts := assert.New(t)
+	timeNow := time.Now()
+	file := &File{
+		Name:     "a.txt",
+		UserID:   1,
+		FolderID: 2,
+		Size:     10,
+		MetadataSerialized: map[string]string{
+			GuestPendingMetadataKey: "1",
+			UploadShareMetadataKey:  "3",
+			UploaderNameMetadataKey: "guest",
+		},
+	}
+	file.ID = 4
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)guest_uploads(.+)").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), 3, 1, 2, 4, "a.txt", 10, "guest", GuestUploadPending).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(0, 0, 4).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)users(.+)storage(.+)").WithArgs(10, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(file.PopChunkToFile(&timeNow, ""))
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.EqualValues(0, file.UserID)
+		asserts.EqualValues(0, file.FolderID)
+	}
+
+	// 创建记录失败
+	{
+		file.UserID = 1
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)guest_uploads(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.Error(file.PopChunkToFile(&timeNow, ""))
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.EqualValues(1, file.UserID)
+	}
+}
+
+func TestGuestUpload_Approve(t *testing.T) {
+	asserts := assert.New(t)
+	record := &GuestUpload{OwnerID: 1, FolderID: 2, FileID: 3, FileName: "a.txt", Size: 10}
+	record.ID = 4
+
+	// 容量不足
+	{
+		mock.ExpectQuery("SELECT(.+)users(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "storage", "group_id"}).AddRow(1, 95, 1))
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "max_storage"}).AddRow(1, 100))
+		err := record.Approve()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrGuestUploadCapacity, err)
+	}
+
+	// 同名文件已存在
+	{
+		mock.ExpectQuery("SELECT(.+)users(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "storage", "group_id"}).AddRow(1, 0, 1))
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "max_storage"}).AddRow(1, 100))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery("SELECT count(.+)files(.+)").WithArgs(2, "a.txt").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		err := record.Approve()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrGuestUploadConflict, err)
+	}
+
+	// 成功
+	{
+		mock.ExpectQuery("SELECT(.+)users(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "storage", "group_id"}).AddRow(1, 0, 1))
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "max_storage"}).AddRow(1, 100))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectQuery("SELECT count(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(2, 1, 3, 0).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)users(.+)storage(.+)").WithArgs(10, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)guest_uploads(.+)").WithArgs(GuestUploadApproved, sqlmock.AnyArg(), 4).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(3, `{"guest_pending":"1"}`))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(record.Approve())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestGuestUpload_Reject(t *testing.T) {
+	asserts := assert.New(t)
+	record := &GuestUpload{OwnerID: 1, FolderID: 2, FileID: 3, Size: 10}
+	record.ID = 4
+
+	// 文件已不存在
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		asserts.Error(record.Reject())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(0, 1, 3, 0).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)users(.+)storage(.+)").WithArgs(10, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)guest_uploads(.+)").WithArgs(GuestUploadRejected, sqlmock.AnyArg(), 4).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(record.Reject())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestGuestUpload_Reopen(t *testing.T) {
+	asserts := assert.New(t)
+	record := &GuestUpload{OwnerID: 1, FolderID: 2, FileID: 3, Size: 10}
+	record.ID = 4
+
+	// 文件已被删除
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(0, 0, 3, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+		asserts.NoError(record.Reopen())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 重新暂存并归还容量
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(0, 0, 3, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)users(.+)storage(.+)").WithArgs(10, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("UPDATE(.+)guest_uploads(.+)").WithArgs(GuestUploadPending, sqlmock.AnyArg(), 4).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(record.Reopen())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestGetStaleGuestUploads(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+	before := now.Add(-time.Hour)
+
+	mock.ExpectQuery("SELECT(.+)guest_uploads(.+)share_id not in \\(SELECT id FROM `shares`(.+)created_at <").
+		WithArgs(GuestUploadPending, now, before).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_id"}).AddRow(1, 3))
+	records, err := GetStaleGuestUploads(now, before, 10)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(records, 1)
+}
@@ -101,6 +101,11 @@ func Init() {
 
 	DB = db
 
+	// 连接只读副本
+	if gin.Mode() != gin.TestMode {
+		initReplicas(confDBType)
+	}
+
 	//执行迁移
 	migration()
 }
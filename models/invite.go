@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/jinzhu/gorm"
+)
+
+// InviteCode 注册邀请码
+type InviteCode struct {
+	gorm.Model
+	Code      string `gorm:"size:32;unique_index"`
+	CreatorID uint   `gorm:"index:creator_id"`
+	MaxUses   int    // 最大使用次数，0 为不限制
+	Used      int
+	Expires   *time.Time
+}
+
+// NewInviteCode 生成新的邀请码
+func NewInviteCode(creator uint, maxUses int, expires *time.Time) *InviteCode {
+	return &InviteCode{
+		Code:      util.RandStringRunes(16),
+		CreatorID: creator,
+		MaxUses:   maxUses,
+		Expires:   expires,
+	}
+}
+
+// Create 创建邀请码
+func (code *InviteCode) Create() error {
+	return DB.Create(code).Error
+}
+
+// GetInviteCodeByCode 查找可用的邀请码
+func GetInviteCodeByCode(code string) (*InviteCode, error) {
+	res := &InviteCode{}
+	result := DB.Where("code = ?", code).First(res)
+	return res, result.Error
+}
+
+// ListInviteCodes 列出用户创建的邀请码
+func ListInviteCodes(creator uint) []InviteCode {
+	var codes []InviteCode
+	DB.Where("creator_id = ?", creator).Order("id desc").Find(&codes)
+	return codes
+}
+
+// IsAvailable 返回邀请码是否仍可使用
+func (code *InviteCode) IsAvailable() bool {
+	if code.MaxUses > 0 && code.Used >= code.MaxUses {
+		return false
+	}
+	if code.Expires != nil && time.Now().After(*code.Expires) {
+		return false
+	}
+	return true
+}
+
+// Use 消耗一次邀请码使用次数，并发使用时次数耗尽返回 false
+func (code *InviteCode) Use() bool {
+	result := DB.Model(&InviteCode{}).
+		Where("id = ? and (max_uses = 0 or used < max_uses)", code.ID).
+		UpdateColumn("used", gorm.Expr("used + ?", 1))
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+
+	code.Used++
+	return true
+}
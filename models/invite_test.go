@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInviteCode(t *testing.T) {
+	asserts := assert.New(t)
+	code := NewInviteCode(1, 5, nil)
+	asserts.Len(code.Code, 16)
+	asserts.EqualValues(1, code.CreatorID)
+	asserts.Equal(5, code.MaxUses)
+}
+
+func TestInviteCode_IsAvailable(t *testing.T) {
+	asserts := assert.New(t)
+	past := time.Now().Add(-time.Hour)
+
+	asserts.True((&InviteCode{}).IsAvailable())
+	asserts.True((&InviteCode{MaxUses: 2, Used: 1}).IsAvailable())
+	asserts.False((&InviteCode{MaxUses: 2, Used: 2}).IsAvailable())
+	asserts.False((&InviteCode{Expires: &past}).IsAvailable())
+}
+
+func TestInviteCode_Use(t *testing.T) {
+	asserts := assert.New(t)
+	code := &InviteCode{MaxUses: 1}
+	code.ID = 1
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		asserts.True(code.Use())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(1, code.Used)
+	}
+
+	// 次数已用尽
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		asserts.False(code.Use())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 数据库错误
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.False(code.Use())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestGetInviteCodeByCode(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)").WithArgs("abc").WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(1, "abc"))
+	code, err := GetInviteCodeByCode("abc")
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.EqualValues(1, code.ID)
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 生命周期规则设定的文件元信息
+const (
+	// ArchivedMetadataKey 文件已转为归档存储
+	ArchivedMetadataKey = "archived"
+	// ExpiryWarnedMetadataKey 已提醒用户文件即将到期删除
+	ExpiryWarnedMetadataKey = "expiry_warned"
+	// RehydratingMetadataKey 已发起解冻，等待转回标准存储
+	RehydratingMetadataKey = "rehydrating"
+	// RehydratedMetadataKey 归档文件已转回标准存储，生命周期规则不再自动归档
+	RehydratedMetadataKey = "rehydrated"
+)
+
+// IsArchived 返回文件是否已转为归档存储
+func (file *File) IsArchived() bool {
+	return IsTrueVal(file.MetadataSerialized[ArchivedMetadataKey])
+}
+
+// IsRehydrating 返回归档文件是否正在解冻
+func (file *File) IsRehydrating() bool {
+	return IsTrueVal(file.MetadataSerialized[RehydratingMetadataKey])
+}
+
+// MarkRehydrated 文件已转回标准存储，清除归档及解冻标记
+func (file *File) MarkRehydrated() error {
+	delete(file.MetadataSerialized, ArchivedMetadataKey)
+	delete(file.MetadataSerialized, RehydratingMetadataKey)
+	file.MetadataSerialized[RehydratedMetadataKey] = "1"
+	metaValue, err := json.Marshal(&file.MetadataSerialized)
+	if err != nil {
+		return err
+	}
+
+	file.Metadata = string(metaValue)
+	return DB.Model(file).Set("gorm:association_autoupdate", false).UpdateColumns(File{Metadata: file.Metadata}).Error
+}
+
+// GetRehydratingFiles 按 ID 顺序分批列出正在解冻的文件，after 为上一批最后一个文件的 ID
+func GetRehydratingFiles(after uint, limit int) ([]File, error) {
+	var files []File
+	result := DB.Where("id > ? and metadata like ?", after, "%\""+RehydratingMetadataKey+"\":\"1\"%").
+		Order("id asc").Limit(limit).Find(&files)
+	return files, result.Error
+}
+
+// HasLifecycle 返回存储策略是否配置了生命周期规则
+func (policy *Policy) HasLifecycle() bool {
+	return policy.OptionsSerialized.ArchiveAfterDays > 0 ||
+		(policy.OptionsSerialized.ExpiryDays > 0 && len(policy.OptionsSerialized.ExpiryFolders) > 0)
+}
+
+// GetLifecyclePolicies 列出配置了生命周期规则的存储策略
+func GetLifecyclePolicies() ([]Policy, error) {
+	var policies []Policy
+	if err := DB.Find(&policies).Error; err != nil {
+		return nil, err
+	}
+
+	res := make([]Policy, 0, len(policies))
+	for i := range policies {
+		if policies[i].HasLifecycle() {
+			res = append(res, policies[i])
+		}
+	}
+	return res, nil
+}
+
+// GetPolicyFilesCreatedBefore 按 ID 顺序分批列出存储策略下 before 之前上传完成的文件，
+// after 为上一批最后一个文件的 ID
+func GetPolicyFilesCreatedBefore(policyID uint, before time.Time, after uint, limit int) ([]File, error) {
+	var files []File
+	result := DB.Where("policy_id = ? and created_at < ? and id > ? and upload_session_id is null", policyID, before, after).
+		Order("id asc").Limit(limit).Find(&files)
+	return files, result.Error
+}
+
+// GetFilesBySourceNames 列出存储策略下使用给定源文件的所有文件，包括软链接
+func GetFilesBySourceNames(policyID uint, sourceNames []string) ([]File, error) {
+	var files []File
+	result := DB.Where("policy_id = ? and source_name in (?)", policyID, sourceNames).Find(&files)
+	return files, result.Error
+}
+
+// GetFolderFilesCreatedBefore 列出目录下存储策略中 before 之前上传完成的文件
+func GetFolderFilesCreatedBefore(folderIDs []uint, policyID uint, before time.Time) ([]File, error) {
+	var files []File
+	result := DB.Where("folder_id in (?) and policy_id = ? and created_at < ? and upload_session_id is null",
+		folderIDs, policyID, before).Find(&files)
+	return files, result.Error
+}
+
+// GetPolicyUserIDs 列出在存储策略下存有文件的用户
+func GetPolicyUserIDs(policyID uint) ([]uint, error) {
+	var uids []uint
+	result := DB.Model(&File{}).Where("policy_id = ? and user_id > 0", policyID).Pluck("distinct user_id", &uids)
+	return uids, result.Error
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_HasLifecycle(t *testing.T) {
+	asserts := assert.New(t)
+	policy := &Policy{}
+	asserts.False(policy.HasLifecycle())
+
+	policy.OptionsSerialized.ArchiveAfterDays = 30
+	asserts.True(policy.HasLifecycle())
+
+	// 未指定过期目录
+	policy.OptionsSerialized.ArchiveAfterDays = 0
+	policy.OptionsSerialized.ExpiryDays = 7
+	asserts.False(policy.HasLifecycle())
+
+	policy.OptionsSerialized.ExpiryFolders = []string{"/temp"}
+	asserts.True(policy.HasLifecycle())
+}
+
+func TestGetLifecyclePolicies(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)policies(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "options"}).
+		AddRow(1, "{}").
+		AddRow(2, `{"archive_after_days":30}`).
+		AddRow(3, `{"expiry_days":7,"expiry_folders":["/temp"]}`))
+	policies, err := GetLifecyclePolicies()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(policies, 2)
+	asserts.EqualValues(2, policies[0].ID)
+	asserts.EqualValues(3, policies[1].ID)
+}
+
+func TestGetPolicyFilesCreatedBefore(t *testing.T) {
+	asserts := assert.New(t)
+	before := time.Now()
+	mock.ExpectQuery("SELECT(.+)files(.+)upload_session_id is null(.+)").
+		WithArgs(1, before, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(11, `{"archived":"1"}`))
+	files, err := GetPolicyFilesCreatedBefore(1, before, 10, 100)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(files, 1)
+	asserts.True(files[0].IsArchived())
+}
+
+func TestGetPolicyUserIDs(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT distinct user_id(.+)files(.+)").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2).AddRow(3))
+	uids, err := GetPolicyUserIDs(1)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Equal([]uint{2, 3}, uids)
+}
+
+func TestGetRehydratingFiles(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)files(.+)metadata like(.+)").
+		WithArgs(10, `%"rehydrating":"1"%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(11, `{"archived":"1","rehydrating":"1"}`))
+	files, err := GetRehydratingFiles(10, 100)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(files, 1)
+	asserts.True(files[0].IsRehydrating())
+}
+
+func TestFile_MarkRehydrated(t *testing.T) {
+	asserts := assert.New(t)
+	file := &File{MetadataSerialized: map[string]string{ArchivedMetadataKey: "1", RehydratingMetadataKey: "1", "k": "v"}}
+	file.ID = 1
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)files(.+)").WithArgs(`{"k":"v","rehydrated":"1"}`, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	asserts.NoError(file.MarkRehydrated())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.False(file.IsArchived())
+	asserts.False(file.IsRehydrating())
+}
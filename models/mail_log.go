@@ -0,0 +1,27 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// 邮件投递状态
+const (
+	MailSent   = "sent"
+	MailFailed = "failed"
+)
+
+// MailLog 邮件投递记录
+type MailLog struct {
+	gorm.Model
+	Provider  string
+	To        string `gorm:"index:to"`
+	Subject   string
+	MessageID string
+	Status    string
+	Error     string `gorm:"type:text"`
+}
+
+// Create 创建邮件投递记录
+func (log *MailLog) Create() error {
+	return DB.Create(log).Error
+}
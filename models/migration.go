@@ -41,7 +41,12 @@ func migration() {
 	}
 
 	DB.AutoMigrate(&User{}, &Setting{}, &Group{}, &Policy{}, &Folder{}, &File{}, &Share{},
-		&Task{}, &Download{}, &Tag{}, &Webdav{}, &Node{}, &SourceLink{})
+		&Task{}, &Download{}, &Tag{}, &Webdav{}, &Node{}, &SourceLink{}, &Quarantine{}, &BlockedHash{}, &BlocklistLog{}, &Report{}, &InviteCode{}, &MailLog{}, &Notification{}, &ShareEvent{}, &FileVersion{}, &AudioMeta{}, &PhotoMeta{}, &ThumbRecord{}, &Traffic{}, &FileChange{}, &FileLock{}, &AuditLog{}, &Announcement{}, &AnnouncementRead{}, &UsageRollup{}, &Mount{}, &Tenant{}, &GuestUpload{}, &MultipartUpload{}, &Vault{}, &RetentionHold{})
+
+	// Email 改为在租户内唯一，移除旧版本的全局唯一索引
+	if DB.Dialect().HasIndex("users", "uix_users_email") {
+		DB.Model(&User{}).RemoveIndex("uix_users_email")
+	}
 
 	// 创建初始存储策略
 	addDefaultPolicy()
@@ -108,6 +113,7 @@ func addDefaultGroups() {
 				ArchiveTask:      true,
 				ShareDownload:    true,
 				Aria2:            true,
+				Gallery:          true,
 				SourceBatchSize:  1000,
 				Aria2BatchSize:   50,
 				RedirectedSource: true,
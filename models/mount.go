@@ -0,0 +1,117 @@
+package model
+
+import (
+	"path"
+
+	"github.com/jinzhu/gorm"
+)
+
+// Mount 用户挂载的外部存储，挂载点目录下的文件存放于挂载所用的存储策略中
+type Mount struct {
+	gorm.Model
+	Name     string
+	UserID   uint `gorm:"index:mount_user"`
+	FolderID uint `gorm:"unique_index:mount_folder"` // 挂载点目录
+	PolicyID uint // 挂载专用的存储策略
+}
+
+// Create 创建挂载及其专用的存储策略
+func (mount *Mount) Create(policy *Policy) error {
+	policy.OptionsSerialized.MountOwner = mount.UserID
+
+	tx := DB.Begin()
+	if err := tx.Create(policy).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	mount.PolicyID = policy.ID
+	if err := tx.Create(mount).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// Delete 解除挂载，删除挂载存储策略下的文件记录及策略本身，不会删除外部存储中的文件
+func (mount *Mount) Delete() error {
+	var files []*File
+	if err := DB.Where("user_id = ? and policy_id = ?", mount.UserID, mount.PolicyID).Find(&files).Error; err != nil {
+		return err
+	}
+	if err := DeleteFiles(files, mount.UserID); err != nil {
+		return err
+	}
+
+	tx := DB.Begin()
+	if err := tx.Unscoped().Where("id = ?", mount.PolicyID).Delete(&Policy{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Unscoped().Delete(mount).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	(&Policy{Model: gorm.Model{ID: mount.PolicyID}}).ClearCache()
+	return nil
+}
+
+// GetMountsByUID 列出用户的所有挂载
+func GetMountsByUID(uid uint) ([]Mount, error) {
+	var mounts []Mount
+	result := DB.Where("user_id = ?", uid).Order("id asc").Find(&mounts)
+	return mounts, result.Error
+}
+
+// GetMountByID 根据 ID 获取用户的挂载
+func GetMountByID(id, uid uint) (*Mount, error) {
+	var mount Mount
+	result := DB.Where("id = ? and user_id = ?", id, uid).First(&mount)
+	return &mount, result.Error
+}
+
+// GetMountOfFolder 查找目录所在的挂载，目录本身或其任一上级目录为挂载点时返回该挂载
+func GetMountOfFolder(folder *Folder) (*Mount, error) {
+	mount, _, err := ResolveMountOfFolder(folder)
+	return mount, err
+}
+
+// ResolveMountOfFolder 查找目录所在的挂载，同时返回目录相对于挂载点的路径，
+// 目录本身为挂载点时相对路径为 /
+func ResolveMountOfFolder(folder *Folder) (*Mount, string, error) {
+	mounts, err := GetMountsByUID(folder.OwnerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(mounts) == 0 {
+		return nil, "", gorm.ErrRecordNotFound
+	}
+
+	mountPoints := make(map[uint]*Mount, len(mounts))
+	for i := range mounts {
+		mountPoints[mounts[i].FolderID] = &mounts[i]
+	}
+
+	relative := "/"
+	current := folder
+	for {
+		if mount, ok := mountPoints[current.ID]; ok {
+			return mount, relative, nil
+		}
+		if current.ParentID == nil {
+			return nil, "", gorm.ErrRecordNotFound
+		}
+
+		relative = path.Join("/", current.Name, relative)
+		var parent Folder
+		if err := DB.Where("id = ? and owner_id = ?", *current.ParentID, folder.OwnerID).First(&parent).Error; err != nil {
+			return nil, "", err
+		}
+		current = &parent
+	}
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMount_Create(t *testing.T) {
+	a := assert.New(t)
+	conf.SystemConfig.CredentialKey = "secret"
+	defer func() { conf.SystemConfig.CredentialKey = "" }()
+
+	mount := &Mount{UserID: 1, FolderID: 2}
+	policy := &Policy{Type: "s3", AccessKey: "ak", SecretKey: "sk"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)policies").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT(.+)mounts").WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectCommit()
+
+	a.NoError(mount.Create(policy))
+	a.NoError(mock.ExpectationsWereMet())
+	a.EqualValues(3, mount.PolicyID)
+	a.EqualValues(1, policy.OptionsSerialized.MountOwner)
+	// 保存后恢复明文凭证
+	a.Equal("ak", policy.AccessKey)
+	a.Equal("sk", policy.SecretKey)
+}
+
+func TestPolicy_MountCredentials(t *testing.T) {
+	a := assert.New(t)
+	conf.SystemConfig.CredentialKey = "secret"
+	defer func() { conf.SystemConfig.CredentialKey = "" }()
+
+	policy := &Policy{AccessKey: "ak", SecretKey: "sk"}
+	policy.OptionsSerialized.MountOwner = 1
+	a.NoError(policy.encryptCredentials())
+	a.NotEqual("ak", policy.AccessKey)
+	encrypted := *policy
+
+	// 密钥取自配置文件，与数据库中的设置无关
+	cache.Set("setting_secret_key", "other", 0)
+
+	// 读取时解密
+	policy.Options = `{"mount_owner":1}`
+	a.NoError(policy.AfterFind())
+	a.Equal("ak", policy.AccessKey)
+	a.Equal("sk", policy.SecretKey)
+
+	// 配置文件中的密钥不一致时无法解密
+	conf.SystemConfig.CredentialKey = "other"
+	encrypted.Options = `{"mount_owner":1}`
+	a.Error(encrypted.AfterFind())
+
+	// 非挂载策略不处理
+	plain := &Policy{AccessKey: "ak"}
+	a.NoError(plain.AfterFind())
+	a.Equal("ak", plain.AccessKey)
+}
+
+func TestGetMountOfFolder(t *testing.T) {
+	a := assert.New(t)
+	parentID := uint(2)
+	folder := &Folder{Model: gorm.Model{ID: 3}, ParentID: &parentID, OwnerID: 1}
+
+	// 无挂载
+	{
+		mock.ExpectQuery("SELECT(.+)mounts").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		_, err := GetMountOfFolder(folder)
+		a.NoError(mock.ExpectationsWereMet())
+		a.True(gorm.IsRecordNotFoundError(err))
+	}
+
+	// 上级目录为挂载点
+	{
+		mock.ExpectQuery("SELECT(.+)mounts").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id", "policy_id"}).AddRow(5, 2, 6))
+		mock.ExpectQuery("SELECT(.+)folders").WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(2, 1))
+		mount, err := GetMountOfFolder(folder)
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.EqualValues(6, mount.PolicyID)
+	}
+
+	// 相对于挂载点的路径
+	{
+		mock.ExpectQuery("SELECT(.+)mounts").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id", "policy_id"}).AddRow(5, 2, 6))
+		mock.ExpectQuery("SELECT(.+)folders").WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(2, 1))
+		mount, relative, err := ResolveMountOfFolder(&Folder{Model: gorm.Model{ID: 3}, Name: "sub", ParentID: &parentID, OwnerID: 1})
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.EqualValues(5, mount.ID)
+		a.Equal("/sub", relative)
+
+		mock.ExpectQuery("SELECT(.+)mounts").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id"}).AddRow(5, 3))
+		_, relative, err = ResolveMountOfFolder(folder)
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.Equal("/", relative)
+	}
+
+	// 上溯至根目录仍未找到
+	{
+		mock.ExpectQuery("SELECT(.+)mounts").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id"}).AddRow(5, 9))
+		mock.ExpectQuery("SELECT(.+)folders").WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		_, err := GetMountOfFolder(folder)
+		a.NoError(mock.ExpectationsWereMet())
+		a.True(gorm.IsRecordNotFoundError(err))
+	}
+}
+
+func TestMount_Delete(t *testing.T) {
+	a := assert.New(t)
+	mount := &Mount{Model: gorm.Model{ID: 5}, UserID: 1, PolicyID: 6}
+	cache.Set("policy_6", Policy{}, 0)
+
+	mock.ExpectQuery("SELECT(.+)files").WithArgs(1, 6).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)users").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE(.+)policies").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE(.+)mounts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	a.NoError(mount.Delete())
+	a.NoError(mock.ExpectationsWereMet())
+	_, ok := cache.Get("policy_6")
+	a.False(ok)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// MultipartUpload 客户端直传时在存储端发起的分片上传，
+// 上传未完成时需在存储端取消，否则已上传的分片会持续占用空间
+type MultipartUpload struct {
+	gorm.Model
+	PolicyID  uint
+	UserID    uint
+	SessionID string `gorm:"index:multipart_session"`
+	UploadID  string
+	SavePath  string `gorm:"type:text"`
+}
+
+// Create 记录发起的分片上传
+func (upload *MultipartUpload) Create() error {
+	return DB.Create(upload).Error
+}
+
+// Delete 删除分片上传记录
+func (upload *MultipartUpload) Delete() error {
+	return DB.Unscoped().Delete(upload).Error
+}
+
+// IsCompleted 返回分片上传是否已合并为正式文件
+func (upload *MultipartUpload) IsCompleted() bool {
+	var count int
+	DB.Model(&File{}).Where("policy_id = ? and source_name = ? and upload_session_id is null",
+		upload.PolicyID, upload.SavePath).Count(&count)
+	return count > 0
+}
+
+// GetMultipartUploadsBefore 按 ID 顺序分批列出 before 之前发起的分片上传，
+// after 为上一批最后一条记录的 ID
+func GetMultipartUploadsBefore(before time.Time, after uint, limit int) ([]MultipartUpload, error) {
+	var uploads []MultipartUpload
+	result := DB.Where("created_at < ? and id > ?", before, after).Order("id asc").Limit(limit).Find(&uploads)
+	return uploads, result.Error
+}
+
+// DeleteMultipartUploadsBySession 删除上传会话对应的分片上传记录
+func DeleteMultipartUploadsBySession(sessionID string) error {
+	return DB.Unscoped().Where("session_id = ?", sessionID).Delete(&MultipartUpload{}).Error
+}
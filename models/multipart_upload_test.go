@@ -0,0 +1,45 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipartUpload_IsCompleted(t *testing.T) {
+	asserts := assert.New(t)
+	upload := &MultipartUpload{PolicyID: 1, SavePath: "uploads/1.txt"}
+
+	// 已合并为正式文件
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(1, "uploads/1.txt").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		asserts.True(upload.IsCompleted())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 未完成
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(1, "uploads/1.txt").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		asserts.False(upload.IsCompleted())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestGetMultipartUploadsBefore(t *testing.T) {
+	asserts := assert.New(t)
+	before := time.Now()
+	mock.ExpectQuery("SELECT(.+)multipart_uploads(.+)").
+		WithArgs(before, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "upload_id"}).AddRow(6, "a").AddRow(7, "b"))
+	uploads, err := GetMultipartUploadsBefore(before, 5, 10)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(uploads, 2)
+	asserts.Equal("b", uploads[1].UploadID)
+}
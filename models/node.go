@@ -24,9 +24,13 @@ type Node struct {
 
 // Aria2Option 非公有的Aria2配置属性
 type Aria2Option struct {
+	// 下载器驱动，为空时使用 aria2
+	Driver string `json:"driver,omitempty"`
 	// RPC 服务器地址
 	Server string `json:"server,omitempty"`
-	// RPC 密钥
+	// Web API 用户名，仅 qBittorrent、Transmission 使用
+	Username string `json:"username,omitempty"`
+	// RPC 密钥，使用 qBittorrent 时为 Web API 密码
 	Token string `json:"token,omitempty"`
 	// 临时下载目录
 	TempPath string `json:"temp_path,omitempty"`
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 通知类型
+const (
+	// NotifyShareDownloaded 分享被下载
+	NotifyShareDownloaded = "share_downloaded"
+	// NotifyTaskFinished 任务执行结束
+	NotifyTaskFinished = "task_finished"
+	// NotifyQuotaWarning 容量即将用尽
+	NotifyQuotaWarning = "quota_warning"
+	// NotifyAnnouncement 管理员公告
+	NotifyAnnouncement = "announcement"
+	// NotifyShareSecurity 分享安全提醒
+	NotifyShareSecurity = "share_security"
+	// NotifyUsageReport 站点用量报告，仅发送给管理员
+	NotifyUsageReport = "usage_report"
+	// NotifyFileExpiry 文件即将按生命周期规则删除
+	NotifyFileExpiry = "file_expiry"
+	// NotifyShareExpired 分享已到期
+	NotifyShareExpired = "share_expired"
+)
+
+// 通知渠道
+const (
+	NotifyChannelEmail = "email"
+	NotifyChannelInApp = "in_app"
+)
+
+// NotifyTypes 所有可设定偏好的通知类型
+var NotifyTypes = []string{NotifyShareDownloaded, NotifyTaskFinished, NotifyQuotaWarning, NotifyAnnouncement, NotifyShareSecurity, NotifyUsageReport, NotifyFileExpiry, NotifyShareExpired}
+
+// defaultNotifyPrefs 用户未设定时的默认通知偏好
+var defaultNotifyPrefs = map[string]NotifyPref{
+	NotifyShareDownloaded: {Email: false, InApp: true},
+	NotifyTaskFinished:    {Email: false, InApp: true},
+	NotifyQuotaWarning:    {Email: true, InApp: true},
+	NotifyAnnouncement:    {Email: true, InApp: true},
+	NotifyShareSecurity:   {Email: true, InApp: true},
+	NotifyUsageReport:     {Email: false, InApp: true},
+	NotifyFileExpiry:      {Email: true, InApp: true},
+	NotifyShareExpired:    {Email: false, InApp: true},
+}
+
+// NotifyPref 单个通知类型的投递偏好
+type NotifyPref struct {
+	Email bool `json:"email"`
+	InApp bool `json:"in_app"`
+}
+
+// Notification 站内通知
+type Notification struct {
+	gorm.Model
+	UserID  uint `gorm:"index:user_id"`
+	Type    string
+	Title   string
+	Content string `gorm:"type:text"`
+	ReadAt  *time.Time
+}
+
+// Create 创建站内通知
+func (notification *Notification) Create() error {
+	return DB.Create(notification).Error
+}
+
+// ListNotifications 列出用户的站内通知
+func ListNotifications(uid uint, page, pageSize int) ([]Notification, int) {
+	var (
+		res   []Notification
+		total int
+	)
+	dbChain := DB.Model(&Notification{}).Where("user_id = ?", uid)
+	dbChain.Count(&total)
+	dbChain.Order("id desc").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res)
+
+	return res, total
+}
+
+// CountUnreadNotifications 返回用户的未读通知数
+func CountUnreadNotifications(uid uint) int {
+	total := 0
+	DB.Model(&Notification{}).Where("user_id = ? and read_at is NULL", uid).Count(&total)
+	return total
+}
+
+// MarkNotificationsRead 将用户的通知标记为已读，ids 为空时标记全部
+func MarkNotificationsRead(uid uint, ids []uint) error {
+	dbChain := DB.Model(&Notification{}).Where("user_id = ? and read_at is NULL", uid)
+	if len(ids) > 0 {
+		dbChain = dbChain.Where("id in (?)", ids)
+	}
+	return dbChain.Update("read_at", time.Now()).Error
+}
+
+// NotifyPrefs 返回用户所有通知类型的投递偏好，未设定的类型使用默认值
+func (user *User) NotifyPrefs() map[string]NotifyPref {
+	res := make(map[string]NotifyPref, len(defaultNotifyPrefs))
+	for typ, pref := range defaultNotifyPrefs {
+		res[typ] = pref
+	}
+	for typ, pref := range user.OptionsSerialized.Notify {
+		if _, ok := res[typ]; ok {
+			res[typ] = pref
+		}
+	}
+	return res
+}
+
+// NotifyEnabled 返回用户是否接收指定类型、指定渠道的通知
+func (user *User) NotifyEnabled(typ, channel string) bool {
+	pref, ok := user.NotifyPrefs()[typ]
+	if !ok {
+		return false
+	}
+
+	switch channel {
+	case NotifyChannelEmail:
+		return pref.Email
+	case NotifyChannelInApp:
+		return pref.InApp
+	}
+	return false
+}
+
+// SetNotifyPref 设定用户某一通知类型在指定渠道上的偏好
+func (user *User) SetNotifyPref(typ, channel string, enabled bool) {
+	pref := user.NotifyPrefs()[typ]
+	switch channel {
+	case NotifyChannelEmail:
+		pref.Email = enabled
+	case NotifyChannelInApp:
+		pref.InApp = enabled
+	}
+
+	if user.OptionsSerialized.Notify == nil {
+		user.OptionsSerialized.Notify = make(map[string]NotifyPref)
+	}
+	user.OptionsSerialized.Notify[typ] = pref
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_NotifyPrefs(t *testing.T) {
+	asserts := assert.New(t)
+	user := &User{}
+
+	// 默认偏好
+	{
+		asserts.True(user.NotifyEnabled(NotifyQuotaWarning, NotifyChannelEmail))
+		asserts.False(user.NotifyEnabled(NotifyShareDownloaded, NotifyChannelEmail))
+		asserts.True(user.NotifyEnabled(NotifyShareDownloaded, NotifyChannelInApp))
+		asserts.False(user.NotifyEnabled("unknown", NotifyChannelInApp))
+		asserts.False(user.NotifyEnabled(NotifyAnnouncement, "unknown"))
+	}
+
+	// 用户设定覆盖默认值，两种渠道互不影响
+	{
+		user.SetNotifyPref(NotifyShareDownloaded, NotifyChannelEmail, true)
+		user.SetNotifyPref(NotifyAnnouncement, NotifyChannelInApp, false)
+		asserts.True(user.NotifyEnabled(NotifyShareDownloaded, NotifyChannelEmail))
+		asserts.True(user.NotifyEnabled(NotifyShareDownloaded, NotifyChannelInApp))
+		asserts.False(user.NotifyEnabled(NotifyAnnouncement, NotifyChannelInApp))
+		asserts.True(user.NotifyEnabled(NotifyAnnouncement, NotifyChannelEmail))
+		asserts.Len(user.NotifyPrefs(), len(NotifyTypes))
+	}
+}
+
+func TestListNotifications(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(2))
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(1))
+	res, total := ListNotifications(1, 1, 10)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(2, total)
+	asserts.Len(res, 2)
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 标记全部
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+		asserts.NoError(MarkNotificationsRead(1, nil))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 标记指定通知
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)id in(.+)").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+		asserts.NoError(MarkNotificationsRead(1, []uint{2, 3}))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
@@ -0,0 +1,128 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// PhotoMeta 照片的 EXIF 拍摄信息
+type PhotoMeta struct {
+	gorm.Model
+	FileID      uint   `gorm:"unique_index:photo_file"`
+	UserID      uint   `gorm:"index:photo_user"`
+	SourceName  string `gorm:"type:text"` // 提取信息时的物理文件，用于判断是否需要重新提取
+	TakenAt     time.Time
+	CameraMake  string
+	CameraModel string
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// PhotoFilter 照片时间线的筛选条件
+type PhotoFilter struct {
+	CameraMake  string
+	CameraModel string
+	// 经纬度范围，MinLat 与 MaxLat 均为 0 时不按位置筛选
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+	// 只列出带有位置信息的照片
+	WithLocation bool
+}
+
+// PhotoCamera 用户拍摄照片使用过的相机
+type PhotoCamera struct {
+	CameraMake  string
+	CameraModel string
+	Count       int
+}
+
+// Save 保存照片信息
+func (meta *PhotoMeta) Save() error {
+	return DB.Save(meta).Error
+}
+
+// IsStale 返回照片信息是否已不对应文件的当前内容
+func (meta *PhotoMeta) IsStale(file *File) bool {
+	return meta.SourceName != file.SourceName
+}
+
+// GetPhotoMetaByFileID 获取文件的照片信息
+func GetPhotoMetaByFileID(fileID uint) (*PhotoMeta, error) {
+	var meta PhotoMeta
+	result := DB.Where("file_id = ?", fileID).First(&meta)
+	return &meta, result.Error
+}
+
+// ListPhotoMeta 按拍摄时间倒序列出用户符合条件的照片
+func ListPhotoMeta(uid uint, filter *PhotoFilter, page, pageSize int) ([]PhotoMeta, int) {
+	var (
+		res   []PhotoMeta
+		total int
+	)
+	dbChain := DB.Model(&PhotoMeta{}).Where("user_id = ?", uid)
+	if filter.CameraMake != "" {
+		dbChain = dbChain.Where("camera_make = ?", filter.CameraMake)
+	}
+	if filter.CameraModel != "" {
+		dbChain = dbChain.Where("camera_model = ?", filter.CameraModel)
+	}
+	if filter.WithLocation {
+		dbChain = dbChain.Where("latitude is not NULL and longitude is not NULL")
+	}
+	if filter.MinLat != 0 || filter.MaxLat != 0 {
+		dbChain = dbChain.Where("latitude between ? and ? and longitude between ? and ?",
+			filter.MinLat, filter.MaxLat, filter.MinLng, filter.MaxLng)
+	}
+
+	dbChain.Count(&total)
+	dbChain.Order("taken_at desc, id desc").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res)
+	return res, total
+}
+
+// ListPhotoCameras 列出用户拍摄照片使用过的相机
+func ListPhotoCameras(uid uint) []PhotoCamera {
+	var res []PhotoCamera
+	DB.Model(&PhotoMeta{}).
+		Select("camera_make, camera_model, count(*) as count").
+		Where("user_id = ? and camera_model <> ?", uid, "").
+		Group("camera_make, camera_model").Order("count desc").Scan(&res)
+	return res
+}
+
+// GetFilesWithoutPhotoMeta 列出用户尚未提取照片信息的图片文件
+func GetFilesWithoutPhotoMeta(uid uint, exts []string, limit int) ([]File, error) {
+	var files []File
+	conditions := make([]string, 0, len(exts))
+	args := []interface{}{uid}
+	for _, ext := range exts {
+		if ext = strings.TrimSpace(ext); ext != "" {
+			conditions = append(conditions, "name like ?")
+			args = append(args, "%."+ext)
+		}
+	}
+	if len(conditions) == 0 {
+		return files, nil
+	}
+
+	// 相关子查询可使用照片信息的文件索引，无需取出用户的全部已提取文件
+	fileTable := DB.NewScope(&File{}).TableName()
+	metaTable := DB.NewScope(&PhotoMeta{}).TableName()
+	result := DB.Where("user_id = ? and upload_session_id is NULL and ("+strings.Join(conditions, " or ")+")", args...).
+		Where("not exists (select 1 from " + metaTable + " where " + metaTable + ".file_id = " + fileTable + ".id and " + metaTable + ".deleted_at is NULL)").
+		Limit(limit).Find(&files)
+	return files, result.Error
+}
+
+// DeletePhotoMetaByFileIDs 删除多个文件的照片信息
+func DeletePhotoMetaByFileIDs(fileIDs []uint) error {
+	return DB.Unscoped().Where("file_id in (?)", fileIDs).Delete(&PhotoMeta{}).Error
+}
+
+// CountPhotoMetaByFileIDs 返回多个文件中已有照片信息的数量
+func CountPhotoMetaByFileIDs(fileIDs []uint) int {
+	total := 0
+	DB.Model(&PhotoMeta{}).Where("file_id in (?)", fileIDs).Count(&total)
+	return total
+}
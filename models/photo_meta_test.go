@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPhotoMetaByFileID(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)photo_meta").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_id", "camera_make"}).AddRow(1, 2, "Canon"))
+	res, err := GetPhotoMetaByFileID(2)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Equal("Canon", res.CameraMake)
+}
+
+func TestListPhotoMeta(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)photo_meta(.+)camera_make(.+)latitude").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("SELECT(.+)photo_meta(.+)camera_make(.+)latitude").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_id"}).AddRow(1, 2).AddRow(2, 3))
+	res, total := ListPhotoMeta(1, &PhotoFilter{CameraMake: "Canon", WithLocation: true}, 1, 10)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(2, total)
+	asserts.Len(res, 2)
+}
+
+func TestGetFilesWithoutPhotoMeta(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 未设定扩展名
+	{
+		res, err := GetFilesWithoutPhotoMeta(1, []string{" "}, 10)
+		asserts.NoError(err)
+		asserts.Empty(res)
+	}
+
+	// 单条查询排除已提取的文件
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)name like(.+)not exists \\(select 1 from photo_meta(.+)file_id = files.id").
+			WithArgs(1, "%.jpg", "%.png").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "a.jpg"))
+		res, err := GetFilesWithoutPhotoMeta(1, []string{"jpg", "png"}, 10)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Len(res, 1)
+	}
+}
+
+func TestPhotoMeta_IsStale(t *testing.T) {
+	asserts := assert.New(t)
+	meta := &PhotoMeta{SourceName: "a.jpg"}
+
+	asserts.False(meta.IsStale(&File{SourceName: "a.jpg"}))
+	asserts.True(meta.IsStale(&File{SourceName: "b.jpg"}))
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/jinzhu/gorm"
 )
@@ -34,6 +35,8 @@ type Policy struct {
 	FileNameRule       string
 	IsOriginLinkEnable bool
 	Options            string `gorm:"type:text"`
+	// 所属租户，0 为默认租户
+	TenantID uint `gorm:"index:policy_tenant"`
 
 	// 数据库忽略字段
 	OptionsSerialized PolicyOption `gorm:"-"`
@@ -71,8 +74,38 @@ type PolicyOption struct {
 	S3ForcePathStyle bool `json:"s3_path_style"`
 	// File extensions that support thumbnail generation using native policy API.
 	ThumbExts []string `json:"thumb_exts,omitempty"`
+	// 此策略下文件可使用的缩略图生成器，为空时使用所有已启用的生成器
+	ThumbGenerators []string `json:"thumb_generators,omitempty"`
+	// 上传完成后检查文件内容与扩展名是否一致，不一致时 reject 删除文件、quarantine 隔离文件，为空时不检查
+	ContentSniff string `json:"content_sniff,omitempty"`
+	// MountOwner 由用户挂载的外部存储所属用户 ID，此类策略的凭证加密存储
+	MountOwner uint `json:"mount_owner,omitempty"`
+	// ArchiveAfterDays 文件上传满指定天数后转为归档存储类型，0 表示不转换
+	ArchiveAfterDays int `json:"archive_after_days,omitempty"`
+	// ArchiveStorageClass 归档使用的存储类型，为空时 S3 使用 GLACIER，OSS 使用 Archive
+	ArchiveStorageClass string `json:"archive_storage_class,omitempty"`
+	// ExpiryFolders 文件到期后自动删除的目录，为相对于用户根目录的路径，包含其子目录
+	ExpiryFolders []string `json:"expiry_folders,omitempty"`
+	// ExpiryDays 上述目录中的文件上传满指定天数后删除，0 表示不删除
+	ExpiryDays int `json:"expiry_days,omitempty"`
+	// ExpiryWarnDays 删除前提前通知用户的天数，0 表示不通知
+	ExpiryWarnDays int `json:"expiry_warn_days,omitempty"`
+	// DownloadTTL 下载地址的有效期，单位为秒，0 表示使用站点设置
+	DownloadTTL int `json:"download_ttl,omitempty"`
+	// DownloadBindIP 下载地址仅允许签发时的客户端 IP 使用，仅对本机与从机策略生效
+	DownloadBindIP bool `json:"download_bind_ip,omitempty"`
+	// DownloadOneTime 下载地址仅允许使用一次，仅对本机与从机策略生效
+	DownloadOneTime bool `json:"download_one_time,omitempty"`
+	// ProxyMaxConcurrent 从机节点经由主机中转下载的最大并发数，0 表示不限制
+	ProxyMaxConcurrent int `json:"proxy_max_concurrent,omitempty"`
 }
 
+// 文件内容与扩展名不一致时的处理方式
+const (
+	ContentSniffReject     = "reject"
+	ContentSniffQuarantine = "quarantine"
+)
+
 func init() {
 	// 注册缓存用到的复杂结构
 	gob.Register(Policy{})
@@ -106,6 +139,9 @@ func (policy *Policy) AfterFind() (err error) {
 	if policy.OptionsSerialized.FileType == nil {
 		policy.OptionsSerialized.FileType = []string{}
 	}
+	if err == nil && policy.IsMounted() {
+		err = policy.decryptCredentials()
+	}
 
 	return err
 }
@@ -113,6 +149,51 @@ func (policy *Policy) AfterFind() (err error) {
 // BeforeSave Save策略前的钩子
 func (policy *Policy) BeforeSave() (err error) {
 	err = policy.SerializeOptions()
+	if err == nil && policy.IsMounted() {
+		err = policy.encryptCredentials()
+	}
+	return err
+}
+
+// AfterSave Save策略后的钩子，恢复已加密的凭证
+func (policy *Policy) AfterSave() (err error) {
+	if policy.IsMounted() {
+		err = policy.decryptCredentials()
+	}
+	return err
+}
+
+// IsMounted 返回策略是否为用户挂载的外部存储
+func (policy *Policy) IsMounted() bool {
+	return policy.OptionsSerialized.MountOwner != 0
+}
+
+// credentialKey 返回加密挂载存储凭证的密钥。密钥取自配置文件，
+// 避免与密文一同保存在数据库中
+func credentialKey() string {
+	if conf.SystemConfig.CredentialKey != "" {
+		return conf.SystemConfig.CredentialKey
+	}
+	return conf.SystemConfig.SessionSecret
+}
+
+// encryptCredentials 加密用户挂载存储的凭证
+func (policy *Policy) encryptCredentials() (err error) {
+	secret := credentialKey()
+	if policy.AccessKey, err = util.Encrypt(secret, policy.AccessKey); err != nil {
+		return err
+	}
+	policy.SecretKey, err = util.Encrypt(secret, policy.SecretKey)
+	return err
+}
+
+// decryptCredentials 解密用户挂载存储的凭证
+func (policy *Policy) decryptCredentials() (err error) {
+	secret := credentialKey()
+	if policy.AccessKey, err = util.Decrypt(secret, policy.AccessKey); err != nil {
+		return err
+	}
+	policy.SecretKey, err = util.Decrypt(secret, policy.SecretKey)
 	return err
 }
 
@@ -221,7 +302,16 @@ func (policy *Policy) SaveAndClearCache() error {
 
 // SaveAndClearCache 更新并清理缓存
 func (policy *Policy) UpdateAccessKeyAndClearCache(s string) error {
-	err := DB.Model(policy).UpdateColumn("access_key", s).Error
+	value := s
+	if policy.IsMounted() {
+		encrypted, err := util.Encrypt(credentialKey(), s)
+		if err != nil {
+			return err
+		}
+		value = encrypted
+	}
+	err := DB.Model(policy).UpdateColumn("access_key", value).Error
+	policy.AccessKey = s
 	policy.ClearCache()
 	return err
 }
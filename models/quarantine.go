@@ -0,0 +1,82 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// 隔离记录状态
+const (
+	// QuarantinePending 等待管理员审核
+	QuarantinePending = iota
+	// QuarantineReleased 已解除隔离
+	QuarantineReleased
+	// QuarantineDeleted 文件已删除
+	QuarantineDeleted
+)
+
+// QuarantineMetadataKey 文件被隔离时设定的元信息
+const QuarantineMetadataKey = "av_quarantine"
+
+// Quarantine 病毒扫描隔离记录
+type Quarantine struct {
+	gorm.Model
+	FileID    uint `gorm:"index:file_id"`
+	UserID    uint `gorm:"index:user_id"`
+	FileName  string
+	Signature string
+	Status    int
+}
+
+// IsQuarantined 返回文件是否处于隔离状态
+func (file *File) IsQuarantined() bool {
+	return IsTrueVal(file.MetadataSerialized[QuarantineMetadataKey])
+}
+
+// QuarantineFile 隔离文件并创建审核记录
+func QuarantineFile(file *File, signature string) (*Quarantine, error) {
+	record := &Quarantine{
+		FileID:    file.ID,
+		UserID:    file.UserID,
+		FileName:  file.Name,
+		Signature: signature,
+		Status:    QuarantinePending,
+	}
+
+	if err := file.UpdateMetadata(map[string]string{QuarantineMetadataKey: "1"}); err != nil {
+		return nil, err
+	}
+
+	if err := DB.Create(record).Error; err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// GetQuarantineByID 根据ID获取隔离记录
+func GetQuarantineByID(id interface{}) (*Quarantine, error) {
+	record := &Quarantine{}
+	result := DB.Where("id = ?", id).First(record)
+	return record, result.Error
+}
+
+// SetStatus 设定隔离记录状态
+func (q *Quarantine) SetStatus(status int) error {
+	return DB.Model(q).Update("status", status).Error
+}
+
+// Release 解除文件隔离
+func (q *Quarantine) Release() error {
+	files, err := GetFilesByIDs([]uint{q.FileID}, q.UserID)
+	if err != nil {
+		return err
+	}
+
+	if len(files) > 0 {
+		if err := files[0].UpdateMetadata(map[string]string{QuarantineMetadataKey: ""}); err != nil {
+			return err
+		}
+	}
+
+	return q.SetStatus(QuarantineReleased)
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantineFile(t *testing.T) {
+	asserts := assert.New(t)
+	file := &File{Name: "virus.exe", UserID: 1}
+	file.ID = 2
+
+	// 更新元信息失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		_, err := QuarantineFile(file, "Eicar")
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Error(err)
+	}
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+		record, err := QuarantineFile(file, "Eicar")
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.EqualValues(3, record.ID)
+		asserts.Equal("Eicar", record.Signature)
+		asserts.EqualValues(2, record.FileID)
+		asserts.True(file.IsQuarantined())
+	}
+}
+
+func TestGetQuarantineByID(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "file_id"}).AddRow(1, 2))
+	record, err := GetQuarantineByID(1)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.EqualValues(2, record.FileID)
+}
+
+func TestQuarantine_Release(t *testing.T) {
+	asserts := assert.New(t)
+	record := &Quarantine{FileID: 2, UserID: 1}
+	record.ID = 1
+
+	// 查找文件失败
+	{
+		mock.ExpectQuery("SELECT(.+)").WillReturnError(errors.New("error"))
+		asserts.Error(record.Release())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 成功
+	{
+		mock.ExpectQuery("SELECT(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(2, `{"av_quarantine":"1"}`))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(record.Release())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(QuarantineReleased, record.Status)
+	}
+}
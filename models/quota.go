@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+)
+
+// 超额宽限期结束后的限制
+const (
+	// QuotaActionBlock 禁止上传
+	QuotaActionBlock = ""
+	// QuotaActionDisableShares 禁止上传并停用用户的全部分享
+	QuotaActionDisableShares = "disable_shares"
+)
+
+// IsOverQuota 返回用户已用容量是否超出配额
+func (user *User) IsOverQuota() bool {
+	return user.Storage > user.TotalCapacity()
+}
+
+// QuotaGraceEnds 返回超额宽限期的结束时间，未超额或宽限期不限时间时返回 nil
+func (user *User) QuotaGraceEnds() *time.Time {
+	days := user.Group.OptionsSerialized.QuotaGraceDays
+	if user.QuotaExceededAt == nil || days <= 0 {
+		return nil
+	}
+
+	ends := user.QuotaExceededAt.AddDate(0, 0, days)
+	return &ends
+}
+
+// InQuotaGrace 返回用户当前能否使用超额宽限容量
+func (user *User) InQuotaGrace() bool {
+	if user.Group.OptionsSerialized.QuotaGrace <= 0 {
+		return false
+	}
+
+	ends := user.QuotaGraceEnds()
+	return ends == nil || time.Now().Before(*ends)
+}
+
+// SetQuotaState 更新用户的超额状态
+func (user *User) SetQuotaState(exceededAt *time.Time, enforced bool) error {
+	user.QuotaExceededAt = exceededAt
+	user.QuotaEnforced = enforced
+	return DB.Model(user).UpdateColumns(map[string]interface{}{
+		"quota_exceeded_at": exceededAt,
+		"quota_enforced":    enforced,
+	}).Error
+}
+
+// GetUsersOverQuota 列出用户组中已用容量超出配额或仍记录为超额的用户
+func GetUsersOverQuota(group *Group) ([]User, error) {
+	var users []User
+	result := DB.Where("group_id = ? and (quota_exceeded_at is not null or (max_storage > 0 and storage > max_storage) or (max_storage = 0 and storage > ?))",
+		group.ID, group.MaxStorage).Find(&users)
+	for i := range users {
+		users[i].Group = *group
+	}
+	return users, result.Error
+}
+
+// DisableUserShares 停用用户的全部分享，并标记为因超额停用
+func DisableUserShares(uid uint) error {
+	return DB.Model(&Share{}).Where("user_id = ? and disabled = ?", uid, false).
+		UpdateColumns(map[string]interface{}{"disabled": true, "quota_disabled": true}).Error
+}
+
+// EnableUserShares 恢复用户因超额而被停用的分享
+func EnableUserShares(uid uint) error {
+	return DB.Model(&Share{}).Where("user_id = ? and quota_disabled = ?", uid, true).
+		UpdateColumns(map[string]interface{}{"disabled": false, "quota_disabled": false}).Error
+}
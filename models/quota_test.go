@@ -0,0 +1,90 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_QuotaGrace(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{Storage: 100}
+	user.Group.MaxStorage = 100
+
+	// 未开启宽限
+	asserts.False(user.InQuotaGrace())
+	asserts.EqualValues(0, user.GetRemainingCapacity())
+
+	// 超额前即可使用宽限容量
+	user.Group.OptionsSerialized.QuotaGrace = 20
+	user.Group.OptionsSerialized.QuotaGraceDays = 7
+	asserts.True(user.InQuotaGrace())
+	asserts.Nil(user.QuotaGraceEnds())
+	asserts.EqualValues(20, user.GetRemainingCapacity())
+
+	// 宽限期内
+	exceededAt := time.Now().AddDate(0, 0, -1)
+	user.QuotaExceededAt = &exceededAt
+	asserts.True(user.InQuotaGrace())
+	asserts.NotNil(user.QuotaGraceEnds())
+
+	// 宽限期结束
+	exceededAt = time.Now().AddDate(0, 0, -8)
+	asserts.False(user.InQuotaGrace())
+	asserts.EqualValues(0, user.GetRemainingCapacity())
+
+	// 不限宽限时间
+	user.Group.OptionsSerialized.QuotaGraceDays = 0
+	asserts.True(user.InQuotaGrace())
+}
+
+func TestGetUsersOverQuota(t *testing.T) {
+	asserts := assert.New(t)
+	group := &Group{MaxStorage: 10}
+	group.ID = 1
+	mock.ExpectQuery("SELECT(.+)users(.+)quota_exceeded_at is not null(.+)").
+		WithArgs(1, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "storage"}).AddRow(2, 20))
+	users, err := GetUsersOverQuota(group)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(users, 1)
+	asserts.True(users[0].IsOverQuota())
+}
+
+func TestUser_SetQuotaState(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{}
+	user.ID = 1
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)users(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(user.SetQuotaState(&now, true))
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.True(user.QuotaEnforced)
+}
+
+func TestUserShares_QuotaDisabled(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 停用时标记为因超额停用
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)shares(.+)disabled(.+)quota_disabled(.+)WHERE(.+)user_id = (.+)disabled = ").
+		WithArgs(true, true, 1, false).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	asserts.NoError(DisableUserShares(1))
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	// 仅恢复因超额停用的分享
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)shares(.+)disabled(.+)quota_disabled(.+)WHERE(.+)user_id = (.+)quota_disabled = ").
+		WithArgs(false, false, 1, true).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	asserts.NoError(EnableUserShares(1))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/jinzhu/gorm"
+)
+
+// replicaCheckInterval 只读副本健康检查间隔
+const replicaCheckInterval = 10 * time.Second
+
+// replica 只读副本链接
+type replica struct {
+	db      *gorm.DB
+	healthy int32
+}
+
+var (
+	// replicas 已配置的只读副本
+	replicas []*replica
+	// replicaCursor 轮询选择副本的游标
+	replicaCursor uint32
+	// replicaDBType 只读副本的数据库类型，决定复制延迟的查询方式
+	replicaDBType string
+)
+
+// errReplicaNotRunning 副本未在复制主库
+var errReplicaNotRunning = errors.New("replication is not running")
+
+// ReadDB 返回用于只读查询的数据库链接，轮询选择健康的只读副本，
+// 未配置副本或副本均不可用时返回主库。写操作及需读取最新数据的查询应使用 DB
+func ReadDB() *gorm.DB {
+	n := uint32(len(replicas))
+	if n == 0 {
+		return DB
+	}
+
+	start := atomic.AddUint32(&replicaCursor, 1)
+	for i := uint32(0); i < n; i++ {
+		r := replicas[(start+i)%n]
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			return r.db
+		}
+	}
+
+	return DB
+}
+
+// initReplicas 连接配置的只读副本并启动健康检查
+func initReplicas(dbType string) {
+	if len(conf.DatabaseConfig.Replicas) == 0 {
+		return
+	}
+
+	if dbType == "sqlite" || dbType == "UNSET" {
+		util.Log().Warning("Read replicas are not supported by SQLite, ignored.")
+		return
+	}
+
+	replicaDBType = dbType
+	for _, dsn := range conf.DatabaseConfig.Replicas {
+		db, err := gorm.Open(dbType, dsn)
+		if err != nil {
+			util.Log().Warning("Failed to connect to read replica: %s", err)
+			continue
+		}
+
+		db.LogMode(conf.SystemConfig.Debug)
+		db.DB().SetMaxIdleConns(50)
+		db.DB().SetMaxOpenConns(100)
+		db.DB().SetConnMaxLifetime(time.Second * 30)
+		replicas = append(replicas, &replica{db: db})
+	}
+
+	if len(replicas) == 0 {
+		return
+	}
+
+	util.Log().Info("%d read replica(s) connected.", len(replicas))
+	checkReplicas()
+	go func() {
+		for range time.Tick(replicaCheckInterval) {
+			checkReplicas()
+		}
+	}()
+}
+
+// checkReplicas 检查各副本的复制延迟，无法连接或延迟超出限制的副本暂停使用，恢复后重新启用
+func checkReplicas() {
+	maxLag := int64(conf.DatabaseConfig.ReplicaMaxLag)
+	for i, r := range replicas {
+		var healthy int32
+		lag, err := replicaLag(r.db, replicaDBType)
+		if err == nil && lag <= maxLag {
+			healthy = 1
+		}
+
+		if atomic.SwapInt32(&r.healthy, healthy) != healthy {
+			if healthy == 1 {
+				util.Log().Info("Read replica #%d is available.", i)
+			} else if err != nil {
+				util.Log().Warning("Read replica #%d is unavailable: %s", i, err)
+			} else {
+				util.Log().Warning("Read replica #%d is lagging behind by %d seconds.", i, lag)
+			}
+		}
+	}
+}
+
+// replicaLag 返回副本落后主库的秒数
+func replicaLag(db *gorm.DB, dbType string) (int64, error) {
+	switch dbType {
+	case "mysql":
+		return mysqlReplicaLag(db.DB())
+	case "postgres":
+		var lag sql.NullFloat64
+		err := db.Raw("SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0 " +
+			"ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END").Row().Scan(&lag)
+		if err != nil {
+			return 0, err
+		}
+		if !lag.Valid {
+			return 0, errReplicaNotRunning
+		}
+		return int64(lag.Float64), nil
+	default:
+		return 0, db.DB().Ping()
+	}
+}
+
+// mysqlReplicaLag 读取 SHOW SLAVE STATUS 中的 Seconds_Behind_Master
+func mysqlReplicaLag(db *sql.DB) (int64, error) {
+	rows, err := db.Query("SHOW SLAVE STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		return 0, errReplicaNotRunning
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+
+	for i, column := range columns {
+		if column == "Seconds_Behind_Master" || column == "Seconds_Behind_Source" {
+			// 复制线程停止时该值为 NULL
+			if !values[i].Valid {
+				return 0, errReplicaNotRunning
+			}
+			return strconv.ParseInt(values[i].String, 10, 64)
+		}
+	}
+
+	return 0, errReplicaNotRunning
+}
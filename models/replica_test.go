@@ -0,0 +1,76 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDB(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 未配置副本
+	asserts.Equal(DB, ReadDB())
+
+	replicaDB, replicaMock, _ := sqlmock.New()
+	gormDB, _ := gorm.Open("mysql", replicaDB)
+	r := &replica{db: gormDB}
+	replicas = []*replica{r}
+	replicaDBType = "mysql"
+	defer func() { replicas = nil }()
+
+	// 副本不可用时使用主库
+	asserts.Equal(DB, ReadDB())
+
+	// 延迟在限制内
+	replicaMock.ExpectQuery("SHOW SLAVE STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}).AddRow("Waiting", "2"))
+	checkReplicas()
+	asserts.NoError(replicaMock.ExpectationsWereMet())
+	asserts.Equal(gormDB, ReadDB())
+
+	// 延迟超出限制
+	replicaMock.ExpectQuery("SHOW SLAVE STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}).AddRow("Waiting", "3600"))
+	checkReplicas()
+	asserts.NoError(replicaMock.ExpectationsWereMet())
+	asserts.Equal(DB, ReadDB())
+
+	// 复制已停止
+	replicaMock.ExpectQuery("SHOW SLAVE STATUS").
+		WillReturnRows(sqlmock.NewRows([]string{"Slave_IO_State", "Seconds_Behind_Master"}).AddRow("", nil))
+	checkReplicas()
+	asserts.NoError(replicaMock.ExpectationsWereMet())
+	asserts.Equal(DB, ReadDB())
+
+	// 查询出错
+	replicaMock.ExpectQuery("SHOW SLAVE STATUS").WillReturnError(errors.New("error"))
+	checkReplicas()
+	asserts.NoError(replicaMock.ExpectationsWereMet())
+	asserts.Equal(DB, ReadDB())
+}
+
+func TestGetShareByHashID_Primary(t *testing.T) {
+	asserts := assert.New(t)
+
+	replicaDB, replicaMock, _ := sqlmock.New()
+	gormDB, _ := gorm.Open("mysql", replicaDB)
+	replicas = []*replica{{db: gormDB, healthy: 1}}
+	defer func() { replicas = nil }()
+
+	// 决定访问权限的分享记录始终从主库读取
+	mock.ExpectQuery("SELECT(.+)shares(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	share := GetShareBySlug("slug")
+	asserts.NoError(replicaMock.ExpectationsWereMet())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NotNil(share)
+
+	mock.ExpectQuery("SELECT(.+)shares(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	share = GetShareBySlug("slug")
+	asserts.NoError(replicaMock.ExpectationsWereMet())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Nil(share)
+}
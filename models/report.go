@@ -0,0 +1,56 @@
+package model
+
+import (
+	"github.com/jinzhu/gorm"
+)
+
+// 举报记录状态
+const (
+	// ReportPending 等待管理员处理
+	ReportPending = iota
+	// ReportResolved 已处理，分享被停用
+	ReportResolved
+	// ReportIgnored 已忽略
+	ReportIgnored
+)
+
+// Report 分享举报记录
+type Report struct {
+	gorm.Model
+	ShareID     uint `gorm:"index:share_id"`
+	Reason      int
+	Description string `gorm:"type:text"`
+	ReporterID  uint
+	ReporterIP  string
+	Status      int
+
+	// 数据库忽略字段
+	Share Share `gorm:"PRELOAD:false,association_autoupdate:false"`
+}
+
+// Create 创建举报记录
+func (report *Report) Create() (uint, error) {
+	if err := DB.Create(report).Error; err != nil {
+		return 0, err
+	}
+	return report.ID, nil
+}
+
+// GetReportByID 根据ID获取举报记录
+func GetReportByID(id interface{}) (*Report, error) {
+	report := &Report{}
+	result := DB.Where("id = ?", id).First(report)
+	return report, result.Error
+}
+
+// SetStatus 设定举报记录状态
+func (report *Report) SetStatus(status int) error {
+	return DB.Model(report).Update("status", status).Error
+}
+
+// ResolvePendingReports 将分享下所有待处理的举报设为给定状态
+func ResolvePendingReports(shareID uint, status int) error {
+	return DB.Model(&Report{}).
+		Where("share_id = ? and status = ?", shareID, ReportPending).
+		Update("status", status).Error
+}
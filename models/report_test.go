@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport_Create(t *testing.T) {
+	asserts := assert.New(t)
+	report := &Report{ShareID: 1, ReporterIP: "127.0.0.1"}
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectCommit()
+		id, err := report.Create()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.EqualValues(5, id)
+	}
+
+	// 失败
+	{
+		report := &Report{ShareID: 1}
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		id, err := report.Create()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Error(err)
+		asserts.EqualValues(0, id)
+	}
+}
+
+func TestGetReportByID(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "share_id"}).AddRow(1, 2))
+	report, err := GetReportByID(1)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.EqualValues(2, report.ShareID)
+}
+
+func TestResolvePendingReports(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)reports(.+)").
+		WithArgs(ReportResolved, sqlmock.AnyArg(), 1, ReportPending).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	asserts.NoError(ResolvePendingReports(1, ReportResolved))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// RetentionHold 保留冻结，有效期内对象及其下所有内容不能被删除、重命名、移动或覆盖
+type RetentionHold struct {
+	gorm.Model
+	// ObjectID 文件或目录 ID
+	ObjectID uint `gorm:"unique_index:retention_object"`
+	IsDir    bool `gorm:"unique_index:retention_object"`
+	// UserID 对象所有者
+	UserID uint `gorm:"index:retention_user"`
+	// HoldUntil 保留截止时间，为空时为无限期的合规冻结，只能由管理员解除
+	HoldUntil *time.Time
+	Reason    string `gorm:"type:text"`
+	// OperatorID 设置冻结的管理员
+	OperatorID uint
+}
+
+// Save 创建或更新保留冻结
+func (hold *RetentionHold) Save() error {
+	return DB.Save(hold).Error
+}
+
+// Delete 解除保留冻结
+func (hold *RetentionHold) Delete() error {
+	return DB.Unscoped().Delete(hold).Error
+}
+
+// Active 返回保留冻结是否仍在有效期内
+func (hold *RetentionHold) Active() bool {
+	return hold.HoldUntil == nil || hold.HoldUntil.After(time.Now())
+}
+
+// GetRetentionHoldByID 根据 ID 获取保留冻结
+func GetRetentionHoldByID(id uint) (*RetentionHold, error) {
+	var hold RetentionHold
+	result := DB.Where("id = ?", id).First(&hold)
+	return &hold, result.Error
+}
+
+// GetRetentionHold 获取文件或目录的保留冻结，包括已过期的记录
+func GetRetentionHold(objectID uint, isDir bool) (*RetentionHold, error) {
+	var hold RetentionHold
+	result := DB.Where("object_id = ? and is_dir = ?", objectID, isDir).First(&hold)
+	return &hold, result.Error
+}
+
+// GetActiveRetentionHoldsByUID 列出用户对象上仍在有效期内的保留冻结
+func GetActiveRetentionHoldsByUID(uid uint) ([]RetentionHold, error) {
+	var holds []RetentionHold
+	result := DB.Where("user_id = ? and (hold_until is null or hold_until > ?)", uid, time.Now()).Find(&holds)
+	return holds, result.Error
+}
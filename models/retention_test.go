@@ -0,0 +1,84 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetentionHold_Active(t *testing.T) {
+	a := assert.New(t)
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	a.True((&RetentionHold{}).Active())
+	a.True((&RetentionHold{HoldUntil: &future}).Active())
+	a.False((&RetentionHold{HoldUntil: &past}).Active())
+}
+
+func TestRetentionHold_Save(t *testing.T) {
+	a := assert.New(t)
+
+	// 新建
+	{
+		hold := &RetentionHold{ObjectID: 2, UserID: 1}
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)retention_holds(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		a.NoError(hold.Save())
+		a.NoError(mock.ExpectationsWereMet())
+		a.EqualValues(1, hold.ID)
+	}
+
+	// 更新
+	{
+		hold := &RetentionHold{Model: gorm.Model{ID: 1}, ObjectID: 2, UserID: 1}
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)retention_holds(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		a.NoError(hold.Save())
+		a.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestGetRetentionHold(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+		WithArgs(2, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "object_id", "is_dir"}).AddRow(1, 2, true))
+	hold, err := GetRetentionHold(2, true)
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.EqualValues(1, hold.ID)
+
+	mock.ExpectQuery("SELECT(.+)retention_holds(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	_, err = GetRetentionHold(3, false)
+	a.NoError(mock.ExpectationsWereMet())
+	a.True(gorm.IsRecordNotFoundError(err))
+}
+
+func TestGetActiveRetentionHoldsByUID(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)retention_holds(.+)hold_until is null or hold_until >").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "object_id"}).AddRow(1, 2).AddRow(2, 3))
+	holds, err := GetActiveRetentionHoldsByUID(1)
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.Len(holds, 2)
+}
+
+func TestRetentionHold_Delete(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE(.+)retention_holds(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	a.NoError((&RetentionHold{Model: gorm.Model{ID: 1}}).Delete())
+	a.NoError(mock.ExpectationsWereMet())
+}
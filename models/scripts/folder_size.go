@@ -0,0 +1,21 @@
+package scripts
+
+import (
+	"context"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+type FolderSizeCalibration int
+
+// Run 运行脚本校准所有目录的缓存大小
+func (script FolderSizeCalibration) Run(ctx context.Context) {
+	repaired, err := model.RepairFolderSizes()
+	if err != nil {
+		util.Log().Warning("Failed to calibrate folder size: %s", err)
+		return
+	}
+
+	util.Log().Info("Calibrated size of %d folder(s).", repaired)
+}
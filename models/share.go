@@ -1,8 +1,11 @@
 package model
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
+	"path"
 	"strings"
 	"time"
 
@@ -13,6 +16,31 @@ import (
 	"github.com/jinzhu/gorm"
 )
 
+// 分享类型
+const (
+	// ShareTypeDefault 普通分享
+	ShareTypeDefault = iota
+	// ShareTypeUpload 文件收集，访客只能向目录上传文件，不能浏览其内容
+	ShareTypeUpload
+	// ShareTypeGallery 站点展示，目录以只读方式在固定路径下公开浏览与下载
+	ShareTypeGallery
+)
+
+// 分享到期后由定时任务执行的处理
+const (
+	// ShareExpireKeep 保留过期的分享，不做处理
+	ShareExpireKeep = iota
+	// ShareExpireDisable 停用分享
+	ShareExpireDisable
+	// ShareExpireDelete 删除分享
+	ShareExpireDelete
+	// ShareExpireNotify 通知分享创建者
+	ShareExpireNotify
+)
+
+// ErrShareTrafficExceeded 分享剩余流量不足
+var ErrShareTrafficExceeded = errors.New("traffic limit of this share is exceeded")
+
 // Share 分享模型
 type Share struct {
 	gorm.Model
@@ -24,8 +52,24 @@ type Share struct {
 	Downloads       int        // 下载数
 	RemainDownloads int        // 剩余下载配额，负值标识无限制
 	Expires         *time.Time // 过期时间，空值表示无过期时间
+	StartsAt        *time.Time // 开始时间，此前分享不可访问，空值表示立即生效
+	ExpireAction    int        // 到期后的处理
+	ExpireHandled   bool       // 到期后的处理是否已执行
 	PreviewEnabled  bool       // 是否允许直接预览
 	SourceName      string     `gorm:"index:source"` // 用于搜索的字段
+	Disabled        bool       // 是否已被管理员或系统停用
+	QuotaDisabled   bool       // 是否因用户超出容量配额而被停用，容量回落后自动恢复
+	Slug            *string    `gorm:"size:64;unique_index:slug"` // 自定义分享链接，空值表示未设定
+	SpeedLimit      int        // 最大下载速度，单位为 字节/秒，0 表示不限制
+	MaxConcurrent   int        // 最大同时下载数，0 表示不限制
+	TrafficLimit    uint64     // 总流量上限，0 表示不限制
+	Traffic         uint64     // 已签发下载的总流量
+	Type            int        // 分享类型
+	UploadMaxSize   uint64     // 文件收集允许的最大单文件大小，0 表示不限制
+	UploadExts      string     // 文件收集允许的扩展名，逗号分隔，空值表示不限制
+	Moderated       bool       // 文件收集上传的文件是否需经分享者审核后才放入目录
+	Items           string     `gorm:"type:text"` // 多项分享所选的对象，空值表示分享整个源对象
+	IndexPage       bool       // 站点展示目录中存在 index.html 时是否作为首页
 
 	// 数据库忽略字段
 	User   User   `gorm:"PRELOAD:false,association_autoupdate:false"`
@@ -42,29 +86,70 @@ func (share *Share) Create() (uint, error) {
 	return share.ID, nil
 }
 
-// GetShareByHashID 根据HashID查找分享
+// GetShareByHashID 根据HashID查找分享，无法解码时尝试作为自定义链接查找。
+// 分享的有效期、密码、剩余下载次数等决定访问权限，需从主库读取最新记录
 func GetShareByHashID(hashID string) *Share {
 	id, err := hashid.DecodeHashID(hashID, hashid.ShareID)
 	if err != nil {
+		return GetShareBySlug(hashID)
+	}
+	var share Share
+	if err := DB.First(&share, id).Error; err != nil {
+		return nil
+	}
+
+	return &share
+}
+
+// GetShareBySlug 根据自定义链接查找分享
+func GetShareBySlug(slug string) *Share {
+	if slug == "" {
 		return nil
 	}
+
 	var share Share
-	result := DB.First(&share, id)
-	if result.Error != nil {
+	if err := DB.Where("slug = ?", strings.ToLower(slug)).First(&share).Error; err != nil {
 		return nil
 	}
 
 	return &share
 }
 
+// IsShareSlugUsed 返回自定义链接是否已被除 exclude 以外的分享使用
+func IsShareSlugUsed(slug string, exclude uint) bool {
+	total := 0
+	DB.Model(&Share{}).Where("slug = ? and id <> ?", slug, exclude).Count(&total)
+	return total > 0
+}
+
+// Key 返回分享链接中使用的标识，优先使用自定义链接
+func (share *Share) Key() string {
+	if share.Slug != nil && *share.Slug != "" {
+		return *share.Slug
+	}
+	return hashid.HashID(share.ID, hashid.ShareID)
+}
+
+// URL 返回分享的访问链接
+func (share *Share) URL() *url.URL {
+	sharePath, _ := url.Parse("/s/" + share.Key())
+	return GetSiteURL().ResolveReference(sharePath)
+}
+
 // IsAvailable 返回此分享是否可用（是否过期）
 func (share *Share) IsAvailable() bool {
+	if share.Disabled {
+		return false
+	}
 	if share.RemainDownloads == 0 {
 		return false
 	}
 	if share.Expires != nil && time.Now().After(*share.Expires) {
 		return false
 	}
+	if !share.IsStarted() {
+		return false
+	}
 
 	// 检查创建者状态
 	if share.Creator().Status != Active {
@@ -88,6 +173,11 @@ func (share *Share) IsAvailable() bool {
 	return true
 }
 
+// IsStarted 返回分享是否已到开始时间
+func (share *Share) IsStarted() bool {
+	return share.StartsAt == nil || !time.Now().Before(*share.StartsAt)
+}
+
 // Creator 获取分享的创建者
 func (share *Share) Creator() *User {
 	if share.User.ID == 0 {
@@ -96,6 +186,12 @@ func (share *Share) Creator() *User {
 	return &share.User
 }
 
+// InTenant 返回分享创建者是否属于指定租户
+func (share *Share) InTenant(tenantID uint) bool {
+	creator := share.Creator()
+	return creator.ID != 0 && creator.TenantID == tenantID
+}
+
 // Source 返回源对象
 func (share *Share) Source() interface{} {
 	if share.IsDir {
@@ -163,6 +259,126 @@ func (share *Share) DownloadBy(user *User, c *gin.Context) error {
 	return nil
 }
 
+// ShareItems 多项分享所选的目录与文件，均位于分享的源目录下
+type ShareItems struct {
+	Dirs  []uint `json:"dirs"`
+	Files []uint `json:"files"`
+}
+
+// IsMultiItem 返回是否为多项分享。多项分享以所选对象的父目录为源目录，
+// 其根目录下只展示所选的对象
+func (share *Share) IsMultiItem() bool {
+	return share.Items != ""
+}
+
+// SelectedItems 返回多项分享所选的目录与文件
+func (share *Share) SelectedItems() ShareItems {
+	var items ShareItems
+	if share.Items != "" {
+		if err := json.Unmarshal([]byte(share.Items), &items); err != nil {
+			util.Log().Warning("Failed to parse items of share %d: %s", share.ID, err)
+		}
+	}
+	return items
+}
+
+// SetItems 设定多项分享所选的目录与文件
+func (share *Share) SetItems(items ShareItems) {
+	res, _ := json.Marshal(items)
+	share.Items = string(res)
+}
+
+// ItemNames 返回多项分享根目录下可见对象的名称
+func (share *Share) ItemNames() map[string]bool {
+	items := share.SelectedItems()
+	res := make(map[string]bool, len(items.Dirs)+len(items.Files))
+	if len(items.Dirs) > 0 {
+		folders, _ := GetFoldersByIDs(items.Dirs, share.UserID)
+		for _, folder := range folders {
+			if folder.ParentID != nil && *folder.ParentID == share.SourceID {
+				res[folder.Name] = true
+			}
+		}
+	}
+	if len(items.Files) > 0 {
+		files, _ := GetFilesByIDs(items.Files, share.UserID)
+		for _, file := range files {
+			if file.FolderID == share.SourceID {
+				res[file.Name] = true
+			}
+		}
+	}
+	return res
+}
+
+// AllowPath 返回分享目录下的路径是否可被访问，多项分享只能访问所选对象及其子对象
+func (share *Share) AllowPath(p string) bool {
+	if !share.IsMultiItem() {
+		return true
+	}
+
+	parts := util.SplitPath(path.Clean("/" + p))
+	if len(parts) < 2 {
+		return true
+	}
+	return share.ItemNames()[parts[1]]
+}
+
+// IsUploadOnly 返回是否为文件收集分享
+func (share *Share) IsUploadOnly() bool {
+	return share.Type == ShareTypeUpload
+}
+
+// IsGallery 返回是否为站点展示分享
+func (share *Share) IsGallery() bool {
+	return share.Type == ShareTypeGallery
+}
+
+// GalleryURL 返回站点展示的访问地址
+func (share *Share) GalleryURL() *url.URL {
+	galleryPath, _ := url.Parse("/g/" + share.Key() + "/")
+	return GetSiteURL().ResolveReference(galleryPath)
+}
+
+// UploadSizeAllowed 返回文件收集是否接受 size 大小的文件
+func (share *Share) UploadSizeAllowed(size uint64) bool {
+	return share.UploadMaxSize == 0 || size <= share.UploadMaxSize
+}
+
+// UploadTypeAllowed 返回文件收集是否接受给定文件名的扩展名
+func (share *Share) UploadTypeAllowed(name string) bool {
+	if share.UploadExts == "" {
+		return true
+	}
+
+	exts := strings.Split(strings.ToLower(share.UploadExts), ",")
+	for i := range exts {
+		exts[i] = strings.TrimPrefix(strings.TrimSpace(exts[i]), ".")
+	}
+	return util.IsInExtensionList(exts, name)
+}
+
+// AddTraffic 增加分享已使用的流量，剩余流量不足以下载 size 大小的文件时返回 ErrShareTrafficExceeded。
+// 检查与计入在同一条语句中完成，并发下载不会超出限额
+func (share *Share) AddTraffic(size uint64) error {
+	if size == 0 {
+		return nil
+	}
+
+	result := DB.Model(&Share{}).
+		Where("id = ? and (traffic_limit = 0 or traffic + ? <= traffic_limit)", share.ID, size).
+		UpdateColumn("traffic", gorm.Expr("traffic + ?", size))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrShareTrafficExceeded
+	}
+
+	share.Traffic += size
+	return nil
+}
+
 // Viewed 增加访问次数
 func (share *Share) Viewed() {
 	share.Views++
@@ -172,13 +388,12 @@ func (share *Share) Viewed() {
 // Downloaded 增加下载次数
 func (share *Share) Downloaded() {
 	share.Downloads++
+	updates := map[string]interface{}{"downloads": gorm.Expr("downloads + ?", 1)}
 	if share.RemainDownloads > 0 {
 		share.RemainDownloads--
+		updates["remain_downloads"] = gorm.Expr("remain_downloads - ?", 1)
 	}
-	DB.Model(share).Updates(map[string]interface{}{
-		"downloads":        share.Downloads,
-		"remain_downloads": share.RemainDownloads,
-	})
+	DB.Model(share).Updates(updates)
 }
 
 // Update 更新分享属性
@@ -188,12 +403,28 @@ func (share *Share) Update(props map[string]interface{}) error {
 
 // Delete 删除分享
 func (share *Share) Delete() error {
-	return DB.Model(share).Delete(share).Error
+	return DeleteShares(DB.Where("id = ?", share.ID))
 }
 
 // DeleteShareBySourceIDs 根据原始资源类型和ID删除文件
 func DeleteShareBySourceIDs(sources []uint, isDir bool) error {
-	return DB.Where("source_id in (?) and is_dir = ?", sources, isDir).Delete(&Share{}).Error
+	return DeleteShares(DB.Where("source_id in (?) and is_dir = ?", sources, isDir))
+}
+
+// DeleteShares 软删除 tx 条件匹配的分享。自定义链接上有唯一索引，
+// 删除时一并清空，使已删除的分享不再占用其自定义链接
+func DeleteShares(tx *gorm.DB) error {
+	return tx.Model(&Share{}).UpdateColumns(map[string]interface{}{
+		"deleted_at": time.Now(),
+		"slug":       nil,
+	}).Error
+}
+
+// DisableSharesBySourceID 停用指定源对象的所有分享，返回受影响的分享数
+func DisableSharesBySourceID(source uint, isDir bool) (int64, error) {
+	result := DB.Model(&Share{}).Where("source_id = ? and is_dir = ?", source, isDir).
+		Updates(map[string]interface{}{"disabled": true, "quota_disabled": false})
+	return result.RowsAffected, result.Error
 }
 
 // ListShares 列出UID下的分享
@@ -202,10 +433,10 @@ func ListShares(uid uint, page, pageSize int, order string, publicOnly bool) ([]
 		shares []Share
 		total  int
 	)
-	dbChain := DB
+	dbChain := ReadDB()
 	dbChain = dbChain.Where("user_id = ?", uid)
 	if publicOnly {
-		dbChain = dbChain.Where("password = ?", "")
+		dbChain = dbChain.Where("password = ? and (starts_at is NULL or starts_at <= ?)", "", time.Now())
 	}
 
 	// 计算总数用于分页
@@ -216,8 +447,8 @@ func ListShares(uid uint, page, pageSize int, order string, publicOnly bool) ([]
 	return shares, total
 }
 
-// SearchShares 根据关键字搜索分享
-func SearchShares(page, pageSize int, order, keywords string) ([]Share, int) {
+// SearchShares 根据关键字搜索指定租户下用户创建的分享
+func SearchShares(tenantID uint, page, pageSize int, order, keywords string) ([]Share, int) {
 	var (
 		shares []Share
 		total  int
@@ -234,8 +465,10 @@ func SearchShares(page, pageSize int, order, keywords string) ([]Share, int) {
 		return shares, 0
 	}
 
-	dbChain := DB
-	dbChain = dbChain.Where("password = ? and remain_downloads <> 0 and (expires is NULL or expires > ?) and source_name like ?", "", time.Now(), "%"+strings.Join(availableList, "%")+"%")
+	dbChain := ReadDB().Scopes(TenantOwned("user_id", tenantID))
+	now := time.Now()
+	dbChain = dbChain.Where("password = ? and remain_downloads <> 0 and (expires is NULL or expires > ?) and (starts_at is NULL or starts_at <= ?) and source_name like ?",
+		"", now, now, "%"+strings.Join(availableList, "%")+"%")
 
 	// 计算总数用于分页
 	dbChain.Model(&Share{}).Count(&total)
@@ -244,3 +477,11 @@ func SearchShares(page, pageSize int, order, keywords string) ([]Share, int) {
 	dbChain.Limit(pageSize).Offset((page - 1) * pageSize).Order(order).Find(&shares)
 	return shares, total
 }
+
+// GetExpiredSharesToHandle 列出已过期且设定了到期处理、尚未处理的分享
+func GetExpiredSharesToHandle(now time.Time, limit int) ([]Share, error) {
+	var shares []Share
+	result := DB.Where("expires < ? and expire_action <> ? and expire_handled = ?", now, ShareExpireKeep, false).
+		Order("expires asc").Limit(limit).Find(&shares)
+	return shares, result.Error
+}
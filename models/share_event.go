@@ -0,0 +1,120 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 分享访问事件类型
+const (
+	ShareEventView     = "view"
+	ShareEventDownload = "download"
+)
+
+// ShareEvent 分享访问事件
+type ShareEvent struct {
+	gorm.Model
+	ShareID  uint `gorm:"index:share_event"`
+	Type     string
+	FileName string
+	Referrer string `gorm:"size:1024"`
+	IPHash   string
+	Country  string
+}
+
+// ShareDailyStat 分享每日访问统计
+type ShareDailyStat struct {
+	Date      string `json:"date"`
+	Views     int    `json:"views"`
+	Downloads int    `json:"downloads"`
+}
+
+// ShareEventTop 分享访问排行条目
+type ShareEventTop struct {
+	Value string `json:"value"`
+	Total int    `json:"total"`
+}
+
+// Create 记录分享访问事件
+func (event *ShareEvent) Create() error {
+	return DB.Create(event).Error
+}
+
+// ListShareEvents 列出分享的原始访问事件
+func ListShareEvents(shareID uint, page, pageSize int) ([]ShareEvent, int) {
+	var (
+		res   []ShareEvent
+		total int
+	)
+	dbChain := DB.Model(&ShareEvent{}).Where("share_id = ?", shareID)
+	dbChain.Count(&total)
+	dbChain.Order("id desc").Limit(pageSize).Offset((page - 1) * pageSize).Find(&res)
+
+	return res, total
+}
+
+// GetShareDailyStats 按日统计分享自 since 起的浏览与下载次数，日期按服务器时区计算
+func GetShareDailyStats(shareID uint, since time.Time) ([]ShareDailyStat, error) {
+	rows, err := DB.Model(&ShareEvent{}).Select("type, created_at").
+		Where("share_id = ? and created_at >= ?", shareID, since).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]*ShareDailyStat)
+	for rows.Next() {
+		var (
+			typ       string
+			createdAt time.Time
+		)
+		if err := rows.Scan(&typ, &createdAt); err != nil {
+			return nil, err
+		}
+
+		date := createdAt.Local().Format("2006-01-02")
+		if _, ok := stats[date]; !ok {
+			stats[date] = &ShareDailyStat{Date: date}
+		}
+		switch typ {
+		case ShareEventView:
+			stats[date].Views++
+		case ShareEventDownload:
+			stats[date].Downloads++
+		}
+	}
+
+	// 补全没有访问的日期
+	res := make([]ShareDailyStat, 0)
+	for day := since.Local(); !day.After(time.Now()); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if stat, ok := stats[date]; ok {
+			res = append(res, *stat)
+		} else {
+			res = append(res, ShareDailyStat{Date: date})
+		}
+	}
+
+	return res, rows.Err()
+}
+
+// GetShareEventTop 统计自 since 起指定类型事件中 column 取值的排行，column 仅限 file_name、referrer、country
+func GetShareEventTop(shareID uint, typ, column string, since time.Time, limit int) []ShareEventTop {
+	switch column {
+	case "file_name", "referrer", "country":
+	default:
+		return nil
+	}
+
+	res := make([]ShareEventTop, 0, limit)
+	DB.Model(&ShareEvent{}).Select(column+" as value, count(*) as total").
+		Where("share_id = ? and type = ? and created_at >= ? and "+column+" <> ''", shareID, typ, since).
+		Group(column).Order("total desc").Limit(limit).Scan(&res)
+	return res
+}
+
+// DeleteShareEventsBefore 删除 before 之前的分享访问事件
+func DeleteShareEventsBefore(before time.Time) error {
+	return DB.Unscoped().Where("created_at < ?", before).Delete(&ShareEvent{}).Error
+}
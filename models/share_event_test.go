@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetShareDailyStats(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+	since := now.AddDate(0, 0, -2)
+
+	mock.ExpectQuery("SELECT(.+)share_events(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"type", "created_at"}).
+			AddRow(ShareEventView, now).
+			AddRow(ShareEventView, now).
+			AddRow(ShareEventDownload, now).
+			AddRow(ShareEventView, since))
+	res, err := GetShareDailyStats(1, since)
+	asserts.NoError(err)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Len(res, 3)
+	asserts.Equal(ShareDailyStat{Date: since.Format("2006-01-02"), Views: 1}, res[0])
+	asserts.Equal(ShareDailyStat{Date: since.AddDate(0, 0, 1).Format("2006-01-02")}, res[1])
+	asserts.Equal(ShareDailyStat{Date: now.Format("2006-01-02"), Views: 2, Downloads: 1}, res[2])
+}
+
+func TestGetShareEventTop(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 不允许的字段
+	asserts.Nil(GetShareEventTop(1, ShareEventView, "ip_hash", time.Now(), 10))
+
+	mock.ExpectQuery("SELECT file_name as value, count(.+)GROUP BY file_name").
+		WillReturnRows(sqlmock.NewRows([]string{"value", "total"}).AddRow("a.txt", 3).AddRow("b.txt", 1))
+	res := GetShareEventTop(1, ShareEventDownload, "file_name", time.Now(), 10)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal([]ShareEventTop{{"a.txt", 3}, {"b.txt", 1}}, res)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
 	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
 	"github.com/gin-gonic/gin"
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/assert"
@@ -65,10 +66,61 @@ func TestGetShareByHashID(t *testing.T) {
 
 	// ID解码失败
 	{
+		mock.ExpectQuery("SELECT(.+)slug(.+)").
+			WillReturnError(errors.New("not found"))
 		res := GetShareByHashID("empty")
+		asserts.NoError(mock.ExpectationsWereMet())
 		asserts.Nil(res)
 	}
 
+	// 自定义链接
+	{
+		mock.ExpectQuery("SELECT(.+)slug(.+)").
+			WithArgs("q3-report").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow(2, "q3-report"))
+		res := GetShareByHashID("Q3-Report")
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NotNil(res)
+		asserts.EqualValues(2, res.ID)
+	}
+
+}
+
+func TestShare_Key(t *testing.T) {
+	asserts := assert.New(t)
+	conf.SystemConfig.HashIDSalt = ""
+	share := Share{}
+	share.ID = 1
+
+	asserts.Equal(hashid.HashID(1, hashid.ShareID), share.Key())
+
+	slug := "q3-report"
+	share.Slug = &slug
+	asserts.Equal("q3-report", share.Key())
+}
+
+func TestShare_GalleryURL(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_siteURL", "https://cloudreve.org", 0)
+	slug := "releases"
+	share := Share{Slug: &slug, Type: ShareTypeGallery}
+
+	asserts.True(share.IsGallery())
+	asserts.False(share.IsUploadOnly())
+	asserts.Equal("https://cloudreve.org/g/releases/", share.GalleryURL().String())
+}
+
+func TestIsShareSlugUsed(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)").WithArgs("q3-report", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+	asserts.True(IsShareSlugUsed("q3-report", 1))
+
+	mock.ExpectQuery("SELECT(.+)").WithArgs("q3-report", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+	asserts.False(IsShareSlugUsed("q3-report", 0))
+	asserts.NoError(mock.ExpectationsWereMet())
 }
 
 func TestShare_IsAvailable(t *testing.T) {
@@ -90,6 +142,17 @@ func TestShare_IsAvailable(t *testing.T) {
 		asserts.False(share.IsAvailable())
 	}
 
+	// 未到开始时间
+	{
+		startsAt := time.Now().Add(time.Hour)
+		share := Share{
+			RemainDownloads: -1,
+			StartsAt:        &startsAt,
+		}
+		asserts.False(share.IsStarted())
+		asserts.False(share.IsAvailable())
+	}
+
 	// 源对象为目录，但不存在
 	{
 		share := Share{
@@ -275,7 +338,8 @@ func TestShare_UpdateAndDelete(t *testing.T) {
 
 	{
 		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE(.+)").
+		mock.ExpectExec("UPDATE(.+)shares(.+)deleted_at(.+)slug(.+)").
+			WithArgs(sqlmock.AnyArg(), nil, 1).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 		err := share.Delete()
@@ -285,7 +349,7 @@ func TestShare_UpdateAndDelete(t *testing.T) {
 
 	{
 		mock.ExpectBegin()
-		mock.ExpectExec("UPDATE(.+)").
+		mock.ExpectExec("UPDATE(.+)shares(.+)deleted_at(.+)slug(.+)").
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 		err := DeleteShareBySourceIDs([]uint{1}, true)
@@ -311,11 +375,142 @@ func TestSearchShares(t *testing.T) {
 	asserts := assert.New(t)
 
 	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-	mock.ExpectQuery("SELECT(.+)").
-		WithArgs("", sqlmock.AnyArg(), "%1%2%").
+	mock.ExpectQuery("SELECT(.+)user_id in \\(SELECT id FROM `users` WHERE \\(tenant_id = \\?\\)\\)(.+)").
+		WithArgs(2, "", sqlmock.AnyArg(), sqlmock.AnyArg(), "%1%2%").
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
-	res, total := SearchShares(1, 10, "id", "1 2")
+	res, total := SearchShares(2, 1, 10, "id", "1 2")
 	asserts.NoError(mock.ExpectationsWereMet())
 	asserts.Len(res, 1)
 	asserts.Equal(1, total)
 }
+
+func TestGetExpiredSharesToHandle(t *testing.T) {
+	asserts := assert.New(t)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT(.+)shares(.+)").
+		WithArgs(now, ShareExpireKeep, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "expire_action"}).AddRow(1, ShareExpireDisable))
+	shares, err := GetExpiredSharesToHandle(now, 10)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(shares, 1)
+	asserts.Equal(ShareExpireDisable, shares[0].ExpireAction)
+}
+
+func TestShare_Traffic(t *testing.T) {
+	asserts := assert.New(t)
+	share := Share{TrafficLimit: 100, Traffic: 60}
+	share.ID = 1
+
+	// 不计入空流量
+	asserts.NoError(share.AddTraffic(0))
+
+	// 剩余流量充足
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)traffic(.+)traffic_limit = 0 or traffic \\+ (.+) <= traffic_limit").
+		WithArgs(20, 1, 20).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	asserts.NoError(share.AddTraffic(20))
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.EqualValues(80, share.Traffic)
+
+	// 剩余流量不足
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)traffic(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	asserts.Equal(ErrShareTrafficExceeded, share.AddTraffic(21))
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.EqualValues(80, share.Traffic)
+
+	// 数据库错误
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)traffic(.+)").WillReturnError(errors.New("error"))
+	mock.ExpectRollback()
+	asserts.Error(share.AddTraffic(1))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+func TestShare_UploadAllowed(t *testing.T) {
+	asserts := assert.New(t)
+	share := Share{Type: ShareTypeUpload}
+
+	asserts.True(share.IsUploadOnly())
+	asserts.False((&Share{}).IsUploadOnly())
+
+	// 无限制
+	asserts.True(share.UploadSizeAllowed(1 << 40))
+	asserts.True(share.UploadTypeAllowed("a.exe"))
+
+	share.UploadMaxSize = 10
+	share.UploadExts = "pdf, .DOCX"
+	asserts.True(share.UploadSizeAllowed(10))
+	asserts.False(share.UploadSizeAllowed(11))
+	asserts.True(share.UploadTypeAllowed("report.PDF"))
+	asserts.True(share.UploadTypeAllowed("report.docx"))
+	asserts.False(share.UploadTypeAllowed("report.exe"))
+	asserts.False(share.UploadTypeAllowed("report"))
+}
+
+func TestShare_MultiItem(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 非多项分享
+	{
+		share := Share{}
+		asserts.False(share.IsMultiItem())
+		asserts.True(share.AllowPath("/any/path"))
+	}
+
+	share := Share{UserID: 1, SourceID: 2, IsDir: true}
+	share.SetItems(ShareItems{Dirs: []uint{3}, Files: []uint{4}})
+	asserts.True(share.IsMultiItem())
+	asserts.Equal([]uint{3}, share.SelectedItems().Dirs)
+	asserts.Equal([]uint{4}, share.SelectedItems().Files)
+
+	// 根目录
+	asserts.True(share.AllowPath("/"))
+
+	// 所选目录下的对象
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(3, "docs", 2))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(4, "a.txt", 2))
+		asserts.True(share.AllowPath("/docs/sub/b.txt"))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 未选中的对象
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(3, "docs", 2))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(4, "a.txt", 2))
+		asserts.False(share.AllowPath("/other.txt"))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestShare_Downloaded(t *testing.T) {
+	asserts := assert.New(t)
+	share := Share{Model: gorm.Model{ID: 1}, RemainDownloads: 2}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)shares(.+)downloads(.+)downloads \\+(.+)remain_downloads(.+)remain_downloads -").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	share.Downloaded()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(1, share.Downloads)
+	asserts.Equal(1, share.RemainDownloads)
+
+	// 不限制下载次数时不修改剩余次数
+	share.RemainDownloads = -1
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)shares(.+)downloads \\+").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	share.Downloaded()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(-1, share.RemainDownloads)
+}
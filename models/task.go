@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/jinzhu/gorm"
 )
@@ -14,6 +16,14 @@ type Task struct {
 	Progress int    // 进度
 	Error    string `gorm:"type:text"` // 错误信息
 	Props    string `gorm:"type:text"` // 任务属性
+	// Attempts 已重试次数
+	Attempts int
+	// NextRunAt 失败重试时，任务最早可再次执行的时间
+	NextRunAt *time.Time `gorm:"index:next_run_at"`
+	// Owner 领取任务的队列实例
+	Owner string
+	// HeartbeatAt 领取任务的实例最后一次确认任务仍在执行的时间
+	HeartbeatAt *time.Time
 }
 
 // Create 创建任务记录
@@ -40,6 +50,60 @@ func (task *Task) SetError(err string) error {
 	return DB.Model(task).Select("error").Updates(map[string]interface{}{"error": err}).Error
 }
 
+// SetProps 设定任务属性
+func (task *Task) SetProps(props string) error {
+	return DB.Model(task).Select("props").Updates(map[string]interface{}{"props": props}).Error
+}
+
+// Retry 记录一次失败重试，任务在 next 之前不会被再次调度
+func (task *Task) Retry(next time.Time) error {
+	task.Attempts++
+	task.NextRunAt = &next
+	return DB.Model(task).Select("attempts", "next_run_at").Updates(map[string]interface{}{
+		"attempts":    task.Attempts,
+		"next_run_at": next,
+	}).Error
+}
+
+// GetDueTasks 按创建顺序检索处于给定状态、且已到执行时间的任务
+func GetDueTasks(status int, now time.Time, limit int) []Task {
+	var tasks []Task
+	DB.Where("status = ? and (next_run_at is null or next_run_at <= ?)", status, now).
+		Order("id asc").Limit(limit).Find(&tasks)
+	return tasks
+}
+
+// ClaimTask 仅当任务仍处于 from 状态时将其更新为 to 状态，返回是否更新成功，
+// 用于避免同一任务被重复调度
+func ClaimTask(id uint, from, to int) bool {
+	result := DB.Model(&Task{}).Where("id = ? and status = ?", id, from).Update("status", to)
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// ClaimTaskAs 与 ClaimTask 相同，同时记录领取任务的实例及心跳时间
+func ClaimTaskAs(id uint, from, to int, owner string, now time.Time) bool {
+	result := DB.Model(&Task{}).Where("id = ? and status = ?", id, from).Updates(map[string]interface{}{
+		"status":       to,
+		"owner":        owner,
+		"heartbeat_at": now,
+	})
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// TouchTasks 更新实例领取的、处于给定状态的任务的心跳时间
+func TouchTasks(owner string, status int, now time.Time) error {
+	return DB.Model(&Task{}).Where("owner = ? and status = ?", owner, status).
+		UpdateColumn("heartbeat_at", now).Error
+}
+
+// ResetStaleTasks 将处于 from 状态、且心跳早于 before 的任务更新为 to 状态，返回受影响的任务数。
+// 没有心跳记录的任务视为已失去领取者
+func ResetStaleTasks(from, to int, before time.Time) (int64, error) {
+	result := DB.Model(&Task{}).Where("status = ? and (heartbeat_at is null or heartbeat_at < ?)", from, before).
+		Update("status", to)
+	return result.RowsAffected, result.Error
+}
+
 // GetTasksByStatus 根据状态检索任务
 func GetTasksByStatus(status ...int) []Task {
 	var tasks []Task
@@ -6,6 +6,7 @@ import (
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestTask_Create(t *testing.T) {
@@ -102,3 +103,52 @@ func TestGetTasksByStatus(t *testing.T) {
 	a.NoError(mock.ExpectationsWereMet())
 	a.Len(res, 1)
 }
+
+func TestTask_Retry(t *testing.T) {
+	a := assert.New(t)
+	task := Task{
+		Model:    gorm.Model{ID: 1},
+		Attempts: 1,
+	}
+	next := time.Now().Add(time.Minute)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	a.NoError(task.Retry(next))
+	a.NoError(mock.ExpectationsWereMet())
+	a.Equal(2, task.Attempts)
+	a.Equal(next, *task.NextRunAt)
+}
+
+func TestClaimTask(t *testing.T) {
+	a := assert.New(t)
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WithArgs(1, sqlmock.AnyArg(), 1, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		a.True(ClaimTask(1, 0, 1))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 已被领取
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		a.False(ClaimTask(1, 0, 1))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestClaimTaskAs(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)tasks(.+)").WithArgs(now, "instance", 1, sqlmock.AnyArg(), 1, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	a.True(ClaimTaskAs(1, 0, 1, "instance", now))
+	a.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,240 @@
+package model
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+)
+
+// tenantsCacheKey 租户列表的缓存键
+const tenantsCacheKey = "tenants"
+
+// TenantSettings 租户可覆盖的站点设置，包括品牌及注册相关设置
+var TenantSettings = []string{
+	"siteName",
+	"siteTitle",
+	"siteDes",
+	"siteKeywords",
+	"siteScript",
+	"defaultTheme",
+	"themes",
+	"pwa_small_icon",
+	"pwa_medium_icon",
+	"pwa_large_icon",
+	"pwa_display",
+	"pwa_theme_color",
+	"pwa_background_color",
+	"register_enabled",
+	"default_group",
+}
+
+var (
+	// ErrTenantMismatch 关联的对象不属于同一租户
+	ErrTenantMismatch = errors.New("object belongs to another tenant")
+	// ErrTenantSetting 设置项不允许由租户覆盖
+	ErrTenantSetting = errors.New("setting cannot be overridden by tenant")
+)
+
+// Tenant 租户，同一实例按访问域名服务多个租户，各租户拥有独立的用户、用户组及存储策略。
+// ID 为 0 的默认租户即未绑定任何域名的主站点，不在数据表中存储。
+type Tenant struct {
+	gorm.Model
+	Name     string
+	Domain   string `gorm:"type:varchar(255);unique_index:tenant_domain"`
+	Settings string `gorm:"type:text"`
+
+	// 数据库忽略字段
+	SettingsSerialized map[string]string `gorm:"-"`
+}
+
+func init() {
+	gob.Register([]Tenant{})
+}
+
+// AfterFind 找到租户后的钩子
+func (tenant *Tenant) AfterFind() (err error) {
+	if tenant.Settings != "" {
+		err = json.Unmarshal([]byte(tenant.Settings), &tenant.SettingsSerialized)
+	}
+	if tenant.SettingsSerialized == nil {
+		tenant.SettingsSerialized = map[string]string{}
+	}
+	return err
+}
+
+// BeforeSave 保存租户前的钩子，只保留可覆盖的设置项
+func (tenant *Tenant) BeforeSave() error {
+	tenant.Domain = NormalizeTenantDomain(tenant.Domain)
+	for name := range tenant.SettingsSerialized {
+		if !IsTenantSetting(name) {
+			return ErrTenantSetting
+		}
+	}
+
+	settings, err := json.Marshal(tenant.SettingsSerialized)
+	tenant.Settings = string(settings)
+	return err
+}
+
+// AfterSave 保存租户后清除租户缓存
+func (tenant *Tenant) AfterSave() error {
+	ClearTenantCache()
+	return nil
+}
+
+// Create 创建租户
+func (tenant *Tenant) Create() error {
+	return DB.Create(tenant).Error
+}
+
+// Delete 删除租户，租户下仍有用户、用户组或存储策略时无法删除
+func (tenant *Tenant) Delete() error {
+	for _, table := range []interface{}{&User{}, &Group{}, &Policy{}} {
+		var count int
+		if err := DB.Model(table).Where("tenant_id = ?", tenant.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("tenant is not empty")
+		}
+	}
+
+	defer ClearTenantCache()
+	return DB.Unscoped().Delete(tenant).Error
+}
+
+// GetSettingByNames 获取站点设置，租户覆盖的设置项优先。tenant 为 nil 时即默认租户
+func (tenant *Tenant) GetSettingByNames(names ...string) map[string]string {
+	res := GetSettingByNames(names...)
+	if tenant == nil {
+		return res
+	}
+
+	for _, name := range names {
+		if val, ok := tenant.SettingsSerialized[name]; ok {
+			res[name] = val
+		}
+	}
+	return res
+}
+
+// GetSettingByName 获取单项站点设置，租户覆盖的设置项优先
+func (tenant *Tenant) GetSettingByName(name string) string {
+	return tenant.GetSettingByNames(name)[name]
+}
+
+// TenantID 返回租户 ID，nil 即默认租户，返回 0
+func (tenant *Tenant) TenantID() uint {
+	if tenant == nil {
+		return 0
+	}
+	return tenant.ID
+}
+
+// IsTenantSetting 返回设置项是否可由租户覆盖
+func IsTenantSetting(name string) bool {
+	for _, s := range TenantSettings {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTenantDomain 规范化域名，去除端口并转换为小写
+func NormalizeTenantDomain(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.LastIndex(host, ":"); i > strings.LastIndex(host, "]") {
+		host = host[:i]
+	}
+	return host
+}
+
+// GetTenantByDomain 根据访问域名查找租户，未绑定租户的域名返回 nil
+func GetTenantByDomain(host string) (*Tenant, error) {
+	host = NormalizeTenantDomain(host)
+	if host == "" {
+		return nil, nil
+	}
+
+	// 租户数量有限，整体缓存以免每个请求都查询数据库
+	var tenants []Tenant
+	if cached, ok := cache.Get(tenantsCacheKey); ok {
+		tenants = cached.([]Tenant)
+	} else {
+		var err error
+		if tenants, err = ListTenants(); err != nil {
+			return nil, err
+		}
+		_ = cache.Set(tenantsCacheKey, tenants, -1)
+	}
+
+	for i := range tenants {
+		if tenants[i].Domain == host {
+			return &tenants[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// GetTenantByID 根据 ID 获取租户
+func GetTenantByID(id uint) (*Tenant, error) {
+	var tenant Tenant
+	result := DB.First(&tenant, id)
+	return &tenant, result.Error
+}
+
+// ListTenants 列出所有租户
+func ListTenants() ([]Tenant, error) {
+	var tenants []Tenant
+	result := DB.Order("id asc").Find(&tenants)
+	return tenants, result.Error
+}
+
+// ClearTenantCache 清除租户缓存
+func ClearTenantCache() {
+	cache.Deletes([]string{tenantsCacheKey}, "")
+}
+
+// CheckGroupTenant 检查用户组是否属于指定租户
+func CheckGroupTenant(groupID, tenantID uint) error {
+	group, err := GetGroupByID(groupID)
+	if err != nil {
+		return err
+	}
+	if group.TenantID != tenantID {
+		return ErrTenantMismatch
+	}
+	return nil
+}
+
+// CheckPoliciesTenant 检查存储策略是否均属于指定租户
+func CheckPoliciesTenant(policyIDs []uint, tenantID uint) error {
+	for _, id := range policyIDs {
+		policy, err := GetPolicyByID(id)
+		if err != nil {
+			return err
+		}
+		if policy.TenantID != tenantID {
+			return ErrTenantMismatch
+		}
+	}
+	return nil
+}
+
+// TenantOwned 限定查询结果为指定租户下用户所有的记录，column 为记录中所有者 ID 的列名
+func TenantOwned(column string, tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(column+" in (?)", DB.Table("users").Select("id").Where("tenant_id = ?", tenantID).QueryExpr())
+	}
+}
+
+// IsUserInTenant 返回用户是否存在且属于指定租户
+func IsUserInTenant(uid, tenantID uint) bool {
+	user, err := GetUserByID(uid)
+	return err == nil && user.TenantID == tenantID
+}
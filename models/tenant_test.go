@@ -0,0 +1,127 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTenantDomain(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("cloudreve.org", NormalizeTenantDomain(" Cloudreve.ORG:5212 "))
+	a.Equal("cloudreve.org", NormalizeTenantDomain("cloudreve.org"))
+	a.Equal("[::1]", NormalizeTenantDomain("[::1]:5212"))
+	a.Equal("[::1]", NormalizeTenantDomain("[::1]"))
+}
+
+func TestTenant_GetSettingByNames(t *testing.T) {
+	a := assert.New(t)
+	cache.Set("setting_siteName", "Cloudreve", 0)
+	cache.Set("setting_siteTitle", "Title", 0)
+
+	// 默认租户
+	var tenant *Tenant
+	a.Equal("Cloudreve", tenant.GetSettingByName("siteName"))
+	a.EqualValues(0, tenant.TenantID())
+
+	// 租户覆盖的设置项优先
+	tenant = &Tenant{SettingsSerialized: map[string]string{"siteName": "Tenant"}}
+	res := tenant.GetSettingByNames("siteName", "siteTitle")
+	a.Equal("Tenant", res["siteName"])
+	a.Equal("Title", res["siteTitle"])
+}
+
+func TestTenant_BeforeSave(t *testing.T) {
+	a := assert.New(t)
+
+	tenant := &Tenant{Domain: "Tenant.Cloudreve.org", SettingsSerialized: map[string]string{"siteName": "Tenant"}}
+	a.NoError(tenant.BeforeSave())
+	a.Equal("tenant.cloudreve.org", tenant.Domain)
+	a.Equal(`{"siteName":"Tenant"}`, tenant.Settings)
+
+	// 不可覆盖的设置项
+	tenant.SettingsSerialized["secret_key"] = "123"
+	a.ErrorIs(tenant.BeforeSave(), ErrTenantSetting)
+}
+
+func TestGetTenantByDomain(t *testing.T) {
+	a := assert.New(t)
+	cache.Deletes([]string{tenantsCacheKey}, "")
+
+	mock.ExpectQuery("SELECT(.+)tenants").WillReturnRows(sqlmock.NewRows([]string{"id", "domain", "settings"}).
+		AddRow(2, "tenant.cloudreve.org", `{"siteName":"Tenant"}`))
+	tenant, err := GetTenantByDomain("tenant.cloudreve.org:443")
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.EqualValues(2, tenant.ID)
+	a.Equal("Tenant", tenant.SettingsSerialized["siteName"])
+
+	// 使用缓存
+	tenant, err = GetTenantByDomain("cloudreve.org")
+	a.NoError(err)
+	a.Nil(tenant)
+
+	ClearTenantCache()
+	_, ok := cache.Get(tenantsCacheKey)
+	a.False(ok)
+}
+
+func TestTenant_Delete(t *testing.T) {
+	a := assert.New(t)
+	tenant := &Tenant{}
+	tenant.ID = 2
+
+	// 租户下仍有用户
+	mock.ExpectQuery("SELECT count(.+)users").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	a.Error(tenant.Delete())
+	a.NoError(mock.ExpectationsWereMet())
+
+	// 删除成功
+	mock.ExpectQuery("SELECT count(.+)users").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT count(.+)groups").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT count(.+)policies").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE(.+)tenants").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	a.NoError(tenant.Delete())
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestCheckGroupTenant(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)groups").WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(4, 2))
+	a.NoError(CheckGroupTenant(4, 2))
+	a.NoError(mock.ExpectationsWereMet())
+
+	mock.ExpectQuery("SELECT(.+)groups").WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(4, 2))
+	a.ErrorIs(CheckGroupTenant(4, 0), ErrTenantMismatch)
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestCheckPoliciesTenant(t *testing.T) {
+	a := assert.New(t)
+	cache.Set("policy_41", Policy{TenantID: 2}, 0)
+	cache.Set("policy_42", Policy{}, 0)
+
+	a.NoError(CheckPoliciesTenant([]uint{41}, 2))
+	a.ErrorIs(CheckPoliciesTenant([]uint{41, 42}, 2), ErrTenantMismatch)
+}
+
+func TestIsUserInTenant(t *testing.T) {
+	a := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(1, 2))
+	a.True(IsUserInTenant(1, 2))
+	a.NoError(mock.ExpectationsWereMet())
+
+	mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "tenant_id"}).AddRow(1, 2))
+	a.False(IsUserInTenant(1, 0))
+	a.NoError(mock.ExpectationsWereMet())
+
+	mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	a.False(IsUserInTenant(1, 0))
+	a.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ThumbRecord 缩略图缓存记录，用于统计缓存占用并按最近访问时间淘汰。
+// 缩略图占用的空间单独统计，不计入用户的已用容量
+type ThumbRecord struct {
+	gorm.Model
+	FileID     uint `gorm:"unique_index:thumb_file"`
+	PolicyID   uint `gorm:"index:thumb_policy"`
+	Size       uint64
+	AccessedAt time.Time `gorm:"index:thumb_accessed"`
+}
+
+// SaveThumbRecord 新建或更新文件的缩略图缓存记录
+func SaveThumbRecord(fileID, policyID uint, size uint64) error {
+	record := ThumbRecord{}
+	return DB.Where(ThumbRecord{FileID: fileID}).
+		Assign(ThumbRecord{PolicyID: policyID, Size: size, AccessedAt: time.Now()}).
+		FirstOrCreate(&record).Error
+}
+
+// TouchThumbRecord 更新缩略图的最近访问时间
+func TouchThumbRecord(fileID uint) error {
+	return DB.Model(&ThumbRecord{}).Where("file_id = ?", fileID).Update("accessed_at", time.Now()).Error
+}
+
+// GetThumbCacheSize 返回存储策略下缩略图缓存的数量及总大小，policyID 为 0 时统计所有策略
+func GetThumbCacheSize(policyID uint) (int, uint64) {
+	var res struct {
+		Count int
+		Total uint64
+	}
+
+	dbChain := DB.Model(&ThumbRecord{})
+	if policyID > 0 {
+		dbChain = dbChain.Where("policy_id = ?", policyID)
+	}
+	dbChain.Select("count(id) as count, sum(size) as total").Scan(&res)
+	return res.Count, res.Total
+}
+
+// ListThumbRecords 按最近访问时间升序列出缩略图缓存记录，policyID 为 0 时列出所有策略
+func ListThumbRecords(policyID uint, limit int) ([]ThumbRecord, error) {
+	var records []ThumbRecord
+	dbChain := DB.Model(&ThumbRecord{})
+	if policyID > 0 {
+		dbChain = dbChain.Where("policy_id = ?", policyID)
+	}
+	result := dbChain.Order("accessed_at asc, id asc").Limit(limit).Find(&records)
+	return records, result.Error
+}
+
+// DeleteThumbRecordsByFileIDs 删除文件的缩略图缓存记录
+func DeleteThumbRecordsByFileIDs(fileIDs []uint) error {
+	return DB.Unscoped().Where("file_id in (?)", fileIDs).Delete(&ThumbRecord{}).Error
+}
+
+// DeleteOrphanThumbRecords 删除对应文件已不存在的缩略图缓存记录，返回删除的记录数
+func DeleteOrphanThumbRecords() (int64, error) {
+	result := DB.Unscoped().
+		Where("file_id not in (?)", DB.Model(&File{}).Select("id").QueryExpr()).
+		Delete(&ThumbRecord{})
+	return result.RowsAffected, result.Error
+}
+
+// CountThumbRecordsByFileIDs 返回给定文件中拥有缩略图缓存记录的数量
+func CountThumbRecordsByFileIDs(fileIDs []uint) int {
+	total := 0
+	DB.Model(&ThumbRecord{}).Where("file_id in (?)", fileIDs).Count(&total)
+	return total
+}
+
+// GetFilesWithUnavailableThumb 列出存储策略下被标记为无法生成缩略图的文件，policyID 为 0 时列出所有策略
+func GetFilesWithUnavailableThumb(policyID uint, limit int) ([]File, error) {
+	var files []File
+	dbChain := DB.Where("metadata like ?", "%\""+ThumbStatusMetadataKey+"\":\""+ThumbStatusNotAvailable+"\"%")
+	if policyID > 0 {
+		dbChain = dbChain.Where("policy_id = ?", policyID)
+	}
+	result := dbChain.Limit(limit).Find(&files)
+	return files, result.Error
+}
+
+// ClearThumb 清除文件的缩略图状态，下次访问时将重新生成
+func (file *File) ClearThumb() error {
+	if file.MetadataSerialized == nil {
+		return nil
+	}
+
+	delete(file.MetadataSerialized, ThumbStatusMetadataKey)
+	delete(file.MetadataSerialized, ThumbSidecarMetadataKey)
+	metaValue, err := json.Marshal(&file.MetadataSerialized)
+	if err != nil {
+		return err
+	}
+
+	file.Metadata = string(metaValue)
+	return DB.Model(&file).Set("gorm:association_autoupdate", false).UpdateColumns(File{Metadata: file.Metadata}).Error
+}
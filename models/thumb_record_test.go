@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetThumbCacheSize(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)thumb_records(.+)policy_id").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "total"}).AddRow(2, 1024))
+	count, total := GetThumbCacheSize(1)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(2, count)
+	asserts.EqualValues(1024, total)
+}
+
+func TestListThumbRecords(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)thumb_records(.+)ORDER BY accessed_at asc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_id", "size"}).AddRow(1, 2, 10).AddRow(2, 3, 20))
+	records, err := ListThumbRecords(0, 10)
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Len(records, 2)
+}
+
+func TestFile_ClearThumb(t *testing.T) {
+	asserts := assert.New(t)
+	file := &File{MetadataSerialized: map[string]string{
+		ThumbStatusMetadataKey:  ThumbStatusExist,
+		ThumbSidecarMetadataKey: "true",
+		"other":                 "value",
+	}}
+	file.ID = 1
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)files").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(file.ClearThumb())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(map[string]string{"other": "value"}, file.MetadataSerialized)
+}
+
+func TestDeleteOrphanThumbRecords(t *testing.T) {
+	a := assert.New(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE(.+)thumb_records(.+)file_id not in \\(SELECT id FROM `files`").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	removed, err := DeleteOrphanThumbRecords()
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.EqualValues(2, removed)
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// TrafficDateFormat 流量统计记录的日期格式
+const TrafficDateFormat = "2006-01-02"
+
+// Traffic 用户每日的上传、下载流量统计
+type Traffic struct {
+	gorm.Model
+	UserID   uint   `gorm:"unique_index:traffic_user_date"`
+	Date     string `gorm:"size:10;unique_index:traffic_user_date"`
+	Upload   uint64
+	Download uint64
+}
+
+// AddTraffic 将流量计入用户当日的统计记录
+func AddTraffic(uid uint, upload, download uint64) error {
+	if upload == 0 && download == 0 {
+		return nil
+	}
+
+	date := time.Now().Format(TrafficDateFormat)
+	update := func() *gorm.DB {
+		return DB.Model(&Traffic{}).Where("user_id = ? and date = ?", uid, date).UpdateColumns(map[string]interface{}{
+			"upload":   gorm.Expr("upload + ?", upload),
+			"download": gorm.Expr("download + ?", download),
+		})
+	}
+
+	if result := update(); result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	if err := DB.Create(&Traffic{UserID: uid, Date: date, Upload: upload, Download: download}).Error; err != nil {
+		// 并发创建当日记录时，唯一索引冲突后改为更新
+		return update().Error
+	}
+
+	return nil
+}
+
+// GetMonthlyTraffic 返回用户在 month 所在自然月内的上传、下载总流量
+func GetMonthlyTraffic(uid uint, month time.Time) (upload, download uint64) {
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	row := DB.Model(&Traffic{}).
+		Where("user_id = ? and date >= ? and date < ?", uid, start.Format(TrafficDateFormat), start.AddDate(0, 1, 0).Format(TrafficDateFormat)).
+		Select("coalesce(sum(upload), 0), coalesce(sum(download), 0)").
+		Row()
+	if row != nil {
+		row.Scan(&upload, &download)
+	}
+	return
+}
+
+// ListTraffic 按日期升序列出用户自 from 起的每日流量统计
+func ListTraffic(uid uint, from time.Time) ([]Traffic, error) {
+	var res []Traffic
+	result := DB.Where("user_id = ? and date >= ?", uid, from.Format(TrafficDateFormat)).Order("date asc").Find(&res)
+	return res, result.Error
+}
+
+// DeleteTrafficBefore 删除 before 之前的流量统计记录
+func DeleteTrafficBefore(before time.Time) error {
+	return DB.Unscoped().Where("date < ?", before.Format(TrafficDateFormat)).Delete(&Traffic{}).Error
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddTraffic(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 无流量
+	{
+		asserts.NoError(AddTraffic(1, 0, 0))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 更新已有记录
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)traffics(.+)download").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		asserts.NoError(AddTraffic(1, 0, 10))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 创建当日记录
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)traffics").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)traffics").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(AddTraffic(1, 10, 0))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 并发创建冲突
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)traffics").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)traffics").WillReturnError(errors.New("duplicated"))
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)traffics").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		asserts.NoError(AddTraffic(1, 10, 0))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestGetMonthlyTraffic(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)traffics(.+)").
+		WithArgs(1, "2022-12-01", "2023-01-01").
+		WillReturnRows(sqlmock.NewRows([]string{"upload", "download"}).AddRow(10, 20))
+	upload, download := GetMonthlyTraffic(1, time.Date(2022, 12, 15, 0, 0, 0, 0, time.Local))
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.EqualValues(10, upload)
+	asserts.EqualValues(20, download)
+}
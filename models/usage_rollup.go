@@ -0,0 +1,187 @@
+package model
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// 用量汇总指标
+const (
+	// RollupStoragePolicy 各存储策略的已用容量，Count 为文件数
+	RollupStoragePolicy = "storage_policy"
+	// RollupStorageGroup 各用户组的已用容量，Count 为用户数
+	RollupStorageGroup = "storage_group"
+	// RollupStorageUser 已用容量最多的用户
+	RollupStorageUser = "storage_user"
+	// RollupUpload 每日上传量，Count 为有上传的用户数
+	RollupUpload = "upload"
+	// RollupDownload 每日下载量，Count 为有下载的用户数
+	RollupDownload = "download"
+	// RollupActiveUsers 每日活跃用户数
+	RollupActiveUsers = "active_users"
+	// RollupFileDownload 各文件的下载量，Count 为下载次数
+	RollupFileDownload = "file_download"
+	// RollupShareCreated 每日新建分享数
+	RollupShareCreated = "share_created"
+	// RollupShareView 各分享的每日浏览次数
+	RollupShareView = "share_view"
+	// RollupShareDownload 各分享的每日下载次数
+	RollupShareDownload = "share_download"
+	// RollupTypePolicy 各存储策略中各类文件的大小，维度为 "策略ID:类型"，Count 为文件数
+	RollupTypePolicy = "type_policy"
+	// RollupTypeUser 已用容量最多的用户中各类文件的大小，维度为 "用户ID:类型"，Count 为文件数
+	RollupTypeUser = "type_user"
+	// RollupLargestFile 站点中最大的文件
+	RollupLargestFile = "largest_file"
+	// RollupGrowthPolicy 各存储策略每日新增的文件大小，Count 为文件数
+	RollupGrowthPolicy = "growth_policy"
+	// RollupGrowthUser 每日新增文件大小最多的用户，Count 为文件数
+	RollupGrowthUser = "growth_user"
+)
+
+// UsageRollup 定时任务汇总的站点用量统计，报表接口只读取汇总结果
+type UsageRollup struct {
+	ID        uint   `gorm:"primary_key" json:"-"`
+	Date      string `gorm:"size:10;unique_index:usage_rollup" json:"date"`
+	Metric    string `gorm:"size:32;unique_index:usage_rollup" json:"metric"`
+	Dimension string `gorm:"size:64;unique_index:usage_rollup" json:"dimension"`
+	Value     uint64 `json:"value"`
+	Count     uint64 `json:"count"`
+}
+
+// fileDownloads 尚未写入汇总表的文件下载量
+var fileDownloads = struct {
+	sync.Mutex
+	pending map[uint]*UsageRollup
+}{pending: make(map[uint]*UsageRollup)}
+
+// RecordFileDownload 记录一次文件下载，下载量会在下一次 FlushFileDownloads 时计入当日汇总
+func RecordFileDownload(id uint, size uint64) {
+	if id == 0 {
+		return
+	}
+
+	fileDownloads.Lock()
+	defer fileDownloads.Unlock()
+	if stat, ok := fileDownloads.pending[id]; ok {
+		stat.Value += size
+		stat.Count++
+		return
+	}
+	fileDownloads.pending[id] = &UsageRollup{Value: size, Count: 1}
+}
+
+// FlushFileDownloads 将暂存的文件下载量计入当日汇总
+func FlushFileDownloads() error {
+	fileDownloads.Lock()
+	pending := fileDownloads.pending
+	fileDownloads.pending = make(map[uint]*UsageRollup)
+	fileDownloads.Unlock()
+
+	date := time.Now().Format(TrafficDateFormat)
+	for id, stat := range pending {
+		if err := AddUsageRollup(date, RollupFileDownload, strconv.FormatUint(uint64(id), 10), stat.Value, stat.Count); err != nil {
+			restoreFileDownloads(pending)
+			return err
+		}
+		delete(pending, id)
+	}
+
+	return nil
+}
+
+// restoreFileDownloads 将未能写入的下载量放回暂存区
+func restoreFileDownloads(stats map[uint]*UsageRollup) {
+	fileDownloads.Lock()
+	defer fileDownloads.Unlock()
+	for id, stat := range stats {
+		if current, ok := fileDownloads.pending[id]; ok {
+			current.Value += stat.Value
+			current.Count += stat.Count
+			continue
+		}
+		fileDownloads.pending[id] = stat
+	}
+}
+
+// AddUsageRollup 将数值累加到指定日期的汇总记录
+func AddUsageRollup(date, metric, dimension string, value, count uint64) error {
+	update := func() *gorm.DB {
+		return DB.Model(&UsageRollup{}).
+			Where("date = ? and metric = ? and dimension = ?", date, metric, dimension).
+			UpdateColumns(map[string]interface{}{
+				"value": gorm.Expr("value + ?", value),
+				"count": gorm.Expr("count + ?", count),
+			})
+	}
+
+	if result := update(); result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+
+	rollup := &UsageRollup{Date: date, Metric: metric, Dimension: dimension, Value: value, Count: count}
+	if err := DB.Create(rollup).Error; err != nil {
+		// 并发创建记录时，唯一索引冲突后改为更新
+		return update().Error
+	}
+
+	return nil
+}
+
+// ReplaceUsageRollups 用重新统计的结果替换指定日期、指标的汇总记录
+func ReplaceUsageRollups(date, metric string, rollups []UsageRollup) error {
+	tx := DB.Begin()
+	if err := tx.Where("date = ? and metric = ?", date, metric).Delete(&UsageRollup{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for i := range rollups {
+		rollups[i].ID = 0
+		rollups[i].Date = date
+		rollups[i].Metric = metric
+		if err := tx.Create(&rollups[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// ListUsageRollups 按日期升序列出指标在 [from, to] 之间的汇总记录
+func ListUsageRollups(metric, from, to string) []UsageRollup {
+	var res []UsageRollup
+	DB.Where("metric = ? and date >= ? and date <= ?", metric, from, to).Order("date asc, value desc").Find(&res)
+	return res
+}
+
+// TopUsageRollups 将指标在 [from, to] 之间的汇总记录按维度合计，返回合计值最大的至多 limit 项
+func TopUsageRollups(metric, from, to string, limit int) []UsageRollup {
+	var res []UsageRollup
+	DB.Model(&UsageRollup{}).
+		Select("dimension, sum(value) as value, sum(count) as count").
+		Where("metric = ? and date >= ? and date <= ?", metric, from, to).
+		Group("dimension").
+		Order("value desc, count desc").
+		Limit(limit).
+		Scan(&res)
+	return res
+}
+
+// LatestUsageRollupDate 返回指标最近一次汇总的日期，尚未汇总时为空
+func LatestUsageRollupDate(metric string) string {
+	var rollup UsageRollup
+	if err := DB.Where("metric = ?", metric).Order("date desc").First(&rollup).Error; err != nil {
+		return ""
+	}
+	return rollup.Date
+}
+
+// DeleteUsageRollupsBefore 删除 before 之前的汇总记录
+func DeleteUsageRollupsBefore(before string) error {
+	return DB.Where("date < ?", before).Delete(&UsageRollup{}).Error
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlushFileDownloads(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 没有待写入的下载量
+	{
+		asserts.NoError(FlushFileDownloads())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 合并同一文件的多次下载
+	{
+		RecordFileDownload(0, 10)
+		RecordFileDownload(1, 10)
+		RecordFileDownload(1, 20)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)usage_rollups(.+)").WithArgs(2, 30, sqlmock.AnyArg(), RollupFileDownload, "1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		asserts.NoError(FlushFileDownloads())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 写入失败时保留
+	{
+		RecordFileDownload(1, 10)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)usage_rollups(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.Error(FlushFileDownloads())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.EqualValues(10, fileDownloads.pending[1].Value)
+		fileDownloads.pending = make(map[uint]*UsageRollup)
+	}
+}
+
+func TestAddUsageRollup(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)usage_rollups(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)usage_rollups(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(AddUsageRollup("2022-01-01", RollupFileDownload, "1", 10, 1))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+func TestReplaceUsageRollups(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE(.+)usage_rollups(.+)").WithArgs("2022-01-01", RollupStorageGroup).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("INSERT(.+)usage_rollups(.+)").WithArgs("2022-01-01", RollupStorageGroup, "1", 100, 2).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(ReplaceUsageRollups("2022-01-01", RollupStorageGroup, []UsageRollup{{Dimension: "1", Value: 100, Count: 2}}))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE(.+)usage_rollups(.+)").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("INSERT(.+)usage_rollups(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.Error(ReplaceUsageRollups("2022-01-01", RollupStorageGroup, []UsageRollup{{Dimension: "1"}}))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/jinzhu/gorm"
@@ -22,13 +23,17 @@ const (
 	Baned
 	// OveruseBaned 超额使用被封禁
 	OveruseBaned
+	// PendingDeletion 等待注销
+	PendingDeletion
+	// PendingApproval 等待管理员审核
+	PendingApproval
 )
 
 // User 用户模型
 type User struct {
 	// 表字段
 	gorm.Model
-	Email     string `gorm:"type:varchar(100);unique_index"`
+	Email     string `gorm:"type:varchar(100);unique_index:idx_tenant_email"`
 	Nick      string `gorm:"size:50"`
 	Password  string `json:"-"`
 	Status    int
@@ -38,6 +43,20 @@ type User struct {
 	Avatar    string
 	Options   string `json:"-" gorm:"size:4294967295"`
 	Authn     string `gorm:"size:4294967295"`
+	// 二步验证恢复代码摘要
+	TwoFactorRecovery string `json:"-" gorm:"size:4294967295"`
+	// 最后一次设定密码的时间
+	PasswordChangedAt *time.Time `json:"-"`
+	// 计划注销账户的时间
+	DeleteAt *time.Time `json:"delete_at,omitempty"`
+	// 用户专属容量配额，为 0 时使用用户组配额
+	MaxStorage uint64
+	// 所属租户，0 为默认租户，不同租户中的 Email 互不冲突
+	TenantID uint `gorm:"unique_index:idx_tenant_email"`
+	// 已用容量超出配额的时间，未超额时为空
+	QuotaExceededAt *time.Time `json:"-"`
+	// 超额宽限期结束后是否已执行限制
+	QuotaEnforced bool `json:"-"`
 
 	// 关联模型
 	Group  Group  `gorm:"save_associations:false:false"`
@@ -55,6 +74,10 @@ func init() {
 type UserOption struct {
 	ProfileOff     bool   `json:"profile_off,omitempty"`
 	PreferredTheme string `json:"preferred_theme,omitempty"`
+	// 各类通知的投递偏好
+	Notify map[string]NotifyPref `json:"notify,omitempty"`
+	// 用户自定义的目录模板
+	FolderTemplates []FolderTemplate `json:"folder_templates,omitempty"`
 }
 
 // Root 获取用户的根目录
@@ -109,9 +132,20 @@ func (user *User) IncreaseStorageWithoutCheck(size uint64) {
 
 }
 
-// GetRemainingCapacity 获取剩余配额
+// TotalCapacity 获取用户的总容量配额
+func (user *User) TotalCapacity() uint64 {
+	if user.MaxStorage > 0 {
+		return user.MaxStorage
+	}
+	return user.Group.MaxStorage
+}
+
+// GetRemainingCapacity 获取剩余配额，宽限期内可额外使用用户组设定比例的容量
 func (user *User) GetRemainingCapacity() uint64 {
-	total := user.Group.MaxStorage
+	total := user.TotalCapacity()
+	if user.InQuotaGrace() {
+		total += total * uint64(user.Group.OptionsSerialized.QuotaGrace) / 100
+	}
 	if total <= user.Storage {
 		return 0
 	}
@@ -147,17 +181,17 @@ func GetActiveUserByOpenID(openid string) (User, error) {
 	return user, result.Error
 }
 
-// GetUserByEmail 用Email获取用户
-func GetUserByEmail(email string) (User, error) {
+// GetUserByEmail 用Email获取租户中的用户
+func GetUserByEmail(tenantID uint, email string) (User, error) {
 	var user User
-	result := DB.Set("gorm:auto_preload", true).Where("email = ?", email).First(&user)
+	result := DB.Set("gorm:auto_preload", true).Where("tenant_id = ? and email = ?", tenantID, email).First(&user)
 	return user, result.Error
 }
 
-// GetActiveUserByEmail 用Email获取可登录用户
-func GetActiveUserByEmail(email string) (User, error) {
+// GetActiveUserByEmail 用Email获取租户中的可登录用户
+func GetActiveUserByEmail(tenantID uint, email string) (User, error) {
 	var user User
-	result := DB.Set("gorm:auto_preload", true).Where("status = ? and email = ?", Active, email).First(&user)
+	result := DB.Set("gorm:auto_preload", true).Where("status = ? and tenant_id = ? and email = ?", Active, tenantID, email).First(&user)
 	return user, result.Error
 }
 
@@ -198,7 +232,7 @@ func (user *User) AfterFind() (err error) {
 	return err
 }
 
-//SerializeOptions 将序列后的Option写入到数据库字段
+// SerializeOptions 将序列后的Option写入到数据库字段
 func (user *User) SerializeOptions() (err error) {
 	optionsValue, err := json.Marshal(&user.OptionsSerialized)
 	user.Options = string(optionsValue)
@@ -255,9 +289,53 @@ func (user *User) SetPassword(password string) error {
 
 	//存储 Salt 值和摘要， ":"分割
 	user.Password = salt + ":" + string(bs)
+	now := time.Now()
+	user.PasswordChangedAt = &now
 	return nil
 }
 
+// ScheduleDeletion 停用账户并计划在给定时间注销
+func (user *User) ScheduleDeletion(at time.Time) error {
+	user.Status = PendingDeletion
+	user.DeleteAt = &at
+	return DB.Model(user).Updates(map[string]interface{}{
+		"status":    PendingDeletion,
+		"delete_at": &at,
+	}).Error
+}
+
+// CancelDeletion 取消账户注销并恢复为正常状态
+func (user *User) CancelDeletion() error {
+	user.Status = Active
+	user.DeleteAt = nil
+	return DB.Model(user).Updates(map[string]interface{}{
+		"status":    Active,
+		"delete_at": gorm.Expr("NULL"),
+	}).Error
+}
+
+// GetUsersToDelete 获取注销宽限期已到的用户
+func GetUsersToDelete(before time.Time) []User {
+	var users []User
+	DB.Where("status = ? and delete_at <= ?", PendingDeletion, before).Find(&users)
+	return users
+}
+
+// PasswordExpired 根据站点设定的密码有效期判断用户是否需要更换密码
+func (user *User) PasswordExpired() bool {
+	maxAge := GetIntSetting("password_max_age", 0)
+	if maxAge <= 0 || user.IsAnonymous() {
+		return false
+	}
+
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+
+	return time.Since(changedAt) > time.Duration(maxAge)*24*time.Hour
+}
+
 // NewAnonymousUser 返回一个匿名用户
 func NewAnonymousUser() *User {
 	user := User{}
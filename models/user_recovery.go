@@ -0,0 +1,149 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+)
+
+const (
+	// RecoveryCodeNum 每次生成的二步验证恢复代码数量
+	RecoveryCodeNum = 10
+	// recoveryCodeLen 单个恢复代码长度（不含分隔符）
+	recoveryCodeLen = 10
+)
+
+var recoveryCodeRunes = []rune("23456789abcdefghjkmnpqrstuvwxyz")
+
+// normalizeRecoveryCode 去除用户输入恢复代码中的分隔符和空白
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// hashRecoveryCode 计算恢复代码摘要
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRecoveryCode 生成一个随机恢复代码，格式为 xxxxx-xxxxx
+func newRecoveryCode() (string, error) {
+	b := make([]rune, recoveryCodeLen)
+	max := big.NewInt(int64(len(recoveryCodeRunes)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = recoveryCodeRunes[n.Int64()]
+	}
+
+	return string(b[:recoveryCodeLen/2]) + "-" + string(b[recoveryCodeLen/2:]), nil
+}
+
+// recoveryCodeHashes 获得尚未使用的恢复代码摘要
+func (user *User) recoveryCodeHashes() []string {
+	var res []string
+	if user.TwoFactorRecovery == "" {
+		return res
+	}
+
+	_ = json.Unmarshal([]byte(user.TwoFactorRecovery), &res)
+	return res
+}
+
+// encodeRecoveryCodeHashes 序列化恢复代码摘要，没有摘要时为空字符串
+func encodeRecoveryCodeHashes(hashes []string) (string, error) {
+	if len(hashes) == 0 {
+		return "", nil
+	}
+
+	res, err := json.Marshal(hashes)
+	return string(res), err
+}
+
+// saveRecoveryCodeHashes 保存恢复代码摘要
+func (user *User) saveRecoveryCodeHashes(hashes []string) error {
+	raw, err := encodeRecoveryCodeHashes(hashes)
+	if err != nil {
+		return err
+	}
+
+	return DB.Model(user).Update("two_factor_recovery", raw).Error
+}
+
+// RecoveryCodeCount 返回剩余可用的恢复代码数量
+func (user *User) RecoveryCodeCount() int {
+	return len(user.recoveryCodeHashes())
+}
+
+// GenerateRecoveryCodes 生成一组新的恢复代码并作废已有代码，返回明文代码
+func (user *User) GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, RecoveryCodeNum)
+	hashes := make([]string, 0, RecoveryCodeNum)
+	for i := 0; i < RecoveryCodeNum; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	if err := user.saveRecoveryCodeHashes(hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode 校验恢复代码，校验成功后该代码失效。
+// 仅当数据库中的恢复代码未被并发修改时才会作废，同一代码只能被使用一次
+func (user *User) UseRecoveryCode(code string) bool {
+	if normalizeRecoveryCode(code) == "" {
+		return false
+	}
+
+	expected := hashRecoveryCode(code)
+	hashes := user.recoveryCodeHashes()
+	for i := 0; i < len(hashes); i++ {
+		if subtle.ConstantTimeCompare([]byte(hashes[i]), []byte(expected)) == 1 {
+			hashes = append(hashes[:i], hashes[i+1:]...)
+			raw, err := encodeRecoveryCodeHashes(hashes)
+			if err != nil {
+				return false
+			}
+
+			result := DB.Model(&User{}).
+				Where("id = ? and two_factor_recovery = ?", user.ID, user.TwoFactorRecovery).
+				UpdateColumn("two_factor_recovery", raw)
+			if result.Error != nil || result.RowsAffected != 1 {
+				return false
+			}
+
+			user.TwoFactorRecovery = raw
+			return true
+		}
+	}
+
+	return false
+}
+
+// RevokeRecoveryCodes 作废所有恢复代码
+func (user *User) RevokeRecoveryCodes() error {
+	return user.saveRecoveryCodeHashes(nil)
+}
+
+// ResetTwoFactor 关闭二步验证，同时作废所有恢复代码
+func (user *User) ResetTwoFactor() error {
+	return user.Update(map[string]interface{}{
+		"two_factor":          "",
+		"two_factor_recovery": "",
+	})
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUser_GenerateRecoveryCodes(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{}
+	user.ID = 1
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		codes, err := user.GenerateRecoveryCodes()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Len(codes, RecoveryCodeNum)
+		asserts.Equal(RecoveryCodeNum, user.RecoveryCodeCount())
+		for _, code := range codes {
+			asserts.Len(code, recoveryCodeLen+1)
+		}
+	}
+
+	// 数据库错误
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		codes, err := user.GenerateRecoveryCodes()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Error(err)
+		asserts.Nil(codes)
+	}
+}
+
+func TestUser_UseRecoveryCode(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{}
+	user.ID = 1
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	codes, err := user.GenerateRecoveryCodes()
+	asserts.NoError(err)
+
+	// 空代码
+	asserts.False(user.UseRecoveryCode(" - "))
+
+	// 代码不存在
+	asserts.False(user.UseRecoveryCode("not-exist"))
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	// 成功，忽略大小写和分隔符
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		input := " " + codes[0][:5] + codes[0][6:] + " "
+		asserts.True(user.UseRecoveryCode(input))
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(RecoveryCodeNum-1, user.RecoveryCodeCount())
+	}
+
+	// 已使用的代码不能再次使用
+	asserts.False(user.UseRecoveryCode(codes[0]))
+
+	// 数据库错误
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.False(user.UseRecoveryCode(codes[1]))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 代码已被并发请求使用
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)two_factor_recovery(.+)WHERE(.+)two_factor_recovery = ").
+			WithArgs(sqlmock.AnyArg(), 1, user.TwoFactorRecovery).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		asserts.False(user.UseRecoveryCode(codes[1]))
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(RecoveryCodeNum-1, user.RecoveryCodeCount())
+	}
+}
+
+func TestUser_RevokeRecoveryCodes(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{TwoFactorRecovery: `["a","b"]`}
+	user.ID = 1
+	asserts.Equal(2, user.RecoveryCodeCount())
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(user.RevokeRecoveryCodes())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(0, user.RecoveryCodeCount())
+	asserts.Empty(user.TwoFactorRecovery)
+}
+
+func TestUser_ResetTwoFactor(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{TwoFactor: "secret", TwoFactorRecovery: `["a"]`}
+	user.ID = 1
+
+	// 失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		asserts.Error(user.ResetTwoFactor())
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(user.ResetTwoFactor())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Empty(user.TwoFactor)
+		asserts.Equal(0, user.RecoveryCodeCount())
+	}
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
@@ -123,6 +124,30 @@ func TestUser_SetPassword(t *testing.T) {
 	asserts.NotEmpty(user.Password)
 }
 
+func TestUser_PasswordExpired(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{}
+	user.ID = 1
+	user.CreatedAt = time.Now().Add(-48 * time.Hour)
+
+	// 未设定有效期
+	cache.Set("setting_password_max_age", "0", 0)
+	asserts.False(user.PasswordExpired())
+
+	// 从未更改过密码时以注册时间为准
+	cache.Set("setting_password_max_age", "1", 0)
+	asserts.True(user.PasswordExpired())
+
+	// 刚刚设定过密码
+	asserts.NoError(user.SetPassword("123456"))
+	asserts.NotNil(user.PasswordChangedAt)
+	asserts.False(user.PasswordExpired())
+
+	// 匿名用户
+	asserts.False((&User{}).PasswordExpired())
+	cache.Set("setting_password_max_age", "0", 0)
+}
+
 func TestUser_CheckPassword(t *testing.T) {
 	asserts := assert.New(t)
 	user := User{}
@@ -240,6 +265,10 @@ func TestUser_GetRemainingCapacity(t *testing.T) {
 	newUser.Group.MaxStorage = 100
 	newUser.Storage = 200
 	asserts.Equal(uint64(0), newUser.GetRemainingCapacity())
+
+	// 用户专属配额优先
+	newUser.MaxStorage = 300
+	asserts.Equal(uint64(100), newUser.GetRemainingCapacity())
 }
 
 func TestUser_DeductionCapacity(t *testing.T) {
@@ -346,8 +375,8 @@ func TestUser_IncreaseStorageWithoutCheck(t *testing.T) {
 func TestGetActiveUserByEmail(t *testing.T) {
 	asserts := assert.New(t)
 
-	mock.ExpectQuery("SELECT(.+)").WithArgs(Active, "abslant@foxmail.com").WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
-	_, err := GetActiveUserByEmail("abslant@foxmail.com")
+	mock.ExpectQuery("SELECT(.+)").WithArgs(Active, 0, "abslant@foxmail.com").WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+	_, err := GetActiveUserByEmail(0, "abslant@foxmail.com")
 
 	asserts.Error(err)
 	asserts.NoError(mock.ExpectationsWereMet())
@@ -356,8 +385,8 @@ func TestGetActiveUserByEmail(t *testing.T) {
 func TestGetUserByEmail(t *testing.T) {
 	asserts := assert.New(t)
 
-	mock.ExpectQuery("SELECT(.+)").WithArgs("abslant@foxmail.com").WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
-	_, err := GetUserByEmail("abslant@foxmail.com")
+	mock.ExpectQuery("SELECT(.+)").WithArgs(3, "abslant@foxmail.com").WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+	_, err := GetUserByEmail(3, "abslant@foxmail.com")
 
 	asserts.Error(err)
 	asserts.NoError(mock.ExpectationsWereMet())
@@ -436,3 +465,36 @@ func TestUser_UpdateOptions(t *testing.T) {
 	asserts.NoError(user.UpdateOptions())
 	asserts.NoError(mock.ExpectationsWereMet())
 }
+
+func TestUser_ScheduleDeletion(t *testing.T) {
+	asserts := assert.New(t)
+	user := User{}
+	user.ID = 2
+	at := time.Now().Add(time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(user.ScheduleDeletion(at))
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(PendingDeletion, user.Status)
+	asserts.Equal(at, *user.DeleteAt)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(user.CancelDeletion())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(Active, user.Status)
+	asserts.Nil(user.DeleteAt)
+}
+
+func TestGetUsersToDelete(t *testing.T) {
+	asserts := assert.New(t)
+	mock.ExpectQuery("SELECT(.+)").
+		WithArgs(PendingDeletion, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(3))
+	users := GetUsersToDelete(time.Now())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Len(users, 2)
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	// OpaqueMetadataKey 文件位于保险库中，内容由客户端加密，服务端不能预览、生成缩略图或提取元数据
+	OpaqueMetadataKey = "opaque"
+	// VaultMetadataPrefix 客户端为保险库文件保存的加密元数据（如文件名加密参数）的键前缀
+	VaultMetadataPrefix = "vault_"
+)
+
+// Vault 保险库，目录及其子目录下的文件由客户端加密，密钥不会保存在服务端
+type Vault struct {
+	gorm.Model
+	UserID   uint `gorm:"index:vault_user"`
+	FolderID uint `gorm:"unique_index:vault_folder"` // 保险库根目录
+	// WrappedKey 客户端使用口令派生的密钥包装后的保险库密钥，服务端无法解开
+	WrappedKey string `gorm:"type:text"`
+	// KeyParams 客户端派生包装密钥所需的参数，如算法、盐值，服务端不做解析
+	KeyParams string `gorm:"type:text"`
+}
+
+// Create 创建保险库
+func (vault *Vault) Create() error {
+	return DB.Create(vault).Error
+}
+
+// UpdateKey 更新包装后的保险库密钥，用于客户端更换口令
+func (vault *Vault) UpdateKey(wrappedKey, keyParams string) error {
+	vault.WrappedKey = wrappedKey
+	vault.KeyParams = keyParams
+	return DB.Model(vault).Updates(map[string]interface{}{"wrapped_key": wrappedKey, "key_params": keyParams}).Error
+}
+
+// Delete 删除保险库，目录及其中的文件保持不变
+func (vault *Vault) Delete() error {
+	return DB.Unscoped().Delete(vault).Error
+}
+
+// GetVaultsByUID 列出用户的所有保险库
+func GetVaultsByUID(uid uint) ([]Vault, error) {
+	var vaults []Vault
+	result := DB.Where("user_id = ?", uid).Order("id asc").Find(&vaults)
+	return vaults, result.Error
+}
+
+// GetVaultByID 根据 ID 获取用户的保险库
+func GetVaultByID(id, uid uint) (*Vault, error) {
+	var vault Vault
+	result := DB.Where("id = ? and user_id = ?", id, uid).First(&vault)
+	return &vault, result.Error
+}
+
+// GetVaultOfFolder 查找目录所在的保险库，目录本身或其任一上级目录为保险库根目录时返回该保险库
+func GetVaultOfFolder(folder *Folder) (*Vault, error) {
+	vaults, err := GetVaultsByUID(folder.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(vaults) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	roots := make(map[uint]*Vault, len(vaults))
+	for i := range vaults {
+		roots[vaults[i].FolderID] = &vaults[i]
+	}
+
+	current := folder
+	for {
+		if vault, ok := roots[current.ID]; ok {
+			return vault, nil
+		}
+		if current.ParentID == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+
+		var parent Folder
+		if err := DB.Where("id = ? and owner_id = ?", *current.ParentID, folder.OwnerID).First(&parent).Error; err != nil {
+			return nil, err
+		}
+		current = &parent
+	}
+}
+
+// IsOpaque 返回文件内容是否由客户端加密
+func (file *File) IsOpaque() bool {
+	return IsTrueVal(file.MetadataSerialized[OpaqueMetadataKey])
+}
+
+// VaultMetadata 返回客户端为保险库文件保存的加密元数据，键不含前缀
+func (file *File) VaultMetadata() map[string]string {
+	var res map[string]string
+	for k, v := range file.MetadataSerialized {
+		if strings.HasPrefix(k, VaultMetadataPrefix) {
+			if res == nil {
+				res = make(map[string]string)
+			}
+			res[strings.TrimPrefix(k, VaultMetadataPrefix)] = v
+		}
+	}
+	return res
+}
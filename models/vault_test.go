@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVaultOfFolder(t *testing.T) {
+	a := assert.New(t)
+	parentID := uint(2)
+	folder := &Folder{Model: gorm.Model{ID: 3}, ParentID: &parentID, OwnerID: 1}
+
+	// 无保险库
+	{
+		mock.ExpectQuery("SELECT(.+)vaults").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		_, err := GetVaultOfFolder(folder)
+		a.NoError(mock.ExpectationsWereMet())
+		a.True(gorm.IsRecordNotFoundError(err))
+	}
+
+	// 上级目录为保险库根目录
+	{
+		mock.ExpectQuery("SELECT(.+)vaults").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id"}).AddRow(5, 2))
+		mock.ExpectQuery("SELECT(.+)folders").WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(2, 1))
+		vault, err := GetVaultOfFolder(folder)
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.EqualValues(5, vault.ID)
+	}
+}
+
+func TestFile_VaultMetadata(t *testing.T) {
+	a := assert.New(t)
+	file := &File{MetadataSerialized: map[string]string{ThumbStatusMetadataKey: ThumbStatusExist}}
+	a.False(file.IsOpaque())
+	a.True(file.ShouldLoadThumb())
+	a.Nil(file.VaultMetadata())
+
+	file.MetadataSerialized[OpaqueMetadataKey] = "1"
+	file.MetadataSerialized["vault_name_iv"] = "abc"
+	a.True(file.IsOpaque())
+	a.False(file.ShouldLoadThumb())
+	a.Equal(map[string]string{"name_iv": "abc"}, file.VaultMetadata())
+}
@@ -0,0 +1,71 @@
+package antivirus
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+var (
+	ErrScannerDisabled = errors.New("antivirus scanner is not enabled")
+
+	// Default 全局病毒扫描器，未启用时为 nil
+	Default   Scanner
+	DefaultMu sync.RWMutex
+)
+
+// Result 扫描结果
+type Result struct {
+	Infected  bool
+	Signature string
+}
+
+// Scanner 病毒扫描器
+type Scanner interface {
+	// Scan 扫描给定数据流
+	Scan(ctx context.Context, r io.Reader) (*Result, error)
+}
+
+// Init 根据站点设置初始化全局病毒扫描器
+func Init() {
+	settings := model.GetSettingByNames("antivirus_enabled", "antivirus_clamd_address")
+	var scanner Scanner
+	if model.IsTrueVal(settings["antivirus_enabled"]) {
+		timeout := time.Duration(model.GetIntSetting("antivirus_timeout", 60)) * time.Second
+		clamd, err := NewClamAV(settings["antivirus_clamd_address"], timeout)
+		if err != nil {
+			util.Log().Error("Failed to initialize antivirus scanner: %s", err)
+		} else {
+			scanner = clamd
+		}
+	}
+
+	DefaultMu.Lock()
+	Default = scanner
+	DefaultMu.Unlock()
+}
+
+// Enabled 返回是否启用了病毒扫描
+func Enabled() bool {
+	DefaultMu.RLock()
+	defer DefaultMu.RUnlock()
+	return Default != nil
+}
+
+// Scan 使用全局扫描器扫描数据流
+func Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	DefaultMu.RLock()
+	scanner := Default
+	DefaultMu.RUnlock()
+
+	if scanner == nil {
+		return nil, ErrScannerDisabled
+	}
+
+	return scanner.Scan(ctx, r)
+}
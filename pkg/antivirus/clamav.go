@@ -0,0 +1,109 @@
+package antivirus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const clamdChunkSize = 32 * 1024
+
+// ClamAV 通过 clamd 的 INSTREAM 命令扫描数据流
+type ClamAV struct {
+	Network string
+	Address string
+	Timeout time.Duration
+}
+
+// NewClamAV 根据 clamd 地址新建扫描器，地址格式为 tcp://host:port 或 unix:///path/to/clamd.sock
+func NewClamAV(address string, timeout time.Duration) (*ClamAV, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid clamd address: %w", err)
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		return &ClamAV{Network: "tcp", Address: u.Host, Timeout: timeout}, nil
+	case "unix":
+		return &ClamAV{Network: "unix", Address: u.Path, Timeout: timeout}, nil
+	default:
+		return nil, fmt.Errorf("unsupported clamd address scheme %q", u.Scheme)
+	}
+}
+
+// Scan 实现 Scanner
+func (c *ClamAV) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, c.Network, c.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("failed to send command to clamd: %w", err)
+	}
+
+	// 分块发送数据，每块前附带 4 字节大端序长度，以长度 0 结束
+	buf := make([]byte, clamdChunkSize+4)
+	for {
+		n, readErr := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:n+4]); err != nil {
+				return nil, fmt.Errorf("failed to stream data to clamd: %w", err)
+			}
+		}
+
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read file data: %w", readErr)
+		}
+	}
+
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return nil, fmt.Errorf("failed to stream data to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+
+	return parseClamdReply(reply)
+}
+
+// parseClamdReply 解析 clamd 回复，如 "stream: OK" 或 "stream: Eicar-Signature FOUND"
+func parseClamdReply(reply string) (*Result, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+
+	switch {
+	case reply == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+}
@@ -0,0 +1,134 @@
+package antivirus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClamd 模拟 clamd，收到的数据包含 infected 时报告病毒
+func fakeClamd(t *testing.T, reply func(data []byte) string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+					return
+				}
+
+				var data bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+						return
+					}
+				}
+
+				conn.Write([]byte(reply(data.Bytes()) + "\x00"))
+			}(conn)
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func TestNewClamAV(t *testing.T) {
+	a := assert.New(t)
+
+	c, err := NewClamAV("tcp://127.0.0.1:3310", time.Second)
+	a.NoError(err)
+	a.Equal("tcp", c.Network)
+	a.Equal("127.0.0.1:3310", c.Address)
+
+	c, err = NewClamAV("unix:///var/run/clamd.sock", time.Second)
+	a.NoError(err)
+	a.Equal("unix", c.Network)
+	a.Equal("/var/run/clamd.sock", c.Address)
+
+	_, err = NewClamAV("http://127.0.0.1", time.Second)
+	a.Error(err)
+
+	_, err = NewClamAV(string([]byte{0x7f}), time.Second)
+	a.Error(err)
+}
+
+func TestClamAV_Scan(t *testing.T) {
+	a := assert.New(t)
+	addr := fakeClamd(t, func(data []byte) string {
+		if bytes.Contains(data, []byte("infected")) {
+			return "stream: Eicar-Test-Signature FOUND"
+		}
+		if len(data) == 0 {
+			return "INSTREAM size limit exceeded. ERROR"
+		}
+		return "stream: OK"
+	})
+	c := &ClamAV{Network: "tcp", Address: addr, Timeout: 5 * time.Second}
+
+	// 干净文件，超过单块大小
+	res, err := c.Scan(context.Background(), strings.NewReader(strings.Repeat("a", clamdChunkSize*2+1)))
+	a.NoError(err)
+	a.False(res.Infected)
+
+	// 感染文件
+	res, err = c.Scan(context.Background(), strings.NewReader("this file is infected"))
+	a.NoError(err)
+	a.True(res.Infected)
+	a.Equal("Eicar-Test-Signature", res.Signature)
+
+	// clamd 报错
+	_, err = c.Scan(context.Background(), strings.NewReader(""))
+	a.Error(err)
+
+	// 无法连接
+	c = &ClamAV{Network: "tcp", Address: "127.0.0.1:1", Timeout: time.Second}
+	_, err = c.Scan(context.Background(), strings.NewReader(""))
+	a.Error(err)
+}
+
+func TestInit(t *testing.T) {
+	a := assert.New(t)
+
+	cache.Set("setting_antivirus_enabled", "0", 0)
+	cache.Set("setting_antivirus_clamd_address", "tcp://127.0.0.1:3310", 0)
+	cache.Set("setting_antivirus_timeout", "10", 0)
+	Init()
+	a.False(Enabled())
+	_, err := Scan(context.Background(), strings.NewReader(""))
+	a.ErrorIs(err, ErrScannerDisabled)
+
+	cache.Set("setting_antivirus_enabled", "1", 0)
+	Init()
+	a.True(Enabled())
+
+	cache.Set("setting_antivirus_clamd_address", "invalid://", 0)
+	Init()
+	a.False(Enabled())
+}
@@ -10,7 +10,9 @@ import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/monitor"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/qbittorrent"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/rpc"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/transmission"
 	"github.com/cloudreve/Cloudreve/v3/pkg/balancer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
 	"github.com/cloudreve/Cloudreve/v3/pkg/mq"
@@ -65,3 +67,33 @@ func TestRPCConnection(server, secret string, timeout int) (rpc.VersionInfo, err
 
 	return caller.GetVersion()
 }
+
+// TestDownloaderConnection 连接第三方下载器并返回其版本，测试服务连通性
+func TestDownloaderConnection(driver, server, username, password string, timeout int) (string, error) {
+	options := model.Aria2Option{
+		Driver:   driver,
+		Server:   server,
+		Username: username,
+		Token:    password,
+		Timeout:  timeout,
+	}
+
+	var client interface {
+		Init() error
+		Version() (string, error)
+	}
+	switch driver {
+	case qbittorrent.DriverName:
+		client = qbittorrent.New(options)
+	case transmission.DriverName:
+		client = transmission.New(options)
+	default:
+		return "", fmt.Errorf("unknown downloader driver %q", driver)
+	}
+
+	if err := client.Init(); err != nil {
+		return "", fmt.Errorf("cannot connect to %s: %w", driver, err)
+	}
+
+	return client.Version()
+}
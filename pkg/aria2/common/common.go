@@ -18,6 +18,12 @@ type Aria2 interface {
 	Cancel(task *model.Download) error
 	// 选择要下载的文件
 	Select(task *model.Download, files []int) error
+	// 暂停任务
+	Pause(task *model.Download) error
+	// 恢复已暂停的任务
+	Resume(task *model.Download) error
+	// 设置任务的最大下载速度（字节/秒），0 表示不限制
+	SetSpeedLimit(task *model.Download, limit int) error
 	// 获取离线下载配置
 	GetConfig() model.Aria2Option
 	// 删除临时下载文件
@@ -85,6 +91,21 @@ func (instance *DummyAria2) Select(task *model.Download, files []int) error {
 	return ErrNotEnabled
 }
 
+// Pause 返回未开启错误
+func (instance *DummyAria2) Pause(task *model.Download) error {
+	return ErrNotEnabled
+}
+
+// Resume 返回未开启错误
+func (instance *DummyAria2) Resume(task *model.Download) error {
+	return ErrNotEnabled
+}
+
+// SetSpeedLimit 返回未开启错误
+func (instance *DummyAria2) SetSpeedLimit(task *model.Download, limit int) error {
+	return ErrNotEnabled
+}
+
 // GetConfig 返回空的
 func (instance *DummyAria2) GetConfig() model.Aria2Option {
 	return model.Aria2Option{}
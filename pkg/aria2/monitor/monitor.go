@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/rpc"
+	"github.com/cloudreve/Cloudreve/v3/pkg/balancer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
@@ -26,6 +29,7 @@ type Monitor struct {
 
 	notifier <-chan mq.Message
 	node     cluster.Node
+	pool     cluster.Pool
 	retried  int
 }
 
@@ -37,6 +41,7 @@ func NewMonitor(task *model.Download, pool cluster.Pool, mqClient mq.MQ) {
 		Task:     task,
 		notifier: make(chan mq.Message),
 		node:     pool.GetNodeByID(task.GetNodeID()),
+		pool:     pool,
 	}
 
 	if monitor.node != nil {
@@ -79,6 +84,11 @@ func (monitor *Monitor) Update() bool {
 		monitor.retried++
 		util.Log().Warning("Cannot get status of download task %q: %s", monitor.Task.GID, err)
 
+		// 节点不可用时，尝试将排队中的任务转移到其他节点
+		if monitor.pool != nil && monitor.failover(err) {
+			return false
+		}
+
 		// 十次重试后认定为任务失败
 		if monitor.retried > MAX_RETRY {
 			util.Log().Warning("Cannot get status of download task %q，exceed maximum retry threshold: %s",
@@ -236,13 +246,17 @@ func (monitor *Monitor) RemoveTempFolder() {
 
 // Complete 完成下载，返回是否中断监控
 func (monitor *Monitor) Complete(pool task.Pool) bool {
-	// 未开始转存，提交转存任务
+	// 未开始转存，做种达到上限后提交转存任务
 	if monitor.Task.TaskID == 0 {
+		if monitor.seeding() {
+			return false
+		}
 		return monitor.transfer(pool)
 	}
 
 	// 做种完成
-	if common.GetStatus(monitor.Task.StatusInfo) == common.Complete {
+	if common.GetStatus(monitor.Task.StatusInfo) == common.Complete ||
+		(monitor.Task.SeedingLimited() && monitor.Task.SeedingFinished(time.Now())) {
 		transferTask, err := model.GetTasksByID(monitor.Task.TaskID)
 		if err != nil {
 			monitor.setErrorStatus(err)
@@ -269,27 +283,54 @@ func (monitor *Monitor) Complete(pool task.Pool) bool {
 	return false
 }
 
+// seeding 返回任务是否需要继续做种，首次进入做种状态时记录开始时间
+func (monitor *Monitor) seeding() bool {
+	if !monitor.Task.SeedingLimited() || common.GetStatus(monitor.Task.StatusInfo) != common.Seeding {
+		return false
+	}
+
+	now := time.Now()
+	if monitor.Task.SeededAt == nil {
+		monitor.Task.SeededAt = &now
+		monitor.Task.Save()
+	}
+
+	return !monitor.Task.SeedingFinished(now)
+}
+
 func (monitor *Monitor) transfer(pool task.Pool) bool {
-	// 创建中转任务
+	// 创建中转任务，跳过未通过文件名筛选的文件
+	rules := monitor.Task.PostProcessSerialized
 	file := make([]string, 0, len(monitor.Task.StatusInfo.Files))
 	sizes := make(map[string]uint64, len(monitor.Task.StatusInfo.Files))
 	for i := 0; i < len(monitor.Task.StatusInfo.Files); i++ {
 		fileInfo := monitor.Task.StatusInfo.Files[i]
-		if fileInfo.Selected == "true" {
+		if fileInfo.Selected == "true" && rules.Match(path.Base(util.FormSlash(fileInfo.Path))) {
 			file = append(file, fileInfo.Path)
 			size, _ := strconv.ParseUint(fileInfo.Length, 10, 64)
 			sizes[fileInfo.Path] = size
 		}
 	}
 
+	parent := monitor.Task.Parent
+	if rules.Flatten {
+		parent = flattenParent(parent, file)
+	}
+
+	var post *model.DownloadPostProcess
+	if !rules.IsEmpty() {
+		post = &rules
+	}
+
 	job, err := task.NewTransferTask(
 		monitor.Task.UserID,
 		file,
 		monitor.Task.Dst,
-		monitor.Task.Parent,
+		parent,
 		true,
 		monitor.node.ID(),
 		sizes,
+		post,
 	)
 	if err != nil {
 		monitor.setErrorStatus(err)
@@ -307,6 +348,60 @@ func (monitor *Monitor) transfer(pool task.Pool) bool {
 	return false
 }
 
+// flattenParent 所有文件位于同一个顶层目录下时，返回该目录作为转存时去除的路径前缀
+func flattenParent(parent string, files []string) string {
+	prefix := strings.TrimSuffix(util.FormSlash(parent), "/") + "/"
+	top := ""
+	for _, file := range files {
+		rel := strings.TrimPrefix(util.FormSlash(file), prefix)
+		parts := strings.SplitN(rel, "/", 2)
+		if len(parts) < 2 || (top != "" && parts[0] != top) {
+			return parent
+		}
+		top = parts[0]
+	}
+
+	if top == "" {
+		return parent
+	}
+
+	return prefix + top
+}
+
+// failover 记录节点调用失败，节点被视为不健康时将尚未开始下载的任务转移到其他节点，返回是否转移成功
+func (monitor *Monitor) failover(err error) bool {
+	origin := monitor.node.ID()
+	cluster.MarkAria2Failure(origin, err)
+	if cluster.IsAria2Healthy(origin) || monitor.Task.Status != common.Ready ||
+		monitor.Task.DownloadedSize > 0 || monitor.Task.TaskID != 0 {
+		return false
+	}
+
+	user := monitor.Task.GetOwner()
+	if user == nil {
+		return false
+	}
+
+	node, gid, err := cluster.CreateAria2Task(monitor.pool, &user.Group, balancer.NewBalancer("RoundRobin"), monitor.Task, origin)
+	if err != nil {
+		util.Log().Warning("Failed to move download task %q to another node: %s", monitor.Task.GID, err)
+		return false
+	}
+
+	// 尽量取消原节点中的任务
+	monitor.node.GetAria2Instance().Cancel(monitor.Task)
+	util.Log().Info("Download task %q is moved from node [ID=%d] to node [ID=%d] as %q.",
+		monitor.Task.GID, origin, node.ID(), gid)
+
+	monitor.Task.GID = gid
+	monitor.Task.NodeID = node.ID()
+	monitor.Task.Save()
+	monitor.node = node
+	monitor.retried = 0
+	monitor.Interval = time.Duration(node.GetAria2Instance().GetConfig().Interval) * time.Second
+	return true
+}
+
 func (monitor *Monitor) setErrorStatus(err error) {
 	monitor.Task.Status = common.Error
 	monitor.Task.Error = err.Error()
@@ -57,6 +57,7 @@ func TestNewMonitor(t *testing.T) {
 	{
 		mockNode := &mocks.NodeMock{}
 		mockNode.On("GetAria2Instance").Return(&common.DummyAria2{})
+		mockNode.On("ID").Return(uint(1)).Maybe()
 		mockPool := &mocks.NodePoolMock{}
 		mockPool.On("GetNodeByID", uint(1)).Return(mockNode)
 
@@ -445,3 +446,76 @@ func TestMonitor_Complete(t *testing.T) {
 	mockNode.AssertExpectations(t)
 	mockPool.AssertExpectations(t)
 }
+
+func TestMonitor_CompleteSeeding(t *testing.T) {
+	a := assert.New(t)
+	mockNode := &mocks.NodeMock{}
+	mockNode.On("ID").Return(uint(1))
+	mockPool := &mocks.TaskPoolMock{}
+	mockPool.On("Submit", testMock.Anything)
+	m := &Monitor{
+		node: mockNode,
+		Task: &model.Download{
+			Model:     gorm.Model{ID: 1},
+			TotalSize: 100,
+			UserID:    9414,
+			SeedRatio: 1,
+		},
+	}
+	m.Task.StatusInfo.Status = "active"
+	m.Task.StatusInfo.TotalLength = "100"
+	m.Task.StatusInfo.CompletedLength = "100"
+	m.Task.StatusInfo.UploadLength = "50"
+	m.Task.StatusInfo.BitTorrent.Mode = "single"
+	m.Task.StatusInfo.Files = []rpc.FileInfo{
+		{
+			Length:   "100",
+			Selected: "true",
+		},
+	}
+
+	// 未达到分享率，继续做种
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)downloads").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	a.False(m.Complete(mockPool))
+	a.NotNil(m.Task.SeededAt)
+	a.EqualValues(0, m.Task.TaskID)
+	mockPool.AssertNotCalled(t, "Submit", testMock.Anything)
+
+	// 达到分享率，开始转存
+	m.Task.StatusInfo.UploadLength = "100"
+	mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9414))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)tasks").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)downloads").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	a.False(m.Complete(mockPool))
+	a.EqualValues(1, m.Task.TaskID)
+
+	// 转存完成后回收，无需等待下载器停止做种
+	mock.ExpectQuery("SELECT(.+)tasks").WillReturnRows(sqlmock.NewRows([]string{"id", "type", "status"}).AddRow(1, 2, 4))
+	mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9414))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)tasks").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+	a.True(m.Complete(mockPool))
+	a.NoError(mock.ExpectationsWereMet())
+	mockPool.AssertExpectations(t)
+}
+
+func TestFlattenParent(t *testing.T) {
+	a := assert.New(t)
+
+	// 单个顶层目录
+	a.Equal("/tmp/1/movie", flattenParent("/tmp/1", []string{"/tmp/1/movie/a.mkv", "/tmp/1/movie/sub/b.srt"}))
+
+	// 多个顶层对象
+	a.Equal("/tmp/1", flattenParent("/tmp/1", []string{"/tmp/1/movie/a.mkv", "/tmp/1/b.srt"}))
+	a.Equal("/tmp/1", flattenParent("/tmp/1", []string{"/tmp/1/movie/a.mkv", "/tmp/1/other/b.srt"}))
+
+	// 无文件
+	a.Equal("/tmp/1", flattenParent("/tmp/1", nil))
+}
@@ -0,0 +1,463 @@
+package qbittorrent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/rpc"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gofrs/uuid"
+)
+
+// DriverName 使用 qBittorrent 作为离线下载器时的驱动标识
+const DriverName = "qbittorrent"
+
+const deleteTempFileDuration = 60 * time.Second
+
+var (
+	// ErrLoginFailed 登录 Web API 失败
+	ErrLoginFailed = errors.New("failed to login qBittorrent Web API")
+	// ErrTaskNotFound 未找到下载任务对应的种子
+	ErrTaskNotFound = errors.New("torrent not found in qBittorrent")
+)
+
+// Client qBittorrent Web API 客户端，实现离线下载处理接口。
+// 任务以随机生成的标签标识，标签同时作为任务的 GID
+type Client struct {
+	Client request.Client
+
+	lock        sync.RWMutex
+	options     model.Aria2Option
+	sid         string
+	initialized bool
+
+	deletePaddingDuration time.Duration
+}
+
+// torrentInfo /api/v2/torrents/info 返回的种子信息
+type torrentInfo struct {
+	Hash      string  `json:"hash"`
+	Name      string  `json:"name"`
+	State     string  `json:"state"`
+	Size      uint64  `json:"size"`
+	Completed uint64  `json:"completed"`
+	Uploaded  uint64  `json:"uploaded"`
+	DlSpeed   int64   `json:"dlspeed"`
+	UpSpeed   int64   `json:"upspeed"`
+	NumSeeds  int     `json:"num_seeds"`
+	NumLeechs int     `json:"num_leechs"`
+	Progress  float64 `json:"progress"`
+	SavePath  string  `json:"save_path"`
+}
+
+// torrentFile /api/v2/torrents/files 返回的文件信息
+type torrentFile struct {
+	Name     string  `json:"name"`
+	Size     uint64  `json:"size"`
+	Progress float64 `json:"progress"`
+	Priority int     `json:"priority"`
+}
+
+// New 根据节点的离线下载配置新建客户端
+func New(options model.Aria2Option) *Client {
+	return &Client{
+		Client:                request.NewClient(),
+		options:               options,
+		deletePaddingDuration: deleteTempFileDuration,
+	}
+}
+
+// Init 登录 Web API
+func (c *Client) Init() error {
+	c.lock.Lock()
+	c.initialized = false
+	form := url.Values{
+		"username": {c.options.Username},
+		"password": {c.options.Token},
+	}
+	c.lock.Unlock()
+
+	resp := c.Client.Request(
+		"POST",
+		c.endpoint("auth/login"),
+		strings.NewReader(form.Encode()),
+		c.requestOptions(form, "")...,
+	).CheckHTTPResponse(200)
+	if resp.Err != nil {
+		return resp.Err
+	}
+	defer resp.Response.Body.Close()
+
+	for _, cookie := range resp.Response.Cookies() {
+		if cookie.Name == "SID" {
+			c.lock.Lock()
+			c.sid = cookie.Value
+			c.initialized = true
+			c.lock.Unlock()
+			return nil
+		}
+	}
+
+	return ErrLoginFailed
+}
+
+// Initialized 返回客户端是否已登录
+func (c *Client) Initialized() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.initialized
+}
+
+// Version 返回 qBittorrent 版本，用于检查连通性
+func (c *Client) Version() (string, error) {
+	return c.call("GET", "app/version", nil)
+}
+
+// CreateTask 创建新的下载任务
+func (c *Client) CreateTask(task *model.Download, options map[string]interface{}) (string, error) {
+	tag, _ := uuid.NewV4()
+	gid := strings.ReplaceAll(tag.String(), "-", "")
+
+	c.lock.RLock()
+	savePath := filepath.Join(c.options.TempPath, "aria2", gid)
+	c.lock.RUnlock()
+
+	res, err := c.call("POST", "torrents/add", url.Values{
+		"urls":     {task.Source},
+		"savepath": {savePath},
+		"tags":     {gid},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(res) != "Ok." {
+		return "", fmt.Errorf("qBittorrent refused to add torrent: %s", res)
+	}
+
+	return gid, nil
+}
+
+// Status 返回任务状态，转换为 aria2 格式
+func (c *Client) Status(task *model.Download) (rpc.StatusInfo, error) {
+	torrent, err := c.torrent(task.GID)
+	if err != nil {
+		return rpc.StatusInfo{}, err
+	}
+
+	files, err := c.files(torrent.Hash)
+	if err != nil {
+		return rpc.StatusInfo{}, err
+	}
+
+	return convertStatus(task.GID, torrent, files), nil
+}
+
+// Cancel 取消任务并删除已下载的文件
+func (c *Client) Cancel(task *model.Download) error {
+	torrent, err := c.torrent(task.GID)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.call("POST", "torrents/delete", url.Values{
+		"hashes":      {torrent.Hash},
+		"deleteFiles": {"true"},
+	})
+	if err != nil {
+		util.Log().Warning("Failed to cancel task %q: %s", task.GID, err)
+	}
+
+	return err
+}
+
+// Select 选择要下载的文件，files 为从 1 开始的文件序号
+func (c *Client) Select(task *model.Download, files []int) error {
+	torrent, err := c.torrent(task.GID)
+	if err != nil {
+		return err
+	}
+
+	all, err := c.files(torrent.Hash)
+	if err != nil {
+		return err
+	}
+
+	selected := make(map[int]bool, len(files))
+	for _, index := range files {
+		selected[index-1] = true
+	}
+
+	var skip, keep []string
+	for i := range all {
+		if selected[i] {
+			keep = append(keep, strconv.Itoa(i))
+		} else {
+			skip = append(skip, strconv.Itoa(i))
+		}
+	}
+
+	for priority, ids := range map[string][]string{"0": skip, "1": keep} {
+		if len(ids) == 0 {
+			continue
+		}
+
+		if _, err := c.call("POST", "torrents/filePrio", url.Values{
+			"hash":     {torrent.Hash},
+			"id":       {strings.Join(ids, "|")},
+			"priority": {priority},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Pause 暂停任务
+func (c *Client) Pause(task *model.Download) error {
+	return c.toggle(task, "torrents/pause", "torrents/stop")
+}
+
+// Resume 恢复已暂停的任务
+func (c *Client) Resume(task *model.Download) error {
+	return c.toggle(task, "torrents/resume", "torrents/start")
+}
+
+// toggle 暂停或恢复任务，qBittorrent 5.0 起接口更名为 stop/start，旧接口调用失败时改用新接口
+func (c *Client) toggle(task *model.Download, endpoint, fallback string) error {
+	torrent, err := c.torrent(task.GID)
+	if err != nil {
+		return err
+	}
+
+	values := url.Values{"hashes": {torrent.Hash}}
+	if _, err := c.call("POST", endpoint, values); err != nil {
+		_, err = c.call("POST", fallback, values)
+		return err
+	}
+
+	return nil
+}
+
+// SetSpeedLimit 设置任务的最大下载速度（字节/秒），0 表示不限制
+func (c *Client) SetSpeedLimit(task *model.Download, limit int) error {
+	torrent, err := c.torrent(task.GID)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.call("POST", "torrents/setDownloadLimit", url.Values{
+		"hashes": {torrent.Hash},
+		"limit":  {strconv.Itoa(limit)},
+	})
+	return err
+}
+
+// GetConfig 返回离线下载配置
+func (c *Client) GetConfig() model.Aria2Option {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.options
+}
+
+// DeleteTempFile 从 qBittorrent 中移除任务并删除下载目录
+func (c *Client) DeleteTempFile(task *model.Download) error {
+	if torrent, err := c.torrent(task.GID); err == nil {
+		if _, err := c.call("POST", "torrents/delete", url.Values{
+			"hashes":      {torrent.Hash},
+			"deleteFiles": {"true"},
+		}); err != nil {
+			util.Log().Warning("Failed to remove torrent %q from qBittorrent: %s", task.GID, err)
+		}
+	}
+
+	// qBittorrent 与 Cloudreve 位于同一主机时，异步清理可能残留的下载目录
+	go func(d time.Duration, src string) {
+		time.Sleep(d)
+		if src == "" {
+			return
+		}
+		if err := os.RemoveAll(src); err != nil {
+			util.Log().Warning("Failed to delete temp download folder: %q: %s", src, err)
+		}
+	}(c.deletePaddingDuration, task.Parent)
+
+	return nil
+}
+
+// torrent 根据标签查找种子
+func (c *Client) torrent(gid string) (*torrentInfo, error) {
+	res, err := c.call("GET", "torrents/info?tag="+url.QueryEscape(gid), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var torrents []torrentInfo
+	if err := json.Unmarshal([]byte(res), &torrents); err != nil {
+		return nil, fmt.Errorf("failed to parse torrent list: %w", err)
+	}
+
+	if len(torrents) == 0 {
+		return nil, ErrTaskNotFound
+	}
+
+	return &torrents[0], nil
+}
+
+// files 列出种子中的文件，种子元数据尚未获取时为空
+func (c *Client) files(hash string) ([]torrentFile, error) {
+	res, err := c.call("GET", "torrents/files?hash="+url.QueryEscape(hash), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []torrentFile
+	if err := json.Unmarshal([]byte(res), &files); err != nil {
+		return nil, fmt.Errorf("failed to parse torrent files: %w", err)
+	}
+
+	return files, nil
+}
+
+// call 调用 Web API，会话失效时重新登录后重试一次
+func (c *Client) call(method, api string, form url.Values) (string, error) {
+	res, status, err := c.send(method, api, form)
+	if err == nil && status == http.StatusForbidden {
+		if err := c.Init(); err != nil {
+			return "", err
+		}
+		res, status, err = c.send(method, api, form)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if status != http.StatusOK {
+		return "", fmt.Errorf("qBittorrent Web API returns unexpected status %d: %s", status, res)
+	}
+
+	return res, nil
+}
+
+func (c *Client) send(method, api string, form url.Values) (string, int, error) {
+	c.lock.RLock()
+	sid := c.sid
+	c.lock.RUnlock()
+
+	var body *strings.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	} else {
+		body = strings.NewReader("")
+	}
+
+	resp := c.Client.Request(method, c.endpoint(api), body, c.requestOptions(form, sid)...)
+	if resp.Err != nil {
+		return "", 0, resp.Err
+	}
+
+	res, err := resp.GetResponse()
+	return res, resp.Response.StatusCode, err
+}
+
+func (c *Client) endpoint(api string) string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return strings.TrimSuffix(c.options.Server, "/") + "/api/v2/" + api
+}
+
+func (c *Client) requestOptions(form url.Values, sid string) []request.Option {
+	c.lock.RLock()
+	timeout := c.options.Timeout
+	c.lock.RUnlock()
+
+	header := http.Header{}
+	if sid != "" {
+		header.Set("Cookie", "SID="+sid)
+	}
+
+	opts := []request.Option{request.WithHeader(header)}
+	if form != nil {
+		header.Set("Content-Type", "application/x-www-form-urlencoded")
+		opts = append(opts, request.WithContentLength(int64(len(form.Encode()))))
+	} else {
+		opts = append(opts, request.WithContentLength(0))
+	}
+	if timeout > 0 {
+		opts = append(opts, request.WithTimeout(time.Duration(timeout)*time.Second))
+	}
+
+	return opts
+}
+
+// convertStatus 将 qBittorrent 的种子状态转换为 aria2 格式
+func convertStatus(gid string, torrent *torrentInfo, files []torrentFile) rpc.StatusInfo {
+	status := rpc.StatusInfo{
+		Gid:             gid,
+		TotalLength:     strconv.FormatUint(torrent.Size, 10),
+		CompletedLength: strconv.FormatUint(torrent.Completed, 10),
+		UploadLength:    strconv.FormatUint(torrent.Uploaded, 10),
+		DownloadSpeed:   strconv.FormatInt(torrent.DlSpeed, 10),
+		UploadSpeed:     strconv.FormatInt(torrent.UpSpeed, 10),
+		InfoHash:        torrent.Hash,
+		NumSeeders:      strconv.Itoa(torrent.NumSeeds),
+		Connections:     strconv.Itoa(torrent.NumSeeds + torrent.NumLeechs),
+		Dir:             torrent.SavePath,
+		Files:           make([]rpc.FileInfo, 0, len(files)),
+	}
+	status.BitTorrent.Info.Name = torrent.Name
+
+	// 元数据获取完成前文件列表为空，此时不视为 BT 任务，避免被误判为做种中
+	if len(files) == 1 {
+		status.BitTorrent.Mode = "single"
+	} else if len(files) > 1 {
+		status.BitTorrent.Mode = "multi"
+	}
+
+	for i, file := range files {
+		status.Files = append(status.Files, rpc.FileInfo{
+			Index:           strconv.Itoa(i + 1),
+			Path:            filepath.Join(torrent.SavePath, file.Name),
+			Length:          strconv.FormatUint(file.Size, 10),
+			CompletedLength: strconv.FormatUint(uint64(float64(file.Size)*file.Progress), 10),
+			Selected:        strconv.FormatBool(file.Priority > 0),
+		})
+	}
+
+	switch torrent.State {
+	case "error", "missingFiles":
+		status.Status = "error"
+		status.ErrorMessage = "qBittorrent reports torrent state " + torrent.State
+	case "pausedUP", "stoppedUP":
+		status.Status = "complete"
+	case "pausedDL", "stoppedDL":
+		status.Status = "paused"
+	case "queuedDL", "checkingDL", "checkingResumeData", "allocating":
+		status.Status = "waiting"
+	case "uploading", "stalledUP", "queuedUP", "forcedUP", "checkingUP":
+		status.Status = "active"
+		status.Seeder = "true"
+		// 做种时以已选文件的完成大小判断下载完成
+		status.CompletedLength = status.TotalLength
+	default:
+		// downloading、stalledDL、metaDL、forcedDL、moving 等
+		status.Status = "active"
+	}
+
+	return status
+}
@@ -0,0 +1,152 @@
+package qbittorrent
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
+	"github.com/cloudreve/Cloudreve/v3/pkg/mocks/requestmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+func response(status int, body string, header http.Header) *request.Response {
+	return &request.Response{
+		Response: &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		},
+	}
+}
+
+func newTestClient() (*Client, *requestmock.RequestMock) {
+	mockHttp := &requestmock.RequestMock{}
+	client := New(model.Aria2Option{Server: "http://qb/", Username: "admin", Token: "pass", TempPath: "/tmp"})
+	client.Client = mockHttp
+	client.sid = "sid"
+	client.initialized = true
+	return client, mockHttp
+}
+
+func TestClient_Init(t *testing.T) {
+	a := assert.New(t)
+
+	// 登录成功
+	{
+		client, mockHttp := newTestClient()
+		client.initialized = false
+		mockHttp.On("Request", "POST", "http://qb/api/v2/auth/login", testMock.Anything, testMock.Anything).
+			Return(response(200, "Ok.", http.Header{"Set-Cookie": {"SID=new; path=/"}}))
+		a.NoError(client.Init())
+		a.True(client.Initialized())
+		a.Equal("new", client.sid)
+		mockHttp.AssertExpectations(t)
+	}
+
+	// 未返回会话
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", "http://qb/api/v2/auth/login", testMock.Anything, testMock.Anything).
+			Return(response(200, "Fails.", http.Header{}))
+		a.Equal(ErrLoginFailed, client.Init())
+		a.False(client.Initialized())
+	}
+}
+
+func TestClient_CreateTask(t *testing.T) {
+	a := assert.New(t)
+
+	// 成功
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", "http://qb/api/v2/torrents/add", testMock.Anything, testMock.Anything).
+			Return(response(200, "Ok.", nil))
+		gid, err := client.CreateTask(&model.Download{Source: "magnet:?xt=urn:btih:abc"}, nil)
+		a.NoError(err)
+		a.Len(gid, 32)
+		mockHttp.AssertExpectations(t)
+	}
+
+	// 会话过期后重新登录
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", "http://qb/api/v2/torrents/add", testMock.Anything, testMock.Anything).
+			Return(response(403, "Forbidden", nil)).Once()
+		mockHttp.On("Request", "POST", "http://qb/api/v2/auth/login", testMock.Anything, testMock.Anything).
+			Return(response(200, "Ok.", http.Header{"Set-Cookie": {"SID=new"}}))
+		mockHttp.On("Request", "POST", "http://qb/api/v2/torrents/add", testMock.Anything, testMock.Anything).
+			Return(response(200, "Fails.", nil)).Once()
+		_, err := client.CreateTask(&model.Download{Source: "magnet:?xt=urn:btih:abc"}, nil)
+		a.Error(err)
+		mockHttp.AssertExpectations(t)
+	}
+}
+
+func TestClient_Status(t *testing.T) {
+	a := assert.New(t)
+
+	// 未找到任务
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "GET", "http://qb/api/v2/torrents/info?tag=gid", testMock.Anything, testMock.Anything).
+			Return(response(200, "[]", nil))
+		_, err := client.Status(&model.Download{GID: "gid"})
+		a.Equal(ErrTaskNotFound, err)
+	}
+
+	// 下载中
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "GET", "http://qb/api/v2/torrents/info?tag=gid", testMock.Anything, testMock.Anything).
+			Return(response(200, `[{"hash":"h","name":"demo","state":"downloading","size":300,"completed":100,"dlspeed":10,"save_path":"/tmp/aria2/gid"}]`, nil))
+		mockHttp.On("Request", "GET", "http://qb/api/v2/torrents/files?hash=h", testMock.Anything, testMock.Anything).
+			Return(response(200, `[{"name":"demo/a.mkv","size":200,"progress":0.5,"priority":1},{"name":"demo/b.txt","size":100,"progress":0,"priority":0}]`, nil))
+		status, err := client.Status(&model.Download{GID: "gid"})
+		a.NoError(err)
+		a.Equal(common.Downloading, common.GetStatus(status))
+		a.Equal("300", status.TotalLength)
+		a.Equal("/tmp/aria2/gid", status.Dir)
+		a.Equal("multi", status.BitTorrent.Mode)
+		a.Len(status.Files, 2)
+		a.Equal("1", status.Files[0].Index)
+		a.Equal("/tmp/aria2/gid/demo/a.mkv", status.Files[0].Path)
+		a.Equal("true", status.Files[0].Selected)
+		a.Equal("false", status.Files[1].Selected)
+	}
+}
+
+func TestConvertStatus(t *testing.T) {
+	a := assert.New(t)
+	files := []torrentFile{{Name: "a", Size: 10, Progress: 1, Priority: 1}}
+
+	// 获取元数据中，不视为做种
+	a.Equal(common.Downloading, common.GetStatus(convertStatus("gid", &torrentInfo{State: "metaDL"}, nil)))
+	// 排队中
+	a.Equal(common.Ready, common.GetStatus(convertStatus("gid", &torrentInfo{State: "queuedDL"}, files)))
+	// 暂停
+	a.Equal(common.Paused, common.GetStatus(convertStatus("gid", &torrentInfo{State: "pausedDL"}, files)))
+	// 做种中
+	a.Equal(common.Seeding, common.GetStatus(convertStatus("gid", &torrentInfo{State: "stalledUP", Size: 10, Completed: 10}, files)))
+	// 做种完成
+	a.Equal(common.Complete, common.GetStatus(convertStatus("gid", &torrentInfo{State: "pausedUP"}, files)))
+	// 出错
+	a.Equal(common.Error, common.GetStatus(convertStatus("gid", &torrentInfo{State: "missingFiles"}, files)))
+}
+
+func TestClient_Select(t *testing.T) {
+	a := assert.New(t)
+	client, mockHttp := newTestClient()
+	mockHttp.On("Request", "GET", "http://qb/api/v2/torrents/info?tag=gid", testMock.Anything, testMock.Anything).
+		Return(response(200, `[{"hash":"h"}]`, nil))
+	mockHttp.On("Request", "GET", "http://qb/api/v2/torrents/files?hash=h", testMock.Anything, testMock.Anything).
+		Return(response(200, `[{"name":"a"},{"name":"b"},{"name":"c"}]`, nil))
+	mockHttp.On("Request", "POST", "http://qb/api/v2/torrents/filePrio", testMock.Anything, testMock.Anything).
+		Return(response(200, "", nil)).Twice()
+	a.NoError(client.Select(&model.Download{GID: "gid"}, []int{2}))
+	mockHttp.AssertExpectations(t)
+}
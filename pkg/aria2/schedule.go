@@ -0,0 +1,168 @@
+package aria2
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// appliedLimits 已下发给下载器的任务限速，避免每次调度重复设置
+var appliedLimits = struct {
+	sync.Mutex
+	tasks map[uint]int
+}{tasks: make(map[uint]int)}
+
+// ParseWindow 解析 "HH:MM-HH:MM" 格式的时间段，返回距零点的起止时间，结束早于开始时表示跨越零点
+func ParseWindow(window string) (time.Duration, time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(window), "-")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid time window %q", window)
+	}
+
+	var res [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid time window %q: %w", window, err)
+		}
+		res[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+
+	return res[0], res[1], nil
+}
+
+// InWindows 返回给定时间是否位于任意一个时间段内，未设置时间段时不做限制
+func InWindows(windows []string, now time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+
+	offset := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	for _, window := range windows {
+		start, end, err := ParseWindow(window)
+		if err != nil {
+			util.Log().Warning("Skipping download time window: %s", err)
+			continue
+		}
+
+		if start <= end {
+			if offset >= start && offset < end {
+				return true
+			}
+		} else if offset >= start || offset < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Schedule 按用户组配置的时间段暂停、恢复离线下载任务，并在用户组下载中的任务间平分总下载速度上限
+func Schedule(pool cluster.Pool, now time.Time) {
+	tasks := model.GetDownloadsByStatus(common.Ready, common.Downloading, common.Paused)
+	users := make(map[uint]*model.User)
+	running := make(map[uint][]*model.Download)
+	limits := make(map[uint]int)
+	nodes := make(map[uint]common.Aria2)
+	seen := make(map[uint]bool, len(tasks))
+
+	for i := range tasks {
+		task := &tasks[i]
+		seen[task.ID] = true
+		user, ok := users[task.UserID]
+		if !ok {
+			user = task.GetOwner()
+			users[task.UserID] = user
+		}
+		if user == nil {
+			continue
+		}
+
+		options := user.Group.OptionsSerialized
+		if len(options.Aria2Windows) == 0 && options.Aria2SpeedLimit == 0 && !hasAppliedLimit(task.ID) {
+			continue
+		}
+
+		node := pool.GetNodeByID(task.GetNodeID())
+		if node == nil {
+			continue
+		}
+		instance := node.GetAria2Instance()
+		nodes[task.ID] = instance
+
+		allowed := InWindows(options.Aria2Windows, now)
+		if !allowed && task.Status != common.Paused {
+			if err := instance.Pause(task); err != nil {
+				util.Log().Warning("Failed to pause download task %q outside of allowed time window: %s", task.GID, err)
+			} else {
+				util.Log().Debug("Download task %q is paused outside of allowed time window.", task.GID)
+			}
+			continue
+		}
+
+		if allowed && task.Status == common.Paused && len(options.Aria2Windows) > 0 {
+			if err := instance.Resume(task); err != nil {
+				util.Log().Warning("Failed to resume download task %q: %s", task.GID, err)
+				continue
+			}
+			util.Log().Debug("Download task %q is resumed in allowed time window.", task.GID)
+		}
+
+		if allowed {
+			running[user.GroupID] = append(running[user.GroupID], task)
+			limits[user.GroupID] = options.Aria2SpeedLimit
+		}
+	}
+
+	appliedLimits.Lock()
+	defer appliedLimits.Unlock()
+
+	for groupID, list := range running {
+		limit := 0
+		if limits[groupID] > 0 {
+			limit = limits[groupID] / len(list)
+			if limit == 0 {
+				limit = 1
+			}
+		}
+
+		for _, task := range list {
+			applied, ok := appliedLimits.tasks[task.ID]
+			if (ok && applied == limit) || (!ok && limit == 0) {
+				continue
+			}
+
+			if err := nodes[task.ID].SetSpeedLimit(task, limit); err != nil {
+				util.Log().Warning("Failed to set speed limit of download task %q: %s", task.GID, err)
+				continue
+			}
+
+			if limit == 0 {
+				delete(appliedLimits.tasks, task.ID)
+			} else {
+				appliedLimits.tasks[task.ID] = limit
+			}
+		}
+	}
+
+	// 清理已结束任务的限速记录
+	for id := range appliedLimits.tasks {
+		if !seen[id] {
+			delete(appliedLimits.tasks, id)
+		}
+	}
+}
+
+func hasAppliedLimit(id uint) bool {
+	appliedLimits.Lock()
+	defer appliedLimits.Unlock()
+
+	_, ok := appliedLimits.tasks[id]
+	return ok
+}
@@ -0,0 +1,91 @@
+package aria2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
+	"github.com/cloudreve/Cloudreve/v3/pkg/mocks"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+func TestInWindows(t *testing.T) {
+	a := assert.New(t)
+	at := func(hour, minute int) time.Time {
+		return time.Date(2022, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	a.True(InWindows(nil, at(12, 0)))
+	a.True(InWindows([]string{"09:00-18:00"}, at(9, 0)))
+	a.False(InWindows([]string{"09:00-18:00"}, at(18, 0)))
+	a.True(InWindows([]string{"23:00-07:00"}, at(23, 30)))
+	a.True(InWindows([]string{"23:00-07:00"}, at(6, 59)))
+	a.False(InWindows([]string{"23:00-07:00"}, at(12, 0)))
+	a.True(InWindows([]string{"invalid", "12:00-13:00"}, at(12, 30)))
+	a.False(InWindows([]string{"invalid"}, at(12, 30)))
+
+	_, _, err := ParseWindow("25:00-01:00")
+	a.Error(err)
+}
+
+func TestSchedule(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.Local)
+	newPool := func(mockAria2 *mocks.Aria2Mock) *mocks.NodePoolMock {
+		mockNode := &mocks.NodeMock{}
+		mockNode.On("GetAria2Instance").Return(mockAria2)
+		mockPool := &mocks.NodePoolMock{}
+		mockPool.On("GetNodeByID", uint(1)).Return(mockNode)
+		return mockPool
+	}
+	groupRows := func(options string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "options"}).AddRow(1, options)
+	}
+	downloadRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "g_id", "status", "user_id", "node_id"}).
+			AddRow(1, "1", common.Downloading, 1, 1).
+			AddRow(2, "2", common.Paused, 1, 1)
+	}
+
+	// 时间段外暂停下载中的任务
+	{
+		mock.ExpectQuery("SELECT(.+)downloads").WillReturnRows(downloadRows())
+		mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "group_id"}).AddRow(1, 1))
+		mock.ExpectQuery("SELECT(.+)groups").WillReturnRows(groupRows(`{"aria2_windows":["23:00-07:00"]}`))
+		mockAria2 := &mocks.Aria2Mock{}
+		mockAria2.On("Pause", testMock.Anything).Return(nil).Once()
+		Schedule(newPool(mockAria2), now)
+		a.NoError(mock.ExpectationsWereMet())
+		mockAria2.AssertExpectations(t)
+	}
+
+	// 时间段内恢复已暂停的任务，并平分速度上限
+	{
+		mock.ExpectQuery("SELECT(.+)downloads").WillReturnRows(downloadRows())
+		mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "group_id"}).AddRow(1, 1))
+		mock.ExpectQuery("SELECT(.+)groups").WillReturnRows(groupRows(`{"aria2_windows":["09:00-18:00"],"aria2_speed_limit":1000}`))
+		mockAria2 := &mocks.Aria2Mock{}
+		mockAria2.On("Resume", testMock.Anything).Return(nil).Once()
+		mockAria2.On("SetSpeedLimit", testMock.Anything, 500).Return(nil).Twice()
+		Schedule(newPool(mockAria2), now)
+		a.NoError(mock.ExpectationsWereMet())
+		mockAria2.AssertExpectations(t)
+		a.Equal(map[uint]int{1: 500, 2: 500}, appliedLimits.tasks)
+	}
+
+	// 取消上限后恢复不限速，清理已结束任务的记录
+	{
+		mock.ExpectQuery("SELECT(.+)downloads").WillReturnRows(sqlmock.NewRows([]string{"id", "g_id", "status", "user_id", "node_id"}).
+			AddRow(1, "1", common.Downloading, 1, 1))
+		mock.ExpectQuery("SELECT(.+)users").WillReturnRows(sqlmock.NewRows([]string{"id", "group_id"}).AddRow(1, 1))
+		mock.ExpectQuery("SELECT(.+)groups").WillReturnRows(groupRows(`{}`))
+		mockAria2 := &mocks.Aria2Mock{}
+		mockAria2.On("SetSpeedLimit", testMock.Anything, 0).Return(nil).Once()
+		Schedule(newPool(mockAria2), now)
+		a.NoError(mock.ExpectationsWereMet())
+		mockAria2.AssertExpectations(t)
+		a.Empty(appliedLimits.tasks)
+	}
+}
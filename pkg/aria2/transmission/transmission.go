@@ -0,0 +1,514 @@
+package transmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/rpc"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gofrs/uuid"
+)
+
+// DriverName 使用 Transmission 作为离线下载器时的驱动标识
+const DriverName = "transmission"
+
+// SessionHeader Transmission 用于防范 CSRF 的会话请求头
+const SessionHeader = "X-Transmission-Session-Id"
+
+const (
+	deleteTempFileDuration = 60 * time.Second
+
+	// gidLength 任务 GID 的长度，取种子 hash 的前缀
+	gidLength = 32
+)
+
+// Transmission 种子状态
+const (
+	statusStopped = iota
+	statusCheckWait
+	statusCheck
+	statusDownloadWait
+	statusDownload
+	statusSeedWait
+	statusSeed
+)
+
+// errorLocal Transmission 本地错误，其余错误为 Tracker 警告，不影响下载
+const errorLocal = 3
+
+var (
+	// ErrTaskNotFound 未找到下载任务对应的种子
+	ErrTaskNotFound = errors.New("torrent not found in Transmission")
+)
+
+// torrentFields 查询种子状态时需要的字段
+var torrentFields = []string{
+	"hashString", "name", "status", "error", "errorString", "sizeWhenDone", "leftUntilDone",
+	"uploadedEver", "rateDownload", "rateUpload", "downloadDir", "files", "fileStats",
+	"peersConnected", "peersSendingToUs", "isFinished",
+}
+
+// Client Transmission RPC 客户端，实现离线下载处理接口。
+// 任务以种子 hash 的前 32 位作为 GID
+type Client struct {
+	Client request.Client
+
+	lock        sync.RWMutex
+	options     model.Aria2Option
+	session     string
+	initialized bool
+	// hashes GID 到完整种子 hash 的缓存
+	hashes map[string]string
+
+	deletePaddingDuration time.Duration
+}
+
+// torrent torrent-get 返回的种子信息
+type torrent struct {
+	HashString    string `json:"hashString"`
+	Name          string `json:"name"`
+	Status        int    `json:"status"`
+	Error         int    `json:"error"`
+	ErrorString   string `json:"errorString"`
+	SizeWhenDone  uint64 `json:"sizeWhenDone"`
+	LeftUntilDone uint64 `json:"leftUntilDone"`
+	UploadedEver  uint64 `json:"uploadedEver"`
+	RateDownload  int64  `json:"rateDownload"`
+	RateUpload    int64  `json:"rateUpload"`
+	DownloadDir   string `json:"downloadDir"`
+	Files         []struct {
+		Name           string `json:"name"`
+		Length         uint64 `json:"length"`
+		BytesCompleted uint64 `json:"bytesCompleted"`
+	} `json:"files"`
+	FileStats []struct {
+		Wanted bool `json:"wanted"`
+	} `json:"fileStats"`
+	PeersConnected   int  `json:"peersConnected"`
+	PeersSendingToUs int  `json:"peersSendingToUs"`
+	IsFinished       bool `json:"isFinished"`
+}
+
+// rpcResponse RPC 响应
+type rpcResponse struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// New 根据节点的离线下载配置新建客户端
+func New(options model.Aria2Option) *Client {
+	return &Client{
+		Client:                request.NewClient(),
+		options:               options,
+		hashes:                make(map[string]string),
+		deletePaddingDuration: deleteTempFileDuration,
+	}
+}
+
+// Init 获取 RPC 会话
+func (c *Client) Init() error {
+	c.lock.Lock()
+	c.initialized = false
+	c.lock.Unlock()
+
+	if _, err := c.Version(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	c.initialized = true
+	c.lock.Unlock()
+	return nil
+}
+
+// Initialized 返回客户端是否已连接
+func (c *Client) Initialized() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.initialized
+}
+
+// Version 返回 Transmission 版本，用于检查连通性
+func (c *Client) Version() (string, error) {
+	var res struct {
+		Version string `json:"version"`
+	}
+	if err := c.call("session-get", map[string]interface{}{"fields": []string{"version"}}, &res); err != nil {
+		return "", err
+	}
+
+	return res.Version, nil
+}
+
+// CreateTask 创建新的下载任务
+func (c *Client) CreateTask(task *model.Download, options map[string]interface{}) (string, error) {
+	dir, _ := uuid.NewV4()
+
+	c.lock.RLock()
+	downloadDir := filepath.Join(c.options.TempPath, "aria2", dir.String())
+	c.lock.RUnlock()
+
+	var res struct {
+		Added     *torrent `json:"torrent-added"`
+		Duplicate *torrent `json:"torrent-duplicate"`
+	}
+	if err := c.call("torrent-add", map[string]interface{}{
+		"filename":     task.Source,
+		"download-dir": downloadDir,
+	}, &res); err != nil {
+		return "", err
+	}
+
+	// 重复添加的种子仍在被其他任务使用，不可接管
+	if res.Added == nil || len(res.Added.HashString) < gidLength {
+		return "", errors.New("Transmission refused to add torrent, it may already exist")
+	}
+
+	gid := res.Added.HashString[:gidLength]
+	c.lock.Lock()
+	c.hashes[gid] = res.Added.HashString
+	c.lock.Unlock()
+
+	return gid, nil
+}
+
+// Status 返回任务状态，转换为 aria2 格式
+func (c *Client) Status(task *model.Download) (rpc.StatusInfo, error) {
+	t, err := c.torrent(task.GID, torrentFields)
+	if err != nil {
+		return rpc.StatusInfo{}, err
+	}
+
+	return convertStatus(task.GID, t), nil
+}
+
+// Cancel 取消任务并删除已下载的文件
+func (c *Client) Cancel(task *model.Download) error {
+	err := c.remove(task.GID)
+	if err != nil {
+		util.Log().Warning("Failed to cancel task %q: %s", task.GID, err)
+	}
+
+	return err
+}
+
+// Select 选择要下载的文件，files 为从 1 开始的文件序号
+func (c *Client) Select(task *model.Download, files []int) error {
+	t, err := c.torrent(task.GID, []string{"hashString", "files"})
+	if err != nil {
+		return err
+	}
+
+	selected := make(map[int]bool, len(files))
+	for _, index := range files {
+		selected[index-1] = true
+	}
+
+	wanted, unwanted := []int{}, []int{}
+	for i := range t.Files {
+		if selected[i] {
+			wanted = append(wanted, i)
+		} else {
+			unwanted = append(unwanted, i)
+		}
+	}
+
+	return c.call("torrent-set", map[string]interface{}{
+		"ids":            []string{t.HashString},
+		"files-wanted":   wanted,
+		"files-unwanted": unwanted,
+	}, nil)
+}
+
+// Pause 暂停任务
+func (c *Client) Pause(task *model.Download) error {
+	return c.action(task.GID, "torrent-stop", nil)
+}
+
+// Resume 恢复已暂停的任务
+func (c *Client) Resume(task *model.Download) error {
+	return c.action(task.GID, "torrent-start", nil)
+}
+
+// SetSpeedLimit 设置任务的最大下载速度（字节/秒），0 表示不限制。Transmission 以 KB/s 为单位，不足 1 KB/s 时按 1 KB/s 处理
+func (c *Client) SetSpeedLimit(task *model.Download, limit int) error {
+	kb := limit / 1024
+	if limit > 0 && kb == 0 {
+		kb = 1
+	}
+
+	return c.action(task.GID, "torrent-set", map[string]interface{}{
+		"downloadLimited": limit > 0,
+		"downloadLimit":   kb,
+	})
+}
+
+// action 对任务对应的种子调用 RPC 方法
+func (c *Client) action(gid, method string, arguments map[string]interface{}) error {
+	hash, err := c.hash(gid)
+	if err != nil {
+		return err
+	}
+
+	if arguments == nil {
+		arguments = make(map[string]interface{})
+	}
+	arguments["ids"] = []string{hash}
+	return c.call(method, arguments, nil)
+}
+
+// GetConfig 返回离线下载配置
+func (c *Client) GetConfig() model.Aria2Option {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.options
+}
+
+// DeleteTempFile 从 Transmission 中移除任务并删除下载目录
+func (c *Client) DeleteTempFile(task *model.Download) error {
+	if err := c.remove(task.GID); err != nil && err != ErrTaskNotFound {
+		util.Log().Warning("Failed to remove torrent %q from Transmission: %s", task.GID, err)
+	}
+
+	// Transmission 与 Cloudreve 位于同一主机时，异步清理可能残留的下载目录
+	go func(d time.Duration, src string) {
+		time.Sleep(d)
+		if src == "" {
+			return
+		}
+		if err := os.RemoveAll(src); err != nil {
+			util.Log().Warning("Failed to delete temp download folder: %q: %s", src, err)
+		}
+	}(c.deletePaddingDuration, task.Parent)
+
+	return nil
+}
+
+// remove 移除种子及已下载的数据
+func (c *Client) remove(gid string) error {
+	hash, err := c.hash(gid)
+	if err != nil {
+		return err
+	}
+
+	err = c.call("torrent-remove", map[string]interface{}{
+		"ids":               []string{hash},
+		"delete-local-data": true,
+	}, nil)
+	if err == nil {
+		c.lock.Lock()
+		delete(c.hashes, gid)
+		c.lock.Unlock()
+	}
+
+	return err
+}
+
+// hash 根据 GID 查找完整的种子 hash，缓存未命中时在所有种子中查找
+func (c *Client) hash(gid string) (string, error) {
+	c.lock.RLock()
+	hash, ok := c.hashes[gid]
+	c.lock.RUnlock()
+	if ok {
+		return hash, nil
+	}
+
+	var res struct {
+		Torrents []torrent `json:"torrents"`
+	}
+	if err := c.call("torrent-get", map[string]interface{}{"fields": []string{"hashString"}}, &res); err != nil {
+		return "", err
+	}
+
+	for _, t := range res.Torrents {
+		if gid != "" && strings.HasPrefix(t.HashString, gid) {
+			c.lock.Lock()
+			c.hashes[gid] = t.HashString
+			c.lock.Unlock()
+			return t.HashString, nil
+		}
+	}
+
+	return "", ErrTaskNotFound
+}
+
+// torrent 根据 GID 查询种子信息
+func (c *Client) torrent(gid string, fields []string) (*torrent, error) {
+	hash, err := c.hash(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	var res struct {
+		Torrents []torrent `json:"torrents"`
+	}
+	if err := c.call("torrent-get", map[string]interface{}{
+		"ids":    []string{hash},
+		"fields": fields,
+	}, &res); err != nil {
+		return nil, err
+	}
+
+	if len(res.Torrents) == 0 {
+		c.lock.Lock()
+		delete(c.hashes, gid)
+		c.lock.Unlock()
+		return nil, ErrTaskNotFound
+	}
+
+	return &res.Torrents[0], nil
+}
+
+// call 调用 RPC 方法，会话失效时使用服务端返回的新会话重试一次
+func (c *Client) call(method string, arguments interface{}, result interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"method":    method,
+		"arguments": arguments,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.send(body)
+	if err == nil && resp.Response.StatusCode == http.StatusConflict {
+		resp.Response.Body.Close()
+		c.lock.Lock()
+		c.session = resp.Response.Header.Get(SessionHeader)
+		c.lock.Unlock()
+		resp, err = c.send(body)
+	}
+	if err != nil {
+		return err
+	}
+
+	raw, err := resp.CheckHTTPResponse(http.StatusOK).GetResponse()
+	if err != nil {
+		return err
+	}
+
+	var res rpcResponse
+	if err := json.Unmarshal([]byte(raw), &res); err != nil {
+		return fmt.Errorf("failed to parse Transmission response: %w", err)
+	}
+
+	if res.Result != "success" {
+		return fmt.Errorf("Transmission returns error: %s", res.Result)
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(res.Arguments, result); err != nil {
+			return fmt.Errorf("failed to parse Transmission response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Client) send(body []byte) (*request.Response, error) {
+	c.lock.RLock()
+	server := strings.TrimSuffix(c.options.Server, "/")
+	username, password := c.options.Username, c.options.Token
+	session := c.session
+	timeout := c.options.Timeout
+	c.lock.RUnlock()
+
+	if !strings.HasSuffix(server, "/rpc") {
+		server += "/transmission/rpc"
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if session != "" {
+		header.Set(SessionHeader, session)
+	}
+	if username != "" || password != "" {
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(username, password)
+		header.Set("Authorization", req.Header.Get("Authorization"))
+	}
+
+	opts := []request.Option{request.WithHeader(header), request.WithContentLength(int64(len(body)))}
+	if timeout > 0 {
+		opts = append(opts, request.WithTimeout(time.Duration(timeout)*time.Second))
+	}
+
+	resp := c.Client.Request("POST", server, bytes.NewReader(body), opts...)
+	return resp, resp.Err
+}
+
+// convertStatus 将 Transmission 的种子状态转换为 aria2 格式
+func convertStatus(gid string, t *torrent) rpc.StatusInfo {
+	completed := t.SizeWhenDone - t.LeftUntilDone
+	if t.LeftUntilDone > t.SizeWhenDone {
+		completed = 0
+	}
+
+	status := rpc.StatusInfo{
+		Gid:             gid,
+		TotalLength:     strconv.FormatUint(t.SizeWhenDone, 10),
+		CompletedLength: strconv.FormatUint(completed, 10),
+		UploadLength:    strconv.FormatUint(t.UploadedEver, 10),
+		DownloadSpeed:   strconv.FormatInt(t.RateDownload, 10),
+		UploadSpeed:     strconv.FormatInt(t.RateUpload, 10),
+		InfoHash:        t.HashString,
+		NumSeeders:      strconv.Itoa(t.PeersSendingToUs),
+		Connections:     strconv.Itoa(t.PeersConnected),
+		Dir:             t.DownloadDir,
+		Files:           make([]rpc.FileInfo, 0, len(t.Files)),
+	}
+	status.BitTorrent.Info.Name = t.Name
+
+	// 元数据获取完成前文件列表为空，此时不视为 BT 任务，避免被误判为做种中
+	if len(t.Files) == 1 {
+		status.BitTorrent.Mode = "single"
+	} else if len(t.Files) > 1 {
+		status.BitTorrent.Mode = "multi"
+	}
+
+	for i, file := range t.Files {
+		selected := true
+		if i < len(t.FileStats) {
+			selected = t.FileStats[i].Wanted
+		}
+		status.Files = append(status.Files, rpc.FileInfo{
+			Index:           strconv.Itoa(i + 1),
+			Path:            filepath.Join(t.DownloadDir, file.Name),
+			Length:          strconv.FormatUint(file.Length, 10),
+			CompletedLength: strconv.FormatUint(file.BytesCompleted, 10),
+			Selected:        strconv.FormatBool(selected),
+		})
+	}
+
+	done := len(t.Files) > 0 && t.LeftUntilDone == 0
+	switch {
+	case t.Error == errorLocal:
+		status.Status = "error"
+		status.ErrorMessage = t.ErrorString
+	case t.Status == statusStopped && (t.IsFinished || done):
+		status.Status = "complete"
+	case t.Status == statusStopped:
+		status.Status = "paused"
+	case t.Status == statusDownloadWait:
+		status.Status = "waiting"
+	case t.Status == statusSeedWait || t.Status == statusSeed:
+		status.Status = "active"
+		status.Seeder = "true"
+	default:
+		// 校验中、下载中
+		status.Status = "active"
+	}
+
+	return status
+}
@@ -0,0 +1,181 @@
+package transmission
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
+	"github.com/cloudreve/Cloudreve/v3/pkg/mocks/requestmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+const (
+	testEndpoint = "http://tr/transmission/rpc"
+	testHash     = "0123456789abcdef0123456789abcdef01234567"
+	testGID      = "0123456789abcdef0123456789abcdef"
+)
+
+func response(status int, body string, header http.Header) *request.Response {
+	return &request.Response{
+		Response: &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+		},
+	}
+}
+
+func newTestClient() (*Client, *requestmock.RequestMock) {
+	mockHttp := &requestmock.RequestMock{}
+	client := New(model.Aria2Option{Server: "http://tr/", Username: "admin", Token: "pass", TempPath: "/tmp"})
+	client.Client = mockHttp
+	client.session = "session"
+	client.initialized = true
+	return client, mockHttp
+}
+
+func TestClient_Init(t *testing.T) {
+	a := assert.New(t)
+
+	// 会话过期后重试
+	{
+		client, mockHttp := newTestClient()
+		client.initialized = false
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(409, "", http.Header{SessionHeader: {"new"}})).Once()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(200, `{"result":"success","arguments":{"version":"4.0.5"}}`, http.Header{})).Once()
+		a.NoError(client.Init())
+		a.True(client.Initialized())
+		a.Equal("new", client.session)
+		mockHttp.AssertExpectations(t)
+	}
+
+	// 认证失败
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(401, "", http.Header{}))
+		a.Error(client.Init())
+		a.False(client.Initialized())
+	}
+
+	// RPC 返回错误
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(200, `{"result":"method name not recognized"}`, http.Header{}))
+		a.Error(client.Init())
+	}
+}
+
+func TestClient_CreateTask(t *testing.T) {
+	a := assert.New(t)
+
+	// 成功
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(200, `{"result":"success","arguments":{"torrent-added":{"hashString":"`+testHash+`"}}}`, http.Header{}))
+		gid, err := client.CreateTask(&model.Download{Source: "magnet:?xt=urn:btih:" + testHash}, nil)
+		a.NoError(err)
+		a.Equal(testGID, gid)
+		a.Equal(testHash, client.hashes[gid])
+	}
+
+	// 种子已存在
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(200, `{"result":"success","arguments":{"torrent-duplicate":{"hashString":"`+testHash+`"}}}`, http.Header{}))
+		_, err := client.CreateTask(&model.Download{Source: "magnet:?xt=urn:btih:" + testHash}, nil)
+		a.Error(err)
+	}
+}
+
+func TestClient_Status(t *testing.T) {
+	a := assert.New(t)
+
+	// 缓存未命中时查找种子
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(200, `{"result":"success","arguments":{"torrents":[{"hashString":"`+testHash+`"}]}}`, http.Header{})).Once()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(200, `{"result":"success","arguments":{"torrents":[{"hashString":"`+testHash+`","name":"a","status":4,"sizeWhenDone":10,"leftUntilDone":4,"downloadDir":"/tmp/d","files":[{"name":"a/1.txt","length":10,"bytesCompleted":6}],"fileStats":[{"wanted":true}]}]}}`, http.Header{})).Once()
+		status, err := client.Status(&model.Download{GID: testGID})
+		a.NoError(err)
+		a.Equal("active", status.Status)
+		a.Equal("6", status.CompletedLength)
+		a.Equal("single", status.BitTorrent.Mode)
+		a.Equal("1", status.Files[0].Index)
+		a.Equal("/tmp/d/a/1.txt", status.Files[0].Path)
+		a.Equal(common.Downloading, common.GetStatus(status))
+		mockHttp.AssertExpectations(t)
+	}
+
+	// 种子不存在
+	{
+		client, mockHttp := newTestClient()
+		mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+			Return(response(200, `{"result":"success","arguments":{"torrents":[]}}`, http.Header{}))
+		_, err := client.Status(&model.Download{GID: testGID})
+		a.Equal(ErrTaskNotFound, err)
+	}
+}
+
+func TestConvertStatus(t *testing.T) {
+	a := assert.New(t)
+	newTorrent := func(status, errCode int, left uint64, finished bool) *torrent {
+		t := &torrent{Status: status, Error: errCode, SizeWhenDone: 10, LeftUntilDone: left, IsFinished: finished}
+		t.Files = append(t.Files, struct {
+			Name           string `json:"name"`
+			Length         uint64 `json:"length"`
+			BytesCompleted uint64 `json:"bytesCompleted"`
+		}{Name: "1.txt", Length: 10, BytesCompleted: 10 - left})
+		return t
+	}
+
+	a.Equal(common.Downloading, common.GetStatus(convertStatus(testGID, newTorrent(statusDownload, 0, 5, false))))
+	a.Equal(common.Downloading, common.GetStatus(convertStatus(testGID, newTorrent(statusCheck, 0, 5, false))))
+	a.Equal(common.Ready, common.GetStatus(convertStatus(testGID, newTorrent(statusDownloadWait, 0, 5, false))))
+	a.Equal(common.Seeding, common.GetStatus(convertStatus(testGID, newTorrent(statusSeed, 0, 0, false))))
+	a.Equal(common.Paused, common.GetStatus(convertStatus(testGID, newTorrent(statusStopped, 0, 5, false))))
+	a.Equal(common.Complete, common.GetStatus(convertStatus(testGID, newTorrent(statusStopped, 0, 0, true))))
+	a.Equal(common.Error, common.GetStatus(convertStatus(testGID, newTorrent(statusDownload, errorLocal, 5, false))))
+	a.Equal(common.Downloading, common.GetStatus(convertStatus(testGID, newTorrent(statusDownload, 2, 5, false))))
+
+	// 元数据未就绪
+	a.Equal("", convertStatus(testGID, &torrent{Status: statusDownload}).BitTorrent.Mode)
+}
+
+func TestClient_Select(t *testing.T) {
+	a := assert.New(t)
+	client, mockHttp := newTestClient()
+	client.hashes[testGID] = testHash
+	mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+		Return(response(200, `{"result":"success","arguments":{"torrents":[{"hashString":"`+testHash+`","files":[{"name":"1"},{"name":"2"}]}]}}`, http.Header{})).Once()
+	mockHttp.On("Request", "POST", testEndpoint, testMock.MatchedBy(func(r *bytes.Reader) bool {
+		b, _ := ioutil.ReadAll(r)
+		return strings.Contains(string(b), `"files-unwanted":[0]`) && strings.Contains(string(b), `"files-wanted":[1]`)
+	}), testMock.Anything).
+		Return(response(200, `{"result":"success","arguments":{}}`, http.Header{})).Once()
+	a.NoError(client.Select(&model.Download{GID: testGID}, []int{2}))
+	mockHttp.AssertExpectations(t)
+}
+
+func TestClient_Cancel(t *testing.T) {
+	a := assert.New(t)
+	client, mockHttp := newTestClient()
+	client.hashes[testGID] = testHash
+	mockHttp.On("Request", "POST", testEndpoint, testMock.Anything, testMock.Anything).
+		Return(response(200, `{"result":"success","arguments":{}}`, http.Header{}))
+	a.NoError(client.Cancel(&model.Download{GID: testGID}))
+	a.NotContains(client.hashes, testGID)
+}
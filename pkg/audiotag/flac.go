@@ -0,0 +1,145 @@
+package audiotag
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+const (
+	flacBlockVorbisComment = 4
+	flacBlockPicture       = 6
+)
+
+func readFLAC(r io.Reader) (*Tags, error) {
+	if _, err := io.ReadFull(r, make([]byte, 4)); err != nil {
+		return nil, ErrMalformed
+	}
+
+	tags := &Tags{}
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, ErrMalformed
+		}
+
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7F
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		switch blockType {
+		case flacBlockVorbisComment, flacBlockPicture:
+			if size > maxBlockSize {
+				return nil, ErrMalformed
+			}
+			block := make([]byte, size)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return nil, ErrMalformed
+			}
+
+			if blockType == flacBlockVorbisComment {
+				if err := parseVorbisComment(block, tags); err != nil {
+					return nil, err
+				}
+			} else if tags.Picture == nil {
+				tags.Picture = parseFLACPicture(block)
+			}
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(size)); err != nil {
+				return nil, ErrMalformed
+			}
+		}
+
+		if last {
+			return tags, nil
+		}
+	}
+}
+
+// parseVorbisComment 解析 Vorbis 注释，FLAC、Ogg Vorbis 及 Opus 均使用此格式
+func parseVorbisComment(data []byte, tags *Tags) error {
+	nextString := func() (string, bool) {
+		if len(data) < 4 {
+			return "", false
+		}
+		length := int(binary.LittleEndian.Uint32(data))
+		if length > len(data)-4 {
+			return "", false
+		}
+		s := string(data[4 : 4+length])
+		data = data[4+length:]
+		return s, true
+	}
+
+	// 编码器信息
+	if _, ok := nextString(); !ok || len(data) < 4 {
+		return ErrMalformed
+	}
+
+	count := int(binary.LittleEndian.Uint32(data))
+	data = data[4:]
+	for i := 0; i < count; i++ {
+		comment, ok := nextString()
+		if !ok {
+			return ErrMalformed
+		}
+
+		kv := strings.SplitN(comment, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		if strings.EqualFold(kv[0], "METADATA_BLOCK_PICTURE") {
+			if raw, err := base64.StdEncoding.DecodeString(kv[1]); err == nil && tags.Picture == nil {
+				tags.Picture = parseFLACPicture(raw)
+			}
+			continue
+		}
+
+		tags.setField(kv[0], kv[1])
+	}
+
+	return nil
+}
+
+// parseFLACPicture 解析 FLAC PICTURE 块
+func parseFLACPicture(data []byte) *Picture {
+	next := func() ([]byte, bool) {
+		if len(data) < 4 {
+			return nil, false
+		}
+		length := int(binary.BigEndian.Uint32(data))
+		if length > len(data)-4 {
+			return nil, false
+		}
+		res := data[4 : 4+length]
+		data = data[4+length:]
+		return res, true
+	}
+
+	// 图片类型
+	if len(data) < 4 {
+		return nil
+	}
+	data = data[4:]
+
+	mime, ok := next()
+	if !ok {
+		return nil
+	}
+
+	// 描述
+	if _, ok := next(); !ok || len(data) < 16 {
+		return nil
+	}
+
+	// 宽度、高度、色深、索引颜色数
+	data = data[16:]
+	picture, ok := next()
+	if !ok || len(picture) == 0 {
+		return nil
+	}
+
+	return &Picture{MIMEType: string(mime), Data: picture}
+}
@@ -0,0 +1,244 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ID3v2 帧对应的字段名
+var id3Frames = map[string]string{
+	"TIT2": "TITLE", "TT2": "TITLE",
+	"TPE1": "ARTIST", "TP1": "ARTIST",
+	"TPE2": "ALBUMARTIST", "TP2": "ALBUMARTIST",
+	"TALB": "ALBUM", "TAL": "ALBUM",
+	"TCON": "GENRE", "TCO": "GENRE",
+	"TRCK": "TRACK", "TRK": "TRACK",
+	"TYER": "YEAR", "TYE": "YEAR", "TDRC": "YEAR",
+}
+
+// 流派中的 ID3v1 编号引用，如 "(17)Rock"
+var id3GenreRef = regexp.MustCompile(`^\(\d+\)`)
+
+func readID3v2(r io.Reader) (*Tags, error) {
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrMalformed
+	}
+
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+	if version < 2 || version > 4 || size > maxBlockSize {
+		return nil, ErrMalformed
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, ErrMalformed
+	}
+
+	// 反同步处理
+	if flags&0x80 != 0 && version < 4 {
+		data = bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+
+	// 跳过扩展头
+	if flags&0x40 != 0 && version > 2 && len(data) >= 4 {
+		extSize := int(binary.BigEndian.Uint32(data[:4]))
+		if version == 3 {
+			extSize += 4
+		} else {
+			extSize = syncsafe(data[:4])
+		}
+		if extSize > len(data) {
+			return nil, ErrMalformed
+		}
+		data = data[extSize:]
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	tags := &Tags{}
+	for len(data) >= headerLen {
+		id := string(data[:idLen])
+		if id[0] == 0 {
+			break
+		}
+
+		var frameSize int
+		switch version {
+		case 2:
+			frameSize = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(data[4:8]))
+		default:
+			frameSize = syncsafe(data[4:8])
+		}
+
+		if frameSize > len(data)-headerLen {
+			break
+		}
+		frame := data[headerLen : headerLen+frameSize]
+		data = data[headerLen+frameSize:]
+
+		if field, ok := id3Frames[id]; ok && len(frame) > 0 {
+			value := decodeID3Text(frame[0], frame[1:])
+			if field == "GENRE" {
+				value = id3GenreRef.ReplaceAllString(value, "")
+			}
+			tags.setField(field, value)
+			continue
+		}
+
+		if (id == "APIC" || id == "PIC") && tags.Picture == nil {
+			tags.Picture = decodeID3Picture(id == "PIC", frame)
+		}
+	}
+
+	return tags, nil
+}
+
+// readID3v1 读取文件末尾的 ID3v1 标签，仅补全尚未读取到的字段
+func readID3v1(r io.ReadSeeker, tags *Tags) bool {
+	if _, err := r.Seek(-128, io.SeekEnd); err != nil {
+		return false
+	}
+
+	data := make([]byte, 128)
+	if _, err := io.ReadFull(r, data); err != nil || !bytes.HasPrefix(data, []byte("TAG")) {
+		return false
+	}
+
+	tags.setField("TITLE", latin1(data[3:33]))
+	tags.setField("ARTIST", latin1(data[33:63]))
+	tags.setField("ALBUM", latin1(data[63:93]))
+	tags.setField("YEAR", latin1(data[93:97]))
+	if data[125] == 0 && data[126] != 0 {
+		tags.setField("TRACK", strconv.Itoa(int(data[126])))
+	}
+	return true
+}
+
+// decodeID3Picture 解析 APIC (v2.3/v2.4) 或 PIC (v2.2) 帧
+func decodeID3Picture(v22 bool, frame []byte) *Picture {
+	if len(frame) < 2 {
+		return nil
+	}
+	encoding := frame[0]
+	frame = frame[1:]
+
+	var mime string
+	if v22 {
+		if len(frame) < 3 {
+			return nil
+		}
+		mime = "image/" + strings.ToLower(string(frame[:3]))
+		if mime == "image/jpg" {
+			mime = "image/jpeg"
+		}
+		frame = frame[3:]
+	} else {
+		end := bytes.IndexByte(frame, 0)
+		if end < 0 {
+			return nil
+		}
+		mime = string(frame[:end])
+		frame = frame[end+1:]
+	}
+
+	// 图片类型
+	if len(frame) < 1 {
+		return nil
+	}
+	frame = frame[1:]
+
+	// 跳过描述
+	_, frame = splitID3Text(encoding, frame)
+	if len(frame) == 0 {
+		return nil
+	}
+
+	if !strings.Contains(mime, "/") {
+		mime = "image/" + strings.ToLower(mime)
+	}
+	return &Picture{MIMEType: mime, Data: frame}
+}
+
+// splitID3Text 按编码切分以终止符结尾的文本，返回文本及剩余数据
+func splitID3Text(encoding byte, data []byte) (string, []byte) {
+	if encoding == 1 || encoding == 2 {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return decodeID3Text(encoding, data[:i]), data[i+2:]
+			}
+		}
+		return decodeID3Text(encoding, data), nil
+	}
+
+	end := bytes.IndexByte(data, 0)
+	if end < 0 {
+		return decodeID3Text(encoding, data), nil
+	}
+	return decodeID3Text(encoding, data[:end]), data[end+1:]
+}
+
+// decodeID3Text 按 ID3v2 文本编码解码，多个值只保留第一个
+func decodeID3Text(encoding byte, data []byte) string {
+	var s string
+	switch encoding {
+	case 0:
+		s = latin1(data)
+	case 1, 2:
+		s = utf16String(data, encoding == 2)
+	default:
+		s = string(data)
+	}
+
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+func utf16String(data []byte, bigEndian bool) string {
+	if len(data) >= 2 {
+		if data[0] == 0xFF && data[1] == 0xFE {
+			bigEndian, data = false, data[2:]
+		} else if data[0] == 0xFE && data[1] == 0xFF {
+			bigEndian, data = true, data[2:]
+		}
+	}
+
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, binary.BigEndian.Uint16(data[i:]))
+		} else {
+			units = append(units, binary.LittleEndian.Uint16(data[i:]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+func latin1(data []byte) string {
+	runes := make([]rune, 0, len(data))
+	for _, b := range data {
+		if b == 0 {
+			break
+		}
+		runes = append(runes, rune(b))
+	}
+	return string(runes)
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
@@ -0,0 +1,66 @@
+package audiotag
+
+import (
+	"bytes"
+	"io"
+)
+
+var (
+	vorbisCommentHeader = []byte("\x03vorbis")
+	opusCommentHeader   = []byte("OpusTags")
+)
+
+// readOgg 读取 Ogg 流中第二个数据包的 Vorbis 注释
+func readOgg(r io.Reader) (*Tags, error) {
+	var (
+		packets [][]byte
+		current []byte
+		read    int
+	)
+
+	header := make([]byte, 27)
+	for len(packets) < 2 {
+		if _, err := io.ReadFull(r, header); err != nil || !bytes.HasPrefix(header, []byte("OggS")) {
+			return nil, ErrMalformed
+		}
+
+		lacing := make([]byte, header[26])
+		if _, err := io.ReadFull(r, lacing); err != nil {
+			return nil, ErrMalformed
+		}
+
+		for _, size := range lacing {
+			segment := make([]byte, size)
+			if _, err := io.ReadFull(r, segment); err != nil {
+				return nil, ErrMalformed
+			}
+
+			read += int(size)
+			if read > maxBlockSize {
+				return nil, ErrMalformed
+			}
+
+			current = append(current, segment...)
+			if size < 255 {
+				packets = append(packets, current)
+				current = nil
+			}
+		}
+	}
+
+	comment := packets[1]
+	switch {
+	case bytes.HasPrefix(comment, vorbisCommentHeader):
+		comment = comment[len(vorbisCommentHeader):]
+	case bytes.HasPrefix(comment, opusCommentHeader):
+		comment = comment[len(opusCommentHeader):]
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	tags := &Tags{}
+	if err := parseVorbisComment(comment, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
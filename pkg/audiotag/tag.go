@@ -0,0 +1,112 @@
+package audiotag
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported audio format")
+	ErrMalformed         = errors.New("malformed audio tag")
+)
+
+// 单个标签块允许的最大长度，避免读取异常数据时分配过多内存
+const maxBlockSize = 16 << 20
+
+// Tags 音频文件的元数据
+type Tags struct {
+	Title       string
+	Artist      string
+	Album       string
+	AlbumArtist string
+	Genre       string
+	Year        int
+	Track       int
+	Picture     *Picture
+}
+
+// Picture 音频文件内嵌的封面图片
+type Picture struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Read 读取 MP3 (ID3v2/ID3v1)、FLAC、Ogg Vorbis 及 Opus 文件的元数据
+func Read(r io.ReadSeeker) (*Tags, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("ID3")):
+		tags, err := readID3v2(r)
+		if err != nil {
+			return nil, err
+		}
+		readID3v1(r, tags)
+		return tags, nil
+	case bytes.Equal(magic, []byte("fLaC")):
+		return readFLAC(r)
+	case bytes.Equal(magic, []byte("OggS")):
+		return readOgg(r)
+	}
+
+	// 仅有 ID3v1 标签的 MP3 文件
+	tags := &Tags{}
+	if readID3v1(r, tags) {
+		return tags, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// setField 按字段名设置元数据，已有值的字段不会被覆盖
+func (t *Tags) setField(name, value string) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	if value == "" {
+		return
+	}
+
+	switch strings.ToUpper(name) {
+	case "TITLE":
+		setIfEmpty(&t.Title, value)
+	case "ARTIST":
+		setIfEmpty(&t.Artist, value)
+	case "ALBUM":
+		setIfEmpty(&t.Album, value)
+	case "ALBUMARTIST", "ALBUM ARTIST":
+		setIfEmpty(&t.AlbumArtist, value)
+	case "GENRE":
+		setIfEmpty(&t.Genre, value)
+	case "DATE", "YEAR":
+		if t.Year == 0 {
+			t.Year = leadingInt(value)
+		}
+	case "TRACKNUMBER", "TRACK":
+		if t.Track == 0 {
+			t.Track = leadingInt(value)
+		}
+	}
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// leadingInt 解析字符串开头的数字，如 "2004-05-01"、"3/12"
+func leadingInt(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
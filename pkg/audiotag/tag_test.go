@@ -0,0 +1,143 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func id3Frame(id string, body []byte) []byte {
+	frame := []byte(id)
+	size := make([]byte, 4)
+	binary.BigEndian.PutUint32(size, uint32(len(body)))
+	frame = append(frame, size...)
+	frame = append(frame, 0, 0)
+	return append(frame, body...)
+}
+
+func id3Tag(frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(header, body...)
+}
+
+func vorbisComment(comments ...string) []byte {
+	var b bytes.Buffer
+	writeString := func(s string) {
+		binary.Write(&b, binary.LittleEndian, uint32(len(s)))
+		b.WriteString(s)
+	}
+	writeString("vendor")
+	binary.Write(&b, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		writeString(c)
+	}
+	return b.Bytes()
+}
+
+func flacPicture(mime string, data []byte) []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, uint32(3))
+	binary.Write(&b, binary.BigEndian, uint32(len(mime)))
+	b.WriteString(mime)
+	binary.Write(&b, binary.BigEndian, uint32(0))
+	b.Write(make([]byte, 16))
+	binary.Write(&b, binary.BigEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestRead_ID3(t *testing.T) {
+	asserts := assert.New(t)
+
+	artist := []byte{1, 0xFF, 0xFE, 'A', 0, 'r', 0, 't', 0}
+	picture := append([]byte{0}, []byte("image/png\x00\x03desc\x00")...)
+	picture = append(picture, 0x89, 'P', 'N', 'G')
+	file := id3Tag(
+		id3Frame("TIT2", append([]byte{0}, "Song"...)),
+		id3Frame("TPE1", artist),
+		id3Frame("TRCK", append([]byte{3}, "3/12"...)),
+		id3Frame("TCON", append([]byte{0}, "(17)Rock"...)),
+		id3Frame("APIC", picture),
+	)
+	file = append(file, make([]byte, 100)...)
+
+	// ID3v1 补全专辑与年份
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "Ignored")
+	copy(v1[63:], "Album")
+	copy(v1[93:], "1999")
+	file = append(file, v1...)
+
+	tags, err := Read(bytes.NewReader(file))
+	asserts.NoError(err)
+	asserts.Equal("Song", tags.Title)
+	asserts.Equal("Art", tags.Artist)
+	asserts.Equal("Album", tags.Album)
+	asserts.Equal("Rock", tags.Genre)
+	asserts.Equal(1999, tags.Year)
+	asserts.Equal(3, tags.Track)
+	asserts.Equal("image/png", tags.Picture.MIMEType)
+	asserts.Equal([]byte{0x89, 'P', 'N', 'G'}, tags.Picture.Data)
+}
+
+func TestRead_FLAC(t *testing.T) {
+	asserts := assert.New(t)
+
+	comment := vorbisComment("TITLE=Song", "artist=Art", "DATE=2004-05-01", "TRACKNUMBER=7")
+	picture := flacPicture("image/jpeg", []byte{0xFF, 0xD8})
+
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write([]byte{0, 0, 0, 2, 0, 0})
+	b.Write([]byte{flacBlockVorbisComment, 0, byte(len(comment) >> 8), byte(len(comment))})
+	b.Write(comment)
+	b.Write([]byte{0x80 | flacBlockPicture, 0, byte(len(picture) >> 8), byte(len(picture))})
+	b.Write(picture)
+
+	tags, err := Read(bytes.NewReader(b.Bytes()))
+	asserts.NoError(err)
+	asserts.Equal("Song", tags.Title)
+	asserts.Equal("Art", tags.Artist)
+	asserts.Equal(2004, tags.Year)
+	asserts.Equal(7, tags.Track)
+	asserts.Equal("image/jpeg", tags.Picture.MIMEType)
+}
+
+func TestRead_Ogg(t *testing.T) {
+	asserts := assert.New(t)
+
+	page := func(packets ...[]byte) []byte {
+		header := append([]byte("OggS"), make([]byte, 22)...)
+		var lacing, body []byte
+		for _, p := range packets {
+			lacing = append(lacing, byte(len(p)))
+			body = append(body, p...)
+		}
+		header = append(header, byte(len(lacing)))
+		return append(append(header, lacing...), body...)
+	}
+
+	comment := append([]byte("OpusTags"), vorbisComment("TITLE=Opus Song", "ALBUM=Album")...)
+	file := append(page([]byte("OpusHead")), page(comment)...)
+
+	tags, err := Read(bytes.NewReader(file))
+	asserts.NoError(err)
+	asserts.Equal("Opus Song", tags.Title)
+	asserts.Equal("Album", tags.Album)
+}
+
+func TestRead_Unsupported(t *testing.T) {
+	asserts := assert.New(t)
+
+	_, err := Read(bytes.NewReader([]byte("RIFF0000WAVE")))
+	asserts.Equal(ErrUnsupportedFormat, err)
+
+	_, err = Read(bytes.NewReader([]byte("ID3")))
+	asserts.Equal(ErrUnsupportedFormat, err)
+}
@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
 	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
@@ -21,8 +23,13 @@ var (
 	ErrAuthHeaderMissing = serializer.NewError(serializer.CodeNoPermissionErr, "authorization header is missing", nil)
 	ErrExpiresMissing    = serializer.NewError(serializer.CodeNoPermissionErr, "expire timestamp is missing", nil)
 	ErrExpired           = serializer.NewError(serializer.CodeSignExpired, "signature expired", nil)
+	ErrBindingMismatch   = serializer.NewError(serializer.CodeCredentialInvalid, "signed url is bound to another client", nil)
+	ErrURIUsed           = serializer.NewError(serializer.CodeSignExpired, "signed url has already been used", nil)
 )
 
+// usedURICachePrefix 已使用的一次性签名 URL
+const usedURICachePrefix = "signed_uri_used_"
+
 const CrHeaderPrefix = "X-Cr-"
 
 // General 通用的认证接口
@@ -94,8 +101,40 @@ func getSignContent(r *http.Request) (rawSignString string) {
 	return rawSignString
 }
 
+// URIBinding 签名 URL 的额外使用限制，与 Path 一同签名
+type URIBinding struct {
+	// IP 仅允许此客户端 IP 使用，为空时不限制
+	IP string
+	// OneTime 仅允许使用一次
+	OneTime bool
+}
+
+func (b URIBinding) encode() string {
+	values := url.Values{}
+	if b.IP != "" {
+		values.Set("ip", b.IP)
+	}
+	if b.OneTime {
+		values.Set("once", "1")
+	}
+	return values.Encode()
+}
+
+// uriSignContent 返回 URI 待签名的内容，绑定了额外限制时一并签名
+func uriSignContent(path, binding string) string {
+	if binding == "" {
+		return path
+	}
+	return path + "?" + binding
+}
+
 // SignURI 对URI进行签名,签名只针对Path部分，query部分不做验证
 func SignURI(instance Auth, uri string, expires int64) (*url.URL, error) {
+	return SignBoundURI(instance, uri, expires, URIBinding{})
+}
+
+// SignBoundURI 对URI进行签名，并限制签名 URL 的使用者和使用次数
+func SignBoundURI(instance Auth, uri string, expires int64, binding URIBinding) (*url.URL, error) {
 	// 处理有效期
 	if expires != 0 {
 		expires += time.Now().Unix()
@@ -107,11 +146,15 @@ func SignURI(instance Auth, uri string, expires int64) (*url.URL, error) {
 	}
 
 	// 生成签名
-	sign := instance.Sign(base.Path, expires)
+	bind := binding.encode()
+	sign := instance.Sign(uriSignContent(base.Path, bind), expires)
 
 	// 将签名加到URI中
 	queries := base.Query()
 	queries.Set("sign", sign)
+	if bind != "" {
+		queries.Set("bind", bind)
+	}
 	base.RawQuery = queries.Encode()
 
 	return base, nil
@@ -119,13 +162,52 @@ func SignURI(instance Auth, uri string, expires int64) (*url.URL, error) {
 
 // CheckURI 对URI进行鉴权
 func CheckURI(instance Auth, url *url.URL) error {
+	return CheckBoundURI(instance, url, "")
+}
+
+// CheckBoundURI 对URI进行鉴权，并检查签名时绑定的额外限制，clientIP 为请求方 IP
+func CheckBoundURI(instance Auth, uri *url.URL, clientIP string) error {
 	//获取待验证的签名正文
-	queries := url.Query()
+	queries := uri.Query()
 	sign := queries.Get("sign")
+	bind := queries.Get("bind")
 	queries.Del("sign")
-	url.RawQuery = queries.Encode()
+	queries.Del("bind")
+	uri.RawQuery = queries.Encode()
+
+	if err := instance.Check(uriSignContent(uri.Path, bind), sign); err != nil {
+		return err
+	}
+
+	if bind == "" {
+		return nil
+	}
+
+	binding, err := url.ParseQuery(bind)
+	if err != nil {
+		return ErrAuthFailed.WithError(err)
+	}
+
+	if ip := binding.Get("ip"); ip != "" && ip != clientIP {
+		return ErrBindingMismatch
+	}
+
+	if binding.Get("once") == "1" {
+		// 记录保留至签名过期，并发请求中只有首个设置成功的可以通过
+		ttl := 0
+		if expires, err := strconv.ParseInt(sign[strings.LastIndex(sign, ":")+1:], 10, 64); err == nil && expires > 0 {
+			ttl = int(expires-time.Now().Unix()) + 1
+		}
+		first, err := cache.SetNX(usedURICachePrefix+sign, true, ttl)
+		if err != nil {
+			return err
+		}
+		if !first {
+			return ErrURIUsed
+		}
+	}
 
-	return instance.Check(url.Path, sign)
+	return nil
 }
 
 // Init 初始化通用鉴权器
@@ -3,6 +3,7 @@ package auth
 import (
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -50,6 +51,44 @@ func TestCheckURI(t *testing.T) {
 	}
 }
 
+func TestCheckBoundURI(t *testing.T) {
+	asserts := assert.New(t)
+	General = HMACAuth{SecretKey: []byte(util.RandStringRunes(256))}
+
+	// 绑定 IP
+	{
+		sign, err := SignBoundURI(General, "/api/ok", 10, URIBinding{IP: "1.2.3.4"})
+		asserts.NoError(err)
+		asserts.NotEmpty(sign.Query().Get("bind"))
+		asserts.ErrorIs(CheckBoundURI(General, copyURL(sign), "5.6.7.8"), ErrBindingMismatch)
+		asserts.ErrorIs(CheckURI(General, copyURL(sign)), ErrBindingMismatch)
+		asserts.NoError(CheckBoundURI(General, copyURL(sign), "1.2.3.4"))
+	}
+
+	// 篡改绑定内容
+	{
+		sign, err := SignBoundURI(General, "/api/ok", 10, URIBinding{IP: "1.2.3.4"})
+		asserts.NoError(err)
+		queries := sign.Query()
+		queries.Del("bind")
+		sign.RawQuery = queries.Encode()
+		asserts.ErrorIs(CheckBoundURI(General, sign, "5.6.7.8"), ErrAuthFailed)
+	}
+
+	// 一次性
+	{
+		sign, err := SignBoundURI(General, "/api/once", 10, URIBinding{OneTime: true})
+		asserts.NoError(err)
+		asserts.NoError(CheckBoundURI(General, copyURL(sign), "1.2.3.4"))
+		asserts.ErrorIs(CheckBoundURI(General, copyURL(sign), "1.2.3.4"), ErrURIUsed)
+	}
+}
+
+func copyURL(u *url.URL) *url.URL {
+	res := *u
+	return &res
+}
+
 func TestSignRequest(t *testing.T) {
 	asserts := assert.New(t)
 	General = HMACAuth{SecretKey: []byte(util.RandStringRunes(256))}
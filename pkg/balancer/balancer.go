@@ -9,6 +9,8 @@ func NewBalancer(strategy string) Balancer {
 	switch strategy {
 	case "RoundRobin":
 		return &RoundRobin{}
+	case "LeastLoad":
+		return &LeastLoad{}
 	default:
 		return &RoundRobin{}
 	}
@@ -9,4 +9,5 @@ func TestNewBalancer(t *testing.T) {
 	a := assert.New(t)
 	a.NotNil(NewBalancer(""))
 	a.IsType(&RoundRobin{}, NewBalancer("RoundRobin"))
+	a.IsType(&LeastLoad{}, NewBalancer("LeastLoad"))
 }
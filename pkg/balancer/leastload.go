@@ -0,0 +1,51 @@
+package balancer
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrPeerNotLoader 节点未实现 Loader 接口
+var ErrPeerNotLoader = errors.New("Peer does not report its load")
+
+// Loader 可报告当前负载的节点
+type Loader interface {
+	Load() float64
+}
+
+// LeastLoad 选取负载最低的节点，负载相同的节点之间轮流选取
+type LeastLoad struct {
+	rr RoundRobin
+}
+
+// NextPeer 返回负载最低的节点，nodes 中的元素需实现 Loader 接口
+func (l *LeastLoad) NextPeer(nodes interface{}) (error, interface{}) {
+	v := reflect.ValueOf(nodes)
+	if v.Kind() != reflect.Slice {
+		return ErrInputNotSlice, nil
+	}
+
+	if v.Len() == 0 {
+		return ErrNoAvaliableNode, nil
+	}
+
+	var candidates []int
+	min := 0.0
+	for i := 0; i < v.Len(); i++ {
+		peer, ok := v.Index(i).Interface().(Loader)
+		if !ok {
+			return ErrPeerNotLoader, nil
+		}
+
+		load := peer.Load()
+		if len(candidates) == 0 || load < min {
+			min = load
+			candidates = []int{i}
+		} else if load == min {
+			candidates = append(candidates, i)
+		}
+	}
+
+	next := candidates[l.rr.NextIndex(len(candidates))]
+	return nil, v.Index(next).Interface()
+}
@@ -0,0 +1,55 @@
+package balancer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type loadPeer struct {
+	name string
+	load float64
+}
+
+func (p loadPeer) Load() float64 {
+	return p.load
+}
+
+func TestLeastLoad_NextPeer(t *testing.T) {
+	a := assert.New(t)
+	l := &LeastLoad{}
+
+	// not slice
+	{
+		err, _ := l.NextPeer("s")
+		a.Equal(ErrInputNotSlice, err)
+	}
+
+	// no nodes
+	{
+		err, _ := l.NextPeer([]loadPeer{})
+		a.Equal(ErrNoAvaliableNode, err)
+	}
+
+	// not loader
+	{
+		err, _ := l.NextPeer([]string{"a"})
+		a.Equal(ErrPeerNotLoader, err)
+	}
+
+	// least load
+	{
+		err, res := l.NextPeer([]loadPeer{{"a", 2}, {"b", 0.5}, {"c", 1}})
+		a.NoError(err)
+		a.Equal("b", res.(loadPeer).name)
+	}
+
+	// ties are rotated
+	{
+		peers := []loadPeer{{"a", 1}, {"b", 1}, {"c", 3}}
+		_, first := l.NextPeer(peers)
+		_, second := l.NextPeer(peers)
+		a.NotEqual(first.(loadPeer).name, second.(loadPeer).name)
+		a.NotEqual("c", first.(loadPeer).name)
+		a.NotEqual("c", second.(loadPeer).name)
+	}
+}
@@ -50,6 +50,12 @@ type Driver interface {
 	// 取值，并返回是否成功
 	Get(key string) (interface{}, bool)
 
+	// 仅当值不存在时设置，并返回是否设置成功
+	SetNX(key string, value interface{}, ttl int) (bool, error)
+
+	// 原子地将计数加一并返回新值，同时将过期时间重设为ttl。计数只能通过 Incr 读取
+	Incr(key string, ttl int) (int, error)
+
 	// 批量取值，返回成功取值的map即不存在的值
 	Gets(keys []string, prefix string) (map[string]interface{}, []string)
 
@@ -71,6 +77,16 @@ func Set(key string, value interface{}, ttl int) error {
 	return Store.Set(key, value, ttl)
 }
 
+// SetNX 仅当缓存值不存在时设置，返回是否设置成功
+func SetNX(key string, value interface{}, ttl int) (bool, error) {
+	return Store.SetNX(key, value, ttl)
+}
+
+// Incr 原子地将计数加一并返回新值，同时重设过期时间
+func Incr(key string, ttl int) (int, error) {
+	return Store.Incr(key, ttl)
+}
+
 // Get 获取缓存值
 func Get(key string) (interface{}, bool) {
 	return Store.Get(key)
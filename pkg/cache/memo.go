@@ -13,6 +13,8 @@ import (
 // MemoStore 内存存储驱动
 type MemoStore struct {
 	Store *sync.Map
+	// setNXLock 保证 SetNX、Incr 的检查与设置不被其他 SetNX、Incr 打断
+	setNXLock sync.Mutex
 }
 
 // item 存储的对象
@@ -79,6 +81,35 @@ func (store *MemoStore) Set(key string, value interface{}, ttl int) error {
 	return nil
 }
 
+// SetNX 仅当值不存在或已过期时存储
+func (store *MemoStore) SetNX(key string, value interface{}, ttl int) (bool, error) {
+	store.setNXLock.Lock()
+	defer store.setNXLock.Unlock()
+	if _, ok := store.Get(key); ok {
+		return false, nil
+	}
+
+	store.Store.Store(key, newItem(value, ttl))
+	return true, nil
+}
+
+// Incr 将计数加一，不存在或已过期时从 0 开始计数
+func (store *MemoStore) Incr(key string, ttl int) (int, error) {
+	store.setNXLock.Lock()
+	defer store.setNXLock.Unlock()
+
+	n := 0
+	if value, ok := store.Get(key); ok {
+		if current, ok := value.(int); ok {
+			n = current
+		}
+	}
+
+	n++
+	store.Store.Store(key, newItem(n, ttl))
+	return n, nil
+}
+
 // Get 取值
 func (store *MemoStore) Get(key string) (interface{}, bool) {
 	return getValue(store.Store.Load(key))
@@ -27,6 +27,45 @@ func TestMemoStore_Set(t *testing.T) {
 	asserts.Equal("vAL", val.(itemWithTTL).Value)
 }
 
+func TestMemoStore_SetNX(t *testing.T) {
+	asserts := assert.New(t)
+	store := NewMemoStore()
+
+	// 不存在时设置
+	ok, err := store.SetNX("KEY", "vAL", -1)
+	asserts.NoError(err)
+	asserts.True(ok)
+
+	// 已存在
+	ok, err = store.SetNX("KEY", "vAL2", -1)
+	asserts.NoError(err)
+	asserts.False(ok)
+	val, _ := store.Get("KEY")
+	asserts.Equal("vAL", val)
+
+	// 已过期
+	store.Store.Store("EXPIRED", itemWithTTL{Value: "old", Expires: time.Now().Unix() - 10})
+	ok, err = store.SetNX("EXPIRED", "new", 10)
+	asserts.NoError(err)
+	asserts.True(ok)
+}
+
+func TestMemoStore_Incr(t *testing.T) {
+	asserts := assert.New(t)
+	store := NewMemoStore()
+
+	n, err := store.Incr("counter", 10)
+	asserts.NoError(err)
+	asserts.Equal(1, n)
+	n, _ = store.Incr("counter", 10)
+	asserts.Equal(2, n)
+
+	// 已过期时重新计数
+	store.Store.Store("expired", itemWithTTL{Value: 5, Expires: time.Now().Unix() - 10})
+	n, _ = store.Incr("expired", 10)
+	asserts.Equal(1, n)
+}
+
 func TestMemoStore_Get(t *testing.T) {
 	asserts := assert.New(t)
 	store := NewMemoStore()
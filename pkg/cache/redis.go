@@ -102,6 +102,57 @@ func (store *RedisStore) Set(key string, value interface{}, ttl int) error {
 
 }
 
+// SetNX 仅当值不存在时存储
+func (store *RedisStore) SetNX(key string, value interface{}, ttl int) (bool, error) {
+	rc := store.pool.Get()
+	defer rc.Close()
+
+	serialized, err := serializer(value)
+	if err != nil {
+		return false, err
+	}
+
+	if rc.Err() != nil {
+		return false, rc.Err()
+	}
+
+	var reply interface{}
+	if ttl > 0 {
+		reply, err = rc.Do("SET", key, serialized, "NX", "EX", ttl)
+	} else {
+		reply, err = rc.Do("SET", key, serialized, "NX")
+	}
+
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// Incr 使用 INCR 计数，计数以整数形式存储，不经过序列化
+func (store *RedisStore) Incr(key string, ttl int) (int, error) {
+	rc := store.pool.Get()
+	defer rc.Close()
+	if rc.Err() != nil {
+		return 0, rc.Err()
+	}
+
+	rc.Send("MULTI")
+	rc.Send("INCR", key)
+	if ttl > 0 {
+		rc.Send("EXPIRE", key, ttl)
+	}
+	values, err := redis.Values(rc.Do("EXEC"))
+	if err != nil {
+		return 0, err
+	}
+	if len(values) == 0 {
+		return 0, redis.ErrNil
+	}
+
+	return redis.Int(values[0], nil)
+}
+
 // Get 取值
 func (store *RedisStore) Get(key string) (interface{}, bool) {
 	rc := store.pool.Get()
@@ -225,3 +276,8 @@ func (store *RedisStore) Persist(path string) error {
 func (store *RedisStore) Restore(path string) error {
 	return nil
 }
+
+// Pool 返回底层连接池，供需要原子操作（如 Lua 脚本）的模块使用
+func (store *RedisStore) Pool() *redis.Pool {
+	return store.pool
+}
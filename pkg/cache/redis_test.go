@@ -96,6 +96,73 @@ func TestRedisStore_Set(t *testing.T) {
 
 }
 
+func TestRedisStore_SetNX(t *testing.T) {
+	asserts := assert.New(t)
+	conn := redigomock.NewConn()
+	pool := &redis.Pool{
+		Dial:    func() (redis.Conn, error) { return conn, nil },
+		MaxIdle: 10,
+	}
+	store := &RedisStore{pool: pool}
+
+	// 设置成功
+	{
+		conn.Command("SET", "test", redigomock.NewAnyData(), "NX", "EX", 10).Expect("OK")
+		ok, err := store.SetNX("test", "test val", 10)
+		asserts.NoError(err)
+		asserts.True(ok)
+	}
+
+	// 已存在
+	{
+		conn.Clear()
+		conn.Command("SET", "test", redigomock.NewAnyData(), "NX").Expect(nil)
+		ok, err := store.SetNX("test", "test val", -1)
+		asserts.NoError(err)
+		asserts.False(ok)
+	}
+
+	// 命令执行失败
+	{
+		conn.Clear()
+		conn.Command("SET", "test", redigomock.NewAnyData(), "NX").ExpectError(errors.New("error"))
+		_, err := store.SetNX("test", "test val", -1)
+		asserts.Error(err)
+	}
+}
+
+func TestRedisStore_Incr(t *testing.T) {
+	asserts := assert.New(t)
+	conn := redigomock.NewConn()
+	pool := &redis.Pool{
+		Dial:    func() (redis.Conn, error) { return conn, nil },
+		MaxIdle: 10,
+	}
+	store := &RedisStore{pool: pool}
+
+	// 计数成功
+	{
+		conn.Command("MULTI").Expect("OK")
+		conn.Command("INCR", "test").Expect("QUEUED")
+		conn.Command("EXPIRE", "test", 10).Expect("QUEUED")
+		conn.Command("EXEC").Expect([]interface{}{int64(3), int64(1)})
+		n, err := store.Incr("test", 10)
+		asserts.NoError(err)
+		asserts.Equal(3, n)
+	}
+
+	// 命令执行失败
+	{
+		conn.Clear()
+		conn.Command("MULTI").Expect("OK")
+		conn.Command("INCR", "test").Expect("QUEUED")
+		conn.Command("EXPIRE", "test", 10).Expect("QUEUED")
+		conn.Command("EXEC").ExpectError(errors.New("error"))
+		_, err := store.Incr("test", 10)
+		asserts.Error(err)
+	}
+}
+
 func TestRedisStore_Get(t *testing.T) {
 	asserts := assert.New(t)
 	conn := redigomock.NewConn()
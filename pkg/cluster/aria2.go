@@ -0,0 +1,258 @@
+package cluster
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
+	"github.com/cloudreve/Cloudreve/v3/pkg/balancer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+	 离线下载节点调度
+   ================
+*/
+
+// ErrNoAria2Node 没有可用的离线下载节点
+var ErrNoAria2Node = errors.New("no available aria2 node")
+
+// Aria2Health 离线下载节点的健康状态
+type Aria2Health struct {
+	NodeID    uint      `json:"id"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures"`
+	LastError string    `json:"last_error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// leastLoad 按负载调度时使用的负载均衡器，负载相同的节点之间轮流选取
+var leastLoad = &balancer.LeastLoad{}
+
+var aria2Health = struct {
+	sync.RWMutex
+	nodes map[uint]*Aria2Health
+}{nodes: make(map[uint]*Aria2Health)}
+
+// MarkAria2Failure 记录节点的一次离线下载调用失败，连续失败达到阈值后节点被视为不健康
+func MarkAria2Failure(id uint, err error) {
+	aria2Health.Lock()
+	defer aria2Health.Unlock()
+
+	health, ok := aria2Health.nodes[id]
+	if !ok {
+		health = &Aria2Health{NodeID: id}
+		aria2Health.nodes[id] = health
+	}
+
+	threshold := model.GetIntSetting("aria2_unhealthy_threshold", 3)
+	health.Failures++
+	health.LastError = err.Error()
+	health.CheckedAt = time.Now()
+	health.Healthy = health.Failures < threshold
+	if health.Failures == threshold {
+		util.Log().Warning("Aria2 node [ID=%d] is marked as unhealthy: %s", id, err)
+	}
+}
+
+// MarkAria2Success 记录节点的一次离线下载调用成功，并恢复节点的健康状态
+func MarkAria2Success(id uint) {
+	aria2Health.Lock()
+	defer aria2Health.Unlock()
+
+	health, ok := aria2Health.nodes[id]
+	if !ok {
+		health = &Aria2Health{NodeID: id, Healthy: true}
+		aria2Health.nodes[id] = health
+	}
+
+	if !health.Healthy {
+		util.Log().Info("Aria2 node [ID=%d] is recovered.", id)
+	}
+	health.Healthy = true
+	health.Failures = 0
+	health.LastError = ""
+	health.CheckedAt = time.Now()
+}
+
+// IsAria2Healthy 返回节点是否健康，尚未检查过的节点视为健康
+func IsAria2Healthy(id uint) bool {
+	aria2Health.RLock()
+	defer aria2Health.RUnlock()
+
+	if health, ok := aria2Health.nodes[id]; ok {
+		return health.Healthy
+	}
+	return true
+}
+
+// GetAria2Health 返回所有已检查节点的健康状态
+func GetAria2Health() []Aria2Health {
+	aria2Health.RLock()
+	defer aria2Health.RUnlock()
+
+	res := make([]Aria2Health, 0, len(aria2Health.nodes))
+	for _, health := range aria2Health.nodes {
+		res = append(res, *health)
+	}
+	return res
+}
+
+// CheckAria2Health 检查节点池中所有离线下载节点的连通性
+func CheckAria2Health(pool Pool) {
+	for _, node := range pool.NodesByFeature("aria2") {
+		var err error
+		switch n := node.(type) {
+		case *MasterNode:
+			err = n.checkAria2()
+		default:
+			if !node.IsActive() {
+				err = errors.New("node is offline")
+			}
+		}
+
+		if err != nil {
+			MarkAria2Failure(node.ID(), err)
+		} else {
+			MarkAria2Success(node.ID())
+		}
+	}
+}
+
+// checkAria2 检查主机 Aria2 RPC 服务的连通性，未连接时尝试重新连接
+func (node *MasterNode) checkAria2() error {
+	node.lock.RLock()
+	initialized := node.aria2RPC.Initialized
+	client := node.downloader
+	node.lock.RUnlock()
+
+	if client != nil {
+		if !client.Initialized() {
+			if err := client.Init(); err != nil {
+				return err
+			}
+		}
+
+		_, err := client.Version()
+		return err
+	}
+
+	if !initialized {
+		if err := node.aria2RPC.Init(); err != nil {
+			return err
+		}
+	}
+
+	_, err := node.aria2RPC.Caller.GetVersion()
+	return err
+}
+
+// aria2Node 附带当前负载的离线下载节点
+type aria2Node struct {
+	Node
+	load float64
+}
+
+// Load 返回节点负载，即进行中的任务数与节点权重之比
+func (n aria2Node) Load() float64 {
+	return n.load
+}
+
+// BalanceAria2Node 为用户组选取处理离线下载任务的节点。仅选取用户组允许使用的健康节点，
+// 站点设置为按负载调度时选取负载最低的节点，否则使用 lb 选取；exclude 中的节点不会被选取
+func BalanceAria2Node(pool Pool, group *model.Group, lb balancer.Balancer, exclude ...uint) (Node, error) {
+	allowed := make(map[uint]bool, len(group.OptionsSerialized.Aria2Nodes))
+	for _, id := range group.OptionsSerialized.Aria2Nodes {
+		allowed[id] = true
+	}
+	excluded := make(map[uint]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+
+	var candidates []Node
+	for _, node := range pool.NodesByFeature("aria2") {
+		id := node.ID()
+		if (len(allowed) > 0 && !allowed[id]) || excluded[id] || !IsAria2Healthy(id) {
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoAria2Node
+	}
+
+	if model.GetSettingByNameWithDefault("aria2_balance_strategy", "RoundRobin") != "LeastLoad" {
+		err, res := lb.NextPeer(candidates)
+		if err != nil {
+			return nil, err
+		}
+		return res.(Node), nil
+	}
+
+	counts, err := model.CountDownloadsByNode(common.Ready, common.Downloading, common.Paused)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]aria2Node, 0, len(candidates))
+	for _, node := range candidates {
+		rank := 1
+		if m := node.DBModel(); m != nil && m.Rank > 0 {
+			rank = m.Rank
+		}
+		loaded = append(loaded, aria2Node{Node: node, load: float64(counts[node.ID()]) / float64(rank)})
+	}
+
+	err, res := leastLoad.NextPeer(loaded)
+	if err != nil {
+		return nil, err
+	}
+	return res.(aria2Node).Node, nil
+}
+
+// CreateAria2Task 选取节点并创建离线下载任务，创建失败的节点会被记录并跳过，改为尝试其他节点
+func CreateAria2Task(pool Pool, group *model.Group, lb balancer.Balancer, task *model.Download, exclude ...uint) (Node, string, error) {
+	options := aria2TaskOptions(group, task)
+
+	var lastErr error
+	for {
+		node, err := BalanceAria2Node(pool, group, lb, exclude...)
+		if err != nil {
+			if lastErr != nil {
+				return nil, "", lastErr
+			}
+			return nil, "", err
+		}
+
+		gid, err := node.GetAria2Instance().CreateTask(task, options)
+		if err == nil {
+			MarkAria2Success(node.ID())
+			return node, gid, nil
+		}
+
+		util.Log().Warning("Failed to create download task on node [ID=%d]: %s", node.ID(), err)
+		MarkAria2Failure(node.ID(), err)
+		exclude = append(exclude, node.ID())
+		lastErr = err
+	}
+}
+
+// aria2TaskOptions 生成创建任务时使用的下载配置。任务设置了做种上限时由 Cloudreve 决定何时停止做种，
+// 避免 aria2 按默认分享率提前停止
+func aria2TaskOptions(group *model.Group, task *model.Download) map[string]interface{} {
+	options := make(map[string]interface{}, len(group.OptionsSerialized.Aria2Options)+1)
+	for k, v := range group.OptionsSerialized.Aria2Options {
+		options[k] = v
+	}
+
+	if task.SeedingLimited() {
+		options["seed-ratio"] = "0.0"
+		delete(options, "seed-time")
+	}
+
+	return options
+}
@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/balancer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAria2Health(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(cache.Set("setting_aria2_unhealthy_threshold", "2", -1))
+
+	a.True(IsAria2Healthy(100))
+
+	MarkAria2Failure(100, errors.New("error"))
+	a.True(IsAria2Healthy(100))
+	MarkAria2Failure(100, errors.New("error"))
+	a.False(IsAria2Healthy(100))
+
+	MarkAria2Success(100)
+	a.True(IsAria2Healthy(100))
+	a.NotEmpty(GetAria2Health())
+}
+
+func TestBalanceAria2Node(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(cache.Set("setting_aria2_unhealthy_threshold", "1", -1))
+	p := &NodePool{}
+	p.Init()
+	p.featureMap["aria2"] = []Node{
+		&MasterNode{Model: &model.Node{Model: gorm.Model{ID: 11}, Rank: 1}},
+		&MasterNode{Model: &model.Node{Model: gorm.Model{ID: 12}, Rank: 2}},
+		&MasterNode{Model: &model.Node{Model: gorm.Model{ID: 13}}},
+	}
+	group := &model.Group{}
+	lb := balancer.NewBalancer("RoundRobin")
+
+	// 用户组限定节点
+	{
+		a.NoError(cache.Set("setting_aria2_balance_strategy", "RoundRobin", -1))
+		group.OptionsSerialized.Aria2Nodes = []uint{12}
+		node, err := BalanceAria2Node(p, group, lb)
+		a.NoError(err)
+		a.EqualValues(12, node.ID())
+	}
+
+	// 排除节点后无可用节点
+	{
+		node, err := BalanceAria2Node(p, group, lb, 12)
+		a.Equal(ErrNoAria2Node, err)
+		a.Nil(node)
+	}
+
+	// 跳过不健康节点
+	{
+		MarkAria2Failure(12, errors.New("error"))
+		_, err := BalanceAria2Node(p, group, lb)
+		a.Equal(ErrNoAria2Node, err)
+		MarkAria2Success(12)
+	}
+
+	// 按负载选取
+	{
+		a.NoError(cache.Set("setting_aria2_balance_strategy", "LeastLoad", -1))
+		group.OptionsSerialized.Aria2Nodes = nil
+		mock.ExpectQuery("SELECT(.+)downloads(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"node_id", "count"}).AddRow(11, 2).AddRow(12, 3).AddRow(13, 4))
+		node, err := BalanceAria2Node(p, group, lb)
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.EqualValues(12, node.ID())
+	}
+
+	// 统计负载失败
+	{
+		mock.ExpectQuery("SELECT(.+)downloads(.+)").WillReturnError(errors.New("error"))
+		_, err := BalanceAria2Node(p, group, lb)
+		a.NoError(mock.ExpectationsWereMet())
+		a.Error(err)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/qbittorrent"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/rpc"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/transmission"
 	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
 	"github.com/cloudreve/Cloudreve/v3/pkg/mq"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
@@ -26,9 +28,29 @@ const (
 )
 
 type MasterNode struct {
-	Model    *model.Node
-	aria2RPC rpcService
-	lock     sync.RWMutex
+	Model      *model.Node
+	aria2RPC   rpcService
+	downloader downloader
+	lock       sync.RWMutex
+}
+
+// downloader aria2 以外的第三方下载器客户端
+type downloader interface {
+	common.Aria2
+	Initialized() bool
+	Version() (string, error)
+}
+
+// newDownloader 根据离线下载配置中的驱动创建第三方下载器客户端，使用 aria2 时返回 nil
+func newDownloader(options model.Aria2Option) downloader {
+	switch options.Driver {
+	case qbittorrent.DriverName:
+		return qbittorrent.New(options)
+	case transmission.DriverName:
+		return transmission.New(options)
+	default:
+		return nil
+	}
 }
 
 // RPCService 通过RPC服务的Aria2任务管理器
@@ -50,6 +72,7 @@ type clientOptions struct {
 func (node *MasterNode) Init(nodeModel *model.Node) {
 	node.lock.Lock()
 	node.Model = nodeModel
+	node.downloader = nil
 	node.aria2RPC.parent = node
 	node.aria2RPC.retryDuration = statusRetryDuration
 	node.aria2RPC.deletePaddingDuration = deleteTempFileDuration
@@ -57,7 +80,20 @@ func (node *MasterNode) Init(nodeModel *model.Node) {
 
 	node.lock.RLock()
 	if node.Model.Aria2Enabled {
+		options := node.Model.Aria2OptionsSerialized
 		node.lock.RUnlock()
+
+		// 使用第三方下载器
+		if client := newDownloader(options); client != nil {
+			node.lock.Lock()
+			node.downloader = client
+			node.lock.Unlock()
+			if err := client.Init(); err != nil {
+				util.Log().Warning("Failed to connect to %s: %s", options.Driver, err)
+			}
+			return
+		}
+
 		node.aria2RPC.Init()
 		return
 	}
@@ -127,6 +163,16 @@ func (node *MasterNode) GetAria2Instance() common.Aria2 {
 		return &common.DummyAria2{}
 	}
 
+	if client := node.downloader; client != nil {
+		node.lock.RUnlock()
+		if !client.Initialized() {
+			client.Init()
+			return &common.DummyAria2{}
+		}
+
+		return client
+	}
+
 	if !node.aria2RPC.Initialized {
 		node.lock.RUnlock()
 		node.aria2RPC.Init()
@@ -248,6 +294,21 @@ func (r *rpcService) Select(task *model.Download, files []int) error {
 	return err
 }
 
+func (r *rpcService) Pause(task *model.Download) error {
+	_, err := r.Caller.Pause(task.GID)
+	return err
+}
+
+func (r *rpcService) Resume(task *model.Download) error {
+	_, err := r.Caller.Unpause(task.GID)
+	return err
+}
+
+func (r *rpcService) SetSpeedLimit(task *model.Download, limit int) error {
+	_, err := r.Caller.ChangeOption(task.GID, map[string]interface{}{"max-download-limit": strconv.Itoa(limit)})
+	return err
+}
+
 func (r *rpcService) GetConfig() model.Aria2Option {
 	r.parent.lock.RLock()
 	defer r.parent.lock.RUnlock()
@@ -259,6 +320,13 @@ func (s *rpcService) DeleteTempFile(task *model.Download) error {
 	s.parent.lock.RLock()
 	defer s.parent.lock.RUnlock()
 
+	// 仍在做种的任务需先从 aria2 中移除
+	if common.GetStatus(task.StatusInfo) == common.Seeding && s.Caller != nil {
+		if _, err := s.Caller.Remove(task.GID); err != nil {
+			util.Log().Warning("Failed to stop seeding task %q: %s", task.GID, err)
+		}
+	}
+
 	// 避免被aria2占用，异步执行删除
 	go func(d time.Duration, src string) {
 		time.Sleep(d)
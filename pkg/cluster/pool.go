@@ -17,6 +17,9 @@ type Pool interface {
 	// Returns active node selected by given feature and load balancer
 	BalanceNodeByFeature(feature string, lb balancer.Balancer) (error, Node)
 
+	// Returns all active nodes with given feature enabled
+	NodesByFeature(feature string) []Node
+
 	// Returns node by ID
 	GetNodeByID(id uint) Node
 
@@ -188,3 +191,13 @@ func (pool *NodePool) BalanceNodeByFeature(feature string, lb balancer.Balancer)
 
 	return ErrFeatureNotExist, nil
 }
+
+// NodesByFeature 返回启用了 feature 的所有在线节点
+func (pool *NodePool) NodesByFeature(feature string) []Node {
+	pool.lock.RLock()
+	defer pool.lock.RUnlock()
+
+	nodes := make([]Node, len(pool.featureMap[feature]))
+	copy(nodes, pool.featureMap[feature])
+	return nodes
+}
@@ -377,6 +377,67 @@ func (s *slaveCaller) Select(task *model.Download, files []int) error {
 	return nil
 }
 
+func (s *slaveCaller) Pause(task *model.Download) error {
+	s.parent.lock.RLock()
+	defer s.parent.lock.RUnlock()
+
+	req := &serializer.SlaveAria2Call{
+		Task: task,
+	}
+
+	res, err := s.SendAria2Call(req, "pause")
+	if err != nil {
+		return err
+	}
+
+	if res.Code != 0 {
+		return serializer.NewErrorFromResponse(res)
+	}
+
+	return nil
+}
+
+func (s *slaveCaller) Resume(task *model.Download) error {
+	s.parent.lock.RLock()
+	defer s.parent.lock.RUnlock()
+
+	req := &serializer.SlaveAria2Call{
+		Task: task,
+	}
+
+	res, err := s.SendAria2Call(req, "resume")
+	if err != nil {
+		return err
+	}
+
+	if res.Code != 0 {
+		return serializer.NewErrorFromResponse(res)
+	}
+
+	return nil
+}
+
+func (s *slaveCaller) SetSpeedLimit(task *model.Download, limit int) error {
+	s.parent.lock.RLock()
+	defer s.parent.lock.RUnlock()
+
+	req := &serializer.SlaveAria2Call{
+		Task:       task,
+		SpeedLimit: limit,
+	}
+
+	res, err := s.SendAria2Call(req, "speed")
+	if err != nil {
+		return err
+	}
+
+	if res.Code != 0 {
+		return serializer.NewErrorFromResponse(res)
+	}
+
+	return nil
+}
+
 func (s *slaveCaller) GetConfig() model.Aria2Option {
 	s.parent.lock.RLock()
 	defer s.parent.lock.RUnlock()
@@ -449,3 +510,39 @@ func RemoteCallback(url string, body serializer.UploadCallback) error {
 
 	return nil
 }
+
+// SlaveMetaCallback 向主机推送从机生成的缩略图与元数据
+func SlaveMetaCallback(url string, res *serializer.SlaveMetaResult) error {
+	callbackBody, err := json.Marshal(struct {
+		Data *serializer.SlaveMetaResult `json:"data"`
+	}{
+		Data: res,
+	})
+	if err != nil {
+		return serializer.NewError(serializer.CodeCallbackError, "Failed to encode callback content", err)
+	}
+
+	resp := request.GeneralClient.Request(
+		"POST",
+		url,
+		bytes.NewReader(callbackBody),
+		request.WithTimeout(time.Duration(conf.SlaveConfig.CallbackTimeout)*time.Second),
+		request.WithCredential(auth.General, int64(conf.SlaveConfig.SignatureTTL)),
+	)
+
+	if resp.Err != nil {
+		return serializer.NewError(serializer.CodeCallbackError, "Slave cannot send callback request", resp.Err)
+	}
+
+	response, err := resp.DecodeResponse()
+	if err != nil {
+		msg := fmt.Sprintf("Slave cannot parse callback response from master (StatusCode=%d).", resp.Response.StatusCode)
+		return serializer.NewError(serializer.CodeCallbackError, msg, err)
+	}
+
+	if response.Code != 0 {
+		return serializer.NewError(response.Code, response.Msg, errors.New(response.Error))
+	}
+
+	return nil
+}
@@ -18,6 +18,10 @@ type database struct {
 	Port        int
 	Charset     string
 	UnixSocket  bool
+	// Replicas 只读副本的连接串，多个以逗号分隔，数据库类型与主库相同
+	Replicas []string
+	// ReplicaMaxLag 只读副本允许的最大复制延迟（秒），超出时读请求回落至主库
+	ReplicaMaxLag int `validate:"gte=0"`
 }
 
 // system 系统通用配置
@@ -27,6 +31,8 @@ type system struct {
 	Debug         bool
 	SessionSecret string
 	HashIDSalt    string
+	// CredentialKey 加密用户挂载存储凭证所用的密钥，为空时使用 SessionSecret
+	CredentialKey string
 	GracePeriod   int    `validate:"gte=0"`
 	ProxyHeader   string `validate:"required_with=Listen"`
 }
@@ -69,6 +75,25 @@ type cors struct {
 	Secure           bool
 }
 
+// compression HTTP 响应压缩配置
+type compression struct {
+	Enabled bool
+	// Brotli 客户端支持时优先使用 brotli 压缩
+	Brotli      bool
+	GzipLevel   int `validate:"gte=-1,lte=9"`
+	BrotliLevel int `validate:"gte=0,lte=11"`
+	// ExcludedPaths 不压缩的请求路径前缀，用于文件下载等响应
+	ExcludedPaths []string
+}
+
+// static 内置静态资源的缓存配置
+type static struct {
+	// MaxAge 静态资源的缓存时间（秒），为 0 时不添加缓存头
+	MaxAge int `validate:"gte=0"`
+	// Immutable 文件名带有内容哈希的资源（/static/ 目录下）缓存一年并添加 immutable 标记
+	Immutable bool
+}
+
 var cfg *ini.File
 
 const defaultConf = `[System]
@@ -77,6 +102,7 @@ Mode = master
 Listen = :5212
 SessionSecret = {SessionSecret}
 HashIDSalt = {HashIDSalt}
+CredentialKey = {CredentialKey}
 `
 
 // Init 初始化配置文件
@@ -88,6 +114,7 @@ func Init(path string) {
 		confContent := util.Replace(map[string]string{
 			"{SessionSecret}": util.RandStringRunes(64),
 			"{HashIDSalt}":    util.RandStringRunes(64),
+			"{CredentialKey}": util.RandStringRunes(64),
 		}, defaultConf)
 		f, err := util.CreatNestedFile(path)
 		if err != nil {
@@ -109,13 +136,15 @@ func Init(path string) {
 	}
 
 	sections := map[string]interface{}{
-		"Database":   DatabaseConfig,
-		"System":     SystemConfig,
-		"SSL":        SSLConfig,
-		"UnixSocket": UnixConfig,
-		"Redis":      RedisConfig,
-		"CORS":       CORSConfig,
-		"Slave":      SlaveConfig,
+		"Database":    DatabaseConfig,
+		"System":      SystemConfig,
+		"SSL":         SSLConfig,
+		"UnixSocket":  UnixConfig,
+		"Redis":       RedisConfig,
+		"CORS":        CORSConfig,
+		"Slave":       SlaveConfig,
+		"Compression": CompressionConfig,
+		"Static":      StaticConfig,
 	}
 	for sectionName, sectionStruct := range sections {
 		err = mapSection(sectionName, sectionStruct)
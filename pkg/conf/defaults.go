@@ -10,11 +10,12 @@ var RedisConfig = &redis{
 
 // DatabaseConfig 数据库配置
 var DatabaseConfig = &database{
-	Type:       "UNSET",
-	Charset:    "utf8",
-	DBFile:     "cloudreve.db",
-	Port:       3306,
-	UnixSocket: false,
+	Type:          "UNSET",
+	Charset:       "utf8",
+	DBFile:        "cloudreve.db",
+	Port:          3306,
+	UnixSocket:    false,
+	ReplicaMaxLag: 5,
 }
 
 // SystemConfig 系统公用配置
@@ -42,6 +43,46 @@ var SlaveConfig = &slave{
 	SignatureTTL:    60,
 }
 
+// CompressionConfig HTTP 响应压缩配置
+var CompressionConfig = &compression{
+	Enabled:     true,
+	Brotli:      true,
+	GzipLevel:   -1,
+	BrotliLevel: 4,
+	ExcludedPaths: []string{
+		"/f/",
+		"/g/",
+		"/dav",
+		"/api/v3/file/get/",
+		"/api/v3/file/download/",
+		"/api/v3/file/archive/",
+		"/api/v3/file/preview/",
+		"/api/v3/file/content/",
+		"/api/v3/file/thumb/",
+		"/api/v3/file/audio/",
+		"/api/v3/file/hls/",
+		"/api/v3/file/render/",
+		"/api/v3/file/pdf/",
+		"/api/v3/share/preview/",
+		"/api/v3/share/content/",
+		"/api/v3/share/thumb/",
+		"/api/v3/share/embed/",
+		"/api/v3/user/setting/export/",
+		"/api/v3/onlyoffice/content/",
+		"/api/v3/reader/",
+		"/api/v3/slave/download/",
+		"/api/v3/slave/source/",
+		"/api/v3/slave/thumb/",
+		"/api/v3/wopi/",
+	},
+}
+
+// StaticConfig 内置静态资源缓存配置
+var StaticConfig = &static{
+	MaxAge:    86400,
+	Immutable: true,
+}
+
 var SSLConfig = &ssl{
 	Listen:   ":443",
 	CertPath: "",
@@ -8,15 +8,53 @@ import (
 	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/models/scripts"
+	"github.com/cloudreve/Cloudreve/v3/pkg/aria2"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/pdf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/reader"
+	"github.com/cloudreve/Cloudreve/v3/pkg/transcode"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 )
 
+// orphanBatch 每次最多处理的孤立文件数
+const orphanBatch = 1000
+
 func garbageCollect() {
 	// 清理打包下载产生的临时文件
 	collectArchiveFile()
 
+	// 清理过期的用户数据导出
+	collectExportFile()
+
+	// 清理超出保留期限的分享访问记录
+	collectShareEvents()
+
+	// 清理无法再被审核的访客上传
+	collectGuestUploads()
+
+	// 清理超出保留期限的流量统计
+	collectTraffic()
+
+	// 清理超出保留期限的文件变更日志
+	collectFileChanges()
+
+	// 清理已过期的文件锁
+	if err := model.DeleteExpiredFileLocks(); err != nil {
+		util.Log().Warning("Failed to delete expired file locks: %s", err)
+	}
+
+	// 清理过期的视频转码缓存
+	transcode.CollectCache(model.GetIntSetting("hls_cache_ttl", 86400))
+
+	// 清理过期的 PDF 预览缓存
+	pdf.CollectCache(model.GetIntSetting("pdf_preview_cache_ttl", 86400))
+
+	// 清理过期的电子书、漫画缓存
+	reader.CollectCache(model.GetIntSetting("reader_cache_ttl", 86400))
+
 	// 清理过期的内置内存缓存
 	if store, ok := cache.Store.(*cache.MemoStore); ok {
 		collectCache(store)
@@ -26,17 +64,64 @@ func garbageCollect() {
 }
 
 func collectArchiveFile() {
-	// 读取有效期、目录设置
-	tempPath := util.RelativePath(model.GetSettingByName("temp_path"))
+	// 读取有效期设置
 	expires := model.GetIntSetting("download_timeout", 30)
+	// 可续传的打包文件需保留至续传期限结束
+	if resumeTTL := model.GetIntSetting("archive_resume_ttl", 0); resumeTTL > expires {
+		expires = resumeTTL
+	}
+	collectTempFile("archive", "archive_", expires)
+}
+
+func collectShareEvents() {
+	days := model.GetIntSetting("share_event_retention", 90)
+	if days <= 0 {
+		return
+	}
+
+	if err := model.DeleteShareEventsBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+		util.Log().Warning("Failed to delete expired share events: %s", err)
+	}
+}
+
+func collectFileChanges() {
+	days := model.GetIntSetting("file_change_retention", 30)
+	if days <= 0 {
+		return
+	}
+
+	if err := model.DeleteFileChangesBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+		util.Log().Warning("Failed to delete expired file changes: %s", err)
+	}
+}
+
+func collectTraffic() {
+	days := model.GetIntSetting("traffic_retention", 400)
+	if days <= 0 {
+		return
+	}
+
+	if err := model.DeleteTrafficBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+		util.Log().Warning("Failed to delete expired traffic records: %s", err)
+	}
+}
+
+func collectExportFile() {
+	expires := model.GetIntSetting("export_ttl", 604800)
+	collectTempFile("export", "export_", expires)
+}
+
+// collectTempFile 清理临时目录下指定前缀的过期文件
+func collectTempFile(folder, prefix string, expires int) {
+	tempPath := util.RelativePath(model.GetSettingByName("temp_path"))
 
 	// 列出文件
-	root := filepath.Join(tempPath, "archive")
+	root := filepath.Join(tempPath, folder)
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err == nil && !info.IsDir() &&
-			strings.HasPrefix(filepath.Base(path), "archive_") &&
+			strings.HasPrefix(filepath.Base(path), prefix) &&
 			time.Now().Sub(info.ModTime()).Seconds() > float64(expires) {
-			util.Log().Debug("Delete expired batch download temp file %q.", path)
+			util.Log().Debug("Delete expired temp file %q.", path)
 			// 删除符合条件的文件
 			if err := os.Remove(path); err != nil {
 				util.Log().Debug("Failed to delete temp file %q: %s", path, err)
@@ -46,7 +131,7 @@ func collectArchiveFile() {
 	})
 
 	if err != nil {
-		util.Log().Debug("Crontab job cannot list temp batch download folder: %s", err)
+		util.Log().Debug("Crontab job cannot list temp folder %q: %s", folder, err)
 	}
 
 }
@@ -59,11 +144,17 @@ func collectCache(store *cache.MemoStore) {
 func uploadSessionCollect() {
 	placeholders := model.GetUploadPlaceholderFiles(0)
 
+	// 长时间没有上传新分片的会话视为已放弃
+	idleTimeout := model.GetIntSetting("upload_session_idle_timeout", 0)
+	idleBefore := time.Now().Add(-time.Duration(idleTimeout) * time.Second)
+
 	// 将过期的上传会话按照用户分组
 	userToFiles := make(map[uint][]uint)
 	for _, file := range placeholders {
 		_, sessionExist := cache.Get(filesystem.UploadSessionCachePrefix + *file.UploadSessionID)
-		if sessionExist {
+		// 仅经由服务端中转的分片会更新占位文件，客户端直传的会话无法判断是否空闲，以会话有效期为准
+		idle := idleTimeout > 0 && file.UpdatedAt.Before(idleBefore) && file.GetPolicy().IsTransitUpload(file.Size)
+		if sessionExist && !idle {
 			continue
 		}
 
@@ -97,3 +188,115 @@ func uploadSessionCollect() {
 
 	util.Log().Info("Crontab job \"cron_recycle_upload_session\" complete.")
 }
+
+// deletedUserCollect 注销宽限期已到的用户
+func deletedUserCollect() {
+	for _, user := range model.GetUsersToDelete(time.Now()) {
+		fs, err := filesystem.NewFileSystem(&user)
+		if err != nil {
+			util.Log().Warning("Failed to initialize filesystem for user %q: %s", user.Email, err)
+			continue
+		}
+
+		if err := fs.PurgeUser(context.Background()); err != nil {
+			util.Log().Warning("Failed to delete user %q: %s", user.Email, err)
+		} else {
+			util.Log().Info("User %q is deleted after grace period.", user.Email)
+		}
+		fs.Recycle()
+	}
+
+	util.Log().Info("Crontab job \"cron_purge_deleted_users\" complete.")
+}
+
+// flushFolderSize 将文件变更增量更新至目录的缓存大小
+func flushFolderSize() error {
+	return model.FlushFolderSize()
+}
+
+// repairFolderSize 全量校准目录的缓存大小
+func repairFolderSize() error {
+	repaired, err := model.RepairFolderSizes()
+	if err != nil {
+		return err
+	}
+
+	util.Log().Info("Crontab job \"cron_repair_folder_size\" complete, %d folder(s) repaired.", repaired)
+	return nil
+}
+
+// flushFileChanges 将暂存的文件变更写入变更日志
+func flushFileChanges() error {
+	return model.FlushFileChanges()
+}
+
+// orphanCollect 删除所在目录已不存在的文件
+func orphanCollect() error {
+	orphans, err := model.GetOrphanFiles(orphanBatch)
+	if err != nil {
+		return err
+	}
+
+	userToFiles := make(map[uint][]uint)
+	for _, file := range orphans {
+		userToFiles[file.UserID] = append(userToFiles[file.UserID], file.ID)
+	}
+
+	for uid, fileIDs := range userToFiles {
+		user, err := model.GetUserByID(uid)
+		if err != nil {
+			util.Log().Warning("Owner of orphan files cannot be found: %s", err)
+			continue
+		}
+
+		fs, err := filesystem.NewFileSystem(&user)
+		if err != nil {
+			util.Log().Warning("Failed to initialize filesystem: %s", err)
+			continue
+		}
+
+		if err = fs.Delete(context.Background(), []uint{}, fileIDs, true, false); err != nil {
+			util.Log().Warning("Failed to delete orphan files: %s", err)
+		}
+		fs.Recycle()
+	}
+
+	util.Log().Info("Crontab job \"cron_collect_orphans\" complete, %d orphan file(s) found.", len(orphans))
+	return nil
+}
+
+// thumbCleanup 淘汰超出缓存上限的缩略图，并清理文件已被删除的缩略图记录
+func thumbCleanup() error {
+	evicted, err := filesystem.EvictThumbs(context.Background())
+	if err != nil {
+		return err
+	}
+
+	removed, err := model.DeleteOrphanThumbRecords()
+	if err != nil {
+		return err
+	}
+
+	if evicted > 0 || removed > 0 {
+		util.Log().Info("%d thumbnails evicted, %d orphan thumbnail records removed.", evicted, removed)
+	}
+	return nil
+}
+
+// calibrateStorage 重新计算所有用户的已用容量
+func calibrateStorage() error {
+	scripts.UserStorageCalibration(0).Run(context.Background())
+	return nil
+}
+
+func aria2HealthCheck() {
+	if cluster.Default != nil {
+		cluster.CheckAria2Health(cluster.Default)
+	}
+}
+
+func aria2Schedule() {
+	if cluster.Default != nil {
+		aria2.Schedule(cluster.Default, time.Now())
+	}
+}
@@ -0,0 +1,150 @@
+package crontab
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// contentReportBatch 统计文件类型时每批读取的文件数
+const contentReportBatch = 1000
+
+// contentCategories 按扩展名划分的文件类型
+var contentCategories = map[string][]string{
+	"image":    {"bmp", "iff", "png", "gif", "jpg", "jpeg", "psd", "svg", "webp", "heic", "heif", "tif", "tiff", "raw", "cr2", "nef", "arw", "dng"},
+	"video":    {"mp4", "flv", "avi", "wmv", "mkv", "rm", "rmvb", "mov", "ogv", "webm", "m4v", "ts", "m2ts"},
+	"audio":    {"mp3", "flac", "ape", "wav", "aac", "ogg", "midi", "mid", "m4a", "wma", "opus"},
+	"document": {"txt", "md", "pdf", "doc", "docx", "ppt", "pptx", "xls", "xlsx", "pub", "odt", "ods", "odp", "rtf", "csv", "epub", "wps", "et", "dps"},
+	"archive":  {"zip", "rar", "7z", "tar", "gz", "tgz", "bz2", "xz", "zst", "iso"},
+}
+
+var categoryOfExt = func() map[string]string {
+	res := make(map[string]string)
+	for category, exts := range contentCategories {
+		for _, ext := range exts {
+			res[ext] = category
+		}
+	}
+	return res
+}()
+
+// fileCategory 返回文件所属的类型，无法归类时为 other
+func fileCategory(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	if category, ok := categoryOfExt[ext]; ok {
+		return category
+	}
+	return "other"
+}
+
+// contentReport 汇总文件类型分布、最大的文件，以及各存储策略、用户的容量增长，供容量规划报表使用
+func contentReport() error {
+	now := time.Now()
+	date := now.Format(model.TrafficDateFormat)
+
+	// 仅统计已用容量最多的用户的文件类型，避免汇总表随用户数膨胀
+	var topUsers []uint
+	if err := model.DB.Model(&model.User{}).Order("storage desc").Limit(rollupTopUsers).
+		Pluck("id", &topUsers).Error; err != nil {
+		return err
+	}
+	isTopUser := make(map[uint]bool, len(topUsers))
+	for _, uid := range topUsers {
+		isTopUser[uid] = true
+	}
+
+	byPolicy := make(map[string]*model.UsageRollup)
+	byUser := make(map[string]*model.UsageRollup)
+	add := func(stats map[string]*model.UsageRollup, dimension string, size uint64) {
+		if stat, ok := stats[dimension]; ok {
+			stat.Value += size
+			stat.Count++
+			return
+		}
+		stats[dimension] = &model.UsageRollup{Dimension: dimension, Value: size, Count: 1}
+	}
+
+	var after uint
+	for {
+		files, err := model.GetFilesAfter(after, contentReportBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			category := fileCategory(file.Name)
+			add(byPolicy, strconv.FormatUint(uint64(file.PolicyID), 10)+":"+category, file.Size)
+			if isTopUser[file.UserID] {
+				add(byUser, strconv.FormatUint(uint64(file.UserID), 10)+":"+category, file.Size)
+			}
+		}
+
+		if len(files) < contentReportBatch {
+			break
+		}
+		after = files[len(files)-1].ID
+	}
+
+	var largest []model.UsageRollup
+	if err := model.DB.Model(&model.File{}).Where("upload_session_id is null").
+		Select("id as dimension, size as value, 1 as count").
+		Order("size desc").Limit(rollupTopUsers).Scan(&largest).Error; err != nil {
+		return err
+	}
+
+	if err := replaceRollups(date, []metricRollups{
+		{model.RollupTypePolicy, rollupValues(byPolicy)},
+		{model.RollupTypeUser, rollupValues(byUser)},
+		{model.RollupLargestFile, largest},
+	}); err != nil {
+		return err
+	}
+
+	// 同时重新统计前一日，以补全跨日前最后一段时间的数据
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		if err := rollupGrowth(day); err != nil {
+			return err
+		}
+	}
+
+	util.Log().Info("Crontab job \"cron_content_report\" complete.")
+	return nil
+}
+
+// rollupGrowth 统计指定日期各存储策略新增的文件，以及新增文件最多的用户
+func rollupGrowth(day time.Time) error {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	var policies, users []model.UsageRollup
+	if err := model.DB.Model(&model.File{}).
+		Where("created_at >= ? and created_at < ? and upload_session_id is null", start, end).
+		Select("policy_id as dimension, sum(size) as value, count(*) as count").
+		Group("policy_id").Scan(&policies).Error; err != nil {
+		return err
+	}
+
+	if err := model.DB.Model(&model.File{}).
+		Where("created_at >= ? and created_at < ? and upload_session_id is null", start, end).
+		Select("user_id as dimension, sum(size) as value, count(*) as count").
+		Group("user_id").Order("value desc").Limit(rollupTopUsers).Scan(&users).Error; err != nil {
+		return err
+	}
+
+	return replaceRollups(day.Format(model.TrafficDateFormat), []metricRollups{
+		{model.RollupGrowthPolicy, policies},
+		{model.RollupGrowthUser, users},
+	})
+}
+
+func rollupValues(stats map[string]*model.UsageRollup) []model.UsageRollup {
+	res := make([]model.UsageRollup, 0, len(stats))
+	for _, stat := range stats {
+		res = append(res, *stat)
+	}
+	return res
+}
@@ -0,0 +1,16 @@
+package crontab
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileCategory(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.Equal("image", fileCategory("a.JPG"))
+	asserts.Equal("document", fileCategory("report.docx"))
+	asserts.Equal("archive", fileCategory("backup.tar.gz"))
+	asserts.Equal("other", fileCategory("Makefile"))
+	asserts.Equal("other", fileCategory("a.unknown"))
+}
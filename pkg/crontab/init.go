@@ -9,6 +9,29 @@ import (
 // Cron 定时任务
 var Cron *cron.Cron
 
+// jobs 所有定时任务，键为对应的 cron 日程设置名称
+var jobs = map[string]func() error{
+	"cron_garbage_collect":        noError(garbageCollect),
+	"cron_recycle_upload_session": noError(uploadSessionCollect),
+	"cron_purge_deleted_users":    noError(deletedUserCollect),
+	"cron_flush_folder_size":      flushFolderSize,
+	"cron_repair_folder_size":     repairFolderSize,
+	"cron_flush_file_changes":     flushFileChanges,
+	"cron_aria2_health_check":     noError(aria2HealthCheck),
+	"cron_aria2_schedule":         noError(aria2Schedule),
+	"cron_collect_orphans":        orphanCollect,
+	"cron_thumb_cleanup":          thumbCleanup,
+	"cron_calibrate_storage":      calibrateStorage,
+	"cron_usage_report":           usageReport,
+	"cron_usage_rollup":           usageRollup,
+	"cron_content_report":         contentReport,
+	"cron_policy_lifecycle":       policyLifecycle,
+	"cron_archive_rehydrate":      archiveRehydrate,
+	"cron_share_expiry":           shareExpiry,
+	"cron_quota_grace":            quotaGrace,
+	"cron_multipart_cleanup":      multipartCleanup,
+}
+
 // Reload 重新启动定时任务
 func Reload() {
 	if Cron != nil {
@@ -21,27 +44,34 @@ func Reload() {
 func Init() {
 	util.Log().Info("Initialize crontab jobs...")
 	// 读取cron日程设置
-	options := model.GetSettingByNames(
-		"cron_garbage_collect",
-		"cron_recycle_upload_session",
-	)
-	Cron := cron.New()
+	names := make([]string, 0, len(jobs))
+	for name := range jobs {
+		names = append(names, name)
+	}
+	options := model.GetSettingByNames(names...)
+
+	Cron = cron.New()
+	resetStatus()
 	for k, v := range options {
-		var handler func()
-		switch k {
-		case "cron_garbage_collect":
-			handler = garbageCollect
-		case "cron_recycle_upload_session":
-			handler = uploadSessionCollect
-		default:
-			util.Log().Warning("Unknown crontab job type %q, skipping...", k)
+		// 日程为空时不启用
+		if v == "" {
 			continue
 		}
 
-		if _, err := Cron.AddFunc(v, handler); err != nil {
+		id, err := Cron.AddFunc(v, wrap(k, jobs[k]))
+		if err != nil {
 			util.Log().Warning("Failed to start crontab job %q: %s", k, err)
+			continue
 		}
-
+		register(k, v, id)
 	}
 	Cron.Start()
 }
+
+// noError 将不返回错误的定时任务转换为统一的形式
+func noError(handler func()) func() error {
+	return func() error {
+		handler()
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package crontab
+
+import (
+	"context"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// policyLifecycle 执行存储策略的生命周期规则：转换到期文件的存储类型，删除过期目录中到期的文件
+func policyLifecycle() error {
+	policies, err := model.GetLifecyclePolicies()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range policies {
+		policy := &policies[i]
+		if days := policy.OptionsSerialized.ArchiveAfterDays; days > 0 {
+			archived, err := filesystem.ArchivePolicyFiles(context.Background(), policy, now.AddDate(0, 0, -days))
+			if err != nil {
+				util.Log().Warning("Failed to archive files in policy %q: %s", policy.Name, err)
+			}
+			if archived > 0 {
+				util.Log().Info("%d file(s) in policy %q are moved to archive storage.", archived, policy.Name)
+			}
+		}
+
+		if policy.OptionsSerialized.ExpiryDays > 0 && len(policy.OptionsSerialized.ExpiryFolders) > 0 {
+			if err := expirePolicyFiles(policy, now); err != nil {
+				util.Log().Warning("Failed to delete expired files in policy %q: %s", policy.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// archiveRehydrate 将已解冻完成的归档文件转回标准存储
+func archiveRehydrate() error {
+	var after uint
+	for {
+		files, err := model.GetRehydratingFiles(after, 100)
+		if err != nil || len(files) == 0 {
+			return err
+		}
+		after = files[len(files)-1].ID
+
+		policyFiles := make(map[uint][]model.File)
+		for _, file := range files {
+			policyFiles[file.PolicyID] = append(policyFiles[file.PolicyID], file)
+		}
+
+		for policyID, group := range policyFiles {
+			policy, err := model.GetPolicyByID(policyID)
+			if err != nil {
+				util.Log().Warning("Failed to get policy %d of rehydrating files: %s", policyID, err)
+				continue
+			}
+
+			rehydrated, err := filesystem.RehydrateFiles(context.Background(), &policy, group)
+			if err != nil {
+				util.Log().Warning("Failed to rehydrate files in policy %q: %s", policy.Name, err)
+			}
+			if rehydrated > 0 {
+				util.Log().Info("%d file(s) in policy %q are moved back to standard storage.", rehydrated, policy.Name)
+			}
+		}
+	}
+}
+
+// expirePolicyFiles 逐个用户删除过期目录中的到期文件，并提醒即将到期文件的所有者
+func expirePolicyFiles(policy *model.Policy, now time.Time) error {
+	uids, err := model.GetPolicyUserIDs(policy.ID)
+	if err != nil {
+		return err
+	}
+
+	expireBefore := now.AddDate(0, 0, -policy.OptionsSerialized.ExpiryDays)
+	warnBefore := expireBefore.AddDate(0, 0, policy.OptionsSerialized.ExpiryWarnDays)
+	for _, uid := range uids {
+		user, err := model.GetUserByID(uid)
+		if err != nil {
+			continue
+		}
+
+		fs, err := filesystem.NewFileSystem(&user)
+		if err != nil {
+			util.Log().Warning("Failed to initialize filesystem: %s", err)
+			continue
+		}
+
+		deleted, expiring, err := fs.ExpireFiles(context.Background(), policy, expireBefore, warnBefore)
+		fs.Recycle()
+		if err != nil {
+			util.Log().Warning("Failed to delete expired files of user %d: %s", uid, err)
+		}
+		if deleted > 0 {
+			util.Log().Info("%d expired file(s) of user %d in policy %q are deleted.", deleted, uid, policy.Name)
+		}
+
+		if len(expiring) == 0 {
+			continue
+		}
+
+		// 以最早到期的文件计算删除日期
+		expireAt := expiring[0].CreatedAt
+		for _, file := range expiring {
+			if file.CreatedAt.Before(expireAt) {
+				expireAt = file.CreatedAt
+			}
+		}
+		notify.FilesExpiring(&user, expiring, expireAt.AddDate(0, 0, policy.OptionsSerialized.ExpiryDays))
+
+		for i := range expiring {
+			if err := expiring[i].UpdateMetadata(map[string]string{model.ExpiryWarnedMetadataKey: "1"}); err != nil {
+				util.Log().Warning("Failed to mark file %d as warned: %s", expiring[i].ID, err)
+			}
+		}
+	}
+
+	return nil
+}
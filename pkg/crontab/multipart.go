@@ -0,0 +1,27 @@
+package crontab
+
+import (
+	"context"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// multipartCleanup 取消上传会话已过期、但仍未完成的客户端直传分片上传
+func multipartCleanup() error {
+	// 上传会话过期后客户端无法再完成上传
+	expires := model.GetIntSetting("upload_session_timeout", 86400) + model.GetIntSetting("multipart_abort_grace", 3600)
+	before := time.Now().Add(-time.Duration(expires) * time.Second)
+
+	aborted, err := filesystem.AbortStaleMultipartUploads(context.Background(), before)
+	if err != nil {
+		return err
+	}
+
+	if aborted > 0 {
+		util.Log().Info("%d stale multipart upload(s) are aborted.", aborted)
+	}
+	return nil
+}
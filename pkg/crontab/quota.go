@@ -0,0 +1,79 @@
+package crontab
+
+import (
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// quotaGrace 跟踪超出容量配额的用户：记录超额开始时间并提醒，宽限期结束后执行用户组设定的限制，
+// 已用容量回落到配额以内后清除超额状态
+func quotaGrace() error {
+	var groups []model.Group
+	if err := model.DB.Find(&groups).Error; err != nil {
+		return err
+	}
+
+	for i := range groups {
+		users, err := model.GetUsersOverQuota(&groups[i])
+		if err != nil {
+			util.Log().Warning("Failed to list users over quota in group %q: %s", groups[i].Name, err)
+			continue
+		}
+
+		for j := range users {
+			if err := checkUserQuota(&users[j]); err != nil {
+				util.Log().Warning("Failed to update quota state of user %q: %s", users[j].Email, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkUserQuota(user *model.User) error {
+	if !user.IsOverQuota() {
+		// 恢复超额限制停用的分享，用户组的限制方式可能已被修改，无需判断
+		if user.QuotaEnforced {
+			if err := model.EnableUserShares(user.ID); err != nil {
+				return err
+			}
+		}
+		return user.SetQuotaState(nil, false)
+	}
+
+	// 未开启超额宽限的用户组无需跟踪
+	if user.Group.OptionsSerialized.QuotaGrace <= 0 {
+		return nil
+	}
+
+	if user.QuotaExceededAt == nil {
+		now := time.Now()
+		if err := user.SetQuotaState(&now, false); err != nil {
+			return err
+		}
+
+		notify.QuotaExceeded(user)
+		return nil
+	}
+
+	if user.QuotaEnforced || user.InQuotaGrace() {
+		return nil
+	}
+
+	if user.Group.OptionsSerialized.QuotaOverAction == model.QuotaActionDisableShares {
+		if err := model.DisableUserShares(user.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := user.SetQuotaState(user.QuotaExceededAt, true); err != nil {
+		return err
+	}
+
+	util.Log().Info("Grace period of user %q over quota is ended.", user.Email)
+	notify.QuotaEnforced(user)
+	return nil
+}
@@ -0,0 +1,41 @@
+package crontab
+
+import (
+	"fmt"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
+)
+
+// reportDays 用量报告统计的天数
+const reportDays = 7
+
+// usageReport 统计最近一段时间的站点用量，并发送给管理员用户组中的所有用户
+func usageReport() error {
+	since := time.Now().AddDate(0, 0, -reportDays)
+	var newUsers, newFiles, newShares, totalUsers, totalFiles int
+	model.DB.Model(&model.User{}).Where("created_at > ?", since).Count(&newUsers)
+	model.DB.Model(&model.File{}).Where("created_at > ?", since).Count(&newFiles)
+	model.DB.Model(&model.Share{}).Where("created_at > ?", since).Count(&newShares)
+	model.DB.Model(&model.User{}).Count(&totalUsers)
+	model.DB.Model(&model.File{}).Count(&totalFiles)
+
+	var storage struct {
+		Total uint64
+	}
+	model.DB.Model(&model.User{}).Select("sum(storage) as total").Scan(&storage)
+
+	var admins []model.User
+	if err := model.DB.Where("group_id = ?", 1).Find(&admins).Error; err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("最近 %d 天新增用户 %d 个、文件 %d 个、分享 %d 个。当前共有用户 %d 个、文件 %d 个，已用容量 %.2f GB。",
+		reportDays, newUsers, newFiles, newShares, totalUsers, totalFiles, float64(storage.Total)/(1<<30))
+	for i := range admins {
+		notify.Send(&admins[i], model.NotifyUsageReport, "站点用量报告", content)
+	}
+
+	return nil
+}
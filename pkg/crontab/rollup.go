@@ -0,0 +1,137 @@
+package crontab
+
+import (
+	"strconv"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+)
+
+// rollupTopUsers 容量统计中保留的用户数
+const rollupTopUsers = 100
+
+// usageRollup 汇总站点用量统计，报表接口只读取汇总结果。当日数据在每次运行时重新统计，
+// 同时重新统计前一日，以补全跨日前最后一段时间的数据
+func usageRollup() error {
+	if err := model.FlushFileDownloads(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := rollupStorage(now.Format(model.TrafficDateFormat)); err != nil {
+		return err
+	}
+
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		if err := rollupDay(day); err != nil {
+			return err
+		}
+	}
+
+	if days := model.GetIntSetting("usage_rollup_retention", 400); days > 0 {
+		return model.DeleteUsageRollupsBefore(now.AddDate(0, 0, -days).Format(model.TrafficDateFormat))
+	}
+
+	return nil
+}
+
+// rollupStorage 统计各存储策略、用户组的已用容量，以及已用容量最多的用户
+func rollupStorage(date string) error {
+	var policies, groups, users []model.UsageRollup
+	if err := model.DB.Model(&model.File{}).
+		Select("policy_id as dimension, sum(size) as value, count(*) as count").
+		Group("policy_id").Scan(&policies).Error; err != nil {
+		return err
+	}
+
+	if err := model.DB.Model(&model.User{}).
+		Select("group_id as dimension, sum(storage) as value, count(*) as count").
+		Group("group_id").Scan(&groups).Error; err != nil {
+		return err
+	}
+
+	if err := model.DB.Model(&model.User{}).
+		Select("id as dimension, storage as value").
+		Order("storage desc").Limit(rollupTopUsers).Scan(&users).Error; err != nil {
+		return err
+	}
+
+	return replaceRollups(date, []metricRollups{
+		{model.RollupStoragePolicy, policies},
+		{model.RollupStorageGroup, groups},
+		{model.RollupStorageUser, users},
+	})
+}
+
+// rollupDay 统计指定日期的流量、活跃用户与分享数据
+func rollupDay(day time.Time) error {
+	date := day.Format(model.TrafficDateFormat)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+
+	var upload, download model.UsageRollup
+	model.DB.Model(&model.Traffic{}).Where("date = ? and upload > 0", date).
+		Select("coalesce(sum(upload), 0) as value, count(*) as count").Scan(&upload)
+	model.DB.Model(&model.Traffic{}).Where("date = ? and download > 0", date).
+		Select("coalesce(sum(download), 0) as value, count(*) as count").Scan(&download)
+
+	// 当日有流量或文件变更的用户视为活跃用户
+	var trafficUsers, changeUsers []uint
+	model.DB.Model(&model.Traffic{}).Where("date = ?", date).Pluck("user_id", &trafficUsers)
+	model.DB.Model(&model.FileChange{}).Where("created_at >= ? and created_at < ?", start, end).
+		Pluck("distinct user_id", &changeUsers)
+	active := make(map[uint]bool, len(trafficUsers))
+	for _, uid := range append(trafficUsers, changeUsers...) {
+		active[uid] = true
+	}
+
+	shareCreated := 0
+	model.DB.Model(&model.Share{}).Where("created_at >= ? and created_at < ?", start, end).Count(&shareCreated)
+
+	var events []struct {
+		ShareID uint
+		Type    string
+		Total   uint64
+	}
+	if err := model.DB.Model(&model.ShareEvent{}).
+		Select("share_id, type, count(*) as total").
+		Where("created_at >= ? and created_at < ?", start, end).
+		Group("share_id, type").Scan(&events).Error; err != nil {
+		return err
+	}
+
+	var views, downloads []model.UsageRollup
+	for _, event := range events {
+		rollup := model.UsageRollup{Dimension: strconv.FormatUint(uint64(event.ShareID), 10), Count: event.Total}
+		switch event.Type {
+		case model.ShareEventView:
+			views = append(views, rollup)
+		case model.ShareEventDownload:
+			downloads = append(downloads, rollup)
+		}
+	}
+
+	return replaceRollups(date, []metricRollups{
+		{model.RollupUpload, []model.UsageRollup{upload}},
+		{model.RollupDownload, []model.UsageRollup{download}},
+		{model.RollupActiveUsers, []model.UsageRollup{{Value: uint64(len(active))}}},
+		{model.RollupShareCreated, []model.UsageRollup{{Value: uint64(shareCreated)}}},
+		{model.RollupShareView, views},
+		{model.RollupShareDownload, downloads},
+	})
+}
+
+type metricRollups struct {
+	metric  string
+	rollups []model.UsageRollup
+}
+
+// replaceRollups 依次替换各指标在指定日期的汇总记录
+func replaceRollups(date string, metrics []metricRollups) error {
+	for _, m := range metrics {
+		if err := model.ReplaceUsageRollups(date, m.metric, m.rollups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package crontab
+
+import (
+	"context"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// shareExpiry 对已到期的分享执行创建者设定的到期处理
+func shareExpiry() error {
+	shares, err := model.GetExpiredSharesToHandle(time.Now(), 100)
+	if err != nil {
+		return err
+	}
+
+	for i := range shares {
+		share := &shares[i]
+		switch share.ExpireAction {
+		case model.ShareExpireDisable:
+			err = share.Update(map[string]interface{}{"disabled": true, "quota_disabled": false, "expire_handled": true})
+		case model.ShareExpireDelete:
+			err = share.Delete()
+		case model.ShareExpireNotify:
+			notify.ShareExpired(share)
+			err = share.Update(map[string]interface{}{"expire_handled": true})
+		default:
+			err = share.Update(map[string]interface{}{"expire_handled": true})
+		}
+
+		if err != nil {
+			util.Log().Warning("Failed to handle expired share %d: %s", share.ID, err)
+		}
+
+		// 已到期的文件收集不再审核访客上传
+		if share.Moderated {
+			purgeShareGuestUploads(share)
+		}
+	}
+
+	if len(shares) > 0 {
+		util.Log().Info("%d expired share(s) are handled.", len(shares))
+	}
+
+	return nil
+}
+
+func purgeShareGuestUploads(share *model.Share) {
+	records, err := model.GetGuestUploadsByShare(share.ID, model.GuestUploadPending)
+	if err != nil || len(records) == 0 {
+		return
+	}
+
+	if err := filesystem.PurgeGuestUploads(context.Background(), records); err != nil {
+		util.Log().Warning("Failed to purge guest uploads of share %d: %s", share.ID, err)
+	}
+}
+
+// collectGuestUploads 清理所属分享已删除或已到期、或长时间未审核的访客上传
+func collectGuestUploads() {
+	ttl := model.GetIntSetting("guest_upload_pending_ttl", 2592000)
+	now := time.Now()
+	records, err := model.GetStaleGuestUploads(now, now.Add(-time.Duration(ttl)*time.Second), orphanBatch)
+	if err != nil {
+		util.Log().Warning("Failed to list stale guest uploads: %s", err)
+		return
+	}
+
+	if len(records) == 0 {
+		return
+	}
+
+	if err := filesystem.PurgeGuestUploads(context.Background(), records); err != nil {
+		util.Log().Warning("Failed to purge stale guest uploads: %s", err)
+	}
+	util.Log().Info("%d stale guest upload(s) are purged.", len(records))
+}
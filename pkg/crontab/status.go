@@ -0,0 +1,123 @@
+package crontab
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/robfig/cron/v3"
+)
+
+// Status 定时任务的日程及最近一次执行的结果
+type Status struct {
+	Name     string     `json:"name"`
+	Spec     string     `json:"spec"`
+	Running  bool       `json:"running"`
+	LastRun  *time.Time `json:"last_run,omitempty"`
+	Duration float64    `json:"duration"` // 最近一次执行耗时，单位为秒
+	Error    string     `json:"error,omitempty"`
+	NextRun  *time.Time `json:"next_run,omitempty"`
+
+	entry cron.EntryID
+}
+
+var status = struct {
+	sync.Mutex
+	jobs map[string]*Status
+}{jobs: make(map[string]*Status)}
+
+// resetStatus 清除所有任务的日程，重新加载后由 register 重新记录，执行记录会被保留
+func resetStatus() {
+	status.Lock()
+	defer status.Unlock()
+
+	for _, s := range status.jobs {
+		s.Spec = ""
+		s.entry = 0
+	}
+}
+
+// register 记录已启用任务的日程
+func register(name, spec string, id cron.EntryID) {
+	status.Lock()
+	defer status.Unlock()
+
+	s, ok := status.jobs[name]
+	if !ok {
+		s = &Status{Name: name}
+		status.jobs[name] = s
+	}
+	s.Spec = spec
+	s.entry = id
+}
+
+// wrap 包装定时任务，记录执行结果，并跳过与上一次执行重叠的调度
+func wrap(name string, handler func() error) func() {
+	return func() {
+		status.Lock()
+		s, ok := status.jobs[name]
+		if !ok {
+			s = &Status{Name: name}
+			status.jobs[name] = s
+		}
+		if s.Running {
+			status.Unlock()
+			util.Log().Warning("Crontab job %q is still running, skipping...", name)
+			return
+		}
+		start := time.Now()
+		s.Running = true
+		s.LastRun = &start
+		status.Unlock()
+
+		err := safeRun(handler)
+
+		status.Lock()
+		s.Running = false
+		s.Duration = time.Since(start).Seconds()
+		s.Error = ""
+		if err != nil {
+			s.Error = err.Error()
+			util.Log().Warning("Crontab job %q failed: %s", name, err)
+		}
+		status.Unlock()
+	}
+}
+
+func safeRun(handler func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return handler()
+}
+
+// GetStatus 返回所有定时任务的状态，按名称排序
+func GetStatus() []Status {
+	status.Lock()
+	defer status.Unlock()
+
+	res := make([]Status, 0, len(jobs))
+	for name := range jobs {
+		s := Status{Name: name}
+		if recorded, ok := status.jobs[name]; ok {
+			s = *recorded
+		}
+
+		if s.entry != 0 && Cron != nil {
+			if next := Cron.Entry(s.entry).Next; !next.IsZero() {
+				s.NextRun = &next
+			}
+		}
+		res = append(res, s)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
@@ -0,0 +1,44 @@
+package crontab
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	a := assert.New(t)
+	Cron = cron.New()
+	id, err := Cron.AddFunc("@daily", func() {})
+	a.NoError(err)
+	register("cron_usage_report", "@daily", id)
+
+	// 执行成功
+	wrap("cron_usage_report", func() error { return nil })()
+	// 执行失败
+	wrap("cron_thumb_cleanup", func() error { return errors.New("error") })()
+	// 致命错误
+	wrap("cron_calibrate_storage", func() error { panic("fatal") })()
+
+	res := make(map[string]Status)
+	for _, s := range GetStatus() {
+		res[s.Name] = s
+	}
+	a.Len(res, len(jobs))
+
+	a.Equal("@daily", res["cron_usage_report"].Spec)
+	a.NotNil(res["cron_usage_report"].LastRun)
+	a.Empty(res["cron_usage_report"].Error)
+	a.False(res["cron_usage_report"].Running)
+
+	a.Equal("error", res["cron_thumb_cleanup"].Error)
+	a.Equal("panic: fatal", res["cron_calibrate_storage"].Error)
+	a.Nil(res["cron_collect_orphans"].LastRun)
+
+	// 跳过仍在执行的任务
+	status.jobs["cron_usage_report"].Running = true
+	wrap("cron_usage_report", func() error { return errors.New("error") })()
+	a.Empty(status.jobs["cron_usage_report"].Error)
+}
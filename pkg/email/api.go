@@ -0,0 +1,226 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/aws/aws-sdk-go/service/ses/sesiface"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+)
+
+// APIConfig 基于 HTTP API 的邮件服务配置
+type APIConfig struct {
+	Name     string // 发送者名
+	Address  string // 发送者地址
+	ReplyTo  string // 回复地址
+	Key      string // API Key 或 AccessKey ID
+	Secret   string // AccessKey Secret，仅 SES 使用
+	Domain   string // 发信域名，仅 Mailgun 使用
+	Region   string // 服务区域
+	Endpoint string // 自定义 API 地址
+}
+
+func (config *APIConfig) from() string {
+	return (&mail.Address{Name: config.Name, Address: config.Address}).String()
+}
+
+// SendGrid 使用 SendGrid API 发送邮件
+type SendGrid struct {
+	Config APIConfig
+	Client request.Client
+}
+
+// NewSendGridClient 新建 SendGrid 发送客户端
+func NewSendGridClient(config APIConfig) *SendGrid {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://api.sendgrid.com"
+	}
+	return &SendGrid{Config: config, Client: request.NewClient()}
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridRequest struct {
+	Personalizations []struct {
+		To []sendGridAddress `json:"to"`
+	} `json:"personalizations"`
+	From    sendGridAddress  `json:"from"`
+	ReplyTo *sendGridAddress `json:"reply_to,omitempty"`
+	Subject string           `json:"subject"`
+	Content []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"content"`
+}
+
+// Send 发送邮件
+func (client *SendGrid) Send(to, title, body string) error {
+	req := sendGridRequest{
+		From:    sendGridAddress{Email: client.Config.Address, Name: client.Config.Name},
+		Subject: title,
+	}
+	req.Personalizations = make([]struct {
+		To []sendGridAddress `json:"to"`
+	}, 1)
+	req.Personalizations[0].To = []sendGridAddress{{Email: to}}
+	req.Content = make([]struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	}, 1)
+	req.Content[0].Type = "text/html"
+	req.Content[0].Value = body
+	if client.Config.ReplyTo != "" {
+		req.ReplyTo = &sendGridAddress{Email: client.Config.ReplyTo}
+	}
+
+	payload, _ := json.Marshal(req)
+	resp := client.Client.Request(
+		"POST",
+		strings.TrimSuffix(client.Config.Endpoint, "/")+"/v3/mail/send",
+		bytes.NewReader(payload),
+		request.WithHeader(http.Header{
+			"Authorization": {"Bearer " + client.Config.Key},
+			"Content-Type":  {"application/json"},
+		}),
+	).CheckHTTPResponse(202)
+
+	messageID := ""
+	if resp.Err == nil {
+		messageID = resp.Response.Header.Get("X-Message-Id")
+		resp.Response.Body.Close()
+	}
+
+	logDelivery("sendgrid", to, title, messageID, resp.Err)
+	return resp.Err
+}
+
+// Close 关闭驱动
+func (client *SendGrid) Close() {
+}
+
+// Mailgun 使用 Mailgun API 发送邮件
+type Mailgun struct {
+	Config APIConfig
+	Client request.Client
+}
+
+// NewMailgunClient 新建 Mailgun 发送客户端
+func NewMailgunClient(config APIConfig) *Mailgun {
+	if config.Endpoint == "" {
+		config.Endpoint = "https://api.mailgun.net"
+		if strings.EqualFold(config.Region, "eu") {
+			config.Endpoint = "https://api.eu.mailgun.net"
+		}
+	}
+	return &Mailgun{Config: config, Client: request.NewClient()}
+}
+
+// Send 发送邮件
+func (client *Mailgun) Send(to, title, body string) error {
+	form := url.Values{
+		"from":    {client.Config.from()},
+		"to":      {to},
+		"subject": {title},
+		"html":    {body},
+	}
+	if client.Config.ReplyTo != "" {
+		form.Set("h:Reply-To", client.Config.ReplyTo)
+	}
+
+	credential := base64.StdEncoding.EncodeToString([]byte("api:" + client.Config.Key))
+	res, err := client.Client.Request(
+		"POST",
+		fmt.Sprintf("%s/v3/%s/messages", strings.TrimSuffix(client.Config.Endpoint, "/"), client.Config.Domain),
+		strings.NewReader(form.Encode()),
+		request.WithHeader(http.Header{
+			"Authorization": {"Basic " + credential},
+			"Content-Type":  {"application/x-www-form-urlencoded"},
+		}),
+	).CheckHTTPResponse(200).GetResponse()
+
+	messageID := ""
+	if err == nil {
+		var reply struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal([]byte(res), &reply) == nil {
+			messageID = reply.ID
+		}
+	}
+
+	logDelivery("mailgun", to, title, messageID, err)
+	return err
+}
+
+// Close 关闭驱动
+func (client *Mailgun) Close() {
+}
+
+// SES 使用 Amazon SES API 发送邮件
+type SES struct {
+	Config APIConfig
+	Client sesiface.SESAPI
+}
+
+// NewSESClient 新建 Amazon SES 发送客户端
+func NewSESClient(config APIConfig) (*SES, error) {
+	awsConfig := &aws.Config{
+		Credentials: credentials.NewStaticCredentials(config.Key, config.Secret, ""),
+		Region:      aws.String(config.Region),
+	}
+	if config.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+	}
+
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SES{Config: config, Client: ses.New(sess)}, nil
+}
+
+// Send 发送邮件
+func (client *SES) Send(to, title, body string) error {
+	input := &ses.SendEmailInput{
+		Source: aws.String(client.Config.from()),
+		Destination: &ses.Destination{
+			ToAddresses: []*string{aws.String(to)},
+		},
+		Message: &ses.Message{
+			Subject: &ses.Content{Charset: aws.String("UTF-8"), Data: aws.String(title)},
+			Body: &ses.Body{
+				Html: &ses.Content{Charset: aws.String("UTF-8"), Data: aws.String(body)},
+			},
+		},
+	}
+	if client.Config.ReplyTo != "" {
+		input.ReplyToAddresses = []*string{aws.String(client.Config.ReplyTo)}
+	}
+
+	messageID := ""
+	output, err := client.Client.SendEmail(input)
+	if err == nil && output.MessageId != nil {
+		messageID = *output.MessageId
+	}
+
+	logDelivery("ses", to, title, messageID, err)
+	return err
+}
+
+// Close 关闭驱动
+func (client *SES) Close() {
+}
@@ -0,0 +1,135 @@
+package email
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/aws/aws-sdk-go/service/ses/sesiface"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/mocks/requestmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+var mock sqlmock.Sqlmock
+
+func TestMain(m *testing.M) {
+	var db *sql.DB
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		panic("An error was not expected when opening a stub database connection")
+	}
+	model.DB, _ = gorm.Open("mysql", db)
+	defer db.Close()
+	m.Run()
+}
+
+func expectLog(status string) {
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)mail_logs(.+)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "to@example.com", "title", sqlmock.AnyArg(), status, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+func TestSendGrid_Send(t *testing.T) {
+	asserts := assert.New(t)
+	client := NewSendGridClient(APIConfig{Key: "key", Address: "from@example.com"})
+	asserts.Equal("https://api.sendgrid.com", client.Config.Endpoint)
+
+	// 成功
+	{
+		clientMock := requestmock.RequestMock{}
+		clientMock.On("Request", "POST", "https://api.sendgrid.com/v3/mail/send", testMock.Anything, testMock.Anything).
+			Return(&request.Response{
+				Response: &http.Response{
+					StatusCode: 202,
+					Header:     http.Header{"X-Message-Id": {"msg"}},
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				},
+			})
+		client.Client = clientMock
+		expectLog(model.MailSent)
+		asserts.NoError(client.Send("to@example.com", "title", "body"))
+		clientMock.AssertExpectations(t)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 失败
+	{
+		clientMock := requestmock.RequestMock{}
+		clientMock.On("Request", "POST", "https://api.sendgrid.com/v3/mail/send", testMock.Anything, testMock.Anything).
+			Return(&request.Response{
+				Response: &http.Response{
+					StatusCode: 401,
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				},
+			})
+		client.Client = clientMock
+		expectLog(model.MailFailed)
+		asserts.Error(client.Send("to@example.com", "title", "body"))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestMailgun_Send(t *testing.T) {
+	asserts := assert.New(t)
+	client := NewMailgunClient(APIConfig{Key: "key", Domain: "example.com", Region: "EU"})
+	asserts.Equal("https://api.eu.mailgun.net", client.Config.Endpoint)
+
+	clientMock := requestmock.RequestMock{}
+	clientMock.On("Request", "POST", "https://api.eu.mailgun.net/v3/example.com/messages", testMock.Anything, testMock.Anything).
+		Return(&request.Response{
+			Response: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":"<msg@example.com>","message":"Queued"}`)),
+			},
+		})
+	client.Client = clientMock
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)mail_logs(.+)").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "mailgun", "to@example.com", "title", "<msg@example.com>", model.MailSent, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	asserts.NoError(client.Send("to@example.com", "title", "body"))
+	clientMock.AssertExpectations(t)
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+type sesMock struct {
+	sesiface.SESAPI
+	err error
+}
+
+func (s sesMock) SendEmail(input *ses.SendEmailInput) (*ses.SendEmailOutput, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	id := "msg"
+	return &ses.SendEmailOutput{MessageId: &id}, nil
+}
+
+func TestSES_Send(t *testing.T) {
+	asserts := assert.New(t)
+	client, err := NewSESClient(APIConfig{Key: "ak", Secret: "sk", Region: "us-east-1"})
+	asserts.NoError(err)
+
+	client.Client = sesMock{}
+	expectLog(model.MailSent)
+	asserts.NoError(client.Send("to@example.com", "title", "body"))
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	client.Client = sesMock{err: errors.New("error")}
+	expectLog(model.MailFailed)
+	asserts.Error(client.Send("to@example.com", "title", "body"))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
@@ -23,7 +23,7 @@ func Init() {
 		Client.Close()
 	}
 
-	// 读取SMTP设置
+	// 读取发信设置
 	options := model.GetSettingByNames(
 		"fromName",
 		"fromAdress",
@@ -32,7 +32,43 @@ func Init() {
 		"smtpUser",
 		"smtpPass",
 		"smtpEncryption",
+		"mail_provider",
+		"mail_api_key",
+		"mail_api_secret",
+		"mail_api_domain",
+		"mail_api_region",
+		"mail_api_endpoint",
 	)
+
+	apiConfig := APIConfig{
+		Name:     options["fromName"],
+		Address:  options["fromAdress"],
+		ReplyTo:  options["replyTo"],
+		Key:      options["mail_api_key"],
+		Secret:   options["mail_api_secret"],
+		Domain:   options["mail_api_domain"],
+		Region:   options["mail_api_region"],
+		Endpoint: options["mail_api_endpoint"],
+	}
+
+	switch options["mail_provider"] {
+	case "sendgrid":
+		Client = NewSendGridClient(apiConfig)
+		return
+	case "mailgun":
+		Client = NewMailgunClient(apiConfig)
+		return
+	case "ses":
+		client, err := NewSESClient(apiConfig)
+		if err != nil {
+			util.Log().Warning("Failed to initialize Amazon SES client: %s", err)
+			Client = nil
+			return
+		}
+		Client = client
+		return
+	}
+
 	port := model.GetIntSetting("smtpPort", 25)
 	keepAlive := model.GetIntSetting("mail_keepalive", 30)
 
@@ -3,6 +3,9 @@ package email
 import (
 	"errors"
 	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 )
 
 // Driver 邮件发送驱动
@@ -36,3 +39,23 @@ func Send(to, title, body string) error {
 
 	return Client.Send(to, title, body)
 }
+
+// logDelivery 记录邮件投递结果
+func logDelivery(provider, to, title, messageID string, err error) {
+	log := &model.MailLog{
+		Provider:  provider,
+		To:        to,
+		Subject:   title,
+		MessageID: messageID,
+		Status:    model.MailSent,
+	}
+	if err != nil {
+		log.Status = model.MailFailed
+		log.Error = err.Error()
+		util.Log().Warning("Failed to send email to %q via %s: %s", to, provider, err)
+	}
+
+	if err := log.Create(); err != nil {
+		util.Log().Debug("Failed to save email delivery log: %s", err)
+	}
+}
@@ -1,6 +1,7 @@
 package email
 
 import (
+	"strings"
 	"time"
 
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
@@ -101,9 +102,9 @@ func (client *SMTP) Init() {
 					}
 					open = true
 				}
-				if err := mail.Send(s, m); err != nil {
-					util.Log().Warning("Failed to send email: %s", err)
-				} else {
+				err := mail.Send(s, m)
+				logDelivery("smtp", strings.Join(m.GetHeader("To"), ","), strings.Join(m.GetHeader("Subject"), ""), "", err)
+				if err == nil {
 					util.Log().Debug("Email sent.")
 				}
 			// 长时间没有新邮件，则关闭SMTP连接
@@ -1,36 +1,186 @@
 package email
 
 import (
-	"fmt"
+	"bytes"
+	htmlTemplate "html/template"
+	"strings"
+	textTemplate "text/template"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 )
 
-// NewActivationEmail 新建激活邮件
-func NewActivationEmail(userName, activateURL string) (string, string) {
-	options := model.GetSettingByNames("siteName", "siteURL", "siteTitle", "mail_activation_template")
-	replace := map[string]string{
-		"{siteTitle}":     options["siteName"],
-		"{userName}":      userName,
-		"{activationUrl}": activateURL,
-		"{siteUrl}":       options["siteURL"],
-		"{siteSecTitle}":  options["siteTitle"],
+// 可自定义的邮件模板
+const (
+	ActivationTemplate   = "activation"
+	ResetTemplate        = "reset_pwd"
+	QuarantineTemplate   = "quarantine"
+	NotificationTemplate = "notification"
+)
+
+// Templates 可自定义的邮件模板及其专属变量，所有模板均可使用 siteTitle、siteUrl、siteSecTitle
+var Templates = map[string][]string{
+	ActivationTemplate:   {"userName", "activationUrl"},
+	ResetTemplate:        {"userName", "resetUrl"},
+	QuarantineTemplate:   {"userName", "fileName", "signature"},
+	NotificationTemplate: {"userName", "title", "content"},
+}
+
+// SubjectKey 返回邮件标题模板的设置项名称
+func SubjectKey(name, lang string) string {
+	return withLang("mail_"+name+"_subject", lang)
+}
+
+// BodyKey 返回邮件正文模板的设置项名称
+func BodyKey(name, lang string) string {
+	return withLang("mail_"+name+"_template", lang)
+}
+
+func withLang(key, lang string) string {
+	if lang == "" {
+		return key
+	}
+	return key + "_" + lang
+}
+
+// ParseLang 从 Accept-Language 等字符串中取出首选语言
+func ParseLang(raw string) string {
+	lang := strings.TrimSpace(strings.Split(strings.Split(raw, ",")[0], ";")[0])
+	if len(lang) > 16 {
+		return ""
+	}
+	return lang
+}
+
+// langCandidates 返回已配置的语言中与 lang 匹配的候选项，按优先级排列
+func langCandidates(lang string) []string {
+	if lang == "" {
+		return nil
+	}
+
+	configured := make(map[string]bool)
+	for _, l := range strings.Split(model.GetSettingByName("mail_template_langs"), ",") {
+		if l = strings.TrimSpace(l); l != "" {
+			configured[l] = true
+		}
+	}
+
+	candidates := make([]string, 0, 2)
+	if configured[lang] {
+		candidates = append(candidates, lang)
+	}
+	if primary := strings.Split(lang, "-")[0]; primary != lang && configured[primary] {
+		candidates = append(candidates, primary)
+	}
+	return candidates
+}
+
+// siteVars 返回所有模板共用的站点变量
+func siteVars() map[string]string {
+	options := model.GetSettingByNames("siteName", "siteURL", "siteTitle")
+	return map[string]string{
+		"siteTitle":    options["siteName"],
+		"siteUrl":      options["siteURL"],
+		"siteSecTitle": options["siteTitle"],
+	}
+}
+
+// Load 读取模板，优先使用 lang 对应的语言版本
+func Load(name, lang string) (string, string) {
+	for _, l := range langCandidates(lang) {
+		options := model.GetSettingByNames(SubjectKey(name, l), BodyKey(name, l))
+		if body, ok := options[BodyKey(name, l)]; ok && body != "" {
+			return options[SubjectKey(name, l)], body
+		}
 	}
-	return fmt.Sprintf("【%s】注册激活", options["siteName"]),
-		util.Replace(replace, options["mail_activation_template"])
+
+	options := model.GetSettingByNames(SubjectKey(name, ""), BodyKey(name, ""))
+	return options[SubjectKey(name, "")], options[BodyKey(name, "")]
+}
+
+// legacyToTemplate 将旧版 {var} 形式的占位符转换为模板语法
+func legacyToTemplate(s string, vars map[string]string) string {
+	for k := range vars {
+		s = strings.Replace(s, "{"+k+"}", "{{."+k+"}}", -1)
+	}
+	return s
+}
+
+// RenderTemplate 使用给定变量渲染标题与正文模板，正文中的变量会进行 HTML 转义
+func RenderTemplate(subject, body string, vars map[string]string) (string, string, error) {
+	for k, v := range siteVars() {
+		if _, ok := vars[k]; !ok {
+			vars[k] = v
+		}
+	}
+
+	subjectTpl, err := textTemplate.New("subject").Parse(legacyToTemplate(subject, vars))
+	if err != nil {
+		return "", "", err
+	}
+
+	bodyTpl, err := htmlTemplate.New("body").Parse(legacyToTemplate(body, vars))
+	if err != nil {
+		return "", "", err
+	}
+
+	var subjectBuf, bodyBuf bytes.Buffer
+	if err := subjectTpl.Execute(&subjectBuf, vars); err != nil {
+		return "", "", err
+	}
+	if err := bodyTpl.Execute(&bodyBuf, vars); err != nil {
+		return "", "", err
+	}
+
+	return subjectBuf.String(), bodyBuf.String(), nil
+}
+
+// Render 渲染已保存的邮件模板，模板无法解析时退回到简单的占位符替换
+func Render(name, lang string, vars map[string]string) (string, string) {
+	subject, body := Load(name, lang)
+	title, content, err := RenderTemplate(subject, body, vars)
+	if err != nil {
+		util.Log().Warning("Failed to render email template %q: %s", name, err)
+		replace := make(map[string]string, len(vars))
+		for k, v := range vars {
+			replace["{"+k+"}"] = v
+		}
+		return util.Replace(replace, subject), util.Replace(replace, body)
+	}
+
+	return title, content
+}
+
+// NewActivationEmail 新建激活邮件
+func NewActivationEmail(lang, userName, activateURL string) (string, string) {
+	return Render(ActivationTemplate, lang, map[string]string{
+		"userName":      userName,
+		"activationUrl": activateURL,
+	})
+}
+
+// NewQuarantineEmail 新建文件被隔离通知邮件
+func NewQuarantineEmail(lang, userName, fileName, signature string) (string, string) {
+	return Render(QuarantineTemplate, lang, map[string]string{
+		"userName":  userName,
+		"fileName":  fileName,
+		"signature": signature,
+	})
 }
 
 // NewResetEmail 新建重设密码邮件
-func NewResetEmail(userName, resetURL string) (string, string) {
-	options := model.GetSettingByNames("siteName", "siteURL", "siteTitle", "mail_reset_pwd_template")
-	replace := map[string]string{
-		"{siteTitle}":    options["siteName"],
-		"{userName}":     userName,
-		"{resetUrl}":     resetURL,
-		"{siteUrl}":      options["siteURL"],
-		"{siteSecTitle}": options["siteTitle"],
-	}
-	return fmt.Sprintf("【%s】密码重置", options["siteName"]),
-		util.Replace(replace, options["mail_reset_pwd_template"])
+func NewResetEmail(lang, userName, resetURL string) (string, string) {
+	return Render(ResetTemplate, lang, map[string]string{
+		"userName": userName,
+		"resetUrl": resetURL,
+	})
+}
+
+// NewNotificationEmail 新建通用通知邮件
+func NewNotificationEmail(lang, userName, title, content string) (string, string) {
+	return Render(NotificationTemplate, lang, map[string]string{
+		"userName": userName,
+		"title":    title,
+		"content":  content,
+	})
 }
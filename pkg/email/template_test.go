@@ -0,0 +1,66 @@
+package email
+
+import (
+	"testing"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func setTemplateSettings() {
+	cache.SetSettings(map[string]string{
+		"siteName":                   "Cloudreve",
+		"siteURL":                    "http://example.com",
+		"siteTitle":                  "Sec",
+		"mail_template_langs":        "en",
+		"mail_activation_subject":    "【{siteTitle}】注册激活",
+		"mail_activation_template":   "<p>{userName}</p>",
+		"mail_notification_subject":  "{{",
+		"mail_notification_template": "<p>{content}</p>",
+	}, "setting_")
+}
+
+func TestParseLang(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.Equal("en-US", ParseLang("en-US,en;q=0.9"))
+	asserts.Equal("zh", ParseLang("zh;q=0.8"))
+	asserts.Equal("", ParseLang(""))
+}
+
+func TestRenderTemplate(t *testing.T) {
+	asserts := assert.New(t)
+	setTemplateSettings()
+
+	// 兼容旧版占位符，变量被转义
+	title, body, err := RenderTemplate("【{siteTitle}】{{.userName}}", "<p>{userName}</p>", map[string]string{
+		"userName": "<b>x</b>",
+	})
+	asserts.NoError(err)
+	asserts.Equal("【Cloudreve】<b>x</b>", title)
+	asserts.Equal("<p>&lt;b&gt;x&lt;/b&gt;</p>", body)
+
+	// 语法错误
+	_, _, err = RenderTemplate("{{", "", map[string]string{})
+	asserts.Error(err)
+}
+
+func TestRender(t *testing.T) {
+	asserts := assert.New(t)
+	setTemplateSettings()
+
+	// 模板无法解析时退回占位符替换
+	title, body := NewNotificationEmail("", "foo", "t", "c")
+	asserts.Equal("{{", title)
+	asserts.Equal("<p>c</p>", body)
+
+	// 语言版本
+	cache.Set("setting_mail_activation_subject_en", "Activate {userName}", 0)
+	cache.Set("setting_mail_activation_template_en", "<a href=\"{activationUrl}\">go</a>", 0)
+	title, body = NewActivationEmail("en-US", "foo", "http://example.com/a")
+	asserts.Equal("Activate foo", title)
+	asserts.Equal(`<a href="http://example.com/a">go</a>`, body)
+
+	// 未配置的语言使用默认模板
+	title, _ = NewActivationEmail("fr", "foo", "http://example.com/a")
+	asserts.Equal("【Cloudreve】注册激活", title)
+}
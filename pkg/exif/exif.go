@@ -0,0 +1,233 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoExif    = errors.New("no EXIF data found")
+	ErrMalformed = errors.New("malformed EXIF data")
+)
+
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagDateTime         = 0x0132
+	tagExifIFD          = 0x8769
+	tagGPSIFD           = 0x8825
+	tagDateTimeOriginal = 0x9003
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+
+	typeASCII    = 2
+	typeRational = 5
+
+	// JPEG 中 APP1 段的最大长度
+	maxSegmentSize = 64 << 10
+	dateLayout     = "2006:01:02 15:04:05"
+)
+
+// Exif 照片的拍摄信息
+type Exif struct {
+	Make      string
+	Model     string
+	TakenAt   *time.Time
+	Latitude  *float64
+	Longitude *float64
+}
+
+// Read 读取 JPEG 或 TIFF 文件中的 EXIF 信息
+func Read(r io.Reader) (*Exif, error) {
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, ErrNoExif
+	}
+
+	if bytes.Equal(magic, []byte("II*\x00")) || bytes.Equal(magic, []byte("MM\x00*")) {
+		// TIFF 文件的 IFD 可能位于文件任意位置，只读取开头部分
+		rest, err := io.ReadAll(io.LimitReader(r, 4<<20))
+		if err != nil {
+			return nil, err
+		}
+		return parseTIFF(append(magic, rest...))
+	}
+
+	if magic[0] != 0xFF || magic[1] != 0xD8 {
+		return nil, ErrNoExif
+	}
+
+	// 逐个读取 JPEG 段，直至找到 APP1 Exif 段
+	marker := magic[2:4]
+	for {
+		if marker[0] != 0xFF {
+			return nil, ErrMalformed
+		}
+
+		// 图像数据开始，之后不再有元数据段
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return nil, ErrNoExif
+		}
+
+		lengthBuf := make([]byte, 2)
+		if _, err := io.ReadFull(r, lengthBuf); err != nil {
+			return nil, ErrNoExif
+		}
+		length := int(binary.BigEndian.Uint16(lengthBuf)) - 2
+		if length < 0 || length > maxSegmentSize {
+			return nil, ErrMalformed
+		}
+
+		segment := make([]byte, length)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return nil, ErrNoExif
+		}
+
+		if marker[1] == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return parseTIFF(segment[6:])
+		}
+
+		if _, err := io.ReadFull(r, marker); err != nil {
+			return nil, ErrNoExif
+		}
+	}
+}
+
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type entry struct {
+	typ    uint16
+	count  uint32
+	offset []byte
+}
+
+func parseTIFF(data []byte) (*Exif, error) {
+	if len(data) < 8 {
+		return nil, ErrMalformed
+	}
+
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, ErrMalformed
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(data[4:8]))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Exif{
+		Make:  t.ascii(ifd0[tagMake]),
+		Model: t.ascii(ifd0[tagModel]),
+	}
+
+	taken := t.ascii(ifd0[tagDateTime])
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if exifIFD, err := t.readIFD(t.order.Uint32(e.offset)); err == nil {
+			if original := t.ascii(exifIFD[tagDateTimeOriginal]); original != "" {
+				taken = original
+			}
+		}
+	}
+
+	if parsed, err := time.ParseInLocation(dateLayout, taken, time.Local); err == nil {
+		res.TakenAt = &parsed
+	}
+
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err := t.readIFD(t.order.Uint32(e.offset)); err == nil {
+			res.Latitude = t.coordinate(gps[tagGPSLatitude], t.ascii(gps[tagGPSLatitudeRef]), "S")
+			res.Longitude = t.coordinate(gps[tagGPSLongitude], t.ascii(gps[tagGPSLongitudeRef]), "W")
+		}
+	}
+
+	return res, nil
+}
+
+// readIFD 读取指定偏移处的 IFD，返回以标签为键的条目
+func (t *tiff) readIFD(offset uint32) (map[uint16]entry, error) {
+	if int(offset)+2 > len(t.data) {
+		return nil, ErrMalformed
+	}
+
+	count := int(t.order.Uint16(t.data[offset:]))
+	pos := int(offset) + 2
+	if pos+count*12 > len(t.data) {
+		return nil, ErrMalformed
+	}
+
+	res := make(map[uint16]entry, count)
+	for i := 0; i < count; i++ {
+		raw := t.data[pos+i*12 : pos+i*12+12]
+		res[t.order.Uint16(raw)] = entry{
+			typ:    t.order.Uint16(raw[2:]),
+			count:  t.order.Uint32(raw[4:]),
+			offset: raw[8:12],
+		}
+	}
+	return res, nil
+}
+
+// value 返回条目的原始数据，长度不超过 4 字节时数据直接存放在偏移字段中
+func (t *tiff) value(e entry, size int) []byte {
+	length := int(e.count) * size
+	if length <= 4 {
+		return e.offset[:length]
+	}
+
+	offset := int(t.order.Uint32(e.offset))
+	if offset < 0 || offset+length > len(t.data) {
+		return nil
+	}
+	return t.data[offset : offset+length]
+}
+
+func (t *tiff) ascii(e entry) string {
+	if e.typ != typeASCII {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(t.value(e, 1)), "\x00"))
+}
+
+// coordinate 将度、分、秒三个有理数转换为十进制坐标，negRef 为表示负方向的参考值
+func (t *tiff) coordinate(e entry, ref, negRef string) *float64 {
+	if e.typ != typeRational || e.count != 3 {
+		return nil
+	}
+
+	raw := t.value(e, 8)
+	if len(raw) != 24 {
+		return nil
+	}
+
+	var parts [3]float64
+	for i := range parts {
+		num := t.order.Uint32(raw[i*8:])
+		den := t.order.Uint32(raw[i*8+4:])
+		if den == 0 {
+			return nil
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+
+	res := parts[0] + parts[1]/60 + parts[2]/3600
+	if strings.EqualFold(ref, negRef) {
+		res = -res
+	}
+	return &res
+}
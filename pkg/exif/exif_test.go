@@ -0,0 +1,125 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	data  []byte
+}
+
+// buildIFD 在 buf 末尾写入 IFD 及其外部数据，返回 IFD 的偏移
+func buildIFD(buf *bytes.Buffer, entries []testEntry) uint32 {
+	offset := uint32(buf.Len())
+	dataOffset := offset + 2 + uint32(len(entries))*12 + 4
+
+	var extra bytes.Buffer
+	binary.Write(buf, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		binary.Write(buf, binary.LittleEndian, e.tag)
+		binary.Write(buf, binary.LittleEndian, e.typ)
+		binary.Write(buf, binary.LittleEndian, e.count)
+		if len(e.data) <= 4 {
+			buf.Write(append(e.data, make([]byte, 4-len(e.data))...))
+			continue
+		}
+		binary.Write(buf, binary.LittleEndian, dataOffset+uint32(extra.Len()))
+		extra.Write(e.data)
+	}
+	buf.Write([]byte{0, 0, 0, 0})
+	buf.Write(extra.Bytes())
+	return offset
+}
+
+func ascii(tag uint16, s string) testEntry {
+	return testEntry{tag: tag, typ: typeASCII, count: uint32(len(s) + 1), data: append([]byte(s), 0)}
+}
+
+func rational(tag uint16, values ...uint32) testEntry {
+	var b bytes.Buffer
+	for _, v := range values {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	return testEntry{tag: tag, typ: typeRational, count: uint32(len(values) / 2), data: b.Bytes()}
+}
+
+func offsetEntry(tag uint16, offset uint32) testEntry {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, offset)
+	return testEntry{tag: tag, typ: 4, count: 1, data: data}
+}
+
+func buildTIFF() []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte("II*\x00"))
+	buf.Write([]byte{0, 0, 0, 0})
+
+	exifIFD := buildIFD(&buf, []testEntry{ascii(tagDateTimeOriginal, "2021:06:01 08:30:00")})
+	gpsIFD := buildIFD(&buf, []testEntry{
+		ascii(tagGPSLatitudeRef, "N"),
+		rational(tagGPSLatitude, 31, 1, 30, 1, 0, 1),
+		ascii(tagGPSLongitudeRef, "W"),
+		rational(tagGPSLongitude, 121, 1, 15, 1, 36, 1),
+	})
+	ifd0 := buildIFD(&buf, []testEntry{
+		ascii(tagMake, "Canon"),
+		ascii(tagModel, "EOS R5"),
+		ascii(tagDateTime, "2021:06:02 00:00:00"),
+		offsetEntry(tagExifIFD, exifIFD),
+		offsetEntry(tagGPSIFD, gpsIFD),
+	})
+
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint32(data[4:], ifd0)
+	return data
+}
+
+func TestRead_TIFF(t *testing.T) {
+	asserts := assert.New(t)
+
+	res, err := Read(bytes.NewReader(buildTIFF()))
+	asserts.NoError(err)
+	asserts.Equal("Canon", res.Make)
+	asserts.Equal("EOS R5", res.Model)
+	asserts.Equal("2021-06-01 08:30", res.TakenAt.Format("2006-01-02 15:04"))
+	asserts.InDelta(31.5, *res.Latitude, 0.0001)
+	asserts.InDelta(-121.26, *res.Longitude, 0.0001)
+}
+
+func TestRead_JPEG(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 带有 Exif 段
+	{
+		tiff := buildTIFF()
+		var b bytes.Buffer
+		b.Write([]byte{0xFF, 0xD8, 0xFF, 0xE0, 0, 4, 0, 0})
+		b.Write([]byte{0xFF, 0xE1})
+		binary.Write(&b, binary.BigEndian, uint16(len(tiff)+8))
+		b.WriteString("Exif\x00\x00")
+		b.Write(tiff)
+
+		res, err := Read(bytes.NewReader(b.Bytes()))
+		asserts.NoError(err)
+		asserts.Equal("Canon", res.Make)
+	}
+
+	// 没有 Exif 段
+	{
+		_, err := Read(bytes.NewReader([]byte{0xFF, 0xD8, 0xFF, 0xDA, 0, 2}))
+		asserts.Equal(ErrNoExif, err)
+	}
+
+	// 不支持的格式
+	{
+		_, err := Read(bytes.NewReader([]byte("\x89PNG")))
+		asserts.Equal(ErrNoExif, err)
+	}
+}
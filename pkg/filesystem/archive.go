@@ -17,6 +17,7 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/mholt/archiver/v4"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 /* ===============
@@ -24,8 +25,29 @@ import (
    ===============
 */
 
+// DecompressibleSuffixes 支持解压的压缩文件扩展名
+var DecompressibleSuffixes = []string{".zip", ".gz", ".xz", ".tar", ".rar"}
+
+// IsDecompressible 根据扩展名判断文件是否为支持解压的压缩文件
+func IsDecompressible(name string) bool {
+	for _, suffix := range DecompressibleSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Compress 创建给定目录和文件的压缩文件
-func (fs *FileSystem) Compress(ctx context.Context, writer io.Writer, folderIDs, fileIDs []uint, isArchive bool) error {
+func (fs *FileSystem) Compress(ctx context.Context, writer io.Writer, folderIDs, fileIDs []uint, isArchive bool) (err error) {
+	// 打包的内容均可被读取，视为下载
+	event := &Event{Op: OpDownload, Files: fileIDs, Folders: folderIDs}
+	if err := fs.emitBefore(ctx, event); err != nil {
+		return err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
 	// 查找待压缩目录
 	folders, err := model.GetFoldersByIDs(folderIDs, fs.User.ID)
 	if err != nil && len(folderIDs) != 0 {
@@ -74,6 +96,12 @@ func (fs *FileSystem) Compress(ctx context.Context, writer io.Writer, folderIDs,
 	zipWriter := zip.NewWriter(writer)
 	defer zipWriter.Close()
 
+	if report, ok := ctx.Value(fsctx.ProgressFuncCtx).(fsctx.ProgressFunc); ok {
+		reqContext = context.WithValue(reqContext, fsctx.ProgressFuncCtx, report)
+	}
+	if encoding, ok := ctx.Value(fsctx.ArchiveEncodingCtx).(string); ok {
+		reqContext = context.WithValue(reqContext, fsctx.ArchiveEncodingCtx, encoding)
+	}
 	ctx = reqContext
 
 	// 压缩各个目录及文件
@@ -100,9 +128,124 @@ func (fs *FileSystem) Compress(ctx context.Context, writer io.Writer, folderIDs,
 	return nil
 }
 
+// 压缩文件中文件名的编码
+const (
+	ArchiveEncodingUTF8 = "utf-8"
+	ArchiveEncodingGBK  = "gbk"
+)
+
+// zipFileHeader 创建压缩文件中的文件头。文件名默认以 UTF-8 编码并设置 UTF-8 标志；
+// 指定 GBK 编码时不设置该标志，以兼容不识别该标志的旧版 Windows 解压工具，
+// 文件名含有 GBK 无法表示的字符时仍使用 UTF-8。
+// 文件大小、偏移量超过 4 GiB 或条目数超过 65535 时，archive/zip 会自动写入 Zip64 扩展信息，
+// 因此这里不预设文件大小，由写入的实际字节数决定。
+func zipFileHeader(name string, modified time.Time, encoding string) *zip.FileHeader {
+	header := &zip.FileHeader{
+		Name:     name,
+		Modified: modified,
+	}
+
+	if encoding == ArchiveEncodingGBK {
+		if encoded, err := simplifiedchinese.GBK.NewEncoder().String(name); err == nil {
+			header.Name = encoded
+			header.NonUTF8 = true
+			return header
+		}
+	}
+
+	header.Flags |= 0x800
+	return header
+}
+
+// archiveBuilding 正在生成的可续传打包文件，避免同一会话重复打包
+var archiveBuilding sync.Map
+
+// ResumableArchivePath 返回打包下载会话对应的临时文件路径
+func ResumableArchivePath(sessionID string) string {
+	return filepath.Join(
+		util.RelativePath(model.GetSettingByName("temp_path")),
+		"archive",
+		fmt.Sprintf("archive_%s.zip", sessionID),
+	)
+}
+
+// archiveTee 将打包内容写入临时文件，同时尽力写给客户端，客户端写入失败后只写入临时文件
+type archiveTee struct {
+	file   io.Writer
+	client io.Writer
+}
+
+func (t *archiveTee) Write(p []byte) (int, error) {
+	n, err := t.file.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	if t.client != nil {
+		if _, err := t.client.Write(p); err != nil {
+			t.client = nil
+		}
+	}
+	return n, nil
+}
+
+// CompressResumable 生成打包下载会话 sessionID 的打包文件并保留在临时目录中，以便之后按范围续传。
+// 打包文件不存在时边生成边写给 w，返回的 streamed 为 true；打包文件已存在或 w 为 nil 时只返回文件路径。
+// ctx 中不应包含请求上下文，以免客户端中途断开时中断打包。
+func (fs *FileSystem) CompressResumable(ctx context.Context, sessionID string, w io.Writer, folderIDs, fileIDs []uint) (string, bool, error) {
+	archivePath := ResumableArchivePath(sessionID)
+
+	lockRaw, _ := archiveBuilding.LoadOrStore(sessionID, &sync.Mutex{})
+	lock := lockRaw.(*sync.Mutex)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if util.Exists(archivePath) {
+		return archivePath, false, nil
+	}
+	defer archiveBuilding.Delete(sessionID)
+
+	// 先写入临时文件，打包完成后再重命名，避免续传请求读到不完整的文件
+	partPath := archivePath + ".part"
+	file, err := util.CreatNestedFile(partPath)
+	if err != nil {
+		return "", false, err
+	}
+
+	tee := &archiveTee{file: file}
+	if w != nil {
+		tee.client = w
+	}
+
+	err = fs.Compress(ctx, tee, folderIDs, fileIDs, true)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partPath, archivePath)
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return "", false, err
+	}
+
+	return archivePath, w != nil, nil
+}
+
 func (fs *FileSystem) doCompress(ctx context.Context, file *model.File, folder *model.Folder, zipWriter *zip.Writer, isArchive bool) {
+	// 压缩已被取消
+	if ctx.Err() != nil {
+		return
+	}
+
 	// 如果对象是文件
 	if file != nil {
+		// 跳过被隔离或屏蔽的文件
+		if checkRestricted(file) != nil {
+			util.Log().Debug("Skip restricted file %q while compressing.", file.Name)
+			return
+		}
+
 		// 切换上传策略
 		fs.Policy = file.GetPolicy()
 		err := fs.DispatchHandler()
@@ -125,11 +268,8 @@ func (fs *FileSystem) doCompress(ctx context.Context, file *model.File, folder *
 		}
 
 		// 创建压缩文件头
-		header := &zip.FileHeader{
-			Name:               filepath.FromSlash(path.Join(file.Position, file.Name)),
-			Modified:           file.UpdatedAt,
-			UncompressedSize64: file.Size,
-		}
+		encoding, _ := ctx.Value(fsctx.ArchiveEncodingCtx).(string)
+		header := zipFileHeader(path.Join(file.Position, file.Name), file.UpdatedAt, encoding)
 
 		// 指定是压缩还是归档
 		if isArchive {
@@ -144,6 +284,9 @@ func (fs *FileSystem) doCompress(ctx context.Context, file *model.File, folder *
 		}
 
 		_, err = io.Copy(writer, fileToZip)
+		if err == nil {
+			fsctx.ReportProgress(ctx, path.Join(file.Position, file.Name), file.Size)
+		}
 	} else if folder != nil {
 		// 对象是目录
 		// 获取子文件
@@ -171,6 +314,10 @@ func (fs *FileSystem) Decompress(ctx context.Context, src, dst, encoding string)
 		return err
 	}
 
+	if err := checkRestricted(&fs.FileTarget[0]); err != nil {
+		return err
+	}
+
 	tempZipFilePath := ""
 	defer func() {
 		// 结束时删除临时压缩文件
@@ -275,11 +422,18 @@ func (fs *FileSystem) Decompress(ctx context.Context, src, dst, encoding string)
 		fileStream.Close()
 		if err != nil {
 			util.Log().Debug("Failed to upload file %q in archive file: %s, skipping...", rawPath, err)
+			return
 		}
+		fsctx.ReportProgress(ctx, savePath, uint64(size))
 	}
 
 	// 解压缩文件，回调函数如果出错会停止解压的下一步进行，全部return nil
 	err = extractor.Extract(ctx, reader, nil, func(ctx context.Context, f archiver.File) error {
+		// 解压已被取消
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		rawPath := util.FormSlash(f.NameInArchive)
 		savePath := path.Join(dst, rawPath)
 		// 路径是否合法
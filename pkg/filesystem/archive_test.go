@@ -1,9 +1,11 @@
 package filesystem
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	testMock "github.com/stretchr/testify/mock"
 	"io"
@@ -12,6 +14,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	model "github.com/cloudreve/Cloudreve/v3/models"
@@ -19,6 +22,7 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/simplifiedchinese"
 )
 
 func TestFileSystem_Compress(t *testing.T) {
@@ -160,6 +164,103 @@ func Path(rel string) string {
 	return filepath.Join(basepath, rel)
 }
 
+func TestFileSystem_CompressResumable(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	fs := FileSystem{
+		User: &model.User{Model: gorm.Model{ID: 1}},
+	}
+	asserts.NoError(cache.Set("setting_temp_path", "tests", -1))
+	asserts.NoError(cache.Set("policy_1", model.Policy{Type: "local"}, -1))
+	archivePath := ResumableArchivePath("resumable")
+	defer os.Remove(archivePath)
+
+	// 首次请求，边打包边输出
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(1, 1).
+			WillReturnRows(
+				sqlmock.NewRows(
+					[]string{"id", "name", "source_name", "policy_id"}).
+					AddRow(1, "1.txt", "tests/file1.txt", 1),
+			)
+		w := &bytes.Buffer{}
+
+		res, streamed, err := fs.CompressResumable(ctx, "resumable", w, nil, []uint{1})
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.True(streamed)
+		asserts.Equal(archivePath, res)
+		asserts.NotEmpty(w.Len())
+
+		content, err := os.ReadFile(archivePath)
+		asserts.NoError(err)
+		asserts.Equal(w.Bytes(), content)
+		asserts.False(util.Exists(archivePath + ".part"))
+	}
+
+	// 续传请求，直接返回已生成的文件
+	{
+		res, streamed, err := fs.CompressResumable(ctx, "resumable", &bytes.Buffer{}, nil, []uint{1})
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.False(streamed)
+		asserts.Equal(archivePath, res)
+	}
+}
+
+func TestZipFileHeader(t *testing.T) {
+	asserts := assert.New(t)
+	modified := time.Now()
+
+	// 默认 UTF-8
+	{
+		header := zipFileHeader("目录/文件.txt", modified, "")
+		asserts.Equal("目录/文件.txt", header.Name)
+		asserts.False(header.NonUTF8)
+		asserts.NotZero(header.Flags & 0x800)
+	}
+
+	// GBK
+	{
+		header := zipFileHeader("目录/文件.txt", modified, ArchiveEncodingGBK)
+		asserts.True(header.NonUTF8)
+		asserts.Zero(header.Flags & 0x800)
+		decoded, err := simplifiedchinese.GBK.NewDecoder().String(header.Name)
+		asserts.NoError(err)
+		asserts.Equal("目录/文件.txt", decoded)
+	}
+
+	// GBK 无法表示的字符，仍使用 UTF-8
+	{
+		header := zipFileHeader("😀.txt", modified, ArchiveEncodingGBK)
+		asserts.Equal("😀.txt", header.Name)
+		asserts.False(header.NonUTF8)
+	}
+}
+
+func TestZipFileHeader_Zip64Entries(t *testing.T) {
+	asserts := assert.New(t)
+	buf := &bytes.Buffer{}
+	zipWriter := zip.NewWriter(buf)
+
+	// 超过 65535 个条目时需要 Zip64 目录记录
+	const entries = 70000
+	for i := 0; i < entries; i++ {
+		header := zipFileHeader(fmt.Sprintf("%d.txt", i), time.Now(), "")
+		header.Method = zip.Store
+		_, err := zipWriter.CreateHeader(header)
+		asserts.NoError(err)
+	}
+	asserts.NoError(zipWriter.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	asserts.NoError(err)
+	asserts.Len(reader.File, entries)
+}
+
 func TestFileSystem_Decompress(t *testing.T) {
 	asserts := assert.New(t)
 	ctx := context.Background()
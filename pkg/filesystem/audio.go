@@ -0,0 +1,100 @@
+package filesystem
+
+import (
+	"context"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/audiotag"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+	 音频元数据
+   ================
+*/
+
+// IsAudio 返回文件是否为支持提取元数据的音频
+func IsAudio(file *model.File) bool {
+	return util.IsInExtensionList(strings.Split(model.GetSettingByName("audio_meta_exts"), ","), file.Name)
+}
+
+// AudioMeta 返回音频文件的元数据。cached 为已保存的元数据，为空或与文件当前内容
+// 不一致时重新提取并保存，无法解析的文件保存空白元数据以免重复提取
+func (fs *FileSystem) AudioMeta(ctx context.Context, file *model.File, cached *model.AudioMeta) (*model.AudioMeta, error) {
+	if file.IsOpaque() {
+		return nil, ErrFileOpaque
+	}
+
+	if cached != nil && !cached.IsStale(file) {
+		return cached, nil
+	}
+
+	tags, err := fs.readAudioTags(ctx, file)
+	if err != nil {
+		util.Log().Debug("Failed to read audio tags of %q: %s", file.Name, err)
+	}
+
+	meta := newAudioMeta(file, cached, tags)
+	if err := meta.Save(); err != nil {
+		return nil, ErrDBListObjects.WithError(err)
+	}
+
+	return meta, nil
+}
+
+// newAudioMeta 根据音频标签构建元数据，tags 为空时构建空白元数据
+func newAudioMeta(file *model.File, cached *model.AudioMeta, tags *audiotag.Tags) *model.AudioMeta {
+	meta := &model.AudioMeta{}
+	if cached != nil {
+		meta.ID = cached.ID
+		meta.CreatedAt = cached.CreatedAt
+	}
+	meta.FileID = file.ID
+	meta.UserID = file.UserID
+	meta.SourceName = file.SourceName
+
+	if tags != nil {
+		meta.Title = tags.Title
+		meta.Artist = tags.Artist
+		meta.Album = tags.Album
+		meta.AlbumArtist = tags.AlbumArtist
+		meta.Genre = tags.Genre
+		meta.Year = tags.Year
+		meta.Track = tags.Track
+		meta.HasCover = tags.Picture != nil
+	}
+
+	if meta.AlbumArtist == "" {
+		meta.AlbumArtist = meta.Artist
+	}
+
+	return meta
+}
+
+// AudioCover 读取音频文件内嵌的封面图片
+func (fs *FileSystem) AudioCover(ctx context.Context, file *model.File) (*audiotag.Picture, error) {
+	tags, err := fs.readAudioTags(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if tags.Picture == nil {
+		return nil, ErrObjectNotExist
+	}
+
+	return tags.Picture, nil
+}
+
+func (fs *FileSystem) readAudioTags(ctx context.Context, file *model.File) (*audiotag.Tags, error) {
+	fs.SetTargetFile(&[]model.File{*file})
+	defer fs.CleanTargets()
+
+	rs, err := fs.GetContent(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	return audiotag.Read(rs)
+}
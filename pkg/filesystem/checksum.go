@@ -0,0 +1,180 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"hash"
+	"io"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+)
+
+// ChecksumCachePrefix 分片上传过程中文件哈希计算状态的缓存前缀
+const ChecksumCachePrefix = "upload_checksum_"
+
+// Checksum 同时计算文件内容的 MD5 和 SHA-256
+type Checksum struct {
+	md5    hash.Hash
+	sha256 hash.Hash
+	// Size 本次写入的字节数，不包含恢复计算状态前已计算的内容
+	Size uint64
+}
+
+// ChecksumState 分片上传过程中缓存的哈希计算状态
+type ChecksumState struct {
+	// Chunks 已计算的分片数量
+	Chunks int
+	// State 计算完前 Chunks 个分片后的状态
+	State []byte
+	// Prev 计算完前 Chunks-1 个分片后的状态，用于最后一个分片重传
+	Prev []byte
+}
+
+// NewChecksum 创建新的哈希计算器
+func NewChecksum() *Checksum {
+	return &Checksum{md5: md5.New(), sha256: sha256.New()}
+}
+
+// Write 写入要计算哈希的内容
+func (c *Checksum) Write(p []byte) (int, error) {
+	c.md5.Write(p)
+	c.sha256.Write(p)
+	c.Size += uint64(len(p))
+	return len(p), nil
+}
+
+// Sums 返回以文件元信息键索引的哈希值
+func (c *Checksum) Sums() map[string]string {
+	return map[string]string{
+		model.MD5MetadataKey:    hex.EncodeToString(c.md5.Sum(nil)),
+		model.SHA256MetadataKey: hex.EncodeToString(c.sha256.Sum(nil)),
+	}
+}
+
+// MarshalBinary 导出计算状态
+func (c *Checksum) MarshalBinary() ([]byte, error) {
+	md5State, err := c.md5.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	sha256State, err := c.sha256.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{byte(len(md5State))}, append(md5State, sha256State...)...), nil
+}
+
+// UnmarshalBinary 恢复计算状态
+func (c *Checksum) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || len(data) < int(data[0])+1 {
+		return io.ErrUnexpectedEOF
+	}
+
+	c.md5, c.sha256 = md5.New(), sha256.New()
+	if err := c.md5.(encoding.BinaryUnmarshaler).UnmarshalBinary(data[1 : data[0]+1]); err != nil {
+		return err
+	}
+	return c.sha256.(encoding.BinaryUnmarshaler).UnmarshalBinary(data[data[0]+1:])
+}
+
+// NewChecksumReader 返回读取时同步计算哈希的 ReadCloser
+func NewChecksumReader(rc io.ReadCloser, checksum *Checksum) io.ReadCloser {
+	return checksumReadCloser{Reader: io.TeeReader(rc, checksum), Closer: rc}
+}
+
+type checksumReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ChunkChecksum 返回上传会话第 index 个分片使用的哈希计算器。
+// 分片未按顺序上传导致无法继续计算时返回 nil
+func ChunkChecksum(sessionID string, index int) *Checksum {
+	if index == 0 {
+		return NewChecksum()
+	}
+
+	stateRaw, ok := cache.Get(ChecksumCachePrefix + sessionID)
+	if !ok {
+		return nil
+	}
+
+	state := stateRaw.(ChecksumState)
+	checksum := &Checksum{}
+	switch index {
+	case state.Chunks:
+		if checksum.UnmarshalBinary(state.State) != nil {
+			return nil
+		}
+	case state.Chunks - 1:
+		if checksum.UnmarshalBinary(state.Prev) != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	return checksum
+}
+
+// HookChunkChecksum 分片上传完成后保存哈希计算状态，最后一个分片完成后将哈希写入文件元信息，
+// 需在更新文件大小的钩子之前执行，以便一同保存
+func HookChunkChecksum(sessionID string, index int, checksum *Checksum, isLastChunk bool) Hook {
+	return func(ctx context.Context, fs *FileSystem, fileHeader fsctx.FileHeader) error {
+		if checksum == nil || checksum.Size != fileHeader.Info().Size {
+			cache.Deletes([]string{sessionID}, ChecksumCachePrefix)
+			return nil
+		}
+
+		if isLastChunk {
+			cache.Deletes([]string{sessionID}, ChecksumCachePrefix)
+			if fileModel, ok := fileHeader.Info().Model.(*model.File); ok {
+				return fileModel.SetChecksums(checksum.Sums())
+			}
+			return nil
+		}
+
+		state := ChecksumState{Chunks: index + 1}
+		state.State, _ = checksum.MarshalBinary()
+		if stateRaw, ok := cache.Get(ChecksumCachePrefix + sessionID); ok && stateRaw.(ChecksumState).Chunks == index {
+			state.Prev = stateRaw.(ChecksumState).State
+		}
+
+		return cache.Set(ChecksumCachePrefix+sessionID, state, model.GetIntSetting("upload_session_timeout", 86400))
+	}
+}
+
+// HookSaveChecksum 保存存储端或从机提供的文件内容哈希
+func HookSaveChecksum(sums map[string]string) Hook {
+	return func(ctx context.Context, fs *FileSystem, fileHeader fsctx.FileHeader) error {
+		if sums[model.MD5MetadataKey] == "" && sums[model.SHA256MetadataKey] == "" {
+			return nil
+		}
+
+		fileModel := fileHeader.Info().Model.(*model.File)
+		if err := fileModel.SetChecksums(sums); err != nil {
+			return err
+		}
+		return fileModel.UpdateMetadata(nil)
+	}
+}
+
+// ETagChecksum 返回存储端 ETag 对应的 MD5，分片上传生成的 ETag 不是文件内容的 MD5，返回空
+func ETagChecksum(etag string) string {
+	etag = strings.ToLower(strings.Trim(etag, `"`))
+	if len(etag) != hex.EncodedLen(md5.Size) {
+		return ""
+	}
+	if _, err := hex.DecodeString(etag); err != nil {
+		return ""
+	}
+	return etag
+}
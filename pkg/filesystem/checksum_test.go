@@ -0,0 +1,94 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksum(t *testing.T) {
+	a := assert.New(t)
+	content := "hello world"
+	md5Sum, sha256Sum := md5.Sum([]byte(content)), sha256.Sum256([]byte(content))
+
+	checksum := NewChecksum()
+	rc := NewChecksumReader(ioutil.NopCloser(strings.NewReader(content)), checksum)
+	_, err := ioutil.ReadAll(rc)
+	a.NoError(err)
+	a.NoError(rc.Close())
+	a.EqualValues(len(content), checksum.Size)
+	a.Equal(hex.EncodeToString(md5Sum[:]), checksum.Sums()[model.MD5MetadataKey])
+	a.Equal(hex.EncodeToString(sha256Sum[:]), checksum.Sums()[model.SHA256MetadataKey])
+
+	// 导出后恢复计算状态
+	partial := NewChecksum()
+	partial.Write([]byte("hello "))
+	state, err := partial.MarshalBinary()
+	a.NoError(err)
+	restored := &Checksum{}
+	a.NoError(restored.UnmarshalBinary(state))
+	restored.Write([]byte("world"))
+	a.Equal(checksum.Sums(), restored.Sums())
+	a.EqualValues(5, restored.Size)
+
+	// 状态无效
+	a.Error(restored.UnmarshalBinary(nil))
+	a.Error(restored.UnmarshalBinary([]byte{10, 1}))
+}
+
+func TestChunkChecksum(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{}
+	content := "hello world"
+	expected := NewChecksum()
+	expected.Write([]byte(content))
+
+	// 首个分片
+	first := ChunkChecksum("session", 0)
+	a.NotNil(first)
+	first.Write([]byte("hello "))
+	file := &model.File{}
+	hook := HookChunkChecksum("session", 0, first, false)
+	a.NoError(hook(context.Background(), fs, &fsctx.FileStream{Size: 6, Model: file}))
+	_, ok := cache.Get(ChecksumCachePrefix + "session")
+	a.True(ok)
+
+	// 分片顺序不连续
+	a.Nil(ChunkChecksum("session", 2))
+	a.Nil(ChunkChecksum("not-exist", 1))
+
+	// 最后一个分片
+	last := ChunkChecksum("session", 1)
+	a.NotNil(last)
+	last.Write([]byte("world"))
+	hook = HookChunkChecksum("session", 1, last, true)
+	a.NoError(hook(context.Background(), fs, &fsctx.FileStream{Size: 5, Model: file}))
+	a.Equal(expected.Sums()[model.SHA256MetadataKey], file.MetadataSerialized[model.SHA256MetadataKey])
+	a.Contains(file.Metadata, expected.Sums()[model.MD5MetadataKey])
+	_, ok = cache.Get(ChecksumCachePrefix + "session")
+	a.False(ok)
+
+	// 分片内容未完整计算
+	first = ChunkChecksum("session2", 0)
+	first.Write([]byte("hello"))
+	hook = HookChunkChecksum("session2", 0, first, false)
+	a.NoError(hook(context.Background(), fs, &fsctx.FileStream{Size: 6, Model: file}))
+	a.Nil(ChunkChecksum("session2", 1))
+}
+
+func TestETagChecksum(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("5eb63bbbe01eeed093cb22bb8f5acdc3", ETagChecksum(`"5EB63BBBE01EEED093CB22BB8F5ACDC3"`))
+	a.Empty(ETagChecksum(`"5eb63bbbe01eeed093cb22bb8f5acdc3-2"`))
+	a.Empty(ETagChecksum("zzb63bbbe01eeed093cb22bb8f5acdc3"))
+	a.Empty(ETagChecksum(""))
+}
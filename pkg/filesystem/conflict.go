@@ -0,0 +1,134 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// 上传目标重名时的处理方式
+const (
+	// ConflictReject 拒绝上传
+	ConflictReject = "reject"
+	// ConflictRename 自动重命名为不冲突的文件名
+	ConflictRename = "rename"
+	// ConflictOverwrite 覆盖原文件，原内容保留为历史版本
+	ConflictOverwrite = "overwrite"
+)
+
+// maxRenameAttempts 自动重命名时最多尝试的序号
+const maxRenameAttempts = 1000
+
+// ErrInvalidConflictMode 未知的重名处理方式
+var ErrInvalidConflictMode = serializer.NewError(serializer.CodeParamErr, "Unknown conflict mode", nil)
+
+// ParseConflictMode 校验重名处理方式，mode 为空时返回 fallback
+func ParseConflictMode(mode, fallback string) (string, error) {
+	switch mode {
+	case "":
+		return fallback, nil
+	case ConflictReject, ConflictRename, ConflictOverwrite:
+		return mode, nil
+	default:
+		return "", ErrInvalidConflictMode
+	}
+}
+
+// AvailableName 返回 folder 下与现有文件、目录均不重名的文件名，
+// 重名时在扩展名前追加序号，如 report (1).pdf
+func (fs *FileSystem) AvailableName(folder *model.Folder, name string) (string, error) {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 1; i <= maxRenameAttempts; i++ {
+		if ok, _ := fs.IsChildFileExist(folder, candidate); !ok {
+			if _, err := folder.GetChild(candidate); err != nil {
+				return candidate, nil
+			}
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+
+	return "", ErrFileExisted
+}
+
+// resolveUploadConflict 按 mode 处理上传目标 file 的重名冲突。自动重命名时修改 file 的文件名；
+// 覆盖时返回被替换的文件，文件不存在或拒绝上传时返回 nil，由后续创建文件记录时检查冲突
+func (fs *FileSystem) resolveUploadConflict(file *fsctx.FileStream, mode string) (*model.File, error) {
+	if mode == "" || mode == ConflictReject {
+		return nil, nil
+	}
+
+	exist, folder := fs.IsPathExist(file.VirtualPath)
+	if !exist {
+		return nil, nil
+	}
+
+	exist, origin := fs.IsChildFileExist(folder, file.Name)
+	if !exist {
+		return nil, nil
+	}
+
+	if origin.UploadSessionID != nil {
+		return nil, ErrFileUploadSessionExisted
+	}
+
+	switch mode {
+	case ConflictRename:
+		name, err := fs.AvailableName(folder, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		file.Name = name
+		return nil, nil
+	case ConflictOverwrite:
+		return origin, nil
+	default:
+		return nil, ErrInvalidConflictMode
+	}
+}
+
+// HookReplaceWithPlaceholder 覆盖上传完成后，以占位文件的内容作为被替换文件的新版本。
+// 被替换的文件已不存在时，将占位文件提升为正式文件并尽量恢复其原文件名
+func HookReplaceWithPlaceholder(target uint, name, picInfo string) Hook {
+	return func(ctx context.Context, fs *FileSystem, fileHeader fsctx.FileHeader) error {
+		fileInfo := fileHeader.Info()
+		placeholder := fileInfo.Model.(*model.File)
+
+		files, err := model.GetFilesByIDs([]uint{target}, fs.User.ID)
+		if err != nil || len(files) == 0 {
+			if err := placeholder.PopChunkToFile(fileInfo.LastModified, picInfo); err != nil {
+				return err
+			}
+			if err := placeholder.Rename(name); err != nil {
+				util.Log().Warning("Failed to rename uploaded file %q to %q: %s", placeholder.Name, name, err)
+			}
+			return nil
+		}
+
+		placeholder.PicInfo = picInfo
+		placeholder.UpdatedAt = time.Now()
+		if fileInfo.LastModified != nil {
+			placeholder.UpdatedAt = *fileInfo.LastModified
+		}
+		if err := files[0].ReplaceWith(placeholder, fs.User.Nick); err != nil {
+			return ErrInsertFileRecord.WithError(err)
+		}
+
+		fs.pruneVersions(ctx, target)
+		fileHeader.SetModel(&files[0])
+		return nil
+	}
+}
+
+// placeholderName 返回覆盖上传时占位文件使用的临时文件名，保留原扩展名以通过文件类型校验
+func placeholderName(sessionID, name string) string {
+	return fmt.Sprintf(".uploading_%s_%s", sessionID[:8], name)
+}
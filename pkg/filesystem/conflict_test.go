@@ -0,0 +1,55 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConflictMode(t *testing.T) {
+	a := assert.New(t)
+
+	mode, err := ParseConflictMode("", ConflictOverwrite)
+	a.NoError(err)
+	a.Equal(ConflictOverwrite, mode)
+
+	mode, err = ParseConflictMode(ConflictRename, ConflictOverwrite)
+	a.NoError(err)
+	a.Equal(ConflictRename, mode)
+
+	_, err = ParseConflictMode("merge", ConflictOverwrite)
+	a.Equal(ErrInvalidConflictMode, err)
+}
+
+func TestFileSystem_AvailableName(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+	folder := &model.Folder{Model: gorm.Model{ID: 1}, OwnerID: 1}
+
+	// 文件、目录均重名
+	mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(1, "a.txt").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(1, "a (1).txt").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(1, 1, "a (1).txt").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+	mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(1, "a (2).txt").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(1, 1, "a (2).txt").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	name, err := fs.AvailableName(folder, "a.txt")
+	a.NoError(mock.ExpectationsWereMet())
+	a.NoError(err)
+	a.Equal("a (2).txt", name)
+}
+
+func TestFileSystem_ResolveUploadConflict(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+
+	// 拒绝上传时由创建文件记录时检查
+	origin, err := fs.resolveUploadConflict(&fsctx.FileStream{Name: "a.txt", VirtualPath: "/"}, ConflictReject)
+	a.NoError(err)
+	a.Nil(origin)
+
+	a.Equal(".uploading_12345678_a.txt", placeholderName("1234567890", "a.txt"))
+}
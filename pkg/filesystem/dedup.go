@@ -0,0 +1,185 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// duplicateScanBatch 查找重复文件时每批读取的文件记录数量
+const duplicateScanBatch = 1000
+
+// DuplicateGroup 内容相同的一组文件
+type DuplicateGroup struct {
+	// Hash 分组依据的哈希，格式为 算法:哈希值；仅按大小分组时为空
+	Hash string `json:"hash,omitempty"`
+	// Verified 是否已通过内容哈希确认重复
+	Verified bool     `json:"verified"`
+	Size     uint64   `json:"size"`
+	Files    []string `json:"files"` // 编码后的文件ID
+	// Reclaimable 只保留一份时可释放的空间
+	Reclaimable uint64 `json:"reclaimable"`
+
+	// ids 原始文件ID，用于分组排序
+	ids []uint
+}
+
+// duplicateKey 返回文件用于分组的键，优先使用 SHA-256，其次 MD5，均缺失时按大小分组
+func duplicateKey(file *model.File) (key string, verified bool) {
+	if sum := file.MetadataSerialized[model.SHA256MetadataKey]; sum != "" {
+		return "sha256:" + sum, true
+	}
+	if sum := file.MetadataSerialized[model.MD5MetadataKey]; sum != "" {
+		return "md5:" + sum, true
+	}
+	return fmt.Sprintf("size:%d", file.Size), false
+}
+
+// sameContent 返回两个文件是否已通过内容哈希确认一致
+func sameContent(a, b *model.File) bool {
+	if a.Size != b.Size {
+		return false
+	}
+
+	keyA, verifiedA := duplicateKey(a)
+	keyB, _ := duplicateKey(b)
+	return verifiedA && keyA == keyB
+}
+
+// FindDuplicates 查找用户的重复文件，按可释放空间降序返回。
+// 未计算哈希的文件仅按大小分组，分组结果标记为未确认
+func (fs *FileSystem) FindDuplicates(ctx context.Context) ([]DuplicateGroup, error) {
+	groups := make(map[string]*DuplicateGroup)
+	var after uint
+	for {
+		files, err := model.GetFilesByUserAfter(fs.User.ID, after, duplicateScanBatch)
+		if err != nil {
+			return nil, ErrDBListObjects.WithError(err)
+		}
+
+		for i := range files {
+			after = files[i].ID
+			if files[i].Size == 0 {
+				continue
+			}
+
+			key, verified := duplicateKey(&files[i])
+			group, ok := groups[key]
+			if !ok {
+				group = &DuplicateGroup{Verified: verified, Size: files[i].Size}
+				if verified {
+					group.Hash = key
+				}
+				groups[key] = group
+			}
+			group.ids = append(group.ids, files[i].ID)
+		}
+
+		if len(files) < duplicateScanBatch {
+			break
+		}
+	}
+
+	res := make([]DuplicateGroup, 0)
+	for _, group := range groups {
+		if len(group.ids) < 2 {
+			continue
+		}
+
+		group.Files = make([]string, 0, len(group.ids))
+		for _, id := range group.ids {
+			group.Files = append(group.Files, hashid.HashID(id, hashid.FileID))
+		}
+		group.Reclaimable = group.Size * uint64(len(group.ids)-1)
+		res = append(res, *group)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Reclaimable != res[j].Reclaimable {
+			return res[i].Reclaimable > res[j].Reclaimable
+		}
+		return res[i].ids[0] < res[j].ids[0]
+	})
+
+	return res, nil
+}
+
+// ResolveDuplicates 处理与 keep 内容相同的 duplicates，link 为 false 时删除重复文件，
+// 为 true 时保留文件记录并改为引用 keep 的物理文件，不再被引用的物理文件随后删除
+func (fs *FileSystem) ResolveDuplicates(ctx context.Context, keep uint, duplicates []uint, link bool) error {
+	files, err := model.GetFilesByIDs(append([]uint{keep}, duplicates...), fs.User.ID)
+	if err != nil || len(files) != len(duplicates)+1 {
+		return ErrObjectNotExist
+	}
+
+	var keepFile *model.File
+	dupFiles := make([]*model.File, 0, len(duplicates))
+	for i := range files {
+		if files[i].ID == keep {
+			keepFile = &files[i]
+		} else {
+			dupFiles = append(dupFiles, &files[i])
+		}
+	}
+
+	if keepFile == nil || keepFile.UploadSessionID != nil {
+		return ErrObjectNotExist
+	}
+
+	for _, dup := range dupFiles {
+		if dup.UploadSessionID != nil || !sameContent(keepFile, dup) {
+			return ErrNotDuplicate
+		}
+	}
+
+	if !link {
+		fs.CleanTargets()
+		return fs.Delete(ctx, []uint{}, duplicates, false, false)
+	}
+
+	// 改为引用其他物理文件等同于覆盖文件内容
+	if err := fs.CheckRetention(nil, duplicates); err != nil {
+		return err
+	}
+
+	if err := fs.CheckLocks(ctx, nil, duplicates); err != nil {
+		return err
+	}
+
+	orphans := make([]model.File, 0, len(dupFiles))
+	for _, dup := range dupFiles {
+		if dup.PolicyID == keepFile.PolicyID && dup.SourceName == keepFile.SourceName {
+			continue
+		}
+
+		// 保留原物理文件的信息，用于删除其缩略图
+		origin := *dup
+		origin.MetadataSerialized = make(map[string]string, len(dup.MetadataSerialized))
+		for k, v := range dup.MetadataSerialized {
+			origin.MetadataSerialized[k] = v
+		}
+
+		if err := dup.Relink(keepFile); err != nil {
+			return err
+		}
+
+		if count, err := model.CountFilesBySource(origin.PolicyID, origin.SourceName); err == nil && count == 0 {
+			orphans = append(orphans, origin)
+		}
+	}
+
+	if len(orphans) > 0 {
+		failed := fs.deleteGroupedFile(ctx, fs.GroupFileByPolicy(ctx, orphans))
+		for policyID, sources := range failed {
+			for _, source := range sources {
+				util.Log().Warning("Failed to delete unreferenced file %q of policy %d.", source, policyID)
+			}
+		}
+	}
+
+	return nil
+}
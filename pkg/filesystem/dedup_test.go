@@ -0,0 +1,150 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	a := assert.New(t)
+	file := &model.File{Size: 10, MetadataSerialized: map[string]string{}}
+
+	key, verified := duplicateKey(file)
+	a.Equal("size:10", key)
+	a.False(verified)
+
+	file.MetadataSerialized[model.MD5MetadataKey] = "md5"
+	key, verified = duplicateKey(file)
+	a.Equal("md5:md5", key)
+	a.True(verified)
+
+	file.MetadataSerialized[model.SHA256MetadataKey] = "sha"
+	key, verified = duplicateKey(file)
+	a.Equal("sha256:sha", key)
+	a.True(verified)
+
+	// 大小不一致或未确认
+	other := &model.File{Size: 10, MetadataSerialized: map[string]string{model.SHA256MetadataKey: "sha"}}
+	a.True(sameContent(file, other))
+	other.Size = 11
+	a.False(sameContent(file, other))
+	a.False(sameContent(&model.File{Size: 10}, &model.File{Size: 10}))
+}
+
+func TestFileSystem_FindDuplicates(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+
+	// 查询失败
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnError(ErrObjectNotExist)
+		res, err := fs.FindDuplicates(context.Background())
+		a.NoError(mock.ExpectationsWereMet())
+		a.Error(err)
+		a.Nil(res)
+	}
+
+	// 成功
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "size", "metadata"}).
+				AddRow(1, 10, `{"hash_sha256":"a"}`).
+				AddRow(2, 10, `{"hash_sha256":"a"}`).
+				AddRow(3, 10, `{"hash_sha256":"b"}`).
+				AddRow(4, 20, "").
+				AddRow(5, 20, "").
+				AddRow(6, 20, "").
+				AddRow(7, 0, "").
+				AddRow(8, 0, ""),
+		)
+		res, err := fs.FindDuplicates(context.Background())
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.Len(res, 2)
+		a.False(res[0].Verified)
+		a.Equal([]string{
+			hashid.HashID(4, hashid.FileID),
+			hashid.HashID(5, hashid.FileID),
+			hashid.HashID(6, hashid.FileID),
+		}, res[0].Files)
+		a.EqualValues(40, res[0].Reclaimable)
+		a.True(res[1].Verified)
+		a.Equal("sha256:a", res[1].Hash)
+		a.EqualValues(10, res[1].Reclaimable)
+	}
+}
+
+func TestFileSystem_ResolveDuplicates(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+
+	// 文件不存在
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		a.Equal(ErrObjectNotExist, fs.ResolveDuplicates(context.Background(), 1, []uint{2}, false))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 内容未确认一致
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "size", "metadata"}).
+				AddRow(1, 10, `{"hash_sha256":"a"}`).
+				AddRow(2, 10, ""),
+		)
+		a.Equal(ErrNotDuplicate, fs.ResolveDuplicates(context.Background(), 1, []uint{2}, true))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 已引用同一物理文件，无需处理
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "size", "policy_id", "source_name", "metadata"}).
+				AddRow(1, 10, 1, "1.txt", `{"hash_sha256":"a"}`).
+				AddRow(2, 10, 1, "1.txt", `{"hash_sha256":"a"}`),
+		)
+		expectNoRetention()
+		expectNoLocks()
+		a.NoError(fs.ResolveDuplicates(context.Background(), 1, []uint{2}, true))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 替换为引用，原物理文件仍被其他记录引用
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "size", "policy_id", "source_name", "metadata"}).
+				AddRow(1, 10, 1, "1.txt", `{"hash_sha256":"a"}`).
+				AddRow(2, 10, 1, "2.txt", `{"hash_sha256":"a","thumb_status":"exist"}`),
+		)
+		expectNoRetention()
+		expectNoLocks()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT count(.+)").WithArgs(1, "2.txt").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		a.NoError(fs.ResolveDuplicates(context.Background(), 1, []uint{2}, true))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+	// 重复文件被冻结
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "size", "policy_id", "source_name", "metadata"}).
+				AddRow(1, 10, 1, "1.txt", `{"hash_sha256":"a"}`).
+				AddRow(2, 10, 1, "2.txt", `{"hash_sha256":"a"}`),
+		)
+		mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "object_id", "is_dir", "user_id"}).AddRow(1, 2, false, 1))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(2, "2.txt", 1))
+		err := fs.ResolveDuplicates(context.Background(), 1, []uint{2}, true)
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(serializer.CodeRetentionHold, err.(serializer.AppError).Code)
+	}
+}
@@ -0,0 +1,310 @@
+package filesystem
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+	 分块增量同步
+   ================
+*/
+
+const (
+	// DeltaSignatureCachePrefix 文件分块签名的缓存前缀
+	DeltaSignatureCachePrefix = "delta_sig_"
+
+	// MinDeltaBlockSize 允许的最小分块大小
+	MinDeltaBlockSize = 4 << 10
+	// MaxDeltaBlockSize 允许的最大分块大小
+	MaxDeltaBlockSize = 16 << 20
+
+	// DeltaOpCopy 增量指令：复制原内容中的连续分块，参数为起始分块序号与分块数量
+	DeltaOpCopy byte = 1
+	// DeltaOpData 增量指令：写入新数据，参数为数据长度，随后紧跟数据
+	DeltaOpData byte = 2
+)
+
+var (
+	ErrDeltaBaseChanged = serializer.NewError(serializer.CodeEditConflict, "File has been modified since the signature was generated", nil)
+	ErrInvalidDelta     = serializer.NewError(serializer.CodeParamErr, "Invalid delta stream", nil)
+	ErrDeltaMismatch    = serializer.NewError(serializer.CodeMetaMismatch, "Reassembled content does not match the expected hash", nil)
+)
+
+func init() {
+	gob.Register(DeltaSignature{})
+}
+
+// BlockSignature 单个分块的签名
+type BlockSignature struct {
+	// Weak 分块的滚动校验和，用于客户端快速匹配
+	Weak uint32 `json:"weak"`
+	// Strong 分块的 MD5，用于确认匹配
+	Strong string `json:"strong"`
+}
+
+// DeltaSignature 文件各分块的签名
+type DeltaSignature struct {
+	// Base 生成签名时文件内容的标识，提交增量时需原样传回
+	Base      string           `json:"base"`
+	BlockSize int              `json:"block_size"`
+	Size      uint64           `json:"size"`
+	Blocks    []BlockSignature `json:"blocks"`
+}
+
+// RollingChecksum rsync 风格的弱校验和，可在窗口滑动一个字节时以 O(1) 更新
+type RollingChecksum struct {
+	a, b uint32
+	n    uint32
+}
+
+// Write 以 p 作为初始窗口计算校验和
+func (r *RollingChecksum) Write(p []byte) (int, error) {
+	for i, c := range p {
+		r.a += uint32(c)
+		r.b += uint32(len(p)-i) * uint32(c)
+	}
+	r.n += uint32(len(p))
+	return len(p), nil
+}
+
+// Roll 将窗口向后滑动一个字节，out 为移出窗口的字节，in 为移入窗口的字节
+func (r *RollingChecksum) Roll(out, in byte) {
+	r.a = r.a - uint32(out) + uint32(in)
+	r.b = r.b - r.n*uint32(out) + r.a
+}
+
+// Sum32 返回当前窗口的校验和
+func (r *RollingChecksum) Sum32() uint32 {
+	return (r.b&0xffff)<<16 | r.a&0xffff
+}
+
+// Reset 清空校验和
+func (r *RollingChecksum) Reset() {
+	r.a, r.b, r.n = 0, 0, 0
+}
+
+// DeltaBase 返回文件当前内容的标识，文件内容被替换后会发生变化
+func DeltaBase(file *model.File) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%d|%s|%d|%d", file.PolicyID, file.SourceName, file.Size, file.UpdatedAt.UnixNano())
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// DeltaBlockSize 校验客户端指定的分块大小，未指定时使用站点默认值
+func DeltaBlockSize(size int) (int, error) {
+	if size == 0 {
+		size = model.GetIntSetting("delta_block_size", 1<<20)
+	}
+	if size < MinDeltaBlockSize || size > MaxDeltaBlockSize {
+		return 0, serializer.NewError(serializer.CodeParamErr,
+			fmt.Sprintf("Block size must be between %d and %d", MinDeltaBlockSize, MaxDeltaBlockSize), nil)
+	}
+	return size, nil
+}
+
+// ComputeSignature 按 blockSize 计算 r 中各分块的签名，最后一个分块可能不足 blockSize
+func ComputeSignature(r io.Reader, blockSize int) ([]BlockSignature, uint64, error) {
+	var (
+		blocks []BlockSignature
+		size   uint64
+		weak   RollingChecksum
+	)
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			weak.Reset()
+			weak.Write(buf[:n])
+			strong := md5.Sum(buf[:n])
+			blocks = append(blocks, BlockSignature{Weak: weak.Sum32(), Strong: hex.EncodeToString(strong[:])})
+			size += uint64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return blocks, size, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+}
+
+// Signature 返回文件按 blockSize 分块的签名，结果按文件内容缓存
+func (fs *FileSystem) Signature(ctx context.Context, file *model.File, blockSize int) (*DeltaSignature, error) {
+	base := DeltaBase(file)
+	cacheKey := fmt.Sprintf("%s%s_%d", DeltaSignatureCachePrefix, base, blockSize)
+	if sig, ok := cache.Get(cacheKey); ok {
+		res := sig.(DeltaSignature)
+		return &res, nil
+	}
+
+	fs.SetTargetFile(&[]model.File{*file})
+	defer fs.CleanTargets()
+
+	rs, err := fs.GetContent(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	blocks, size, err := ComputeSignature(rs, blockSize)
+	if err != nil {
+		return nil, ErrIO.WithError(err)
+	}
+
+	sig := DeltaSignature{Base: base, BlockSize: blockSize, Size: size, Blocks: blocks}
+	_ = cache.Set(cacheKey, sig, model.GetIntSetting("delta_signature_ttl", 3600))
+	return &sig, nil
+}
+
+// ApplyDelta 读取 delta 中的增量指令，以 base 的内容为基础重建新内容并写入 dst，返回写入的字节数。
+// 复制指令引用的分块需完整位于 base 内，最后一个不足 blockSize 的分块按实际长度复制；
+// maxSize 大于 0 时新内容不得超过 maxSize
+func ApplyDelta(base io.ReadSeeker, baseSize uint64, blockSize int, delta io.Reader, dst io.Writer, maxSize uint64) (uint64, error) {
+	var (
+		written uint64
+		header  [9]byte
+	)
+	blocks := (baseSize + uint64(blockSize) - 1) / uint64(blockSize)
+
+	checkSize := func(n uint64) error {
+		if maxSize > 0 && written+n > maxSize {
+			return ErrFileSizeTooBig
+		}
+		return nil
+	}
+
+	for {
+		if _, err := io.ReadFull(delta, header[:1]); err == io.EOF {
+			return written, nil
+		} else if err != nil {
+			return written, ErrInvalidDelta.WithError(err)
+		}
+
+		switch header[0] {
+		case DeltaOpCopy:
+			if _, err := io.ReadFull(delta, header[1:9]); err != nil {
+				return written, ErrInvalidDelta.WithError(err)
+			}
+			start := uint64(binary.BigEndian.Uint32(header[1:5]))
+			count := uint64(binary.BigEndian.Uint32(header[5:9]))
+			if count == 0 || start+count > blocks {
+				return written, ErrInvalidDelta
+			}
+
+			offset := start * uint64(blockSize)
+			length := count * uint64(blockSize)
+			if offset+length > baseSize {
+				length = baseSize - offset
+			}
+			if err := checkSize(length); err != nil {
+				return written, err
+			}
+
+			if _, err := base.Seek(int64(offset), io.SeekStart); err != nil {
+				return written, ErrIO.WithError(err)
+			}
+			n, err := io.CopyN(dst, base, int64(length))
+			written += uint64(n)
+			if err != nil {
+				return written, ErrIO.WithError(err)
+			}
+		case DeltaOpData:
+			if _, err := io.ReadFull(delta, header[1:5]); err != nil {
+				return written, ErrInvalidDelta.WithError(err)
+			}
+			length := uint64(binary.BigEndian.Uint32(header[1:5]))
+			if err := checkSize(length); err != nil {
+				return written, err
+			}
+
+			n, err := io.CopyN(dst, delta, int64(length))
+			written += uint64(n)
+			if err != nil {
+				return written, ErrInvalidDelta.WithError(err)
+			}
+		default:
+			return written, ErrInvalidDelta
+		}
+	}
+}
+
+// PatchFile 以 delta 中的增量指令重建文件内容，并保存为文件的新版本。
+// base 需与生成签名时的文件内容标识一致；sha256 不为空时重建后的内容需与之匹配。
+// 此方法会为文件系统挂载钩子，调用方应使用单独的文件系统实例
+func (fs *FileSystem) PatchFile(ctx context.Context, file *model.File, base string, blockSize int, delta io.Reader, sha256Sum string) error {
+	if base != DeltaBase(file) {
+		return ErrDeltaBaseChanged
+	}
+
+	// 重建的内容不得超过用户剩余容量及存储策略的单文件大小限制，
+	// 避免构造的复制指令反复引用原内容，无限制地写入临时文件
+	maxSize := fs.User.GetRemainingCapacity()
+	if maxSize == 0 {
+		return ErrInsufficientCapacity
+	}
+	if policyMax := file.GetPolicy().MaxSize; policyMax > 0 && policyMax < maxSize {
+		maxSize = policyMax
+	}
+
+	fs.SetTargetFile(&[]model.File{*file})
+	rs, err := fs.GetContent(ctx, file.ID)
+	fs.CleanTargets()
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	// 重建的内容先写入临时文件
+	tempPath := filepath.Join(
+		util.RelativePath(model.GetSettingByName("temp_path")),
+		"delta",
+		fmt.Sprintf("%d_%d", file.ID, time.Now().UnixNano()),
+	)
+	temp, err := util.CreatNestedFile(tempPath)
+	if err != nil {
+		return ErrIO.WithError(err)
+	}
+	defer func() {
+		temp.Close()
+		os.Remove(tempPath)
+	}()
+
+	checksum := NewChecksum()
+	size, err := ApplyDelta(rs, file.Size, blockSize, delta, io.MultiWriter(temp, checksum), maxSize)
+	if err != nil {
+		return err
+	}
+
+	sums := checksum.Sums()
+	if sha256Sum != "" && !strings.EqualFold(sha256Sum, sums[model.SHA256MetadataKey]) {
+		return ErrDeltaMismatch
+	}
+
+	if _, err := temp.Seek(0, io.SeekStart); err != nil {
+		return ErrIO.WithError(err)
+	}
+
+	return fs.SaveNewVersion(ctx, file, &fsctx.FileStream{
+		File:     temp,
+		Seeker:   temp,
+		Size:     size,
+		Metadata: sums,
+	}, fs.User.Nick)
+}
@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func deltaCopy(start, count uint32) []byte {
+	op := make([]byte, 9)
+	op[0] = DeltaOpCopy
+	binary.BigEndian.PutUint32(op[1:5], start)
+	binary.BigEndian.PutUint32(op[5:9], count)
+	return op
+}
+
+func deltaData(data string) []byte {
+	op := make([]byte, 5)
+	op[0] = DeltaOpData
+	binary.BigEndian.PutUint32(op[1:5], uint32(len(data)))
+	return append(op, data...)
+}
+
+func TestRollingChecksum(t *testing.T) {
+	a := assert.New(t)
+	content := []byte("the quick brown fox jumps over the lazy dog")
+	window := 8
+
+	rolling := &RollingChecksum{}
+	rolling.Write(content[:window])
+	for i := 1; i+window <= len(content); i++ {
+		rolling.Roll(content[i-1], content[i+window-1])
+		fresh := &RollingChecksum{}
+		fresh.Write(content[i : i+window])
+		a.Equal(fresh.Sum32(), rolling.Sum32(), "offset %d", i)
+	}
+}
+
+func TestComputeSignature(t *testing.T) {
+	a := assert.New(t)
+
+	blocks, size, err := ComputeSignature(strings.NewReader("aaaabbbbcc"), 4)
+	a.NoError(err)
+	a.EqualValues(10, size)
+	a.Len(blocks, 3)
+	a.NotEqual(blocks[0].Strong, blocks[1].Strong)
+
+	blocks, size, err = ComputeSignature(strings.NewReader(""), 4)
+	a.NoError(err)
+	a.EqualValues(0, size)
+	a.Len(blocks, 0)
+}
+
+func TestDeltaBlockSize(t *testing.T) {
+	a := assert.New(t)
+
+	size, err := DeltaBlockSize(MinDeltaBlockSize)
+	a.NoError(err)
+	a.Equal(MinDeltaBlockSize, size)
+
+	_, err = DeltaBlockSize(MinDeltaBlockSize - 1)
+	a.Error(err)
+	_, err = DeltaBlockSize(MaxDeltaBlockSize + 1)
+	a.Error(err)
+}
+
+func TestApplyDelta(t *testing.T) {
+	a := assert.New(t)
+	base := strings.NewReader("aaaabbbbcc")
+
+	// 复制、写入新数据，最后一个分块按实际长度复制
+	{
+		delta := bytes.NewBuffer(nil)
+		delta.Write(deltaCopy(1, 2))
+		delta.Write(deltaData("xyz"))
+		delta.Write(deltaCopy(0, 1))
+		dst := bytes.NewBuffer(nil)
+		n, err := ApplyDelta(base, 10, 4, delta, dst, 0)
+		a.NoError(err)
+		a.EqualValues(13, n)
+		a.Equal("bbbbccxyzaaaa", dst.String())
+	}
+
+	// 分块超出原内容
+	{
+		_, err := ApplyDelta(base, 10, 4, bytes.NewReader(deltaCopy(2, 2)), bytes.NewBuffer(nil), 0)
+		a.Equal(ErrInvalidDelta, err)
+	}
+
+	// 未知指令
+	{
+		_, err := ApplyDelta(base, 10, 4, bytes.NewReader([]byte{9}), bytes.NewBuffer(nil), 0)
+		a.Equal(ErrInvalidDelta, err)
+	}
+
+	// 数据不完整
+	{
+		_, err := ApplyDelta(base, 10, 4, bytes.NewReader(deltaData("xyz")[:6]), bytes.NewBuffer(nil), 0)
+		a.Error(err)
+	}
+
+	// 超出大小限制
+	{
+		_, err := ApplyDelta(base, 10, 4, bytes.NewReader(deltaData("xyz")), bytes.NewBuffer(nil), 2)
+		a.Equal(ErrFileSizeTooBig, err)
+	}
+}
+
+func TestFileSystem_PatchFile(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	file := &model.File{SourceName: "1.bin", Size: 10}
+
+	// 内容已变化
+	a.Equal(ErrDeltaBaseChanged, fs.PatchFile(context.Background(), file, "mismatch", 4, bytes.NewReader(nil), ""))
+	a.Equal(DeltaBase(file), DeltaBase(&model.File{SourceName: "1.bin", Size: 10}))
+	a.NotEqual(DeltaBase(file), DeltaBase(&model.File{SourceName: "1.bin", Size: 11}))
+
+	// 容量已用尽
+	a.Equal(ErrInsufficientCapacity, fs.PatchFile(context.Background(), file, DeltaBase(file), 4, bytes.NewReader(nil), ""))
+}
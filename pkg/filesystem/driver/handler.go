@@ -4,11 +4,27 @@ import (
 	"context"
 	"fmt"
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/response"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 )
 
+// DownloadBinding 根据存储策略设置返回下载地址的使用限制
+func DownloadBinding(ctx context.Context, policy *model.Policy) auth.URIBinding {
+	binding := auth.URIBinding{}
+	clientIP, ok := ctx.Value(fsctx.DownloadClientIPCtx).(string)
+	if !ok {
+		return binding
+	}
+
+	if policy.OptionsSerialized.DownloadBindIP {
+		binding.IP = clientIP
+	}
+	binding.OneTime = policy.OptionsSerialized.DownloadOneTime
+	return binding
+}
+
 var (
 	ErrorThumbNotExist     = fmt.Errorf("thumb not exist")
 	ErrorThumbNotSupported = fmt.Errorf("thumb not supported")
@@ -49,3 +65,25 @@ type Handler interface {
 	// recursive - 是否递归列出
 	List(ctx context.Context, path string, recursive bool) ([]response.Object, error)
 }
+
+// Archiver 支持转换对象存储类型的适配器，用于生命周期规则将文件转为归档存储
+type Archiver interface {
+	// Archive 将给定路径的文件转为 storageClass 存储类型，为空时使用默认的归档类型，
+	// 返回转换失败的文件路径列表及遇到的最后一个错误
+	Archive(ctx context.Context, files []string, storageClass string) ([]string, error)
+
+	// Restore 为归档存储中的文件发起解冻请求，解冻后的临时副本保留 days 天，
+	// 返回请求失败的文件路径列表及遇到的最后一个错误
+	Restore(ctx context.Context, files []string, days int) ([]string, error)
+
+	// Rehydrate 将已解冻完成的文件原地复制转回标准存储类型，仍在解冻中的文件不做处理，
+	// 返回已转回标准存储的文件路径列表及遇到的最后一个错误
+	Rehydrate(ctx context.Context, files []string) ([]string, error)
+}
+
+// MetaGenerator 由存储端生成缩略图与元数据的适配器，主机无法直接读取文件内容时使用，
+// 生成结果由存储端异步推送至请求中的回调地址
+type MetaGenerator interface {
+	// GenerateMeta 请求存储端为文件生成缩略图与元数据
+	GenerateMeta(ctx context.Context, req *serializer.SlaveMetaReq) error
+}
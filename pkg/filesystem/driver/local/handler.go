@@ -247,11 +247,21 @@ func (handler Driver) Source(ctx context.Context, path string, ttl int64, isDown
 			return "", serializer.NewError(serializer.CodeCacheOperation, "Failed to create download session", err)
 		}
 
+		// 记录下载会话的额外限制，在中转下载时执行
+		if limit, ok := ctx.Value(fsctx.DownloadLimitCtx).(*fsctx.DownloadLimit); ok {
+			sessionLimit := *limit
+			sessionLimit.SpeedLimit = speed
+			if err := cache.Set("download_limit_"+downloadSessionID, sessionLimit, int(ttl)); err != nil {
+				return "", serializer.NewError(serializer.CodeCacheOperation, "Failed to create download session", err)
+			}
+		}
+
 		// 签名生成文件记录
-		signedURI, err = auth.SignURI(
+		signedURI, err = auth.SignBoundURI(
 			auth.General,
 			fmt.Sprintf("/api/v3/file/download/%s", downloadSessionID),
 			ttl,
+			driver.DownloadBinding(ctx, handler.Policy),
 		)
 	} else {
 		// 签名生成文件记录
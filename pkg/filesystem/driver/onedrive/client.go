@@ -5,6 +5,7 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
 	"github.com/cloudreve/Cloudreve/v3/pkg/request"
 )
 
@@ -59,7 +60,7 @@ func NewClient(policy *model.Policy) (*Client, error) {
 		ClientID:          policy.BucketName,
 		ClientSecret:      policy.SecretKey,
 		Redirect:          policy.OptionsSerialized.OauthRedirect,
-		Request:           request.NewClient(),
+		Request:           newRequestClient(policy),
 		ClusterController: cluster.DefaultController,
 	}
 
@@ -75,3 +76,11 @@ func NewClient(policy *model.Policy) (*Client, error) {
 
 	return client, nil
 }
+
+// newRequestClient 创建请求客户端，用户挂载的外部存储只允许连接公网地址
+func newRequestClient(policy *model.Policy) request.Client {
+	if policy.IsMounted() {
+		return request.NewClient(request.WithTransport(netpolicy.PublicTransport()))
+	}
+	return request.NewClient()
+}
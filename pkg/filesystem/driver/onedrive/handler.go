@@ -36,7 +36,7 @@ func NewDriver(policy *model.Policy) (driver.Handler, error) {
 	return Driver{
 		Policy:     policy,
 		Client:     client,
-		HTTPClient: request.NewClient(),
+		HTTPClient: newRequestClient(policy),
 	}, err
 }
 
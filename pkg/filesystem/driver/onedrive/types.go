@@ -36,7 +36,13 @@ type FileInfo struct {
 }
 
 type file struct {
-	MimeType string `json:"mimeType"`
+	MimeType string  `json:"mimeType"`
+	Hashes   *hashes `json:"hashes"`
+}
+
+// hashes 文件内容哈希，SHA-256 仅 OneDrive 个人版提供
+type hashes struct {
+	Sha256Hash string `json:"sha256Hash"`
 }
 
 type folder struct {
@@ -286,6 +286,83 @@ func (handler *Driver) Delete(ctx context.Context, files []string) ([]string, er
 	return []string{}, nil
 }
 
+// Archive 原地复制对象以转换存储类型，
+// 返回转换失败的文件，及遇到的最后一个错误
+func (handler *Driver) Archive(ctx context.Context, files []string, storageClass string) ([]string, error) {
+	if storageClass == "" {
+		storageClass = string(oss.StorageArchive)
+	}
+
+	var (
+		failed  = make([]string, 0, len(files))
+		lastErr error
+	)
+	for _, file := range files {
+		_, err := handler.bucket.CopyObject(file, file,
+			oss.ObjectStorageClass(oss.StorageClassType(storageClass)),
+			oss.MetadataDirective(oss.MetaCopy),
+		)
+		if err != nil {
+			failed = append(failed, file)
+			lastErr = err
+		}
+	}
+
+	return failed, lastErr
+}
+
+// Restore 为归档的对象发起解冻请求，已在解冻中的对象重复请求时服务端直接返回成功。
+// OSS 解冻后的副本固定保留一天，忽略 days，解冻完成后应尽快转回标准存储。
+// 返回请求失败的文件，及遇到的最后一个错误
+func (handler *Driver) Restore(ctx context.Context, files []string, days int) ([]string, error) {
+	var (
+		failed  = make([]string, 0, len(files))
+		lastErr error
+	)
+	for _, file := range files {
+		if err := handler.bucket.RestoreObject(file); err != nil {
+			failed = append(failed, file)
+			lastErr = err
+		}
+	}
+
+	return failed, lastErr
+}
+
+// Rehydrate 将已解冻完成的对象原地复制为标准存储类型，
+// 返回已转回标准存储的文件，及遇到的最后一个错误
+func (handler *Driver) Rehydrate(ctx context.Context, files []string) ([]string, error) {
+	var (
+		done    = make([]string, 0, len(files))
+		lastErr error
+	)
+	for _, file := range files {
+		meta, err := handler.bucket.GetObjectDetailedMeta(file)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if class := meta.Get(oss.HTTPHeaderOssStorageClass); class == "" || class == string(oss.StorageStandard) {
+			done = append(done, file)
+			continue
+		}
+
+		// 解冻完成后 x-oss-restore 头为 ongoing-request="false"
+		if !strings.Contains(meta.Get("X-Oss-Restore"), `ongoing-request="false"`) {
+			continue
+		}
+
+		if _, err := handler.Archive(ctx, []string{file}, string(oss.StorageStandard)); err != nil {
+			lastErr = err
+			continue
+		}
+		done = append(done, file)
+	}
+
+	return done, lastErr
+}
+
 // Thumb 获取文件缩略图
 func (handler *Driver) Thumb(ctx context.Context, file *model.File) (*response.ContentResponse, error) {
 	// quick check by extension name
@@ -421,7 +498,7 @@ func (handler *Driver) Token(ctx context.Context, ttl int64, uploadSession *seri
 	// 回调策略
 	callbackPolicy := CallbackPolicy{
 		CallbackURL:      apiURL.String(),
-		CallbackBody:     `{"name":${x:fname},"source_name":${object},"size":${size},"pic_info":"${imageInfo.width},${imageInfo.height}"}`,
+		CallbackBody:     `{"name":${x:fname},"source_name":${object},"size":${size},"pic_info":"${imageInfo.width},${imageInfo.height}","content_md5":"${contentMd5}"}`,
 		CallbackBodyType: "application/json",
 	}
 	callbackPolicyJSON, err := json.Marshal(callbackPolicy)
@@ -37,6 +37,8 @@ type Client interface {
 	Upload(ctx context.Context, file fsctx.FileHeader) error
 	// DeleteUploadSession deletes remote upload session
 	DeleteUploadSession(ctx context.Context, sessionID string) error
+	// GenerateMeta requests slave to generate thumbnail and metadata, results are pushed back via callback
+	GenerateMeta(ctx context.Context, req *serializer.SlaveMetaReq) error
 }
 
 // NewClient creates new Client from given policy
@@ -157,6 +159,29 @@ func (c *remoteClient) CreateUploadSession(ctx context.Context, session *seriali
 	return nil
 }
 
+func (c *remoteClient) GenerateMeta(ctx context.Context, req *serializer.SlaveMetaReq) error {
+	reqBodyEncoded, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Request(
+		"POST",
+		"meta",
+		strings.NewReader(string(reqBodyEncoded)),
+		request.WithContext(ctx),
+	).CheckHTTPResponse(200).DecodeResponse()
+	if err != nil {
+		return err
+	}
+
+	if resp.Code != 0 {
+		return serializer.NewErrorFromResponse(resp)
+	}
+
+	return nil
+}
+
 func (c *remoteClient) GetUploadURL(ttl int64, sessionID string) (string, string, error) {
 	base, err := url.Parse(c.policy.Server)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/cloudreve/Cloudreve/v3/pkg/mocks/requestmock"
 	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/stretchr/testify/assert"
 	testMock "github.com/stretchr/testify/mock"
 	"io/ioutil"
@@ -213,6 +214,56 @@ func TestRemoteClient_CreateUploadSessionFailed(t *testing.T) {
 	clientMock.AssertExpectations(t)
 }
 
+func TestRemoteClient_GenerateMeta(t *testing.T) {
+	a := assert.New(t)
+	c, _ := NewClient(&model.Policy{})
+	req := &serializer.SlaveMetaReq{Src: "a.mp4", Name: "a.mp4", Thumb: true}
+
+	// 从机返回错误
+	{
+		clientMock := requestmock.RequestMock{}
+		c.(*remoteClient).httpClient = &clientMock
+		clientMock.On(
+			"Request",
+			"POST",
+			"meta",
+			testMock.Anything,
+			testMock.Anything,
+		).Return(&request.Response{
+			Err: nil,
+			Response: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"code":500,"msg":"error"}`)),
+			},
+		})
+		err := c.GenerateMeta(context.Background(), req)
+		a.Error(err)
+		a.Contains(err.Error(), "error")
+		clientMock.AssertExpectations(t)
+	}
+
+	// 成功
+	{
+		clientMock := requestmock.RequestMock{}
+		c.(*remoteClient).httpClient = &clientMock
+		clientMock.On(
+			"Request",
+			"POST",
+			"meta",
+			testMock.Anything,
+			testMock.Anything,
+		).Return(&request.Response{
+			Err: nil,
+			Response: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"code":0}`)),
+			},
+		})
+		a.NoError(c.GenerateMeta(context.Background(), req))
+		clientMock.AssertExpectations(t)
+	}
+}
+
 func TestRemoteClient_UploadChunkFailed(t *testing.T) {
 	a := assert.New(t)
 	c, _ := NewClient(&model.Policy{})
@@ -214,7 +214,9 @@ func (handler *Driver) Thumb(ctx context.Context, file *model.File) (*response.C
 		supported = handler.Policy.OptionsSerialized.ThumbExts
 	}
 
-	if !util.IsInExtensionList(supported, file.Name) {
+	// 从机已在上传后生成了缩略图的文件不受扩展名限制
+	if file.MetadataSerialized[model.ThumbStatusMetadataKey] != model.ThumbStatusExist &&
+		!util.IsInExtensionList(supported, file.Name) {
 		return nil, driver.ErrorThumbNotSupported
 	}
 
@@ -232,6 +234,11 @@ func (handler *Driver) Thumb(ctx context.Context, file *model.File) (*response.C
 	}, nil
 }
 
+// GenerateMeta 请求从机生成缩略图与元数据
+func (handler *Driver) GenerateMeta(ctx context.Context, req *serializer.SlaveMetaReq) error {
+	return handler.uploadClient.GenerateMeta(ctx, req)
+}
+
 // Source 获取外链URL
 func (handler *Driver) Source(ctx context.Context, path string, ttl int64, isDownload bool, speed int) (string, error) {
 	// 尝试从上下文获取文件名
@@ -264,10 +271,15 @@ func (handler *Driver) Source(ctx context.Context, path string, ttl int64, isDow
 
 	// 签名下载地址
 	sourcePath := base64.RawURLEncoding.EncodeToString([]byte(path))
-	signedURI, err = auth.SignURI(
+	binding := auth.URIBinding{}
+	if isDownload {
+		binding = driver.DownloadBinding(ctx, handler.Policy)
+	}
+	signedURI, err = auth.SignBoundURI(
 		handler.AuthInstance,
 		fmt.Sprintf("%s/%d/%s/%s", controller, speed, sourcePath, url.PathEscape(fileName)),
 		ttl,
+		binding,
 	)
 
 	if err != nil {
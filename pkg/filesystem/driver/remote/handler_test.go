@@ -400,6 +400,14 @@ func TestHandler_Thumb(t *testing.T) {
 		asserts.ErrorIs(err, driver.ErrorThumbNotSupported)
 		asserts.Nil(resp)
 	}
+
+	// ext not support, but thumb generated by slave
+	{
+		file.MetadataSerialized = map[string]string{model.ThumbStatusMetadataKey: model.ThumbStatusExist}
+		resp, err := handler.Thumb(ctx, file)
+		asserts.NoError(err)
+		asserts.True(resp.Redirect)
+	}
 }
 
 func TestHandler_Token(t *testing.T) {
@@ -18,6 +18,7 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -25,6 +26,7 @@ import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/response"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
 	"github.com/cloudreve/Cloudreve/v3/pkg/request"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 )
@@ -68,12 +70,19 @@ func (handler *Driver) InitS3Client() error {
 
 	if handler.svc == nil {
 		// 初始化会话
-		sess, err := session.NewSession(&aws.Config{
+		config := &aws.Config{
 			Credentials:      credentials.NewStaticCredentials(handler.Policy.AccessKey, handler.Policy.SecretKey, ""),
 			Endpoint:         &handler.Policy.Server,
 			Region:           &handler.Policy.OptionsSerialized.Region,
 			S3ForcePathStyle: &handler.Policy.OptionsSerialized.S3ForcePathStyle,
-		})
+		}
+
+		// 用户挂载的外部存储只允许连接公网地址
+		if handler.Policy.IsMounted() {
+			config.HTTPClient = &http.Client{Transport: netpolicy.PublicTransport()}
+		}
+
+		sess, err := session.NewSession(config)
 
 		if err != nil {
 			return err
@@ -257,6 +266,102 @@ func (handler *Driver) Delete(ctx context.Context, files []string) ([]string, er
 
 }
 
+// Archive 原地复制对象以转换存储类型，
+// 返回转换失败的文件，及遇到的最后一个错误
+func (handler *Driver) Archive(ctx context.Context, files []string, storageClass string) ([]string, error) {
+	if storageClass == "" {
+		storageClass = s3.StorageClassGlacier
+	}
+
+	var (
+		failed  = make([]string, 0, len(files))
+		lastErr error
+	)
+	for _, file := range files {
+		_, err := handler.svc.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+			Bucket:            &handler.Policy.BucketName,
+			Key:               aws.String(file),
+			CopySource:        aws.String(strings.ReplaceAll(url.PathEscape(handler.Policy.BucketName+"/"+file), "%2F", "/")),
+			StorageClass:      aws.String(storageClass),
+			MetadataDirective: aws.String(s3.MetadataDirectiveCopy),
+		})
+		if err != nil {
+			failed = append(failed, file)
+			lastErr = err
+		}
+	}
+
+	return failed, lastErr
+}
+
+// Restore 为归档的对象发起解冻请求，已在解冻中的对象视为成功，
+// 返回请求失败的文件，及遇到的最后一个错误
+func (handler *Driver) Restore(ctx context.Context, files []string, days int) ([]string, error) {
+	var (
+		failed  = make([]string, 0, len(files))
+		lastErr error
+	)
+	for _, file := range files {
+		_, err := handler.svc.RestoreObjectWithContext(ctx, &s3.RestoreObjectInput{
+			Bucket: &handler.Policy.BucketName,
+			Key:    aws.String(file),
+			RestoreRequest: &s3.RestoreRequest{
+				Days:                 aws.Int64(int64(days)),
+				GlacierJobParameters: &s3.GlacierJobParameters{Tier: aws.String(s3.TierStandard)},
+			},
+		})
+		if err != nil {
+			var awsErr awserr.Error
+			if errors.As(err, &awsErr) && (awsErr.Code() == "RestoreAlreadyInProgress" ||
+				awsErr.Code() == s3.ErrCodeObjectAlreadyInActiveTierError) {
+				continue
+			}
+			failed = append(failed, file)
+			lastErr = err
+		}
+	}
+
+	return failed, lastErr
+}
+
+// Rehydrate 将已解冻完成的对象原地复制为标准存储类型，
+// 返回已转回标准存储的文件，及遇到的最后一个错误
+func (handler *Driver) Rehydrate(ctx context.Context, files []string) ([]string, error) {
+	var (
+		done    = make([]string, 0, len(files))
+		lastErr error
+	)
+	for _, file := range files {
+		head, err := handler.svc.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+			Bucket: &handler.Policy.BucketName,
+			Key:    aws.String(file),
+		})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		// 未指定存储类型即为标准存储
+		if head.StorageClass == nil || *head.StorageClass == s3.StorageClassStandard {
+			done = append(done, file)
+			continue
+		}
+
+		// 解冻完成后 Restore 头为 ongoing-request="false"
+		if head.Restore == nil || !strings.Contains(*head.Restore, `ongoing-request="false"`) {
+			continue
+		}
+
+		if _, err := handler.Archive(ctx, []string{file}, s3.StorageClassStandard); err != nil {
+			lastErr = err
+			continue
+		}
+		done = append(done, file)
+	}
+
+	return done, lastErr
+}
+
 // Thumb 获取文件缩略图
 func (handler *Driver) Thumb(ctx context.Context, file *model.File) (*response.ContentResponse, error) {
 	return nil, driver.ErrorThumbNotSupported
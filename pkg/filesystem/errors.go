@@ -23,4 +23,20 @@ var (
 	ErrDBListObjects            = serializer.NewError(serializer.CodeDBError, "Failed to list object records", nil)
 	ErrDBDeleteObjects          = serializer.NewError(serializer.CodeDBError, "Failed to delete object records", nil)
 	ErrOneObjectOnly            = serializer.ParamErr("You can only copy one object at the same time", nil)
+	ErrFileQuarantined          = serializer.NewError(serializer.CodeFileQuarantined, "This file has been quarantined for security reasons", nil)
+	ErrFileBlocked              = serializer.NewError(serializer.CodeFileBlocked, "This file has been blocked due to prohibited content", nil)
+	ErrThumbGenerating          = serializer.NewError(serializer.CodeThumbGenerating, "Thumbnail is being generated", nil)
+	ErrThumbQueueFull           = serializer.NewError(serializer.CodeTooManyRequests, "Too many pending thumbnail jobs", nil)
+	ErrTrafficExceeded          = serializer.NewError(serializer.CodeTrafficExceeded, "Monthly traffic quota is exceeded", nil)
+	ErrFolderQuotaExceeded      = serializer.NewError(serializer.CodeFolderQuotaExceeded, "Folder quota is exceeded", nil)
+	ErrIllegalRenameRule        = serializer.NewError(serializer.CodeParamErr, "Invalid rename rule", nil)
+	ErrNotDuplicate             = serializer.NewError(serializer.CodeParamErr, "Files are not verified duplicates", nil)
+	ErrFileLocked               = serializer.NewError(serializer.CodeFileLocked, "File is locked", nil)
+	ErrPreviewDisabled          = serializer.NewError(serializer.CodeGroupNotAllowed, "This preview type is disabled for your group", nil)
+	ErrFileArchived             = serializer.NewError(serializer.CodeFileArchived, "File has been moved to archive storage", nil)
+	ErrArchiveNotSupported      = serializer.NewError(serializer.CodePolicyNotAllowed, "Storage policy does not support archiving", nil)
+	ErrNotArchived              = serializer.NewError(serializer.CodeParamErr, "File is not archived or is already being restored", nil)
+	ErrIllegalFolderTemplate    = serializer.NewError(serializer.CodeParamErr, "Invalid folder template", nil)
+	ErrFileOpaque               = serializer.NewError(serializer.CodeFileOpaque, "File is encrypted in a vault", nil)
+	ErrRetentionHold            = serializer.NewError(serializer.CodeRetentionHold, "Object is under retention hold", nil)
 )
@@ -0,0 +1,105 @@
+package filesystem
+
+import (
+	"context"
+	"sync"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ==================
+	 文件系统事件钩子
+   ==================
+*/
+
+// Operation 可注册事件钩子的文件系统操作
+type Operation string
+
+const (
+	OpUpload   Operation = "upload"
+	OpDelete   Operation = "delete"
+	OpMove     Operation = "move"
+	OpRename   Operation = "rename"
+	OpDownload Operation = "download"
+)
+
+// Event 文件系统操作事件，未涉及的字段留空
+type Event struct {
+	Op      Operation
+	User    *model.User
+	Files   []uint // 操作的文件 ID
+	Folders []uint // 操作的目录 ID
+	Src     string // 移动的源目录
+	Dst     string // 移动的目标目录
+	NewName string // 重命名后的名称
+	// 上传的文件，创建上传会话、完成上传回调时也会触发上传事件，可通过 Info().Mode 区分
+	Upload fsctx.FileHeader
+	// 下载或签发地址的文件，打包下载时为空，打包的对象见 Files、Folders
+	File *model.File
+	// 操作结果，仅在操作后的钩子中有效
+	Err error
+}
+
+// EventHook 事件钩子，操作前的钩子返回错误时中止操作，
+// 操作后的钩子返回的错误只记录日志
+type EventHook func(ctx context.Context, fs *FileSystem, event *Event) error
+
+var (
+	eventHooksLock sync.RWMutex
+	beforeHooks    = make(map[Operation][]EventHook)
+	afterHooks     = make(map[Operation][]EventHook)
+)
+
+// OnBefore 注册在操作执行前触发的全局钩子，用于编译进自定义的命名规范、内容检查等逻辑
+func OnBefore(op Operation, hook EventHook) {
+	eventHooksLock.Lock()
+	defer eventHooksLock.Unlock()
+	beforeHooks[op] = append(beforeHooks[op], hook)
+}
+
+// OnAfter 注册在操作执行后触发的全局钩子，操作失败时同样会触发
+func OnAfter(op Operation, hook EventHook) {
+	eventHooksLock.Lock()
+	defer eventHooksLock.Unlock()
+	afterHooks[op] = append(afterHooks[op], hook)
+}
+
+// ClearEventHooks 清空全局事件钩子
+func ClearEventHooks() {
+	eventHooksLock.Lock()
+	defer eventHooksLock.Unlock()
+	beforeHooks = make(map[Operation][]EventHook)
+	afterHooks = make(map[Operation][]EventHook)
+}
+
+func eventHooks(hooks map[Operation][]EventHook, op Operation) []EventHook {
+	eventHooksLock.RLock()
+	defer eventHooksLock.RUnlock()
+	return hooks[op]
+}
+
+// emitBefore 依次执行操作前的钩子，遇到第一个错误时返回
+func (fs *FileSystem) emitBefore(ctx context.Context, event *Event) error {
+	event.User = fs.User
+	for _, hook := range eventHooks(beforeHooks, event.Op) {
+		if err := hook(ctx, fs, event); err != nil {
+			util.Log().Debug("Before %s hook rejected the operation: %s", event.Op, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// emitAfter 执行操作后的钩子
+func (fs *FileSystem) emitAfter(ctx context.Context, event *Event, err error) {
+	event.User = fs.User
+	event.Err = err
+	for _, hook := range eventHooks(afterHooks, event.Op) {
+		if hookErr := hook(ctx, fs, event); hookErr != nil {
+			util.Log().Warning("Failed to execute after %s hook: %s", event.Op, hookErr)
+		}
+	}
+}
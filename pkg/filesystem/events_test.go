@@ -0,0 +1,107 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSystem_EventHooks(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}, Policy: &model.Policy{}}
+	ctx := context.Background()
+	defer ClearEventHooks()
+
+	// 操作前的钩子中止操作
+	{
+		expectNoRetention()
+		expectNoLocks()
+		rejected := errors.New("rejected")
+		afterCalled := false
+		OnBefore(OpRename, func(ctx context.Context, fs *FileSystem, event *Event) error {
+			asserts.Equal("new.txt", event.NewName)
+			asserts.Equal([]uint{10}, event.Files)
+			asserts.EqualValues(1, event.User.ID)
+			return rejected
+		})
+		OnAfter(OpRename, func(ctx context.Context, fs *FileSystem, event *Event) error {
+			afterCalled = true
+			return nil
+		})
+
+		err := fs.Rename(ctx, []uint{}, []uint{10}, "new.txt")
+		asserts.Equal(rejected, err)
+		asserts.False(afterCalled)
+		asserts.NoError(mock.ExpectationsWereMet())
+		ClearEventHooks()
+	}
+
+	// 操作后的钩子获得操作结果
+	{
+		expectNoRetention()
+		expectNoLocks()
+		var result error
+		OnAfter(OpRename, func(ctx context.Context, fs *FileSystem, event *Event) error {
+			result = event.Err
+			return errors.New("ignored")
+		})
+
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(10, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+		err := fs.Rename(ctx, []uint{}, []uint{10}, "new.txt")
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrPathNotExist, err)
+		asserts.Equal(ErrPathNotExist, result)
+		ClearEventHooks()
+	}
+
+	// 未注册钩子的操作不受影响
+	{
+		expectNoRetention()
+		expectNoLocks()
+		OnBefore(OpDelete, func(ctx context.Context, fs *FileSystem, event *Event) error {
+			return errors.New("rejected")
+		})
+
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(10, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+		err := fs.Rename(ctx, []uint{}, []uint{10}, "new.txt")
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrPathNotExist, err)
+	}
+}
+
+func TestFileSystem_DownloadEvents(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}, Policy: &model.Policy{}}
+	ctx := context.Background()
+	defer ClearEventHooks()
+
+	rejected := errors.New("rejected")
+	var events []*Event
+	OnBefore(OpDownload, func(ctx context.Context, fs *FileSystem, event *Event) error {
+		events = append(events, event)
+		return rejected
+	})
+
+	// 签发地址
+	file := &model.File{Model: gorm.Model{ID: 10}}
+	_, err := fs.SignURL(ctx, file, 0, false)
+	asserts.Equal(rejected, err)
+
+	// 打包下载
+	asserts.Equal(rejected, fs.Compress(ctx, nil, []uint{2}, []uint{3}, true))
+
+	asserts.Len(events, 2)
+	asserts.Equal(file, events[0].File)
+	asserts.Equal([]uint{2}, events[1].Folders)
+	asserts.Equal([]uint{3}, events[1].Files)
+	asserts.NoError(mock.ExpectationsWereMet())
+}
@@ -2,8 +2,13 @@ package filesystem
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"net/http"
+	"strings"
+	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
@@ -30,11 +35,21 @@ func (r lrs) Read(p []byte) (int, error) {
 	return r.r.Read(p)
 }
 
+// speedLimit 返回当前下载的速度限制，取用户组与上下文中额外限制的较小值
+func (fs *FileSystem) speedLimit(ctx context.Context) int {
+	speed := fs.User.Group.SpeedLimit
+	if limit, ok := ctx.Value(fsctx.DownloadLimitCtx).(*fsctx.DownloadLimit); ok && limit.SpeedLimit > 0 {
+		if speed == 0 || limit.SpeedLimit < speed {
+			speed = limit.SpeedLimit
+		}
+	}
+	return speed
+}
+
 // withSpeedLimit 给原有的ReadSeeker加上限速
-func (fs *FileSystem) withSpeedLimit(rs response.RSCloser) response.RSCloser {
-	// 如果用户组有速度限制，就返回限制流速的ReaderSeeker
-	if fs.User.Group.SpeedLimit != 0 {
-		speed := fs.User.Group.SpeedLimit
+func (fs *FileSystem) withSpeedLimit(ctx context.Context, rs response.RSCloser) response.RSCloser {
+	// 如果用户组或下载会话有速度限制，就返回限制流速的ReaderSeeker
+	if speed := fs.speedLimit(ctx); speed != 0 {
 		bucket := ratelimit.NewBucketWithRate(float64(speed), int64(speed))
 		lrs := lrs{rs, ratelimit.Reader(rs, bucket)}
 		return lrs
@@ -44,6 +59,28 @@ func (fs *FileSystem) withSpeedLimit(rs response.RSCloser) response.RSCloser {
 
 }
 
+// LimitWriter 给打包下载等写出流加上当前下载的速度限制
+func (fs *FileSystem) LimitWriter(ctx context.Context, w io.Writer) io.Writer {
+	if speed := fs.speedLimit(ctx); speed != 0 {
+		return ratelimit.Writer(w, ratelimit.NewBucketWithRate(float64(speed), int64(speed)))
+	}
+	return w
+}
+
+// limitedReadCloser 限速后的上传流
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// LimitReadCloser 给上传流加上速度限制，speed 单位为 字节/秒，0 表示不限制
+func LimitReadCloser(rc io.ReadCloser, speed int) io.ReadCloser {
+	if speed <= 0 {
+		return rc
+	}
+	return limitedReadCloser{ratelimit.Reader(rc, ratelimit.NewBucketWithRate(float64(speed), int64(speed))), rc}
+}
+
 // AddFile 新增文件记录
 func (fs *FileSystem) AddFile(ctx context.Context, parent *model.Folder, file fsctx.FileHeader) (*model.File, error) {
 	// 添加文件记录前的钩子
@@ -64,6 +101,14 @@ func (fs *FileSystem) AddFile(ctx context.Context, parent *model.Folder, file fs
 		UploadSessionID:    uploadInfo.UploadSessionID,
 	}
 
+	// 保险库中的文件由客户端加密，标记为不透明
+	if vault, err := model.GetVaultOfFolder(parent); err == nil && vault.ID > 0 {
+		if newFile.MetadataSerialized == nil {
+			newFile.MetadataSerialized = make(map[string]string)
+		}
+		newFile.MetadataSerialized[model.OpaqueMetadataKey] = "1"
+	}
+
 	err = newFile.Create()
 
 	if err != nil {
@@ -89,7 +134,7 @@ func (fs *FileSystem) GetPhysicalFileContent(ctx context.Context, path string) (
 		return nil, err
 	}
 
-	return fs.withSpeedLimit(rs), nil
+	return fs.withSpeedLimit(ctx, rs), nil
 }
 
 // Preview 预览文件
@@ -103,12 +148,26 @@ func (fs *FileSystem) Preview(ctx context.Context, id uint, isText bool) (*respo
 		return nil, err
 	}
 
+	// 保险库中的文件无法在服务端预览
+	if fs.FileTarget[0].IsOpaque() {
+		return nil, ErrFileOpaque
+	}
+
 	// 如果是文本文件预览，需要检查大小限制
 	sizeLimit := model.GetIntSetting("maxEditSize", 2<<20)
 	if isText && fs.FileTarget[0].Size > uint64(sizeLimit) {
 		return nil, ErrFileSizeTooBig
 	}
 
+	// 检查用户组预览限制
+	previewType := PreviewTypeOf(fs.FileTarget[0].Name)
+	if isText {
+		previewType = PreviewText
+	}
+	if err := fs.ValidatePreview(previewType, fs.FileTarget[0].Size); err != nil {
+		return nil, err
+	}
+
 	// 是否直接返回文件内容
 	if isText || fs.Policy.IsDirectlyPreview() {
 		resp, err := fs.GetDownloadContent(ctx, id)
@@ -136,15 +195,25 @@ func (fs *FileSystem) Preview(ctx context.Context, id uint, isText bool) (*respo
 }
 
 // GetDownloadContent 获取用于下载的文件流
-func (fs *FileSystem) GetDownloadContent(ctx context.Context, id uint) (response.RSCloser, error) {
+func (fs *FileSystem) GetDownloadContent(ctx context.Context, id uint) (rs response.RSCloser, err error) {
+	if err := fs.resetFileIDIfNotExist(ctx, id); err != nil {
+		return nil, err
+	}
+
+	event := &Event{Op: OpDownload, Files: []uint{fs.FileTarget[0].ID}, File: &fs.FileTarget[0]}
+	if err := fs.emitBefore(ctx, event); err != nil {
+		return nil, err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
 	// 获取原始文件流
-	rs, err := fs.GetContent(ctx, id)
+	rs, err = fs.GetContent(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	// 返回限速处理后的文件流
-	return fs.withSpeedLimit(rs), nil
+	return fs.withSpeedLimit(ctx, rs), nil
 
 }
 
@@ -154,6 +223,10 @@ func (fs *FileSystem) GetContent(ctx context.Context, id uint) (response.RSClose
 	if err != nil {
 		return nil, err
 	}
+
+	if err := checkRestricted(&fs.FileTarget[0]); err != nil {
+		return nil, err
+	}
 	ctx = context.WithValue(ctx, fsctx.FileModelCtx, fs.FileTarget[0])
 
 	// 获取文件流
@@ -206,6 +279,8 @@ func (fs *FileSystem) deleteGroupedFile(ctx context.Context, files map[uint][]*m
 		for _, upSession := range uploadSessions {
 			if err := fs.Handler.CancelToken(ctx, upSession); err != nil {
 				util.Log().Warning("Failed to cancel upload session for %q: %s", upSession.Name, err)
+			} else if upSession.UploadID != "" {
+				model.DeleteMultipartUploadsBySession(upSession.Key)
 			}
 
 			cache.Deletes([]string{upSession.Key}, UploadSessionCachePrefix)
@@ -241,16 +316,33 @@ func (fs *FileSystem) GroupFileByPolicy(ctx context.Context, files []model.File)
 }
 
 // GetDownloadURL 创建文件下载链接, timeout 为数据库中存储过期时间的字段
-func (fs *FileSystem) GetDownloadURL(ctx context.Context, id uint, timeout string) (string, error) {
-	err := fs.resetFileIDIfNotExist(ctx, id)
+func (fs *FileSystem) GetDownloadURL(ctx context.Context, id uint, timeout string) (source string, err error) {
+	err = fs.resetFileIDIfNotExist(ctx, id)
 	if err != nil {
 		return "", err
 	}
 	fileTarget := &fs.FileTarget[0]
 
+	event := &Event{Op: OpDownload, Files: []uint{fileTarget.ID}, File: fileTarget}
+	if err := fs.emitBefore(ctx, event); err != nil {
+		return "", err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
+	// 检查并计入下载流量，流量按签发的下载地址计算
+	if err := fs.ChargeDownload(fileTarget.Size); err != nil {
+		return "", err
+	}
+	model.RecordFileDownload(fileTarget.ID, fileTarget.Size)
+
 	// 生成下載地址
 	ttl := model.GetIntSetting(timeout, 60)
-	source, err := fs.SignURL(
+	if _, ok := ctx.Value(fsctx.DownloadClientIPCtx).(string); ok {
+		if policyTTL := fileTarget.GetPolicy().OptionsSerialized.DownloadTTL; policyTTL > 0 {
+			ttl = policyTTL
+		}
+	}
+	source, err = fs.signURL(
 		ctx,
 		fileTarget,
 		int64(ttl),
@@ -288,8 +380,40 @@ func (fs *FileSystem) GetSource(ctx context.Context, fileID uint) (string, error
 	return source, nil
 }
 
-// SignURL 签名文件原始 URL
-func (fs *FileSystem) SignURL(ctx context.Context, file *model.File, ttl int64, isDownload bool) (string, error) {
+// checkRestricted 检查文件是否因隔离、命中屏蔽列表或已归档而被禁止访问
+func checkRestricted(file *model.File) error {
+	if file.IsQuarantined() {
+		return ErrFileQuarantined
+	}
+	if file.IsBlocked() {
+		return ErrFileBlocked
+	}
+	if file.IsArchived() {
+		return ErrFileArchived
+	}
+	return nil
+}
+
+// SignURL 签名文件原始 URL，签发的地址可直接获取文件内容，视为下载
+func (fs *FileSystem) SignURL(ctx context.Context, file *model.File, ttl int64, isDownload bool) (source string, err error) {
+	event := &Event{Op: OpDownload, Files: []uint{file.ID}, File: file}
+	if err := fs.emitBefore(ctx, event); err != nil {
+		return "", err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
+	return fs.signURL(ctx, file, ttl, isDownload)
+}
+
+// signURL 签名文件原始 URL，不触发下载事件
+func (fs *FileSystem) signURL(ctx context.Context, file *model.File, ttl int64, isDownload bool) (string, error) {
+	if err := checkRestricted(file); err != nil {
+		return "", err
+	}
+	if !isDownload && file.IsOpaque() {
+		return "", ErrFileOpaque
+	}
+
 	fs.FileTarget = []model.File{*file}
 	ctx = context.WithValue(ctx, fsctx.FileModelCtx, *file)
 
@@ -300,7 +424,7 @@ func (fs *FileSystem) SignURL(ctx context.Context, file *model.File, ttl int64,
 
 	// 签名最终URL
 	// 生成外链地址
-	source, err := fs.Handler.Source(ctx, fs.FileTarget[0].SourceName, ttl, isDownload, fs.User.Group.SpeedLimit)
+	source, err := fs.Handler.Source(ctx, fs.FileTarget[0].SourceName, ttl, isDownload, fs.speedLimit(ctx))
 	if err != nil {
 		return "", serializer.NewError(serializer.CodeNotSet, "Failed to get source link", err)
 	}
@@ -385,3 +509,40 @@ func (fs *FileSystem) Search(ctx context.Context, keywords ...interface{}) ([]se
 
 	return fs.listObjects(ctx, "/", files, nil, nil), nil
 }
+
+// ContentETag 根据文件内容计算 ETag，用于在线编辑时检测并发修改
+func ContentETag(r io.Reader) (string, error) {
+	h := sha1.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`, nil
+}
+
+// MatchETag 判断 If-Match 请求头是否与 etag 匹配，"*" 匹配任意版本
+func MatchETag(ifMatch, etag string) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// FileETag 根据修改时间与大小生成文件的 ETag，与 WebDAV 中的 ETag 保持一致
+func FileETag(modTime time.Time, size uint64) string {
+	return fmt.Sprintf(`"%x%x"`, modTime.UnixNano(), size)
+}
+
+// ServeContent 输出文件内容，并设置 ETag 与缓存相关响应头，以支持断点续传及条件请求
+func ServeContent(w http.ResponseWriter, r *http.Request, name string, modTime time.Time, size uint64, content io.ReadSeeker) {
+	if w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", FileETag(modTime, size))
+	}
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+
+	http.ServeContent(w, r, name, modTime, content)
+}
@@ -3,8 +3,13 @@ package filesystem
 import (
 	"context"
 	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	model "github.com/cloudreve/Cloudreve/v3/models"
@@ -638,6 +643,27 @@ func TestFileSystem_Preview(t *testing.T) {
 		asserts.Equal(ErrFileSizeTooBig, err)
 		asserts.Nil(resp)
 	}
+
+	// 用户组禁用了视频预览
+	{
+		fs := FileSystem{
+			User: &model.User{},
+		}
+		fs.User.Group.OptionsSerialized.PreviewDisabled = []string{PreviewVideo}
+		fs.FileTarget = []model.File{
+			{
+				Name:     "movie.mp4",
+				PolicyID: 1,
+				Policy: model.Policy{
+					Model: gorm.Model{ID: 1},
+					Type:  "local",
+				},
+			},
+		}
+		resp, err := fs.Preview(ctx, 1, false)
+		asserts.Equal(ErrPreviewDisabled, err)
+		asserts.Nil(resp)
+	}
 }
 
 func TestFileSystem_ResetFileIDIfNotExist(t *testing.T) {
@@ -667,3 +693,114 @@ func TestFileSystem_Search(t *testing.T) {
 	asserts.NoError(err)
 	asserts.Len(res, 1)
 }
+
+func TestFileSystem_Quarantined(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	fs := FileSystem{User: &model.User{}}
+	file := model.File{
+		Policy:             model.Policy{Model: gorm.Model{ID: 1}, Type: "local"},
+		MetadataSerialized: map[string]string{model.QuarantineMetadataKey: "1"},
+	}
+
+	// 获取内容
+	fs.FileTarget = []model.File{file}
+	rs, err := fs.GetContent(ctx, 1)
+	asserts.Equal(ErrFileQuarantined, err)
+	asserts.Nil(rs)
+
+	// 签名 URL
+	source, err := fs.SignURL(ctx, &file, 0, true)
+	asserts.Equal(ErrFileQuarantined, err)
+	asserts.Empty(source)
+
+	// 命中屏蔽列表
+	file.MetadataSerialized = map[string]string{model.BlockedMetadataKey: "abc"}
+	source, err = fs.SignURL(ctx, &file, 0, true)
+	asserts.Equal(ErrFileBlocked, err)
+	asserts.Empty(source)
+}
+
+func TestContentETag(t *testing.T) {
+	asserts := assert.New(t)
+
+	etag, err := ContentETag(strings.NewReader("hello"))
+	asserts.NoError(err)
+	asserts.Equal(`"aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"`, etag)
+
+	asserts.True(MatchETag(etag, etag))
+	asserts.True(MatchETag(`"other", W/`+etag, etag))
+	asserts.True(MatchETag("*", etag))
+	asserts.False(MatchETag(`"other"`, etag))
+}
+
+func TestServeContent(t *testing.T) {
+	asserts := assert.New(t)
+	modTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	etag := FileETag(modTime, 10)
+
+	// 断点续传
+	{
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Range", "bytes=5-")
+		ServeContent(w, r, "a.txt", modTime, 10, strings.NewReader("0123456789"))
+		asserts.Equal(http.StatusPartialContent, w.Code)
+		asserts.Equal("56789", w.Body.String())
+		asserts.Equal(etag, w.Header().Get("ETag"))
+		asserts.Equal("private, no-cache", w.Header().Get("Cache-Control"))
+	}
+
+	// 条件请求
+	{
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("If-None-Match", etag)
+		ServeContent(w, r, "a.txt", modTime, 10, strings.NewReader("0123456789"))
+		asserts.Equal(http.StatusNotModified, w.Code)
+	}
+
+	// If-Range 不匹配时返回完整内容
+	{
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Range", "bytes=5-")
+		r.Header.Set("If-Range", `"stale"`)
+		ServeContent(w, r, "a.txt", modTime, 10, strings.NewReader("0123456789"))
+		asserts.Equal(http.StatusOK, w.Code)
+		asserts.Equal("0123456789", w.Body.String())
+	}
+}
+
+func TestLimitReadCloser(t *testing.T) {
+	asserts := assert.New(t)
+	body := ioutil.NopCloser(strings.NewReader("content"))
+
+	// 不限速
+	asserts.Equal(body, LimitReadCloser(body, 0))
+
+	// 限速
+	limited := LimitReadCloser(body, 1024)
+	asserts.NotEqual(body, limited)
+	content, err := ioutil.ReadAll(limited)
+	asserts.NoError(err)
+	asserts.Equal("content", string(content))
+	asserts.NoError(limited.Close())
+}
+
+func TestFileSystem_LimitWriter(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	buf := &strings.Builder{}
+
+	// 不限速
+	asserts.Equal(buf, fs.LimitWriter(context.Background(), buf))
+
+	// 用户组限速
+	fs.User.Group.SpeedLimit = 1024
+	w := fs.LimitWriter(context.Background(), buf)
+	asserts.NotEqual(buf, w)
+	_, err := w.Write([]byte("content"))
+	asserts.NoError(err)
+	asserts.Equal("content", buf.String())
+}
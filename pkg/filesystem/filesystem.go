@@ -61,6 +61,8 @@ type FileSystem struct {
 
 	// 回收锁
 	recycleLock sync.Mutex
+	// 当前所在挂载点的虚拟路径，未使用挂载策略时为空
+	mountRoot string
 }
 
 // getEmptyFS 从pool中获取新的FileSystem
@@ -84,6 +86,7 @@ func (fs *FileSystem) reset() {
 	fs.Hooks = nil
 	fs.Handler = nil
 	fs.Root = nil
+	fs.mountRoot = ""
 	fs.Lock = sync.Mutex{}
 	fs.recycleLock = sync.Mutex{}
 }
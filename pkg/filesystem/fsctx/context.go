@@ -1,5 +1,7 @@
 package fsctx
 
+import "context"
+
 type key int
 
 const (
@@ -41,4 +43,26 @@ const (
 	WebDAVCtx
 	// WebDAV反代Url
 	WebDAVProxyUrlCtx
+	// DownloadLimitCtx 下载会话的额外限制
+	DownloadLimitCtx
+	// ConflictModeCtx 上传目标重名时的处理方式
+	ConflictModeCtx
+	// ProgressFuncCtx 长任务的进度回调
+	ProgressFuncCtx
+	// ArchiveEncodingCtx 压缩文件中文件名的编码
+	ArchiveEncodingCtx
+	// DownloadClientIPCtx 请求下载地址的客户端 IP，设定后下载地址按存储策略设置限制使用者
+	DownloadClientIPCtx
+	// LockTokenCtx 修改被锁定的文件时客户端提供的锁令牌
+	LockTokenCtx
 )
+
+// ProgressFunc 进度回调，current 为刚处理完成的文件路径，size 为其大小
+type ProgressFunc func(current string, size uint64)
+
+// ReportProgress 调用上下文中的进度回调
+func ReportProgress(ctx context.Context, current string, size uint64) {
+	if report, ok := ctx.Value(ProgressFuncCtx).(ProgressFunc); ok {
+		report(current, size)
+	}
+}
@@ -0,0 +1,17 @@
+package fsctx
+
+import "encoding/gob"
+
+func init() {
+	gob.Register(DownloadLimit{})
+}
+
+// DownloadLimit 下载会话的额外限制，目前由分享设定
+type DownloadLimit struct {
+	// ShareID 来源分享
+	ShareID uint
+	// SpeedLimit 最大下载速度，单位为 字节/秒，0 表示不限制
+	SpeedLimit int
+	// MaxConcurrent 最大同时下载数，0 表示不限制，仅对经由服务端中转的下载生效
+	MaxConcurrent int
+}
@@ -0,0 +1,62 @@
+package filesystem
+
+import (
+	"context"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// RejectGuestUploads 拒绝并删除当前用户等待审核的访客上传。删除失败的文件重新暂存，
+// 归还已计入的容量，由定时任务稍后重试
+func (fs *FileSystem) RejectGuestUploads(ctx context.Context, records []model.GuestUpload) error {
+	var lastErr error
+	for i := range records {
+		record := &records[i]
+		if err := record.Reject(); err != nil {
+			lastErr = err
+			continue
+		}
+
+		if err := fs.Delete(ctx, []uint{}, []uint{record.FileID}, true, false); err != nil {
+			util.Log().Warning("Failed to delete rejected guest upload %d: %s", record.FileID, err)
+			if err := record.Reopen(); err != nil {
+				util.Log().Warning("Failed to reopen guest upload %d: %s", record.ID, err)
+			}
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// PurgeGuestUploads 删除等待审核的访客上传，按分享者分组处理
+func PurgeGuestUploads(ctx context.Context, records []model.GuestUpload) error {
+	ownerToRecords := make(map[uint][]model.GuestUpload)
+	for _, record := range records {
+		ownerToRecords[record.OwnerID] = append(ownerToRecords[record.OwnerID], record)
+	}
+
+	var lastErr error
+	for uid, owned := range ownerToRecords {
+		user, err := model.GetUserByID(uid)
+		if err != nil {
+			util.Log().Warning("Owner of guest uploads cannot be found: %s", err)
+			lastErr = err
+			continue
+		}
+
+		fs, err := NewFileSystem(&user)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if err := fs.RejectGuestUploads(ctx, owned); err != nil {
+			lastErr = err
+		}
+		fs.Recycle()
+	}
+
+	return lastErr
+}
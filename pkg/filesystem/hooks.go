@@ -11,6 +11,7 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"io/ioutil"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -110,6 +111,42 @@ func HookValidateCapacityDiff(ctx context.Context, fs *FileSystem, newFile fsctx
 	return nil
 }
 
+// HookValidateFolderQuota 验证上传目标目录及其上级目录的容量上限，目标目录不存在时从最近的已存在上级目录开始验证
+func HookValidateFolderQuota(ctx context.Context, fs *FileSystem, file fsctx.FileHeader) error {
+	fileInfo := file.Info()
+	if fileInfo.Size == 0 || !model.HasFolderQuota(fs.User.ID) {
+		return nil
+	}
+
+	dir := fileInfo.VirtualPath
+	for {
+		if exist, folder := fs.IsPathExist(dir); exist {
+			return fs.ValidateFolderQuota(ctx, folder, fileInfo.Size)
+		}
+
+		if dir == "/" || dir == "" || dir == "." {
+			return nil
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// HookValidateFolderQuotaDiff 根据原有文件和新文件的大小验证所在目录的容量上限
+func HookValidateFolderQuotaDiff(ctx context.Context, fs *FileSystem, newFile fsctx.FileHeader) error {
+	originFile := ctx.Value(fsctx.FileModelCtx).(model.File)
+	newFileSize := newFile.Info().Size
+	if newFileSize <= originFile.Size || !model.HasFolderQuota(fs.User.ID) {
+		return nil
+	}
+
+	folders, err := model.GetFoldersByIDs([]uint{originFile.FolderID}, fs.User.ID)
+	if err != nil || len(folders) == 0 {
+		return nil
+	}
+
+	return fs.ValidateFolderQuota(ctx, &folders[0], newFileSize-originFile.Size)
+}
+
 // HookDeleteTempFile 删除已保存的临时文件
 func HookDeleteTempFile(ctx context.Context, fs *FileSystem, file fsctx.FileHeader) error {
 	// 删除临时文件
@@ -169,6 +206,15 @@ func GenericAfterUpdate(ctx context.Context, fs *FileSystem, newFile fsctx.FileH
 
 	newFile.SetModel(&originFile)
 
+	// 替换旧内容的哈希，随文件大小一同保存
+	sums := newFile.Info().Metadata
+	if sums[model.SHA256MetadataKey] != "" || originFile.MetadataSerialized[model.SHA256MetadataKey] != "" ||
+		originFile.MetadataSerialized[model.MD5MetadataKey] != "" {
+		if err := originFile.SetChecksums(sums); err != nil {
+			return err
+		}
+	}
+
 	err := originFile.UpdateSize(newFile.Info().Size)
 	if err != nil {
 		return err
@@ -177,8 +223,8 @@ func GenericAfterUpdate(ctx context.Context, fs *FileSystem, newFile fsctx.FileH
 	return nil
 }
 
-// SlaveAfterUpload Slave模式下上传完成钩子
-func SlaveAfterUpload(session *serializer.UploadSession) Hook {
+// SlaveAfterUpload Slave模式下上传完成钩子，checksum 不为空时随回调发送最后一个分片计算后的文件哈希
+func SlaveAfterUpload(session *serializer.UploadSession, checksum *Checksum) Hook {
 	return func(ctx context.Context, fs *FileSystem, fileHeader fsctx.FileHeader) error {
 		if session.Callback == "" {
 			return nil
@@ -186,6 +232,11 @@ func SlaveAfterUpload(session *serializer.UploadSession) Hook {
 
 		// 发送回调请求
 		callbackBody := serializer.UploadCallback{}
+		if checksum != nil && checksum.Size == fileHeader.Info().Size {
+			sums := checksum.Sums()
+			callbackBody.MD5 = sums[model.MD5MetadataKey]
+			callbackBody.SHA256 = sums[model.SHA256MetadataKey]
+		}
 		return cluster.RemoteCallback(session.Callback, callbackBody)
 	}
 }
@@ -503,7 +503,7 @@ func TestSlaveAfterUpload(t *testing.T) {
 			Name:        "test.txt",
 			SavePath:    "/not_exist",
 		}
-		err := SlaveAfterUpload(&serializer.UploadSession{Callback: "http://test/callbakc"})(context.Background(), fs, file)
+		err := SlaveAfterUpload(&serializer.UploadSession{Callback: "http://test/callbakc"}, nil)(context.Background(), fs, file)
 		clientMock.AssertExpectations(t)
 		asserts.NoError(err)
 	}
@@ -516,7 +516,7 @@ func TestSlaveAfterUpload(t *testing.T) {
 			Name:        "test.txt",
 			SavePath:    "/not_exist",
 		}
-		err := SlaveAfterUpload(&serializer.UploadSession{})(context.Background(), fs, file)
+		err := SlaveAfterUpload(&serializer.UploadSession{}, nil)(context.Background(), fs, file)
 		asserts.NoError(err)
 	}
 }
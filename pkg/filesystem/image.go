@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
 	"sync"
 
 	"runtime"
@@ -42,6 +44,10 @@ func (fs *FileSystem) GetThumb(ctx context.Context, id uint) (*response.ContentR
 	res, err := fs.Handler.Thumb(ctx, &file)
 	if errors.Is(err, driver.ErrorThumbNotExist) {
 		// Regenerate thumb if the thumb is not initialized yet
+		if thumbQueueEnabled() {
+			return nil, fs.enqueueThumb(&file)
+		}
+
 		if generateErr := fs.generateThumbnail(ctx, &file); generateErr == nil {
 			res, err = fs.Handler.Thumb(ctx, &file)
 		} else {
@@ -60,6 +66,10 @@ func (fs *FileSystem) GetThumb(ctx context.Context, id uint) (*response.ContentR
 				res.URL, err = fs.Handler.Source(ctx, file.ThumbFile(), int64(model.GetIntSetting("preview_timeout", 60)), false, 0)
 			} else {
 				// if not exist, generate and upload the sidecar thumb.
+				if thumbQueueEnabled() {
+					return nil, fs.enqueueThumb(&file)
+				}
+
 				if err = fs.generateThumbnail(ctx, &file); err == nil {
 					return fs.GetThumb(ctx, id)
 				}
@@ -72,6 +82,7 @@ func (fs *FileSystem) GetThumb(ctx context.Context, id uint) (*response.ContentR
 
 	if err == nil && conf.SystemConfig.Mode == "master" {
 		res.MaxAge = model.GetIntSetting("preview_timeout", 60)
+		touchThumb(&file)
 	}
 
 	return res, err
@@ -117,7 +128,26 @@ func (fs *FileSystem) generateThumbnail(ctx context.Context, file *model.File) e
 	defer cancel()
 	// TODO: check file size
 
-	if file.Size > uint64(model.GetIntSetting("thumb_max_src_size", 31457280)) {
+	// Provide file source path for local policy files
+	src := ""
+	if conf.SystemConfig.Mode == "slave" || file.GetPolicy().Type == "local" {
+		src = file.SourceName
+	}
+
+	options := fs.thumbOptions(file)
+
+	// 视频文件交由 ffmpeg 按需读取，不受原始文件大小限制
+	isVideo := thumb.IsVideo(file.Name)
+	if (isVideo && src == "") || thumb.NeedSourceURL(file.Name) {
+		sourceURL, err := fs.Handler.Source(ctx, file.SourceName, int64(model.GetIntSetting("preview_timeout", 60)), false, 0)
+		if parsed, parseErr := url.Parse(sourceURL); err == nil && parseErr == nil {
+			options[thumb.SourceURLOption] = model.GetSiteURL().ResolveReference(parsed).String()
+		} else {
+			isVideo = false
+		}
+	}
+
+	if !isVideo && file.Size > uint64(model.GetIntSetting("thumb_max_src_size", 31457280)) {
 		_ = updateThumbStatus(file, model.ThumbStatusNotAvailable)
 		return errors.New("file too large")
 	}
@@ -132,20 +162,7 @@ func (fs *FileSystem) generateThumbnail(ctx context.Context, file *model.File) e
 	}
 	defer source.Close()
 
-	// Provide file source path for local policy files
-	src := ""
-	if conf.SystemConfig.Mode == "slave" || file.GetPolicy().Type == "local" {
-		src = file.SourceName
-	}
-
-	thumbRes, err := thumb.Generators.Generate(ctx, source, src, file.Name, model.GetSettingByNames(
-		"thumb_width",
-		"thumb_height",
-		"thumb_builtin_enabled",
-		"thumb_vips_enabled",
-		"thumb_ffmpeg_enabled",
-		"thumb_libreoffice_enabled",
-	))
+	thumbRes, err := thumb.Generators.Generate(ctx, source, src, file.Name, options)
 	if err != nil {
 		_ = updateThumbStatus(file, model.ThumbStatusNotAvailable)
 		return fmt.Errorf("failed to generate thumb for %q: %w", file.Name, err)
@@ -185,11 +202,49 @@ func (fs *FileSystem) generateThumbnail(ctx context.Context, file *model.File) e
 	// 失败时删除缩略图文件
 	if err != nil {
 		_, _ = fs.Handler.Delete(newCtx, []string{file.SourceName + model.GetSettingByNameWithDefault("thumb_file_suffix", "._thumb")})
+		return nil
+	}
+
+	// 记录缩略图缓存占用
+	if conf.SystemConfig.Mode == "master" && file.ID > 0 {
+		if err := model.SaveThumbRecord(file.ID, file.PolicyID, uint64(fileInfo.Size())); err != nil {
+			util.Log().Debug("Failed to save thumbnail record of %q: %s", file.Name, err)
+		}
 	}
 
 	return nil
 }
 
+// thumbOptions 返回生成缩略图使用的设置，存储策略限定了可用的生成器时，其余生成器将被禁用
+func (fs *FileSystem) thumbOptions(file *model.File) map[string]string {
+	options := model.GetSettingByNames(
+		"thumb_width",
+		"thumb_height",
+		"thumb_external_enabled",
+		"thumb_builtin_enabled",
+		"thumb_vips_enabled",
+		"thumb_ffmpeg_enabled",
+		"thumb_libreoffice_enabled",
+		"thumb_magick_enabled",
+		"thumb_pdf_enabled",
+	)
+
+	if conf.SystemConfig.Mode != "master" {
+		return options
+	}
+
+	if generators := file.GetPolicy().OptionsSerialized.ThumbGenerators; len(generators) > 0 {
+		for key := range options {
+			name := strings.TrimSuffix(strings.TrimPrefix(key, "thumb_"), "_enabled")
+			if name != key && strings.HasSuffix(key, "_enabled") && !util.ContainsString(generators, name) {
+				options[key] = "0"
+			}
+		}
+	}
+
+	return options
+}
+
 // GenerateThumbnailSize 获取要生成的缩略图的尺寸
 func (fs *FileSystem) GenerateThumbnailSize(w, h int) (uint, uint) {
 	return uint(model.GetIntSetting("thumb_width", 400)), uint(model.GetIntSetting("thumb_height", 300))
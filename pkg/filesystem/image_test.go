@@ -125,3 +125,28 @@ func TestFileSystem_ThumbWorker(t *testing.T) {
 		getThumbWorker().releaseWorker()
 	})
 }
+
+func TestFileSystem_ThumbOptions(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	cache.Set("setting_thumb_vips_enabled", "1", 0)
+	cache.Set("setting_thumb_builtin_enabled", "1", 0)
+	file := &model.File{Policy: model.Policy{OptionsSerialized: model.PolicyOption{ThumbGenerators: []string{"builtin"}}}}
+	file.Policy.ID = 1
+
+	options := fs.thumbOptions(file)
+	a.Equal("1", options["thumb_builtin_enabled"])
+	a.Equal("0", options["thumb_vips_enabled"])
+	a.NotEqual("0", options["thumb_width"])
+}
+
+func TestFileSystem_EnqueueThumb(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	file := &model.File{}
+	file.ID = 3
+
+	thumbPending.Store(file.ID, true)
+	defer thumbPending.Delete(file.ID)
+	a.ErrorIs(fs.enqueueThumb(file), ErrThumbGenerating)
+}
@@ -0,0 +1,241 @@
+package filesystem
+
+import (
+	"context"
+	"path"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// lifecycleBatch 执行生命周期规则时每批处理的文件数
+const lifecycleBatch = 100
+
+// policyArchiver 返回存储策略的归档适配器，使用完毕后需回收返回的文件系统
+func policyArchiver(policy *model.Policy) (*FileSystem, driver.Archiver, error) {
+	fs, err := NewAnonymousFileSystem()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fs.Policy = policy
+	if err := fs.DispatchHandler(); err != nil {
+		fs.Recycle()
+		return nil, nil, err
+	}
+
+	archiver, ok := fs.Handler.(driver.Archiver)
+	if !ok {
+		fs.Recycle()
+		return nil, nil, ErrArchiveNotSupported
+	}
+
+	return fs, archiver, nil
+}
+
+// ArchivePolicyFiles 将存储策略下 before 之前上传的文件转为归档存储，返回转换的文件数
+func ArchivePolicyFiles(ctx context.Context, policy *model.Policy, before time.Time) (int, error) {
+	fs, archiver, err := policyArchiver(policy)
+	if err != nil {
+		return 0, err
+	}
+	defer fs.Recycle()
+
+	archived := 0
+	var after uint
+	for {
+		files, err := model.GetPolicyFilesCreatedBefore(policy.ID, before, after, lifecycleBatch)
+		if err != nil || len(files) == 0 {
+			return archived, err
+		}
+		after = files[len(files)-1].ID
+
+		// 软链接的文件共用同一源文件，只需转换一次；用户主动解冻过的文件不再自动归档
+		sources := make([]string, 0, len(files))
+		seen := make(map[string]bool, len(files))
+		for _, file := range files {
+			if !file.IsArchived() && !model.IsTrueVal(file.MetadataSerialized[model.RehydratedMetadataKey]) && !seen[file.SourceName] {
+				seen[file.SourceName] = true
+				sources = append(sources, file.SourceName)
+			}
+		}
+		if len(sources) == 0 {
+			continue
+		}
+
+		failed, err := archiver.Archive(ctx, sources, policy.OptionsSerialized.ArchiveStorageClass)
+		if err != nil {
+			util.Log().Warning("Failed to archive %d file(s) in policy %q: %s", len(failed), policy.Name, err)
+		}
+
+		succeed := util.SliceDifference(sources, failed)
+		if len(succeed) == 0 {
+			continue
+		}
+
+		// 标记使用已转换源文件的所有文件
+		linked, err := model.GetFilesBySourceNames(policy.ID, succeed)
+		if err != nil {
+			return archived, err
+		}
+
+		for i := range linked {
+			if err := linked[i].UpdateMetadata(map[string]string{model.ArchivedMetadataKey: "1"}); err != nil {
+				util.Log().Warning("Failed to mark file %d as archived: %s", linked[i].ID, err)
+			}
+		}
+		archived += len(succeed)
+	}
+}
+
+// uniqueSources 返回文件使用的源文件，软链接的文件共用同一源文件
+func uniqueSources(files []model.File) []string {
+	sources := make([]string, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		if !seen[file.SourceName] {
+			seen[file.SourceName] = true
+			sources = append(sources, file.SourceName)
+		}
+	}
+	return sources
+}
+
+// RestoreArchivedFiles 为用户的归档文件发起解冻请求，并将使用相同源文件的文件标记为解冻中，
+// 返回发起解冻的源文件数。解冻完成后由定时任务调用 RehydrateFiles 转回标准存储
+func (fs *FileSystem) RestoreArchivedFiles(ctx context.Context, ids []uint) (int, error) {
+	files, err := model.GetFilesByIDs(ids, fs.User.ID)
+	if err != nil {
+		return 0, ErrDBListObjects.WithError(err)
+	}
+
+	policyFiles := make(map[uint][]model.File)
+	for _, file := range files {
+		if file.IsArchived() && !file.IsRehydrating() {
+			policyFiles[file.PolicyID] = append(policyFiles[file.PolicyID], file)
+		}
+	}
+	if len(policyFiles) == 0 {
+		return 0, ErrNotArchived
+	}
+
+	days := model.GetIntSetting("archive_restore_days", 1)
+	restored := 0
+	for _, group := range policyFiles {
+		policy := group[0].GetPolicy()
+		archiverFs, archiver, err := policyArchiver(policy)
+		if err != nil {
+			return restored, err
+		}
+
+		sources := uniqueSources(group)
+		failed, err := archiver.Restore(ctx, sources, days)
+		archiverFs.Recycle()
+		if err != nil {
+			util.Log().Warning("Failed to restore %d archived file(s) in policy %q: %s", len(failed), policy.Name, err)
+		}
+
+		succeed := util.SliceDifference(sources, failed)
+		if len(succeed) == 0 {
+			return restored, ErrIO.WithError(err)
+		}
+
+		linked, err := model.GetFilesBySourceNames(policy.ID, succeed)
+		if err != nil {
+			return restored, ErrDBListObjects.WithError(err)
+		}
+		for i := range linked {
+			if err := linked[i].UpdateMetadata(map[string]string{model.RehydratingMetadataKey: "1"}); err != nil {
+				util.Log().Warning("Failed to mark file %d as rehydrating: %s", linked[i].ID, err)
+			}
+		}
+		restored += len(succeed)
+	}
+
+	return restored, nil
+}
+
+// RehydrateFiles 将存储策略下已解冻完成的文件转回标准存储类型，并清除归档标记，返回转换的源文件数
+func RehydrateFiles(ctx context.Context, policy *model.Policy, files []model.File) (int, error) {
+	fs, archiver, err := policyArchiver(policy)
+	if err != nil {
+		return 0, err
+	}
+	defer fs.Recycle()
+
+	done, err := archiver.Rehydrate(ctx, uniqueSources(files))
+	if err != nil {
+		util.Log().Warning("Failed to rehydrate archived files in policy %q: %s", policy.Name, err)
+	}
+	if len(done) == 0 {
+		return 0, nil
+	}
+
+	linked, err := model.GetFilesBySourceNames(policy.ID, done)
+	if err != nil {
+		return 0, ErrDBListObjects.WithError(err)
+	}
+	for i := range linked {
+		if err := linked[i].MarkRehydrated(); err != nil {
+			util.Log().Warning("Failed to clear archived mark of file %d: %s", linked[i].ID, err)
+		}
+	}
+
+	return len(done), nil
+}
+
+// ExpireFiles 删除存储策略的过期目录中 expireBefore 之前上传的文件，
+// 返回删除的文件数，以及 warnBefore 之前上传、即将到期且尚未提醒过的文件
+func (fs *FileSystem) ExpireFiles(ctx context.Context, policy *model.Policy, expireBefore, warnBefore time.Time) (int, []model.File, error) {
+	dirs := make([]uint, 0, len(policy.OptionsSerialized.ExpiryFolders))
+	for _, p := range policy.OptionsSerialized.ExpiryFolders {
+		if exist, folder := fs.IsPathExist(path.Join("/", p)); exist {
+			dirs = append(dirs, folder.ID)
+		}
+	}
+	if len(dirs) == 0 {
+		return 0, nil, nil
+	}
+
+	folders, err := model.GetRecursiveChildFolder(dirs, fs.User.ID, true)
+	if err != nil {
+		return 0, nil, ErrDBListObjects.WithError(err)
+	}
+
+	folderIDs := make([]uint, 0, len(folders))
+	for _, folder := range folders {
+		folderIDs = append(folderIDs, folder.ID)
+	}
+
+	files, err := model.GetFolderFilesCreatedBefore(folderIDs, policy.ID, warnBefore)
+	if err != nil {
+		return 0, nil, ErrDBListObjects.WithError(err)
+	}
+
+	var (
+		expired  []uint
+		expiring []model.File
+	)
+	for _, file := range files {
+		if file.CreatedAt.Before(expireBefore) {
+			expired = append(expired, file.ID)
+		} else if !model.IsTrueVal(file.MetadataSerialized[model.ExpiryWarnedMetadataKey]) {
+			expiring = append(expiring, file)
+		}
+	}
+
+	// 逐个删除，处于保留冻结或被锁定的文件不应阻止删除其他过期文件
+	deleted := 0
+	for _, id := range expired {
+		fs.CleanTargets()
+		if err := fs.Delete(ctx, []uint{}, []uint{id}, true, false); err != nil {
+			util.Log().Warning("Failed to delete expired file %d: %s", id, err)
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, expiring, nil
+}
@@ -0,0 +1,111 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchivePolicyFiles(t *testing.T) {
+	asserts := assert.New(t)
+	// 存储策略不支持归档
+	{
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		policy := &model.Policy{Type: "local"}
+		_, err := ArchivePolicyFiles(context.Background(), policy, time.Now())
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrArchiveNotSupported, err)
+	}
+}
+
+func TestFileSystem_RestoreArchivedFiles(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	fs.User.ID = 1
+
+	// 文件未归档或已在解冻中
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).
+			AddRow(2, "{}").
+			AddRow(3, `{"archived":"1","rehydrating":"1"}`))
+		_, err := fs.RestoreArchivedFiles(context.Background(), []uint{2, 3})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrNotArchived, err)
+	}
+
+	// 存储策略不支持归档
+	{
+		cache.Set("policy_4", model.Policy{Type: "local"}, 0)
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "policy_id", "metadata"}).
+			AddRow(2, 4, `{"archived":"1"}`))
+		mock.ExpectQuery("SELECT(.+)groups(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		_, err := fs.RestoreArchivedFiles(context.Background(), []uint{2})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrArchiveNotSupported, err)
+	}
+}
+
+func TestFileSystem_ExpireFiles(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	fs.User.ID = 1
+	policy := &model.Policy{}
+	policy.ID = 2
+	policy.OptionsSerialized.ExpiryFolders = []string{"temp"}
+	now := time.Now()
+
+	// 过期目录不存在
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}))
+		deleted, expiring, err := fs.ExpireFiles(context.Background(), policy, now, now)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Zero(deleted)
+		asserts.Empty(expiring)
+	}
+
+	// 仅有即将到期的文件
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(1, 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "name"}).AddRow(3, 1, "temp"))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(3, 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "metadata"}).
+			AddRow(4, now.Add(-time.Hour), "{}").
+			AddRow(5, now.Add(-time.Hour), `{"expiry_warned":"1"}`))
+		deleted, expiring, err := fs.ExpireFiles(context.Background(), policy, now.Add(-24*time.Hour), now)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Zero(deleted)
+		asserts.Len(expiring, 1)
+		asserts.EqualValues(4, expiring[0].ID)
+	}
+
+	// 过期文件处于保留冻结中，不影响其他过期文件的删除
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(1, 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "name"}).AddRow(3, 1, "temp"))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(3, 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "metadata"}).
+			AddRow(4, now.Add(-48*time.Hour), "{}").
+			AddRow(5, now.Add(-48*time.Hour), "{}"))
+		for _, id := range []int{4, 5} {
+			mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "object_id", "is_dir", "user_id"}).AddRow(1, id, false, 1))
+			expectNoLocks()
+			mock.ExpectQuery("SELECT(.+)files(.+)").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(id, "report.pdf", 3))
+		}
+		deleted, expiring, err := fs.ExpireFiles(context.Background(), policy, now.Add(-24*time.Hour), now)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Zero(deleted)
+		asserts.Empty(expiring)
+	}
+}
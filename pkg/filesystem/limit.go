@@ -0,0 +1,38 @@
+package filesystem
+
+import (
+	"sync"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+)
+
+// downloadSlots 各分享正在进行中的中转下载数
+var downloadSlots = struct {
+	sync.Mutex
+	active map[uint]int
+}{active: make(map[uint]int)}
+
+// AcquireDownloadSlot 为受限的下载会话占用一个并发名额，成功时返回释放名额的函数
+func AcquireDownloadSlot(limit *fsctx.DownloadLimit) (func(), bool) {
+	if limit == nil || limit.MaxConcurrent <= 0 {
+		return func() {}, true
+	}
+
+	downloadSlots.Lock()
+	defer downloadSlots.Unlock()
+	if downloadSlots.active[limit.ShareID] >= limit.MaxConcurrent {
+		return nil, false
+	}
+	downloadSlots.active[limit.ShareID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			downloadSlots.Lock()
+			defer downloadSlots.Unlock()
+			if downloadSlots.active[limit.ShareID]--; downloadSlots.active[limit.ShareID] <= 0 {
+				delete(downloadSlots.active, limit.ShareID)
+			}
+		})
+	}, true
+}
@@ -0,0 +1,54 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireDownloadSlot(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 无限制
+	{
+		release, ok := AcquireDownloadSlot(nil)
+		asserts.True(ok)
+		release()
+	}
+
+	// 超出并发数
+	{
+		limit := &fsctx.DownloadLimit{ShareID: 1, MaxConcurrent: 1}
+		release, ok := AcquireDownloadSlot(limit)
+		asserts.True(ok)
+		_, ok = AcquireDownloadSlot(limit)
+		asserts.False(ok)
+
+		// 重复释放不影响计数
+		release()
+		release()
+		release, ok = AcquireDownloadSlot(limit)
+		asserts.True(ok)
+		release()
+		asserts.Empty(downloadSlots.active)
+	}
+}
+
+func TestFileSystem_speedLimit(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	ctx := context.Background()
+
+	asserts.Equal(0, fs.speedLimit(ctx))
+
+	fs.User.Group.SpeedLimit = 100
+	asserts.Equal(100, fs.speedLimit(ctx))
+	asserts.Equal(50, fs.speedLimit(context.WithValue(ctx, fsctx.DownloadLimitCtx, &fsctx.DownloadLimit{SpeedLimit: 50})))
+	asserts.Equal(100, fs.speedLimit(context.WithValue(ctx, fsctx.DownloadLimitCtx, &fsctx.DownloadLimit{SpeedLimit: 200})))
+
+	fs.User.Group.SpeedLimit = 0
+	asserts.Equal(200, fs.speedLimit(context.WithValue(ctx, fsctx.DownloadLimitCtx, &fsctx.DownloadLimit{SpeedLimit: 200})))
+}
@@ -0,0 +1,223 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/jinzhu/gorm"
+)
+
+/* ================
+	 文件锁
+   ================
+*/
+
+// LockTokenHeader 修改被锁定的文件时携带锁令牌的请求头
+const LockTokenHeader = "X-Cr-Lock-Token"
+
+// LockTTL 校验客户端指定的锁有效期（秒），未指定时使用站点默认值，超出上限时截断
+func LockTTL(ttl int) int {
+	if ttl <= 0 {
+		ttl = model.GetIntSetting("file_lock_ttl", 3600)
+	}
+	if max := model.GetIntSetting("file_lock_max_ttl", 86400); max > 0 && ttl > max {
+		ttl = max
+	}
+	return ttl
+}
+
+// LockFile 为文件加锁。文件已被锁定时，提供相同令牌可刷新锁的有效期，否则返回当前的锁及 ErrFileLocked
+func (fs *FileSystem) LockFile(file *model.File, token string, ttl int, note string) (*model.FileLock, error) {
+	expires := time.Now().Add(time.Duration(LockTTL(ttl)) * time.Second)
+
+	lock, err := model.GetFileLock(file.ID)
+	if err == nil {
+		if !lock.Expired() {
+			if token == "" || lock.Token != token {
+				return lock, lockedError(lock)
+			}
+
+			if err := lock.Refresh(expires, note); err != nil {
+				return nil, err
+			}
+			return lock, nil
+		}
+
+		// 清理过期的锁
+		if err := model.DeleteFileLocks([]uint{file.ID}); err != nil {
+			return nil, ErrDBDeleteObjects.WithError(err)
+		}
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return nil, ErrDBListObjects.WithError(err)
+	}
+
+	lock = &model.FileLock{
+		FileID:    file.ID,
+		UserID:    fs.User.ID,
+		Owner:     fs.User.Nick,
+		Token:     util.RandStringRunes(32),
+		ExpiresAt: expires,
+		Note:      note,
+	}
+	if err := lock.Create(); err != nil {
+		// 并发加锁时唯一索引冲突
+		return nil, ErrFileLocked.WithError(err)
+	}
+
+	return lock, nil
+}
+
+// UnlockFile 解除文件锁，force 为 false 时需提供与锁一致的令牌
+func (fs *FileSystem) UnlockFile(file *model.File, token string, force bool) error {
+	lock, err := model.GetFileLock(file.ID)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil
+		}
+		return ErrDBListObjects.WithError(err)
+	}
+
+	if !force && !lock.Expired() && lock.Token != token {
+		return lockedError(lock)
+	}
+
+	if err := model.DeleteFileLocks([]uint{file.ID}); err != nil {
+		return ErrDBDeleteObjects.WithError(err)
+	}
+	return nil
+}
+
+// CheckFileLocks 检查文件是否可被修改，任一文件被锁定且令牌不匹配时返回 ErrFileLocked
+func CheckFileLocks(fileIDs []uint, token string) error {
+	locks, err := model.GetActiveFileLocks(fileIDs)
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+
+	for i := range locks {
+		if token == "" || locks[i].Token != token {
+			return lockedError(&locks[i])
+		}
+	}
+	return nil
+}
+
+// lockSet 当前请求无法修改的文件锁，键为文件 ID
+type lockSet map[uint]*model.FileLock
+
+// loadLocks 读取当前用户有效的文件锁，忽略与上下文中锁令牌一致的锁，没有锁时返回 nil
+func (fs *FileSystem) loadLocks(ctx context.Context) (lockSet, error) {
+	locks, err := model.GetActiveFileLocksByUID(fs.User.ID)
+	if err != nil {
+		return nil, ErrDBListObjects.WithError(err)
+	}
+
+	token, _ := ctx.Value(fsctx.LockTokenCtx).(string)
+	set := make(lockSet)
+	for i := range locks {
+		if token == "" || locks[i].Token != token {
+			set[locks[i].FileID] = &locks[i]
+		}
+	}
+	if len(set) == 0 {
+		return nil, nil
+	}
+	return set, nil
+}
+
+// locked 检查文件本身是否被锁定
+func (set lockSet) locked(files []model.File) error {
+	for i := range files {
+		if lock, ok := set[files[i].ID]; ok {
+			return lockedError(lock)
+		}
+	}
+	return nil
+}
+
+// lockedBelow 检查目录下是否包含被锁定的文件。文件锁通常很少，从被锁定的文件向上查找是否位于目标目录下
+func (set lockSet) lockedBelow(uid uint, folders []uint) error {
+	if len(folders) == 0 {
+		return nil
+	}
+
+	targets := make(map[uint]bool, len(folders))
+	for _, id := range folders {
+		targets[id] = true
+	}
+
+	ids := make([]uint, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	files, err := model.GetFilesByIDs(ids, uid)
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+
+	// 当前层级的目录及位于其下的被锁定文件
+	origins := make(map[uint]*model.FileLock, len(files))
+	for i := range files {
+		origins[files[i].FolderID] = set[files[i].ID]
+	}
+
+	visited := make(map[uint]bool)
+	for len(origins) > 0 {
+		next := make([]uint, 0, len(origins))
+		for id, lock := range origins {
+			if targets[id] {
+				return lockedError(lock)
+			}
+			if !visited[id] {
+				visited[id] = true
+				next = append(next, id)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+
+		parents, err := model.GetFoldersByIDs(next, uid)
+		if err != nil {
+			return ErrDBListObjects.WithError(err)
+		}
+
+		upper := make(map[uint]*model.FileLock)
+		for i := range parents {
+			if parents[i].ParentID != nil {
+				upper[*parents[i].ParentID] = origins[parents[i].ID]
+			}
+		}
+		origins = upper
+	}
+
+	return nil
+}
+
+// CheckLocks 检查文件和目录是否可被删除、重命名、移动或覆盖，文件被锁定、
+// 或目录下包含被锁定的文件，且上下文中没有对应的锁令牌时返回 ErrFileLocked
+func (fs *FileSystem) CheckLocks(ctx context.Context, dirs, files []uint) error {
+	if len(dirs) == 0 && len(files) == 0 {
+		return nil
+	}
+
+	set, err := fs.loadLocks(ctx)
+	if err != nil || set == nil {
+		return err
+	}
+
+	for _, id := range files {
+		if lock, ok := set[id]; ok {
+			return lockedError(lock)
+		}
+	}
+	return set.lockedBelow(fs.User.ID, dirs)
+}
+
+func lockedError(lock *model.FileLock) error {
+	return ErrFileLocked.WithError(fmt.Errorf("file is locked by %q until %s", lock.Owner, lock.ExpiresAt.Format(time.RFC3339)))
+}
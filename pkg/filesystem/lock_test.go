@@ -0,0 +1,221 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectNoLocks() {
+	mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func TestLockTTL(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(cache.Set("setting_file_lock_ttl", "600", -1))
+	a.NoError(cache.Set("setting_file_lock_max_ttl", "3600", -1))
+
+	a.Equal(600, LockTTL(0))
+	a.Equal(120, LockTTL(120))
+	a.Equal(3600, LockTTL(7200))
+}
+
+func TestFileSystem_LockFile(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(cache.Set("setting_file_lock_ttl", "600", -1))
+	a.NoError(cache.Set("setting_file_lock_max_ttl", "3600", -1))
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}, Nick: "alice"}}
+	file := &model.File{Model: gorm.Model{ID: 1}}
+	active := time.Now().Add(time.Hour)
+
+	// 未锁定，新建锁
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)file_locks(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		lock, err := fs.LockFile(file, "", 0, "editing")
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.Len(lock.Token, 32)
+		a.Equal("alice", lock.Owner)
+		a.EqualValues(1, lock.UserID)
+	}
+
+	// 已被其他令牌锁定
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "file_id", "owner", "token", "expires_at"}).AddRow(1, 1, "bob", "token", active))
+		lock, err := fs.LockFile(file, "other", 0, "")
+		a.NoError(mock.ExpectationsWereMet())
+		a.Error(err)
+		a.Equal("bob", lock.Owner)
+	}
+
+	// 令牌一致，刷新锁
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "file_id", "owner", "token", "expires_at"}).AddRow(1, 1, "bob", "token", active))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)file_locks(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		lock, err := fs.LockFile(file, "token", 60, "")
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.True(lock.ExpiresAt.Before(active))
+	}
+
+	// 已过期的锁被替换
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "file_id", "owner", "token", "expires_at"}).AddRow(1, 1, "bob", "token", time.Now().Add(-time.Minute)))
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE(.+)file_locks(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)file_locks(.+)").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		lock, err := fs.LockFile(file, "", 0, "")
+		a.NoError(mock.ExpectationsWereMet())
+		a.NoError(err)
+		a.Equal("alice", lock.Owner)
+	}
+}
+
+func TestFileSystem_UnlockFile(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+	file := &model.File{Model: gorm.Model{ID: 1}}
+	active := time.Now().Add(time.Hour)
+
+	// 未锁定
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		a.NoError(fs.UnlockFile(file, "", false))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 令牌不匹配
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "file_id", "token", "expires_at"}).AddRow(1, 1, "token", active))
+		a.Error(fs.UnlockFile(file, "other", false))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 强制解锁
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "file_id", "token", "expires_at"}).AddRow(1, 1, "token", active))
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE(.+)file_locks(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		a.NoError(fs.UnlockFile(file, "other", true))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestCheckFileLocks(t *testing.T) {
+	a := assert.New(t)
+
+	// 无锁
+	mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	a.NoError(CheckFileLocks([]uint{1, 2}, ""))
+	a.NoError(mock.ExpectationsWereMet())
+
+	// 令牌匹配
+	mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "token"}).AddRow(1, "token"))
+	a.NoError(CheckFileLocks([]uint{1, 2}, "token"))
+	a.NoError(mock.ExpectationsWereMet())
+
+	// 令牌不匹配
+	mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "token"}).AddRow(1, "token"))
+	err := CheckFileLocks([]uint{1, 2}, "")
+	a.NoError(mock.ExpectationsWereMet())
+	a.Error(err)
+	a.Equal(ErrFileLocked.Code, err.(serializer.AppError).Code)
+}
+
+func TestFileSystem_CheckLocks(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+	lockColumns := []string{"id", "file_id", "user_id", "token"}
+	tokenCtx := context.WithValue(context.Background(), fsctx.LockTokenCtx, "token")
+
+	// 无对象不查询
+	a.NoError(fs.CheckLocks(context.Background(), nil, nil))
+
+	// 无锁
+	{
+		expectNoLocks()
+		a.NoError(fs.CheckLocks(context.Background(), []uint{2}, []uint{3}))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 文件被锁定，令牌匹配
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(1, 3, 1, "token"))
+		a.NoError(fs.CheckLocks(tokenCtx, nil, []uint{3}))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 文件被锁定，未提供令牌
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(1, 3, 1, "token"))
+		err := fs.CheckLocks(context.Background(), nil, []uint{3})
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(ErrFileLocked.Code, err.(serializer.AppError).Code)
+	}
+
+	// 多级子目录下的文件被锁定
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(1, 3, 1, "token"))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(3, "report.docx", 4))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(4, "deep", 2))
+		err := fs.CheckLocks(context.Background(), []uint{2}, nil)
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(ErrFileLocked.Code, err.(serializer.AppError).Code)
+	}
+
+	// 被锁定的文件不在目录下
+	{
+		mock.ExpectQuery("SELECT(.+)file_locks(.+)").WillReturnRows(sqlmock.NewRows(lockColumns).AddRow(1, 3, 1, "token"))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(3, "report.docx", 4))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(4, "other", 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(1, "/", nil))
+		a.NoError(fs.CheckLocks(context.Background(), []uint{2}, nil))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestFileSystem_Delete_Locks(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+
+	// 目录下的文件被锁定
+	expectNoRetention()
+	mock.ExpectQuery("SELECT(.+)file_locks(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_id", "user_id", "token"}).AddRow(1, 3, 1, "token"))
+	mock.ExpectQuery("SELECT(.+)folders(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "sub", 1))
+	mock.ExpectQuery("SELECT(.+)files(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(3, "report.docx", 2))
+	err := fs.Delete(context.Background(), []uint{2}, nil, false, false)
+	a.NoError(mock.ExpectationsWereMet())
+	a.Equal(ErrFileLocked.Code, err.(serializer.AppError).Code)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"sort"
 	"strings"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
@@ -25,6 +26,20 @@ func (fs *FileSystem) Rename(ctx context.Context, dir, file []uint, new string)
 		return ErrIllegalObjectName
 	}
 
+	if err := fs.CheckRetention(dir, file); err != nil {
+		return err
+	}
+
+	if err := fs.CheckLocks(ctx, dir, file); err != nil {
+		return err
+	}
+
+	event := &Event{Op: OpRename, Files: file, Folders: dir, NewName: new}
+	if err := fs.emitBefore(ctx, event); err != nil {
+		return err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
 	// 如果源对象是文件
 	if len(file) > 0 {
 		fileObject, err := model.GetFilesByIDs([]uint{file[0]}, fs.User.ID)
@@ -66,6 +81,10 @@ func (fs *FileSystem) Copy(ctx context.Context, dirs, files []uint, src, dst str
 		return ErrPathNotExist
 	}
 
+	if err := fs.checkMountBoundary(srcFolder, dstFolder); err != nil {
+		return err
+	}
+
 	// 记录复制的文件的总容量
 	var newUsedStorage uint64
 
@@ -99,7 +118,7 @@ func (fs *FileSystem) Copy(ctx context.Context, dirs, files []uint, src, dst str
 }
 
 // Move 移动文件和目录, 将id列表dirs和files从src移动至dst
-func (fs *FileSystem) Move(ctx context.Context, dirs, files []uint, src, dst string) error {
+func (fs *FileSystem) Move(ctx context.Context, dirs, files []uint, src, dst string) (err error) {
 	// 获取目的目录
 	isDstExist, dstFolder := fs.IsPathExist(dst)
 	isSrcExist, srcFolder := fs.IsPathExist(src)
@@ -113,8 +132,26 @@ func (fs *FileSystem) Move(ctx context.Context, dirs, files []uint, src, dst str
 		dstFolder.WebdavDstName = dstName
 	}
 
+	if err := fs.checkMountBoundary(srcFolder, dstFolder); err != nil {
+		return err
+	}
+
+	if err := fs.CheckRetention(dirs, files); err != nil {
+		return err
+	}
+
+	if err := fs.CheckLocks(ctx, dirs, files); err != nil {
+		return err
+	}
+
+	event := &Event{Op: OpMove, Files: files, Folders: dirs, Src: src, Dst: dst}
+	if err := fs.emitBefore(ctx, event); err != nil {
+		return err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
 	// 处理目录及子文件移动
-	err := srcFolder.MoveFolderTo(dirs, dstFolder)
+	err = srcFolder.MoveFolderTo(dirs, dstFolder)
 	if err != nil {
 		return ErrFileExisted.WithError(err)
 	}
@@ -132,7 +169,23 @@ func (fs *FileSystem) Move(ctx context.Context, dirs, files []uint, src, dst str
 
 // Delete 递归删除对象, force 为 true 时强制删除文件记录，忽略物理删除是否成功;
 // unlink 为 true 时只删除虚拟文件系统的文件记录，不删除物理文件。
-func (fs *FileSystem) Delete(ctx context.Context, dirs, files []uint, force, unlink bool) error {
+func (fs *FileSystem) Delete(ctx context.Context, dirs, files []uint, force, unlink bool) (err error) {
+	event := &Event{Op: OpDelete, Files: files, Folders: dirs}
+	if err := fs.emitBefore(ctx, event); err != nil {
+		return err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
+	holds, err := fs.loadRetention()
+	if err != nil {
+		return err
+	}
+
+	locks, err := fs.loadLocks(ctx)
+	if err != nil {
+		return err
+	}
+
 	// 已删除的文件ID
 	var deletedFiles = make([]*model.File, 0, len(fs.FileTarget))
 	// 删除失败的文件的父目录ID
@@ -156,6 +209,23 @@ func (fs *FileSystem) Delete(ctx context.Context, dirs, files []uint, force, unl
 		}
 	}
 
+	// 待删除的对象或其上级目录处于保留冻结中时拒绝删除
+	if holds != nil {
+		if err := holds.held(fs.DirTarget, fs.FileTarget); err != nil {
+			return err
+		}
+		if err := holds.heldAbove(fs.User.ID, fs.DirTarget, fs.FileTarget); err != nil {
+			return err
+		}
+	}
+
+	// 待删除的文件中包含目录下的全部文件，其中有被锁定的文件时拒绝删除
+	if locks != nil {
+		if err := locks.locked(fs.FileTarget); err != nil {
+			return err
+		}
+	}
+
 	// 去除待删除文件中包含软连接的部分
 	filesToBeDelete, err := model.RemoveFilesWithSoftLinks(fs.FileTarget)
 	if err != nil {
@@ -202,6 +272,20 @@ func (fs *FileSystem) Delete(ctx context.Context, dirs, files []uint, force, unl
 
 	model.DeleteShareBySourceIDs(deletedFileIDs, false)
 
+	// 删除文件的历史版本、音频元数据、照片信息及缩略图缓存记录
+	if len(deletedFileIDs) > 0 {
+		fs.DeleteVersions(ctx, model.ListFileVersionsByFileIDs(deletedFileIDs), unlink)
+		if len(model.GetAudioMetaByFileIDs(deletedFileIDs)) > 0 {
+			model.DeleteAudioMetaByFileIDs(deletedFileIDs)
+		}
+		if model.CountPhotoMetaByFileIDs(deletedFileIDs) > 0 {
+			model.DeletePhotoMetaByFileIDs(deletedFileIDs)
+		}
+		if model.CountThumbRecordsByFileIDs(deletedFileIDs) > 0 {
+			model.DeleteThumbRecordsByFileIDs(deletedFileIDs)
+		}
+	}
+
 	// 如果文件全部删除成功，继续删除目录
 	if len(deletedFiles) == len(allFiles) {
 		var allFolderIDs = make([]uint, 0, len(fs.DirTarget))
@@ -213,6 +297,16 @@ func (fs *FileSystem) Delete(ctx context.Context, dirs, files []uint, force, unl
 			return ErrDBDeleteObjects.WithError(err)
 		}
 
+		// 被删除目录的父目录需重新统计大小
+		for _, value := range fs.DirTarget {
+			var parent uint
+			if value.ParentID != nil {
+				parent = *value.ParentID
+				model.MarkFolderSizeDirty(parent)
+			}
+			model.RecordFileChange(value.OwnerID, model.FileChangeDelete, true, value.ID, parent, value.Name)
+		}
+
 		// 删除目录记录对应的分享记录
 		model.DeleteShareBySourceIDs(allFolderIDs, true)
 	}
@@ -278,6 +372,15 @@ func (fs *FileSystem) List(ctx context.Context, dirPath string, pathProcessor fu
 	}
 	fs.SetTargetDir(&[]model.Folder{*folder})
 
+	// 目录位于挂载的外部存储中时，列出结果使用挂载的存储策略，并同步外部存储中已有的对象
+	if err := fs.SwitchToMount(folder); err != nil {
+		util.Log().Warning("Failed to resolve mount of folder %d: %s", folder.ID, err)
+	} else if fs.Policy.IsMounted() {
+		if err := fs.syncMount(ctx, folder); err != nil {
+			util.Log().Warning("Failed to list mounted storage of folder %d: %s", folder.ID, err)
+		}
+	}
+
 	var parentPath = path.Join(folder.Position, folder.Name)
 	var childFolders []model.Folder
 	var childFiles []model.File
@@ -291,6 +394,148 @@ func (fs *FileSystem) List(ctx context.Context, dirPath string, pathProcessor fu
 	return fs.listObjects(ctx, parentPath, childFiles, childFolders, pathProcessor), nil
 }
 
+// ListOption 分页列目录的排序和分页选项
+type ListOption struct {
+	// OrderBy 排序字段，可选 name、size、date、type
+	OrderBy string
+	// Desc 是否降序排列
+	Desc bool
+	// Offset 跳过的对象数量，目录总是排在文件之前
+	Offset int
+	// Limit 返回的最大对象数量，不大于 0 时不限制
+	Limit int
+}
+
+// listOrder 返回排序字段对应的 SQL 排序语句
+func (opt *ListOption) listOrder() string {
+	direction := "asc"
+	if opt.Desc {
+		direction = "desc"
+	}
+
+	column := "name"
+	switch opt.OrderBy {
+	case "size":
+		column = "size"
+	case "date":
+		column = "updated_at"
+	}
+
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
+}
+
+// ListPage 按排序选项分页列出目录，返回当前页对象及目录下对象总数
+func (fs *FileSystem) ListPage(ctx context.Context, dirPath string, opt ListOption, pathProcessor func(string) string) ([]serializer.Object, int, error) {
+	// 获取父目录
+	isExist, folder := fs.IsPathExist(dirPath)
+	if !isExist {
+		return nil, 0, ErrPathNotExist
+	}
+	fs.SetTargetDir(&[]model.Folder{*folder})
+
+	// 目录位于挂载的外部存储中时，列出结果使用挂载的存储策略，并同步外部存储中已有的对象
+	if err := fs.SwitchToMount(folder); err != nil {
+		util.Log().Warning("Failed to resolve mount of folder %d: %s", folder.ID, err)
+	} else if fs.Policy.IsMounted() {
+		if err := fs.syncMount(ctx, folder); err != nil {
+			util.Log().Warning("Failed to list mounted storage of folder %d: %s", folder.ID, err)
+		}
+	}
+
+	folderNum, fileNum, err := folder.CountChildren()
+	if err != nil {
+		return nil, 0, ErrDBListObjects.WithError(err)
+	}
+
+	var (
+		parentPath   = path.Join(folder.Position, folder.Name)
+		childFolders []model.Folder
+		childFiles   []model.File
+		order        = opt.listOrder()
+	)
+
+	// 目录排在文件之前，目录不区分类型，按名称排序
+	if opt.OrderBy == "type" {
+		order = (&ListOption{Desc: opt.Desc}).listOrder()
+	}
+	if opt.Offset < folderNum {
+		childFolders, err = folder.GetChildFolderPage(order, opt.Offset, opt.Limit)
+		if err != nil {
+			return nil, 0, ErrDBListObjects.WithError(err)
+		}
+	}
+
+	remain := opt.Limit - len(childFolders)
+	if opt.Limit <= 0 || remain > 0 {
+		fileOffset := opt.Offset - folderNum
+		if fileOffset < 0 {
+			fileOffset = 0
+		}
+
+		if opt.OrderBy == "type" {
+			childFiles, err = fs.listFilesByType(folder, opt.Desc, fileOffset, remain)
+		} else if fileOffset < fileNum {
+			childFiles, err = folder.GetChildFilesPage(order, fileOffset, remain)
+		}
+		if err != nil {
+			return nil, 0, ErrDBListObjects.WithError(err)
+		}
+	}
+
+	return fs.listObjects(ctx, parentPath, childFiles, childFolders, pathProcessor), folderNum + fileNum, nil
+}
+
+// listFilesByType 按扩展名排序分页列出目录下的文件，扩展名相同时按名称排序
+func (fs *FileSystem) listFilesByType(folder *model.Folder, desc bool, offset, limit int) ([]model.File, error) {
+	names, err := folder.GetChildFileNames()
+	if err != nil || offset >= len(names) {
+		return nil, err
+	}
+
+	// names 已按名称排序，扩展名相同时保持原有次序
+	exts := make(map[uint]string, len(names))
+	for i := range names {
+		exts[names[i].ID] = strings.ToLower(path.Ext(names[i].Name))
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return exts[names[i].ID] < exts[names[j].ID]
+	})
+	if desc {
+		for l, r := 0, len(names)-1; l < r; l, r = l+1, r-1 {
+			names[l], names[r] = names[r], names[l]
+		}
+	}
+
+	names = names[offset:]
+	if limit > 0 && limit < len(names) {
+		names = names[:limit]
+	}
+
+	ids := make([]uint, len(names))
+	for i := range names {
+		ids[i] = names[i].ID
+	}
+
+	files, err := model.GetFilesByIDs(ids, fs.User.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按排序结果重新排列查询到的文件
+	index := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return index[files[i].ID] < index[files[j].ID]
+	})
+	for i := range files {
+		files[i].Position = path.Join(folder.Position, folder.Name)
+	}
+
+	return files, nil
+}
+
 // ListPhysical 列出存储策略中的外部目录
 // TODO:测试
 func (fs *FileSystem) ListPhysical(ctx context.Context, dirPath string) ([]serializer.Object, error) {
@@ -351,7 +596,7 @@ func (fs *FileSystem) listObjects(ctx context.Context, parent string, files []mo
 			ID:         hashid.HashID(subFolder.ID, hashid.FolderID),
 			Name:       subFolder.Name,
 			Path:       processedPath,
-			Size:       0,
+			Size:       subFolder.Size,
 			Type:       "dir",
 			Date:       subFolder.UpdatedAt,
 			CreateDate: subFolder.CreatedAt,
@@ -379,6 +624,9 @@ func (fs *FileSystem) listObjects(ctx context.Context, parent string, files []mo
 				SourceEnabled: file.GetPolicy().IsOriginLinkEnable,
 				CreateDate:    file.CreatedAt,
 			}
+			if file.IsOpaque() {
+				newFile.Vault = file.VaultMetadata()
+			}
 			if shareKey != "" {
 				newFile.Key = shareKey
 			}
@@ -477,3 +725,43 @@ func (fs *FileSystem) SaveTo(ctx context.Context, path string) error {
 
 	return nil
 }
+
+// PurgeUser 删除当前用户的所有文件、分享及相关记录，最后删除用户本身
+func (fs *FileSystem) PurgeUser(ctx context.Context) error {
+	uid := fs.User.ID
+
+	// 存在保留冻结时不能删除用户
+	holds, err := model.GetActiveRetentionHoldsByUID(uid)
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+	if len(holds) > 0 {
+		return ErrRetentionHold.WithError(fmt.Errorf("user has %d object(s) under retention hold", len(holds)))
+	}
+
+	// 删除所有文件
+	root, err := fs.User.Root()
+	if err != nil {
+		return err
+	}
+
+	// 用户自己持有的文件锁不应阻止删除其文件
+	model.DB.Unscoped().Where("user_id = ?", uid).Delete(&model.FileLock{})
+	fs.Delete(ctx, []uint{root.ID}, []uint{}, false, false)
+
+	// 删除分享
+	model.DeleteShares(model.DB.Where("user_id = ?", uid))
+
+	// 删除相关任务
+	model.DB.Where("user_id = ?", uid).Delete(&model.Download{})
+	model.DB.Where("user_id = ?", uid).Delete(&model.Task{})
+
+	// 删除标签
+	model.DB.Where("user_id = ?", uid).Delete(&model.Tag{})
+
+	// 删除WebDAV账号
+	model.DB.Where("user_id = ?", uid).Delete(&model.Webdav{})
+
+	// 删除此用户
+	return model.DB.Unscoped().Delete(fs.User).Error
+}
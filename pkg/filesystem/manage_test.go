@@ -66,6 +66,71 @@ func TestFileSystem_ListPhysical(t *testing.T) {
 	}
 }
 
+func TestFileSystem_ListPage(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{
+		Model: gorm.Model{
+			ID: 1,
+		},
+	}}
+	ctx := context.Background()
+
+	expectFolder := func() {
+		mock.ExpectQuery("SELECT(.+)").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id"}).AddRow(1, "/", 1))
+		mock.ExpectQuery("SELECT(.+)").
+			WithArgs(1, 1, "folder").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id"}).AddRow(5, "folder", 1))
+		mock.ExpectQuery("SELECT count(.+)folders(.+)").WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery("SELECT count(.+)files(.+)").WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	}
+
+	// 目录不足一页，剩余部分由文件补齐
+	{
+		expectFolder()
+		mock.ExpectQuery("SELECT(.+)folders(.+)ORDER BY size desc, id desc(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "sub_folder2"))
+		mock.ExpectQuery("SELECT(.+)files(.+)ORDER BY size desc, id desc(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(8, "a.txt").AddRow(9, "b.txt"))
+		objects, total, err := fs.ListPage(ctx, "/folder", ListOption{OrderBy: "size", Desc: true, Offset: 1, Limit: 3}, nil)
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(5, total)
+		asserts.Len(objects, 3)
+		asserts.Equal("dir", objects[0].Type)
+		asserts.Equal("file", objects[2].Type)
+	}
+
+	// 按类型排序
+	{
+		expectFolder()
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(8, "a.txt").AddRow(9, "b.jpg").AddRow(10, "c.txt"))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(8, "a.txt").AddRow(10, "c.txt"))
+		objects, total, err := fs.ListPage(ctx, "/folder", ListOption{OrderBy: "type", Offset: 3}, nil)
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(5, total)
+		asserts.Len(objects, 2)
+		asserts.Equal("a.txt", objects[0].Name)
+		asserts.Equal("c.txt", objects[1].Name)
+	}
+
+	// 目录不存在
+	{
+		mock.ExpectQuery("SELECT(.+)").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "owner_id"}))
+		_, _, err := fs.ListPage(ctx, "/folder", ListOption{}, nil)
+		asserts.Equal(ErrPathNotExist, err)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
 func TestFileSystem_List(t *testing.T) {
 	asserts := assert.New(t)
 	fs := &FileSystem{User: &model.User{
@@ -457,6 +522,8 @@ func TestFileSystem_Delete(t *testing.T) {
 
 	//全部未成功，强制
 	{
+		expectNoRetention()
+		expectNoLocks()
 		fs.CleanTargets()
 		mock.ExpectQuery("SELECT(.+)").
 			WillReturnRows(
@@ -509,6 +576,8 @@ func TestFileSystem_Delete(t *testing.T) {
 	}
 	//全部成功
 	{
+		expectNoRetention()
+		expectNoLocks()
 		fs.CleanTargets()
 		file, err := os.Create(util.RelativePath("1.txt"))
 		file2, err := os.Create(util.RelativePath("2.txt"))
@@ -610,6 +679,7 @@ func TestFileSystem_Copy(t *testing.T) {
 		mock.ExpectQuery("SELECT(.+)").
 			WithArgs(1, 1, "src").
 			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(2, 1))
+		expectNoMount()
 
 		err := fs.Copy(ctx, []uint{1}, []uint{}, "/src", "/dst")
 		asserts.Error(err)
@@ -658,6 +728,9 @@ func TestFileSystem_Move(t *testing.T) {
 		mock.ExpectQuery("SELECT(.+)").
 			WithArgs(1, 1, "src").
 			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(2, 1))
+		expectNoMount()
+		expectNoRetention()
+		expectNoLocks()
 		err := fs.Move(ctx, []uint{1}, []uint{}, "/src", "/dst")
 		asserts.Error(err)
 		asserts.NoError(mock.ExpectationsWereMet())
@@ -677,6 +750,8 @@ func TestFileSystem_Rename(t *testing.T) {
 
 	// 重命名文件 成功
 	{
+		expectNoRetention()
+		expectNoLocks()
 		mock.ExpectQuery("SELECT(.+)files(.+)").
 			WithArgs(10, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(10, "old.text"))
@@ -692,6 +767,8 @@ func TestFileSystem_Rename(t *testing.T) {
 
 	// 重命名文件 不存在
 	{
+		expectNoRetention()
+		expectNoLocks()
 		mock.ExpectQuery("SELECT(.+)files(.+)").
 			WithArgs(10, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
@@ -703,6 +780,8 @@ func TestFileSystem_Rename(t *testing.T) {
 
 	// 重命名文件 失败
 	{
+		expectNoRetention()
+		expectNoLocks()
 		mock.ExpectQuery("SELECT(.+)files(.+)").
 			WithArgs(10, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(10, "old.text"))
@@ -719,6 +798,8 @@ func TestFileSystem_Rename(t *testing.T) {
 
 	// 重命名目录 成功
 	{
+		expectNoRetention()
+		expectNoLocks()
 		mock.ExpectQuery("SELECT(.+)folders(.+)").
 			WithArgs(10, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(10, "old"))
@@ -734,6 +815,8 @@ func TestFileSystem_Rename(t *testing.T) {
 
 	// 重命名目录 不存在
 	{
+		expectNoRetention()
+		expectNoLocks()
 		mock.ExpectQuery("SELECT(.+)folders(.+)").
 			WithArgs(10, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
@@ -745,6 +828,8 @@ func TestFileSystem_Rename(t *testing.T) {
 
 	// 重命名目录 失败
 	{
+		expectNoRetention()
+		expectNoLocks()
 		mock.ExpectQuery("SELECT(.+)folders(.+)").
 			WithArgs(10, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(10, "old"))
@@ -790,6 +875,8 @@ func TestFileSystem_Rename(t *testing.T) {
 
 	// 新名字是目录，不应该检测扩展名
 	{
+		expectNoRetention()
+		expectNoLocks()
 		fs.Policy.OptionsSerialized.FileType = []string{"txt"}
 		mock.ExpectQuery("SELECT(.+)folders(.+)").
 			WithArgs(10, 1).
@@ -846,3 +933,22 @@ func TestFileSystem_SaveTo(t *testing.T) {
 		asserts.Error(err)
 	}
 }
+
+func TestFileSystem_PurgeUser(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	fs.User.ID = 2
+
+	// 存在保留冻结
+	mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "object_id", "user_id"}).AddRow(1, 3, 2))
+	err := fs.PurgeUser(context.Background())
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal(serializer.CodeRetentionHold, err.(serializer.AppError).Code)
+
+	// 根目录不存在
+	expectNoRetention()
+	mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnError(errors.New("error"))
+	asserts.Error(fs.PurgeUser(context.Background()))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,189 @@
+package filesystem
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/jinzhu/gorm"
+)
+
+/* ================
+	 用户挂载存储
+   ================
+*/
+
+// ErrCrossMount 不能在挂载的外部存储与其他目录间移动或复制对象
+var ErrCrossMount = serializer.NewError(serializer.CodeParamErr, "Cannot move or copy objects across mount boundary", nil)
+
+// SwitchToMount 目录位于用户挂载的外部存储中时，将文件系统切换至挂载所用的存储策略；
+// 目录不在挂载中而当前使用的是挂载策略时，切换回用户的存储策略
+func (fs *FileSystem) SwitchToMount(folder *model.Folder) error {
+	mount, relative, err := model.ResolveMountOfFolder(folder)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return fs.leaveMount()
+		}
+		return err
+	}
+
+	policy, err := model.GetPolicyByID(mount.PolicyID)
+	if err != nil {
+		return err
+	}
+
+	// 挂载点的虚拟路径，挂载中的文件按相对于挂载点的路径存放。
+	// 以挂载内的目录为根目录时（如分享），无法得到挂载点的路径
+	fs.mountRoot = ""
+	if fullPath := path.Join(folder.Position, folder.Name); relative == "/" {
+		fs.mountRoot = fullPath
+	} else if strings.HasSuffix(fullPath, relative) {
+		fs.mountRoot = path.Join("/", strings.TrimSuffix(fullPath, relative))
+	}
+
+	fs.Policy = &policy
+	return fs.DispatchHandler()
+}
+
+// SwitchToMountOf 按路径查找最近的已存在目录，并切换至其所在挂载的存储策略
+func (fs *FileSystem) SwitchToMountOf(dirPath string) error {
+	// 用户没有挂载时无需逐级查找目录
+	mounts, err := model.GetMountsByUID(fs.User.ID)
+	if err != nil {
+		return err
+	}
+	if len(mounts) == 0 {
+		return fs.leaveMount()
+	}
+
+	for {
+		if exist, folder := fs.IsPathExist(dirPath); exist {
+			return fs.SwitchToMount(folder)
+		}
+		if dirPath == "/" || dirPath == "." || dirPath == "" {
+			return fs.leaveMount()
+		}
+		dirPath = path.Dir(dirPath)
+	}
+}
+
+// leaveMount 当前使用挂载策略时切换回用户的存储策略
+func (fs *FileSystem) leaveMount() error {
+	fs.mountRoot = ""
+	if fs.Policy == nil || !fs.Policy.IsMounted() {
+		return nil
+	}
+
+	fs.Policy = &fs.User.Policy
+	return fs.DispatchHandler()
+}
+
+// mountRelative 返回虚拟路径相对于当前挂载点的路径
+func (fs *FileSystem) mountRelative(virtualPath string) string {
+	return path.Join("/", strings.TrimPrefix(virtualPath, fs.mountRoot))
+}
+
+// checkMountBoundary 源目录与目标目录位于不同挂载（或只有一方位于挂载）时返回 ErrCrossMount，
+// 对象的数据存放于各自的存储策略中，仅修改文件记录无法迁移数据
+func (fs *FileSystem) checkMountBoundary(src, dst *model.Folder) error {
+	mounts, err := model.GetMountsByUID(fs.User.ID)
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+	if len(mounts) == 0 {
+		return nil
+	}
+
+	mountID := func(folder *model.Folder) (uint, error) {
+		mount, err := model.GetMountOfFolder(folder)
+		if err != nil {
+			if gorm.IsRecordNotFoundError(err) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		return mount.ID, nil
+	}
+
+	srcMount, err := mountID(src)
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+	dstMount, err := mountID(dst)
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+
+	if srcMount != dstMount {
+		return ErrCrossMount
+	}
+	return nil
+}
+
+// syncMount 将挂载的外部存储中已存在、但还没有文件记录的对象添加到目录中，
+// 需先通过 SwitchToMount 切换至目录所在挂载的存储策略
+func (fs *FileSystem) syncMount(ctx context.Context, folder *model.Folder) error {
+	if fs.mountRoot == "" {
+		return nil
+	}
+
+	base := fs.Policy.GeneratePath(fs.User.ID, fs.mountRelative(path.Join(folder.Position, folder.Name)))
+	objects, err := fs.Handler.List(ctx, base, false)
+	if err != nil || len(objects) == 0 {
+		return err
+	}
+
+	childFolders, err := folder.GetChildFolder()
+	if err != nil {
+		return err
+	}
+	folderNames := make(map[string]bool, len(childFolders))
+	for i := range childFolders {
+		folderNames[childFolders[i].Name] = true
+	}
+
+	// 文件移动后物理路径不变，按物理路径判断对象是否已有记录
+	sources := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if !object.IsDir {
+			sources = append(sources, object.Source)
+		}
+	}
+	recorded, err := model.GetRecordedSourceNames(fs.Policy.ID, sources)
+	if err != nil {
+		return err
+	}
+
+	for _, object := range objects {
+		if object.IsDir {
+			if folderNames[object.Name] {
+				continue
+			}
+
+			child := &model.Folder{Name: object.Name, ParentID: &folder.ID, OwnerID: fs.User.ID}
+			if _, err := child.Create(); err != nil {
+				util.Log().Warning("Failed to create folder %q for mounted storage: %s", object.Name, err)
+			}
+			continue
+		}
+
+		if recorded[object.Source] {
+			continue
+		}
+
+		if _, err := fs.AddFile(ctx, folder, &fsctx.FileStream{
+			Size:        object.Size,
+			Name:        object.Name,
+			SavePath:    object.Source,
+			VirtualPath: path.Join(folder.Position, folder.Name),
+		}); err != nil {
+			util.Log().Debug("Failed to add file %q from mounted storage: %s", object.Source, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/response"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+func expectNoMount() {
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func TestFileSystem_mountRelative(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{}
+
+	fs.mountRoot = "/"
+	a.Equal("/a/b", fs.mountRelative("/a/b"))
+
+	fs.mountRoot = "/mnt"
+	a.Equal("/", fs.mountRelative("/mnt"))
+	a.Equal("/sub", fs.mountRelative("/mnt/sub"))
+}
+
+func TestFileSystem_SwitchToMountOf(t *testing.T) {
+	a := assert.New(t)
+	user := &model.User{Model: gorm.Model{ID: 1}, Policy: model.Policy{Type: "mock"}}
+	mounted := &model.Policy{Type: "mock"}
+	mounted.OptionsSerialized.MountOwner = 1
+	fs := &FileSystem{User: user, Policy: mounted, mountRoot: "/mnt"}
+
+	// 用户没有挂载时切换回用户的存储策略
+	expectNoMount()
+	a.NoError(fs.SwitchToMountOf("/mnt/sub"))
+	a.NoError(mock.ExpectationsWereMet())
+	a.Equal(&user.Policy, fs.Policy)
+	a.Empty(fs.mountRoot)
+}
+
+func TestFileSystem_GenerateSavePath_Mount(t *testing.T) {
+	a := assert.New(t)
+	policy := &model.Policy{DirNameRule: "cloudreve/{path}", FileNameRule: "{originname}"}
+	policy.OptionsSerialized.MountOwner = 1
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}, Policy: policy, mountRoot: "/docs/mnt"}
+
+	file := &fsctx.FileStream{Name: "a.txt", VirtualPath: "/docs/mnt/sub"}
+	a.Equal("cloudreve/sub/a.txt", fs.GenerateSavePath(context.Background(), file))
+
+	file = &fsctx.FileStream{Name: "a.txt", VirtualPath: "/docs/mnt"}
+	a.Equal("cloudreve/a.txt", fs.GenerateSavePath(context.Background(), file))
+}
+
+func TestFileSystem_checkMountBoundary(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+	root := &model.Folder{Model: gorm.Model{ID: 1}, OwnerID: 1}
+	mountPoint := &model.Folder{Model: gorm.Model{ID: 2}, ParentID: &root.ID, OwnerID: 1}
+
+	// 没有挂载
+	expectNoMount()
+	a.NoError(fs.checkMountBoundary(root, mountPoint))
+	a.NoError(mock.ExpectationsWereMet())
+
+	// 移入挂载
+	mounts := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "folder_id", "policy_id"}).AddRow(5, 2, 6)
+	}
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(mounts())
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(mounts())
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(mounts())
+	a.Equal(ErrCrossMount, fs.checkMountBoundary(root, mountPoint))
+	a.NoError(mock.ExpectationsWereMet())
+
+	// 同一挂载内
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(mounts())
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(mounts())
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(mounts())
+	a.NoError(fs.checkMountBoundary(mountPoint, mountPoint))
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestFileSystem_SwitchToMount(t *testing.T) {
+	a := assert.New(t)
+	rootID := uint(1)
+	mountID := uint(2)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}, Policy: model.Policy{Type: "mock"}}}
+	cache.Set("policy_6", model.Policy{Model: gorm.Model{ID: 6}, Type: "mock", OptionsSerialized: model.PolicyOption{MountOwner: 1}}, 0)
+	defer cache.Deletes([]string{"6"}, "policy_")
+
+	// 挂载点下的子目录
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id", "policy_id"}).AddRow(5, 2, 6))
+	mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "mnt", 1))
+	a.NoError(fs.SwitchToMount(&model.Folder{Model: gorm.Model{ID: 3}, Name: "sub", Position: "/docs/mnt", ParentID: &mountID, OwnerID: 1}))
+	a.NoError(mock.ExpectationsWereMet())
+	a.EqualValues(6, fs.Policy.ID)
+	a.Equal("/docs/mnt", fs.mountRoot)
+
+	// 挂载点本身
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id", "policy_id"}).AddRow(5, 2, 6))
+	a.NoError(fs.SwitchToMount(&model.Folder{Model: gorm.Model{ID: 2}, Name: "mnt", Position: "/docs", ParentID: &rootID, OwnerID: 1}))
+	a.NoError(mock.ExpectationsWereMet())
+	a.Equal("/docs/mnt", fs.mountRoot)
+
+	// 以挂载内的目录为根目录时无法确定挂载点路径
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id", "policy_id"}).AddRow(5, 2, 6))
+	mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(2, 1).WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "mnt", 1))
+	a.NoError(fs.SwitchToMount(&model.Folder{Model: gorm.Model{ID: 3}, Name: "shared", ParentID: &mountID, OwnerID: 1}))
+	a.NoError(mock.ExpectationsWereMet())
+	a.EqualValues(6, fs.Policy.ID)
+	a.Empty(fs.mountRoot)
+
+	// 离开挂载
+	mock.ExpectQuery("SELECT(.+)mounts(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "folder_id", "policy_id"}).AddRow(5, 2, 6))
+	a.NoError(fs.SwitchToMount(&model.Folder{Model: gorm.Model{ID: 1}, Name: "/", OwnerID: 1}))
+	a.NoError(mock.ExpectationsWereMet())
+	a.Equal(&fs.User.Policy, fs.Policy)
+}
+
+func TestFileSystem_syncMount(t *testing.T) {
+	a := assert.New(t)
+	parentID := uint(1)
+	policy := &model.Policy{Model: gorm.Model{ID: 6}, DirNameRule: "cloudreve/{path}"}
+	policy.OptionsSerialized.MountOwner = 1
+	handler := new(FileHeaderMock)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}, Policy: policy, Handler: handler, mountRoot: "/mnt"}
+	folder := &model.Folder{Model: gorm.Model{ID: 3}, Name: "sub", Position: "/mnt", ParentID: &parentID, OwnerID: 1}
+
+	handler.On("List", testMock.Anything, "cloudreve/sub", false).Return([]response.Object{
+		{Name: "dir", IsDir: true},
+		{Name: "a.txt", Source: "cloudreve/sub/a.txt", Size: 1},
+		{Name: "b.txt", Source: "cloudreve/sub/b.txt", Size: 2},
+	}, nil)
+	mock.ExpectQuery("SELECT(.+)folders(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "dir"))
+	mock.ExpectQuery("SELECT(.+)files(.+)").
+		WithArgs(6, "cloudreve/sub/a.txt", "cloudreve/sub/b.txt").
+		WillReturnRows(sqlmock.NewRows([]string{"source_name"}).AddRow("cloudreve/sub/a.txt"))
+	// 只添加尚无记录的 b.txt
+	mock.ExpectQuery("SELECT(.+)vaults(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)files(.+)").WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectExec("UPDATE(.+)users(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	a.NoError(fs.syncMount(context.Background(), folder))
+	a.NoError(mock.ExpectationsWereMet())
+	handler.AssertExpectations(t)
+
+	// 无法确定挂载点路径时不同步
+	fs.mountRoot = ""
+	a.NoError(fs.syncMount(context.Background(), folder))
+}
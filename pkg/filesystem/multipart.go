@@ -0,0 +1,77 @@
+package filesystem
+
+import (
+	"context"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// multipartBatch 清理分片上传时每批处理的记录数
+const multipartBatch = 100
+
+// multipartGiveUp 取消失败的分片上传超出此期限后不再重试
+const multipartGiveUp = 7 * 24 * time.Hour
+
+// AbortStaleMultipartUploads 取消 before 之前发起、且未合并为正式文件的分片上传，
+// 返回取消的数量
+func AbortStaleMultipartUploads(ctx context.Context, before time.Time) (int, error) {
+	fs, err := NewAnonymousFileSystem()
+	if err != nil {
+		return 0, err
+	}
+	defer fs.Recycle()
+
+	aborted := 0
+	var after uint
+	for {
+		uploads, err := model.GetMultipartUploadsBefore(before, after, multipartBatch)
+		if err != nil || len(uploads) == 0 {
+			return aborted, err
+		}
+		after = uploads[len(uploads)-1].ID
+
+		for i := range uploads {
+			upload := &uploads[i]
+			if !upload.IsCompleted() {
+				if err := fs.abortMultipartUpload(ctx, upload); err != nil {
+					util.Log().Warning("Failed to abort multipart upload %q: %s", upload.UploadID, err)
+					// 存储端暂时不可用时保留记录，下次继续重试
+					if upload.CreatedAt.After(before.Add(-multipartGiveUp)) {
+						continue
+					}
+				} else {
+					aborted++
+				}
+			}
+
+			if err := upload.Delete(); err != nil {
+				util.Log().Warning("Failed to delete multipart upload record %d: %s", upload.ID, err)
+			}
+		}
+	}
+}
+
+// abortMultipartUpload 通过存储策略的接口取消分片上传
+func (fs *FileSystem) abortMultipartUpload(ctx context.Context, upload *model.MultipartUpload) error {
+	policy, err := model.GetPolicyByID(upload.PolicyID)
+	if err != nil {
+		// 存储策略已被删除，无法再取消
+		return nil
+	}
+
+	fs.Policy = &policy
+	if err := fs.DispatchHandler(); err != nil {
+		return err
+	}
+
+	return fs.Handler.CancelToken(ctx, &serializer.UploadSession{
+		Key:      upload.SessionID,
+		UID:      upload.UserID,
+		Policy:   policy,
+		SavePath: upload.SavePath,
+		UploadID: upload.UploadID,
+	})
+}
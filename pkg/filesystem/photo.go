@@ -0,0 +1,110 @@
+package filesystem
+
+import (
+	"context"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/exif"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+	 照片拍摄信息
+   ================
+*/
+
+// IsPhoto 返回文件是否为支持提取 EXIF 信息的图片
+func IsPhoto(file *model.File) bool {
+	return util.IsInExtensionList(strings.Split(model.GetSettingByName("photo_meta_exts"), ","), file.Name)
+}
+
+// PhotoMeta 返回照片的拍摄信息。cached 为已保存的信息，为空或与文件当前内容不一致时
+// 重新提取并保存，没有 EXIF 信息的图片以上传时间作为拍摄时间
+func (fs *FileSystem) PhotoMeta(ctx context.Context, file *model.File, cached *model.PhotoMeta) (*model.PhotoMeta, error) {
+	if cached != nil && !cached.IsStale(file) {
+		return cached, nil
+	}
+
+	info, err := fs.readExif(ctx, file)
+	if err != nil {
+		util.Log().Debug("Failed to read EXIF of %q: %s", file.Name, err)
+	}
+
+	meta := newPhotoMeta(file, cached, info)
+	if err := meta.Save(); err != nil {
+		return nil, ErrDBListObjects.WithError(err)
+	}
+
+	return meta, nil
+}
+
+// newPhotoMeta 根据 EXIF 信息构建照片的拍摄信息，info 为空时以上传时间作为拍摄时间
+func newPhotoMeta(file *model.File, cached *model.PhotoMeta, info *exif.Exif) *model.PhotoMeta {
+	meta := &model.PhotoMeta{}
+	if cached != nil {
+		meta.ID = cached.ID
+		meta.CreatedAt = cached.CreatedAt
+	}
+	meta.FileID = file.ID
+	meta.UserID = file.UserID
+	meta.SourceName = file.SourceName
+	meta.TakenAt = file.CreatedAt
+
+	if info != nil {
+		meta.CameraMake = info.Make
+		meta.CameraModel = info.Model
+		meta.Latitude = info.Latitude
+		meta.Longitude = info.Longitude
+		if info.TakenAt != nil {
+			meta.TakenAt = *info.TakenAt
+		}
+	}
+
+	return meta
+}
+
+func (fs *FileSystem) readExif(ctx context.Context, file *model.File) (*exif.Exif, error) {
+	fs.SetTargetFile(&[]model.File{*file})
+	defer fs.CleanTargets()
+
+	rs, err := fs.GetContent(ctx, file.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	return exif.Read(rs)
+}
+
+// HookExtractPhotoMeta 上传完成后异步提取照片的拍摄信息
+func HookExtractPhotoMeta(ctx context.Context, fs *FileSystem, fileHeader fsctx.FileHeader) error {
+	file, ok := fileHeader.Info().Model.(*model.File)
+	if !ok || !model.IsTrueVal(model.GetSettingByName("photo_meta_on_upload")) || !IsPhoto(file) || file.IsOpaque() {
+		return nil
+	}
+
+	// 由存储端提取拍摄信息，见 HookGenerateSlaveMeta
+	if _, ok := fs.Handler.(driver.MetaGenerator); ok && model.IsTrueVal(model.GetSettingByName("slave_meta_on_upload")) {
+		return nil
+	}
+
+	user := *fs.User
+	target := *file
+	go func() {
+		photoFs, err := NewFileSystem(&user)
+		if err != nil {
+			util.Log().Warning("Failed to initialize filesystem for photo %q: %s", target.Name, err)
+			return
+		}
+		defer photoFs.Recycle()
+
+		if _, err := photoFs.PhotoMeta(context.Background(), &target, nil); err != nil {
+			util.Log().Warning("Failed to extract photo info of %q: %s", target.Name, err)
+		}
+	}()
+
+	return nil
+}
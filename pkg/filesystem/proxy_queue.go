@@ -0,0 +1,219 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+)
+
+// ErrProxyQueued 从机节点的中转下载并发已满，请求已排队
+var ErrProxyQueued = serializer.NewError(serializer.CodeTooManyRequests, "Too many transfers on this node, download is queued", nil)
+
+// proxyTicket 排队中的中转下载，客户端重试时凭相同的 ID 保持排队位置
+type proxyTicket struct {
+	id       string
+	uid      uint
+	enqueued time.Time
+	lastSeen time.Time
+	// live 正在阻塞等待的请求数，为 0 时客户端尚未重试，名额让给后续等待者
+	live int
+}
+
+// proxyQueue 单个从机节点的中转下载队列
+type proxyQueue struct {
+	active       int
+	activeByUser map[uint]int
+	lastServed   map[uint]time.Time
+	waiting      map[string]*proxyTicket
+	// changed 在有名额释放时关闭并替换，用于唤醒等待者
+	changed chan struct{}
+}
+
+var proxyQueues = struct {
+	sync.Mutex
+	nodes map[string]*proxyQueue
+}{nodes: make(map[string]*proxyQueue)}
+
+// ordered 按公平顺序排列等待者：进行中传输较少的用户优先，其次是较久未获得名额的用户，最后按排队先后
+func (q *proxyQueue) ordered() []*proxyTicket {
+	res := make([]*proxyTicket, 0, len(q.waiting))
+	for _, ticket := range q.waiting {
+		res = append(res, ticket)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		ai, aj := q.activeByUser[res[i].uid], q.activeByUser[res[j].uid]
+		if ai != aj {
+			return ai < aj
+		}
+		si, sj := q.lastServed[res[i].uid], q.lastServed[res[j].uid]
+		if !si.Equal(sj) {
+			return si.Before(sj)
+		}
+		return res[i].enqueued.Before(res[j].enqueued)
+	})
+	return res
+}
+
+// position 返回等待者的排队位置，从 1 开始
+func (q *proxyQueue) position(id string) int {
+	for i, ticket := range q.ordered() {
+		if ticket.id == id {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// next 返回按公平顺序下一个可获得名额的等待者，跳过没有请求在等待的排队凭据
+func (q *proxyQueue) next() string {
+	for _, ticket := range q.ordered() {
+		if ticket.live > 0 {
+			return ticket.id
+		}
+	}
+	return ""
+}
+
+// expire 移除超过 ttl 未重试的等待者
+func (q *proxyQueue) expire(now time.Time, ttl time.Duration) {
+	for id, ticket := range q.waiting {
+		if ticket.live == 0 && now.Sub(ticket.lastSeen) > ttl {
+			delete(q.waiting, id)
+		}
+	}
+}
+
+// notify 唤醒所有等待者重新检查名额
+func (q *proxyQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// AcquireProxySlot 为经由主机中转的从机策略下载占用节点的并发名额。名额已满时按公平顺序排队，
+// 最多等待 wait，仍未轮到时返回排队位置与 ErrProxyQueued，客户端使用相同的 ticket 重试可保留位置
+func AcquireProxySlot(ctx context.Context, policy *model.Policy, uid uint, ticket string, wait time.Duration) (func(), int, error) {
+	limit := policy.OptionsSerialized.ProxyMaxConcurrent
+	if policy.Type != "remote" || limit <= 0 {
+		return func() {}, 0, nil
+	}
+
+	ttl := time.Duration(model.GetIntSetting("proxy_queue_ticket_ttl", 60)) * time.Second
+	deadline := time.Now().Add(wait)
+	// joined 当前请求计入的排队凭据，同一凭据的其他请求获得名额后可能被重新创建
+	var joined *proxyTicket
+	for {
+		proxyQueues.Lock()
+		q, ok := proxyQueues.nodes[policy.Server]
+		if !ok {
+			q = &proxyQueue{
+				activeByUser: make(map[uint]int),
+				lastServed:   make(map[uint]time.Time),
+				waiting:      make(map[string]*proxyTicket),
+				changed:      make(chan struct{}),
+			}
+			proxyQueues.nodes[policy.Server] = q
+		}
+
+		now := time.Now()
+		q.expire(now, ttl)
+		t, ok := q.waiting[ticket]
+		if !ok {
+			t = &proxyTicket{id: ticket, uid: uid, enqueued: now}
+			q.waiting[ticket] = t
+		}
+		t.lastSeen = now
+		if joined != t {
+			t.live++
+			joined = t
+		}
+
+		position := q.position(ticket)
+		if q.active < limit && q.next() == ticket {
+			delete(q.waiting, ticket)
+			q.active++
+			q.activeByUser[uid]++
+			q.lastServed[uid] = now
+			proxyQueues.Unlock()
+			return releaseProxySlot(policy.Server, uid), 0, nil
+		}
+
+		changed := q.changed
+		proxyQueues.Unlock()
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			leaveProxyQueue(policy.Server, ticket, joined, false)
+			return nil, position, ErrProxyQueued
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			// 客户端已断开，不再保留排队位置
+			leaveProxyQueue(policy.Server, ticket, joined, true)
+			return nil, position, ctx.Err()
+		}
+	}
+}
+
+// ProxyTicket 返回中转下载的排队凭据，同一用户从同一客户端重试下载同一文件时保持不变
+func ProxyTicket(uid, fileID uint, client string) string {
+	return fmt.Sprintf("%d/%d/%s", uid, fileID, client)
+}
+
+// ProxyQueueWait 返回中转下载请求排队时的最长等待时间
+func ProxyQueueWait() time.Duration {
+	return time.Duration(model.GetIntSetting("proxy_queue_wait", 10)) * time.Second
+}
+
+func releaseProxySlot(node string, uid uint) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			proxyQueues.Lock()
+			defer proxyQueues.Unlock()
+			q := proxyQueues.nodes[node]
+			q.active--
+			if q.activeByUser[uid]--; q.activeByUser[uid] <= 0 {
+				delete(q.activeByUser, uid)
+			}
+
+			q.notify()
+			if q.active <= 0 && len(q.waiting) == 0 {
+				delete(proxyQueues.nodes, node)
+			}
+		})
+	}
+}
+
+// leaveProxyQueue 请求停止等待，drop 为 true 时同时移除排队凭据。
+// 排在前面的等待者离开后唤醒其余等待者，以免空闲名额被无人等待的凭据占住
+func leaveProxyQueue(node, ticket string, joined *proxyTicket, drop bool) {
+	proxyQueues.Lock()
+	defer proxyQueues.Unlock()
+	q, ok := proxyQueues.nodes[node]
+	if !ok {
+		return
+	}
+
+	if t, ok := q.waiting[ticket]; ok && t == joined {
+		if t.live--; drop && t.live <= 0 {
+			delete(q.waiting, ticket)
+		}
+	}
+
+	q.notify()
+	if q.active <= 0 && len(q.waiting) == 0 {
+		delete(proxyQueues.nodes, node)
+	}
+}
@@ -0,0 +1,90 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcquireProxySlot(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.NoError(cache.Set("setting_proxy_queue_ticket_ttl", "60", -1))
+	ctx := context.Background()
+	proxyQueues.nodes = make(map[string]*proxyQueue)
+
+	// 未限制并发
+	{
+		release, position, err := AcquireProxySlot(ctx, &model.Policy{Type: "local"}, 1, "a", 0)
+		asserts.NoError(err)
+		asserts.Equal(0, position)
+		release()
+	}
+
+	policy := &model.Policy{Type: "remote", Server: "http://node1"}
+	policy.OptionsSerialized.ProxyMaxConcurrent = 1
+
+	// 用户 1 占用名额
+	release1, _, err := AcquireProxySlot(ctx, policy, 1, "u1-a", 0)
+	asserts.NoError(err)
+
+	// 名额已满，用户 1 与用户 2 依次排队
+	_, position, err := AcquireProxySlot(ctx, policy, 1, "u1-b", 0)
+	asserts.ErrorIs(err, ErrProxyQueued)
+	asserts.Equal(1, position)
+	_, position, err = AcquireProxySlot(ctx, policy, 2, "u2-a", 0)
+	asserts.ErrorIs(err, ErrProxyQueued)
+	// 用户 2 没有进行中的传输，排在用户 1 之前
+	asserts.Equal(1, position)
+
+	// 释放名额后由用户 2 获得
+	done := make(chan error)
+	go func() {
+		release, _, err := AcquireProxySlot(ctx, policy, 2, "u2-a", time.Second)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release1()
+	release1()
+	asserts.NoError(<-done)
+
+	// 用户 1 重试时保持排队并最终获得名额
+	release, _, err := AcquireProxySlot(ctx, policy, 1, "u1-b", 0)
+	asserts.NoError(err)
+	release()
+
+	// 排在前面的客户端未重试时，名额由正在等待的请求获得
+	release1, _, err = AcquireProxySlot(ctx, policy, 1, "u1-c", 0)
+	asserts.NoError(err)
+	_, position, err = AcquireProxySlot(ctx, policy, 3, "u3-a", 0)
+	asserts.ErrorIs(err, ErrProxyQueued)
+	asserts.Equal(1, position)
+	go func() {
+		release, _, err := AcquireProxySlot(ctx, policy, 4, "u4-a", time.Second)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	release1()
+	asserts.NoError(<-done)
+
+	// 客户端断开后移除排队凭据
+	release1, _, err = AcquireProxySlot(ctx, policy, 1, "u1-d", 0)
+	asserts.NoError(err)
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, _, err = AcquireProxySlot(cancelCtx, policy, 5, "u5-a", time.Second)
+	asserts.ErrorIs(err, context.Canceled)
+	proxyQueues.Lock()
+	asserts.NotContains(proxyQueues.nodes[policy.Server].waiting, "u5-a")
+	proxyQueues.Unlock()
+	release1()
+}
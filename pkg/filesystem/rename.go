@@ -0,0 +1,256 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+)
+
+// 重命名规则中的大小写转换方式
+const (
+	RenameCaseLower = "lower"
+	RenameCaseUpper = "upper"
+	RenameCaseTitle = "title"
+)
+
+// RenameRule 批量重命名规则。规则依次执行查找替换、序号填充和大小写转换
+type RenameRule struct {
+	// Find 要查找的文本，Regex 为 true 时为正则表达式；为空时以 Replace 替换整个名称
+	Find string `json:"find"`
+	// Replace 替换内容，正则模式下可使用 $1 等引用捕获组，{n} 会被替换为序号
+	Replace string `json:"replace"`
+	// Regex 是否使用正则表达式查找
+	Regex bool `json:"regex"`
+	// Case 大小写转换方式，可选 lower、upper、title
+	Case string `json:"case" binding:"omitempty,eq=lower|eq=upper|eq=title"`
+	// SeqStart 序号起始值
+	SeqStart int `json:"seq_start"`
+	// SeqDigits 序号最小位数，不足时以 0 填充
+	SeqDigits int `json:"seq_digits" binding:"min=0,max=10"`
+	// KeepExt 是否只处理扩展名之前的部分
+	KeepExt bool `json:"keep_ext"`
+}
+
+// RenamePlan 批量重命名中单个对象的处理结果
+type RenamePlan struct {
+	ID       uint
+	IsDir    bool
+	OldName  string
+	NewName  string
+	Conflict bool
+}
+
+// Apply 对 name 执行重命名规则，seq 为对象在批次中的序号（从 0 开始）
+func (rule *RenameRule) Apply(name string, seq int) (string, error) {
+	base, ext := name, ""
+	if rule.KeepExt {
+		ext = path.Ext(name)
+		base = strings.TrimSuffix(name, ext)
+	}
+
+	replace := strings.ReplaceAll(rule.Replace, "{n}", fmt.Sprintf("%0*d", rule.SeqDigits, rule.SeqStart+seq))
+	switch {
+	case rule.Find == "":
+		base = replace
+	case rule.Regex:
+		exp, err := regexp.Compile(rule.Find)
+		if err != nil {
+			return "", ErrIllegalRenameRule.WithError(err)
+		}
+		base = exp.ReplaceAllString(base, replace)
+	default:
+		base = strings.ReplaceAll(base, rule.Find, replace)
+	}
+
+	switch rule.Case {
+	case RenameCaseLower:
+		base = strings.ToLower(base)
+	case RenameCaseUpper:
+		base = strings.ToUpper(base)
+	case RenameCaseTitle:
+		base = titleCase(base)
+	}
+
+	return base + ext, nil
+}
+
+// PlanRename 按照 rule 计算 dirs、files 的新名称，目录在前、文件在后依次编号，
+// 不会修改任何对象。名称不合法时返回错误，与其他对象重名的对象会被标记为冲突
+func (fs *FileSystem) PlanRename(ctx context.Context, dirs, files []uint, rule *RenameRule) ([]RenamePlan, error) {
+	folderObjects, fileObjects, err := fs.renameTargets(dirs, files)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.planRename(ctx, folderObjects, fileObjects, rule)
+}
+
+// planRename 计算已查找到的目录和文件的新名称
+func (fs *FileSystem) planRename(ctx context.Context, folderObjects []model.Folder, fileObjects []model.File, rule *RenameRule) ([]RenamePlan, error) {
+	var err error
+	plans := make([]RenamePlan, 0, len(folderObjects)+len(fileObjects))
+	parents := make([]uint, 0, len(plans))
+	for _, folder := range folderObjects {
+		plans = append(plans, RenamePlan{ID: folder.ID, IsDir: true, OldName: folder.Name})
+		parents = append(parents, *folder.ParentID)
+	}
+	for _, file := range fileObjects {
+		plans = append(plans, RenamePlan{ID: file.ID, OldName: file.Name})
+		parents = append(parents, file.FolderID)
+	}
+
+	// 同一目录下的目标名称
+	type target struct {
+		parent uint
+		isDir  bool
+		name   string
+	}
+	renamed := make(map[target]int, len(plans))
+	for i := range plans {
+		if plans[i].NewName, err = rule.Apply(plans[i].OldName, i); err != nil {
+			return nil, err
+		}
+
+		if !fs.ValidateLegalName(ctx, plans[i].NewName) || (!plans[i].IsDir && !fs.ValidateExtension(ctx, plans[i].NewName)) {
+			return nil, ErrIllegalObjectName.WithError(fmt.Errorf("illegal name %q", plans[i].NewName))
+		}
+
+		key := target{parent: parents[i], isDir: plans[i].IsDir, name: plans[i].NewName}
+		renamed[key]++
+	}
+
+	// 批次外未被重命名的同名对象
+	for i := range plans {
+		key := target{parent: parents[i], isDir: plans[i].IsDir, name: plans[i].NewName}
+		if renamed[key] > 1 {
+			plans[i].Conflict = true
+			continue
+		}
+
+		parent := &model.Folder{OwnerID: fs.User.ID}
+		parent.ID = parents[i]
+		var exist bool
+		var existID uint
+		if plans[i].IsDir {
+			if child, err := parent.GetChild(plans[i].NewName); err == nil {
+				exist, existID = true, child.ID
+			}
+		} else if child, err := parent.GetChildFile(plans[i].NewName); err == nil && child.ID > 0 {
+			exist, existID = true, child.ID
+		}
+
+		if exist && !inBatch(plans, existID, plans[i].IsDir) {
+			plans[i].Conflict = true
+		}
+	}
+
+	return plans, nil
+}
+
+// titleCase 将每个单词的首字母转为大写
+func titleCase(s string) string {
+	runes := []rune(s)
+	for i := range runes {
+		if i == 0 || !(unicode.IsLetter(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+			runes[i] = unicode.ToUpper(runes[i])
+		}
+	}
+	return string(runes)
+}
+
+// inBatch 返回对象是否也在本次重命名的批次中
+func inBatch(plans []RenamePlan, id uint, isDir bool) bool {
+	for _, plan := range plans {
+		if plan.ID == id && plan.IsDir == isDir {
+			return true
+		}
+	}
+	return false
+}
+
+// BatchRename 按照 rule 原子地重命名 dirs、files，任一对象冲突时不做任何修改
+func (fs *FileSystem) BatchRename(ctx context.Context, dirs, files []uint, rule *RenameRule) ([]RenamePlan, error) {
+	folderObjects, fileObjects, err := fs.renameTargets(dirs, files)
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := fs.planRename(ctx, folderObjects, fileObjects, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fs.CheckRetention(dirs, files); err != nil {
+		return plans, err
+	}
+
+	if err := fs.CheckLocks(ctx, dirs, files); err != nil {
+		return plans, err
+	}
+
+	folderNames := make(map[uint]string, len(folderObjects))
+	fileNames := make(map[uint]string, len(fileObjects))
+	for _, plan := range plans {
+		if plan.Conflict {
+			return plans, ErrFileExisted
+		}
+
+		if plan.IsDir {
+			folderNames[plan.ID] = plan.NewName
+		} else {
+			fileNames[plan.ID] = plan.NewName
+		}
+	}
+
+	if err := model.BatchRename(fileObjects, fileNames, folderObjects, folderNames); err != nil {
+		return plans, ErrFileExisted.WithError(err)
+	}
+
+	return plans, nil
+}
+
+// renameTargets 查找待重命名的目录和文件，保持请求中的顺序
+func (fs *FileSystem) renameTargets(dirs, files []uint) ([]model.Folder, []model.File, error) {
+	folders := make([]model.Folder, 0, len(dirs))
+	if len(dirs) > 0 {
+		res, err := model.GetFoldersByIDs(dirs, fs.User.ID)
+		if err != nil || len(res) != len(dirs) {
+			return nil, nil, ErrPathNotExist
+		}
+
+		index := make(map[uint]model.Folder, len(res))
+		for _, folder := range res {
+			// 根目录不可重命名
+			if folder.ParentID == nil {
+				return nil, nil, ErrRootProtected
+			}
+			index[folder.ID] = folder
+		}
+		for _, id := range dirs {
+			folders = append(folders, index[id])
+		}
+	}
+
+	fileObjects := make([]model.File, 0, len(files))
+	if len(files) > 0 {
+		res, err := model.GetFilesByIDs(files, fs.User.ID)
+		if err != nil || len(res) != len(files) {
+			return nil, nil, ErrPathNotExist
+		}
+
+		index := make(map[uint]model.File, len(res))
+		for _, file := range res {
+			index[file.ID] = file
+		}
+		for _, id := range files {
+			fileObjects = append(fileObjects, index[id])
+		}
+	}
+
+	return folders, fileObjects, nil
+}
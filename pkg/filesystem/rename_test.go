@@ -0,0 +1,91 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenameRule_Apply(t *testing.T) {
+	asserts := assert.New(t)
+
+	testCases := []struct {
+		rule     RenameRule
+		name     string
+		seq      int
+		expected string
+	}{
+		{RenameRule{Find: "IMG", Replace: "photo"}, "IMG_001.jpg", 0, "photo_001.jpg"},
+		{RenameRule{Find: `^(\d{4})(\d{2})`, Replace: "$1-$2", Regex: true}, "202301 trip.jpg", 0, "2023-01 trip.jpg"},
+		{RenameRule{Replace: "photo_{n}", SeqStart: 1, SeqDigits: 3, KeepExt: true}, "IMG_9.JPG", 1, "photo_002.JPG"},
+		{RenameRule{Case: RenameCaseLower, KeepExt: true, Find: "x", Replace: "x"}, "ABC.TXT", 0, "abc.TXT"},
+		{RenameRule{Case: RenameCaseUpper, Find: "x", Replace: "x"}, "abc.txt", 0, "ABC.TXT"},
+		{RenameRule{Case: RenameCaseTitle, Find: "_", Replace: " "}, "my_holiday_photo", 0, "My Holiday Photo"},
+	}
+
+	for _, testCase := range testCases {
+		res, err := testCase.rule.Apply(testCase.name, testCase.seq)
+		asserts.NoError(err)
+		asserts.Equal(testCase.expected, res)
+	}
+
+	// 非法正则
+	_, err := (&RenameRule{Find: "(", Regex: true}).Apply("a", 0)
+	asserts.Error(err)
+}
+
+func TestFileSystem_PlanRename(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_forbid_name", "", 0)
+	fs := &FileSystem{
+		User:   &model.User{Model: gorm.Model{ID: 1}},
+		Policy: &model.Policy{},
+	}
+	ctx := context.Background()
+	rule := &RenameRule{Replace: "{n}", KeepExt: true, SeqStart: 1}
+
+	// 批次内互换名称，与批次外对象重名
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(1, "2.txt", 5).AddRow(2, "1.txt", 5).AddRow(3, "a.txt", 5))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(5, "1.txt").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "1.txt"))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(5, "2.txt").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "2.txt"))
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(5, "3.txt").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(4, "3.txt"))
+		plans, err := fs.PlanRename(ctx, nil, []uint{1, 2, 3}, rule)
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Len(plans, 3)
+		asserts.Equal("1.txt", plans[0].NewName)
+		asserts.False(plans[0].Conflict)
+		asserts.False(plans[1].Conflict)
+		asserts.True(plans[2].Conflict)
+	}
+
+	// 批次内重名
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(1, "a.txt", 5).AddRow(2, "b.txt", 5))
+		plans, err := fs.PlanRename(ctx, nil, []uint{1, 2}, &RenameRule{Replace: "same", KeepExt: true})
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.True(plans[0].Conflict)
+		asserts.True(plans[1].Conflict)
+	}
+
+	// 对象不存在
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}))
+		_, err := fs.PlanRename(ctx, nil, []uint{1}, rule)
+		asserts.Equal(ErrPathNotExist, err)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
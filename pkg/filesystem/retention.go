@@ -0,0 +1,231 @@
+package filesystem
+
+import (
+	"fmt"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+)
+
+/* ================
+	 保留冻结
+   ================
+*/
+
+// retentionSet 用户对象上有效的保留冻结
+type retentionSet struct {
+	files map[uint]*model.RetentionHold
+	dirs  map[uint]*model.RetentionHold
+}
+
+// loadRetention 读取当前用户有效的保留冻结，没有冻结时返回 nil
+func (fs *FileSystem) loadRetention() (*retentionSet, error) {
+	holds, err := model.GetActiveRetentionHoldsByUID(fs.User.ID)
+	if err != nil {
+		return nil, ErrDBListObjects.WithError(err)
+	}
+	if len(holds) == 0 {
+		return nil, nil
+	}
+
+	set := &retentionSet{
+		files: make(map[uint]*model.RetentionHold),
+		dirs:  make(map[uint]*model.RetentionHold),
+	}
+	for i := range holds {
+		if holds[i].IsDir {
+			set.dirs[holds[i].ObjectID] = &holds[i]
+		} else {
+			set.files[holds[i].ObjectID] = &holds[i]
+		}
+	}
+	return set, nil
+}
+
+// held 检查对象本身是否被冻结
+func (set *retentionSet) held(folders []model.Folder, files []model.File) error {
+	for i := range files {
+		if hold, ok := set.files[files[i].ID]; ok {
+			return retentionError(files[i].Name, hold)
+		}
+	}
+	for i := range folders {
+		if hold, ok := set.dirs[folders[i].ID]; ok {
+			return retentionError(folders[i].Name, hold)
+		}
+	}
+	return nil
+}
+
+// heldAbove 检查对象的上级目录是否被冻结，被冻结目录下的所有内容均视为冻结
+func (set *retentionSet) heldAbove(uid uint, folders []model.Folder, files []model.File) error {
+	if len(set.dirs) == 0 {
+		return nil
+	}
+
+	// 对象本身已单独检查，无需重复查询
+	visited := make(map[uint]bool, len(folders))
+	for i := range folders {
+		visited[folders[i].ID] = true
+	}
+
+	next := make([]uint, 0, len(folders)+len(files))
+	enqueue := func(id uint) {
+		if !visited[id] {
+			visited[id] = true
+			next = append(next, id)
+		}
+	}
+	for i := range folders {
+		if folders[i].ParentID != nil {
+			enqueue(*folders[i].ParentID)
+		}
+	}
+	for i := range files {
+		enqueue(files[i].FolderID)
+	}
+
+	for len(next) > 0 {
+		parents, err := model.GetFoldersByIDs(next, uid)
+		if err != nil {
+			return ErrDBListObjects.WithError(err)
+		}
+
+		next = next[:0]
+		for i := range parents {
+			if hold, ok := set.dirs[parents[i].ID]; ok {
+				return retentionError(parents[i].Name, hold)
+			}
+			if parents[i].ParentID != nil {
+				enqueue(*parents[i].ParentID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// heldBelow 检查目录下是否包含被冻结的对象，移动或重命名目录会改变其下所有对象的路径。
+// 冻结记录通常很少，从被冻结的对象向上查找是否位于目标目录下
+func (set *retentionSet) heldBelow(uid uint, folders []model.Folder) error {
+	if len(folders) == 0 {
+		return nil
+	}
+
+	targets := make(map[uint]bool, len(folders))
+	for i := range folders {
+		targets[folders[i].ID] = true
+	}
+
+	type heldObject struct {
+		name string
+		hold *model.RetentionHold
+	}
+
+	// 当前层级的目录及位于其下的被冻结对象
+	origins := make(map[uint][]heldObject)
+	if len(set.files) > 0 {
+		ids := make([]uint, 0, len(set.files))
+		for id := range set.files {
+			ids = append(ids, id)
+		}
+		files, err := model.GetFilesByIDs(ids, uid)
+		if err != nil {
+			return ErrDBListObjects.WithError(err)
+		}
+		for i := range files {
+			origins[files[i].FolderID] = append(origins[files[i].FolderID], heldObject{files[i].Name, set.files[files[i].ID]})
+		}
+	}
+	if len(set.dirs) > 0 {
+		ids := make([]uint, 0, len(set.dirs))
+		for id := range set.dirs {
+			ids = append(ids, id)
+		}
+		dirs, err := model.GetFoldersByIDs(ids, uid)
+		if err != nil {
+			return ErrDBListObjects.WithError(err)
+		}
+		for i := range dirs {
+			if dirs[i].ParentID != nil {
+				origins[*dirs[i].ParentID] = append(origins[*dirs[i].ParentID], heldObject{dirs[i].Name, set.dirs[dirs[i].ID]})
+			}
+		}
+	}
+
+	visited := make(map[uint]bool)
+	for len(origins) > 0 {
+		next := make([]uint, 0, len(origins))
+		for id, objects := range origins {
+			if targets[id] {
+				return retentionError(objects[0].name, objects[0].hold)
+			}
+			if !visited[id] {
+				visited[id] = true
+				next = append(next, id)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+
+		parents, err := model.GetFoldersByIDs(next, uid)
+		if err != nil {
+			return ErrDBListObjects.WithError(err)
+		}
+
+		upper := make(map[uint][]heldObject)
+		for i := range parents {
+			if parents[i].ParentID != nil {
+				upper[*parents[i].ParentID] = append(upper[*parents[i].ParentID], origins[parents[i].ID]...)
+			}
+		}
+		origins = upper
+	}
+
+	return nil
+}
+
+// CheckRetention 检查文件和目录是否可被删除、重命名、移动或覆盖，
+// 对象本身或任一上级目录处于保留冻结中时返回 ErrRetentionHold，
+// 目录下包含被冻结的对象时同样不可移动或重命名
+func (fs *FileSystem) CheckRetention(dirs, files []uint) error {
+	if len(dirs) == 0 && len(files) == 0 {
+		return nil
+	}
+
+	set, err := fs.loadRetention()
+	if err != nil || set == nil {
+		return err
+	}
+
+	var (
+		folderObjects []model.Folder
+		fileObjects   []model.File
+	)
+	if len(dirs) > 0 {
+		if folderObjects, err = model.GetFoldersByIDs(dirs, fs.User.ID); err != nil {
+			return ErrDBListObjects.WithError(err)
+		}
+	}
+	if len(files) > 0 {
+		if fileObjects, err = model.GetFilesByIDs(files, fs.User.ID); err != nil {
+			return ErrDBListObjects.WithError(err)
+		}
+	}
+
+	if err := set.held(folderObjects, fileObjects); err != nil {
+		return err
+	}
+	if err := set.heldAbove(fs.User.ID, folderObjects, fileObjects); err != nil {
+		return err
+	}
+	return set.heldBelow(fs.User.ID, folderObjects)
+}
+
+func retentionError(name string, hold *model.RetentionHold) error {
+	if hold.HoldUntil == nil {
+		return ErrRetentionHold.WithError(fmt.Errorf("%q is under legal hold", name))
+	}
+	return ErrRetentionHold.WithError(fmt.Errorf("%q is retained until %s", name, hold.HoldUntil.Format(time.RFC3339)))
+}
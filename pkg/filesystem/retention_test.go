@@ -0,0 +1,116 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectNoRetention 模拟用户没有有效的保留冻结
+func expectNoRetention() {
+	mock.ExpectQuery("SELECT(.+)retention_holds(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+}
+
+func TestFileSystem_CheckRetention(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+	holdColumns := []string{"id", "object_id", "is_dir", "user_id", "hold_until"}
+
+	// 没有冻结
+	{
+		expectNoRetention()
+		a.NoError(fs.CheckRetention([]uint{2}, []uint{3}))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 文件本身被冻结
+	{
+		until := time.Now().Add(time.Hour)
+		mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+			WillReturnRows(sqlmock.NewRows(holdColumns).AddRow(1, 3, false, 1, until))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(3, "report.pdf", 2))
+		err := fs.CheckRetention(nil, []uint{3})
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(serializer.CodeRetentionHold, err.(serializer.AppError).Code)
+	}
+
+	// 上级目录被冻结
+	{
+		mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+			WillReturnRows(sqlmock.NewRows(holdColumns).AddRow(1, 1, true, 1, nil))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(3, "report.pdf", 2))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "sub", 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(1, "/", nil))
+		err := fs.CheckRetention(nil, []uint{3})
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(serializer.CodeRetentionHold, err.(serializer.AppError).Code)
+	}
+
+	// 冻结的目录不在对象上级
+	{
+		mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+			WillReturnRows(sqlmock.NewRows(holdColumns).AddRow(1, 5, true, 1, nil))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "sub", 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(1, "/", nil))
+		// 冻结的目录也不在目标目录下
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(5, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(5, "other", 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(1, "/", nil))
+		a.NoError(fs.CheckRetention([]uint{2}, nil))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 目录下的文件被冻结
+	{
+		mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+			WillReturnRows(sqlmock.NewRows(holdColumns).AddRow(1, 3, false, 1, nil))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "sub", 1))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WithArgs(3, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(3, "report.pdf", 4))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WithArgs(4, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(4, "deep", 2))
+		err := fs.CheckRetention([]uint{2}, nil)
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(serializer.CodeRetentionHold, err.(serializer.AppError).Code)
+	}
+}
+
+func TestFileSystem_Delete_Retention(t *testing.T) {
+	a := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+
+	// 目录下的文件被冻结
+	mock.ExpectQuery("SELECT(.+)retention_holds(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "object_id", "is_dir", "user_id"}).AddRow(1, 3, false, 1))
+	expectNoLocks()
+	mock.ExpectQuery("SELECT(.+)folders(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(2, "sub", 1))
+	mock.ExpectQuery("SELECT(.+)files(.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(3, "report.pdf", 2))
+	err := fs.Delete(context.Background(), []uint{2}, nil, false, false)
+	a.NoError(mock.ExpectationsWereMet())
+	a.Equal(serializer.CodeRetentionHold, err.(serializer.AppError).Code)
+}
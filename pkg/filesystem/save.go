@@ -0,0 +1,172 @@
+package filesystem
+
+import (
+	"context"
+	"path"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ==================
+	 转存他人文件
+   ==================
+*/
+
+// SavePlan 统计从 src 转存目录 dirs 及文件 files 需要复制的文件数量、总大小，
+// 以及其中是否有文件使用的存储策略不在当前用户组的可用策略中
+func (fs *FileSystem) SavePlan(src *FileSystem, dirs, files []uint) (count int, size uint64, crossPolicy bool, err error) {
+	fileObjects := make([]model.File, 0, len(files))
+	if len(files) > 0 {
+		fileObjects, err = model.GetFilesByIDs(files, src.User.ID)
+		if err != nil {
+			return 0, 0, false, err
+		}
+	}
+
+	if len(dirs) > 0 {
+		folders, err := model.GetRecursiveChildFolder(dirs, src.User.ID, true)
+		if err != nil {
+			return 0, 0, false, err
+		}
+
+		childFiles, err := model.GetChildFilesOfFolders(&folders)
+		if err != nil {
+			return 0, 0, false, err
+		}
+		fileObjects = append(fileObjects, childFiles...)
+	}
+
+	for _, file := range fileObjects {
+		count++
+		size += file.Size
+		if !fs.canLink(&file) {
+			crossPolicy = true
+		}
+	}
+
+	return count, size, crossPolicy, nil
+}
+
+// SaveFrom 将 src 文件系统中的目录 dirs 及文件 files 转存至当前用户的 dst 目录下。
+// 存储策略可被当前用户使用的文件只复制文件记录，其余文件读取内容后重新上传至当前用户的存储策略
+func (fs *FileSystem) SaveFrom(ctx context.Context, src *FileSystem, dirs, files []uint, dst string) error {
+	isDstExist, dstFolder := fs.IsPathExist(dst)
+	if !isDstExist {
+		return ErrPathNotExist
+	}
+
+	if len(files) > 0 {
+		fileObjects, err := model.GetFilesByIDs(files, src.User.ID)
+		if err != nil {
+			return ErrObjectNotExist.WithError(err)
+		}
+
+		for i := range fileObjects {
+			if err := fs.saveFile(ctx, src, &fileObjects[i], dstFolder, dst); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(dirs) > 0 {
+		folders, err := model.GetFoldersByIDs(dirs, src.User.ID)
+		if err != nil {
+			return ErrObjectNotExist.WithError(err)
+		}
+
+		for i := range folders {
+			if err := fs.saveFolder(ctx, src, &folders[i], dstFolder, dst); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// saveFolder 在 parent 下创建同名目录，并递归转存其中的文件与子目录
+func (fs *FileSystem) saveFolder(ctx context.Context, src *FileSystem, folder, parent *model.Folder, parentPath string) error {
+	newFolder := &model.Folder{
+		Name:     folder.Name,
+		ParentID: &parent.ID,
+		OwnerID:  fs.User.ID,
+	}
+	if _, err := newFolder.Create(); err != nil {
+		return ErrFileExisted.WithError(err)
+	}
+	folderPath := path.Join(parentPath, folder.Name)
+
+	childFiles, err := folder.GetChildFiles()
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+	for i := range childFiles {
+		if err := fs.saveFile(ctx, src, &childFiles[i], newFolder, folderPath); err != nil {
+			return err
+		}
+	}
+
+	childFolders, err := folder.GetChildFolder()
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+	for i := range childFolders {
+		if err := fs.saveFolder(ctx, src, &childFolders[i], newFolder, folderPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveFile 将单个文件转存至 parent 目录下
+func (fs *FileSystem) saveFile(ctx context.Context, src *FileSystem, file *model.File, parent *model.Folder, parentPath string) error {
+	if !file.CanCopy() {
+		util.Log().Warning("Cannot save file %q because it's being uploaded now, skipping...", file.Name)
+		return nil
+	}
+
+	// 与原文件共用物理文件，仅插入新的文件记录
+	if fs.canLink(file) {
+		newFile := &model.File{
+			Name:               file.Name,
+			SourceName:         file.SourceName,
+			UserID:             fs.User.ID,
+			Size:               file.Size,
+			PicInfo:            file.PicInfo,
+			FolderID:           parent.ID,
+			PolicyID:           file.PolicyID,
+			MetadataSerialized: file.MetadataSerialized,
+		}
+		if err := newFile.Create(); err != nil {
+			return ErrFileExisted.WithError(err)
+		}
+		fs.User.Storage += file.Size
+		return nil
+	}
+
+	// 跨存储策略，读取原文件内容后重新上传
+	src.SetTargetFile(&[]model.File{*file})
+	defer src.CleanTargets()
+
+	rs, err := src.GetContent(ctx, file.ID)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	return fs.UploadFromStream(ctx, &fsctx.FileStream{
+		File:        rs,
+		Seeker:      rs,
+		Size:        file.Size,
+		Name:        file.Name,
+		VirtualPath: parentPath,
+	}, true)
+}
+
+// canLink 返回文件是否可直接与当前用户共用物理文件
+func (fs *FileSystem) canLink(file *model.File) bool {
+	return util.ContainsUint(fs.User.Group.PolicyList, file.PolicyID)
+}
@@ -0,0 +1,82 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSystem_SavePlan(t *testing.T) {
+	asserts := assert.New(t)
+	src := &FileSystem{User: &model.User{Model: gorm.Model{ID: 2}}}
+	fs := &FileSystem{User: &model.User{
+		Model: gorm.Model{ID: 1},
+		Group: model.Group{PolicyList: []uint{1}},
+	}}
+
+	// 同一存储策略
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "size", "policy_id"}).AddRow(1, 10, 1))
+		count, size, cross, err := fs.SavePlan(src, nil, []uint{1})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Equal(1, count)
+		asserts.EqualValues(10, size)
+		asserts.False(cross)
+	}
+
+	// 目录中有其他存储策略的文件
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(3, 2))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "size", "policy_id"}).
+				AddRow(2, 5, 1).
+				AddRow(3, 6, 2))
+		count, size, cross, err := fs.SavePlan(src, []uint{3}, nil)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.Equal(2, count)
+		asserts.EqualValues(11, size)
+		asserts.True(cross)
+	}
+}
+
+func TestFileSystem_SaveFrom(t *testing.T) {
+	asserts := assert.New(t)
+	src := &FileSystem{User: &model.User{Model: gorm.Model{ID: 2}}}
+	fs := &FileSystem{User: &model.User{
+		Model: gorm.Model{ID: 1},
+		Group: model.Group{PolicyList: []uint{1}},
+	}}
+
+	// 目的目录不存在
+	{
+		mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+		err := fs.SaveFrom(context.Background(), src, nil, []uint{1}, "/")
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(ErrPathNotExist, err)
+	}
+
+	// 同一存储策略，仅复制文件记录
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(1, 1))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "size", "policy_id", "source_name"}).
+				AddRow(5, "a.txt", 10, 1, "a.txt"))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)files(.+)").WillReturnResult(sqlmock.NewResult(6, 1))
+		mock.ExpectExec("UPDATE(.+)users(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		err := fs.SaveFrom(context.Background(), src, nil, []uint{5}, "/")
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.EqualValues(10, fs.User.Storage)
+	}
+}
@@ -0,0 +1,213 @@
+package filesystem
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strconv"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/audiotag"
+	"github.com/cloudreve/Cloudreve/v3/pkg/exif"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/response"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/thumb"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ==============================
+	 存储端生成缩略图与元数据
+   ==============================
+*/
+
+// HookGenerateSlaveMeta 上传完成后请求存储端生成缩略图并提取元数据，
+// 用于主机无法直接读取文件内容的存储策略，生成结果由存储端异步推送回主机
+func HookGenerateSlaveMeta(ctx context.Context, fs *FileSystem, fileHeader fsctx.FileHeader) error {
+	generator, ok := fs.Handler.(driver.MetaGenerator)
+	if !ok || !model.IsTrueVal(model.GetSettingByName("slave_meta_on_upload")) {
+		return nil
+	}
+
+	file, ok := fileHeader.Info().Model.(*model.File)
+	if !ok || file.IsOpaque() {
+		return nil
+	}
+
+	req := newSlaveMetaReq(file)
+	if !req.Thumb && !req.Photo && !req.Audio && !req.Duration {
+		return nil
+	}
+
+	go func() {
+		if err := generator.GenerateMeta(context.Background(), req); err != nil {
+			util.Log().Warning("Failed to request metadata generation of %q: %s", file.Name, err)
+		}
+	}()
+
+	return nil
+}
+
+// newSlaveMetaReq 根据文件类型和站点设置构建生成请求
+func newSlaveMetaReq(file *model.File) *serializer.SlaveMetaReq {
+	callback := model.GetSiteURL().ResolveReference(&url.URL{
+		Path: "/api/v3/callback/meta/" + hashid.HashID(file.ID, hashid.FileID),
+	})
+
+	return &serializer.SlaveMetaReq{
+		Src:      file.SourceName,
+		Name:     file.Name,
+		Size:     file.Size,
+		Thumb:    file.MetadataSerialized[model.ThumbStatusMetadataKey] == model.ThumbStatusNotExist,
+		Photo:    model.IsTrueVal(model.GetSettingByName("photo_meta_on_upload")) && IsPhoto(file),
+		Audio:    IsAudio(file),
+		Duration: IsAudio(file) || thumb.IsVideo(file.Name),
+		Callback: callback.String(),
+	}
+}
+
+// SlaveMeta 在从机上为本地文件生成缩略图并提取元数据
+func (fs *FileSystem) SlaveMeta(ctx context.Context, req *serializer.SlaveMetaReq) *serializer.SlaveMetaResult {
+	res := &serializer.SlaveMetaResult{Src: req.Src}
+	file := &model.File{Name: req.Name, SourceName: req.Src, Size: req.Size}
+
+	if req.Thumb {
+		if err := fs.generateThumbnail(ctx, file); err != nil {
+			util.Log().Warning("Failed to generate thumb for %q: %s", req.Src, err)
+		}
+
+		res.ThumbStatus = file.MetadataSerialized[model.ThumbStatusMetadataKey]
+		if res.ThumbStatus == model.ThumbStatusExist {
+			if info, err := os.Stat(util.RelativePath(file.ThumbFile())); err == nil {
+				res.ThumbSize = uint64(info.Size())
+			}
+		}
+	}
+
+	if req.Photo {
+		res.Photo = true
+		if err := fs.readLocal(ctx, req.Src, func(rs response.RSCloser) (err error) {
+			res.Exif, err = exif.Read(rs)
+			return
+		}); err != nil {
+			util.Log().Debug("Failed to read EXIF of %q: %s", req.Src, err)
+		}
+	}
+
+	if req.Audio {
+		res.Audio = &serializer.SlaveAudioTags{}
+		if err := fs.readLocal(ctx, req.Src, func(rs response.RSCloser) error {
+			tags, err := audiotag.Read(rs)
+			if err != nil {
+				return err
+			}
+
+			res.Audio = &serializer.SlaveAudioTags{
+				Title:       tags.Title,
+				Artist:      tags.Artist,
+				Album:       tags.Album,
+				AlbumArtist: tags.AlbumArtist,
+				Genre:       tags.Genre,
+				Year:        tags.Year,
+				Track:       tags.Track,
+				HasCover:    tags.Picture != nil,
+			}
+			return nil
+		}); err != nil {
+			util.Log().Debug("Failed to read audio tags of %q: %s", req.Src, err)
+		}
+	}
+
+	if req.Duration {
+		if duration, err := thumb.MediaDuration(ctx, util.RelativePath(req.Src)); err == nil {
+			res.Duration = duration
+		} else {
+			util.Log().Debug("Failed to probe duration of %q: %s", req.Src, err)
+		}
+	}
+
+	return res
+}
+
+// ApplySlaveMeta 保存存储端推送的缩略图与元数据
+func ApplySlaveMeta(file *model.File, res *serializer.SlaveMetaResult) error {
+	// 生成期间文件已被覆盖，结果不再对应文件的当前内容
+	if file.SourceName != res.Src {
+		return nil
+	}
+
+	meta := make(map[string]string)
+	switch res.ThumbStatus {
+	case model.ThumbStatusExist:
+		meta[model.ThumbStatusMetadataKey] = model.ThumbStatusExist
+		meta[model.ThumbSidecarMetadataKey] = "true"
+	case model.ThumbStatusNotAvailable:
+		meta[model.ThumbStatusMetadataKey] = model.ThumbStatusNotAvailable
+	}
+
+	if res.Duration > 0 {
+		meta[model.MediaDurationMetadataKey] = strconv.FormatFloat(res.Duration, 'f', -1, 64)
+	}
+
+	if len(meta) > 0 {
+		if err := file.UpdateMetadata(meta); err != nil {
+			return err
+		}
+	}
+
+	if res.ThumbStatus == model.ThumbStatusExist {
+		if err := model.SaveThumbRecord(file.ID, file.PolicyID, res.ThumbSize); err != nil {
+			util.Log().Debug("Failed to save thumbnail record of %q: %s", file.Name, err)
+		}
+	}
+
+	if res.Photo {
+		cached, err := model.GetPhotoMetaByFileID(file.ID)
+		if err != nil {
+			cached = nil
+		}
+
+		if err := newPhotoMeta(file, cached, res.Exif).Save(); err != nil {
+			return err
+		}
+	}
+
+	if res.Audio != nil {
+		var cached *model.AudioMeta
+		if existing, ok := model.GetAudioMetaByFileIDs([]uint{file.ID})[file.ID]; ok {
+			cached = &existing
+		}
+
+		tags := &audiotag.Tags{
+			Title:       res.Audio.Title,
+			Artist:      res.Audio.Artist,
+			Album:       res.Audio.Album,
+			AlbumArtist: res.Audio.AlbumArtist,
+			Genre:       res.Audio.Genre,
+			Year:        res.Audio.Year,
+			Track:       res.Audio.Track,
+		}
+		if res.Audio.HasCover {
+			tags.Picture = &audiotag.Picture{}
+		}
+
+		if err := newAudioMeta(file, cached, tags).Save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readLocal 打开存储端的文件交由 read 读取
+func (fs *FileSystem) readLocal(ctx context.Context, src string, read func(rs response.RSCloser) error) error {
+	rs, err := fs.Handler.Get(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer rs.Close()
+
+	return read(rs)
+}
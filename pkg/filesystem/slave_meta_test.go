@@ -0,0 +1,50 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySlaveMeta(t *testing.T) {
+	asserts := assert.New(t)
+	file := &model.File{Name: "a.mp4", SourceName: "a.mp4"}
+	file.ID = 1
+
+	// 文件已被覆盖
+	{
+		asserts.NoError(ApplySlaveMeta(file, &serializer.SlaveMetaResult{Src: "b.mp4", Duration: 10}))
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Empty(file.MetadataSerialized)
+	}
+
+	// 缩略图不可用，保存时长
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)files(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(ApplySlaveMeta(file, &serializer.SlaveMetaResult{
+			Src:         "a.mp4",
+			ThumbStatus: model.ThumbStatusNotAvailable,
+			Duration:    12.5,
+		}))
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Equal(model.ThumbStatusNotAvailable, file.MetadataSerialized[model.ThumbStatusMetadataKey])
+		asserts.Equal("12.5", file.MetadataSerialized[model.MediaDurationMetadataKey])
+	}
+
+	// 没有 EXIF 信息的照片
+	{
+		file.Name = "a.jpg"
+		file.SourceName = "a.jpg"
+		mock.ExpectQuery("SELECT(.+)photo_meta(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)photo_meta(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		asserts.NoError(ApplySlaveMeta(file, &serializer.SlaveMetaResult{Src: "a.jpg", Photo: true}))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
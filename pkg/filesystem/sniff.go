@@ -0,0 +1,120 @@
+package filesystem
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// SniffLen 判断文件类型时读取的文件头长度
+const SniffLen = 512
+
+// magic 文件头特征
+type magic struct {
+	mime   string
+	offset int
+	prefix []byte
+	// exts 此类文件可能使用的扩展名
+	exts []string
+}
+
+// magics 可由文件头可靠识别的文件类型。纯文本类文件无法可靠识别，不做检查
+var magics = []magic{
+	{"application/x-msdownload", 0, []byte("MZ"), []string{"exe", "dll", "sys", "scr", "com", "cpl", "ocx", "drv", "efi", "mui"}},
+	{"application/x-elf", 0, []byte("\x7fELF"), []string{"so", "elf", "bin", "o", "out", "ko", "axf", "prx", "run"}},
+	{"application/x-mach-binary", 0, []byte("\xcf\xfa\xed\xfe"), []string{"dylib", "bundle", "o", "macho"}},
+	{"application/x-mach-binary", 0, []byte("\xce\xfa\xed\xfe"), []string{"dylib", "bundle", "o", "macho"}},
+	{"application/pdf", 0, []byte("%PDF-"), []string{"pdf", "ai"}},
+	{"image/png", 0, []byte("\x89PNG\r\n\x1a\n"), []string{"png", "apng"}},
+	{"image/jpeg", 0, []byte("\xff\xd8\xff"), []string{"jpg", "jpeg", "jpe", "jfif", "jif"}},
+	{"image/gif", 0, []byte("GIF87a"), []string{"gif"}},
+	{"image/gif", 0, []byte("GIF89a"), []string{"gif"}},
+	{"image/webp", 8, []byte("WEBP"), []string{"webp"}},
+	{"image/vnd.adobe.photoshop", 0, []byte("8BPS"), []string{"psd", "psb"}},
+	{"image/tiff", 0, []byte("II*\x00"), []string{"tif", "tiff", "dng", "cr2", "nef", "arw", "pef", "srw"}},
+	{"image/tiff", 0, []byte("MM\x00*"), []string{"tif", "tiff", "dng", "nef", "pef"}},
+	{"audio/wav", 8, []byte("WAVE"), []string{"wav", "wave"}},
+	{"video/avi", 8, []byte("AVI "), []string{"avi"}},
+	{"video/mp4", 4, []byte("ftyp"), []string{"mp4", "m4v", "m4a", "m4b", "m4p", "m4r", "mov", "qt", "3gp", "3g2", "f4v", "f4a", "heic", "heif", "avif", "mj2", "jp2", "cr3"}},
+	{"video/webm", 0, []byte("\x1a\x45\xdf\xa3"), []string{"mkv", "webm", "mka", "mk3d", "mks"}},
+	{"video/x-flv", 0, []byte("FLV\x01"), []string{"flv"}},
+	{"audio/ogg", 0, []byte("OggS"), []string{"ogg", "oga", "ogv", "ogx", "opus", "spx"}},
+	{"audio/flac", 0, []byte("fLaC"), []string{"flac"}},
+	{"audio/mpeg", 0, []byte("ID3"), []string{"mp3", "aac", "mp2", "mpga"}},
+	{"application/zip", 0, []byte("PK\x03\x04"), zipExtensions},
+	{"application/zip", 0, []byte("PK\x05\x06"), zipExtensions},
+	{"application/x-rar-compressed", 0, []byte("Rar!\x1a\x07"), []string{"rar", "cbr"}},
+	{"application/x-7z-compressed", 0, []byte("7z\xbc\xaf\x27\x1c"), []string{"7z", "cb7"}},
+	{"application/gzip", 0, []byte("\x1f\x8b"), []string{"gz", "tgz", "gzip", "svgz", "emz"}},
+	{"application/x-xz", 0, []byte("\xfd7zXZ\x00"), []string{"xz", "txz"}},
+	{"application/zstd", 0, []byte("\x28\xb5\x2f\xfd"), []string{"zst", "tzst"}},
+	{"application/x-ole-storage", 0, []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), []string{"doc", "dot", "xls", "xlt", "ppt", "pps", "pot", "msg", "msi", "msp", "vsd", "pub", "mpp", "wps", "et", "dps"}},
+	{"application/wasm", 0, []byte("\x00asm"), []string{"wasm"}},
+	{"application/x-sqlite3", 0, []byte("SQLite format 3\x00"), []string{"sqlite", "sqlite3", "db", "db3", "sdb"}},
+	{"font/woff", 0, []byte("wOFF"), []string{"woff"}},
+	{"font/woff2", 0, []byte("wOF2"), []string{"woff2"}},
+	{"font/otf", 0, []byte("OTTO"), []string{"otf"}},
+}
+
+// zipExtensions 以 ZIP 为容器的文件扩展名
+var zipExtensions = []string{
+	"zip", "docx", "docm", "dotx", "xlsx", "xlsm", "xltx", "pptx", "pptm", "potx", "ppsx",
+	"odt", "ods", "odp", "odg", "ott", "jar", "war", "ear", "apk", "aab", "xapk", "ipa", "epub",
+	"xpi", "vsix", "nupkg", "whl", "kmz", "3mf", "xps", "oxps", "cbz", "appx", "msix", "vsdx",
+	"pages", "numbers", "key", "sketch", "usdz", "ofd", "hwpx",
+}
+
+// Sniff 根据文件头判断文件类型，返回 MIME 类型及此类文件可能使用的扩展名，无法识别时返回空
+func Sniff(head []byte) (string, []string) {
+	for _, m := range magics {
+		if len(head) >= m.offset+len(m.prefix) && bytes.Equal(head[m.offset:m.offset+len(m.prefix)], m.prefix) {
+			return m.mime, m.exts
+		}
+	}
+	return "", nil
+}
+
+// ContentMismatch 检查文件内容与扩展名是否一致，不一致时返回识别出的 MIME 类型
+func ContentMismatch(name string, head []byte) (string, bool) {
+	mime, exts := Sniff(head)
+	if mime == "" {
+		return "", false
+	}
+
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	if util.ContainsString(exts, ext) {
+		return "", false
+	}
+
+	return mime, true
+}
+
+// SniffWriter 记录写入内容的前 SniffLen 字节及总长度，用于在读取文件的同时判断文件类型与实际大小
+type SniffWriter struct {
+	head []byte
+	size uint64
+}
+
+// Write 实现 io.Writer
+func (w *SniffWriter) Write(p []byte) (int, error) {
+	if remain := SniffLen - len(w.head); remain > 0 {
+		if len(p) < remain {
+			remain = len(p)
+		}
+		w.head = append(w.head, p[:remain]...)
+	}
+	w.size += uint64(len(p))
+	return len(p), nil
+}
+
+// Head 返回文件头
+func (w *SniffWriter) Head() []byte {
+	return w.head
+}
+
+// Size 返回已写入的总长度
+func (w *SniffWriter) Size() uint64 {
+	return w.size
+}
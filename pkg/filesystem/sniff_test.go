@@ -0,0 +1,58 @@
+package filesystem
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniff(t *testing.T) {
+	asserts := assert.New(t)
+
+	mime, exts := Sniff([]byte("\x89PNG\r\n\x1a\n0000"))
+	asserts.Equal("image/png", mime)
+	asserts.Contains(exts, "png")
+
+	mime, _ = Sniff([]byte("\x00\x00\x00\x20ftypisom"))
+	asserts.Equal("video/mp4", mime)
+
+	// 纯文本与过短的内容无法识别
+	mime, exts = Sniff([]byte("hello world"))
+	asserts.Empty(mime)
+	asserts.Nil(exts)
+	mime, _ = Sniff([]byte("\x00\x00\x00"))
+	asserts.Empty(mime)
+}
+
+func TestContentMismatch(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 扩展名与内容一致
+	_, mismatch := ContentMismatch("photo.JPG", []byte("\xff\xd8\xff\xe0"))
+	asserts.False(mismatch)
+	_, mismatch = ContentMismatch("report.docx", []byte("PK\x03\x04"))
+	asserts.False(mismatch)
+
+	// 无法识别的内容
+	_, mismatch = ContentMismatch("notes.jpg", []byte("plain text"))
+	asserts.False(mismatch)
+
+	// 改名的可执行文件
+	mime, mismatch := ContentMismatch("photo.jpg", []byte("MZ\x90\x00"))
+	asserts.True(mismatch)
+	asserts.Equal("application/x-msdownload", mime)
+	_, mismatch = ContentMismatch("noext", []byte("\x7fELF"))
+	asserts.True(mismatch)
+}
+
+func TestSniffWriter(t *testing.T) {
+	asserts := assert.New(t)
+	w := &SniffWriter{}
+	n, err := io.Copy(w, strings.NewReader(strings.Repeat("a", SniffLen+100)))
+	asserts.NoError(err)
+	asserts.EqualValues(SniffLen+100, n)
+	asserts.Len(w.Head(), SniffLen)
+	asserts.EqualValues(SniffLen+100, w.Size())
+}
@@ -0,0 +1,137 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// maxTemplateFolders 单个目录模板最多展开的路径数
+const maxTemplateFolders = 500
+
+// ExpandFolderTemplate 将目录模板展开为相对路径列表。模板每行一个路径，
+// {a,b} 展开为多个目录，{name} 替换为 vars 中的同名变量
+func ExpandFolderTemplate(structure string, vars map[string]string) ([]string, error) {
+	for name, value := range vars {
+		if value == "" || strings.ContainsAny(value, "/\\{},") {
+			return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("invalid value of variable %q", name))
+		}
+	}
+
+	return expandFolderTemplate(structure, func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+}
+
+// ValidateFolderTemplate 检查目录模板的语法，变量使用占位值展开
+func ValidateFolderTemplate(structure string) error {
+	_, err := expandFolderTemplate(structure, func(name string) (string, bool) {
+		return "var", true
+	})
+	return err
+}
+
+func expandFolderTemplate(structure string, lookup func(name string) (string, bool)) ([]string, error) {
+	res := make([]string, 0)
+	for _, line := range strings.Split(structure, "\n") {
+		line = strings.Trim(strings.TrimSpace(line), "/")
+		if line == "" {
+			continue
+		}
+
+		expanded, err := expandTemplateLine(line, lookup)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range expanded {
+			// 以根目录为起点清理路径，避免 .. 超出创建位置
+			p = path.Clean("/" + p)
+			if p == "/" {
+				return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("invalid path %q", p))
+			}
+			res = append(res, p)
+		}
+
+		if len(res) > maxTemplateFolders {
+			return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("template expands to more than %d folders", maxTemplateFolders))
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("template is empty"))
+	}
+
+	return res, nil
+}
+
+// expandTemplateLine 展开一行模板中的第一个花括号，并递归展开其余部分
+func expandTemplateLine(line string, lookup func(name string) (string, bool)) ([]string, error) {
+	start := strings.Index(line, "{")
+	if start < 0 {
+		if strings.Contains(line, "}") {
+			return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("unmatched brace in %q", line))
+		}
+		return []string{line}, nil
+	}
+
+	end := strings.Index(line[start:], "}")
+	if end < 0 {
+		return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("unmatched brace in %q", line))
+	}
+	end += start
+
+	inner := line[start+1 : end]
+	var options []string
+	if strings.Contains(inner, ",") {
+		options = strings.Split(inner, ",")
+	} else {
+		value, ok := lookup(inner)
+		if !ok {
+			return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("variable %q is not given", inner))
+		}
+		options = []string{value}
+	}
+
+	rest, err := expandTemplateLine(line[end+1:], lookup)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, 0, len(options)*len(rest))
+	for _, option := range options {
+		for _, suffix := range rest {
+			res = append(res, line[:start]+strings.TrimSpace(option)+suffix)
+			if len(res) > maxTemplateFolders {
+				return nil, ErrIllegalFolderTemplate.WithError(fmt.Errorf("template expands to more than %d folders", maxTemplateFolders))
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// CreateFromTemplate 在 parent 目录下创建模板展开后的目录结构，已存在的目录会被跳过，
+// 返回新创建的目录路径
+func (fs *FileSystem) CreateFromTemplate(ctx context.Context, parent string, paths []string) ([]string, error) {
+	if exist, _ := fs.IsPathExist(parent); !exist {
+		return nil, ErrPathNotExist
+	}
+
+	created := make([]string, 0, len(paths))
+	for _, p := range paths {
+		fullPath := path.Join(parent, p)
+		if exist, _ := fs.IsPathExist(fullPath); exist {
+			continue
+		}
+
+		if _, err := fs.CreateDirectory(ctx, fullPath); err != nil {
+			return created, err
+		}
+		created = append(created, fullPath)
+	}
+
+	return created, nil
+}
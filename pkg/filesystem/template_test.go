@@ -0,0 +1,75 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandFolderTemplate(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 展开与变量替换
+	{
+		res, err := ExpandFolderTemplate("Projects/{name}/{src,docs,assets}\n\n/Shared/", map[string]string{"name": "demo"})
+		asserts.NoError(err)
+		asserts.Equal([]string{
+			"/Projects/demo/src",
+			"/Projects/demo/docs",
+			"/Projects/demo/assets",
+			"/Shared",
+		}, res)
+	}
+
+	// 多组展开
+	{
+		res, err := ExpandFolderTemplate("{a,b}/{1,2}", nil)
+		asserts.NoError(err)
+		asserts.Equal([]string{"/a/1", "/a/2", "/b/1", "/b/2"}, res)
+	}
+
+	// 不超出创建位置
+	{
+		res, err := ExpandFolderTemplate("../../etc", nil)
+		asserts.NoError(err)
+		asserts.Equal([]string{"/etc"}, res)
+	}
+
+	// 未给定变量
+	{
+		_, err := ExpandFolderTemplate("Projects/{name}", nil)
+		asserts.ErrorIs(err, ErrIllegalFolderTemplate)
+	}
+
+	// 变量值非法
+	{
+		_, err := ExpandFolderTemplate("Projects/{name}", map[string]string{"name": "a/b"})
+		asserts.ErrorIs(err, ErrIllegalFolderTemplate)
+	}
+
+	// 括号不匹配
+	{
+		_, err := ExpandFolderTemplate("Projects/{a,b", nil)
+		asserts.ErrorIs(err, ErrIllegalFolderTemplate)
+		_, err = ExpandFolderTemplate("Projects/a}", nil)
+		asserts.ErrorIs(err, ErrIllegalFolderTemplate)
+	}
+
+	// 空模板
+	{
+		_, err := ExpandFolderTemplate("\n /", nil)
+		asserts.ErrorIs(err, ErrIllegalFolderTemplate)
+	}
+
+	// 展开数量过多
+	{
+		_, err := ExpandFolderTemplate("{0,1,2,3,4,5,6,7,8,9}/{0,1,2,3,4,5,6,7,8,9}/{0,1,2,3,4,5,6,7,8,9}", nil)
+		asserts.ErrorIs(err, ErrIllegalFolderTemplate)
+	}
+}
+
+func TestValidateFolderTemplate(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.NoError(ValidateFolderTemplate("Projects/{name}/{src,docs}"))
+	asserts.ErrorIs(ValidateFolderTemplate("Projects/{name"), ErrIllegalFolderTemplate)
+}
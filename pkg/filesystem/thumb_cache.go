@@ -0,0 +1,150 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+     缩略图缓存管理
+   ================
+*/
+
+const (
+	thumbTouchedCachePrefix = "thumb_touched_"
+	thumbCacheBatch         = 100
+)
+
+// touchThumb 记录缩略图被访问，同一缩略图在间隔时间内只记录一次
+func touchThumb(file *model.File) {
+	if model.GetIntSetting("thumb_cache_max_size", 0) <= 0 {
+		return
+	}
+
+	key := fmt.Sprintf("%s%d", thumbTouchedCachePrefix, file.ID)
+	if _, ok := cache.Get(key); ok {
+		return
+	}
+	cache.Set(key, true, model.GetIntSetting("thumb_cache_touch_interval", 3600))
+
+	if err := model.TouchThumbRecord(file.ID); err != nil {
+		util.Log().Debug("Failed to update access time of thumbnail %q: %s", file.Name, err)
+	}
+}
+
+// EvictThumbs 按最近访问时间淘汰缩略图，直至缓存总大小不超过 thumb_cache_max_size，
+// 返回被淘汰的缩略图数量
+func EvictThumbs(ctx context.Context) (int, error) {
+	limit := uint64(model.GetIntSetting("thumb_cache_max_size", 0))
+	if limit == 0 {
+		return 0, nil
+	}
+
+	evicted := 0
+	_, total := model.GetThumbCacheSize(0)
+	for total > limit {
+		records, err := model.ListThumbRecords(0, thumbCacheBatch)
+		if err != nil || len(records) == 0 {
+			return evicted, err
+		}
+
+		toBeEvicted := make([]model.ThumbRecord, 0, len(records))
+		for _, record := range records {
+			if total <= limit {
+				break
+			}
+			toBeEvicted = append(toBeEvicted, record)
+			total -= record.Size
+		}
+
+		if err := removeThumbs(ctx, toBeEvicted); err != nil {
+			return evicted, err
+		}
+		evicted += len(toBeEvicted)
+	}
+
+	return evicted, nil
+}
+
+// PurgeThumbs 删除存储策略下的所有缩略图，policyID 为 0 时删除所有策略，缩略图将在下次访问时重新生成。
+// rebuild 为 true 时同时重置被标记为无法生成的文件，使其重新尝试生成
+func PurgeThumbs(ctx context.Context, policyID uint, rebuild bool) (int, error) {
+	purged := 0
+	for {
+		records, err := model.ListThumbRecords(policyID, thumbCacheBatch)
+		if err != nil {
+			return purged, err
+		}
+		if len(records) == 0 {
+			break
+		}
+
+		if err := removeThumbs(ctx, records); err != nil {
+			return purged, err
+		}
+		purged += len(records)
+	}
+
+	for rebuild {
+		files, err := model.GetFilesWithUnavailableThumb(policyID, thumbCacheBatch)
+		if err != nil {
+			return purged, err
+		}
+
+		for i := range files {
+			if err := files[i].ClearThumb(); err != nil {
+				return purged, err
+			}
+		}
+		rebuild = len(files) == thumbCacheBatch
+	}
+
+	return purged, nil
+}
+
+// removeThumbs 删除缩略图文件及其缓存记录，并清除对应文件的缩略图状态
+func removeThumbs(ctx context.Context, records []model.ThumbRecord) error {
+	fileIDs := make([]uint, 0, len(records))
+	for _, record := range records {
+		fileIDs = append(fileIDs, record.FileID)
+	}
+
+	files, err := model.GetFilesByIDs(fileIDs, 0)
+	if err != nil {
+		return ErrDBListObjects.WithError(err)
+	}
+
+	fs := getEmptyFS()
+	fs.User = &model.User{}
+	defer fs.Recycle()
+
+	for _, group := range fs.GroupFileByPolicy(ctx, files) {
+		fs.Policy = group[0].GetPolicy()
+		if err := fs.DispatchHandler(); err != nil {
+			util.Log().Warning("Failed to dispatch handler for policy %d: %s", fs.Policy.ID, err)
+			continue
+		}
+
+		thumbs := make([]string, 0, len(group))
+		for _, file := range group {
+			thumbs = append(thumbs, file.ThumbFile())
+		}
+
+		if failed, err := fs.Handler.Delete(ctx, thumbs); err != nil {
+			util.Log().Warning("Failed to delete %d thumbnails: %s", len(failed), err)
+		}
+
+		for _, file := range group {
+			if err := file.ClearThumb(); err != nil {
+				util.Log().Warning("Failed to reset thumbnail status of %q: %s", file.Name, err)
+			}
+		}
+	}
+
+	// 文件已被删除的记录也一并清除
+	return model.DeleteThumbRecordsByFileIDs(fileIDs)
+}
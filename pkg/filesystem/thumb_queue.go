@@ -0,0 +1,124 @@
+package filesystem
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+     异步缩略图队列
+   ================
+*/
+
+// thumbJob 异步缩略图生成任务
+type thumbJob struct {
+	user    model.User
+	file    model.File
+	attempt int
+}
+
+var (
+	thumbJobs      chan *thumbJob
+	thumbQueueOnce sync.Once
+	// 已在队列中或正在生成的文件，避免重复入队
+	thumbPending sync.Map
+)
+
+// thumbQueueEnabled 返回是否使用异步队列生成缩略图
+func thumbQueueEnabled() bool {
+	return conf.SystemConfig.Mode == "master" && model.IsTrueVal(model.GetSettingByName("thumb_queue_enabled"))
+}
+
+// initThumbQueue 初始化任务队列并启动工作协程
+func initThumbQueue() {
+	thumbQueueOnce.Do(func() {
+		size := model.GetIntSetting("thumb_queue_size", 1000)
+		if size <= 0 {
+			size = 1000
+		}
+		thumbJobs = make(chan *thumbJob, size)
+
+		maxWorker := model.GetIntSetting("thumb_max_task_count", -1)
+		if maxWorker <= 0 {
+			maxWorker = runtime.GOMAXPROCS(0)
+		}
+		for i := 0; i < maxWorker; i++ {
+			go thumbWorker()
+		}
+		util.Log().Debug("Initialize thumbnails job queue with: Size = %d, WorkerNum = %d", size, maxWorker)
+	})
+}
+
+// enqueueThumb 将文件加入缩略图生成队列，队列已满时返回 ErrThumbQueueFull
+func (fs *FileSystem) enqueueThumb(file *model.File) error {
+	initThumbQueue()
+	if _, pending := thumbPending.LoadOrStore(file.ID, true); pending {
+		return ErrThumbGenerating
+	}
+
+	if !pushThumbJob(&thumbJob{user: *fs.User, file: *file}) {
+		thumbPending.Delete(file.ID)
+		return ErrThumbQueueFull
+	}
+
+	return ErrThumbGenerating
+}
+
+func pushThumbJob(job *thumbJob) bool {
+	select {
+	case thumbJobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func thumbWorker() {
+	for job := range thumbJobs {
+		err := job.run()
+		if err == nil {
+			thumbPending.Delete(job.file.ID)
+			continue
+		}
+
+		job.attempt++
+		if job.attempt > model.GetIntSetting("thumb_queue_retry", 3) {
+			util.Log().Warning("Failed to generate thumbnail for %q after %d attempts: %s", job.file.Name, job.attempt, err)
+			thumbPending.Delete(job.file.ID)
+			continue
+		}
+
+		// 按重试次数退避后重新入队
+		util.Log().Debug("Failed to generate thumbnail for %q, will retry: %s", job.file.Name, err)
+		delay := time.Duration(job.attempt*model.GetIntSetting("thumb_queue_retry_interval", 10)) * time.Second
+		retry := job
+		time.AfterFunc(delay, func() {
+			if !pushThumbJob(retry) {
+				thumbPending.Delete(retry.file.ID)
+			}
+		})
+	}
+}
+
+// run 使用文件所有者的文件系统生成缩略图
+func (job *thumbJob) run() error {
+	fs, err := NewFileSystem(&job.user)
+	if err != nil {
+		return err
+	}
+	defer fs.Recycle()
+
+	file := job.file
+	fs.SetTargetFile(&[]model.File{file})
+	if err := fs.resetPolicyToFirstFile(context.Background()); err != nil {
+		return err
+	}
+
+	return fs.generateThumbnail(context.Background(), &file)
+}
@@ -0,0 +1,99 @@
+package filesystem
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+	 流量统计与限制
+   ================
+*/
+
+// CheckTraffic 检查用户本月剩余的下载流量是否足够下载 size 大小的内容
+func (fs *FileSystem) CheckTraffic(size uint64) error {
+	limit := fs.User.Group.OptionsSerialized.TrafficLimit
+	if fs.User.ID == 0 || limit == 0 {
+		return nil
+	}
+
+	_, used := model.GetMonthlyTraffic(fs.User.ID, time.Now())
+	if used >= limit || used+size > limit {
+		return ErrTrafficExceeded
+	}
+	return nil
+}
+
+// ChargeDownload 检查并计入用户的下载流量，匿名用户不做统计
+func (fs *FileSystem) ChargeDownload(size uint64) error {
+	if err := fs.CheckTraffic(size); err != nil {
+		return err
+	}
+
+	fs.AddTraffic(0, size)
+	return nil
+}
+
+// AddTraffic 计入用户的上传、下载流量，失败时仅记录日志
+func (fs *FileSystem) AddTraffic(upload, download uint64) {
+	if fs.User == nil || fs.User.ID == 0 {
+		return
+	}
+
+	if err := model.AddTraffic(fs.User.ID, upload, download); err != nil {
+		util.Log().Warning("Failed to record traffic of user %d: %s", fs.User.ID, err)
+	}
+}
+
+// HookAddUploadTraffic 上传完成后将 size 计入用户的上传流量
+func HookAddUploadTraffic(size uint64) Hook {
+	return func(ctx context.Context, fs *FileSystem, fileHeader fsctx.FileHeader) error {
+		fs.AddTraffic(size, 0)
+		return nil
+	}
+}
+
+// RangeLength 返回 Range 请求头所请求的字节数，无法解析或包含多个区间时返回完整大小
+func RangeLength(header string, size uint64) uint64 {
+	spec := strings.TrimPrefix(header, "bytes=")
+	if header == "" || spec == header || strings.Contains(spec, ",") {
+		return size
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(spec), "-", 2)
+	if len(parts) != 2 {
+		return size
+	}
+
+	// 后缀区间，如 bytes=-500
+	if parts[0] == "" {
+		suffix, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil || suffix > size {
+			return size
+		}
+		return suffix
+	}
+
+	start, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || start >= size {
+		return size
+	}
+
+	end := size - 1
+	if parts[1] != "" {
+		if end, err = strconv.ParseUint(parts[1], 10, 64); err != nil || end < start {
+			return size
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return end - start + 1
+}
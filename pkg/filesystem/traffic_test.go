@@ -0,0 +1,67 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSystem_CheckTraffic(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	fs.User.ID = 1
+
+	// 未设定上限
+	asserts.NoError(fs.CheckTraffic(100))
+
+	fs.User.Group.OptionsSerialized.TrafficLimit = 100
+
+	// 流量充足
+	mock.ExpectQuery("SELECT(.+)traffics").
+		WillReturnRows(sqlmock.NewRows([]string{"upload", "download"}).AddRow(0, 50))
+	asserts.NoError(fs.CheckTraffic(50))
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	// 超出上限
+	mock.ExpectQuery("SELECT(.+)traffics").
+		WillReturnRows(sqlmock.NewRows([]string{"upload", "download"}).AddRow(0, 50))
+	asserts.Equal(ErrTrafficExceeded, fs.CheckTraffic(51))
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	// 已用尽
+	mock.ExpectQuery("SELECT(.+)traffics").
+		WillReturnRows(sqlmock.NewRows([]string{"upload", "download"}).AddRow(0, 100))
+	asserts.Equal(ErrTrafficExceeded, fs.CheckTraffic(0))
+	asserts.NoError(mock.ExpectationsWereMet())
+
+	// 匿名用户
+	fs.User.ID = 0
+	asserts.NoError(fs.CheckTraffic(1000))
+}
+
+func TestFileSystem_ChargeDownload(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{}}
+	fs.User.ID = 1
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)traffics").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	asserts.NoError(fs.ChargeDownload(10))
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+func TestRangeLength(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.EqualValues(100, RangeLength("", 100))
+	asserts.EqualValues(100, RangeLength("bytes=0-", 100))
+	asserts.EqualValues(10, RangeLength("bytes=0-9", 100))
+	asserts.EqualValues(50, RangeLength("bytes=50-", 100))
+	asserts.EqualValues(20, RangeLength("bytes=-20", 100))
+	asserts.EqualValues(10, RangeLength("bytes=90-200", 100))
+	asserts.EqualValues(100, RangeLength("bytes=0-1,5-6", 100))
+	asserts.EqualValues(100, RangeLength("bytes=200-", 100))
+	asserts.EqualValues(100, RangeLength("items=0-1", 100))
+}
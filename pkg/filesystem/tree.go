@@ -0,0 +1,111 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+)
+
+// TreeCursor 子树列表的分页位置，目录总是排在文件之前
+type TreeCursor struct {
+	// Files 是否已列完目录，进入文件部分
+	Files bool
+	// ID 上一页最后一个对象的ID
+	ID uint
+}
+
+// String 将分页位置编码为游标字符串
+func (cursor TreeCursor) String() string {
+	kind := "d"
+	if cursor.Files {
+		kind = "f"
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(kind + ":" + strconv.FormatUint(uint64(cursor.ID), 10)))
+}
+
+// ParseTreeCursor 解析游标字符串，空游标表示从头开始
+func ParseTreeCursor(s string) (TreeCursor, error) {
+	if s == "" {
+		return TreeCursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return TreeCursor{}, err
+	}
+
+	kind, id, found := strings.Cut(string(raw), ":")
+	if !found || (kind != "d" && kind != "f") {
+		return TreeCursor{}, errors.New("invalid cursor")
+	}
+
+	value, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return TreeCursor{}, err
+	}
+
+	return TreeCursor{Files: kind == "f", ID: uint(value)}, nil
+}
+
+// ListTree 以平铺形式分页列出 root 子树下的所有目录和文件，目录在前、文件在后，
+// 各自按ID升序排列。root 需已通过 TraceRoot 补全路径，没有下一页时返回的游标为 nil
+func (fs *FileSystem) ListTree(ctx context.Context, root *model.Folder, cursor TreeCursor, limit int) ([]serializer.Object, *TreeCursor, error) {
+	folders, err := model.GetRecursiveChildFolder([]uint{root.ID}, fs.User.ID, false)
+	if err != nil {
+		return nil, nil, ErrDBListObjects.WithError(err)
+	}
+
+	// 逐层计算各目录的完整路径
+	paths := map[uint]string{root.ID: path.Join(root.Position, root.Name)}
+	ids := []uint{root.ID}
+	for _, folder := range folders {
+		paths[folder.ID] = path.Join(paths[*folder.ParentID], folder.Name)
+		ids = append(ids, folder.ID)
+	}
+
+	objects := make([]serializer.Object, 0, limit)
+	next := cursor
+	if !cursor.Files {
+		sort.Slice(folders, func(i, j int) bool {
+			return folders[i].ID < folders[j].ID
+		})
+
+		for _, folder := range folders {
+			if folder.ID <= cursor.ID {
+				continue
+			}
+
+			if len(objects) >= limit {
+				return objects, &next, nil
+			}
+
+			objects = append(objects, fs.listObjects(ctx, paths[*folder.ParentID], nil, []model.Folder{folder}, nil)...)
+			next.ID = folder.ID
+		}
+
+		next = TreeCursor{Files: true}
+	}
+
+	files, err := model.GetFilesByParentIDsAfter(ids, fs.User.ID, next.ID, limit-len(objects)+1)
+	if err != nil {
+		return nil, nil, ErrDBListObjects.WithError(err)
+	}
+
+	for _, file := range files {
+		if len(objects) >= limit {
+			return objects, &next, nil
+		}
+
+		objects = append(objects, fs.listObjects(ctx, paths[file.FolderID], []model.File{file}, nil, nil)...)
+		next.ID = file.ID
+	}
+
+	return objects, nil, nil
+}
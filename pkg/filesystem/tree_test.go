@@ -0,0 +1,83 @@
+package filesystem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTreeCursor(t *testing.T) {
+	asserts := assert.New(t)
+
+	cursor, err := ParseTreeCursor("")
+	asserts.NoError(err)
+	asserts.Equal(TreeCursor{}, cursor)
+
+	cursor, err = ParseTreeCursor(TreeCursor{Files: true, ID: 12}.String())
+	asserts.NoError(err)
+	asserts.Equal(TreeCursor{Files: true, ID: 12}, cursor)
+
+	_, err = ParseTreeCursor("invalid")
+	asserts.Error(err)
+}
+
+func TestFileSystem_ListTree(t *testing.T) {
+	asserts := assert.New(t)
+	fs := &FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+	root := &model.Folder{Name: "root", Position: "/"}
+	root.ID = 1
+	ctx := context.Background()
+
+	expectFolders := func() {
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "root"))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(3, "b", 1).AddRow(2, "a", 1))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").WithArgs(1, 3, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}))
+	}
+
+	// 第一页只包含目录，文件留待下一页
+	{
+		expectFolders()
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(1, 1, 3, 2, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(5, "1.txt", 3))
+		objects, next, err := fs.ListTree(ctx, root, TreeCursor{}, 2)
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Len(objects, 2)
+		asserts.Equal("a", objects[0].Name)
+		asserts.Equal("/root", objects[0].Path)
+		asserts.Equal(&TreeCursor{Files: true}, next)
+	}
+
+	// 最后一页
+	{
+		expectFolders()
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(1, 1, 3, 2, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(6, "2.txt", 1))
+		objects, next, err := fs.ListTree(ctx, root, TreeCursor{Files: true, ID: 5}, 2)
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Len(objects, 1)
+		asserts.Nil(next)
+	}
+
+	// 目录之后继续列出文件
+	{
+		expectFolders()
+		mock.ExpectQuery("SELECT(.+)files(.+)").WithArgs(1, 1, 3, 2, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(5, "1.txt", 3).AddRow(6, "2.txt", 1))
+		objects, next, err := fs.ListTree(ctx, root, TreeCursor{ID: 2}, 2)
+		asserts.NoError(err)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Len(objects, 2)
+		asserts.Equal("b", objects[0].Name)
+		asserts.Equal("/root/b", objects[1].Path)
+		asserts.Equal(&TreeCursor{Files: true, ID: 5}, next)
+	}
+}
@@ -30,6 +30,33 @@ const (
 
 // Upload 上传文件
 func (fs *FileSystem) Upload(ctx context.Context, file *fsctx.FileStream) (err error) {
+	// 更新已有文件的内容时检查保留冻结和文件锁
+	if originFile, ok := ctx.Value(fsctx.FileModelCtx).(model.File); ok {
+		if err = fs.CheckRetention(nil, []uint{originFile.ID}); err != nil {
+			request.BlackHole(file)
+			return err
+		}
+		if err = fs.CheckLocks(ctx, nil, []uint{originFile.ID}); err != nil {
+			request.BlackHole(file)
+			return err
+		}
+	}
+
+	// 新文件位于挂载的外部存储中时使用挂载的存储策略
+	if _, ok := ctx.Value(fsctx.FileModelCtx).(model.File); !ok && file.SavePath == "" {
+		if err = fs.SwitchToMountOf(file.VirtualPath); err != nil {
+			request.BlackHole(file)
+			return err
+		}
+	}
+
+	event := &Event{Op: OpUpload, Upload: file}
+	if err = fs.emitBefore(ctx, event); err != nil {
+		request.BlackHole(file)
+		return err
+	}
+	defer func() { fs.emitAfter(ctx, event, err) }()
+
 	// 上传前的钩子
 	err = fs.Trigger(ctx, "BeforeUpload", file)
 	if err != nil {
@@ -54,11 +81,28 @@ func (fs *FileSystem) Upload(ctx context.Context, file *fsctx.FileStream) (err e
 		// 处理客户端未完成上传时，关闭连接
 		go fs.CancelUpload(ctx, savePath, file)
 
+		// 完整上传的文件同步计算内容哈希
+		var checksum *Checksum
+		if file.Mode&fsctx.Append != fsctx.Append && file.File != nil {
+			checksum = NewChecksum()
+			file.File = NewChecksumReader(file.File, checksum)
+		}
+
 		err = fs.Handler.Put(ctx, file)
 		if err != nil {
 			fs.Trigger(ctx, "AfterUploadFailed", file)
 			return err
 		}
+
+		// 存储端重复读取文件时计算结果无效
+		if checksum != nil && checksum.Size == file.Size {
+			if file.Metadata == nil {
+				file.Metadata = make(map[string]string)
+			}
+			for key, sum := range checksum.Sums() {
+				file.Metadata[key] = sum
+			}
+		}
 	}
 
 	// 上传完成后的钩子
@@ -82,10 +126,16 @@ func (fs *FileSystem) Upload(ctx context.Context, file *fsctx.FileStream) (err e
 // TODO 完善测试
 func (fs *FileSystem) GenerateSavePath(ctx context.Context, file fsctx.FileHeader) string {
 	fileInfo := file.Info()
+	virtualPath := fileInfo.VirtualPath
+	// 挂载的外部存储中，文件按相对于挂载点的路径存放
+	if fs.Policy.IsMounted() && fs.mountRoot != "" {
+		virtualPath = fs.mountRelative(virtualPath)
+	}
+
 	return path.Join(
 		fs.Policy.GeneratePath(
 			fs.User.Model.ID,
-			fileInfo.VirtualPath,
+			virtualPath,
 		),
 		fs.Policy.GenerateFileName(
 			fs.User.Model.ID,
@@ -134,6 +184,21 @@ func (fs *FileSystem) CreateUploadSession(ctx context.Context, file *fsctx.FileS
 	callbackKey := uuid.Must(uuid.NewV4()).String()
 	fileSize := file.Size
 
+	// 处理重名冲突
+	mode, _ := ctx.Value(fsctx.ConflictModeCtx).(string)
+	replace, err := fs.resolveUploadConflict(file, mode)
+	if err != nil {
+		return nil, err
+	}
+	if replace != nil {
+		if err := fs.CheckRetention(nil, []uint{replace.ID}); err != nil {
+			return nil, err
+		}
+		if err := fs.CheckLocks(ctx, nil, []uint{replace.ID}); err != nil {
+			return nil, err
+		}
+	}
+
 	// 创建占位的文件，同时校验文件信息
 	file.Mode = fsctx.Nop
 	if callbackKey != "" {
@@ -142,6 +207,7 @@ func (fs *FileSystem) CreateUploadSession(ctx context.Context, file *fsctx.FileS
 
 	fs.Use("BeforeUpload", HookValidateFile)
 	fs.Use("BeforeUpload", HookValidateCapacity)
+	fs.Use("BeforeUpload", HookValidateFolderQuota)
 
 	// 验证文件规格
 	if err := fs.Upload(ctx, file); err != nil {
@@ -158,6 +224,10 @@ func (fs *FileSystem) CreateUploadSession(ctx context.Context, file *fsctx.FileS
 		SavePath:       file.SavePath,
 		LastModified:   file.LastModified,
 		CallbackSecret: util.RandStringRunes(32),
+		SpeedLimit:     fs.User.Group.OptionsSerialized.UploadSpeedLimit,
+	}
+	if replace != nil {
+		uploadSession.Replace = replace.ID
 	}
 
 	// 获取上传凭证
@@ -166,12 +236,30 @@ func (fs *FileSystem) CreateUploadSession(ctx context.Context, file *fsctx.FileS
 		return nil, err
 	}
 
+	// 记录存储端发起的分片上传，未完成时由定时任务取消
+	if uploadSession.UploadID != "" {
+		multipart := &model.MultipartUpload{
+			PolicyID:  fs.Policy.ID,
+			UserID:    fs.User.ID,
+			SessionID: callbackKey,
+			UploadID:  uploadSession.UploadID,
+			SavePath:  uploadSession.SavePath,
+		}
+		if err := multipart.Create(); err != nil {
+			util.Log().Warning("Failed to record multipart upload %q: %s", uploadSession.UploadID, err)
+		}
+	}
+
 	// 创建占位符
 	if !fs.Policy.IsUploadPlaceholderWithSize() {
 		fs.Use("AfterUpload", HookClearFileHeaderSize)
 	}
 	fs.Use("AfterUpload", GenericAfterUpload)
 	ctx = context.WithValue(ctx, fsctx.IgnoreDirectoryConflictCtx, true)
+	if replace != nil {
+		// 覆盖上传时占位文件使用临时文件名，上传完成后合并至原文件
+		file.Name = placeholderName(callbackKey, file.Name)
+	}
 	if err := fs.Upload(ctx, file); err != nil {
 		return nil, err
 	}
@@ -188,6 +276,7 @@ func (fs *FileSystem) CreateUploadSession(ctx context.Context, file *fsctx.FileS
 
 	// 补全上传凭证其他信息
 	credential.Expires = time.Now().Add(time.Duration(callBackSessionTTL) * time.Second).Unix()
+	credential.Name = uploadSession.Name
 
 	return credential, nil
 }
@@ -208,6 +297,7 @@ func (fs *FileSystem) UploadFromStream(ctx context.Context, file *fsctx.FileStre
 	if fs.Hooks == nil {
 		fs.Use("BeforeUpload", HookValidateFile)
 		fs.Use("BeforeUpload", HookValidateCapacity)
+		fs.Use("BeforeUpload", HookValidateFolderQuota)
 		fs.Use("AfterUploadCanceled", HookDeleteTempFile)
 		fs.Use("AfterUpload", GenericAfterUpload)
 		fs.Use("AfterValidateFailed", HookDeleteTempFile)
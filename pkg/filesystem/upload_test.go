@@ -92,8 +92,10 @@ func TestFileSystem_Upload(t *testing.T) {
 		VirtualPath: "/",
 		Name:        "1.txt",
 	}
+	expectNoMount()
 	err := fs.Upload(ctx, file)
 	asserts.NoError(err)
+	asserts.NoError(mock.ExpectationsWereMet())
 
 	// 正常，上下文已指定源文件
 	testHandler = new(FileHeaderMock)
@@ -122,6 +124,8 @@ func TestFileSystem_Upload(t *testing.T) {
 		Name:        "1.txt",
 		File:        ioutil.NopCloser(strings.NewReader("")),
 	}
+	expectNoRetention()
+	expectNoLocks()
 	err = fs.Upload(ctx, file)
 	asserts.NoError(err)
 
@@ -129,6 +133,8 @@ func TestFileSystem_Upload(t *testing.T) {
 	fs.Use("BeforeUpload", func(ctx context.Context, fs *FileSystem, file fsctx.FileHeader) error {
 		return errors.New("error")
 	})
+	expectNoRetention()
+	expectNoLocks()
 	err = fs.Upload(ctx, file)
 	asserts.Error(err)
 	fs.Hooks["BeforeUpload"] = nil
@@ -138,6 +144,8 @@ func TestFileSystem_Upload(t *testing.T) {
 	testHandler2 := new(FileHeaderMock)
 	testHandler2.On("Put", testMock.Anything, testMock.Anything).Return(errors.New("error"))
 	fs.Handler = testHandler2
+	expectNoRetention()
+	expectNoLocks()
 	err = fs.Upload(ctx, file)
 	asserts.Error(err)
 	testHandler2.AssertExpectations(t)
@@ -152,6 +160,8 @@ func TestFileSystem_Upload(t *testing.T) {
 	fs.Use("AfterValidateFailed", func(ctx context.Context, fs *FileSystem, file fsctx.FileHeader) error {
 		return errors.New("error")
 	})
+	expectNoRetention()
+	expectNoLocks()
 	err = fs.Upload(ctx, file)
 	asserts.Error(err)
 	testHandler2.AssertExpectations(t)
@@ -174,6 +184,7 @@ func TestFileSystem_GetUploadToken(t *testing.T) {
 		testHandler := new(FileHeaderMock)
 		testHandler.On("Token", testMock.Anything, int64(10), testMock.Anything, testMock.Anything).Return(&serializer.UploadCredential{Credential: "test"}, nil)
 		fs.Handler = testHandler
+		expectNoMount()
 		mock.ExpectQuery("SELECT(.+)").
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(1, 1))
@@ -202,6 +213,7 @@ func TestFileSystem_GetUploadToken(t *testing.T) {
 		testHandler := new(FileHeaderMock)
 		testHandler.On("Token", testMock.Anything, int64(10), testMock.Anything, testMock.Anything).Return(&serializer.UploadCredential{}, errors.New("error"))
 		fs.Handler = testHandler
+		expectNoMount()
 		mock.ExpectQuery("SELECT(.+)").
 			WithArgs(1).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id"}).AddRow(1, 1))
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 )
 
@@ -12,6 +13,16 @@ import (
    ==========
 */
 
+// 在线预览类型，用户组可单独禁用
+const (
+	PreviewVideo  = "video"
+	PreviewOffice = "office"
+	PreviewText   = "text"
+)
+
+// 按视频方式预览的扩展名
+var videoExtensions = []string{"mp4", "webm", "ogv", "m4v", "mov", "mkv", "flv", "avi", "wmv", "3gp"}
+
 // 文件/路径名保留字符
 var reservedCharacter = []string{"\\", "?", "*", "<", "\"", ":", ">", "/", "|"}
 
@@ -55,6 +66,40 @@ func (fs *FileSystem) ValidateCapacity(ctx context.Context, size uint64) bool {
 	return fs.User.IncreaseStorage(size)
 }
 
+// ValidateFolderQuota 验证向 folder 新增 size 大小的内容后，folder 及其所有上级目录是否仍在容量上限内。
+// 调用方应先通过 model.HasFolderQuota 确认用户设定了目录容量上限，以免无谓的查询
+func (fs *FileSystem) ValidateFolderQuota(ctx context.Context, folder *model.Folder, size uint64) error {
+	if folder == nil || size == 0 {
+		return nil
+	}
+
+	current := folder
+	for i := 0; i < 65535; i++ {
+		if current.Quota > 0 {
+			used, err := current.TotalSize()
+			if err != nil {
+				return ErrDBListObjects.WithError(err)
+			}
+
+			if used+size > current.Quota {
+				return ErrFolderQuotaExceeded
+			}
+		}
+
+		if current.ParentID == nil {
+			break
+		}
+
+		parents, err := model.GetFoldersByIDs([]uint{*current.ParentID}, current.OwnerID)
+		if err != nil || len(parents) == 0 {
+			break
+		}
+		current = &parents[0]
+	}
+
+	return nil
+}
+
 // ValidateExtension 验证文件扩展名
 func (fs *FileSystem) ValidateExtension(ctx context.Context, fileName string) bool {
 	// 不需要验证
@@ -64,3 +109,25 @@ func (fs *FileSystem) ValidateExtension(ctx context.Context, fileName string) bo
 
 	return util.IsInExtensionList(fs.Policy.OptionsSerialized.FileType, fileName)
 }
+
+// ValidatePreview 验证用户组是否允许在线预览/编辑指定类型、大小的文件，previewType 为空时只验证大小
+func (fs *FileSystem) ValidatePreview(previewType string, size uint64) error {
+	options := fs.User.Group.OptionsSerialized
+	if previewType != "" && util.ContainsString(options.PreviewDisabled, previewType) {
+		return ErrPreviewDisabled
+	}
+
+	if options.PreviewMaxSize > 0 && size > options.PreviewMaxSize {
+		return ErrFileSizeTooBig
+	}
+
+	return nil
+}
+
+// PreviewTypeOf 根据文件名判断通用预览接口的预览类型，无法归类时返回空
+func PreviewTypeOf(name string) string {
+	if util.IsInExtensionList(videoExtensions, name) {
+		return PreviewVideo
+	}
+	return ""
+}
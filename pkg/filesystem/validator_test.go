@@ -110,3 +110,59 @@ func TestFileSystem_ValidateExtension(t *testing.T) {
 	asserts.True(fs.ValidateExtension(ctx, "1.png.jpG"))
 	asserts.False(fs.ValidateExtension(ctx, "1.png"))
 }
+
+func TestFileSystem_ValidateFolderQuota(t *testing.T) {
+	asserts := assert.New(t)
+	ctx := context.Background()
+	fs := FileSystem{User: &model.User{Model: gorm.Model{ID: 1}}}
+	parentID := uint(1)
+	folder := &model.Folder{ParentID: &parentID, OwnerID: 1}
+	folder.ID = 2
+
+	// 无需验证
+	asserts.NoError(fs.ValidateFolderQuota(ctx, nil, 10))
+	asserts.NoError(fs.ValidateFolderQuota(ctx, folder, 0))
+
+	// 上级目录超出上限
+	{
+		mock.ExpectQuery("SELECT(.+)folders").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "quota"}).AddRow(1, 1, 100))
+		mock.ExpectQuery("SELECT(.+)folders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("SELECT(.+)folders").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT(.+)files").WillReturnRows(sqlmock.NewRows([]string{"size"}).AddRow(95))
+		asserts.Equal(ErrFolderQuotaExceeded, fs.ValidateFolderQuota(ctx, folder, 10))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 未超出上限
+	{
+		mock.ExpectQuery("SELECT(.+)folders").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "quota"}).AddRow(1, 1, 100))
+		mock.ExpectQuery("SELECT(.+)folders").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("SELECT(.+)folders").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT(.+)files").WillReturnRows(sqlmock.NewRows([]string{"size"}).AddRow(50))
+		asserts.NoError(fs.ValidateFolderQuota(ctx, folder, 10))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestFileSystem_ValidatePreview(t *testing.T) {
+	asserts := assert.New(t)
+	fs := FileSystem{User: &model.User{}}
+
+	// 未设置限制
+	asserts.NoError(fs.ValidatePreview(PreviewVideo, 1024))
+
+	// 超出大小限制
+	fs.User.Group.OptionsSerialized.PreviewMaxSize = 10
+	asserts.NoError(fs.ValidatePreview("", 10))
+	asserts.Equal(ErrFileSizeTooBig, fs.ValidatePreview(PreviewText, 11))
+
+	// 类型被禁用
+	fs.User.Group.OptionsSerialized.PreviewDisabled = []string{PreviewOffice}
+	asserts.Equal(ErrPreviewDisabled, fs.ValidatePreview(PreviewOffice, 1))
+	asserts.NoError(fs.ValidatePreview(PreviewText, 1))
+
+	asserts.Equal(PreviewVideo, PreviewTypeOf("a.MP4"))
+	asserts.Equal("", PreviewTypeOf("a.png"))
+}
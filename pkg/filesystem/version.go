@@ -0,0 +1,137 @@
+package filesystem
+
+import (
+	"context"
+	"path"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+/* ================
+	 文件历史版本
+   ================
+*/
+
+// SaveNewVersion 将 stream 写入为文件 file 的新内容。新内容保存为新的物理文件，
+// 被替换的物理文件保留为历史版本并继续计入用户容量，超出数量上限的最早版本会被删除。
+// 此方法会为文件系统挂载钩子，调用方应使用单独的文件系统实例
+func (fs *FileSystem) SaveNewVersion(ctx context.Context, file *model.File, stream *fsctx.FileStream, author string) error {
+	origin := *file
+	stream.Name = file.Name
+	stream.Mode &= ^fsctx.Overwrite
+
+	// 在原存储策略下生成新的物理文件路径
+	fs.Policy = file.GetPolicy()
+	if err := fs.DispatchHandler(); err != nil {
+		return err
+	}
+	updated := *file
+	updated.SourceName = fs.GenerateSavePath(ctx, stream)
+	if updated.SourceName == origin.SourceName {
+		// 命名规则未包含随机部分时，避免覆盖原物理文件
+		dir, name := path.Split(updated.SourceName)
+		updated.SourceName = path.Join(dir, util.RandStringRunes(8)+"_"+name)
+	}
+
+	fs.Use("BeforeUpload", HookValidateFile)
+	fs.Use("BeforeUpload", HookValidateCapacity)
+	fs.Use("AfterUpload", GenericAfterUpdate)
+	fs.Use("AfterUpload", HookUpdateSourceName)
+	fs.Use("AfterUploadCanceled", HookDeleteTempFile)
+	fs.Use("AfterValidateFailed", HookDeleteTempFile)
+
+	ctx = context.WithValue(ctx, fsctx.FileModelCtx, updated)
+	if err := fs.Upload(ctx, stream); err != nil {
+		return err
+	}
+
+	// 原物理文件保留为历史版本
+	version := &model.FileVersion{
+		FileID:     origin.ID,
+		UserID:     origin.UserID,
+		Size:       origin.Size,
+		SourceName: origin.SourceName,
+		PolicyID:   origin.PolicyID,
+		Author:     author,
+	}
+	if err := version.Create(); err != nil {
+		return ErrInsertFileRecord.WithError(err)
+	}
+	fs.User.IncreaseStorageWithoutCheck(origin.Size)
+
+	file.SourceName = updated.SourceName
+	file.Size = stream.Size
+	fs.pruneVersions(ctx, origin.ID)
+	return nil
+}
+
+// RestoreVersion 将文件恢复为指定的历史版本，当前内容会保存为新的历史版本
+func (fs *FileSystem) RestoreVersion(ctx context.Context, file *model.File, version *model.FileVersion) error {
+	handler, err := fs.versionHandler(version)
+	if err != nil {
+		return err
+	}
+
+	rs, err := handler.Handler.Get(ctx, version.SourceName)
+	if err != nil {
+		return ErrIO.WithError(err)
+	}
+	defer rs.Close()
+
+	return fs.SaveNewVersion(ctx, file, &fsctx.FileStream{
+		File:   rs,
+		Seeker: rs,
+		Size:   version.Size,
+	}, fs.User.Nick)
+}
+
+// pruneVersions 删除超出数量上限的最早版本
+func (fs *FileSystem) pruneVersions(ctx context.Context, fileID uint) {
+	max := model.GetIntSetting("file_version_max", 10)
+	versions := model.ListFileVersions(fileID)
+	if len(versions) <= max {
+		return
+	}
+
+	fs.DeleteVersions(ctx, versions[max:], false)
+}
+
+// DeleteVersions 删除历史版本记录，unlink 为 false 时同时删除不再被引用的物理文件
+func (fs *FileSystem) DeleteVersions(ctx context.Context, versions []model.FileVersion, unlink bool) {
+	for i := range versions {
+		if err := versions[i].Delete(); err != nil {
+			util.Log().Warning("Failed to delete file version %d: %s", versions[i].ID, err)
+			continue
+		}
+		fs.User.DeductionStorage(versions[i].Size)
+
+		if unlink || model.IsSourceNameUsed(versions[i].PolicyID, versions[i].SourceName, versions[i].ID) {
+			continue
+		}
+
+		handler, err := fs.versionHandler(&versions[i])
+		if err != nil {
+			util.Log().Warning("Failed to delete physical file of version %d: %s", versions[i].ID, err)
+			continue
+		}
+		if _, err := handler.Handler.Delete(ctx, []string{versions[i].SourceName}); err != nil {
+			util.Log().Warning("Failed to delete physical file of version %d: %s", versions[i].ID, err)
+		}
+	}
+}
+
+// versionHandler 返回使用历史版本所在存储策略的文件系统
+func (fs *FileSystem) versionHandler(version *model.FileVersion) (*FileSystem, error) {
+	policy, err := model.GetPolicyByID(version.PolicyID)
+	if err != nil {
+		return nil, ErrObjectNotExist.WithError(err)
+	}
+
+	handler := &FileSystem{User: fs.User, Policy: &policy}
+	if err := handler.DispatchHandler(); err != nil {
+		return nil, err
+	}
+	return handler, nil
+}
@@ -0,0 +1,238 @@
+package health
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gob.Register(Component{})
+}
+
+// 组件及整体状态
+const (
+	StatusOK = "ok"
+	// StatusDegraded 非关键组件异常，站点仍可提供服务
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// policyProbePrefix 存储策略探测结果的缓存键前缀
+const policyProbePrefix = "health_policy_"
+
+// Component 单个依赖组件的检查结果
+type Component struct {
+	Status string `json:"status"`
+	// Critical 关键组件异常时当前实例不能提供服务
+	Critical  bool      `json:"critical"`
+	Latency   int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report 健康检查报告
+type Report struct {
+	Status     string               `json:"status"`
+	Version    string               `json:"version"`
+	Components map[string]Component `json:"components"`
+}
+
+// Healthy 返回关键组件是否均正常
+func (report *Report) Healthy() bool {
+	return report.Status != StatusDown
+}
+
+// Liveness 检查数据库和缓存，用于判断进程是否需要重启
+func Liveness(ctx context.Context) *Report {
+	report := newReport()
+	report.add("database", checkDatabase(ctx))
+	report.add("cache", checkCache())
+	return report.summarize()
+}
+
+// Readiness 在 Liveness 的基础上检查存储策略和离线下载节点，用于负载均衡判断实例能否接收流量。
+// 存储策略与离线下载节点为非关键组件，异常时整体状态为 degraded
+func Readiness(ctx context.Context) *Report {
+	report := newReport()
+	report.add("database", checkDatabase(ctx))
+	report.add("cache", checkCache())
+	if report.Components["database"].Status == StatusOK {
+		for name, component := range checkPolicies(ctx) {
+			report.add(name, component)
+		}
+	}
+	for name, component := range checkAria2() {
+		report.add(name, component)
+	}
+	return report.summarize()
+}
+
+func newReport() *Report {
+	version := conf.BackendVersion
+	if conf.IsPro == "true" {
+		version += "-pro"
+	}
+
+	return &Report{
+		Version:    version,
+		Components: make(map[string]Component),
+	}
+}
+
+func (report *Report) add(name string, component Component) {
+	// 生产环境隐藏底层报错
+	if gin.Mode() == gin.ReleaseMode {
+		component.Error = ""
+	}
+	report.Components[name] = component
+}
+
+// summarize 根据各组件状态得出整体状态
+func (report *Report) summarize() *Report {
+	report.Status = StatusOK
+	for _, component := range report.Components {
+		if component.Status == StatusOK {
+			continue
+		}
+		if component.Critical {
+			report.Status = StatusDown
+			return report
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+// probe 执行检查并记录耗时
+func probe(critical bool, check func() error) Component {
+	start := time.Now()
+	err := check()
+	component := Component{
+		Status:    StatusOK,
+		Critical:  critical,
+		Latency:   time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	return component
+}
+
+func probeTimeout() time.Duration {
+	return time.Duration(model.GetIntSetting("health_probe_timeout", 10)) * time.Second
+}
+
+func checkDatabase(ctx context.Context) Component {
+	return probe(true, func() error {
+		if model.DB == nil {
+			return fmt.Errorf("database is not initialized")
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, probeTimeout())
+		defer cancel()
+		return model.DB.DB().PingContext(ctx)
+	})
+}
+
+func checkCache() Component {
+	return probe(true, func() error {
+		// 每次探测使用独立的键，并发探测之间互不覆盖
+		nonce := util.RandStringRunes(16)
+		if err := cache.Set("health_probe_"+nonce, nonce, 10); err != nil {
+			return err
+		}
+		defer cache.Deletes([]string{nonce}, "health_probe_")
+
+		if value, ok := cache.Get("health_probe_" + nonce); !ok || value != nonce {
+			return fmt.Errorf("cache value mismatch")
+		}
+		return nil
+	})
+}
+
+// policyProbeLock 避免缓存过期时多个请求同时探测存储策略
+var policyProbeLock sync.Mutex
+
+// checkPolicies 使用存储策略的凭证列取根目录，结果缓存 health_probe_ttl 秒。
+// 本机存储策略及用户挂载的外部存储不做检查，后者的可用性不影响站点本身
+func checkPolicies(ctx context.Context) map[string]Component {
+	var policies []model.Policy
+	// 挂载策略的设置中包含非零的 mount_owner，在查询时排除，也避免其凭证解密失败影响整个查询
+	if err := model.DB.Where("type <> ? and options not like ?", "local", `%"mount_owner":%`).Find(&policies).Error; err != nil {
+		util.Log().Warning("Failed to list storage policies for health check: %s", err)
+		return nil
+	}
+
+	res := make(map[string]Component, len(policies))
+	for i := range policies {
+		res[fmt.Sprintf("policy_%d", policies[i].ID)] = checkPolicy(ctx, &policies[i])
+	}
+	return res
+}
+
+func checkPolicy(ctx context.Context, policy *model.Policy) Component {
+	key := fmt.Sprintf("%s%d", policyProbePrefix, policy.ID)
+	if cached, ok := cache.Get(key); ok {
+		return cached.(Component)
+	}
+
+	policyProbeLock.Lock()
+	defer policyProbeLock.Unlock()
+	if cached, ok := cache.Get(key); ok {
+		return cached.(Component)
+	}
+
+	component := probe(false, func() error {
+		fs, err := filesystem.NewAnonymousFileSystem()
+		if err != nil {
+			return err
+		}
+		defer fs.Recycle()
+
+		fs.Policy = policy
+		if err := fs.DispatchHandler(); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, probeTimeout())
+		defer cancel()
+		_, err = fs.Handler.List(ctx, "", false)
+		return err
+	})
+
+	if err := cache.Set(key, component, model.GetIntSetting("health_probe_ttl", 300)); err != nil {
+		util.Log().Warning("Failed to cache health probe result of policy %d: %s", policy.ID, err)
+	}
+	return component
+}
+
+// checkAria2 返回离线下载节点的健康状态，由定时任务 cron_aria2_health_check 更新
+func checkAria2() map[string]Component {
+	nodes := cluster.GetAria2Health()
+	res := make(map[string]Component, len(nodes))
+	for _, node := range nodes {
+		component := Component{
+			Status:    StatusOK,
+			Error:     node.LastError,
+			CheckedAt: node.CheckedAt,
+		}
+		if !node.Healthy {
+			component.Status = StatusDown
+		}
+		res[fmt.Sprintf("aria2_%d", node.NodeID)] = component
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+var mock sqlmock.Sqlmock
+
+func TestMain(m *testing.M) {
+	var db *sql.DB
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		panic("An error was not expected when opening a stub database connection")
+	}
+	model.DB, _ = gorm.Open("mysql", db)
+	defer db.Close()
+	m.Run()
+}
+
+func TestReport_summarize(t *testing.T) {
+	a := assert.New(t)
+
+	// 全部正常
+	report := newReport()
+	report.add("database", Component{Status: StatusOK, Critical: true})
+	report.add("policy_1", Component{Status: StatusOK})
+	a.Equal(StatusOK, report.summarize().Status)
+	a.True(report.Healthy())
+
+	// 非关键组件异常
+	report.add("policy_1", Component{Status: StatusDown})
+	a.Equal(StatusDegraded, report.summarize().Status)
+	a.True(report.Healthy())
+
+	// 关键组件异常
+	report.add("cache", Component{Status: StatusDown, Critical: true})
+	a.Equal(StatusDown, report.summarize().Status)
+	a.False(report.Healthy())
+}
+
+func TestLiveness(t *testing.T) {
+	a := assert.New(t)
+
+	report := Liveness(context.Background())
+	a.Equal(StatusOK, report.Status)
+	a.Contains(report.Components, "database")
+	a.Contains(report.Components, "cache")
+}
+
+func TestReadiness(t *testing.T) {
+	a := assert.New(t)
+	a.NoError(cache.Set("setting_aria2_unhealthy_threshold", "1", 0))
+
+	// 存储策略使用缓存的探测结果，离线下载节点异常
+	a.NoError(cache.Set(policyProbePrefix+"1", Component{Status: StatusDown, Error: "denied"}, 0))
+	cluster.MarkAria2Failure(5, errors.New("connection refused"))
+	mock.ExpectQuery("SELECT(.+)policies(.+)").
+		WithArgs("local", `%"mount_owner":%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(1, "s3"))
+	report := Readiness(context.Background())
+	a.NoError(mock.ExpectationsWereMet())
+	a.Equal(StatusDegraded, report.Status)
+	a.Equal(StatusDown, report.Components["policy_1"].Status)
+	a.Equal("denied", report.Components["policy_1"].Error)
+	a.Equal(StatusDown, report.Components["aria2_5"].Status)
+	a.True(report.Healthy())
+
+	cluster.MarkAria2Success(5)
+}
@@ -0,0 +1,105 @@
+package i18n
+
+// catalogEnUS 英文消息目录
+var catalogEnUS = map[string]string{
+	"not_fully_success":              "Some operations were not completed.",
+	"check_login":                    "Login required.",
+	"no_permission":                  "You do not have permission to access this resource.",
+	"not_found":                      "Resource not found.",
+	"too_many_requests":              "Too many requests, please try again later.",
+	"conflict":                       "Resource conflict.",
+	"upload_failed":                  "Upload failed.",
+	"create_folder_failed":           "Failed to create folder.",
+	"object_exist":                   "Object already exists.",
+	"sign_expired":                   "Signature expired.",
+	"policy_not_allowed":             "Operation not allowed by the current storage policy.",
+	"group_not_allowed":              "Your user group cannot perform this action.",
+	"admin_required":                 "Administrator privileges required.",
+	"master_not_found":               "Master node is not registered.",
+	"upload_session_expired":         "Upload session expired.",
+	"invalid_chunk_index":            "Invalid chunk index.",
+	"invalid_content_length":         "Invalid Content-Length.",
+	"batch_source_size":              "Too many files to get source links at once.",
+	"batch_aria2_size":               "Too many offline download tasks at once.",
+	"parent_not_exist":               "Parent folder does not exist.",
+	"user_banned":                    "This account has been banned.",
+	"user_not_activated":             "This account is not activated.",
+	"feature_not_enabled":            "This feature is not enabled.",
+	"credential_invalid":             "Invalid credentials.",
+	"user_not_found":                 "User not found.",
+	"2fa_code_error":                 "Incorrect two-factor verification code.",
+	"login_session_not_exist":        "Login session does not exist.",
+	"webauthn_init_failed":           "Failed to initialize WebAuthn.",
+	"webauthn_credential_error":      "Invalid WebAuthn credential.",
+	"captcha_error":                  "Incorrect captcha.",
+	"captcha_refresh_needed":         "Captcha expired, please refresh it.",
+	"failed_send_email":              "Failed to send email.",
+	"invalid_temp_link":              "Invalid link.",
+	"temp_link_expired":              "Link expired.",
+	"email_existed":                  "Email already in use.",
+	"email_sent":                     "Activation email has been resent.",
+	"user_cannot_activate":           "This account cannot be activated.",
+	"policy_not_exist":               "Storage policy does not exist.",
+	"delete_default_policy":          "The default storage policy cannot be deleted.",
+	"policy_used_by_files":           "There are still files using this storage policy.",
+	"policy_used_by_groups":          "This storage policy is still used by user groups.",
+	"group_not_found":                "User group not found.",
+	"invalid_action_on_system_group": "This action cannot be performed on a system user group.",
+	"group_used_by_user":             "This user group is still in use.",
+	"change_group_for_default_user":  "The user group of the initial user cannot be changed.",
+	"invalid_action_on_default_user": "This action cannot be performed on the initial user.",
+	"file_not_found":                 "File not found.",
+	"list_files_error":               "Failed to list files.",
+	"invalid_action_on_system_node":  "This action cannot be performed on the system node.",
+	"create_fs_error":                "Failed to create file system.",
+	"create_task_error":              "Failed to create task.",
+	"file_too_large":                 "File is too large.",
+	"file_type_not_allowed":          "File type not allowed.",
+	"insufficient_capacity":          "Insufficient storage capacity.",
+	"illegal_object_name":            "Invalid file or folder name.",
+	"root_protected":                 "This action cannot be performed on the root folder.",
+	"conflict_upload_ongoing":        "A file with the same name is being uploaded.",
+	"meta_mismatch":                  "File information mismatch.",
+	"unsupported_archive_type":       "Unsupported archive format.",
+	"policy_changed":                 "Available storage policies have changed, please refresh the page.",
+	"share_link_not_found":           "Share link is invalid or expired.",
+	"save_own_share":                 "You cannot save your own share.",
+	"slave_ping_master":              "Slave node cannot reach the master node.",
+	"version_mismatch":               "Cloudreve version mismatch.",
+	"insufficient_credit":            "Insufficient credits.",
+	"group_conflict":                 "User group conflict.",
+	"group_invalid":                  "You are already in this user group.",
+	"invalid_gift_code":              "Invalid gift code.",
+	"qq_bind_conflict":               "A QQ account is already linked.",
+	"qq_bind_other_account":          "This QQ account is linked to another account.",
+	"qq_not_linked":                  "No account is linked to this QQ account.",
+	"incorrect_password":             "Incorrect password.",
+	"disabled_share_preview":         "Preview is disabled for this share.",
+	"invalid_sign":                   "Invalid signature.",
+	"network_restricted":             "Access from the current network is restricted.",
+	"password_too_weak":              "Password does not meet the password policy.",
+	"password_breached":              "This password has appeared in a known data breach.",
+	"file_quarantined":               "This file has been quarantined for security reasons.",
+	"file_blocked":                   "This file has been blocked due to prohibited content.",
+	"user_pending_deletion":          "This account is pending deletion.",
+	"invalid_invite_code":            "Invalid invite code.",
+	"user_pending_approval":          "This account is waiting for administrator approval.",
+	"invalid_share_slug":             "Invalid custom share link.",
+	"share_traffic_exceeded":         "Traffic quota of this share is exhausted.",
+	"thumb_generating":               "Thumbnail is being generated.",
+	"edit_conflict":                  "File has been modified by another session.",
+	"traffic_exceeded":               "Monthly traffic quota is exceeded.",
+	"folder_quota_exceeded":          "Folder quota is exceeded.",
+	"file_locked":                    "File is locked.",
+	"db_error":                       "Database operation failed.",
+	"encrypt_error":                  "Encryption failed.",
+	"io_failed":                      "I/O operation failed.",
+	"internal_setting":               "Invalid internal setting.",
+	"cache_operation":                "Cache operation failed.",
+	"callback_error":                 "Callback failed.",
+	"update_setting":                 "Failed to update settings.",
+	"add_cors":                       "Failed to add CORS policy.",
+	"node_offline":                   "Node is unavailable.",
+	"query_meta_failed":              "Failed to query file metadata.",
+	"invalid_parameters":             "Invalid parameters.",
+}
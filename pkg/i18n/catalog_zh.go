@@ -0,0 +1,105 @@
+package i18n
+
+// catalogZhCN 简体中文消息目录
+var catalogZhCN = map[string]string{
+	"not_fully_success":              "部分操作未能完成",
+	"check_login":                    "请先登录",
+	"no_permission":                  "无权访问此资源",
+	"not_found":                      "资源不存在",
+	"too_many_requests":              "请求过于频繁，请稍后再试",
+	"conflict":                       "资源冲突",
+	"upload_failed":                  "上传失败",
+	"create_folder_failed":           "目录创建失败",
+	"object_exist":                   "对象已存在",
+	"sign_expired":                   "签名已过期",
+	"policy_not_allowed":             "当前存储策略不允许此操作",
+	"group_not_allowed":              "当前用户组无法进行此操作",
+	"admin_required":                 "需要管理员权限",
+	"master_not_found":               "主机节点未注册",
+	"upload_session_expired":         "上传会话已过期",
+	"invalid_chunk_index":            "无效的分片序号",
+	"invalid_content_length":         "无效的正文长度",
+	"batch_source_size":              "超出批量获取外链的数量限制",
+	"batch_aria2_size":               "超出单次离线下载任务数量限制",
+	"parent_not_exist":               "父目录不存在",
+	"user_banned":                    "此账号已被封禁",
+	"user_not_activated":             "此账号尚未激活",
+	"feature_not_enabled":            "此功能未开启",
+	"credential_invalid":             "凭证无效",
+	"user_not_found":                 "用户不存在",
+	"2fa_code_error":                 "二步验证代码错误",
+	"login_session_not_exist":        "登录会话不存在",
+	"webauthn_init_failed":           "无法初始化 WebAuthn",
+	"webauthn_credential_error":      "WebAuthn 凭证无效",
+	"captcha_error":                  "验证码错误",
+	"captcha_refresh_needed":         "验证码已过期，请刷新",
+	"failed_send_email":              "邮件发送失败",
+	"invalid_temp_link":              "链接无效",
+	"temp_link_expired":              "链接已过期",
+	"email_existed":                  "邮箱已被使用",
+	"email_sent":                     "激活邮件已重新发送",
+	"user_cannot_activate":           "此账号无法激活",
+	"policy_not_exist":               "存储策略不存在",
+	"delete_default_policy":          "无法删除默认存储策略",
+	"policy_used_by_files":           "此存储策略下仍有文件",
+	"policy_used_by_groups":          "此存储策略仍被用户组使用",
+	"group_not_found":                "用户组不存在",
+	"invalid_action_on_system_group": "无法对系统用户组执行此操作",
+	"group_used_by_user":             "此用户组仍被用户使用",
+	"change_group_for_default_user":  "无法更改初始用户的用户组",
+	"invalid_action_on_default_user": "无法对初始用户执行此操作",
+	"file_not_found":                 "文件不存在",
+	"list_files_error":               "列取文件失败",
+	"invalid_action_on_system_node":  "无法对系统节点执行此操作",
+	"create_fs_error":                "无法创建文件系统",
+	"create_task_error":              "任务创建失败",
+	"file_too_large":                 "文件过大",
+	"file_type_not_allowed":          "不允许的文件类型",
+	"insufficient_capacity":          "存储容量不足",
+	"illegal_object_name":            "文件或目录名不合法",
+	"root_protected":                 "无法对根目录执行此操作",
+	"conflict_upload_ongoing":        "当前目录下已有同名文件正在上传",
+	"meta_mismatch":                  "文件信息不一致",
+	"unsupported_archive_type":       "不支持该格式的压缩文件",
+	"policy_changed":                 "可用存储策略已发生变化，请刷新页面",
+	"share_link_not_found":           "分享链接无效或已过期",
+	"save_own_share":                 "不能转存自己的分享",
+	"slave_ping_master":              "从机无法连接主机",
+	"version_mismatch":               "Cloudreve 版本不一致",
+	"insufficient_credit":            "积分不足",
+	"group_conflict":                 "用户组冲突",
+	"group_invalid":                  "当前已处于此用户组中",
+	"invalid_gift_code":              "兑换码无效",
+	"qq_bind_conflict":               "已绑定了 QQ 账号",
+	"qq_bind_other_account":          "此 QQ 账号已被绑定到其他账号",
+	"qq_not_linked":                  "此 QQ 账号未绑定任何账号",
+	"incorrect_password":             "密码不正确",
+	"disabled_share_preview":         "此分享无法预览",
+	"invalid_sign":                   "签名无效",
+	"network_restricted":             "当前网络环境被限制访问",
+	"password_too_weak":              "密码不符合密码策略",
+	"password_breached":              "此密码出现在已知的泄露数据中",
+	"file_quarantined":               "文件因安全原因已被隔离",
+	"file_blocked":                   "文件内容命中屏蔽列表，已被拦截",
+	"user_pending_deletion":          "此账号已申请注销",
+	"invalid_invite_code":            "邀请码无效",
+	"user_pending_approval":          "此账号正在等待管理员审核",
+	"invalid_share_slug":             "自定义分享链接无效",
+	"share_traffic_exceeded":         "此分享的流量已用尽",
+	"thumb_generating":               "缩略图正在生成中",
+	"edit_conflict":                  "文件已被其他会话修改",
+	"traffic_exceeded":               "本月下载流量已用尽",
+	"folder_quota_exceeded":          "目录容量已超出上限",
+	"file_locked":                    "文件已被锁定",
+	"db_error":                       "数据库操作失败",
+	"encrypt_error":                  "加密失败",
+	"io_failed":                      "IO 操作失败",
+	"internal_setting":               "内部设置参数错误",
+	"cache_operation":                "缓存操作失败",
+	"callback_error":                 "回调失败",
+	"update_setting":                 "设置更新失败",
+	"add_cors":                       "跨域策略添加失败",
+	"node_offline":                   "节点不可用",
+	"query_meta_failed":              "文件元信息查询失败",
+	"invalid_parameters":             "参数错误",
+}
@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceLang 代码中硬编码的消息所使用的语言
+const SourceLang = "en-US"
+
+// catalogs 各语言的消息目录，以错误标识为键
+var catalogs = map[string]map[string]string{
+	"en-US": catalogEnUS,
+	"zh-CN": catalogZhCN,
+}
+
+// primaryLangs 只给出主语言代码时使用的语言
+var primaryLangs = map[string]string{
+	"en": "en-US",
+	"zh": "zh-CN",
+}
+
+// Negotiate 按 Accept-Language 中的优先级选出受支持的语言，没有可用语言时返回空
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag, q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, c := range candidates {
+		for lang := range catalogs {
+			if strings.EqualFold(lang, c.tag) {
+				return lang
+			}
+		}
+		if lang, ok := primaryLangs[strings.ToLower(strings.Split(c.tag, "-")[0])]; ok {
+			return lang
+		}
+	}
+
+	return ""
+}
+
+// Message 返回错误标识在指定语言下的消息
+func Message(lang, key string) (string, bool) {
+	msg, ok := catalogs[lang][key]
+	return msg, ok
+}
@@ -0,0 +1,42 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("", Negotiate(""))
+	a.Equal("", Negotiate("fr-FR, de;q=0.8, *"))
+	a.Equal("zh-CN", Negotiate("zh-cn"))
+	a.Equal("zh-CN", Negotiate("zh-TW,zh;q=0.9"))
+	a.Equal("en-US", Negotiate("fr-FR, en-GB;q=0.8, zh-CN;q=0.5"))
+	a.Equal("zh-CN", Negotiate("en;q=0.3, zh-CN;q=0.9"))
+	a.Equal("zh-CN", Negotiate("en;q=0, zh"))
+}
+
+func TestMessage(t *testing.T) {
+	a := assert.New(t)
+	msg, ok := Message("zh-CN", "file_too_large")
+	a.True(ok)
+	a.Equal("文件过大", msg)
+
+	_, ok = Message("fr-FR", "file_too_large")
+	a.False(ok)
+	_, ok = Message("en-US", "not_exist")
+	a.False(ok)
+}
+
+func TestCatalogs(t *testing.T) {
+	a := assert.New(t)
+
+	// 各语言目录应包含相同的错误标识
+	for lang, catalog := range catalogs {
+		a.Len(catalog, len(catalogEnUS), lang)
+		for key := range catalogEnUS {
+			a.Contains(catalog, key, lang)
+		}
+	}
+}
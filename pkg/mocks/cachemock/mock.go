@@ -10,6 +10,16 @@ func (c CacheClientMock) Set(key string, value interface{}, ttl int) error {
 	return c.Called(key, value, ttl).Error(0)
 }
 
+func (c CacheClientMock) SetNX(key string, value interface{}, ttl int) (bool, error) {
+	args := c.Called(key, value, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (c CacheClientMock) Incr(key string, ttl int) (int, error) {
+	args := c.Called(key, ttl)
+	return args.Int(0), args.Error(1)
+}
+
 func (c CacheClientMock) Get(key string) (interface{}, bool) {
 	args := c.Called(key)
 	return args.Get(0), args.Bool(1)
@@ -21,6 +21,11 @@ func (n NodePoolMock) BalanceNodeByFeature(feature string, lb balancer.Balancer)
 	return args.Error(0), args.Get(1).(cluster.Node)
 }
 
+func (n NodePoolMock) NodesByFeature(feature string) []cluster.Node {
+	args := n.Called(feature)
+	return args.Get(0).([]cluster.Node)
+}
+
 func (n NodePoolMock) GetNodeByID(id uint) cluster.Node {
 	args := n.Called(id)
 	if res, ok := args.Get(0).(cluster.Node); ok {
@@ -128,6 +133,21 @@ func (a Aria2Mock) Select(task *model.Download, files []int) error {
 	return args.Error(0)
 }
 
+func (a Aria2Mock) Pause(task *model.Download) error {
+	args := a.Called(task)
+	return args.Error(0)
+}
+
+func (a Aria2Mock) Resume(task *model.Download) error {
+	args := a.Called(task)
+	return args.Error(0)
+}
+
+func (a Aria2Mock) SetSpeedLimit(task *model.Download, limit int) error {
+	args := a.Called(task, limit)
+	return args.Error(0)
+}
+
 func (a Aria2Mock) GetConfig() model.Aria2Option {
 	args := a.Called()
 	return args.Get(0).(model.Aria2Option)
@@ -30,3 +30,8 @@ func (r *RemoteClientMock) DeleteUploadSession(ctx context.Context, sessionID st
 	args := r.Called(ctx, sessionID)
 	return args.Error(0)
 }
+
+func (r *RemoteClientMock) GenerateMeta(ctx context.Context, req *serializer.SlaveMetaReq) error {
+	args := r.Called(ctx, req)
+	return args.Error(0)
+}
@@ -0,0 +1,84 @@
+package netpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+)
+
+// ErrInternalEndpoint 用户提供的外部服务地址指向内网或本机
+var ErrInternalEndpoint = serializer.NewError(serializer.CodeParamErr, "Endpoint must be a public address", nil)
+
+// lookupIP 解析主机名，测试时可替换
+var lookupIP = net.LookupIP
+
+// CheckPublicEndpoint 检查用户提供的外部服务地址是否可由服务端访问，
+// 地址解析到本机、内网、链路本地等地址时返回 ErrInternalEndpoint，避免服务端请求伪造
+func CheckPublicEndpoint(endpoint string) error {
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return ErrInternalEndpoint.WithError(fmt.Errorf("invalid endpoint %q", endpoint))
+	}
+
+	ips := []net.IP{net.ParseIP(u.Hostname())}
+	if ips[0] == nil {
+		if ips, err = lookupIP(u.Hostname()); err != nil {
+			return ErrInternalEndpoint.WithError(err)
+		}
+	}
+
+	for _, ip := range ips {
+		if isInternalIP(ip) {
+			return ErrInternalEndpoint.WithError(fmt.Errorf("%q resolves to internal address %s", u.Hostname(), ip))
+		}
+	}
+
+	return nil
+}
+
+// PublicTransport 返回只允许连接公网地址的 HTTP Transport，用于访问用户提供的外部服务。
+// 在建立连接时检查解析后的地址，避免通过 DNS 重绑定绕过 CheckPublicEndpoint
+func PublicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   denyInternalAddress,
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport
+}
+
+// denyInternalAddress 拒绝连接内网或本机地址
+func denyInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrInternalEndpoint.WithError(err)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || isInternalIP(ip) {
+		return ErrInternalEndpoint.WithError(fmt.Errorf("connection to internal address %s is not allowed", host))
+	}
+	return nil
+}
+
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast()
+}
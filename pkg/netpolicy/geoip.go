@@ -0,0 +1,118 @@
+package netpolicy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+const (
+	geoIPCachePrefix = "geoip_"
+	geoIPCacheTTL    = 86400
+)
+
+var (
+	ErrGeoIPDisabled = errors.New("geoip provider is not configured")
+
+	// Default 全局 GeoIP 查询提供者，未配置时为 nil
+	Default   Provider
+	DefaultMu sync.RWMutex
+)
+
+// Provider GeoIP 查询提供者
+type Provider interface {
+	// Country 返回 IP 所属国家/地区的 ISO 3166-1 两位代码
+	Country(ip string, r *http.Request) (string, error)
+}
+
+// Init 根据站点设置初始化全局 GeoIP 提供者
+func Init() {
+	settings := model.GetSettingByNames("geoip_provider", "geoip_header", "geoip_endpoint")
+	var provider Provider
+	switch settings["geoip_provider"] {
+	case "header":
+		provider = &HeaderProvider{Header: settings["geoip_header"]}
+	case "http":
+		provider = &HTTPProvider{
+			Endpoint: settings["geoip_endpoint"],
+			Client:   request.NewClient(),
+		}
+	case "":
+	default:
+		util.Log().Warning("Unknown GeoIP provider %q, country restrictions are disabled.", settings["geoip_provider"])
+	}
+
+	DefaultMu.Lock()
+	Default = provider
+	DefaultMu.Unlock()
+}
+
+// Country 使用全局 GeoIP 提供者查询 IP 所属国家/地区
+func Country(ip string, r *http.Request) (string, error) {
+	DefaultMu.RLock()
+	provider := Default
+	DefaultMu.RUnlock()
+
+	if provider == nil {
+		return "", ErrGeoIPDisabled
+	}
+
+	return provider.Country(ip, r)
+}
+
+// HeaderProvider 从反向代理/CDN 添加的请求头中读取国家代码，如 Cloudflare 的 CF-IPCountry
+type HeaderProvider struct {
+	Header string
+}
+
+// Country 实现 Provider
+func (p *HeaderProvider) Country(ip string, r *http.Request) (string, error) {
+	if r == nil {
+		return "", errors.New("request is not available")
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(r.Header.Get(p.Header)))
+	if country == "" {
+		return "", fmt.Errorf("header %q is empty", p.Header)
+	}
+
+	return country, nil
+}
+
+// HTTPProvider 请求外部 GeoIP 服务，Endpoint 中的 {ip} 会被替换为客户端 IP，
+// 服务需要返回纯文本的国家代码
+type HTTPProvider struct {
+	Endpoint string
+	Client   request.Client
+}
+
+// Country 实现 Provider
+func (p *HTTPProvider) Country(ip string, r *http.Request) (string, error) {
+	if country, ok := cache.Get(geoIPCachePrefix + ip); ok {
+		return country.(string), nil
+	}
+
+	endpoint := strings.ReplaceAll(p.Endpoint, "{ip}", url.PathEscape(ip))
+	res, err := p.Client.Request("GET", endpoint, nil).
+		CheckHTTPResponse(http.StatusOK).
+		GetResponse()
+	if err != nil {
+		return "", fmt.Errorf("failed to request GeoIP endpoint: %w", err)
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(res))
+	if len(country) != 2 {
+		return "", fmt.Errorf("unexpected GeoIP response %q", res)
+	}
+
+	_ = cache.Set(geoIPCachePrefix+ip, country, geoIPCacheTTL)
+	return country, nil
+}
@@ -0,0 +1,83 @@
+package netpolicy
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+var (
+	ErrIPDenied      = serializer.NewError(serializer.CodeNetworkRestricted, "Your IP address is not allowed", nil)
+	ErrCountryDenied = serializer.NewError(serializer.CodeNetworkRestricted, "Access from your region is not allowed", nil)
+)
+
+// Check 检查客户端 IP 是否符合用户组的网络策略，禁止列表优先于允许列表
+func Check(group *model.Group, ip string, r *http.Request) error {
+	option := group.OptionsSerialized
+	if len(option.IPAllowList) == 0 && len(option.IPDenyList) == 0 && len(option.CountryDenyList) == 0 {
+		return nil
+	}
+
+	clientIP := net.ParseIP(ip)
+	if clientIP == nil {
+		return ErrIPDenied
+	}
+
+	if MatchAny(option.IPDenyList, clientIP) {
+		return ErrIPDenied
+	}
+
+	if len(option.IPAllowList) > 0 && !MatchAny(option.IPAllowList, clientIP) {
+		return ErrIPDenied
+	}
+
+	if len(option.CountryDenyList) > 0 {
+		country, err := Country(ip, r)
+		if err != nil {
+			// GeoIP 查询失败时不阻止访问，避免外部服务故障导致所有用户无法使用
+			util.Log().Warning("Failed to query country of IP %q: %s", ip, err)
+			return nil
+		}
+
+		for _, denied := range option.CountryDenyList {
+			if strings.EqualFold(strings.TrimSpace(denied), country) {
+				return ErrCountryDenied
+			}
+		}
+	}
+
+	return nil
+}
+
+// MatchAny 判断 IP 是否命中规则列表，规则可以是单个 IP 或 CIDR
+func MatchAny(rules []string, ip net.IP) bool {
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		if strings.Contains(rule, "/") {
+			_, network, err := net.ParseCIDR(rule)
+			if err != nil {
+				util.Log().Warning("Invalid CIDR rule %q in group network policy.", rule)
+				continue
+			}
+
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if target := net.ParseIP(rule); target != nil && target.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
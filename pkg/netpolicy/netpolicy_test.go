@@ -0,0 +1,199 @@
+package netpolicy
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/mocks/requestmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+func TestMatchAny(t *testing.T) {
+	a := assert.New(t)
+	rules := []string{"", "invalid/cidr", "10.0.0.0/8", " 192.168.1.1 ", "2001:db8::/32"}
+	a.True(MatchAny(rules, net.ParseIP("10.1.2.3")))
+	a.True(MatchAny(rules, net.ParseIP("192.168.1.1")))
+	a.True(MatchAny(rules, net.ParseIP("2001:db8::1")))
+	a.False(MatchAny(rules, net.ParseIP("192.168.1.2")))
+	a.False(MatchAny(nil, net.ParseIP("192.168.1.2")))
+}
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+	group := &model.Group{}
+
+	// 未配置策略
+	a.NoError(Check(group, "invalid", nil))
+
+	// 无效 IP
+	group.OptionsSerialized.IPDenyList = []string{"10.0.0.0/8"}
+	a.Equal(ErrIPDenied, Check(group, "invalid", nil))
+
+	// 命中禁止列表
+	a.Equal(ErrIPDenied, Check(group, "10.0.0.1", nil))
+	a.NoError(Check(group, "192.168.1.1", nil))
+
+	// 不在允许列表中
+	group.OptionsSerialized.IPAllowList = []string{"192.168.0.0/16", "10.0.0.1"}
+	a.Equal(ErrIPDenied, Check(group, "172.16.0.1", nil))
+	a.NoError(Check(group, "192.168.1.1", nil))
+
+	// 禁止列表优先
+	a.Equal(ErrIPDenied, Check(group, "10.0.0.1", nil))
+}
+
+func TestCheck_Country(t *testing.T) {
+	a := assert.New(t)
+	group := &model.Group{}
+	group.OptionsSerialized.CountryDenyList = []string{"kp"}
+	r := httptest.NewRequest("GET", "/", nil)
+	defer func() {
+		Default = nil
+	}()
+
+	// 未配置 GeoIP 时放行
+	Default = nil
+	a.NoError(Check(group, "1.1.1.1", r))
+
+	Default = &HeaderProvider{Header: "CF-IPCountry"}
+	r.Header.Set("CF-IPCountry", "KP")
+	a.Equal(ErrCountryDenied, Check(group, "1.1.1.1", r))
+
+	r.Header.Set("CF-IPCountry", "US")
+	a.NoError(Check(group, "1.1.1.1", r))
+}
+
+func TestHeaderProvider_Country(t *testing.T) {
+	a := assert.New(t)
+	provider := &HeaderProvider{Header: "X-Country"}
+
+	_, err := provider.Country("1.1.1.1", nil)
+	a.Error(err)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	_, err = provider.Country("1.1.1.1", r)
+	a.Error(err)
+
+	r.Header.Set("X-Country", " cn ")
+	country, err := provider.Country("1.1.1.1", r)
+	a.NoError(err)
+	a.Equal("CN", country)
+}
+
+func TestHTTPProvider_Country(t *testing.T) {
+	a := assert.New(t)
+	mockHttp := &requestmock.RequestMock{}
+	provider := &HTTPProvider{Endpoint: "https://geoip/{ip}/country", Client: mockHttp}
+
+	// 请求失败
+	{
+		mockHttp.On("Request", "GET", "https://geoip/2.2.2.2/country", testMock.Anything, testMock.Anything).
+			Return(&request.Response{Err: errors.New("error")})
+		_, err := provider.Country("2.2.2.2", nil)
+		a.Error(err)
+	}
+
+	// 响应无效
+	{
+		mockHttp.On("Request", "GET", "https://geoip/3.3.3.3/country", testMock.Anything, testMock.Anything).
+			Return(&request.Response{Response: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("unknown")),
+			}})
+		_, err := provider.Country("3.3.3.3", nil)
+		a.Error(err)
+	}
+
+	// 成功并缓存
+	{
+		mockHttp.On("Request", "GET", "https://geoip/4.4.4.4/country", testMock.Anything, testMock.Anything).
+			Return(&request.Response{Response: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("jp\n")),
+			}}).Once()
+		country, err := provider.Country("4.4.4.4", nil)
+		a.NoError(err)
+		a.Equal("JP", country)
+
+		cached, ok := cache.Get(geoIPCachePrefix + "4.4.4.4")
+		a.True(ok)
+		a.Equal("JP", cached)
+		country, err = provider.Country("4.4.4.4", nil)
+		a.NoError(err)
+		a.Equal("JP", country)
+	}
+
+	mockHttp.AssertExpectations(t)
+}
+
+func TestInit(t *testing.T) {
+	a := assert.New(t)
+	defer func() {
+		Default = nil
+	}()
+
+	cache.Set("setting_geoip_header", "X-Country", 0)
+	cache.Set("setting_geoip_endpoint", "https://geoip/{ip}", 0)
+
+	cache.Set("setting_geoip_provider", "header", 0)
+	Init()
+	a.IsType(&HeaderProvider{}, Default)
+
+	cache.Set("setting_geoip_provider", "http", 0)
+	Init()
+	a.IsType(&HTTPProvider{}, Default)
+
+	cache.Set("setting_geoip_provider", "unknown", 0)
+	Init()
+	a.Nil(Default)
+}
+
+func TestCheckPublicEndpoint(t *testing.T) {
+	a := assert.New(t)
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "internal.example.com":
+			return []net.IP{net.ParseIP("1.1.1.1"), net.ParseIP("10.0.0.2")}, nil
+		case "public.example.com":
+			return []net.IP{net.ParseIP("1.1.1.1")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	defer func() { lookupIP = net.LookupIP }()
+
+	a.NoError(CheckPublicEndpoint("https://public.example.com"))
+	a.NoError(CheckPublicEndpoint("public.example.com:9000"))
+	a.NoError(CheckPublicEndpoint("http://1.1.1.1"))
+	a.Error(CheckPublicEndpoint("http://127.0.0.1:9000"))
+	a.Error(CheckPublicEndpoint("http://[::1]"))
+	a.Error(CheckPublicEndpoint("http://169.254.169.254/latest"))
+	a.Error(CheckPublicEndpoint("192.168.1.1"))
+	a.Error(CheckPublicEndpoint("https://internal.example.com"))
+	a.Error(CheckPublicEndpoint("https://unknown.example.com"))
+	a.Error(CheckPublicEndpoint("http://"))
+}
+
+func TestPublicTransport(t *testing.T) {
+	a := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: PublicTransport()}
+	_, err := client.Get(server.URL)
+	a.Error(err)
+	a.Contains(err.Error(), "public address")
+
+	a.NoError(denyInternalAddress("tcp", "1.1.1.1:443", nil))
+	a.Error(denyInternalAddress("tcp", "10.0.0.1:443", nil))
+	a.Error(denyInternalAddress("tcp", "[::1]:443", nil))
+	a.Error(denyInternalAddress("tcp", "invalid", nil))
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/email"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+const quotaWarnedCachePrefix = "quota_warned_"
+
+// Send 根据用户的通知偏好投递站内通知和邮件通知，邮件异步发送
+func Send(user *model.User, typ, title, content string) {
+	if user == nil || user.ID == 0 {
+		return
+	}
+
+	if user.NotifyEnabled(typ, model.NotifyChannelInApp) {
+		notification := &model.Notification{
+			UserID:  user.ID,
+			Type:    typ,
+			Title:   title,
+			Content: content,
+		}
+		if err := notification.Create(); err != nil {
+			util.Log().Warning("Failed to create notification for user %d: %s", user.ID, err)
+		}
+	}
+
+	if user.NotifyEnabled(typ, model.NotifyChannelEmail) {
+		to := user.Email
+		subject, body := email.NewNotificationEmail("", user.Nick, title, content)
+		go func() {
+			if err := email.Send(to, subject, body); err != nil {
+				util.Log().Warning("Failed to send notification email to %q: %s", to, err)
+			}
+		}()
+	}
+}
+
+// ShareDownloaded 通知分享创建者其分享被下载
+func ShareDownloaded(share *model.Share, downloader *model.User) {
+	if share.UserID == downloader.ID {
+		return
+	}
+
+	who := "匿名用户"
+	if !downloader.IsAnonymous() {
+		who = downloader.Nick
+	}
+
+	Send(share.Creator(), model.NotifyShareDownloaded, "分享被下载",
+		fmt.Sprintf("您分享的 %s 被 %s 下载。", share.SourceName, who))
+}
+
+// TaskFinished 通知用户其任务执行结束
+func TaskFinished(user *model.User, taskName string, jobErr string) {
+	if jobErr != "" {
+		Send(user, model.NotifyTaskFinished, "任务执行失败",
+			fmt.Sprintf("您的%s任务执行失败：%s", taskName, jobErr))
+		return
+	}
+
+	Send(user, model.NotifyTaskFinished, "任务已完成", fmt.Sprintf("您的%s任务已完成。", taskName))
+}
+
+// CheckQuota 用户已用容量超出警告阈值时发送提醒，同一用户在间隔时间内只提醒一次
+func CheckQuota(user *model.User) {
+	threshold := model.GetIntSetting("quota_warning_threshold", 90)
+	total := user.TotalCapacity()
+	if threshold <= 0 || total == 0 {
+		return
+	}
+
+	used := user.Storage * 100 / total
+	if used < uint64(threshold) {
+		return
+	}
+
+	key := fmt.Sprintf("%s%d", quotaWarnedCachePrefix, user.ID)
+	if _, ok := cache.Get(key); ok {
+		return
+	}
+	cache.Set(key, true, model.GetIntSetting("quota_warning_interval", 86400))
+
+	Send(user, model.NotifyQuotaWarning, "存储空间即将用尽",
+		fmt.Sprintf("您已使用 %d%% 的存储空间，请及时清理不需要的文件。", used))
+}
+
+// QuotaExceeded 通知用户已用容量超出配额，进入超额宽限期
+func QuotaExceeded(user *model.User) {
+	content := "您的已用存储空间已超出配额，请及时清理不需要的文件。"
+	if ends := user.QuotaGraceEnds(); ends != nil {
+		content = fmt.Sprintf("您的已用存储空间已超出配额，请在 %s 前清理不需要的文件，否则将被限制上传。",
+			ends.Format("2006-01-02 15:04"))
+	}
+
+	Send(user, model.NotifyQuotaWarning, "存储空间已超出配额", content)
+}
+
+// QuotaEnforced 通知用户超额宽限期已结束
+func QuotaEnforced(user *model.User) {
+	content := "超额宽限期已结束，在清理文件使已用空间低于配额前，您将无法上传新文件。"
+	if user.Group.OptionsSerialized.QuotaOverAction == model.QuotaActionDisableShares {
+		content = "超额宽限期已结束，您的全部分享已被停用。在清理文件使已用空间低于配额前，您将无法上传新文件。"
+	}
+
+	Send(user, model.NotifyQuotaWarning, "存储空间超额限制已生效", content)
+}
+
+// announceBatchSize 投递公告时每批读取的用户数
+const announceBatchSize = 500
+
+// Announce 向所有正常状态的用户投递公告
+func Announce(announcement *model.Announcement) {
+	var lastID uint
+	for {
+		var users []model.User
+		if err := model.DB.Where("id > ? and status = ?", lastID, model.Active).
+			Order("id").Limit(announceBatchSize).Find(&users).Error; err != nil {
+			util.Log().Warning("Failed to list users for announcement %d: %s", announcement.ID, err)
+			return
+		}
+
+		for i := range users {
+			Send(&users[i], model.NotifyAnnouncement, announcement.Title, announcement.Content)
+		}
+
+		if len(users) < announceBatchSize {
+			return
+		}
+		lastID = users[len(users)-1].ID
+	}
+}
+
+// ShareExpired 通知分享创建者其分享已到期
+func ShareExpired(share *model.Share) {
+	Send(share.Creator(), model.NotifyShareExpired, "分享已到期",
+		fmt.Sprintf("您分享的 %s 已于 %s 到期。", share.SourceName, share.Expires.Format("2006-01-02 15:04")))
+}
+
+// FilesExpiring 提醒用户过期目录中的文件即将按生命周期规则删除
+func FilesExpiring(user *model.User, files []model.File, expireAt time.Time) {
+	names := make([]string, 0, len(files))
+	for i := range files {
+		if i >= 10 {
+			names = append(names, fmt.Sprintf("等 %d 个文件", len(files)))
+			break
+		}
+		names = append(names, files[i].Name)
+	}
+
+	Send(user, model.NotifyFileExpiry, "文件即将到期删除",
+		fmt.Sprintf("以下文件将于 %s 后被自动删除，如需保留请移出所在目录：%s",
+			expireAt.Format("2006-01-02"), strings.Join(names, "、")))
+}
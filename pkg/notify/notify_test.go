@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+var mock sqlmock.Sqlmock
+
+func TestMain(m *testing.M) {
+	var db *sql.DB
+	var err error
+	db, mock, err = sqlmock.New()
+	if err != nil {
+		panic("An error was not expected when opening a stub database connection")
+	}
+	model.DB, _ = gorm.Open("mysql", db)
+	defer db.Close()
+	m.Run()
+}
+
+func expectInApp() {
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)notifications(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
+
+func testUser(id uint) *model.User {
+	user := &model.User{Email: "test@example.com"}
+	user.ID = id
+	// 关闭邮件通知，避免异步发送
+	for _, typ := range model.NotifyTypes {
+		user.SetNotifyPref(typ, model.NotifyChannelEmail, false)
+	}
+	return user
+}
+
+func TestSend(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 匿名用户
+	{
+		Send(&model.User{}, model.NotifyTaskFinished, "title", "content")
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 站内通知
+	{
+		expectInApp()
+		Send(testUser(1), model.NotifyTaskFinished, "title", "content")
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 用户关闭站内通知
+	{
+		user := testUser(1)
+		user.SetNotifyPref(model.NotifyTaskFinished, model.NotifyChannelInApp, false)
+		Send(user, model.NotifyTaskFinished, "title", "content")
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestShareDownloaded(t *testing.T) {
+	asserts := assert.New(t)
+	owner := testUser(1)
+
+	// 分享者自己下载
+	{
+		ShareDownloaded(&model.Share{UserID: 1, User: *owner}, owner)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 其他用户下载
+	{
+		expectInApp()
+		ShareDownloaded(&model.Share{UserID: 1, User: *owner}, testUser(2))
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	asserts := assert.New(t)
+	cache.SetSettings(map[string]string{
+		"quota_warning_threshold": "90",
+		"quota_warning_interval":  "3600",
+	}, "setting_")
+
+	user := testUser(3)
+	user.Group.MaxStorage = 100
+
+	// 未超出阈值
+	{
+		user.Storage = 50
+		CheckQuota(user)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 超出阈值
+	{
+		user.Storage = 95
+		expectInApp()
+		CheckQuota(user)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 间隔时间内不重复提醒
+	{
+		CheckQuota(user)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 功能关闭
+	{
+		cache.Set("setting_quota_warning_threshold", "0", 0)
+		cache.Deletes([]string{"3"}, quotaWarnedCachePrefix)
+		CheckQuota(user)
+		asserts.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	asserts := assert.New(t)
+	options := `{"notify":{"announcement":{"email":false,"in_app":true}}}`
+
+	mock.ExpectQuery("SELECT(.+)users(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "options"}).
+		AddRow(1, options).
+		AddRow(2, `{"notify":{"announcement":{"email":false,"in_app":false}}}`).
+		AddRow(3, options))
+	expectInApp()
+	expectInApp()
+	Announce(&model.Announcement{Title: "title", Content: "content"})
+	asserts.NoError(mock.ExpectationsWereMet())
+}
+
+func TestFilesExpiring(t *testing.T) {
+	asserts := assert.New(t)
+	expectInApp()
+	FilesExpiring(testUser(1), []model.File{{Name: "a.txt"}}, time.Now())
+	asserts.NoError(mock.ExpectationsWereMet())
+}
@@ -0,0 +1,292 @@
+package onlyoffice
+
+import (
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ActionType 文档会话的操作类型
+type ActionType string
+
+const (
+	ActionView ActionType = "view"
+	ActionEdit ActionType = "edit"
+
+	// SessionCachePrefix 文档会话的缓存键前缀
+	SessionCachePrefix = "onlyoffice_session_"
+
+	// StatusMustSave 文档编辑完毕，需要保存
+	StatusMustSave = 2
+	// StatusForceSave 文档被强制保存
+	StatusForceSave = 6
+
+	apiJSPath = "web-apps/apps/api/documents/api.js"
+)
+
+var (
+	ErrUnsupportedType = errors.New("file type not supported by OnlyOffice")
+	ErrInvalidToken    = errors.New("invalid OnlyOffice token")
+	ErrSecretNotSet    = errors.New("OnlyOffice secret is not set")
+	ErrUntrustedURL    = errors.New("document URL does not belong to the OnlyOffice server")
+
+	// 各文档类型可编辑的扩展名
+	editableExts = map[string]string{
+		"docx": "word",
+		"xlsx": "cell",
+		"pptx": "slide",
+	}
+
+	// 各文档类型仅可查看的扩展名
+	viewableExts = map[string]string{
+		"doc": "word", "odt": "word", "rtf": "word", "txt": "word", "pdf": "word",
+		"xls": "cell", "ods": "cell", "csv": "cell",
+		"ppt": "slide", "odp": "slide",
+	}
+)
+
+// SessionCache 缓存中保存的文档会话
+type SessionCache struct {
+	FileID uint
+	UserID uint
+	Action ActionType
+	Key    string
+}
+
+// Config 文档编辑器配置
+type Config struct {
+	Document     Document     `json:"document"`
+	DocumentType string       `json:"documentType"`
+	EditorConfig EditorConfig `json:"editorConfig"`
+	Token        string       `json:"token,omitempty"`
+}
+
+// Document 编辑器配置中的文档信息
+type Document struct {
+	FileType    string      `json:"fileType"`
+	Key         string      `json:"key"`
+	Title       string      `json:"title"`
+	URL         string      `json:"url"`
+	Permissions Permissions `json:"permissions"`
+}
+
+// Permissions 编辑器配置中的文档权限
+type Permissions struct {
+	Edit     bool `json:"edit"`
+	Download bool `json:"download"`
+}
+
+// EditorConfig 编辑器配置中的编辑器选项
+type EditorConfig struct {
+	CallbackURL string `json:"callbackUrl,omitempty"`
+	Mode        string `json:"mode"`
+	Lang        string `json:"lang,omitempty"`
+	User        User   `json:"user"`
+}
+
+// User 编辑器配置中的当前用户
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Callback 文档服务器发送的保存回调
+type Callback struct {
+	Key    string   `json:"key"`
+	Status int      `json:"status"`
+	URL    string   `json:"url"`
+	Users  []string `json:"users"`
+	Token  string   `json:"token"`
+}
+
+func init() {
+	gob.Register(SessionCache{})
+}
+
+// Enabled 返回是否已启用 OnlyOffice 集成，未设置密钥时无法校验文档服务器的请求，视为未启用
+func Enabled() bool {
+	settings := model.GetSettingByNames("onlyoffice_enabled", "onlyoffice_endpoint", "onlyoffice_secret")
+	return model.IsTrueVal(settings["onlyoffice_enabled"]) && settings["onlyoffice_endpoint"] != "" &&
+		settings["onlyoffice_secret"] != ""
+}
+
+// APIURL 返回文档服务器编辑器脚本的地址
+func APIURL() string {
+	return strings.TrimSuffix(model.GetSettingByName("onlyoffice_endpoint"), "/") + "/" + apiJSPath
+}
+
+// DocumentType 返回文件对应的文档类型，editable 为 true 时仅匹配可编辑的格式
+func DocumentType(name string, editable bool) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	if typ, ok := editableExts[ext]; ok {
+		return typ
+	}
+	if editable {
+		return ""
+	}
+	return viewableExts[ext]
+}
+
+// DocumentKey 返回文档的唯一标识，文件内容变化后标识随之改变
+func DocumentKey(file *model.File) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%d|%s", file.ID, file.UpdatedAt.UnixNano(), file.SourceName)))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewConfig 为文件创建编辑器配置，设置了密钥时附带签名
+func NewConfig(file *model.File, user *model.User, action ActionType, contentURL, callbackURL, lang, userID string) (*Config, error) {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(file.Name), "."))
+	docType := DocumentType(file.Name, action == ActionEdit)
+	if docType == "" {
+		return nil, ErrUnsupportedType
+	}
+
+	config := &Config{
+		Document: Document{
+			FileType: ext,
+			Key:      DocumentKey(file),
+			Title:    file.Name,
+			URL:      contentURL,
+			Permissions: Permissions{
+				Edit:     action == ActionEdit,
+				Download: true,
+			},
+		},
+		DocumentType: docType,
+		EditorConfig: EditorConfig{
+			Mode: string(ActionView),
+			Lang: lang,
+			User: User{ID: userID, Name: user.Nick},
+		},
+	}
+
+	if action == ActionEdit {
+		config.EditorConfig.Mode = string(ActionEdit)
+		config.EditorConfig.CallbackURL = callbackURL
+	}
+
+	if secret := model.GetSettingByName("onlyoffice_secret"); secret != "" {
+		token, err := Sign(config, secret)
+		if err != nil {
+			return nil, err
+		}
+		config.Token = token
+	}
+
+	return config, nil
+}
+
+// Sign 使用密钥对载荷进行 JWT 签名
+func Sign(payload interface{}, secret string) (string, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return "", err
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// Verify 校验 JWT 签名，并返回其中的载荷
+func Verify(token, secret string) (jwt.MapClaims, error) {
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+		return []byte(secret), nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// VerifyHeader 校验请求头中携带的 Bearer JWT
+func VerifyHeader(header, secret string) error {
+	if secret == "" {
+		return ErrSecretNotSet
+	}
+
+	_, err := Verify(strings.TrimPrefix(header, "Bearer "), secret)
+	return err
+}
+
+// ParseCallback 解析保存回调请求体，回调内容以请求体中的 token 或请求头中的 JWT 载荷为准。
+// 未设置密钥时拒绝回调
+func ParseCallback(body []byte, header, secret string) (*Callback, error) {
+	if secret == "" {
+		return nil, ErrSecretNotSet
+	}
+
+	callback := &Callback{}
+	if err := json.Unmarshal(body, callback); err != nil {
+		return nil, err
+	}
+
+	var (
+		claims jwt.MapClaims
+		err    error
+	)
+	if callback.Token != "" {
+		claims, err = Verify(callback.Token, secret)
+	} else {
+		claims, err = Verify(strings.TrimPrefix(header, "Bearer "), secret)
+		// 请求头中的载荷位于 payload 字段中
+		if payload, ok := claims["payload"]; ok && err == nil {
+			if m, ok := payload.(map[string]interface{}); ok {
+				claims = m
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	verified := &Callback{}
+	if err := json.Unmarshal(raw, verified); err != nil {
+		return nil, err
+	}
+	return verified, nil
+}
+
+// ShouldSave 返回回调状态是否表示需要保存文档
+func (c *Callback) ShouldSave() bool {
+	return (c.Status == StatusMustSave || c.Status == StatusForceSave) && c.URL != ""
+}
+
+// CheckDocumentURL 检查回调中的文档地址是否位于文档服务器上，避免下载任意地址的内容
+func CheckDocumentURL(raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return ErrUntrustedURL
+	}
+
+	endpoint, err := url.Parse(model.GetSettingByName("onlyoffice_endpoint"))
+	if err != nil || endpoint.Host == "" || !strings.EqualFold(endpoint.Host, target.Host) {
+		return ErrUntrustedURL
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package onlyoffice
+
+import (
+	"encoding/json"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentType(t *testing.T) {
+	asserts := assert.New(t)
+
+	asserts.Equal("word", DocumentType("a.DOCX", true))
+	asserts.Equal("cell", DocumentType("a.xlsx", true))
+	asserts.Equal("slide", DocumentType("a.pptx", false))
+	asserts.Equal("", DocumentType("a.doc", true))
+	asserts.Equal("word", DocumentType("a.doc", false))
+	asserts.Equal("", DocumentType("a.zip", false))
+}
+
+func TestNewConfig(t *testing.T) {
+	asserts := assert.New(t)
+	file := &model.File{Name: "a.docx", SourceName: "1/a.docx"}
+	file.ID = 1
+	user := &model.User{Nick: "nick"}
+
+	// 不支持的格式
+	{
+		cache.Set("setting_onlyoffice_secret", "", 0)
+		_, err := NewConfig(&model.File{Name: "a.doc"}, user, ActionEdit, "content", "callback", "", "uid")
+		asserts.Equal(ErrUnsupportedType, err)
+	}
+
+	// 查看，无签名
+	{
+		config, err := NewConfig(file, user, ActionView, "content", "callback", "", "uid")
+		asserts.NoError(err)
+		asserts.Equal("view", config.EditorConfig.Mode)
+		asserts.Empty(config.EditorConfig.CallbackURL)
+		asserts.Empty(config.Token)
+		asserts.False(config.Document.Permissions.Edit)
+	}
+
+	// 编辑，带签名
+	{
+		cache.Set("setting_onlyoffice_secret", "secret", 0)
+		config, err := NewConfig(file, user, ActionEdit, "content", "callback", "zh-CN", "uid")
+		asserts.NoError(err)
+		asserts.Equal("edit", config.EditorConfig.Mode)
+		asserts.Equal("callback", config.EditorConfig.CallbackURL)
+		asserts.Equal(DocumentKey(file), config.Document.Key)
+
+		claims, err := Verify(config.Token, "secret")
+		asserts.NoError(err)
+		asserts.Equal("word", claims["documentType"])
+
+		_, err = Verify(config.Token, "wrong")
+		asserts.Equal(ErrInvalidToken, err)
+	}
+}
+
+func TestParseCallback(t *testing.T) {
+	asserts := assert.New(t)
+	body, _ := json.Marshal(map[string]interface{}{"key": "k", "status": 2, "url": "http://ds/a.docx"})
+
+	// 未设置密钥
+	{
+		_, err := ParseCallback(body, "", "")
+		asserts.Equal(ErrSecretNotSet, err)
+	}
+
+	// 请求体中的签名
+	{
+		token, _ := Sign(map[string]interface{}{"key": "k", "status": 4}, "secret")
+		raw, _ := json.Marshal(map[string]interface{}{"key": "k", "status": 2, "url": "u", "token": token})
+		res, err := ParseCallback(raw, "", "secret")
+		asserts.NoError(err)
+		asserts.Equal(4, res.Status)
+		asserts.False(res.ShouldSave())
+	}
+
+	// 请求头中的签名
+	{
+		token, _ := Sign(map[string]interface{}{"payload": map[string]interface{}{"key": "k", "status": 6, "url": "u"}}, "secret")
+		res, err := ParseCallback(body, "Bearer "+token, "secret")
+		asserts.NoError(err)
+		asserts.Equal(6, res.Status)
+		asserts.True(res.ShouldSave())
+	}
+
+	// 签名无效
+	{
+		_, err := ParseCallback(body, "Bearer invalid", "secret")
+		asserts.Equal(ErrInvalidToken, err)
+	}
+}
+
+func TestVerifyHeader(t *testing.T) {
+	asserts := assert.New(t)
+	token, _ := Sign(map[string]interface{}{"key": "k"}, "secret")
+
+	asserts.Equal(ErrSecretNotSet, VerifyHeader("Bearer "+token, ""))
+	asserts.NoError(VerifyHeader("Bearer "+token, "secret"))
+	asserts.Equal(ErrInvalidToken, VerifyHeader("Bearer "+token, "wrong"))
+}
+
+func TestEnabled(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_onlyoffice_enabled", "1", 0)
+	cache.Set("setting_onlyoffice_endpoint", "https://ds.example.com/", 0)
+
+	cache.Set("setting_onlyoffice_secret", "", 0)
+	asserts.False(Enabled())
+
+	cache.Set("setting_onlyoffice_secret", "secret", 0)
+	asserts.True(Enabled())
+}
+
+func TestCheckDocumentURL(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_onlyoffice_endpoint", "https://ds.example.com/", 0)
+
+	asserts.NoError(CheckDocumentURL("https://ds.example.com/cache/files/a.docx"))
+	asserts.NoError(CheckDocumentURL("http://DS.example.com/cache/files/a.docx"))
+	asserts.Equal(ErrUntrustedURL, CheckDocumentURL("http://127.0.0.1/a.docx"))
+	asserts.Equal(ErrUntrustedURL, CheckDocumentURL("https://ds.example.com.evil.com/a.docx"))
+	asserts.Equal(ErrUntrustedURL, CheckDocumentURL("file:///etc/passwd"))
+
+	cache.Set("setting_onlyoffice_endpoint", "", 0)
+	asserts.Equal(ErrUntrustedURL, CheckDocumentURL("https://ds.example.com/a.docx"))
+}
@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ResponseSchema 通用响应结构的名称
+const ResponseSchema = "Response"
+
+// Build 根据路由表与源码信息生成 OpenAPI 文档，只收录 prefix 下的路由
+func Build(routes gin.RoutesInfo, src *Source, prefix, title, version string) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: title, Version: version},
+		Servers: []Server{{URL: prefix}},
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: map[string]*Schema{
+				ResponseSchema: {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"code":  {Type: "integer", Description: "0 for success, otherwise an error code"},
+						"data":  {},
+						"msg":   {Type: "string"},
+						"error": {Type: "string"},
+						"key":   {Type: "string", Description: "Stable identifier of the error code"},
+					},
+					Required: []string{"code", "msg"},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"session": {Type: "apiKey", In: "cookie", Name: "cloudreve-session"},
+			},
+		},
+		Security: []map[string][]string{{"session": {}}},
+	}
+
+	sorted := make(gin.RoutesInfo, 0, len(routes))
+	for _, route := range routes {
+		if strings.HasPrefix(route.Path, prefix+"/") {
+			sorted = append(sorted, route)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+
+	operationIDs := make(map[string]int)
+	tags := make(map[string]bool)
+	for _, route := range sorted {
+		relative := strings.TrimPrefix(route.Path, prefix)
+		path, params := convertPath(relative)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+
+		name := handlerName(route.Handler)
+		op := &Operation{
+			OperationID: name,
+			Responses: map[string]*Response{
+				"200": {
+					Description: "OK",
+					Content: map[string]*MediaType{
+						"application/json": {Schema: &Schema{Ref: "#/components/schemas/" + ResponseSchema}},
+					},
+				},
+			},
+		}
+		if n := operationIDs[name]; n > 0 {
+			op.OperationID = name + "_" + strconv.Itoa(n+1)
+		}
+		operationIDs[name]++
+
+		if tag := strings.Split(strings.TrimPrefix(relative, "/"), "/")[0]; tag != "" && !strings.HasPrefix(tag, ":") {
+			op.Tags = []string{tag}
+			tags[tag] = true
+		}
+
+		handler := src.Handlers[name]
+		if handler != nil {
+			op.Summary = handler.Summary
+		}
+		op.Parameters, op.RequestBody = src.parameters(handler, route.Method, params)
+
+		setOperation(item, route.Method, op)
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+
+	return doc
+}
+
+// parameters 生成接口的路径参数、查询参数及请求正文
+func (src *Source) parameters(handler *Handler, method string, pathParams []string) ([]Parameter, *RequestBody) {
+	var (
+		params []Parameter
+		body   *RequestBody
+	)
+
+	uriFields := map[string]*Schema{}
+	if handler != nil {
+		for _, b := range handler.Bindings {
+			switch b.Method {
+			case BindURI:
+				schema := src.Schema(b.Package, b.Type, "uri")
+				for name, prop := range schema.Properties {
+					uriFields[name] = prop
+				}
+			case BindQuery:
+				params = append(params, src.queryParameters(b, "form")...)
+			case BindForm:
+				if method == http.MethodGet {
+					params = append(params, src.queryParameters(b, "form")...)
+					continue
+				}
+				body = &RequestBody{
+					Required: true,
+					Content: map[string]*MediaType{
+						"application/x-www-form-urlencoded": {Schema: src.Schema(b.Package, b.Type, "form")},
+					},
+				}
+			case BindJSON:
+				body = &RequestBody{
+					Required: true,
+					Content: map[string]*MediaType{
+						"application/json": {Schema: src.Schema(b.Package, b.Type, "json")},
+					},
+				}
+			}
+		}
+	}
+
+	pathParameters := make([]Parameter, 0, len(pathParams))
+	for _, name := range pathParams {
+		schema, ok := uriFields[name]
+		if !ok {
+			schema = &Schema{Type: "string"}
+		}
+		pathParameters = append(pathParameters, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+
+	return append(pathParameters, params...), body
+}
+
+// queryParameters 将参数结构的各字段转换为查询参数
+func (src *Source) queryParameters(b Binding, tagKey string) []Parameter {
+	schema := src.Schema(b.Package, b.Type, tagKey)
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]Parameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, Parameter{Name: name, In: "query", Required: required[name], Schema: schema.Properties[name]})
+	}
+	return params
+}
+
+// convertPath 将 gin 路由路径转换为 OpenAPI 路径，返回路径参数名
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// handlerName 从 gin 处理函数全名中取出函数名，闭包取其外层函数名
+func handlerName(handler string) string {
+	parts := strings.Split(handler[strings.LastIndex(handler, "/")+1:], ".")
+	if len(parts) < 2 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// setOperation 按请求方法设定接口
+func setOperation(item *PathItem, method string, op *Operation) {
+	switch method {
+	case http.MethodGet:
+		item.Get = op
+	case http.MethodPut:
+		item.Put = op
+	case http.MethodPost:
+		item.Post = op
+	case http.MethodDelete:
+		item.Delete = op
+	case http.MethodOptions:
+		item.Options = op
+	case http.MethodHead:
+		item.Head = op
+	case http.MethodPatch:
+		item.Patch = op
+	}
+}
@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertPath(t *testing.T) {
+	asserts := assert.New(t)
+
+	path, params := convertPath("/file/:id/thumb/*path")
+	asserts.Equal("/file/{id}/thumb/{path}", path)
+	asserts.Equal([]string{"id", "path"}, params)
+
+	path, params = convertPath("/site/ping")
+	asserts.Equal("/site/ping", path)
+	asserts.Empty(params)
+}
+
+func TestHandlerName(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.Equal("Ping", handlerName("github.com/cloudreve/Cloudreve/v3/routers/controllers.Ping"))
+	asserts.Equal("AdminOAuthURL", handlerName("github.com/cloudreve/Cloudreve/v3/routers/controllers.AdminOAuthURL.func1"))
+}
+
+func TestApplyBinding(t *testing.T) {
+	asserts := assert.New(t)
+
+	{
+		schema := &Schema{Type: "string"}
+		asserts.True(applyBinding(schema, "required,min=1,max=255"))
+		asserts.EqualValues(1, *schema.MinLength)
+		asserts.EqualValues(255, *schema.MaxLength)
+	}
+
+	{
+		schema := &Schema{Type: "integer"}
+		asserts.False(applyBinding(schema, "gte=0,lte=10"))
+		asserts.EqualValues(0, *schema.Minimum)
+		asserts.EqualValues(10, *schema.Maximum)
+	}
+
+	{
+		schema := &Schema{Type: "string"}
+		asserts.False(applyBinding(schema, "oneof=asc desc"))
+		asserts.Equal([]string{"asc", "desc"}, schema.Enum)
+	}
+
+	{
+		schema := &Schema{Type: "array"}
+		asserts.False(applyBinding(schema, "dive,required"))
+	}
+}
+
+func TestBuild(t *testing.T) {
+	asserts := assert.New(t)
+	src := &Source{Handlers: map[string]*Handler{
+		"GetFile": {Summary: "获取文件"},
+	}}
+	routes := gin.RoutesInfo{
+		{Method: "GET", Path: "/api/v3/file/:id", Handler: "controllers.GetFile"},
+		{Method: "DELETE", Path: "/api/v3/file/:id", Handler: "controllers.GetFile"},
+		{Method: "GET", Path: "/custom", Handler: "controllers.Custom"},
+	}
+
+	doc := Build(routes, src, "/api/v3", "API", "1.0")
+	asserts.Len(doc.Paths, 1)
+	item := doc.Paths["/file/{id}"]
+	asserts.NotNil(item)
+	asserts.Equal("GetFile", item.Delete.OperationID)
+	asserts.Equal("GetFile_2", item.Get.OperationID)
+	asserts.Equal("获取文件", item.Get.Summary)
+	asserts.Equal([]string{"file"}, item.Get.Tags)
+	asserts.Len(item.Get.Parameters, 1)
+	asserts.Equal("path", item.Get.Parameters[0].In)
+	asserts.Equal([]Tag{{Name: "file"}}, doc.Tags)
+}
@@ -0,0 +1,403 @@
+package openapi
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 请求参数的绑定方式
+const (
+	BindJSON  = "json"
+	BindURI   = "uri"
+	BindQuery = "query"
+	BindForm  = "form"
+)
+
+// bindMethods gin 绑定方法对应的绑定方式
+var bindMethods = map[string]string{
+	"ShouldBindJSON":  BindJSON,
+	"ShouldBindUri":   BindURI,
+	"ShouldBindQuery": BindQuery,
+	"ShouldBind":      BindForm,
+}
+
+// Binding 控制器绑定的请求参数结构
+type Binding struct {
+	Method  string
+	Package string
+	Type    string
+}
+
+// Handler 控制器的源码信息
+type Handler struct {
+	Summary  string
+	Bindings []Binding
+}
+
+// typeDecl 类型声明及其所在文件的导入表
+type typeDecl struct {
+	pkg     string
+	expr    ast.Expr
+	imports map[string]string
+}
+
+// Source 从源码中读取控制器的注释、绑定的请求参数及参数结构的定义
+type Source struct {
+	root     string
+	module   string
+	Handlers map[string]*Handler
+	types    map[string]map[string]*typeDecl
+}
+
+// NewSource 解析 root 下的控制器源码，root 为模块 module 的根目录，controllers 为控制器所在包的相对路径
+func NewSource(root, module, controllers string) (*Source, error) {
+	src := &Source{
+		root:     root,
+		module:   module,
+		Handlers: make(map[string]*Handler),
+		types:    make(map[string]map[string]*typeDecl),
+	}
+
+	files, err := parseDir(filepath.Join(root, controllers))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		imports := fileImports(file)
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil {
+				continue
+			}
+
+			src.Handlers[fn.Name.Name] = &Handler{
+				Summary:  summary(fn.Name.Name, fn.Doc),
+				Bindings: bindings(fn.Body, imports),
+			}
+		}
+	}
+
+	return src, nil
+}
+
+// parseDir 解析目录下的非测试源码文件
+func parseDir(dir string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// fileImports 返回文件中导入包的名称与路径
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := filepath.Base(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+// summary 取控制器注释的首行，去除开头的函数名
+func summary(name string, doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+
+	line := strings.TrimSpace(strings.Split(doc.Text(), "\n")[0])
+	return strings.TrimSpace(strings.TrimPrefix(line, name))
+}
+
+// bindings 找出控制器中声明的参数变量及其绑定方式
+func bindings(body *ast.BlockStmt, imports map[string]string) []Binding {
+	vars := make(map[string]Binding)
+	var res []Binding
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.ValueSpec:
+			if b, ok := typeRef(node.Type, imports); ok {
+				for _, name := range node.Names {
+					vars[name.Name] = b
+				}
+			}
+		case *ast.AssignStmt:
+			for i, rhs := range node.Rhs {
+				if unary, ok := rhs.(*ast.UnaryExpr); ok {
+					rhs = unary.X
+				}
+				lit, ok := rhs.(*ast.CompositeLit)
+				if !ok || i >= len(node.Lhs) {
+					continue
+				}
+				if ident, ok := node.Lhs[i].(*ast.Ident); ok {
+					if b, ok := typeRef(lit.Type, imports); ok {
+						vars[ident.Name] = b
+					}
+				}
+			}
+		case *ast.CallExpr:
+			sel, ok := node.Fun.(*ast.SelectorExpr)
+			if !ok || len(node.Args) != 1 {
+				return true
+			}
+			method, ok := bindMethods[sel.Sel.Name]
+			if !ok {
+				return true
+			}
+
+			arg := node.Args[0]
+			if unary, ok := arg.(*ast.UnaryExpr); ok {
+				arg = unary.X
+			}
+			if ident, ok := arg.(*ast.Ident); ok {
+				if b, ok := vars[ident.Name]; ok {
+					b.Method = method
+					res = append(res, b)
+				}
+			}
+		}
+		return true
+	})
+
+	return res
+}
+
+// typeRef 解析 pkg.Type 形式的类型引用
+func typeRef(expr ast.Expr, imports map[string]string) (Binding, bool) {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return Binding{}, false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return Binding{}, false
+	}
+	path, ok := imports[pkg.Name]
+	if !ok {
+		return Binding{}, false
+	}
+	return Binding{Package: path, Type: sel.Sel.Name}, true
+}
+
+// lookup 查找包内的类型声明，包不在当前模块中时返回 nil
+func (src *Source) lookup(pkg, name string) *typeDecl {
+	if !strings.HasPrefix(pkg, src.module) {
+		return nil
+	}
+
+	types, ok := src.types[pkg]
+	if !ok {
+		types = make(map[string]*typeDecl)
+		src.types[pkg] = types
+		files, _ := parseDir(filepath.Join(src.root, strings.TrimPrefix(pkg, src.module)))
+		for _, file := range files {
+			imports := fileImports(file)
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					types[ts.Name.Name] = &typeDecl{pkg: pkg, expr: ts.Type, imports: imports}
+				}
+			}
+		}
+	}
+
+	return types[name]
+}
+
+// maxDepth 展开嵌套结构的最大层数
+const maxDepth = 5
+
+// Schema 生成类型的结构定义，tagKey 为确定字段名所用的结构体标签
+func (src *Source) Schema(pkg, name, tagKey string) *Schema {
+	decl := src.lookup(pkg, name)
+	if decl == nil {
+		return &Schema{Type: "object"}
+	}
+	return src.schemaOf(decl, decl.expr, tagKey, 0)
+}
+
+func (src *Source) schemaOf(decl *typeDecl, expr ast.Expr, tagKey string, depth int) *Schema {
+	if depth > maxDepth {
+		return &Schema{}
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if schema := basicSchema(t.Name); schema != nil {
+			return schema
+		}
+		if named := src.lookup(decl.pkg, t.Name); named != nil {
+			return src.schemaOf(named, named.expr, tagKey, depth+1)
+		}
+		return &Schema{}
+	case *ast.StarExpr:
+		return src.schemaOf(decl, t.X, tagKey, depth)
+	case *ast.ArrayType:
+		if ident, ok := t.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: src.schemaOf(decl, t.Elt, tagKey, depth+1)}
+	case *ast.MapType:
+		return &Schema{Type: "object", AdditionalProperties: src.schemaOf(decl, t.Value, tagKey, depth+1)}
+	case *ast.SelectorExpr:
+		ref, ok := typeRef(t, decl.imports)
+		if !ok {
+			return &Schema{}
+		}
+		switch ref.Package + "." + ref.Type {
+		case "time.Time":
+			return &Schema{Type: "string", Format: "date-time"}
+		case "time.Duration":
+			return &Schema{Type: "integer", Format: "int64"}
+		}
+		if named := src.lookup(ref.Package, ref.Type); named != nil {
+			return src.schemaOf(named, named.expr, tagKey, depth+1)
+		}
+		return &Schema{}
+	case *ast.StructType:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		src.addFields(schema, decl, t, tagKey, depth)
+		if len(schema.Properties) == 0 {
+			schema.Properties = nil
+		}
+		return schema
+	}
+
+	return &Schema{}
+}
+
+// addFields 将结构体的字段加入 schema，匿名嵌入的结构体字段会被展开
+func (src *Source) addFields(schema *Schema, decl *typeDecl, st *ast.StructType, tagKey string, depth int) {
+	for _, field := range st.Fields.List {
+		var tag reflect.StructTag
+		if field.Tag != nil {
+			raw, _ := strconv.Unquote(field.Tag.Value)
+			tag = reflect.StructTag(raw)
+		}
+
+		name, _, _ := strings.Cut(tag.Get(tagKey), ",")
+		if name == "-" {
+			continue
+		}
+
+		if len(field.Names) == 0 {
+			// 匿名嵌入的结构体
+			embedded := src.schemaOf(decl, field.Type, tagKey, depth+1)
+			for k, v := range embedded.Properties {
+				schema.Properties[k] = v
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+
+			fieldName := name
+			if fieldName == "" {
+				fieldName = ident.Name
+			}
+
+			prop := src.schemaOf(decl, field.Type, tagKey, depth+1)
+			if applyBinding(prop, tag.Get("binding")) {
+				schema.Required = append(schema.Required, fieldName)
+			}
+			schema.Properties[fieldName] = prop
+		}
+	}
+}
+
+// applyBinding 将 binding 标签中的校验规则写入 schema，返回字段是否必填
+func applyBinding(schema *Schema, rules string) bool {
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "dive":
+			// 之后的规则作用于元素
+			return required
+		case "required":
+			required = true
+		case "oneof":
+			schema.Enum = strings.Fields(value)
+		case "min", "max", "gte", "lte":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			lower := key == "min" || key == "gte"
+			switch schema.Type {
+			case "integer", "number":
+				if lower {
+					schema.Minimum = &v
+				} else {
+					schema.Maximum = &v
+				}
+			case "string":
+				n := uint64(v)
+				if lower {
+					schema.MinLength = &n
+				} else {
+					schema.MaxLength = &n
+				}
+			}
+		}
+	}
+	return required
+}
+
+// basicSchema 返回基础类型的结构定义，非基础类型返回 nil
+func basicSchema(name string) *Schema {
+	switch name {
+	case "string":
+		return &Schema{Type: "string"}
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32":
+		return &Schema{Type: "integer"}
+	case "int64", "uint64":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "float32":
+		return &Schema{Type: "number", Format: "float"}
+	case "float64":
+		return &Schema{Type: "number", Format: "double"}
+	case "any":
+		return &Schema{}
+	}
+	return nil
+}
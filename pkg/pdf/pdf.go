@@ -0,0 +1,186 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+const (
+	// SourceName 缓存目录中源文件副本的文件名
+	SourceName = "source.pdf"
+	// PageContentType 页面预览图的 MIME 类型
+	PageContentType = "image/jpeg"
+
+	cacheFolder = "pdf"
+)
+
+var (
+	ErrPageOutOfRange = errors.New("page out of range")
+
+	pagesLine = regexp.MustCompile(`(?m)^Pages:\s+(\d+)`)
+
+	// 同一缓存目录内的操作需串行执行
+	locks sync.Map
+)
+
+// CacheDir 返回文件预览图的缓存目录，文件内容变化后目录随之改变
+func CacheDir(file *model.File) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%s", file.PolicyID, file.SourceName)))
+	return filepath.Join(
+		util.RelativePath(model.GetSettingByName("temp_path")),
+		cacheFolder,
+		fmt.Sprintf("%d_%s", file.ID, hex.EncodeToString(sum[:8])),
+	)
+}
+
+// PageName 返回第 page 页预览图的文件名
+func PageName(page int) string {
+	return fmt.Sprintf("page_%d.jpg", page)
+}
+
+// RenderPage 使用 pdftoppm 将 input 的第 page 页渲染为宽度不超过 width 的 JPEG 图像，保存至 output
+func RenderPage(ctx context.Context, executable, input string, page, width int, output string) error {
+	if err := os.MkdirAll(filepath.Dir(output), 0744); err != nil {
+		return fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	// pdftoppm 会为输出文件自动添加扩展名
+	cmd := exec.CommandContext(ctx, executable,
+		"-f", strconv.Itoa(page), "-l", strconv.Itoa(page),
+		"-scale-to-x", strconv.Itoa(width), "-scale-to-y", "-1",
+		"-jpeg", "-singlefile", input, strings.TrimSuffix(output, filepath.Ext(output)))
+
+	var stdErr bytes.Buffer
+	cmd.Stderr = &stdErr
+	if err := cmd.Run(); err != nil {
+		util.Log().Warning("Failed to invoke pdftoppm: %s", stdErr.String())
+		return fmt.Errorf("failed to invoke pdftoppm: %w", err)
+	}
+
+	return nil
+}
+
+// PageCount 使用 pdfinfo 获取 input 的页数
+func PageCount(ctx context.Context, executable, input string) (int, error) {
+	cmd := exec.CommandContext(ctx, executable, input)
+
+	var stdOut, stdErr bytes.Buffer
+	cmd.Stdout = &stdOut
+	cmd.Stderr = &stdErr
+	if err := cmd.Run(); err != nil {
+		util.Log().Warning("Failed to invoke pdfinfo: %s", stdErr.String())
+		return 0, fmt.Errorf("failed to invoke pdfinfo: %w", err)
+	}
+
+	return parsePageCount(stdOut.String())
+}
+
+func parsePageCount(info string) (int, error) {
+	match := pagesLine.FindStringSubmatch(info)
+	if match == nil {
+		return 0, errors.New("unknown output from pdfinfo")
+	}
+
+	return strconv.Atoi(match[1])
+}
+
+// Source 返回 dir 中源文件副本的路径，副本不存在时调用 fetch 写入
+func Source(dir string, fetch func(dst string) error) (string, error) {
+	unlock := lock(dir)
+	defer unlock()
+
+	dst := filepath.Join(dir, SourceName)
+	if util.Exists(dst) {
+		touch(dir)
+		return dst, nil
+	}
+
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return "", fmt.Errorf("failed to create cache folder: %w", err)
+	}
+
+	if err := fetch(dst); err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+
+	return dst, nil
+}
+
+// Page 返回 input 第 page 页的预览图路径，缓存不存在时渲染至 dir
+func Page(ctx context.Context, input, dir string, page int) (string, error) {
+	unlock := lock(dir)
+	defer unlock()
+
+	output := filepath.Join(dir, PageName(page))
+	if util.Exists(output) {
+		touch(dir)
+		return output, nil
+	}
+
+	options := model.GetSettingByNames("pdf_preview_pdftoppm_path", "pdf_preview_pdfinfo_path")
+	pages, err := PageCount(ctx, options["pdf_preview_pdfinfo_path"], input)
+	if err != nil {
+		return "", err
+	}
+
+	if page > pages {
+		return "", ErrPageOutOfRange
+	}
+
+	width := model.GetIntSetting("pdf_preview_width", 1280)
+	if err := RenderPage(ctx, options["pdf_preview_pdftoppm_path"], input, page, width, output); err != nil {
+		return "", err
+	}
+
+	return output, nil
+}
+
+// CollectCache 删除超过 ttl 秒未被访问的预览缓存
+func CollectCache(ttl int) {
+	root := filepath.Join(util.RelativePath(model.GetSettingByName("temp_path")), cacheFolder)
+	files, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil || time.Since(info.ModTime()).Seconds() <= float64(ttl) {
+			continue
+		}
+
+		dir := filepath.Join(root, f.Name())
+		unlock := lock(dir)
+		util.Log().Debug("Delete expired PDF preview cache %q.", dir)
+		os.RemoveAll(dir)
+		unlock()
+	}
+}
+
+func lock(dir string) func() {
+	mu, _ := locks.LoadOrStore(dir, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// touch 更新缓存目录的修改时间，用于判断缓存是否过期
+func touch(dir string) {
+	now := time.Now()
+	os.Chtimes(dir, now, now)
+}
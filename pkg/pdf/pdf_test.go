@@ -0,0 +1,83 @@
+package pdf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePageCount(t *testing.T) {
+	asserts := assert.New(t)
+
+	pages, err := parsePageCount("Producer:       pdfTeX\nPages:          12\nEncrypted:      no\n")
+	asserts.NoError(err)
+	asserts.Equal(12, pages)
+
+	_, err = parsePageCount("Syntax Error")
+	asserts.Error(err)
+}
+
+func TestCacheDir(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_temp_path", "temp", 0)
+	file := &model.File{SourceName: "a.pdf", PolicyID: 1}
+	file.ID = 1
+
+	dir := CacheDir(file)
+	file.SourceName = "b.pdf"
+	asserts.NotEqual(dir, CacheDir(file))
+}
+
+func TestSource(t *testing.T) {
+	asserts := assert.New(t)
+	dir := filepath.Join(t.TempDir(), "1_abc")
+
+	// 获取失败
+	{
+		_, err := Source(dir, func(dst string) error {
+			return errors.New("error")
+		})
+		asserts.Error(err)
+		asserts.NoFileExists(filepath.Join(dir, SourceName))
+	}
+
+	// 仅获取一次
+	{
+		calls := 0
+		fetch := func(dst string) error {
+			calls++
+			return os.WriteFile(dst, []byte("%PDF"), 0644)
+		}
+		_, err := Source(dir, fetch)
+		asserts.NoError(err)
+		res, err := Source(dir, fetch)
+		asserts.NoError(err)
+		asserts.Equal(1, calls)
+		asserts.FileExists(res)
+	}
+}
+
+func TestCollectCache(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_temp_path", t.TempDir(), 0)
+	file := &model.File{SourceName: "a.pdf"}
+	file.ID = 1
+	expired := CacheDir(file)
+	file.ID = 2
+	fresh := CacheDir(file)
+
+	asserts.NoError(os.MkdirAll(expired, 0744))
+	asserts.NoError(os.MkdirAll(fresh, 0744))
+	old := time.Now().Add(-time.Hour)
+	asserts.NoError(os.Chtimes(expired, old, old))
+
+	CollectCache(60)
+	asserts.NoDirExists(expired)
+	asserts.DirExists(fresh)
+}
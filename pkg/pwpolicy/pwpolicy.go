@@ -0,0 +1,147 @@
+package pwpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// pwnedRangeAPI HaveIBeenPwned 的 k-anonymity 查询接口，只需提交 SHA1 摘要的前 5 位
+const pwnedRangeAPI = "https://api.pwnedpasswords.com/range/"
+
+var (
+	ErrPasswordTooShort   = serializer.NewError(serializer.CodePasswordTooWeak, "Password is too short", nil)
+	ErrPasswordNoUpper    = serializer.NewError(serializer.CodePasswordTooWeak, "Password must contain uppercase letters", nil)
+	ErrPasswordNoLower    = serializer.NewError(serializer.CodePasswordTooWeak, "Password must contain lowercase letters", nil)
+	ErrPasswordNoDigit    = serializer.NewError(serializer.CodePasswordTooWeak, "Password must contain digits", nil)
+	ErrPasswordNoSymbol   = serializer.NewError(serializer.CodePasswordTooWeak, "Password must contain symbols", nil)
+	ErrPasswordBreached   = serializer.NewError(serializer.CodePasswordBreached, "This password has appeared in a data breach, please choose another one", nil)
+	ErrPasswordNotChanged = serializer.NewError(serializer.CodePasswordTooWeak, "New password must be different from the current one", nil)
+)
+
+// Policy 密码策略
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// BreachCheck 是否查询 HaveIBeenPwned 数据库
+	BreachCheck bool
+}
+
+// FromSettings 从站点设置中读取密码策略
+func FromSettings() Policy {
+	options := model.GetSettingByNames(
+		"password_require_upper",
+		"password_require_lower",
+		"password_require_digit",
+		"password_require_symbol",
+		"password_breach_check",
+	)
+
+	return Policy{
+		MinLength:     model.GetIntSetting("password_min_length", 4),
+		RequireUpper:  model.IsTrueVal(options["password_require_upper"]),
+		RequireLower:  model.IsTrueVal(options["password_require_lower"]),
+		RequireDigit:  model.IsTrueVal(options["password_require_digit"]),
+		RequireSymbol: model.IsTrueVal(options["password_require_symbol"]),
+		BreachCheck:   model.IsTrueVal(options["password_breach_check"]),
+	}
+}
+
+// Validate 检查密码是否满足长度和字符类别要求
+func (p Policy) Validate(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	switch {
+	case p.RequireUpper && !upper:
+		return ErrPasswordNoUpper
+	case p.RequireLower && !lower:
+		return ErrPasswordNoLower
+	case p.RequireDigit && !digit:
+		return ErrPasswordNoDigit
+	case p.RequireSymbol && !symbol:
+		return ErrPasswordNoSymbol
+	}
+
+	return nil
+}
+
+// Check 使用站点设置的策略检查新密码，开启泄露检查时查询 HaveIBeenPwned，
+// 查询服务不可用时不阻止设定密码
+func Check(password string) error {
+	policy := FromSettings()
+	if err := policy.Validate(password); err != nil {
+		return err
+	}
+
+	if policy.BreachCheck {
+		breached, err := Breached(request.NewClient(), password)
+		if err != nil {
+			util.Log().Warning("Failed to query HaveIBeenPwned: %s", err)
+			return nil
+		}
+
+		if breached {
+			return ErrPasswordBreached
+		}
+	}
+
+	return nil
+}
+
+// Breached 通过 k-anonymity 接口查询密码是否出现在已知泄露数据中
+func Breached(client request.Client, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	res, err := client.Request(
+		"GET",
+		pwnedRangeAPI+prefix,
+		nil,
+		request.WithHeader(http.Header{"Add-Padding": {"true"}}),
+		request.WithTimeout(5*time.Second),
+	).CheckHTTPResponse(http.StatusOK).GetResponse()
+	if err != nil {
+		return false, fmt.Errorf("failed to request range API: %w", err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(res))
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)
+		// 填充条目的出现次数为 0
+		if len(parts) == 2 && parts[0] == suffix && strings.TrimSpace(parts[1]) != "0" {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
@@ -0,0 +1,120 @@
+package pwpolicy
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/mocks/requestmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	a := assert.New(t)
+	policy := Policy{
+		MinLength:     8,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	}
+
+	a.Equal(ErrPasswordTooShort, policy.Validate("Ab1!"))
+	a.Equal(ErrPasswordNoUpper, policy.Validate("abcdefg1!"))
+	a.Equal(ErrPasswordNoLower, policy.Validate("ABCDEFG1!"))
+	a.Equal(ErrPasswordNoDigit, policy.Validate("Abcdefgh!"))
+	a.Equal(ErrPasswordNoSymbol, policy.Validate("Abcdefgh1"))
+	a.NoError(policy.Validate("Abcdefg1!"))
+
+	// 长度按字符计算
+	a.NoError(Policy{MinLength: 4}.Validate("密码密码"))
+}
+
+func TestFromSettings(t *testing.T) {
+	a := assert.New(t)
+	cache.SetSettings(map[string]string{
+		"password_min_length":     "10",
+		"password_require_upper":  "1",
+		"password_require_lower":  "0",
+		"password_require_digit":  "1",
+		"password_require_symbol": "0",
+		"password_breach_check":   "1",
+	}, "setting_")
+
+	policy := FromSettings()
+	a.Equal(Policy{
+		MinLength:    10,
+		RequireUpper: true,
+		RequireDigit: true,
+		BreachCheck:  true,
+	}, policy)
+
+	cache.Deletes([]string{
+		"password_min_length",
+		"password_require_upper",
+		"password_require_digit",
+		"password_breach_check",
+	}, "setting_")
+}
+
+func TestBreached(t *testing.T) {
+	a := assert.New(t)
+	// SHA1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+
+	// 请求失败
+	{
+		client := &requestmock.RequestMock{}
+		client.On("Request", "GET", pwnedRangeAPI+"5BAA6", testMock.Anything, testMock.Anything).
+			Return(&request.Response{Err: errors.New("error")})
+		_, err := Breached(client, "password")
+		a.Error(err)
+		client.AssertExpectations(t)
+	}
+
+	// 命中
+	{
+		client := &requestmock.RequestMock{}
+		client.On("Request", "GET", pwnedRangeAPI+"5BAA6", testMock.Anything, testMock.Anything).
+			Return(&request.Response{Response: &http.Response{
+				StatusCode: 200,
+				Body: ioutil.NopCloser(strings.NewReader(
+					"003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n",
+				)),
+			}})
+		breached, err := Breached(client, "password")
+		a.NoError(err)
+		a.True(breached)
+	}
+
+	// 仅命中填充条目
+	{
+		client := &requestmock.RequestMock{}
+		client.On("Request", "GET", pwnedRangeAPI+"5BAA6", testMock.Anything, testMock.Anything).
+			Return(&request.Response{Response: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("1E4C9B93F3F0682250B6CF8331B7EE68FD8:0\r\n")),
+			}})
+		breached, err := Breached(client, "password")
+		a.NoError(err)
+		a.False(breached)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+	cache.SetSettings(map[string]string{
+		"password_min_length":     "6",
+		"password_require_upper":  "0",
+		"password_require_lower":  "0",
+		"password_require_digit":  "0",
+		"password_require_symbol": "0",
+		"password_breach_check":   "0",
+	}, "setting_")
+	a.Equal(ErrPasswordTooShort, Check("12345"))
+	a.NoError(Check("123456"))
+}
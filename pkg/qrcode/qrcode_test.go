@@ -0,0 +1,47 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPNG(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 尺寸无效
+	{
+		_, err := PNG("https://cloudreve.org/s/abc", 10)
+		asserts.Equal(ErrInvalidSize, err)
+	}
+
+	// 成功
+	{
+		res, err := PNG("https://cloudreve.org/s/abc", DefaultSize)
+		asserts.NoError(err)
+		img, err := png.Decode(bytes.NewReader(res))
+		asserts.NoError(err)
+		asserts.Equal(DefaultSize, img.Bounds().Dx())
+	}
+}
+
+func TestSVG(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 尺寸无效
+	{
+		_, err := SVG("https://cloudreve.org/s/abc", MaxSize+1)
+		asserts.Equal(ErrInvalidSize, err)
+	}
+
+	// 成功
+	{
+		res, err := SVG("https://cloudreve.org/s/abc", DefaultSize)
+		asserts.NoError(err)
+		asserts.True(strings.HasPrefix(string(res), "<svg"))
+		asserts.Contains(string(res), `width="256"`)
+	}
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+const backoffPrefix = "backoff_"
+
+// Backoff 失败尝试计数器，连续失败超出免罚次数后按指数退避临时锁定
+type Backoff struct {
+	// Key 计数对象的唯一标识
+	Key string
+	// Free 不触发锁定的失败次数
+	Free int
+	// Base 首次锁定时长，之后每次失败翻倍
+	Base time.Duration
+	// Max 最大锁定时长
+	Max time.Duration
+	// Window 失败计数的保留时长，期间没有新的失败时计数清零
+	Window time.Duration
+}
+
+func (b *Backoff) failuresKey() string {
+	return backoffPrefix + b.Key + "_n"
+}
+
+func (b *Backoff) untilKey() string {
+	return backoffPrefix + b.Key + "_until"
+}
+
+// Locked 返回当前是否处于锁定状态，以及剩余的锁定时长
+func (b *Backoff) Locked() (time.Duration, bool) {
+	until, ok := cache.Get(b.untilKey())
+	if !ok {
+		return 0, false
+	}
+
+	remain := time.Until(time.Unix(until.(int64), 0))
+	return remain, remain > 0
+}
+
+// Fail 记录一次失败，返回累计失败次数以及由此产生的锁定时长。
+// 计数原子地增加，并发的失败尝试不会被漏记
+func (b *Backoff) Fail() (int, time.Duration) {
+	failures, err := cache.Incr(b.failuresKey(), int(b.Window.Seconds()))
+	if err != nil {
+		util.Log().Warning("Failed to count failure for %q: %s", b.Key, err)
+		return 0, 0
+	}
+
+	lock := b.lockFor(failures)
+	if lock > 0 {
+		_ = cache.Set(b.untilKey(), time.Now().Add(lock).Unix(), int(lock.Seconds())+1)
+	}
+
+	return failures, lock
+}
+
+// Reset 清除失败计数与锁定状态
+func (b *Backoff) Reset() {
+	_ = cache.Deletes([]string{b.failuresKey(), b.untilKey()}, "")
+}
+
+// lockFor 计算第 failures 次失败后的锁定时长
+func (b *Backoff) lockFor(failures int) time.Duration {
+	exceeded := failures - b.Free
+	if exceeded <= 0 || b.Base <= 0 {
+		return 0
+	}
+
+	lock := b.Base
+	for i := 1; i < exceeded; i++ {
+		lock *= 2
+		if b.Max > 0 && lock >= b.Max {
+			return b.Max
+		}
+	}
+
+	if b.Max > 0 && lock > b.Max {
+		return b.Max
+	}
+	return lock
+}
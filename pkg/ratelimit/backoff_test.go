@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff_lockFor(t *testing.T) {
+	a := assert.New(t)
+	b := &Backoff{Free: 3, Base: 2 * time.Second, Max: 10 * time.Second}
+
+	a.Equal(time.Duration(0), b.lockFor(3))
+	a.Equal(2*time.Second, b.lockFor(4))
+	a.Equal(4*time.Second, b.lockFor(5))
+	a.Equal(8*time.Second, b.lockFor(6))
+	a.Equal(10*time.Second, b.lockFor(7))
+	a.Equal(10*time.Second, b.lockFor(100))
+}
+
+func TestBackoff_Fail(t *testing.T) {
+	a := assert.New(t)
+	b := &Backoff{Key: "test_fail", Free: 1, Base: time.Minute, Max: time.Hour, Window: time.Hour}
+	b.Reset()
+
+	failures, lock := b.Fail()
+	a.Equal(1, failures)
+	a.Equal(time.Duration(0), lock)
+	_, locked := b.Locked()
+	a.False(locked)
+
+	failures, lock = b.Fail()
+	a.Equal(2, failures)
+	a.Equal(time.Minute, lock)
+	remain, locked := b.Locked()
+	a.True(locked)
+	a.True(remain > 0 && remain <= time.Minute)
+
+	b.Reset()
+	_, locked = b.Locked()
+	a.False(locked)
+	failures, _ = b.Fail()
+	a.Equal(1, failures)
+}
+
+func TestBackoff_Fail_Concurrent(t *testing.T) {
+	a := assert.New(t)
+	b := &Backoff{Key: "test_fail_concurrent", Window: time.Hour}
+	b.Reset()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Fail()
+		}()
+	}
+	wg.Wait()
+
+	failures, _ := b.Fail()
+	a.Equal(51, failures)
+}
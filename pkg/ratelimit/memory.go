@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// 内存中令牌桶数量超过该值时清理闲置的令牌桶
+const memoryPruneThreshold = 10000
+
+type memoryBucket struct {
+	limiter  *rate.Limiter
+	period   time.Duration
+	lastSeen time.Time
+}
+
+// MemoryLimiter 基于进程内存的限流器，仅适用于单节点部署
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryLimiter 新建内存限流器
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*memoryBucket),
+	}
+}
+
+// Allow 实现 Limiter
+func (m *MemoryLimiter) Allow(key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	every := rate.Every(period / time.Duration(limit))
+	bucket, ok := m.buckets[key]
+	if !ok || bucket.limiter.Limit() != every || bucket.limiter.Burst() != limit {
+		if len(m.buckets) >= memoryPruneThreshold {
+			m.prune(now)
+		}
+
+		bucket = &memoryBucket{limiter: rate.NewLimiter(every, limit)}
+		m.buckets[key] = bucket
+	}
+
+	bucket.lastSeen = now
+	bucket.period = period
+	reservation := bucket.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay, nil
+	}
+
+	return true, 0, nil
+}
+
+// prune 清理超过一个周期未使用的令牌桶，此时桶已补满，删除不影响限流结果
+func (m *MemoryLimiter) prune(now time.Time) {
+	for key, bucket := range m.buckets {
+		if now.Sub(bucket.lastSeen) > bucket.period {
+			delete(m.buckets, key)
+		}
+	}
+}
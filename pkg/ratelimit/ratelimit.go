@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+const keyPrefix = "ratelimit_"
+
+// Limiter 令牌桶限流器
+type Limiter interface {
+	// Allow 尝试从 key 对应的令牌桶中取走一个令牌，桶容量为 limit，每 period 补满。
+	// 令牌不足时返回 false 以及建议的重试等待时间
+	Allow(key string, limit int, period time.Duration) (bool, time.Duration, error)
+}
+
+// Rule 单个路由的限流规则
+type Rule struct {
+	// Limit 周期内允许的最大请求数，为 0 时不限制
+	Limit int `json:"limit"`
+	// Period 统计周期，单位为秒
+	Period int `json:"period"`
+	// Groups 针对特定用户组覆盖 Limit，值为 0 时该用户组不限制
+	Groups map[uint]int `json:"groups,omitempty"`
+}
+
+// LimitFor 返回指定用户组适用的限额
+func (r *Rule) LimitFor(groupID uint) int {
+	if limit, ok := r.Groups[groupID]; ok {
+		return limit
+	}
+
+	return r.Limit
+}
+
+var (
+	// Default 全局限流器
+	Default   Limiter = NewMemoryLimiter()
+	DefaultMu sync.RWMutex
+
+	rulesMu  sync.Mutex
+	rulesRaw string
+	rules    map[string]Rule
+)
+
+// Init 初始化全局限流器，使用 Redis 缓存时各节点共享令牌桶
+func Init() {
+	var limiter Limiter
+	if store, ok := cache.Store.(*cache.RedisStore); ok {
+		limiter = NewRedisLimiter(store.Pool())
+	} else {
+		limiter = NewMemoryLimiter()
+	}
+
+	DefaultMu.Lock()
+	Default = limiter
+	DefaultMu.Unlock()
+}
+
+// GetRule 获取站点设置中指定作用域的限流规则
+func GetRule(scope string) (Rule, bool) {
+	raw := model.GetSettingByName("rate_limit_rules")
+
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	if raw != rulesRaw || rules == nil {
+		parsed := make(map[string]Rule)
+		if raw != "" {
+			if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+				util.Log().Warning("Failed to parse rate limit rules: %s", err)
+			}
+		}
+
+		rulesRaw = raw
+		rules = parsed
+	}
+
+	rule, ok := rules[scope]
+	return rule, ok
+}
+
+// Allow 根据作用域规则对 identity 进行限流
+func Allow(scope, identity string, groupID uint) (bool, time.Duration, error) {
+	rule, ok := GetRule(scope)
+	if !ok {
+		return true, 0, nil
+	}
+
+	limit := rule.LimitFor(groupID)
+	if limit <= 0 || rule.Period <= 0 {
+		return true, 0, nil
+	}
+
+	DefaultMu.RLock()
+	limiter := Default
+	DefaultMu.RUnlock()
+
+	return limiter.Allow(keyPrefix+scope+"_"+identity, limit, time.Duration(rule.Period)*time.Second)
+}
@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/gomodule/redigo/redis"
+	"github.com/rafaeljusto/redigomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	a := assert.New(t)
+	limiter := NewMemoryLimiter()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow("key", 3, time.Minute)
+		a.NoError(err)
+		a.True(allowed)
+	}
+
+	allowed, wait, err := limiter.Allow("key", 3, time.Minute)
+	a.NoError(err)
+	a.False(allowed)
+	a.True(wait > 0 && wait <= 20*time.Second)
+
+	// 不同的键互不影响
+	allowed, _, err = limiter.Allow("another", 3, time.Minute)
+	a.NoError(err)
+	a.True(allowed)
+
+	// 规则变化时重建令牌桶
+	allowed, _, err = limiter.Allow("key", 5, time.Minute)
+	a.NoError(err)
+	a.True(allowed)
+}
+
+func TestMemoryLimiter_prune(t *testing.T) {
+	a := assert.New(t)
+	limiter := NewMemoryLimiter()
+	limiter.Allow("idle", 1, time.Millisecond)
+	limiter.Allow("active", 1, time.Hour)
+	limiter.buckets["idle"].lastSeen = time.Now().Add(-time.Second)
+
+	limiter.prune(time.Now())
+	a.Len(limiter.buckets, 1)
+	a.Contains(limiter.buckets, "active")
+}
+
+func TestRedisLimiter_Allow(t *testing.T) {
+	a := assert.New(t)
+	conn := redigomock.NewConn()
+	limiter := NewRedisLimiter(&redis.Pool{
+		Dial:    func() (redis.Conn, error) { return conn, nil },
+		MaxIdle: 10,
+	})
+
+	// 允许
+	{
+		conn.Clear()
+		conn.GenericCommand("EVALSHA").Expect([]interface{}{int64(1), int64(0)})
+		allowed, _, err := limiter.Allow("key", 1, time.Minute)
+		a.NoError(err)
+		a.True(allowed)
+	}
+
+	// 拒绝
+	{
+		conn.Clear()
+		conn.GenericCommand("EVALSHA").Expect([]interface{}{int64(0), int64(1500)})
+		allowed, wait, err := limiter.Allow("key", 1, time.Minute)
+		a.NoError(err)
+		a.False(allowed)
+		a.Equal(1500*time.Millisecond, wait)
+	}
+
+	// 出错时放行
+	{
+		conn.Clear()
+		conn.GenericCommand("EVALSHA").ExpectError(errors.New("error"))
+		allowed, _, err := limiter.Allow("key", 1, time.Minute)
+		a.Error(err)
+		a.True(allowed)
+	}
+}
+
+func TestGetRule(t *testing.T) {
+	a := assert.New(t)
+
+	// 规则无效
+	cache.Set("setting_rate_limit_rules", "{", 0)
+	_, ok := GetRule("login")
+	a.False(ok)
+
+	cache.Set("setting_rate_limit_rules", `{"login":{"limit":5,"period":60,"groups":{"1":0,"2":10}}}`, 0)
+	rule, ok := GetRule("login")
+	a.True(ok)
+	a.Equal(5, rule.LimitFor(3))
+	a.Equal(10, rule.LimitFor(2))
+	a.Equal(0, rule.LimitFor(1))
+
+	_, ok = GetRule("not_exist")
+	a.False(ok)
+}
+
+func TestAllow(t *testing.T) {
+	a := assert.New(t)
+	Default = NewMemoryLimiter()
+	cache.Set("setting_rate_limit_rules", `{"test":{"limit":1,"period":60,"groups":{"1":0}},"invalid":{"limit":1}}`, 0)
+
+	// 未配置规则
+	allowed, _, _ := Allow("not_exist", "ip", 3)
+	a.True(allowed)
+
+	// 周期无效
+	allowed, _, _ = Allow("invalid", "ip", 3)
+	a.True(allowed)
+	allowed, _, _ = Allow("invalid", "ip", 3)
+	a.True(allowed)
+
+	// 用户组不限制
+	for i := 0; i < 3; i++ {
+		allowed, _, _ = Allow("test", "uid_1", 1)
+		a.True(allowed)
+	}
+
+	allowed, _, _ = Allow("test", "ip", 3)
+	a.True(allowed)
+	allowed, wait, _ := Allow("test", "ip", 3)
+	a.False(allowed)
+	a.True(wait > 0)
+}
+
+func TestInit(t *testing.T) {
+	a := assert.New(t)
+	Init()
+	a.IsType(&MemoryLimiter{}, Default)
+}
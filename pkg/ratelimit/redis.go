@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// tokenBucketScript 在 Redis 中原子地补充并消耗令牌，
+// 返回 {是否允许, 建议等待毫秒数}
+var tokenBucketScript = redis.NewScript(1, `
+local burst = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * burst / period)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) * period / burst)
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], period)
+return {allowed, wait}
+`)
+
+// RedisLimiter 基于 Redis 的限流器，适用于多节点部署
+type RedisLimiter struct {
+	pool *redis.Pool
+}
+
+// NewRedisLimiter 新建 Redis 限流器
+func NewRedisLimiter(pool *redis.Pool) *RedisLimiter {
+	return &RedisLimiter{pool: pool}
+}
+
+// Allow 实现 Limiter
+func (r *RedisLimiter) Allow(key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	rc := r.pool.Get()
+	defer rc.Close()
+	if rc.Err() != nil {
+		return true, 0, rc.Err()
+	}
+
+	res, err := redis.Int64s(tokenBucketScript.Do(rc, key, limit, period.Milliseconds(), time.Now().UnixNano()/int64(time.Millisecond)))
+	if err != nil {
+		return true, 0, err
+	}
+
+	if len(res) != 2 || res[0] == 1 {
+		return true, 0, nil
+	}
+
+	return false, time.Duration(res[1]) * time.Millisecond, nil
+}
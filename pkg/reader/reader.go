@@ -0,0 +1,369 @@
+package reader
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/nwaples/rardecode/v2"
+)
+
+// 支持的阅读格式
+const (
+	FormatEPUB = "epub"
+	FormatCBZ  = "cbz"
+	FormatCBR  = "cbr"
+)
+
+const (
+	// SessionCachePrefix 阅读会话的缓存键前缀
+	SessionCachePrefix = "reader_session_"
+
+	cacheFolder = "reader"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported book format")
+	ErrInvalidBook       = errors.New("invalid or corrupted book file")
+	ErrEntryNotFound     = errors.New("entry not found")
+
+	pageExts = []string{"jpg", "jpeg", "png", "gif", "webp", "bmp"}
+
+	// 同一缓存目录内的操作需串行执行
+	locks sync.Map
+)
+
+// SessionCache 缓存中保存的阅读会话
+type SessionCache struct {
+	FileID  uint
+	UserID  uint
+	Format  string
+	Archive string
+}
+
+func init() {
+	gob.Register(SessionCache{})
+}
+
+// Manifest 书籍的阅读清单
+type Manifest struct {
+	Format string `json:"format"`
+	Title  string `json:"title,omitempty"`
+	// Entries 为 EPUB 的章节或漫画的页面，按阅读顺序排列
+	Entries []string `json:"entries"`
+}
+
+// Format 根据文件名返回阅读格式，不支持时返回空字符串
+func Format(name string) string {
+	switch strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")) {
+	case "epub":
+		return FormatEPUB
+	case "cbz":
+		return FormatCBZ
+	case "cbr":
+		return FormatCBR
+	}
+	return ""
+}
+
+// ContentType 返回条目的 MIME 类型
+func ContentType(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".xhtml", ".xht":
+		return "application/xhtml+xml"
+	case ".ncx":
+		return "application/x-dtbncx+xml"
+	case ".opf":
+		return "application/oebps-package+xml"
+	}
+
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// Parse 解析书籍文件，返回阅读清单
+func Parse(archive, format string) (*Manifest, error) {
+	switch format {
+	case FormatEPUB:
+		return parseEPUB(archive)
+	case FormatCBZ:
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return nil, ErrInvalidBook
+		}
+		defer r.Close()
+
+		names := make([]string, 0, len(r.File))
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		return &Manifest{Format: format, Entries: comicPages(names)}, nil
+	case FormatCBR:
+		r, err := rardecode.OpenReader(archive)
+		if err != nil {
+			return nil, ErrInvalidBook
+		}
+		defer r.Close()
+
+		var names []string
+		for {
+			header, err := r.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, ErrInvalidBook
+			}
+			if !header.IsDir {
+				names = append(names, header.Name)
+			}
+		}
+		return &Manifest{Format: format, Entries: comicPages(names)}, nil
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// Open 打开书籍中的条目，w 用于写出条目内容
+func Open(archive, format, name string, w io.Writer) error {
+	switch format {
+	case FormatEPUB, FormatCBZ:
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return ErrInvalidBook
+		}
+		defer r.Close()
+
+		for _, f := range r.File {
+			if f.Name == name {
+				rc, err := f.Open()
+				if err != nil {
+					return ErrInvalidBook
+				}
+				defer rc.Close()
+				_, err = io.Copy(w, rc)
+				return err
+			}
+		}
+	case FormatCBR:
+		// RAR 不支持随机读取，需顺序查找条目
+		r, err := rardecode.OpenReader(archive)
+		if err != nil {
+			return ErrInvalidBook
+		}
+		defer r.Close()
+
+		for {
+			header, err := r.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return ErrInvalidBook
+			}
+			if header.Name == name {
+				_, err = io.Copy(w, r)
+				return err
+			}
+		}
+	default:
+		return ErrUnsupportedFormat
+	}
+
+	return ErrEntryNotFound
+}
+
+// comicPages 筛选出图片，并按自然顺序排列
+func comicPages(names []string) []string {
+	pages := make([]string, 0, len(names))
+	for _, name := range names {
+		base := path.Base(name)
+		if strings.HasPrefix(base, ".") || strings.HasPrefix(name, "__MACOSX/") {
+			continue
+		}
+		if util.IsInExtensionList(pageExts, name) {
+			pages = append(pages, name)
+		}
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		return naturalLess(pages[i], pages[j])
+	})
+	return pages
+}
+
+// naturalLess 按自然顺序比较，连续的数字按数值大小比较
+func naturalLess(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			na, nb := digitRun(a), digitRun(b)
+			ta, tb := strings.TrimLeft(a[:na], "0"), strings.TrimLeft(b[:nb], "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			a, b = a[na:], b[nb:]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func digitRun(s string) int {
+	n := 0
+	for n < len(s) && isDigit(s[n]) {
+		n++
+	}
+	return n
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Manifest []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func parseEPUB(archive string) (*Manifest, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, ErrInvalidBook
+	}
+	defer r.Close()
+
+	var container epubContainer
+	if err := decodeXML(&r.Reader, "META-INF/container.xml", &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil, ErrInvalidBook
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := decodeXML(&r.Reader, opfPath, &pkg); err != nil {
+		return nil, ErrInvalidBook
+	}
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+
+	manifest := &Manifest{Format: FormatEPUB, Entries: make([]string, 0, len(pkg.Spine))}
+	if len(pkg.Title) > 0 {
+		manifest.Title = strings.TrimSpace(pkg.Title[0])
+	}
+
+	// 章节路径相对于 OPF 文件所在目录
+	base := path.Dir(opfPath)
+	for _, ref := range pkg.Spine {
+		if href, ok := hrefs[ref.IDRef]; ok {
+			manifest.Entries = append(manifest.Entries, path.Join(base, strings.SplitN(href, "#", 2)[0]))
+		}
+	}
+
+	return manifest, nil
+}
+
+func decodeXML(r *zip.Reader, name string, v interface{}) error {
+	for _, f := range r.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			return xml.NewDecoder(rc).Decode(v)
+		}
+	}
+	return ErrEntryNotFound
+}
+
+// CacheDir 返回非本机存储的书籍文件的缓存目录，文件内容变化后目录随之改变
+func CacheDir(file *model.File) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%s", file.PolicyID, file.SourceName)))
+	return filepath.Join(
+		util.RelativePath(model.GetSettingByName("temp_path")),
+		cacheFolder,
+		fmt.Sprintf("%d_%s", file.ID, hex.EncodeToString(sum[:8])),
+	)
+}
+
+// Source 返回 dir 中书籍文件副本的路径，副本不存在时调用 fetch 写入
+func Source(dir, format string, fetch func(dst string) error) (string, error) {
+	mu, _ := locks.LoadOrStore(dir, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	dst := filepath.Join(dir, "source."+format)
+	if util.Exists(dst) {
+		now := time.Now()
+		os.Chtimes(dir, now, now)
+		return dst, nil
+	}
+
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		return "", fmt.Errorf("failed to create cache folder: %w", err)
+	}
+
+	if err := fetch(dst); err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+
+	return dst, nil
+}
+
+// CollectCache 删除超过 ttl 秒未被访问的书籍缓存
+func CollectCache(ttl int) {
+	root := filepath.Join(util.RelativePath(model.GetSettingByName("temp_path")), cacheFolder)
+	files, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil || time.Since(info.ModTime()).Seconds() <= float64(ttl) {
+			continue
+		}
+
+		dir := filepath.Join(root, f.Name())
+		util.Log().Debug("Delete expired book cache %q.", dir)
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,143 @@
+package reader
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeZip(t *testing.T, name string, files map[string]string) string {
+	dst := filepath.Join(t.TempDir(), name)
+	out, err := os.Create(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	for name, content := range files {
+		f, _ := w.Create(name)
+		f.Write([]byte(content))
+	}
+	w.Close()
+	return dst
+}
+
+func TestFormat(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.Equal(FormatEPUB, Format("book.EPUB"))
+	asserts.Equal(FormatCBZ, Format("comic.cbz"))
+	asserts.Equal(FormatCBR, Format("comic.cbr"))
+	asserts.Equal("", Format("comic.zip"))
+}
+
+func TestContentType(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.Equal("application/xhtml+xml", ContentType("OEBPS/ch1.xhtml"))
+	asserts.Equal("image/png", ContentType("1.png"))
+	asserts.Equal("application/octet-stream", ContentType("unknown"))
+}
+
+func TestNaturalLess(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.True(naturalLess("page2.jpg", "page10.jpg"))
+	asserts.True(naturalLess("Page02.jpg", "page3.jpg"))
+	asserts.False(naturalLess("b/1.jpg", "a/2.jpg"))
+	asserts.True(naturalLess("a", "ab"))
+}
+
+func TestParse_Comic(t *testing.T) {
+	asserts := assert.New(t)
+	archive := writeZip(t, "comic.cbz", map[string]string{
+		"10.jpg":           "",
+		"2.png":            "",
+		"1.webp":           "",
+		"ComicInfo.xml":    "",
+		"__MACOSX/._1.jpg": "",
+		".hidden.jpg":      "",
+	})
+
+	manifest, err := Parse(archive, FormatCBZ)
+	asserts.NoError(err)
+	asserts.Equal([]string{"1.webp", "2.png", "10.jpg"}, manifest.Entries)
+
+	buf := &bytes.Buffer{}
+	asserts.Equal(ErrEntryNotFound, Open(archive, FormatCBZ, "3.jpg", buf))
+
+	_, err = Parse(filepath.Join(t.TempDir(), "none.cbz"), FormatCBZ)
+	asserts.Equal(ErrInvalidBook, err)
+
+	_, err = Parse(archive, "zip")
+	asserts.Equal(ErrUnsupportedFormat, err)
+}
+
+func TestParse_EPUB(t *testing.T) {
+	asserts := assert.New(t)
+	archive := writeZip(t, "book.epub", map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title> Book </dc:title></metadata>
+  <manifest>
+    <item id="c1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="text/ch2.xhtml#start" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c2"/><itemref idref="c1"/><itemref idref="missing"/></spine>
+</package>`,
+		"OEBPS/text/ch1.xhtml": "chapter 1",
+	})
+
+	manifest, err := Parse(archive, FormatEPUB)
+	asserts.NoError(err)
+	asserts.Equal("Book", manifest.Title)
+	asserts.Equal([]string{"OEBPS/text/ch2.xhtml", "OEBPS/text/ch1.xhtml"}, manifest.Entries)
+
+	buf := &bytes.Buffer{}
+	asserts.NoError(Open(archive, FormatEPUB, "OEBPS/text/ch1.xhtml", buf))
+	asserts.Equal("chapter 1", buf.String())
+
+	// 缺少 container.xml
+	archive = writeZip(t, "broken.epub", map[string]string{"mimetype": "application/epub+zip"})
+	_, err = Parse(archive, FormatEPUB)
+	asserts.Equal(ErrInvalidBook, err)
+}
+
+func TestCacheDir(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_temp_path", "temp", 0)
+	file := &model.File{SourceName: "a.epub", PolicyID: 1}
+	file.ID = 1
+
+	dir := CacheDir(file)
+	file.SourceName = "b.epub"
+	asserts.NotEqual(dir, CacheDir(file))
+}
+
+func TestSource(t *testing.T) {
+	asserts := assert.New(t)
+	dir := filepath.Join(t.TempDir(), "1_abc")
+
+	called := 0
+	fetch := func(dst string) error {
+		called++
+		return os.WriteFile(dst, []byte("book"), 0644)
+	}
+
+	src, err := Source(dir, FormatCBZ, fetch)
+	asserts.NoError(err)
+	asserts.Equal(filepath.Join(dir, "source.cbz"), src)
+
+	_, err = Source(dir, FormatCBZ, fetch)
+	asserts.NoError(err)
+	asserts.Equal(1, called)
+}
@@ -0,0 +1,591 @@
+package render
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Resolver 改写相对路径的资源地址，返回空字符串时保留原地址
+type Resolver func(src string) string
+
+var (
+	atxHeading   = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	thematic     = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	bulletItem   = regexp.MustCompile(`^( {0,3})([-*+])[ \t]+(.*)$`)
+	orderedItem  = regexp.MustCompile(`^( {0,3})(\d{1,9})[.)][ \t]+(.*)$`)
+	tableDivider = regexp.MustCompile(`^\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	safeScheme   = regexp.MustCompile(`^(?i)(https?|mailto):`)
+	safeDataURI  = regexp.MustCompile(`^(?i)data:image/(png|jpeg|gif|webp);base64,[a-z0-9+/=]+$`)
+	hasScheme    = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// Markdown 将 Markdown 文本渲染为 HTML。原始 HTML 一律转义输出，链接与图片只保留安全的地址，
+// resolve 用于改写相对路径的图片地址，可为 nil
+func Markdown(src string, resolve Resolver) string {
+	r := &markdown{resolve: resolve}
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n", "\t", "    ").Replace(src), "\n")
+	r.blocks(lines)
+	return r.out.String()
+}
+
+type markdown struct {
+	out     strings.Builder
+	resolve Resolver
+}
+
+// blocks 渲染块级元素
+func (r *markdown) blocks(lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			i++
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			i = r.fencedCode(lines, i)
+		case atxHeading.MatchString(trimmed) && !strings.HasPrefix(line, "    "):
+			m := atxHeading.FindStringSubmatch(trimmed)
+			r.element("h"+string(rune('0'+len(m[1]))), r.inline(m[2]))
+			i++
+		case thematic.MatchString(line):
+			r.out.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(trimmed, ">") && !strings.HasPrefix(line, "    "):
+			i = r.blockquote(lines, i)
+		case bulletItem.MatchString(line) || orderedItem.MatchString(line):
+			i = r.list(lines, i)
+		case strings.HasPrefix(line, "    "):
+			i = r.indentedCode(lines, i)
+		case strings.Contains(line, "|") && i+1 < len(lines) && tableDivider.MatchString(strings.TrimSpace(lines[i+1])) &&
+			strings.Contains(lines[i+1], "-"):
+			i = r.table(lines, i)
+		default:
+			i = r.paragraph(lines, i)
+		}
+	}
+}
+
+func (r *markdown) element(tag, content string) {
+	r.out.WriteString("<" + tag + ">" + content + "</" + tag + ">\n")
+}
+
+func (r *markdown) fencedCode(lines []string, start int) int {
+	open := strings.TrimSpace(lines[start])
+	fence := open[:3]
+	lang := strings.Fields(strings.TrimLeft(open, fence[:1]))
+
+	var code []string
+	i := start + 1
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+			i++
+			break
+		}
+		code = append(code, lines[i])
+	}
+
+	r.out.WriteString("<pre><code")
+	if len(lang) > 0 {
+		r.out.WriteString(` class="language-` + html.EscapeString(lang[0]) + `"`)
+	}
+	r.out.WriteString(">" + html.EscapeString(strings.Join(code, "\n")))
+	if len(code) > 0 {
+		r.out.WriteString("\n")
+	}
+	r.out.WriteString("</code></pre>\n")
+	return i
+}
+
+func (r *markdown) indentedCode(lines []string, start int) int {
+	var code []string
+	i := start
+	for ; i < len(lines); i++ {
+		if strings.HasPrefix(lines[i], "    ") {
+			code = append(code, lines[i][4:])
+		} else if strings.TrimSpace(lines[i]) == "" {
+			code = append(code, "")
+		} else {
+			break
+		}
+	}
+
+	// 去除末尾空行
+	for len(code) > 0 && code[len(code)-1] == "" {
+		code = code[:len(code)-1]
+	}
+
+	r.out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "\n</code></pre>\n")
+	return i
+}
+
+func (r *markdown) blockquote(lines []string, start int) int {
+	var inner []string
+	i := start
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(trimmed, ">") {
+			if trimmed == "" || len(inner) == 0 {
+				break
+			}
+			// 惰性延续行
+			inner = append(inner, lines[i])
+			continue
+		}
+		trimmed = strings.TrimPrefix(trimmed, ">")
+		inner = append(inner, strings.TrimPrefix(trimmed, " "))
+	}
+
+	r.out.WriteString("<blockquote>\n")
+	r.blocks(inner)
+	r.out.WriteString("</blockquote>\n")
+	return i
+}
+
+func listMarker(line string) (ordered bool, indent int, content string, ok bool) {
+	if m := bulletItem.FindStringSubmatch(line); m != nil {
+		return false, len(m[1]) + len(m[2]) + 1, m[3], true
+	}
+	if m := orderedItem.FindStringSubmatch(line); m != nil {
+		return true, len(m[1]) + len(m[2]) + 2, m[3], true
+	}
+	return false, 0, "", false
+}
+
+func (r *markdown) list(lines []string, start int) int {
+	ordered, _, _, _ := listMarker(lines[start])
+	var items [][]string
+	i := start
+	for i < len(lines) {
+		isOrdered, indent, content, ok := listMarker(lines[i])
+		if !ok || isOrdered != ordered {
+			break
+		}
+
+		item := []string{content}
+		i++
+		for ; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// 空行后仍有缩进内容时属于同一列表项
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) >= indent {
+					item = append(item, "")
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) >= indent {
+				item = append(item, line[indent:])
+				continue
+			}
+			if _, _, _, isItem := listMarker(line); isItem || thematic.MatchString(line) {
+				break
+			}
+			// 惰性延续行
+			item = append(item, strings.TrimSpace(line))
+		}
+		items = append(items, item)
+
+		// 跳过列表项之间的空行
+		for i < len(lines) && strings.TrimSpace(lines[i]) == "" && i+1 < len(lines) {
+			if _, _, _, next := listMarker(lines[i+1]); !next {
+				break
+			}
+			i++
+		}
+	}
+
+	tag, open := "ul", "<ul>"
+	if ordered {
+		tag, open = "ol", "<ol>"
+		if m := orderedItem.FindStringSubmatch(lines[start]); strings.TrimLeft(m[2], "0") != "1" {
+			open = `<ol start="` + strings.TrimLeft(m[2], "0") + `">`
+		}
+	}
+	r.out.WriteString(open + "\n")
+
+	for _, item := range items {
+		r.out.WriteString("<li>")
+		if len(item) == 1 {
+			r.out.WriteString(r.taskItem(item[0]))
+		} else {
+			r.out.WriteString("\n")
+			r.blocks(item)
+		}
+		r.out.WriteString("</li>\n")
+	}
+	r.out.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// taskItem 渲染 GitHub 风格的任务列表项
+func (r *markdown) taskItem(content string) string {
+	if strings.HasPrefix(content, "[ ] ") {
+		return `<input type="checkbox" disabled> ` + r.inline(content[4:])
+	}
+	if strings.HasPrefix(content, "[x] ") || strings.HasPrefix(content, "[X] ") {
+		return `<input type="checkbox" checked disabled> ` + r.inline(content[4:])
+	}
+	return r.inline(content)
+}
+
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	cells := make([]string, 0)
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '|' {
+			cell.WriteByte('|')
+			i++
+			continue
+		}
+		if line[i] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[i])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+func (r *markdown) table(lines []string, start int) int {
+	header := splitRow(lines[start])
+	aligns := make([]string, len(header))
+	for j, spec := range splitRow(lines[start+1]) {
+		if j >= len(aligns) {
+			break
+		}
+		switch {
+		case strings.HasPrefix(spec, ":") && strings.HasSuffix(spec, ":"):
+			aligns[j] = "center"
+		case strings.HasSuffix(spec, ":"):
+			aligns[j] = "right"
+		case strings.HasPrefix(spec, ":"):
+			aligns[j] = "left"
+		}
+	}
+
+	cell := func(tag string, j int, content string) {
+		if aligns[j] != "" {
+			r.out.WriteString("<" + tag + ` align="` + aligns[j] + `">`)
+		} else {
+			r.out.WriteString("<" + tag + ">")
+		}
+		r.out.WriteString(r.inline(content) + "</" + tag + ">")
+	}
+
+	r.out.WriteString("<table>\n<thead>\n<tr>")
+	for j, content := range header {
+		cell("th", j, content)
+	}
+	r.out.WriteString("</tr>\n</thead>\n<tbody>\n")
+
+	i := start + 2
+	for ; i < len(lines) && strings.TrimSpace(lines[i]) != "" && strings.Contains(lines[i], "|"); i++ {
+		row := splitRow(lines[i])
+		r.out.WriteString("<tr>")
+		for j := range header {
+			content := ""
+			if j < len(row) {
+				content = row[j]
+			}
+			cell("td", j, content)
+		}
+		r.out.WriteString("</tr>\n")
+	}
+	r.out.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+func (r *markdown) paragraph(lines []string, start int) int {
+	var text []string
+	i := start
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			break
+		}
+
+		// Setext 标题
+		if len(text) > 0 && strings.Trim(trimmed, "=") == "" {
+			r.element("h1", r.inline(strings.Join(text, "\n")))
+			return i + 1
+		}
+		if len(text) > 0 && strings.Trim(trimmed, "-") == "" {
+			r.element("h2", r.inline(strings.Join(text, "\n")))
+			return i + 1
+		}
+
+		if len(text) > 0 && (atxHeading.MatchString(trimmed) || thematic.MatchString(line) ||
+			strings.HasPrefix(trimmed, ">") || strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") ||
+			bulletItem.MatchString(line)) {
+			break
+		}
+		text = append(text, strings.TrimLeft(line, " "))
+	}
+
+	r.element("p", r.inline(strings.Join(text, "\n")))
+	return i
+}
+
+// inline 渲染行内元素
+func (r *markdown) inline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && text[i+1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+		case c == '\\' && i+1 < len(text) && (unicode.IsPunct(rune(text[i+1])) || unicode.IsSymbol(rune(text[i+1]))):
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+		case c == '`':
+			n := countRun(text[i:], '`')
+			delim := text[i : i+n]
+			if end := strings.Index(text[i+n:], delim); end >= 0 {
+				code := text[i+n : i+n+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(strings.ReplaceAll(code, "\n", " ")) + "</code>")
+				i += n + end + n
+			} else {
+				b.WriteString(delim)
+				i += n
+			}
+		case c == '!' && i+1 < len(text) && text[i+1] == '[':
+			if label, dest, title, n, ok := parseLink(text[i+1:]); ok {
+				b.WriteString(r.image(label, dest, title))
+				i += 1 + n
+			} else {
+				b.WriteString("!")
+				i++
+			}
+		case c == '[':
+			if label, dest, title, n, ok := parseLink(text[i:]); ok {
+				b.WriteString(r.link(r.inline(label), dest, title))
+				i += n
+			} else {
+				b.WriteString("[")
+				i++
+			}
+		case c == '<':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 && isAutolink(text[i+1:i+end]) {
+				target := text[i+1 : i+end]
+				dest := target
+				if strings.Contains(target, "@") && !safeScheme.MatchString(target) {
+					dest = "mailto:" + target
+				}
+				b.WriteString(r.link(html.EscapeString(target), dest, ""))
+				i += end + 1
+			} else {
+				b.WriteString("&lt;")
+				i++
+			}
+		case c == '*' || c == '_' || c == '~':
+			if out, n, ok := r.emphasis(text[i:]); ok {
+				b.WriteString(out)
+				i += n
+			} else {
+				n := countRun(text[i:], c)
+				b.WriteString(text[i : i+n])
+				i += n
+			}
+		case c == '\n':
+			if strings.HasSuffix(b.String(), "  ") {
+				trimmed := strings.TrimRight(b.String(), " ")
+				b.Reset()
+				b.WriteString(trimmed + "<br>")
+			}
+			b.WriteString("\n")
+			i++
+		default:
+			b.WriteString(html.EscapeString(text[i : i+1]))
+			i++
+		}
+	}
+	return b.String()
+}
+
+// emphasis 渲染强调、加粗与删除线，text 以分隔符开头
+func (r *markdown) emphasis(text string) (string, int, bool) {
+	c := text[0]
+	n := countRun(text, c)
+	if n > 3 || (c == '~' && n != 2) || n >= len(text) || unicode.IsSpace(rune(text[n])) {
+		return "", 0, false
+	}
+
+	delim := text[:n]
+	// 查找右侧同等长度的闭合分隔符
+	for j := n; j < len(text); j++ {
+		if text[j] == '`' {
+			if end := strings.IndexByte(text[j+1:], '`'); end >= 0 {
+				j += end + 1
+				continue
+			}
+		}
+		if !strings.HasPrefix(text[j:], delim) || countRun(text[j:], c) != n || unicode.IsSpace(rune(text[j-1])) {
+			continue
+		}
+		// 下划线不在单词内部生效
+		if c == '_' && j+n < len(text) && isWordChar(text[j+n]) {
+			continue
+		}
+
+		inner := r.inline(text[n:j])
+		switch {
+		case c == '~':
+			inner = "<del>" + inner + "</del>"
+		case n == 1:
+			inner = "<em>" + inner + "</em>"
+		case n == 2:
+			inner = "<strong>" + inner + "</strong>"
+		default:
+			inner = "<em><strong>" + inner + "</strong></em>"
+		}
+		return inner, j + n, true
+	}
+	return "", 0, false
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func countRun(text string, c byte) int {
+	n := 0
+	for n < len(text) && text[n] == c {
+		n++
+	}
+	return n
+}
+
+func isAutolink(s string) bool {
+	if strings.ContainsAny(s, " \n<") {
+		return false
+	}
+	return safeScheme.MatchString(s) && !strings.HasPrefix(strings.ToLower(s), "mailto:") ||
+		strings.Count(s, "@") == 1 && !strings.Contains(s, ":")
+}
+
+// parseLink 解析以 [ 开头的 [label](dest "title") 结构，返回消耗的字节数
+func parseLink(text string) (label, dest, title string, n int, ok bool) {
+	depth := 0
+	end := -1
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				end = i
+			}
+		}
+		if end >= 0 {
+			break
+		}
+	}
+	if end < 0 || end+1 >= len(text) || text[end+1] != '(' {
+		return "", "", "", 0, false
+	}
+
+	label = text[1:end]
+	rest := text[end+2:]
+	closing := -1
+	parens := 0
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == '\\' {
+			i++
+			continue
+		}
+		if rest[i] == '(' {
+			parens++
+		} else if rest[i] == ')' {
+			if parens == 0 {
+				closing = i
+				break
+			}
+			parens--
+		}
+	}
+	if closing < 0 {
+		return "", "", "", 0, false
+	}
+
+	inner := strings.TrimSpace(rest[:closing])
+	if strings.HasPrefix(inner, "<") {
+		if gt := strings.IndexByte(inner, '>'); gt > 0 {
+			dest, inner = inner[1:gt], strings.TrimSpace(inner[gt+1:])
+		}
+	} else if sp := strings.IndexAny(inner, " \n"); sp > 0 {
+		dest, inner = inner[:sp], strings.TrimSpace(inner[sp:])
+	} else {
+		dest, inner = inner, ""
+	}
+
+	if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+		title = inner[1 : len(inner)-1]
+	}
+
+	return label, dest, title, end + 2 + closing + 1, true
+}
+
+// SafeURL 返回地址是否可以安全地输出到链接或图片中
+func SafeURL(dest string, image bool) bool {
+	dest = strings.TrimSpace(dest)
+	if strings.IndexFunc(dest, unicode.IsControl) >= 0 {
+		return false
+	}
+	if image && safeDataURI.MatchString(dest) {
+		return true
+	}
+	return safeScheme.MatchString(dest) || !hasScheme.MatchString(dest)
+}
+
+func (r *markdown) link(content, dest, title string) string {
+	if !SafeURL(dest, false) {
+		return content
+	}
+
+	attrs := ` href="` + html.EscapeString(dest) + `"`
+	if title != "" {
+		attrs += ` title="` + html.EscapeString(title) + `"`
+	}
+	if safeScheme.MatchString(dest) {
+		attrs += ` rel="noopener noreferrer nofollow" target="_blank"`
+	}
+	return "<a" + attrs + ">" + content + "</a>"
+}
+
+func (r *markdown) image(alt, dest, title string) string {
+	if r.resolve != nil && !hasScheme.MatchString(dest) && !strings.HasPrefix(dest, "//") {
+		if resolved := r.resolve(dest); resolved != "" {
+			dest = resolved
+		}
+	}
+
+	if !SafeURL(dest, true) {
+		return html.EscapeString(alt)
+	}
+
+	attrs := ` src="` + html.EscapeString(dest) + `" alt="` + html.EscapeString(alt) + `"`
+	if title != "" {
+		attrs += ` title="` + html.EscapeString(title) + `"`
+	}
+	return "<img" + attrs + ` loading="lazy">`
+}
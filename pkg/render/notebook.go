@@ -0,0 +1,140 @@
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidNotebook = errors.New("invalid notebook file")
+
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	base64Data = regexp.MustCompile(`^[A-Za-z0-9+/=\s]+$`)
+)
+
+// multiline Jupyter 中既可以是字符串也可以是字符串数组的文本字段
+type multiline string
+
+func (m *multiline) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*m = multiline(strings.Join(lines, ""))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*m = multiline(s)
+	return nil
+}
+
+type notebook struct {
+	Cells    []notebookCell `json:"cells"`
+	Metadata struct {
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+type notebookCell struct {
+	CellType       string           `json:"cell_type"`
+	Source         multiline        `json:"source"`
+	ExecutionCount *int             `json:"execution_count"`
+	Outputs        []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Name       string                     `json:"name"`
+	Text       multiline                  `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	Ename      string                     `json:"ename"`
+	Evalue     string                     `json:"evalue"`
+	Traceback  []string                   `json:"traceback"`
+}
+
+// Notebook 将 Jupyter Notebook (nbformat 4) 渲染为 HTML。Markdown 单元格与输出按 Markdown 规则渲染，
+// HTML 及 JavaScript 输出不会被执行，而是退回到纯文本形式
+func Notebook(src []byte, resolve Resolver) (string, error) {
+	var nb notebook
+	if err := json.Unmarshal(src, &nb); err != nil {
+		return "", ErrInvalidNotebook
+	}
+
+	lang := nb.Metadata.LanguageInfo.Name
+	var b strings.Builder
+	b.WriteString(`<div class="notebook">` + "\n")
+	for _, cell := range nb.Cells {
+		switch cell.CellType {
+		case "markdown":
+			b.WriteString(`<div class="cell markdown">` + "\n" + Markdown(string(cell.Source), resolve) + "</div>\n")
+		case "code":
+			b.WriteString(`<div class="cell code">` + "\n")
+			if cell.ExecutionCount != nil {
+				b.WriteString(`<div class="prompt">In [` + strconv.Itoa(*cell.ExecutionCount) + "]:</div>\n")
+			}
+			b.WriteString("<pre><code")
+			if lang != "" {
+				b.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+			}
+			b.WriteString(">" + html.EscapeString(string(cell.Source)) + "</code></pre>\n")
+			for _, output := range cell.Outputs {
+				b.WriteString(renderOutput(&output, resolve))
+			}
+			b.WriteString("</div>\n")
+		default:
+			b.WriteString(`<div class="cell raw"><pre>` + html.EscapeString(string(cell.Source)) + "</pre></div>\n")
+		}
+	}
+	b.WriteString("</div>\n")
+	return b.String(), nil
+}
+
+func renderOutput(output *notebookOutput, resolve Resolver) string {
+	switch output.OutputType {
+	case "stream":
+		return `<pre class="output ` + html.EscapeString(output.Name) + `">` +
+			html.EscapeString(ansiEscape.ReplaceAllString(string(output.Text), "")) + "</pre>\n"
+	case "error":
+		trace := ansiEscape.ReplaceAllString(strings.Join(output.Traceback, "\n"), "")
+		if trace == "" {
+			trace = output.Ename + ": " + output.Evalue
+		}
+		return `<pre class="output error">` + html.EscapeString(trace) + "</pre>\n"
+	case "execute_result", "display_data":
+		for _, mime := range []string{"image/png", "image/jpeg", "image/gif"} {
+			if data, ok := output.data(mime); ok && base64Data.MatchString(data) {
+				uri := "data:" + mime + ";base64," + strings.Join(strings.Fields(data), "")
+				return `<div class="output"><img src="` + html.EscapeString(uri) + `" alt=""></div>` + "\n"
+			}
+		}
+		if data, ok := output.data("text/markdown"); ok {
+			return `<div class="output">` + Markdown(data, resolve) + "</div>\n"
+		}
+		if data, ok := output.data("text/plain"); ok {
+			return `<pre class="output">` + html.EscapeString(ansiEscape.ReplaceAllString(data, "")) + "</pre>\n"
+		}
+	}
+	return ""
+}
+
+// data 返回指定 MIME 类型的文本输出，其他结构（如 application/json）的输出将被忽略
+func (output *notebookOutput) data(mime string) (string, bool) {
+	raw, ok := output.Data[mime]
+	if !ok {
+		return "", false
+	}
+
+	var text multiline
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", false
+	}
+	return string(text), true
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	asserts := assert.New(t)
+
+	asserts.Equal("<h2>Title</h2>\n", Markdown("## Title", nil))
+	asserts.Equal("<p><strong>bold</strong> and <em>it</em> <code>a&lt;b</code></p>\n", Markdown("**bold** and *it* `a<b`", nil))
+	asserts.Equal("<pre><code class=\"language-go\">fmt.Println()\n</code></pre>\n", Markdown("```go\nfmt.Println()\n```", nil))
+	asserts.Equal("<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n", Markdown("- a\n- b", nil))
+	asserts.Equal("<ol start=\"3\">\n<li>c</li>\n</ol>\n", Markdown("3. c", nil))
+	asserts.Equal("<blockquote>\n<p>quote</p>\n</blockquote>\n", Markdown("> quote", nil))
+	asserts.Equal("<hr>\n", Markdown("---", nil))
+	asserts.Contains(Markdown("| a | b |\n|---|:-:|\n| 1 | 2 |", nil), "<td align=\"center\">2</td>")
+	asserts.Equal("<h1>Title</h1>\n", Markdown("Title\n===", nil))
+}
+
+func TestMarkdown_Sanitize(t *testing.T) {
+	asserts := assert.New(t)
+
+	asserts.Equal("<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n", Markdown("<script>alert(1)</script>", nil))
+	asserts.Equal("<p>x</p>\n", Markdown("[x](javascript:alert(1))", nil))
+	asserts.Equal("<p>img</p>\n", Markdown(`![img](javascript:alert(1))`, nil))
+	asserts.Equal("<p><a href=\"https://a.com/&#34;x\" rel=\"noopener noreferrer nofollow\" target=\"_blank\">a</a></p>\n",
+		Markdown(`[a](https://a.com/"x)`, nil))
+}
+
+func TestMarkdown_Resolve(t *testing.T) {
+	asserts := assert.New(t)
+	resolve := func(src string) string {
+		if src == "img/a.png" {
+			return "/api/v3/file/preview/x"
+		}
+		return ""
+	}
+
+	asserts.Equal("<p><img src=\"/api/v3/file/preview/x\" alt=\"a\" loading=\"lazy\"></p>\n", Markdown("![a](img/a.png)", resolve))
+	asserts.Equal("<p><img src=\"https://a.com/b.png\" alt=\"b\" loading=\"lazy\"></p>\n", Markdown("![b](https://a.com/b.png)", resolve))
+}
+
+func TestNotebook(t *testing.T) {
+	asserts := assert.New(t)
+
+	_, err := Notebook([]byte("not json"), nil)
+	asserts.Equal(ErrInvalidNotebook, err)
+
+	res, err := Notebook([]byte(`{
+		"metadata": {"language_info": {"name": "python"}},
+		"cells": [
+			{"cell_type": "markdown", "source": ["# Hello\n", "world"]},
+			{"cell_type": "code", "execution_count": 1, "source": "print(1)", "outputs": [
+				{"output_type": "stream", "name": "stdout", "text": ["1\n"]},
+				{"output_type": "display_data", "data": {"text/html": "<script>x</script>", "application/json": {"a": 1}, "text/plain": "<b>"}},
+				{"output_type": "execute_result", "data": {"image/png": "iVBORw0KGgo="}},
+				{"output_type": "error", "traceback": ["\u001b[31mValueError\u001b[0m: x"]}
+			]}
+		]
+	}`), nil)
+	asserts.NoError(err)
+	asserts.Contains(res, "<h1>Hello</h1>")
+	asserts.Contains(res, `<code class="language-python">print(1)</code>`)
+	asserts.Contains(res, "In [1]:")
+	asserts.Contains(res, `<pre class="output stdout">1`)
+	asserts.Contains(res, `<pre class="output">&lt;b&gt;</pre>`)
+	asserts.Contains(res, `src="data:image/png;base64,iVBORw0KGgo="`)
+	asserts.Contains(res, "ValueError: x")
+	asserts.NotContains(res, "<script>")
+}
@@ -53,6 +53,14 @@ func TestWithContext(t *testing.T) {
 	asserts.NotNil(options.ctx)
 }
 
+func TestWithTransport(t *testing.T) {
+	asserts := assert.New(t)
+	options := newDefaultOption()
+	transport := &http.Transport{}
+	WithTransport(transport).apply(options)
+	asserts.Equal(transport, options.transport)
+}
+
 func TestHTTPClient_Request(t *testing.T) {
 	asserts := assert.New(t)
 	client := NewClient(WithSlaveMeta("test"))
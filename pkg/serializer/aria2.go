@@ -20,6 +20,9 @@ type DownloadListResponse struct {
 	Speed          int            `json:"speed"`
 	Info           rpc.StatusInfo `json:"info"`
 	NodeName       string         `json:"node"`
+	SeedRatio      float64        `json:"seed_ratio,omitempty"`
+	SeedTime       int            `json:"seed_time,omitempty"`
+	SeededAt       *time.Time     `json:"seeded_at,omitempty"`
 }
 
 // FinishedListResponse 已完成任务条目
@@ -110,6 +113,9 @@ func BuildDownloadingResponse(tasks []model.Download, intervals map[uint]int) Re
 			Speed:          tasks[i].Speed,
 			Info:           tasks[i].StatusInfo,
 			NodeName:       tasks[i].NodeName,
+			SeedRatio:      tasks[i].SeedRatio,
+			SeedTime:       tasks[i].SeedTime,
+			SeededAt:       tasks[i].SeededAt,
 		})
 	}
 
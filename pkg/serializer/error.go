@@ -54,6 +54,8 @@ const (
 	CodeNoPermissionErr = 403
 	// CodeNotFound 资源未找到
 	CodeNotFound = 404
+	// CodeTooManyRequests 请求过于频繁
+	CodeTooManyRequests = 429
 	// CodeConflict 资源冲突
 	CodeConflict = 409
 	// CodeUploadFailed 上传出错
@@ -192,6 +194,44 @@ const (
 	CodeDisabledSharePreview = 40070
 	// 签名无效
 	CodeInvalidSign = 40071
+	// CodeNetworkRestricted 当前网络环境被限制访问
+	CodeNetworkRestricted = 40072
+	// CodePasswordTooWeak 密码不符合密码策略
+	CodePasswordTooWeak = 40073
+	// CodePasswordBreached 密码出现在已知泄露数据中
+	CodePasswordBreached = 40074
+	// CodeFileQuarantined 文件因安全原因被隔离
+	CodeFileQuarantined = 40075
+	// CodeFileBlocked 文件内容命中屏蔽列表
+	CodeFileBlocked = 40076
+	// CodeUserPendingDeletion 用户已申请注销
+	CodeUserPendingDeletion = 40077
+	// CodeInvalidInviteCode 邀请码无效
+	CodeInvalidInviteCode = 40078
+	// CodeUserPendingApproval 用户等待管理员审核
+	CodeUserPendingApproval = 40079
+	// CodeInvalidShareSlug 自定义分享链接无效
+	CodeInvalidShareSlug = 40080
+	// CodeShareTrafficExceeded 分享流量已用尽
+	CodeShareTrafficExceeded = 40081
+	// CodeThumbGenerating 缩略图正在生成中
+	CodeThumbGenerating = 40082
+	// CodeEditConflict 文件已被其他会话修改
+	CodeEditConflict = 40083
+	// CodeTrafficExceeded 本月下载流量已用尽
+	CodeTrafficExceeded = 40084
+	// CodeFolderQuotaExceeded 目录容量已超出上限
+	CodeFolderQuotaExceeded = 40085
+	// CodeFileLocked 文件已被锁定
+	CodeFileLocked = 40086
+	// CodeFileArchived 文件已转为归档存储
+	CodeFileArchived = 40087
+	// CodeShareNotStarted 分享尚未到开始时间
+	CodeShareNotStarted = 40088
+	// CodeFileOpaque 保险库中的文件由客户端加密，服务端无法处理其内容
+	CodeFileOpaque = 40089
+	// CodeRetentionHold 对象处于保留期或合规冻结中，不能被修改
+	CodeRetentionHold = 40090
 	// CodeDBError 数据库操作失败
 	CodeDBError = 50001
 	// CodeEncryptError 加密失败
@@ -0,0 +1,114 @@
+package serializer
+
+// errorKeys 错误代码对应的字符串标识，供 API 客户端及多语言消息目录使用，已发布的标识不应更改
+var errorKeys = map[int]string{
+	CodeNotFullySuccess:            "not_fully_success",
+	CodeCheckLogin:                 "check_login",
+	CodeNoPermissionErr:            "no_permission",
+	CodeNotFound:                   "not_found",
+	CodeTooManyRequests:            "too_many_requests",
+	CodeConflict:                   "conflict",
+	CodeUploadFailed:               "upload_failed",
+	CodeCreateFolderFailed:         "create_folder_failed",
+	CodeObjectExist:                "object_exist",
+	CodeSignExpired:                "sign_expired",
+	CodePolicyNotAllowed:           "policy_not_allowed",
+	CodeGroupNotAllowed:            "group_not_allowed",
+	CodeAdminRequired:              "admin_required",
+	CodeMasterNotFound:             "master_not_found",
+	CodeUploadSessionExpired:       "upload_session_expired",
+	CodeInvalidChunkIndex:          "invalid_chunk_index",
+	CodeInvalidContentLength:       "invalid_content_length",
+	CodeBatchSourceSize:            "batch_source_size",
+	CodeBatchAria2Size:             "batch_aria2_size",
+	CodeParentNotExist:             "parent_not_exist",
+	CodeUserBaned:                  "user_banned",
+	CodeUserNotActivated:           "user_not_activated",
+	CodeFeatureNotEnabled:          "feature_not_enabled",
+	CodeCredentialInvalid:          "credential_invalid",
+	CodeUserNotFound:               "user_not_found",
+	Code2FACodeErr:                 "2fa_code_error",
+	CodeLoginSessionNotExist:       "login_session_not_exist",
+	CodeInitializeAuthn:            "webauthn_init_failed",
+	CodeWebAuthnCredentialError:    "webauthn_credential_error",
+	CodeCaptchaError:               "captcha_error",
+	CodeCaptchaRefreshNeeded:       "captcha_refresh_needed",
+	CodeFailedSendEmail:            "failed_send_email",
+	CodeInvalidTempLink:            "invalid_temp_link",
+	CodeTempLinkExpired:            "temp_link_expired",
+	CodeEmailExisted:               "email_existed",
+	CodeEmailSent:                  "email_sent",
+	CodeUserCannotActivate:         "user_cannot_activate",
+	CodePolicyNotExist:             "policy_not_exist",
+	CodeDeleteDefaultPolicy:        "delete_default_policy",
+	CodePolicyUsedByFiles:          "policy_used_by_files",
+	CodePolicyUsedByGroups:         "policy_used_by_groups",
+	CodeGroupNotFound:              "group_not_found",
+	CodeInvalidActionOnSystemGroup: "invalid_action_on_system_group",
+	CodeGroupUsedByUser:            "group_used_by_user",
+	CodeChangeGroupForDefaultUser:  "change_group_for_default_user",
+	CodeInvalidActionOnDefaultUser: "invalid_action_on_default_user",
+	CodeFileNotFound:               "file_not_found",
+	CodeListFilesError:             "list_files_error",
+	CodeInvalidActionOnSystemNode:  "invalid_action_on_system_node",
+	CodeCreateFSError:              "create_fs_error",
+	CodeCreateTaskError:            "create_task_error",
+	CodeFileTooLarge:               "file_too_large",
+	CodeFileTypeNotAllowed:         "file_type_not_allowed",
+	CodeInsufficientCapacity:       "insufficient_capacity",
+	CodeIllegalObjectName:          "illegal_object_name",
+	CodeRootProtected:              "root_protected",
+	CodeConflictUploadOngoing:      "conflict_upload_ongoing",
+	CodeMetaMismatch:               "meta_mismatch",
+	CodeUnsupportedArchiveType:     "unsupported_archive_type",
+	CodePolicyChanged:              "policy_changed",
+	CodeShareLinkNotFound:          "share_link_not_found",
+	CodeSaveOwnShare:               "save_own_share",
+	CodeSlavePingMaster:            "slave_ping_master",
+	CodeVersionMismatch:            "version_mismatch",
+	CodeInsufficientCredit:         "insufficient_credit",
+	CodeGroupConflict:              "group_conflict",
+	CodeGroupInvalid:               "group_invalid",
+	CodeInvalidGiftCode:            "invalid_gift_code",
+	CodeQQBindConflict:             "qq_bind_conflict",
+	CodeQQBindOtherAccount:         "qq_bind_other_account",
+	CodeQQNotLinked:                "qq_not_linked",
+	CodeIncorrectPassword:          "incorrect_password",
+	CodeDisabledSharePreview:       "disabled_share_preview",
+	CodeInvalidSign:                "invalid_sign",
+	CodeNetworkRestricted:          "network_restricted",
+	CodePasswordTooWeak:            "password_too_weak",
+	CodePasswordBreached:           "password_breached",
+	CodeFileQuarantined:            "file_quarantined",
+	CodeFileBlocked:                "file_blocked",
+	CodeUserPendingDeletion:        "user_pending_deletion",
+	CodeInvalidInviteCode:          "invalid_invite_code",
+	CodeUserPendingApproval:        "user_pending_approval",
+	CodeInvalidShareSlug:           "invalid_share_slug",
+	CodeShareTrafficExceeded:       "share_traffic_exceeded",
+	CodeThumbGenerating:            "thumb_generating",
+	CodeEditConflict:               "edit_conflict",
+	CodeTrafficExceeded:            "traffic_exceeded",
+	CodeFolderQuotaExceeded:        "folder_quota_exceeded",
+	CodeFileLocked:                 "file_locked",
+	CodeFileArchived:               "file_archived",
+	CodeShareNotStarted:            "share_not_started",
+	CodeFileOpaque:                 "file_opaque",
+	CodeRetentionHold:              "retention_hold",
+	CodeDBError:                    "db_error",
+	CodeEncryptError:               "encrypt_error",
+	CodeIOFailed:                   "io_failed",
+	CodeInternalSetting:            "internal_setting",
+	CodeCacheOperation:             "cache_operation",
+	CodeCallbackError:              "callback_error",
+	CodeUpdateSetting:              "update_setting",
+	CodeAddCORS:                    "add_cors",
+	CodeNodeOffline:                "node_offline",
+	CodeQueryMetaFailed:            "query_meta_failed",
+	CodeParamErr:                   "invalid_parameters",
+}
+
+// ErrorKey 返回错误代码对应的字符串标识，未知代码返回空
+func ErrorKey(code int) string {
+	return errorKeys[code]
+}
@@ -40,3 +40,17 @@ func TestErr(t *testing.T) {
 	resp := Err(400, "", err)
 	a.Equal("Bad Request", resp.Msg)
 }
+
+func TestErrorKey(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("file_too_large", ErrorKey(CodeFileTooLarge))
+	a.Equal("invalid_parameters", ErrorKey(CodeParamErr))
+	a.Empty(ErrorKey(CodeNotSet))
+
+	// 标识不能重复
+	keys := make(map[string]bool, len(errorKeys))
+	for _, key := range errorKeys {
+		a.False(keys[key], key)
+		keys[key] = true
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/gob"
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"strconv"
 	"time"
 )
 
@@ -20,15 +21,21 @@ type ObjectProps struct {
 	ChildFolderNum int       `json:"child_folder_num"`
 	ChildFileNum   int       `json:"child_file_num"`
 	Path           string    `json:"path"`
+	MD5            string    `json:"md5,omitempty"`
+	SHA256         string    `json:"sha256,omitempty"`
+	ChecksumStatus string    `json:"checksum_status,omitempty"`
+	Lock           *FileLock `json:"lock,omitempty"`
 
 	QueryDate time.Time `json:"query_date"`
 }
 
 // ObjectList 文件、目录列表
 type ObjectList struct {
-	Parent  string         `json:"parent,omitempty"`
-	Objects []Object       `json:"objects"`
-	Policy  *PolicySummary `json:"policy,omitempty"`
+	Parent     string         `json:"parent,omitempty"`
+	Objects    []Object       `json:"objects"`
+	Policy     *PolicySummary `json:"policy,omitempty"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"`
 }
 
 // Object 文件或者目录
@@ -43,6 +50,23 @@ type Object struct {
 	CreateDate    time.Time `json:"create_date"`
 	Key           string    `json:"key,omitempty"`
 	SourceEnabled bool      `json:"source_enabled"`
+	// Vault 保险库文件由客户端保存的加密元数据
+	Vault map[string]string `json:"vault,omitempty"`
+}
+
+// SubtreeList 平铺的目录子树列表
+type SubtreeList struct {
+	Objects    []Object `json:"objects"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// RenamePreview 批量重命名中单个对象的结果
+type RenamePreview struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	OldName  string `json:"old_name"`
+	NewName  string `json:"new_name"`
+	Conflict bool   `json:"conflict"`
 }
 
 // PolicySummary 用于前端组件使用的存储策略概况
@@ -58,6 +82,7 @@ type PolicySummary struct {
 func BuildObjectList(parent uint, objects []Object, policy *model.Policy) ObjectList {
 	res := ObjectList{
 		Objects: objects,
+		Total:   len(objects),
 	}
 
 	if parent > 0 {
@@ -129,4 +154,282 @@ type WopiFileInfo struct {
 	SupportsRename    bool
 	SupportsReviewing bool
 	SupportsUpdate    bool
+
+	// Lock
+	SupportsLocks              bool
+	SupportsGetLock            bool
+	SupportsExtendedLockLength bool
+}
+
+// OnlyOfficeSession OnlyOffice 文档编辑会话响应
+type OnlyOfficeSession struct {
+	Endpoint string      `json:"endpoint"`
+	Config   interface{} `json:"config"`
+}
+
+// ReaderSession 电子书、漫画阅读会话响应
+type ReaderSession struct {
+	Session string        `json:"session"`
+	Format  string        `json:"format"`
+	Title   string        `json:"title,omitempty"`
+	Entries []ReaderEntry `json:"entries"`
+}
+
+// ReaderEntry 电子书章节或漫画页面
+type ReaderEntry struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// EditConflict 在线编辑保存冲突响应，包含服务端当前版本与客户端提交的版本
+type EditConflict struct {
+	ETag    string `json:"etag"`
+	Current string `json:"current"`
+	Yours   string `json:"yours"`
+}
+
+// FileVersion 文件历史版本响应
+type FileVersion struct {
+	ID        uint      `json:"id"`
+	Size      uint64    `json:"size"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BuildFileVersions 构建文件历史版本列表响应
+func BuildFileVersions(versions []model.FileVersion) []FileVersion {
+	res := make([]FileVersion, 0, len(versions))
+	for _, version := range versions {
+		res = append(res, FileVersion{
+			ID:        version.ID,
+			Size:      version.Size,
+			Author:    version.Author,
+			CreatedAt: version.CreatedAt,
+		})
+	}
+	return res
+}
+
+// FileLock 文件锁响应，令牌仅在加锁成功时返回
+type FileLock struct {
+	Owner     string    `json:"owner"`
+	Note      string    `json:"note,omitempty"`
+	Token     string    `json:"token,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BuildFileLock 构建文件锁响应
+func BuildFileLock(lock *model.FileLock) *FileLock {
+	return &FileLock{
+		Owner:     lock.Owner,
+		Note:      lock.Note,
+		CreatedAt: lock.CreatedAt,
+		ExpiresAt: lock.ExpiresAt,
+	}
+}
+
+// Subtitle 视频外挂字幕响应
+type Subtitle struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Lang   string `json:"lang"`
+	Format string `json:"format"`
+	URL    string `json:"url"`
+}
+
+// AudioMeta 音频元数据响应
+type AudioMeta struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Album       string `json:"album"`
+	AlbumArtist string `json:"album_artist"`
+	Genre       string `json:"genre"`
+	Year        int    `json:"year,omitempty"`
+	Track       int    `json:"track,omitempty"`
+	HasCover    bool   `json:"has_cover"`
+}
+
+// AudioAlbum 音乐库专辑响应
+type AudioAlbum struct {
+	Album  string `json:"album"`
+	Artist string `json:"artist"`
+	Count  int    `json:"count"`
+}
+
+// BuildAudioMeta 构建音频元数据响应
+func BuildAudioMeta(meta *model.AudioMeta) AudioMeta {
+	return AudioMeta{
+		ID:          hashid.HashID(meta.FileID, hashid.FileID),
+		Title:       meta.Title,
+		Artist:      meta.Artist,
+		Album:       meta.Album,
+		AlbumArtist: meta.AlbumArtist,
+		Genre:       meta.Genre,
+		Year:        meta.Year,
+		Track:       meta.Track,
+		HasCover:    meta.HasCover,
+	}
+}
+
+// BuildAudioMetaList 构建音频元数据列表响应
+func BuildAudioMetaList(metas []model.AudioMeta) []AudioMeta {
+	res := make([]AudioMeta, 0, len(metas))
+	for i := range metas {
+		res = append(res, BuildAudioMeta(&metas[i]))
+	}
+	return res
+}
+
+// BuildAudioAlbums 构建音乐库专辑列表响应
+func BuildAudioAlbums(albums []model.AudioAlbum) []AudioAlbum {
+	res := make([]AudioAlbum, 0, len(albums))
+	for _, album := range albums {
+		res = append(res, AudioAlbum{Album: album.Album, Artist: album.Artist, Count: album.Count})
+	}
+	return res
+}
+
+// PhotoMeta 照片拍摄信息响应
+type PhotoMeta struct {
+	ID          string    `json:"id"`
+	TakenAt     time.Time `json:"taken_at"`
+	CameraMake  string    `json:"camera_make,omitempty"`
+	CameraModel string    `json:"camera_model,omitempty"`
+	Latitude    *float64  `json:"latitude,omitempty"`
+	Longitude   *float64  `json:"longitude,omitempty"`
+}
+
+// PhotoGroup 照片时间线中同一日期的照片
+type PhotoGroup struct {
+	Date   string      `json:"date"`
+	Photos []PhotoMeta `json:"photos"`
+}
+
+// BuildPhotoMeta 构建照片拍摄信息响应
+func BuildPhotoMeta(meta *model.PhotoMeta) PhotoMeta {
+	return PhotoMeta{
+		ID:          hashid.HashID(meta.FileID, hashid.FileID),
+		TakenAt:     meta.TakenAt,
+		CameraMake:  meta.CameraMake,
+		CameraModel: meta.CameraModel,
+		Latitude:    meta.Latitude,
+		Longitude:   meta.Longitude,
+	}
+}
+
+// BuildPhotoTimeline 按 layout 格式化后的拍摄日期将已排序的照片分组
+func BuildPhotoTimeline(metas []model.PhotoMeta, layout string) []PhotoGroup {
+	res := make([]PhotoGroup, 0)
+	for i := range metas {
+		date := metas[i].TakenAt.Format(layout)
+		if len(res) == 0 || res[len(res)-1].Date != date {
+			res = append(res, PhotoGroup{Date: date, Photos: make([]PhotoMeta, 0, 1)})
+		}
+		res[len(res)-1].Photos = append(res[len(res)-1].Photos, BuildPhotoMeta(&metas[i]))
+	}
+	return res
+}
+
+// FileChangeList 文件变更列表
+type FileChangeList struct {
+	Changes []FileChange `json:"changes"`
+	// Cursor 下次请求使用的游标
+	Cursor  string `json:"cursor"`
+	HasMore bool   `json:"has_more"`
+	// Reset 为 true 时游标已失效或为首次同步，客户端需重新列出全部文件后从 Cursor 继续同步
+	Reset bool `json:"reset"`
+}
+
+// FileChange 单条文件变更
+type FileChange struct {
+	Cursor string    `json:"cursor"`
+	Action string    `json:"action"`
+	ID     string    `json:"id"`
+	Type   string    `json:"type"`
+	Parent string    `json:"parent,omitempty"`
+	Name   string    `json:"name,omitempty"`
+	Date   time.Time `json:"date"`
+}
+
+// BuildFileChangeList 构建文件变更列表响应
+func BuildFileChangeList(changes []model.FileChange, cursor uint, hasMore, reset bool) FileChangeList {
+	res := FileChangeList{
+		Changes: make([]FileChange, 0, len(changes)),
+		Cursor:  strconv.FormatUint(uint64(cursor), 10),
+		HasMore: hasMore,
+		Reset:   reset,
+	}
+
+	for _, change := range changes {
+		item := FileChange{
+			Cursor: strconv.FormatUint(uint64(change.ID), 10),
+			Action: change.Action,
+			ID:     hashid.HashID(change.ObjectID, hashid.FileID),
+			Type:   "file",
+			Name:   change.Name,
+			Date:   change.CreatedAt,
+		}
+		if change.IsFolder {
+			item.ID = hashid.HashID(change.ObjectID, hashid.FolderID)
+			item.Type = "dir"
+		}
+		if change.ParentID > 0 {
+			item.Parent = hashid.HashID(change.ParentID, hashid.FolderID)
+		}
+		res.Changes = append(res.Changes, item)
+	}
+
+	return res
+}
+
+// Mount 用户挂载的外部存储，不包含凭证
+type Mount struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Folder    string    `json:"folder"`
+	Type      string    `json:"type"`
+	Server    string    `json:"server,omitempty"`
+	Bucket    string    `json:"bucket,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BuildMount 序列化挂载
+func BuildMount(mount *model.Mount, policy *model.Policy) Mount {
+	res := Mount{
+		ID:        mount.ID,
+		Name:      mount.Name,
+		Folder:    hashid.HashID(mount.FolderID, hashid.FolderID),
+		Type:      policy.Type,
+		Server:    policy.Server,
+		Region:    policy.OptionsSerialized.Region,
+		CreatedAt: mount.CreatedAt,
+	}
+	// OneDrive 的 BucketName 为客户端 ID，不予返回
+	if policy.Type == "s3" {
+		res.Bucket = policy.BucketName
+	}
+	return res
+}
+
+// Vault 用户的保险库
+type Vault struct {
+	ID         uint      `json:"id"`
+	Folder     string    `json:"folder"`
+	WrappedKey string    `json:"wrapped_key"`
+	KeyParams  string    `json:"key_params"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// BuildVault 序列化保险库
+func BuildVault(vault *model.Vault) Vault {
+	return Vault{
+		ID:         vault.ID,
+		Folder:     hashid.HashID(vault.FolderID, hashid.FolderID),
+		WrappedKey: vault.WrappedKey,
+		KeyParams:  vault.KeyParams,
+		CreatedAt:  vault.CreatedAt,
+	}
 }
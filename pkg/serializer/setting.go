@@ -11,6 +11,7 @@ type SiteConfig struct {
 	LoginCaptcha         bool     `json:"loginCaptcha"`
 	RegCaptcha           bool     `json:"regCaptcha"`
 	ForgetCaptcha        bool     `json:"forgetCaptcha"`
+	ShareCaptcha         bool     `json:"shareCaptcha"`
 	EmailActive          bool     `json:"emailActive"`
 	Themes               string   `json:"themes"`
 	DefaultTheme         string   `json:"defaultTheme"`
@@ -21,12 +22,16 @@ type SiteConfig struct {
 	ReCaptchaKey         string   `json:"captcha_ReCaptchaKey"`
 	CaptchaType          string   `json:"captcha_type"`
 	TCaptchaCaptchaAppId string   `json:"tcaptcha_captcha_app_id"`
+	HCaptchaKey          string   `json:"captcha_HCaptchaKey"`
+	TurnstileKey         string   `json:"captcha_TurnstileKey"`
 	RegisterEnabled      bool     `json:"registerEnabled"`
+	RegisterInviteOnly   bool     `json:"registerInviteOnly"`
 	AppPromotion         bool     `json:"app_promotion"`
 	WopiExts             []string `json:"wopi_exts"`
 }
 
 type task struct {
+	ID         uint      `json:"id"`
 	Status     int       `json:"status"`
 	Type       int       `json:"type"`
 	CreateDate time.Time `json:"create_date"`
@@ -39,6 +44,7 @@ func BuildTaskList(tasks []model.Task, total int) Response {
 	res := make([]task, 0, len(tasks))
 	for _, t := range tasks {
 		res = append(res, task{
+			ID:         t.ID,
 			Status:     t.Status,
 			Type:       t.Type,
 			CreateDate: t.CreatedAt,
@@ -74,6 +80,7 @@ func BuildSiteConfig(settings map[string]string, user *model.User, wopiExts []st
 			LoginCaptcha:         model.IsTrueVal(checkSettingValue(settings, "login_captcha")),
 			RegCaptcha:           model.IsTrueVal(checkSettingValue(settings, "reg_captcha")),
 			ForgetCaptcha:        model.IsTrueVal(checkSettingValue(settings, "forget_captcha")),
+			ShareCaptcha:         model.IsTrueVal(checkSettingValue(settings, "share_captcha")),
 			EmailActive:          model.IsTrueVal(checkSettingValue(settings, "email_active")),
 			Themes:               checkSettingValue(settings, "themes"),
 			DefaultTheme:         checkSettingValue(settings, "defaultTheme"),
@@ -84,7 +91,10 @@ func BuildSiteConfig(settings map[string]string, user *model.User, wopiExts []st
 			ReCaptchaKey:         checkSettingValue(settings, "captcha_ReCaptchaKey"),
 			CaptchaType:          checkSettingValue(settings, "captcha_type"),
 			TCaptchaCaptchaAppId: checkSettingValue(settings, "captcha_TCaptcha_CaptchaAppId"),
+			HCaptchaKey:          checkSettingValue(settings, "captcha_HCaptchaKey"),
+			TurnstileKey:         checkSettingValue(settings, "captcha_TurnstileKey"),
 			RegisterEnabled:      model.IsTrueVal(checkSettingValue(settings, "register_enabled")),
+			RegisterInviteOnly:   model.IsTrueVal(checkSettingValue(settings, "register_invite_only")),
 			AppPromotion:         model.IsTrueVal(checkSettingValue(settings, "show_app_promotion")),
 			WopiExts:             wopiExts,
 		}}
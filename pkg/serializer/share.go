@@ -10,6 +10,9 @@ import (
 // Share 分享信息序列化
 type Share struct {
 	Key        string        `json:"key"`
+	Slug       string        `json:"slug,omitempty"`
+	Type       int           `json:"type"`
+	Upload     *shareUpload  `json:"upload,omitempty"`
 	Locked     bool          `json:"locked"`
 	IsDir      bool          `json:"is_dir"`
 	CreateDate time.Time     `json:"create_date,omitempty"`
@@ -27,6 +30,12 @@ type shareCreator struct {
 	GroupName string `json:"group_name"`
 }
 
+type shareUpload struct {
+	MaxSize   uint64 `json:"max_size"`
+	Exts      string `json:"exts"`
+	Moderated bool   `json:"moderated"`
+}
+
 type shareSource struct {
 	Name string `json:"name"`
 	Size uint64 `json:"size"`
@@ -35,6 +44,8 @@ type shareSource struct {
 // myShareItem 我的分享列表条目
 type myShareItem struct {
 	Key             string       `json:"key"`
+	Slug            string       `json:"slug,omitempty"`
+	Type            int          `json:"type"`
 	IsDir           bool         `json:"is_dir"`
 	Password        string       `json:"password"`
 	CreateDate      time.Time    `json:"create_date,omitempty"`
@@ -43,6 +54,12 @@ type myShareItem struct {
 	Views           int          `json:"views"`
 	Expire          int64        `json:"expire"`
 	Preview         bool         `json:"preview"`
+	SpeedLimit      int          `json:"speed_limit"`
+	MaxConcurrent   int          `json:"max_concurrent"`
+	TrafficLimit    uint64       `json:"traffic_limit"`
+	Traffic         uint64       `json:"traffic"`
+	StartsAt        int64        `json:"starts_at,omitempty"`
+	ExpireAction    int          `json:"expire_action"`
 	Source          *shareSource `json:"source,omitempty"`
 }
 
@@ -53,6 +70,7 @@ func BuildShareList(shares []model.Share, total int) Response {
 	for i := 0; i < len(shares); i++ {
 		item := myShareItem{
 			Key:             hashid.HashID(shares[i].ID, hashid.ShareID),
+			Type:            shares[i].Type,
 			IsDir:           shares[i].IsDir,
 			Password:        shares[i].Password,
 			CreateDate:      shares[i].CreatedAt,
@@ -61,6 +79,17 @@ func BuildShareList(shares []model.Share, total int) Response {
 			Preview:         shares[i].PreviewEnabled,
 			Expire:          -1,
 			RemainDownloads: shares[i].RemainDownloads,
+			SpeedLimit:      shares[i].SpeedLimit,
+			MaxConcurrent:   shares[i].MaxConcurrent,
+			TrafficLimit:    shares[i].TrafficLimit,
+			Traffic:         shares[i].Traffic,
+			ExpireAction:    shares[i].ExpireAction,
+		}
+		if shares[i].Slug != nil {
+			item.Slug = *shares[i].Slug
+		}
+		if shares[i].StartsAt != nil {
+			item.StartsAt = shares[i].StartsAt.Unix()
 		}
 		if shares[i].Expires != nil {
 			item.Expire = shares[i].Expires.Unix() - now
@@ -68,7 +97,11 @@ func BuildShareList(shares []model.Share, total int) Response {
 				item.Expire = 0
 			}
 		}
-		if shares[i].File.ID != 0 {
+		if shares[i].IsMultiItem() {
+			item.Source = &shareSource{
+				Name: shares[i].SourceName,
+			}
+		} else if shares[i].File.ID != 0 {
 			item.Source = &shareSource{
 				Name: shares[i].File.Name,
 				Size: shares[i].File.Size,
@@ -99,8 +132,12 @@ func BuildShareResponse(share *model.Share, unlocked bool) Share {
 			Nick:      creator.Nick,
 			GroupName: creator.Group.Name,
 		},
+		Type:       share.Type,
 		CreateDate: share.CreatedAt,
 	}
+	if share.Slug != nil {
+		resp.Slug = *share.Slug
+	}
 
 	// 未解锁时只返回基本信息
 	if !unlocked {
@@ -108,6 +145,13 @@ func BuildShareResponse(share *model.Share, unlocked bool) Share {
 	}
 
 	resp.IsDir = share.IsDir
+	if share.IsUploadOnly() {
+		resp.Upload = &shareUpload{
+			MaxSize:   share.UploadMaxSize,
+			Exts:      share.UploadExts,
+			Moderated: share.Moderated,
+		}
+	}
 	resp.Downloads = share.Downloads
 	resp.Views = share.Views
 	resp.Preview = share.PreviewEnabled
@@ -116,7 +160,11 @@ func BuildShareResponse(share *model.Share, unlocked bool) Share {
 		resp.Expire = share.Expires.Unix() - time.Now().Unix()
 	}
 
-	if share.IsDir {
+	if share.IsMultiItem() {
+		resp.Source = &shareSource{
+			Name: share.SourceName,
+		}
+	} else if share.IsDir {
 		source := share.SourceFolder()
 		resp.Source = &shareSource{
 			Name: source.Name,
@@ -133,3 +181,27 @@ func BuildShareResponse(share *model.Share, unlocked bool) Share {
 	return resp
 
 }
+
+// GuestUpload 待审核的访客上传
+type GuestUpload struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	Size       uint64    `json:"size"`
+	Uploader   string    `json:"uploader"`
+	CreateDate time.Time `json:"create_date"`
+}
+
+// BuildGuestUploads 构建待审核的访客上传列表
+func BuildGuestUploads(records []model.GuestUpload) []GuestUpload {
+	res := make([]GuestUpload, 0, len(records))
+	for _, record := range records {
+		res = append(res, GuestUpload{
+			ID:         record.ID,
+			Name:       record.FileName,
+			Size:       record.Size,
+			Uploader:   record.Uploader,
+			CreateDate: record.CreatedAt,
+		})
+	}
+	return res
+}
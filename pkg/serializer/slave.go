@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/exif"
 )
 
 // RemoteDeleteRequest 远程策略删除接口请求正文
@@ -37,6 +38,7 @@ type SlaveAria2Call struct {
 	Task         *model.Download        `json:"task"`
 	GroupOptions map[string]interface{} `json:"group_options"`
 	Files        []int                  `json:"files"`
+	SpeedLimit   int                    `json:"speed_limit"`
 }
 
 // SlaveTransferReq 从机中转任务创建请求
@@ -54,6 +56,42 @@ func (s *SlaveTransferReq) Hash(id string) string {
 	return fmt.Sprintf("%x", bs)
 }
 
+// SlaveMetaReq 从机生成缩略图与元数据请求
+type SlaveMetaReq struct {
+	Src      string `json:"src" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Size     uint64 `json:"size"`
+	Thumb    bool   `json:"thumb"`    // 生成缩略图
+	Photo    bool   `json:"photo"`    // 读取 EXIF 信息
+	Audio    bool   `json:"audio"`    // 读取音频标签
+	Duration bool   `json:"duration"` // 读取音视频时长
+	// 生成完成后推送结果的主机回调地址
+	Callback string `json:"callback" binding:"required"`
+}
+
+// SlaveMetaResult 从机生成的缩略图与元数据，未请求的项目留空
+type SlaveMetaResult struct {
+	Src         string          `json:"src"`
+	ThumbStatus string          `json:"thumb_status,omitempty"`
+	ThumbSize   uint64          `json:"thumb_size,omitempty"`
+	Photo       bool            `json:"photo,omitempty"`
+	Exif        *exif.Exif      `json:"exif,omitempty"`
+	Audio       *SlaveAudioTags `json:"audio,omitempty"`
+	Duration    float64         `json:"duration,omitempty"`
+}
+
+// SlaveAudioTags 从机读取的音频标签
+type SlaveAudioTags struct {
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Album       string `json:"album"`
+	AlbumArtist string `json:"album_artist"`
+	Genre       string `json:"genre"`
+	Year        int    `json:"year"`
+	Track       int    `json:"track"`
+	HasCover    bool   `json:"has_cover"`
+}
+
 const (
 	SlaveTransferSuccess = "success"
 	SlaveTransferFailed  = "failed"
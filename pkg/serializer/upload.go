@@ -30,6 +30,13 @@ type UploadCredential struct {
 	KeyTime     string   `json:"keyTime,omitempty"` // COS用有效期
 	Policy      string   `json:"policy,omitempty"`
 	CompleteURL string   `json:"completeURL,omitempty"`
+	Name        string   `json:"name,omitempty"` // 自动重命名后实际保存的文件名
+}
+
+// PresignedUploadURL 预签名上传 URL
+type PresignedUploadURL struct {
+	URL     string `json:"url"`
+	Expires int64  `json:"expires"` // 过期时间，Unix 时间戳
 }
 
 // UploadSession 上传会话
@@ -47,11 +54,29 @@ type UploadSession struct {
 	UploadURL      string
 	UploadID       string
 	Credential     string
+	SpeedLimit     int  // 上传速度限制，单位为 字节/秒，0 表示不限制
+	Replace        uint // 覆盖模式下被替换的文件ID，上传完成后占位文件的内容成为其新版本
+}
+
+// UploadSessionItem 上传会话列表条目
+type UploadSessionItem struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Path       string    `json:"path"`
+	Size       uint64    `json:"size"`
+	Uploaded   int64     `json:"uploaded"` // 已上传大小，-1 表示存储策略无法统计
+	PolicyType string    `json:"policy_type"`
+	CreateDate time.Time `json:"create_date"`
+	LastActive time.Time `json:"last_active"`
+	Expired    bool      `json:"expired"` // 会话已失效，等待定时任务清理
 }
 
 // UploadCallback 上传回调正文
 type UploadCallback struct {
 	PicInfo string `json:"pic_info"`
+	MD5     string `json:"md5,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	Size    uint64 `json:"size,omitempty"`
 }
 
 // GeneralUploadCallbackFailed 存储策略上传回调失败响应
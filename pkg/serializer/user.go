@@ -5,6 +5,7 @@ import (
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
 	"github.com/duo-labs/webauthn/webauthn"
 	"time"
 )
@@ -27,8 +28,10 @@ type User struct {
 	CreatedAt      time.Time `json:"created_at"`
 	PreferredTheme string    `json:"preferred_theme"`
 	Anonymous      bool      `json:"anonymous"`
-	Group          group     `json:"group"`
-	Tags           []tag     `json:"tags"`
+	// PasswordExpired 密码已超过有效期，需要更换
+	PasswordExpired bool  `json:"password_expired,omitempty"`
+	Group           group `json:"group"`
+	Tags            []tag `json:"tags"`
 }
 
 type group struct {
@@ -58,6 +61,9 @@ type storage struct {
 	Used  uint64 `json:"used"`
 	Free  uint64 `json:"free"`
 	Total uint64 `json:"total"`
+	// 超额宽限期内可额外使用的容量
+	Grace     uint64     `json:"grace,omitempty"`
+	GraceEnds *time.Time `json:"grace_ends,omitempty"`
 }
 
 // WebAuthnCredentials 外部验证器凭证
@@ -66,6 +72,61 @@ type WebAuthnCredentials struct {
 	FingerPrint string `json:"fingerprint"`
 }
 
+// ActiveSession 用户的活跃登录会话
+type ActiveSession struct {
+	ID         string    `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastActive time.Time `json:"last_active"`
+	Current    bool      `json:"current"`
+}
+
+// WebDAVActivity WebDAV 账号的最近活动
+type WebDAVActivity struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	IP         string     `json:"ip,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	LastActive *time.Time `json:"last_active,omitempty"`
+}
+
+// BuildActiveSessions 构建活跃会话列表，currentID 为当前请求的会话ID
+func BuildActiveSessions(activities []sessionstore.Activity, currentID string) []ActiveSession {
+	res := make([]ActiveSession, 0, len(activities))
+	for _, activity := range activities {
+		res = append(res, ActiveSession{
+			ID:         activity.Key(),
+			IP:         activity.IP,
+			UserAgent:  activity.UserAgent,
+			CreatedAt:  activity.CreatedAt,
+			LastActive: activity.LastActive,
+			Current:    activity.SessionID == currentID,
+		})
+	}
+
+	return res
+}
+
+// BuildWebDAVActivities 构建 WebDAV 账号活动列表
+func BuildWebDAVActivities(accounts []model.Webdav) []WebDAVActivity {
+	res := make([]WebDAVActivity, 0, len(accounts))
+	for _, account := range accounts {
+		item := WebDAVActivity{
+			ID:   account.ID,
+			Name: account.Name,
+		}
+		if activity, ok := sessionstore.WebDAVActivity(account.ID); ok {
+			item.IP = activity.IP
+			item.UserAgent = activity.UserAgent
+			item.LastActive = &activity.LastActive
+		}
+		res = append(res, item)
+	}
+
+	return res
+}
+
 // BuildWebAuthnList 构建设置页面凭证列表
 func BuildWebAuthnList(credentials []webauthn.Credential) []WebAuthnCredentials {
 	res := make([]WebAuthnCredentials, 0, len(credentials))
@@ -84,14 +145,15 @@ func BuildWebAuthnList(credentials []webauthn.Credential) []WebAuthnCredentials
 func BuildUser(user model.User) User {
 	tags, _ := model.GetTagsByUID(user.ID)
 	return User{
-		ID:             hashid.HashID(user.ID, hashid.UserID),
-		Email:          user.Email,
-		Nickname:       user.Nick,
-		Status:         user.Status,
-		Avatar:         user.Avatar,
-		CreatedAt:      user.CreatedAt,
-		PreferredTheme: user.OptionsSerialized.PreferredTheme,
-		Anonymous:      user.IsAnonymous(),
+		ID:              hashid.HashID(user.ID, hashid.UserID),
+		Email:           user.Email,
+		Nickname:        user.Nick,
+		Status:          user.Status,
+		Avatar:          user.Avatar,
+		CreatedAt:       user.CreatedAt,
+		PreferredTheme:  user.OptionsSerialized.PreferredTheme,
+		Anonymous:       user.IsAnonymous(),
+		PasswordExpired: user.PasswordExpired(),
 		Group: group{
 			ID:                   user.GroupID,
 			Name:                 user.Group.Name,
@@ -118,7 +180,7 @@ func BuildUserResponse(user model.User) Response {
 
 // BuildUserStorageResponse 序列化用户存储概况响应
 func BuildUserStorageResponse(user model.User) Response {
-	total := user.Group.MaxStorage
+	total := user.TotalCapacity()
 	storageResp := storage{
 		Used:  user.Storage,
 		Free:  total - user.Storage,
@@ -129,6 +191,11 @@ func BuildUserStorageResponse(user model.User) Response {
 		storageResp.Free = 0
 	}
 
+	if user.InQuotaGrace() {
+		storageResp.Grace = total * uint64(user.Group.OptionsSerialized.QuotaGrace) / 100
+		storageResp.GraceEnds = user.QuotaGraceEnds()
+	}
+
 	return Response{
 		Data: storageResp,
 	}
@@ -154,3 +221,31 @@ func buildTagRes(tags []model.Tag) []tag {
 
 	return res
 }
+
+type traffic struct {
+	Upload   uint64         `json:"upload"`
+	Download uint64         `json:"download"`
+	Limit    uint64         `json:"limit"`
+	Daily    []dailyTraffic `json:"daily"`
+}
+
+type dailyTraffic struct {
+	Date     string `json:"date"`
+	Upload   uint64 `json:"upload"`
+	Download uint64 `json:"download"`
+}
+
+// BuildUserTrafficResponse 序列化用户流量统计响应，upload、download 为本月的总流量
+func BuildUserTrafficResponse(user *model.User, upload, download uint64, daily []model.Traffic) Response {
+	res := traffic{
+		Upload:   upload,
+		Download: download,
+		Limit:    user.Group.OptionsSerialized.TrafficLimit,
+		Daily:    make([]dailyTraffic, 0, len(daily)),
+	}
+	for _, record := range daily {
+		res.Daily = append(res.Daily, dailyTraffic{Date: record.Date, Upload: record.Upload, Download: record.Download})
+	}
+
+	return Response{Data: res}
+}
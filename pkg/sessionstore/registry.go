@@ -0,0 +1,218 @@
+package sessionstore
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+)
+
+const (
+	// KeyPrefix 会话数据在缓存中的键前缀
+	KeyPrefix = "cd_session_"
+
+	userSessionsKey    = "user_sessions_%d"
+	webdavActivityKey  = "webdav_activity_%d"
+	activityTTL        = 60 * 86400
+	activityThrottling = 60 * time.Second
+)
+
+func init() {
+	gob.Register(Activity{})
+	gob.Register(map[string]Activity{})
+}
+
+// Activity 会话或 WebDAV 账号的活动记录
+type Activity struct {
+	// SessionID 原始会话ID，不对外展示
+	SessionID  string `json:"-"`
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastActive time.Time
+}
+
+// Key 返回用于对外展示和撤销的会话标识
+func (a Activity) Key() string {
+	return SessionKey(a.SessionID)
+}
+
+// SessionKey 根据原始会话ID计算对外展示的会话标识
+func SessionKey(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:8])
+}
+
+// registryLock 避免同一进程内并发读写用户会话列表导致记录丢失
+var registryLock sync.Mutex
+
+func userSessions(uid uint) map[string]Activity {
+	if raw, ok := cache.Get(fmt.Sprintf(userSessionsKey, uid)); ok {
+		if sessions, ok := raw.(map[string]Activity); ok {
+			return sessions
+		}
+	}
+
+	return make(map[string]Activity)
+}
+
+func saveUserSessions(uid uint, sessions map[string]Activity) error {
+	if len(sessions) == 0 {
+		return cache.Deletes([]string{fmt.Sprintf(userSessionsKey, uid)}, "")
+	}
+
+	return cache.Set(fmt.Sprintf(userSessionsKey, uid), sessions, activityTTL)
+}
+
+// Record 记录用户会话的活动，同一会话在短时间内的重复活动会被忽略
+func Record(uid uint, sessionID, ip, ua string) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	// 绝大多数请求处于节流期内，无需加锁
+	now := time.Now()
+	if throttled(userSessions(uid), sessionID, ip, now) {
+		return nil
+	}
+
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	sessions := userSessions(uid)
+	if throttled(sessions, sessionID, ip, now) {
+		return nil
+	}
+
+	activity, ok := sessions[sessionID]
+
+	if !ok {
+		activity = Activity{SessionID: sessionID, CreatedAt: now}
+	}
+
+	activity.IP = ip
+	activity.UserAgent = ua
+	activity.LastActive = now
+	sessions[sessionID] = activity
+	return saveUserSessions(uid, sessions)
+}
+
+// throttled 返回会话是否在节流期内已从同一 IP 记录过活动
+func throttled(sessions map[string]Activity, sessionID, ip string, now time.Time) bool {
+	activity, ok := sessions[sessionID]
+	return ok && activity.IP == ip && now.Sub(activity.LastActive) < activityThrottling
+}
+
+// List 列出用户仍然有效的会话，已过期的会话会被清理
+func List(uid uint) []Activity {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	sessions := userSessions(uid)
+	res := make([]Activity, 0, len(sessions))
+	changed := false
+	for id, activity := range sessions {
+		if _, ok := cache.Get(KeyPrefix + id); !ok {
+			delete(sessions, id)
+			changed = true
+			continue
+		}
+
+		res = append(res, activity)
+	}
+
+	if changed {
+		_ = saveUserSessions(uid, sessions)
+	}
+
+	return res
+}
+
+// Forget 从用户会话列表中移除会话记录，不影响会话本身
+func Forget(uid uint, sessionID string) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	sessions := userSessions(uid)
+	if _, ok := sessions[sessionID]; !ok {
+		return nil
+	}
+
+	delete(sessions, sessionID)
+	return saveUserSessions(uid, sessions)
+}
+
+// Revoke 根据对外展示的会话标识撤销用户会话，返回是否找到对应会话
+func Revoke(uid uint, key string) (bool, error) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	sessions := userSessions(uid)
+	for id := range sessions {
+		if SessionKey(id) == key {
+			if err := cache.Deletes([]string{id}, KeyPrefix); err != nil {
+				return true, err
+			}
+
+			delete(sessions, id)
+			return true, saveUserSessions(uid, sessions)
+		}
+	}
+
+	return false, nil
+}
+
+// RevokeAll 撤销用户除 except 以外的所有会话，except 为空时撤销全部
+func RevokeAll(uid uint, except string) error {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	sessions := userSessions(uid)
+	toBeDeleted := make([]string, 0, len(sessions))
+	for id := range sessions {
+		if id != except {
+			toBeDeleted = append(toBeDeleted, id)
+			delete(sessions, id)
+		}
+	}
+
+	if len(toBeDeleted) > 0 {
+		if err := cache.Deletes(toBeDeleted, KeyPrefix); err != nil {
+			return err
+		}
+	}
+
+	return saveUserSessions(uid, sessions)
+}
+
+// RecordWebDAV 记录 WebDAV 账号的活动
+func RecordWebDAV(accountID uint, ip, ua string) error {
+	now := time.Now()
+	activity, ok := WebDAVActivity(accountID)
+	if ok && activity.IP == ip && now.Sub(activity.LastActive) < activityThrottling {
+		return nil
+	}
+
+	if !ok {
+		activity.CreatedAt = now
+	}
+
+	activity.IP = ip
+	activity.UserAgent = ua
+	activity.LastActive = now
+	return cache.Set(fmt.Sprintf(webdavActivityKey, accountID), activity, activityTTL)
+}
+
+// WebDAVActivity 获取 WebDAV 账号最近一次活动
+func WebDAVActivity(accountID uint) (Activity, bool) {
+	if raw, ok := cache.Get(fmt.Sprintf(webdavActivityKey, accountID)); ok {
+		activity, ok := raw.(Activity)
+		return activity, ok
+	}
+
+	return Activity{}, false
+}
@@ -0,0 +1,103 @@
+package sessionstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndList(t *testing.T) {
+	a := assert.New(t)
+	cache.Set(KeyPrefix+"s1", []byte{}, 0)
+	cache.Set(KeyPrefix+"s2", []byte{}, 0)
+
+	// 空会话ID
+	a.NoError(Record(1, "", "127.0.0.1", "ua"))
+	a.Len(List(1), 0)
+
+	a.NoError(Record(1, "s1", "127.0.0.1", "ua1"))
+	a.NoError(Record(1, "s2", "127.0.0.2", "ua2"))
+	a.NoError(Record(1, "expired", "127.0.0.3", "ua3"))
+	a.Len(userSessions(1), 3)
+
+	// 已过期的会话被清理
+	res := List(1)
+	a.Len(res, 2)
+	a.Len(userSessions(1), 2)
+
+	// 短时间内重复活动不更新
+	before := userSessions(1)["s1"].LastActive
+	a.NoError(Record(1, "s1", "127.0.0.1", "ua1"))
+	a.Equal(before, userSessions(1)["s1"].LastActive)
+
+	// 节流期内的活动无需等待锁
+	registryLock.Lock()
+	a.NoError(Record(1, "s1", "127.0.0.1", "ua1"))
+	registryLock.Unlock()
+
+	// IP 变化时更新
+	a.NoError(Record(1, "s1", "127.0.0.9", "ua1"))
+	a.Equal("127.0.0.9", userSessions(1)["s1"].IP)
+
+	a.NoError(Forget(1, "s1"))
+	a.NoError(Forget(1, "not_exist"))
+	a.Len(userSessions(1), 1)
+	_, ok := cache.Get(KeyPrefix + "s1")
+	a.True(ok)
+}
+
+func TestRevoke(t *testing.T) {
+	a := assert.New(t)
+	cache.Set(KeyPrefix+"r1", []byte{}, 0)
+	cache.Set(KeyPrefix+"r2", []byte{}, 0)
+	a.NoError(Record(2, "r1", "127.0.0.1", "ua1"))
+	a.NoError(Record(2, "r2", "127.0.0.1", "ua1"))
+
+	// 会话不存在
+	found, err := Revoke(2, "not_exist")
+	a.False(found)
+	a.NoError(err)
+
+	found, err = Revoke(2, SessionKey("r1"))
+	a.True(found)
+	a.NoError(err)
+	_, ok := cache.Get(KeyPrefix + "r1")
+	a.False(ok)
+	a.Len(List(2), 1)
+}
+
+func TestRevokeAll(t *testing.T) {
+	a := assert.New(t)
+	for _, id := range []string{"a1", "a2", "a3"} {
+		cache.Set(KeyPrefix+id, []byte{}, 0)
+		a.NoError(Record(3, id, "127.0.0.1", "ua"))
+	}
+
+	// 保留当前会话
+	a.NoError(RevokeAll(3, "a1"))
+	res := List(3)
+	a.Len(res, 1)
+	a.Equal("a1", res[0].SessionID)
+	_, ok := cache.Get(KeyPrefix + "a2")
+	a.False(ok)
+
+	// 全部注销
+	a.NoError(RevokeAll(3, ""))
+	a.Len(List(3), 0)
+	_, ok = cache.Get(KeyPrefix + "a1")
+	a.False(ok)
+}
+
+func TestRecordWebDAV(t *testing.T) {
+	a := assert.New(t)
+	_, ok := WebDAVActivity(1)
+	a.False(ok)
+
+	a.NoError(RecordWebDAV(1, "127.0.0.1", "ua"))
+	activity, ok := WebDAVActivity(1)
+	a.True(ok)
+	a.Equal("127.0.0.1", activity.IP)
+	a.WithinDuration(time.Now(), activity.LastActive, time.Minute)
+}
@@ -10,7 +10,7 @@ type Store interface {
 }
 
 func NewStore(driver cache.Driver, keyPairs ...[]byte) Store {
-	return &store{newKvStore("cd_session_", driver, keyPairs...)}
+	return &store{newKvStore(KeyPrefix, driver, keyPairs...)}
 }
 
 type store struct {
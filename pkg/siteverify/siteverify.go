@@ -0,0 +1,80 @@
+package siteverify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+)
+
+const (
+	// HCaptchaEndpoint hCaptcha 校验接口
+	HCaptchaEndpoint = "https://hcaptcha.com/siteverify"
+	// TurnstileEndpoint Cloudflare Turnstile 校验接口
+	TurnstileEndpoint = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+)
+
+var ErrVerificationFailed = errors.New("captcha verification failed")
+
+type verifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes,omitempty"`
+}
+
+// Verifier 兼容 siteverify 协议的验证码校验器，hCaptcha 与 Turnstile 均使用此协议
+type Verifier struct {
+	Endpoint string
+	Secret   string
+	Client   request.Client
+}
+
+// NewHCaptcha 新建 hCaptcha 校验器
+func NewHCaptcha(secret string) *Verifier {
+	return &Verifier{Endpoint: HCaptchaEndpoint, Secret: secret, Client: request.NewClient()}
+}
+
+// NewTurnstile 新建 Turnstile 校验器
+func NewTurnstile(secret string) *Verifier {
+	return &Verifier{Endpoint: TurnstileEndpoint, Secret: secret, Client: request.NewClient()}
+}
+
+// Verify 校验客户端提交的验证码令牌
+func (v *Verifier) Verify(token, remoteIP string) error {
+	if token == "" {
+		return ErrVerificationFailed
+	}
+
+	form := url.Values{}
+	form.Set("secret", v.Secret)
+	form.Set("response", token)
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	res, err := v.Client.Request(
+		"POST",
+		v.Endpoint,
+		strings.NewReader(form.Encode()),
+		request.WithHeader(http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}),
+		request.WithTimeout(10*time.Second),
+	).CheckHTTPResponse(http.StatusOK).GetResponse()
+	if err != nil {
+		return fmt.Errorf("failed to request siteverify endpoint: %w", err)
+	}
+
+	var verifyRes verifyResponse
+	if err := json.Unmarshal([]byte(res), &verifyRes); err != nil {
+		return fmt.Errorf("failed to parse siteverify response: %w", err)
+	}
+
+	if !verifyRes.Success {
+		return fmt.Errorf("%w: %s", ErrVerificationFailed, strings.Join(verifyRes.ErrorCodes, ","))
+	}
+
+	return nil
+}
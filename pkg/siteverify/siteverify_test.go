@@ -0,0 +1,76 @@
+package siteverify
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/mocks/requestmock"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/stretchr/testify/assert"
+	testMock "github.com/stretchr/testify/mock"
+)
+
+func newResponse(body string) *request.Response {
+	return &request.Response{Response: &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}}
+}
+
+func TestNew(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(HCaptchaEndpoint, NewHCaptcha("secret").Endpoint)
+	a.Equal(TurnstileEndpoint, NewTurnstile("secret").Endpoint)
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	a := assert.New(t)
+
+	// 空令牌
+	{
+		v := &Verifier{Endpoint: "https://verify", Secret: "secret"}
+		a.ErrorIs(v.Verify("", ""), ErrVerificationFailed)
+	}
+
+	// 请求失败
+	{
+		client := &requestmock.RequestMock{}
+		v := &Verifier{Endpoint: "https://verify", Secret: "secret", Client: client}
+		client.On("Request", "POST", "https://verify", testMock.Anything, testMock.Anything).
+			Return(&request.Response{Err: errors.New("error")})
+		a.Error(v.Verify("token", "127.0.0.1"))
+		client.AssertExpectations(t)
+	}
+
+	// 响应无法解析
+	{
+		client := &requestmock.RequestMock{}
+		v := &Verifier{Endpoint: "https://verify", Secret: "secret", Client: client}
+		client.On("Request", "POST", "https://verify", testMock.Anything, testMock.Anything).
+			Return(newResponse("not json"))
+		a.Error(v.Verify("token", "127.0.0.1"))
+	}
+
+	// 校验未通过
+	{
+		client := &requestmock.RequestMock{}
+		v := &Verifier{Endpoint: "https://verify", Secret: "secret", Client: client}
+		client.On("Request", "POST", "https://verify", testMock.Anything, testMock.Anything).
+			Return(newResponse(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		a.ErrorIs(v.Verify("token", "127.0.0.1"), ErrVerificationFailed)
+	}
+
+	// 校验通过
+	{
+		client := &requestmock.RequestMock{}
+		v := &Verifier{Endpoint: "https://verify", Secret: "secret", Client: client}
+		client.On("Request", "POST", "https://verify", testMock.MatchedBy(func(body *strings.Reader) bool {
+			raw, _ := ioutil.ReadAll(body)
+			return strings.Contains(string(raw), "response=token") && strings.Contains(string(raw), "secret=secret")
+		}), testMock.Anything).Return(newResponse(`{"success":true}`))
+		a.NoError(v.Verify("token", "127.0.0.1"))
+	}
+}
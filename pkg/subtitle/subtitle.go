@@ -0,0 +1,164 @@
+package subtitle
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// 支持的字幕格式
+const (
+	FormatSRT = "srt"
+	FormatASS = "ass"
+	FormatSSA = "ssa"
+	FormatVTT = "vtt"
+
+	// ContentType WebVTT 的 MIME 类型
+	ContentType = "text/vtt; charset=utf-8"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported subtitle format")
+
+	srtTimestamp = regexp.MustCompile(`(\d{2}:\d{2}:\d{2}),(\d{3})`)
+	assOverride  = regexp.MustCompile(`\{[^}]*\}`)
+
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// Track 视频的一条字幕轨道
+type Track struct {
+	Name   string
+	Lang   string
+	Format string
+}
+
+// Format 返回字幕文件的格式，不支持的格式返回空字符串
+func Format(name string) string {
+	ext := strings.ToLower(strings.TrimPrefix(path.Ext(name), "."))
+	switch ext {
+	case FormatSRT, FormatASS, FormatSSA, FormatVTT:
+		return ext
+	}
+	return ""
+}
+
+// Match 判断 name 是否为视频 video 的外挂字幕，返回字幕轨道信息。
+// 字幕文件名需以视频文件名（不含扩展名）开头，如 movie.srt、movie.en.ass
+func Match(video, name string) (*Track, bool) {
+	format := Format(name)
+	if format == "" {
+		return nil, false
+	}
+
+	videoBase := strings.TrimSuffix(video, path.Ext(video))
+	subBase := strings.TrimSuffix(name, path.Ext(name))
+	if subBase != videoBase && !strings.HasPrefix(subBase, videoBase+".") {
+		return nil, false
+	}
+
+	return &Track{
+		Name:   name,
+		Lang:   strings.TrimPrefix(strings.TrimPrefix(subBase, videoBase), "."),
+		Format: format,
+	}, true
+}
+
+// ToVTT 将指定格式的字幕转换为 WebVTT
+func ToVTT(format string, content []byte) ([]byte, error) {
+	content = bytes.TrimPrefix(content, utf8BOM)
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+
+	switch format {
+	case FormatVTT:
+		return content, nil
+	case FormatSRT:
+		return srtToVTT(content), nil
+	case FormatASS, FormatSSA:
+		return assToVTT(content), nil
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+func srtToVTT(content []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("WEBVTT\n\n")
+	b.Write(srtTimestamp.ReplaceAll(content, []byte("$1.$2")))
+	return b.Bytes()
+}
+
+func assToVTT(content []byte) []byte {
+	var (
+		b        bytes.Buffer
+		inEvents bool
+		fields   []string
+	)
+	b.WriteString("WEBVTT\n\n")
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+
+		if !inEvents {
+			continue
+		}
+
+		if strings.HasPrefix(line, "Format:") {
+			fields = strings.Split(strings.TrimPrefix(line, "Format:"), ",")
+			for i := range fields {
+				fields[i] = strings.ToLower(strings.TrimSpace(fields[i]))
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(line, "Dialogue:") || len(fields) == 0 {
+			continue
+		}
+
+		// 最后一个字段为文本，其中可能包含逗号
+		values := strings.SplitN(strings.TrimPrefix(line, "Dialogue:"), ",", len(fields))
+		if len(values) != len(fields) {
+			continue
+		}
+
+		cue := make(map[string]string, len(fields))
+		for i, field := range fields {
+			cue[field] = strings.TrimSpace(values[i])
+		}
+
+		start, okStart := assTime(cue["start"])
+		end, okEnd := assTime(cue["end"])
+		if !okStart || !okEnd {
+			continue
+		}
+
+		text := assOverride.ReplaceAllString(cue["text"], "")
+		text = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(text)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", start, end, text)
+	}
+
+	return b.Bytes()
+}
+
+// assTime 将 ASS 时间 H:MM:SS.cc 转换为 WebVTT 时间 HH:MM:SS.mmm
+func assTime(raw string) (string, bool) {
+	var h, m, s, cs int
+	if _, err := fmt.Sscanf(raw, "%d:%d:%d.%d", &h, &m, &s, &cs); err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, cs*10), true
+}
@@ -0,0 +1,61 @@
+package subtitle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	asserts := assert.New(t)
+
+	track, ok := Match("movie.mkv", "movie.srt")
+	asserts.True(ok)
+	asserts.Equal("", track.Lang)
+	asserts.Equal(FormatSRT, track.Format)
+
+	track, ok = Match("movie.mkv", "movie.zh-CN.ASS")
+	asserts.True(ok)
+	asserts.Equal("zh-CN", track.Lang)
+	asserts.Equal(FormatASS, track.Format)
+
+	_, ok = Match("movie.mkv", "movie2.srt")
+	asserts.False(ok)
+	_, ok = Match("movie.mkv", "movie.txt")
+	asserts.False(ok)
+}
+
+func TestToVTT(t *testing.T) {
+	asserts := assert.New(t)
+
+	// SRT
+	{
+		res, err := ToVTT(FormatSRT, []byte("\xEF\xBB\xBF1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\n"))
+		asserts.NoError(err)
+		asserts.Equal("WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.500\nHello\n", string(res))
+	}
+
+	// ASS
+	{
+		raw := "[Script Info]\nTitle: test\n\n[Events]\n" +
+			"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+			"Dialogue: 0,0:00:01.20,0:00:03.05,Default,,0,0,0,,{\\b1}Hello,\\Nworld\n" +
+			"Comment: 0,0:00:04.00,0:00:05.00,Default,,0,0,0,,ignored\n"
+		res, err := ToVTT(FormatASS, []byte(raw))
+		asserts.NoError(err)
+		asserts.Equal("WEBVTT\n\n00:00:01.200 --> 00:00:03.050\nHello,\nworld\n\n", string(res))
+	}
+
+	// VTT
+	{
+		res, err := ToVTT(FormatVTT, []byte("WEBVTT\n"))
+		asserts.NoError(err)
+		asserts.Equal("WEBVTT\n", string(res))
+	}
+
+	// 不支持的格式
+	{
+		_, err := ToVTT("txt", nil)
+		asserts.Equal(ErrUnsupportedFormat, err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 )
 
@@ -25,9 +26,10 @@ type CompressTask struct {
 
 // CompressProps 压缩任务属性
 type CompressProps struct {
-	Dirs  []uint `json:"dirs"`
-	Files []uint `json:"files"`
-	Dst   string `json:"dst"`
+	Dirs     []uint `json:"dirs"`
+	Files    []uint `json:"files"`
+	Dst      string `json:"dst"`
+	Encoding string `json:"encoding,omitempty"`
 }
 
 // Props 获取任务属性
@@ -95,6 +97,8 @@ func (job *CompressTask) Do() {
 
 	util.Log().Debug("Starting compress file...")
 	job.TaskModel.SetProgress(CompressingProgress)
+	tracker := trackerOf(job.TaskModel)
+	tracker.SetTotal(job.totalSize())
 
 	// 创建临时压缩文件
 	saveFolder := "compress"
@@ -113,36 +117,72 @@ func (job *CompressTask) Do() {
 	defer zipFile.Close()
 
 	// 开始压缩
-	ctx := context.Background()
+	ctx := context.WithValue(tracker.Context(), fsctx.ArchiveEncodingCtx, job.TaskProps.Encoding)
 	err = fs.Compress(ctx, zipFile, job.TaskProps.Dirs, job.TaskProps.Files, false)
+	job.zipPath = zipFilePath
+	zipFile.Close()
 	if err != nil {
-		job.SetErrorMsg(err.Error())
+		job.fail(tracker, err)
 		return
 	}
 
-	job.zipPath = zipFilePath
-	zipFile.Close()
 	util.Log().Debug("Compressed file saved to %q, start uploading it...", zipFilePath)
 	job.TaskModel.SetProgress(TransferringProgress)
 
 	// 上传文件
 	err = fs.UploadFromPath(ctx, zipFilePath, job.TaskProps.Dst, 0)
 	if err != nil {
-		job.SetErrorMsg(err.Error())
+		job.fail(tracker, err)
 		return
 	}
 
 	job.removeZipFile()
 }
 
-// NewCompressTask 新建压缩任务
-func NewCompressTask(user *model.User, dst string, dirs, files []uint) (Job, error) {
+// fail 删除未完成的压缩文件。服务关闭导致的中断不视为失败，任务将在下次启动时重新压缩
+func (job *CompressTask) fail(tracker *Tracker, err error) {
+	if tracker.Interrupted() {
+		job.removeZipFile()
+		return
+	}
+
+	job.SetErrorMsg(err.Error())
+}
+
+// totalSize 统计待压缩文件的总大小，用于估算剩余时间
+func (job *CompressTask) totalSize() uint64 {
+	var (
+		total uint64
+		files []model.File
+	)
+
+	if len(job.TaskProps.Files) > 0 {
+		files, _ = model.GetFilesByIDs(job.TaskProps.Files, job.User.ID)
+	}
+
+	if len(job.TaskProps.Dirs) > 0 {
+		folders, _ := model.GetRecursiveChildFolder(job.TaskProps.Dirs, job.User.ID, true)
+		if len(folders) > 0 {
+			childFiles, _ := model.GetChildFilesOfFolders(&folders)
+			files = append(files, childFiles...)
+		}
+	}
+
+	for _, file := range files {
+		total += file.Size
+	}
+	return total
+}
+
+// NewCompressTask 新建压缩任务，encoding 为压缩文件中文件名的编码
+func NewCompressTask(user *model.User, dst string, dirs, files []uint, encoding string) (Job, error) {
 	newTask := &CompressTask{
 		User: user,
 		TaskProps: CompressProps{
-			Dirs:  dirs,
-			Files: files,
-			Dst:   dst,
+			Dirs:     dirs,
+			Files:    files,
+			Dst:      dst,
+			Encoding: encoding,
 		},
 	}
 
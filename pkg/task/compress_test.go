@@ -97,6 +97,8 @@ func TestCompressTask_Do(t *testing.T) {
 		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1,
 			1))
 		mock.ExpectCommit()
+		// 统计大小
+		mock.ExpectQuery("SELECT(.+)").WillReturnError(errors.New("error"))
 		// 查找目录
 		mock.ExpectQuery("SELECT(.+)").WillReturnError(errors.New("error"))
 		// 更新错误
@@ -124,6 +126,13 @@ func TestCompressTask_Do(t *testing.T) {
 		mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1,
 			1))
 		mock.ExpectCommit()
+		// 统计大小
+		mock.ExpectQuery("SELECT(.+)folders").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectQuery("SELECT(.+)folders").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT(.+)files").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "size"}).AddRow(1, 10))
 		// 查找目录
 		mock.ExpectQuery("SELECT(.+)folders").
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
@@ -156,7 +165,7 @@ func TestNewCompressTask(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
-		job, err := NewCompressTask(&model.User{}, "/", []uint{12}, []uint{})
+		job, err := NewCompressTask(&model.User{}, "/", []uint{12}, []uint{}, "")
 		asserts.NoError(mock.ExpectationsWereMet())
 		asserts.NotNil(job)
 		asserts.NoError(err)
@@ -167,7 +176,7 @@ func TestNewCompressTask(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
 		mock.ExpectRollback()
-		job, err := NewCompressTask(&model.User{}, "/", []uint{12}, []uint{})
+		job, err := NewCompressTask(&model.User{}, "/", []uint{12}, []uint{}, "")
 		asserts.NoError(mock.ExpectationsWereMet())
 		asserts.Nil(job)
 		asserts.Error(err)
@@ -3,9 +3,11 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"sync"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 )
 
 // DecompressTask 文件压缩任务
@@ -83,7 +85,29 @@ func (job *DecompressTask) Do() {
 
 	job.TaskModel.SetProgress(DecompressingProgress)
 
-	err = fs.Decompress(context.Background(), job.TaskProps.Src, job.TaskProps.Dst, job.TaskProps.Encoding)
+	// 记录已解压的文件，任务取消时删除
+	var (
+		lock      sync.Mutex
+		extracted []string
+	)
+	tracker := trackerOf(job.TaskModel)
+	ctx := context.WithValue(tracker.Context(), fsctx.ProgressFuncCtx, fsctx.ProgressFunc(func(current string, size uint64) {
+		lock.Lock()
+		extracted = append(extracted, current)
+		lock.Unlock()
+		tracker.Advance(current, size)
+	}))
+
+	err = fs.Decompress(ctx, job.TaskProps.Src, job.TaskProps.Dst, job.TaskProps.Encoding)
+	if tracker.Canceled() {
+		// 服务关闭导致的中断同样删除已解压的文件，下次启动时重新解压
+		removeOutput(fs, extracted)
+		if !tracker.Interrupted() {
+			job.SetErrorMsg("Task canceled.", nil)
+		}
+		return
+	}
+
 	if err != nil {
 		job.SetErrorMsg("Failed to decompress file.", err)
 		return
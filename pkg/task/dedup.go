@@ -0,0 +1,145 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+)
+
+// maxDuplicateGroups 任务属性中最多保存的重复文件分组数量
+const maxDuplicateGroups = 200
+
+// DedupTask 重复文件分析任务
+type DedupTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps DedupProps
+	Err       *JobError
+}
+
+// DedupProps 重复文件分析任务属性，分析完成后保存分析结果
+type DedupProps struct {
+	// Groups 可释放空间最多的若干组重复文件
+	Groups []filesystem.DuplicateGroup `json:"groups,omitempty"`
+	// GroupCount 重复文件分组总数
+	GroupCount int `json:"group_count"`
+	// Reclaimable 已确认重复的文件只保留一份时可释放的总空间
+	Reclaimable uint64 `json:"reclaimable"`
+}
+
+// Props 获取任务属性
+func (job *DedupTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *DedupTask) Type() int {
+	return DedupTaskType
+}
+
+// Creator 获取创建者ID
+func (job *DedupTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *DedupTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *DedupTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *DedupTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *DedupTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *DedupTask) GetError() *JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *DedupTask) Do() {
+	fs, err := filesystem.NewFileSystem(job.User)
+	if err != nil {
+		job.SetErrorMsg("Failed to create filesystem.", err)
+		return
+	}
+	defer fs.Recycle()
+
+	job.TaskModel.SetProgress(ListingProgress)
+	groups, err := fs.FindDuplicates(context.Background())
+	if err != nil {
+		job.SetErrorMsg("Failed to find duplicate files.", err)
+		return
+	}
+
+	job.TaskProps.GroupCount = len(groups)
+	job.TaskProps.Reclaimable = 0
+	for _, group := range groups {
+		if group.Verified {
+			job.TaskProps.Reclaimable += group.Reclaimable
+		}
+	}
+
+	if len(groups) > maxDuplicateGroups {
+		groups = groups[:maxDuplicateGroups]
+	}
+	job.TaskProps.Groups = groups
+
+	if err := job.TaskModel.SetProps(job.Props()); err != nil {
+		job.SetErrorMsg("Failed to save analysis result.", err)
+	}
+}
+
+// NewDedupTask 新建重复文件分析任务
+func NewDedupTask(user *model.User) (Job, error) {
+	newTask := &DedupTask{
+		User: user,
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewDedupTaskFromModel 从数据库记录中恢复重复文件分析任务
+func NewDedupTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &DedupTask{
+		User:      &user,
+		TaskModel: task,
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
@@ -0,0 +1,63 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupTask_Props(t *testing.T) {
+	asserts := assert.New(t)
+	task := &DedupTask{
+		User: &model.User{},
+		TaskProps: DedupProps{
+			Groups:      []filesystem.DuplicateGroup{{Size: 1, Files: []string{"a", "b"}, Reclaimable: 1}},
+			GroupCount:  1,
+			Reclaimable: 0,
+		},
+	}
+	asserts.Equal(`{"groups":[{"verified":false,"size":1,"files":["a","b"],"reclaimable":1}],"group_count":1,"reclaimable":0}`, task.Props())
+	asserts.Equal(DedupTaskType, task.Type())
+	asserts.EqualValues(0, task.Creator())
+	asserts.Nil(task.Model())
+}
+
+func TestNewDedupTask(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		job, err := NewDedupTask(&model.User{})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NotNil(job)
+		asserts.NoError(err)
+	}
+
+	// 失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		job, err := NewDedupTask(&model.User{})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Nil(job)
+		asserts.Error(err)
+	}
+}
+
+func TestNewDedupTaskFromModel(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	job, err := NewDedupTaskFromModel(&model.Task{Props: `{"group_count":3}`})
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Equal(3, job.(*DedupTask).TaskProps.GroupCount)
+}
@@ -5,4 +5,6 @@ import "errors"
 var (
 	// ErrUnknownTaskType 未知任务类型
 	ErrUnknownTaskType = errors.New("unknown task type")
+	// ErrTaskNotCancelable 任务已结束或不在当前实例中执行
+	ErrTaskNotCancelable = errors.New("task is not running on this instance")
 )
@@ -0,0 +1,246 @@
+package task
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// ExportTask 用户数据导出任务
+type ExportTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps ExportProps
+	Err       *JobError
+}
+
+// ExportProps 用户数据导出任务属性
+type ExportProps struct {
+	IncludeContent bool   `json:"include_content"`
+	Path           string `json:"path,omitempty"`
+}
+
+// Props 获取任务属性
+func (job *ExportTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *ExportTask) Type() int {
+	return ExportTaskType
+}
+
+// Creator 获取创建者ID
+func (job *ExportTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *ExportTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *ExportTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *ExportTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *ExportTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *ExportTask) GetError() *JobError {
+	return job.Err
+}
+
+// Records 收集用户的所有数据库记录，键为导出文件名
+func (job *ExportTask) Records() map[string]interface{} {
+	uid := job.User.ID
+	var (
+		files       []model.File
+		folders     []model.Folder
+		shares      []model.Share
+		tasks       []model.Task
+		downloads   []model.Download
+		quarantines []model.Quarantine
+		blocklist   []model.BlocklistLog
+		reports     []model.Report
+	)
+
+	model.DB.Where("user_id = ?", uid).Find(&files)
+	model.DB.Where("owner_id = ?", uid).Find(&folders)
+	model.DB.Where("user_id = ?", uid).Find(&shares)
+	model.DB.Where("user_id = ?", uid).Find(&tasks)
+	model.DB.Where("user_id = ?", uid).Find(&downloads)
+	model.DB.Where("user_id = ?", uid).Find(&quarantines)
+	model.DB.Where("user_id = ?", uid).Find(&blocklist)
+	model.DB.Where("reporter_id = ?", uid).Find(&reports)
+
+	// WebDAV 账户密码不导出
+	webdav := model.ListWebDAVAccounts(uid)
+	for i := range webdav {
+		webdav[i].Password = ""
+	}
+
+	// 原始会话ID可用于冒用登录状态，只导出对外展示的标识及设备信息
+	sessions := serializer.BuildActiveSessions(sessionstore.List(uid), "")
+
+	return map[string]interface{}{
+		"profile": map[string]interface{}{
+			"id":          uid,
+			"email":       job.User.Email,
+			"nick":        job.User.Nick,
+			"status":      job.User.Status,
+			"group":       job.User.Group.Name,
+			"storage":     job.User.Storage,
+			"two_factor":  job.User.TwoFactor != "",
+			"options":     job.User.OptionsSerialized,
+			"created_at":  job.User.CreatedAt,
+			"updated_at":  job.User.UpdatedAt,
+			"authn_count": len(job.User.WebAuthnCredentials()),
+		},
+		"files":       files,
+		"folders":     folders,
+		"shares":      shares,
+		"webdav":      webdav,
+		"tasks":       tasks,
+		"downloads":   downloads,
+		"sessions":    sessions,
+		"quarantines": quarantines,
+		"blocklist":   blocklist,
+		"reports":     reports,
+	}
+}
+
+// Do 开始执行任务
+func (job *ExportTask) Do() {
+	job.TaskModel.SetProgress(ListingProgress)
+
+	exportPath := filepath.Join(
+		util.RelativePath(model.GetSettingByName("temp_path")),
+		"export",
+		fmt.Sprintf("export_%d_%d.zip", job.User.ID, time.Now().UnixNano()),
+	)
+	exportFile, err := util.CreatNestedFile(exportPath)
+	if err != nil {
+		job.SetErrorMsg("Failed to create export file.", err)
+		return
+	}
+	defer exportFile.Close()
+
+	if err := job.write(exportFile); err != nil {
+		exportFile.Close()
+		os.Remove(exportPath)
+		job.SetErrorMsg("Failed to export user data.", err)
+		return
+	}
+
+	job.TaskProps.Path = exportPath
+	if err := job.TaskModel.SetProps(job.Props()); err != nil {
+		job.SetErrorMsg("Failed to save export path.", err)
+	}
+}
+
+// write 将用户数据写入 zip
+func (job *ExportTask) write(w *os.File) error {
+	zipWriter := zip.NewWriter(w)
+	defer zipWriter.Close()
+
+	for name, records := range job.Records() {
+		entry, err := zipWriter.Create(name + ".json")
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			return err
+		}
+	}
+
+	if !job.TaskProps.IncludeContent {
+		return nil
+	}
+
+	// 文件内容以嵌套压缩包的形式附带
+	job.TaskModel.SetProgress(CompressingProgress)
+	root, err := job.User.Root()
+	if err != nil {
+		return err
+	}
+
+	fs, err := filesystem.NewFileSystem(job.User)
+	if err != nil {
+		return err
+	}
+	defer fs.Recycle()
+
+	entry, err := zipWriter.Create("files.zip")
+	if err != nil {
+		return err
+	}
+
+	return fs.Compress(context.Background(), entry, []uint{root.ID}, nil, false)
+}
+
+// NewExportTask 新建用户数据导出任务
+func NewExportTask(user *model.User, includeContent bool) (Job, error) {
+	newTask := &ExportTask{
+		User: user,
+		TaskProps: ExportProps{
+			IncludeContent: includeContent,
+		},
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewExportTaskFromModel 从数据库记录中恢复用户数据导出任务
+func NewExportTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &ExportTask{
+		User:      &user,
+		TaskModel: task,
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
@@ -17,6 +17,22 @@ const (
 	ImportTaskType
 	// RecycleTaskType 回收任务
 	RecycleTaskType
+	// ScanTaskType 病毒扫描任务
+	ScanTaskType
+	// ExportTaskType 用户数据导出任务
+	ExportTaskType
+	// ShareSaveTaskType 转存分享内容任务
+	ShareSaveTaskType
+	// VerifyTaskType 文件哈希校验任务
+	VerifyTaskType
+	// DedupTaskType 重复文件分析任务
+	DedupTaskType
+	// UserImportTaskType 批量导入用户任务
+	UserImportTaskType
+	// RelocateTaskType 批量移动、复制任务
+	RelocateTaskType
+	// RehydrateTaskType 归档文件解冻任务
+	RehydrateTaskType
 )
 
 // 任务状态
@@ -31,6 +47,8 @@ const (
 	Canceled
 	// Complete 完成
 	Complete
+	// DeadLetter 多次重试后仍然失败，不再自动重试
+	DeadLetter
 )
 
 // 任务进度
@@ -49,6 +67,10 @@ const (
 	ListingProgress
 	// InsertingProgress 插入中
 	InsertingProgress
+	// ScanningProgress 扫描中
+	ScanningProgress
+	// VerifyingProgress 校验中
+	VerifyingProgress
 )
 
 // Job 任务接口
@@ -117,6 +139,22 @@ func GetJobFromModel(task *model.Task) (Job, error) {
 		return NewImportTaskFromModel(task)
 	case RecycleTaskType:
 		return NewRecycleTaskFromModel(task)
+	case ScanTaskType:
+		return NewScanTaskFromModel(task)
+	case ExportTaskType:
+		return NewExportTaskFromModel(task)
+	case ShareSaveTaskType:
+		return NewShareSaveTaskFromModel(task)
+	case VerifyTaskType:
+		return NewVerifyTaskFromModel(task)
+	case DedupTaskType:
+		return NewDedupTaskFromModel(task)
+	case UserImportTaskType:
+		return NewUserImportTaskFromModel(task)
+	case RelocateTaskType:
+		return NewRelocateTaskFromModel(task)
+	case RehydrateTaskType:
+		return NewRehydrateTaskFromModel(task)
 	default:
 		return nil, ErrUnknownTaskType
 	}
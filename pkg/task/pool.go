@@ -53,16 +53,21 @@ func (pool *AsyncPool) Submit(job Job) {
 	}()
 }
 
-// Init 初始化任务池
+// Init 初始化任务池，主机模式下使用持久化任务队列
 func Init() {
 	maxWorker := model.GetIntSetting("max_worker_num", 10)
+	if conf.SystemConfig.Mode == "master" {
+		queue := NewDBQueue()
+		TaskPoll = queue
+		TaskPoll.Add(maxWorker)
+		util.Log().Info("Initialize task queue with WorkerNum = %d", maxWorker)
+		queue.Start()
+		return
+	}
+
 	TaskPoll = &AsyncPool{
 		idleWorker: make(chan int, maxWorker),
 	}
 	TaskPoll.Add(maxWorker)
 	util.Log().Info("Initialize task queue with WorkerNum = %d", maxWorker)
-
-	if conf.SystemConfig.Mode == "master" {
-		Resume(TaskPoll)
-	}
 }
@@ -1,12 +1,15 @@
 package task
 
 import (
+	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
 	"github.com/jinzhu/gorm"
 	"github.com/stretchr/testify/assert"
 )
@@ -29,10 +32,29 @@ func TestMain(m *testing.M) {
 func TestInit(t *testing.T) {
 	asserts := assert.New(t)
 	cache.Set("setting_max_worker_num", "10", 0)
-	mock.ExpectQuery("SELECT(.+)").WithArgs(Queued, Processing).WillReturnRows(sqlmock.NewRows([]string{"type"}).AddRow(-1))
-	Init()
-	asserts.NoError(mock.ExpectationsWereMet())
-	asserts.Len(TaskPoll.(*AsyncPool).idleWorker, 10)
+
+	// 主机模式使用持久化队列，启动时重新排队中断的任务并开始调度
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT(.+)tasks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery("SELECT(.+)tasks(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		Init()
+		queue, ok := TaskPoll.(*DBQueue)
+		asserts.True(ok)
+		asserts.Eventually(func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+		queue.Shutdown(context.Background())
+	}
+
+	// 从机模式使用内存中的任务池
+	{
+		conf.SystemConfig.Mode = "slave"
+		defer func() { conf.SystemConfig.Mode = "master" }()
+		Init()
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Len(TaskPoll.(*AsyncPool).idleWorker, 10)
+	}
 }
 
 func TestPool_Submit(t *testing.T) {
@@ -0,0 +1,204 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// Progress 执行中任务的细粒度进度
+type Progress struct {
+	Total     uint64 `json:"total"`     // 需要处理的总字节数，0 表示未知
+	Processed uint64 `json:"processed"` // 已处理的字节数
+	Current   string `json:"current"`   // 最近处理的文件
+	ETA       int64  `json:"eta"`       // 预计剩余秒数，-1 表示未知
+}
+
+// Tracker 记录执行中任务的进度，并持有用于取消任务的上下文
+type Tracker struct {
+	lock        sync.Mutex
+	progress    Progress
+	startedAt   time.Time
+	interrupted bool
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// trackers 当前实例中执行中任务的进度记录
+var trackers = struct {
+	sync.RWMutex
+	tasks map[uint]*Tracker
+}{tasks: make(map[uint]*Tracker)}
+
+func newTracker() *Tracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tracker{
+		startedAt: time.Now(),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// startTracking 登记开始执行的任务，没有数据库记录的任务不会被登记
+func startTracking(job Job) *Tracker {
+	record := job.Model()
+	if record == nil {
+		return nil
+	}
+
+	tracker := newTracker()
+	trackers.Lock()
+	trackers.tasks[record.ID] = tracker
+	trackers.Unlock()
+	return tracker
+}
+
+// stopTracking 移除任务的进度记录
+func stopTracking(job Job, tracker *Tracker) {
+	if tracker == nil {
+		return
+	}
+
+	trackers.Lock()
+	if trackers.tasks[job.Model().ID] == tracker {
+		delete(trackers.tasks, job.Model().ID)
+	}
+	trackers.Unlock()
+	tracker.cancel()
+}
+
+// trackerOf 返回任务的进度记录，任务未经任务池执行时返回一个独立的记录
+func trackerOf(record *model.Task) *Tracker {
+	if record != nil {
+		trackers.RLock()
+		tracker, ok := trackers.tasks[record.ID]
+		trackers.RUnlock()
+		if ok {
+			return tracker
+		}
+	}
+
+	return newTracker()
+}
+
+// Context 返回任务被取消时结束的上下文，其中附带了进度回调
+func (t *Tracker) Context() context.Context {
+	return context.WithValue(t.ctx, fsctx.ProgressFuncCtx, fsctx.ProgressFunc(t.Advance))
+}
+
+// Canceled 返回任务是否已被取消
+func (t *Tracker) Canceled() bool {
+	return t != nil && t.ctx.Err() != nil
+}
+
+// Interrupted 返回任务是否因服务关闭而被中断，被中断的任务应保存进度并在下次启动时继续执行
+func (t *Tracker) Interrupted() bool {
+	if t == nil {
+		return false
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.interrupted
+}
+
+// interrupt 因服务关闭中断任务
+func (t *Tracker) interrupt() {
+	t.lock.Lock()
+	t.interrupted = true
+	t.lock.Unlock()
+	t.cancel()
+}
+
+// SetTotal 设定需要处理的总字节数
+func (t *Tracker) SetTotal(total uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.progress.Total = total
+}
+
+// Advance 记录一个文件处理完成
+func (t *Tracker) Advance(current string, size uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.progress.Current = current
+	t.progress.Processed += size
+}
+
+// Progress 返回当前进度
+func (t *Tracker) Progress() Progress {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	res := t.progress
+	res.ETA = -1
+	if res.Total > 0 && res.Processed > 0 && res.Processed <= res.Total {
+		elapsed := time.Since(t.startedAt)
+		res.ETA = int64(elapsed.Seconds() * float64(res.Total-res.Processed) / float64(res.Processed))
+	}
+	return res
+}
+
+// GetProgress 返回当前实例中执行中任务的进度
+func GetProgress(id uint) (Progress, bool) {
+	trackers.RLock()
+	tracker, ok := trackers.tasks[id]
+	trackers.RUnlock()
+	if !ok {
+		return Progress{}, false
+	}
+
+	return tracker.Progress(), true
+}
+
+// Cancel 取消任务。排队中的任务直接标记为已取消，执行中的任务会被通知停止并清理已产生的内容
+func Cancel(record *model.Task) error {
+	if model.ClaimTask(record.ID, Queued, Canceled) {
+		return nil
+	}
+
+	trackers.RLock()
+	tracker, ok := trackers.tasks[record.ID]
+	trackers.RUnlock()
+	if !ok {
+		return ErrTaskNotCancelable
+	}
+
+	tracker.cancel()
+	return nil
+}
+
+// interruptAll 中断当前实例中所有执行中的任务，返回被中断的任务数
+func interruptAll() int {
+	trackers.RLock()
+	defer trackers.RUnlock()
+
+	for _, tracker := range trackers.tasks {
+		tracker.interrupt()
+	}
+	return len(trackers.tasks)
+}
+
+// removeOutput 删除被取消的任务已经产生的文件
+func removeOutput(fs *filesystem.FileSystem, paths []string) {
+	ids := make([]uint, 0, len(paths))
+	for _, p := range paths {
+		if exist, file := fs.IsFileExist(p); exist {
+			ids = append(ids, file.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	fs.CleanTargets()
+	if err := fs.Delete(context.Background(), nil, ids, false, false); err != nil {
+		util.Log().Warning("Failed to remove output of canceled task: %s", err)
+	}
+}
@@ -0,0 +1,82 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker_Progress(t *testing.T) {
+	a := assert.New(t)
+	tracker := newTracker()
+
+	// 总量未知
+	tracker.Advance("a.txt", 10)
+	a.Equal(Progress{Processed: 10, Current: "a.txt", ETA: -1}, tracker.Progress())
+
+	// 估算剩余时间
+	tracker.startedAt = time.Now().Add(-10 * time.Second)
+	tracker.SetTotal(40)
+	fsctx.ReportProgress(tracker.Context(), "b.txt", 10)
+	progress := tracker.Progress()
+	a.EqualValues(20, progress.Processed)
+	a.Equal("b.txt", progress.Current)
+	a.InDelta(10, progress.ETA, 1)
+}
+
+func TestCancel(t *testing.T) {
+	a := assert.New(t)
+	record := &model.Task{Model: gorm.Model{ID: 1}}
+
+	// 排队中
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WithArgs(Canceled, sqlmock.AnyArg(), 1, Queued).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		a.NoError(Cancel(record))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 不在当前实例中执行
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		a.Equal(ErrTaskNotCancelable, Cancel(record))
+		a.NoError(mock.ExpectationsWereMet())
+	}
+
+	// 执行中
+	{
+		started := make(chan struct{})
+		job := &recordJob{record: record}
+		job.DoFunc = func() {
+			close(started)
+			<-trackerOf(record).Context().Done()
+			job.Err = &JobError{Msg: "canceled"}
+		}
+		done := make(chan struct{})
+		go func() {
+			(&GeneralWorker{}).Do(job)
+			close(done)
+		}()
+		<-started
+		_, ok := GetProgress(1)
+		a.True(ok)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		a.NoError(Cancel(record))
+		<-done
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(Canceled, job.Status)
+		_, ok = GetProgress(1)
+		a.False(ok)
+	}
+}
@@ -0,0 +1,326 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+const (
+	// queuePollInterval 队列轮询数据库的间隔
+	queuePollInterval = 5 * time.Second
+	// queueFetchSize 每次调度时最多读取的排队任务数
+	queueFetchSize = 100
+	// queueJobTTL 已提交任务对象在内存中保留的时长，超时后改为从数据库记录恢复
+	queueJobTTL = 10 * time.Minute
+	// queueInterruptTimeout 关闭时等待被中断任务退出的最长时间
+	queueInterruptTimeout = 10 * time.Second
+	// queueHeartbeatInterval 更新执行中任务心跳的间隔
+	queueHeartbeatInterval = 30 * time.Second
+	// queueStaleTimeout 执行中的任务超过此时长没有心跳时，视为所属实例已退出
+	queueStaleTimeout = 3 * queueHeartbeatInterval
+)
+
+// typeNames 任务类型在队列设置中使用的名称
+var typeNames = map[int]string{
+	CompressTaskType:   "compress",
+	DecompressTaskType: "decompress",
+	TransferTaskType:   "transfer",
+	ImportTaskType:     "import",
+	RecycleTaskType:    "recycle",
+	ScanTaskType:       "scan",
+	ExportTaskType:     "export",
+	ShareSaveTaskType:  "share_save",
+	VerifyTaskType:     "verify",
+	DedupTaskType:      "dedup",
+	UserImportTaskType: "user_import",
+	RelocateTaskType:   "relocate",
+	RehydrateTaskType:  "rehydrate",
+}
+
+type submittedJob struct {
+	job Job
+	at  time.Time
+}
+
+// DBQueue 以数据库任务记录为准的持久化任务队列。排队中的任务按类型优先级调度，
+// 失败后按指数退避重新排队，多次重试仍失败的任务进入死信状态
+type DBQueue struct {
+	// instance 当前队列实例的标识，用于区分多个实例领取的任务
+	instance string
+	lock     sync.Mutex
+	idle     int
+	running  map[int]int
+	jobs     map[uint]submittedJob
+	wake     chan struct{}
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewDBQueue 新建持久化任务队列
+func NewDBQueue() *DBQueue {
+	return &DBQueue{
+		instance: util.RandStringRunes(16),
+		running:  make(map[int]int),
+		jobs:     make(map[uint]submittedJob),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Add 增加可用Worker数量
+func (q *DBQueue) Add(num int) {
+	q.lock.Lock()
+	q.idle += num
+	q.lock.Unlock()
+	q.Wake()
+}
+
+// Submit 提交已记录到数据库的任务，没有数据库记录的任务会被立即执行
+func (q *DBQueue) Submit(job Job) {
+	record := job.Model()
+	if record == nil {
+		go (&GeneralWorker{}).Do(job)
+		return
+	}
+
+	q.lock.Lock()
+	q.jobs[record.ID] = submittedJob{job: job, at: time.Now()}
+	q.lock.Unlock()
+	q.Wake()
+}
+
+// Wake 立即触发一次调度
+func (q *DBQueue) Wake() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start 将失去心跳的执行中任务重新排队，并开始调度
+func (q *DBQueue) Start() {
+	q.requeueStale(time.Now())
+
+	go func() {
+		ticker := time.NewTicker(queuePollInterval)
+		defer ticker.Stop()
+		heartbeat := time.NewTicker(queueHeartbeatInterval)
+		defer heartbeat.Stop()
+		for {
+			q.dispatch(time.Now())
+			select {
+			case <-ticker.C:
+			case <-q.wake:
+			case now := <-heartbeat.C:
+				q.heartbeat(now)
+				q.requeueStale(now)
+			case <-q.stop:
+				return
+			}
+		}
+	}()
+}
+
+// heartbeat 更新当前实例执行中任务的心跳时间
+func (q *DBQueue) heartbeat(now time.Time) {
+	if err := model.TouchTasks(q.instance, Processing, now); err != nil {
+		util.Log().Warning("Failed to update heartbeat of running tasks: %s", err)
+	}
+}
+
+// requeueStale 将所属实例已退出或中断的执行中任务重新排队，其他实例仍在执行的任务保持不变
+func (q *DBQueue) requeueStale(now time.Time) {
+	if count, err := model.ResetStaleTasks(Processing, Queued, now.Add(-queueStaleTimeout)); err != nil {
+		util.Log().Warning("Failed to requeue interrupted tasks: %s", err)
+	} else if count > 0 {
+		util.Log().Info("Requeue %d interrupted task(s).", count)
+	}
+}
+
+// Shutdown 停止调度新任务，并等待执行中的任务结束。ctx 结束时仍在执行的任务会被中断，
+// 由任务自行保存进度后重新排队，下次启动时继续执行
+func (q *DBQueue) Shutdown(ctx context.Context) {
+	q.lock.Lock()
+	select {
+	case <-q.stop:
+	default:
+		close(q.stop)
+	}
+	q.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	if count := interruptAll(); count > 0 {
+		util.Log().Info("Interrupt %d running task(s), they will be resumed on next start.", count)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(queueInterruptTimeout):
+		util.Log().Warning("Timeout waiting for interrupted tasks to exit.")
+	}
+}
+
+// dispatch 领取已到执行时间的排队任务，直到没有空闲Worker
+func (q *DBQueue) dispatch(now time.Time) {
+	q.lock.Lock()
+	select {
+	case <-q.stop:
+		q.lock.Unlock()
+		return
+	default:
+	}
+	idle := q.idle
+	for id, submitted := range q.jobs {
+		if now.Sub(submitted.at) > queueJobTTL {
+			delete(q.jobs, id)
+		}
+	}
+	q.lock.Unlock()
+
+	if idle <= 0 {
+		return
+	}
+
+	tasks := model.GetDueTasks(Queued, now, queueFetchSize)
+	if len(tasks) == 0 {
+		return
+	}
+
+	priorities := typeSettings("task_queue_priority")
+	limits := typeSettings("task_queue_concurrency")
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return priorities[tasks[i].Type] > priorities[tasks[j].Type]
+	})
+
+	for i := range tasks {
+		record := &tasks[i]
+
+		q.lock.Lock()
+		if q.idle <= 0 {
+			q.lock.Unlock()
+			return
+		}
+		select {
+		case <-q.stop:
+			q.lock.Unlock()
+			return
+		default:
+		}
+		if limit := limits[record.Type]; limit > 0 && q.running[record.Type] >= limit {
+			q.lock.Unlock()
+			continue
+		}
+		q.lock.Unlock()
+
+		// 任务可能已被取消或由其他实例领取
+		if !model.ClaimTaskAs(record.ID, Queued, Processing, q.instance, now) {
+			continue
+		}
+
+		q.lock.Lock()
+		q.idle--
+		q.running[record.Type]++
+		q.wg.Add(1)
+		submitted := q.jobs[record.ID]
+		delete(q.jobs, record.ID)
+		q.lock.Unlock()
+
+		go q.run(record, submitted.job)
+	}
+}
+
+// run 执行任务，job 为空时从数据库记录恢复任务
+func (q *DBQueue) run(record *model.Task, job Job) {
+	defer func() {
+		q.lock.Lock()
+		q.idle++
+		q.running[record.Type]--
+		q.lock.Unlock()
+		q.wg.Done()
+		q.Wake()
+	}()
+
+	if job == nil {
+		var err error
+		job, err = GetJobFromModel(record)
+		if err != nil || job == nil {
+			util.Log().Warning("Failed to restore task [ID=%d]: %s", record.ID, err)
+			record.SetStatus(Error)
+			return
+		}
+	}
+
+	worker := &GeneralWorker{FailedStatus: q.failedStatus}
+	worker.Do(job)
+}
+
+// failedStatus 决定失败任务的状态，未达到最大重试次数时按指数退避重新排队
+func (q *DBQueue) failedStatus(job Job) int {
+	record := job.Model()
+	maxRetries := model.GetIntSetting("task_max_retries", 3)
+	if record.Attempts >= maxRetries {
+		if maxRetries > 0 {
+			util.Log().Warning("Task [ID=%d] failed after %d retries.", record.ID, record.Attempts)
+			return DeadLetter
+		}
+		return Error
+	}
+
+	backoff := time.Duration(model.GetIntSetting("task_retry_backoff", 60)) * time.Second << uint(record.Attempts)
+	if err := record.Retry(time.Now().Add(backoff)); err != nil {
+		util.Log().Warning("Failed to schedule retry of task [ID=%d]: %s", record.ID, err)
+		return Error
+	}
+
+	util.Log().Info("Task [ID=%d] failed, retry in %s.", record.ID, backoff)
+	return Queued
+}
+
+// typeSettings 读取以任务类型名称为键的整数设置
+func typeSettings(name string) map[int]int {
+	raw := make(map[string]int)
+	if setting := model.GetSettingByName(name); setting != "" {
+		if err := json.Unmarshal([]byte(setting), &raw); err != nil {
+			util.Log().Warning("Failed to parse setting %q: %s", name, err)
+		}
+	}
+
+	res := make(map[int]int, len(raw))
+	for taskType, typeName := range typeNames {
+		if v, ok := raw[typeName]; ok {
+			res[taskType] = v
+		}
+	}
+	return res
+}
+
+// Wake 当前任务池为持久化队列时立即触发一次调度
+func Wake() {
+	if q, ok := TaskPoll.(*DBQueue); ok {
+		q.Wake()
+	}
+}
+
+// Shutdown 关闭任务池，仅持久化任务队列需要关闭
+func Shutdown(ctx context.Context) {
+	if q, ok := TaskPoll.(*DBQueue); ok {
+		q.Shutdown(ctx)
+	}
+}
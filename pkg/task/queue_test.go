@@ -0,0 +1,203 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordJob struct {
+	MockJob
+	record *model.Task
+}
+
+func (job *recordJob) Model() *model.Task {
+	return job.record
+}
+
+func (job *recordJob) Creator() uint {
+	return 0
+}
+
+func TestDBQueue_Submit(t *testing.T) {
+	a := assert.New(t)
+	q := NewDBQueue()
+
+	// 没有数据库记录的任务立即执行
+	{
+		done := make(chan struct{})
+		q.Submit(&recordJob{MockJob: MockJob{DoFunc: func() { close(done) }}})
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			a.Fail("job is not executed")
+		}
+		a.Empty(q.jobs)
+	}
+
+	// 等待调度
+	{
+		q.Submit(&recordJob{record: &model.Task{Model: gorm.Model{ID: 1}}})
+		a.Contains(q.jobs, uint(1))
+		a.Len(q.wake, 1)
+	}
+}
+
+func TestDBQueue_Dispatch(t *testing.T) {
+	a := assert.New(t)
+	cache.Set("setting_task_queue_priority", `{"compress":10}`, 0)
+	cache.Set("setting_task_queue_concurrency", `{"compress":1}`, 0)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "type", "status"}).
+			AddRow(1, ImportTaskType, Queued).
+			AddRow(2, CompressTaskType, Queued)
+	}
+	wait := func(q *DBQueue) {
+		for i := 0; i < 100; i++ {
+			q.lock.Lock()
+			idle := q.idle
+			q.lock.Unlock()
+			if idle > 0 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// 优先调度高优先级任务
+	{
+		q := NewDBQueue()
+		q.Add(1)
+		done := make(chan struct{})
+		q.jobs[2] = submittedJob{job: &recordJob{
+			MockJob: MockJob{DoFunc: func() { close(done) }},
+			record:  &model.Task{Model: gorm.Model{ID: 2}},
+		}, at: time.Now()}
+		mock.ExpectQuery("SELECT(.+)tasks(.+)").WillReturnRows(rows())
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WithArgs(sqlmock.AnyArg(), q.instance, Processing, sqlmock.AnyArg(), 2, Queued).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		q.dispatch(time.Now())
+		<-done
+		wait(q)
+		a.NoError(mock.ExpectationsWereMet())
+		a.Empty(q.jobs)
+		a.Equal(0, q.running[CompressTaskType])
+	}
+
+	// 超出类型并发限制的任务被跳过，已被领取的任务被忽略
+	{
+		q := NewDBQueue()
+		q.Add(1)
+		q.running[CompressTaskType] = 1
+		mock.ExpectQuery("SELECT(.+)tasks(.+)").WillReturnRows(rows())
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WithArgs(sqlmock.AnyArg(), q.instance, Processing, sqlmock.AnyArg(), 1, Queued).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+		q.dispatch(time.Now())
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(1, q.idle)
+	}
+
+	// 没有空闲Worker
+	{
+		q := NewDBQueue()
+		q.dispatch(time.Now())
+		a.NoError(mock.ExpectationsWereMet())
+	}
+}
+
+func TestDBQueue_RequeueStale(t *testing.T) {
+	a := assert.New(t)
+	q := NewDBQueue()
+	now := time.Now()
+
+	// 更新当前实例任务的心跳
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)tasks(.+)").WithArgs(now, q.instance, Processing).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	q.heartbeat(now)
+	a.NoError(mock.ExpectationsWereMet())
+
+	// 仅重新排队心跳超时的任务
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)tasks(.+)heartbeat_at is null or heartbeat_at <").
+		WithArgs(Queued, sqlmock.AnyArg(), Processing, now.Add(-queueStaleTimeout)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	q.requeueStale(now)
+	a.NoError(mock.ExpectationsWereMet())
+}
+
+func TestDBQueue_FailedStatus(t *testing.T) {
+	a := assert.New(t)
+	q := NewDBQueue()
+	cache.Set("setting_task_retry_backoff", "60", 0)
+
+	// 重新排队
+	{
+		cache.Set("setting_task_max_retries", "3", 0)
+		record := &model.Task{Model: gorm.Model{ID: 1}, Attempts: 1}
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		a.Equal(Queued, q.failedStatus(&recordJob{record: record}))
+		a.NoError(mock.ExpectationsWereMet())
+		a.Equal(2, record.Attempts)
+		a.WithinDuration(time.Now().Add(2*time.Minute), *record.NextRunAt, 5*time.Second)
+	}
+
+	// 超出重试次数
+	{
+		record := &model.Task{Model: gorm.Model{ID: 1}, Attempts: 3}
+		a.Equal(DeadLetter, q.failedStatus(&recordJob{record: record}))
+	}
+
+	// 未启用重试
+	{
+		cache.Set("setting_task_max_retries", "0", 0)
+		record := &model.Task{Model: gorm.Model{ID: 1}}
+		a.Equal(Error, q.failedStatus(&recordJob{record: record}))
+	}
+}
+
+func TestGeneralWorker_FailedStatus(t *testing.T) {
+	a := assert.New(t)
+	worker := &GeneralWorker{FailedStatus: func(Job) int { return Queued }}
+	job := &MockJob{DoFunc: func() {}, Err: &JobError{Msg: "error"}}
+	worker.Do(job)
+	a.Equal(Queued, job.Status)
+}
+
+func TestDBQueue_Shutdown(t *testing.T) {
+	a := assert.New(t)
+	q := NewDBQueue()
+	q.Add(1)
+
+	// 中断执行中的任务
+	record := &model.Task{Model: gorm.Model{ID: 3}}
+	started := make(chan struct{})
+	job := &recordJob{record: record}
+	job.DoFunc = func() {
+		close(started)
+		<-trackerOf(record).Context().Done()
+	}
+	q.wg.Add(1)
+	go q.run(record, job)
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Shutdown(ctx)
+	a.Equal(Queued, job.Status)
+
+	// 关闭后不再调度
+	q.dispatch(time.Now())
+	a.NoError(mock.ExpectationsWereMet())
+}
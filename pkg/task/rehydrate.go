@@ -0,0 +1,126 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// RehydrateTask 归档文件解冻任务，为归档存储中的文件发起解冻请求，
+// 解冻完成后由定时任务 cron_archive_rehydrate 转回标准存储
+type RehydrateTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps RehydrateProps
+	Err       *JobError
+}
+
+// RehydrateProps 归档文件解冻任务属性
+type RehydrateProps struct {
+	Files []uint `json:"files"`
+}
+
+// Props 获取任务属性
+func (job *RehydrateTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *RehydrateTask) Type() int {
+	return RehydrateTaskType
+}
+
+// Creator 获取创建者ID
+func (job *RehydrateTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *RehydrateTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *RehydrateTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *RehydrateTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *RehydrateTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *RehydrateTask) GetError() *JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *RehydrateTask) Do() {
+	fs, err := filesystem.NewFileSystem(job.User)
+	if err != nil {
+		job.SetErrorMsg("Failed to create filesystem.", err)
+		return
+	}
+	defer fs.Recycle()
+
+	restored, err := fs.RestoreArchivedFiles(context.Background(), job.TaskProps.Files)
+	if err != nil {
+		job.SetErrorMsg("Failed to restore archived files.", err)
+		return
+	}
+
+	util.Log().Info("Restore requested for %d archived file(s) of user %q.", restored, job.User.Email)
+}
+
+// NewRehydrateTask 新建归档文件解冻任务
+func NewRehydrateTask(user *model.User, files []uint) (Job, error) {
+	newTask := &RehydrateTask{
+		User: user,
+		TaskProps: RehydrateProps{
+			Files: files,
+		},
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewRehydrateTaskFromModel 从数据库记录中恢复归档文件解冻任务
+func NewRehydrateTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &RehydrateTask{
+		User:      &user,
+		TaskModel: task,
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
@@ -0,0 +1,50 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRehydrateTask_Props(t *testing.T) {
+	asserts := assert.New(t)
+	task := &RehydrateTask{
+		User:      &model.User{},
+		TaskProps: RehydrateProps{Files: []uint{1, 2}},
+	}
+	asserts.Equal(`{"files":[1,2]}`, task.Props())
+	asserts.Equal(RehydrateTaskType, task.Type())
+	asserts.EqualValues(0, task.Creator())
+	asserts.Nil(task.Model())
+}
+
+func TestRehydrateTask_Do(t *testing.T) {
+	asserts := assert.New(t)
+	task := &RehydrateTask{
+		User:      &model.User{Policy: model.Policy{Type: "local"}},
+		TaskModel: &model.Task{Model: gorm.Model{ID: 1}},
+		TaskProps: RehydrateProps{Files: []uint{1}},
+	}
+
+	// 文件未归档
+	mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id", "metadata"}).AddRow(1, "{}"))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	task.Do()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NotNil(task.GetError())
+}
+
+func TestNewRehydrateTaskFromModel(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	job, err := NewRehydrateTaskFromModel(&model.Task{Props: `{"files":[2]}`})
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.Equal([]uint{2}, job.(*RehydrateTask).TaskProps.Files)
+}
@@ -0,0 +1,251 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+)
+
+// maxRelocateEntries 任务属性中最多保存的跳过、失败对象数量
+const maxRelocateEntries = 1000
+
+var (
+	errRelocateNotFound = errors.New("object not found in source directory")
+	errRelocateExisted  = errors.New("object with the same name already exists in destination")
+)
+
+// RelocateTask 批量移动、复制文件和目录任务，逐个处理对象并记录跳过及失败的对象
+type RelocateTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps RelocateProps
+	Err       *JobError
+}
+
+// RelocateProps 批量移动、复制任务属性
+type RelocateProps struct {
+	// Copy 为 true 时复制，否则移动
+	Copy  bool   `json:"copy"`
+	Src   string `json:"src"`
+	Dst   string `json:"dst"`
+	Dirs  []uint `json:"dirs"`
+	Files []uint `json:"files"`
+	// Processed 已处理的对象数，目录在前、文件在后，被中断的任务从此处继续
+	Processed int `json:"processed"`
+	// Succeeded 成功处理的对象数
+	Succeeded int `json:"succeeded"`
+	// Skipped 被跳过的对象，如源目录中已不存在或目的目录中已有同名对象
+	Skipped []RelocateEntry `json:"skipped,omitempty"`
+	// Failed 处理失败的对象
+	Failed []RelocateEntry `json:"failed,omitempty"`
+	// LockToken 提交任务时提供的锁令牌，不持久化，恢复的任务不能修改被锁定的文件
+	LockToken string `json:"-"`
+}
+
+// RelocateEntry 被跳过或处理失败的对象
+type RelocateEntry struct {
+	ID    uint   `json:"id"`
+	IsDir bool   `json:"is_dir"`
+	Name  string `json:"name,omitempty"`
+	Error string `json:"error"`
+}
+
+// Props 获取任务属性
+func (job *RelocateTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *RelocateTask) Type() int {
+	return RelocateTaskType
+}
+
+// Creator 获取创建者ID
+func (job *RelocateTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *RelocateTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *RelocateTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *RelocateTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *RelocateTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *RelocateTask) GetError() *JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *RelocateTask) Do() {
+	fs, err := filesystem.NewFileSystem(job.User)
+	if err != nil {
+		job.SetErrorMsg("Failed to create filesystem.", err)
+		return
+	}
+	defer fs.Recycle()
+
+	exist, srcFolder := fs.IsPathExist(job.TaskProps.Src)
+	if !exist {
+		job.SetErrorMsg("Source directory not exist.", nil)
+		return
+	}
+	exist, dstFolder := fs.IsPathExist(job.TaskProps.Dst)
+	if !exist {
+		job.SetErrorMsg("Destination directory not exist.", nil)
+		return
+	}
+
+	total := len(job.TaskProps.Dirs) + len(job.TaskProps.Files)
+	tracker := trackerOf(job.TaskModel)
+	tracker.SetTotal(uint64(total))
+	tracker.Advance("", uint64(job.TaskProps.Processed))
+	job.TaskModel.SetProgress(TransferringProgress)
+
+	ctx := context.WithValue(tracker.Context(), fsctx.LockTokenCtx, job.TaskProps.LockToken)
+	for job.TaskProps.Processed < total {
+		if tracker.Canceled() {
+			break
+		}
+
+		entry := RelocateEntry{IsDir: job.TaskProps.Processed < len(job.TaskProps.Dirs)}
+		if entry.IsDir {
+			entry.ID = job.TaskProps.Dirs[job.TaskProps.Processed]
+		} else {
+			entry.ID = job.TaskProps.Files[job.TaskProps.Processed-len(job.TaskProps.Dirs)]
+		}
+		job.TaskProps.Processed++
+
+		name, err := job.relocate(ctx, fs, srcFolder, dstFolder, entry)
+		entry.Name = name
+		switch {
+		case err == nil:
+			job.TaskProps.Succeeded++
+		case errors.Is(err, errRelocateNotFound) || errors.Is(err, errRelocateExisted):
+			entry.Error = err.Error()
+			if len(job.TaskProps.Skipped) < maxRelocateEntries {
+				job.TaskProps.Skipped = append(job.TaskProps.Skipped, entry)
+			}
+		default:
+			entry.Error = err.Error()
+			if len(job.TaskProps.Failed) < maxRelocateEntries {
+				job.TaskProps.Failed = append(job.TaskProps.Failed, entry)
+			}
+		}
+		tracker.Advance(path.Join(job.TaskProps.Src, name), 1)
+	}
+
+	if err := job.TaskModel.SetProps(job.Props()); err != nil {
+		job.SetErrorMsg("Failed to save task result.", err)
+		return
+	}
+
+	if tracker.Canceled() && !tracker.Interrupted() {
+		job.SetErrorMsg("Task canceled.", nil)
+	}
+}
+
+// relocate 移动或复制单个对象，返回对象名称
+func (job *RelocateTask) relocate(ctx context.Context, fs *filesystem.FileSystem, src, dst *model.Folder, entry RelocateEntry) (string, error) {
+	var (
+		name  string
+		dirs  []uint
+		files []uint
+	)
+
+	if entry.IsDir {
+		folders, err := model.GetFoldersByIDs([]uint{entry.ID}, job.User.ID)
+		if err != nil || len(folders) == 0 || folders[0].ParentID == nil || *folders[0].ParentID != src.ID {
+			return "", errRelocateNotFound
+		}
+		name = folders[0].Name
+		if _, err := dst.GetChild(name); err == nil {
+			return name, errRelocateExisted
+		}
+		dirs = []uint{entry.ID}
+	} else {
+		found, err := model.GetFilesByIDs([]uint{entry.ID}, job.User.ID)
+		if err != nil || len(found) == 0 || found[0].FolderID != src.ID {
+			return "", errRelocateNotFound
+		}
+		name = found[0].Name
+		if exist, _ := fs.IsChildFileExist(dst, name); exist {
+			return name, errRelocateExisted
+		}
+		files = []uint{entry.ID}
+	}
+
+	fs.CleanTargets()
+	if job.TaskProps.Copy {
+		return name, fs.Copy(ctx, dirs, files, job.TaskProps.Src, job.TaskProps.Dst)
+	}
+	return name, fs.Move(ctx, dirs, files, job.TaskProps.Src, job.TaskProps.Dst)
+}
+
+// NewRelocateTask 新建批量移动、复制任务
+func NewRelocateTask(user *model.User, isCopy bool, src, dst string, dirs, files []uint, lockToken string) (Job, error) {
+	newTask := &RelocateTask{
+		User: user,
+		TaskProps: RelocateProps{
+			Copy:      isCopy,
+			Src:       src,
+			Dst:       dst,
+			Dirs:      dirs,
+			Files:     files,
+			LockToken: lockToken,
+		},
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewRelocateTaskFromModel 从数据库记录中恢复批量移动、复制任务
+func NewRelocateTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &RelocateTask{
+		User:      &user,
+		TaskModel: task,
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
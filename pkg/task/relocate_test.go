@@ -0,0 +1,97 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelocateTask_Props(t *testing.T) {
+	asserts := assert.New(t)
+	task := &RelocateTask{
+		User:      &model.User{},
+		TaskProps: RelocateProps{Copy: true, Src: "/a", Dst: "/b", Files: []uint{1}},
+	}
+	asserts.Equal(`{"copy":true,"src":"/a","dst":"/b","dirs":null,"files":[1],"processed":0,"succeeded":0}`, task.Props())
+	asserts.Equal(RelocateTaskType, task.Type())
+	asserts.EqualValues(0, task.Creator())
+	asserts.Nil(task.Model())
+}
+
+func TestRelocateTask_relocate(t *testing.T) {
+	asserts := assert.New(t)
+	user := &model.User{Model: gorm.Model{ID: 1}}
+	task := &RelocateTask{
+		User:      user,
+		TaskProps: RelocateProps{Src: "/a", Dst: "/b"},
+	}
+	fs := &filesystem.FileSystem{User: user}
+	src := &model.Folder{Model: gorm.Model{ID: 2}, OwnerID: 1}
+	dst := &model.Folder{Model: gorm.Model{ID: 3}, OwnerID: 1}
+
+	// 文件已不存在
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		_, err := task.relocate(context.Background(), fs, src, dst, RelocateEntry{ID: 1})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.ErrorIs(err, errRelocateNotFound)
+	}
+
+	// 文件不在源目录中
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(1, "1.txt", 5))
+		_, err := task.relocate(context.Background(), fs, src, dst, RelocateEntry{ID: 1})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.ErrorIs(err, errRelocateNotFound)
+	}
+
+	// 目的目录中已有同名文件
+	{
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(1, "1.txt", 2))
+		mock.ExpectQuery("SELECT(.+)files(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "folder_id"}).AddRow(4, "1.txt", 3))
+		name, err := task.relocate(context.Background(), fs, src, dst, RelocateEntry{ID: 1})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.ErrorIs(err, errRelocateExisted)
+		asserts.Equal("1.txt", name)
+	}
+
+	// 目录不在源目录中
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(1, "sub", 5))
+		_, err := task.relocate(context.Background(), fs, src, dst, RelocateEntry{ID: 1, IsDir: true})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.ErrorIs(err, errRelocateNotFound)
+	}
+
+	// 目的目录中已有同名目录
+	{
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(1, "sub", 2))
+		mock.ExpectQuery("SELECT(.+)folders(.+)").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(6, "sub", 3))
+		name, err := task.relocate(context.Background(), fs, src, dst, RelocateEntry{ID: 1, IsDir: true})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.ErrorIs(err, errRelocateExisted)
+		asserts.Equal("sub", name)
+	}
+}
+
+func TestNewRelocateTaskFromModel(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	job, err := NewRelocateTaskFromModel(&model.Task{Props: `{"copy":true,"processed":3}`})
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.True(job.(*RelocateTask).TaskProps.Copy)
+	asserts.Equal(3, job.(*RelocateTask).TaskProps.Processed)
+}
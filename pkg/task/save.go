@@ -0,0 +1,153 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+)
+
+// ShareSaveTask 转存分享内容任务
+type ShareSaveTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps ShareSaveProps
+	Err       *JobError
+}
+
+// ShareSaveProps 转存分享内容任务属性
+type ShareSaveProps struct {
+	OwnerID uint   `json:"owner_id"` // 分享者 ID
+	Dirs    []uint `json:"dirs"`     // 待转存的目录
+	Files   []uint `json:"files"`    // 待转存的文件
+	Dst     string `json:"dst"`      // 转存目的目录
+}
+
+// Props 获取任务属性
+func (job *ShareSaveTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *ShareSaveTask) Type() int {
+	return ShareSaveTaskType
+}
+
+// Creator 获取创建者ID
+func (job *ShareSaveTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *ShareSaveTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *ShareSaveTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *ShareSaveTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *ShareSaveTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *ShareSaveTask) GetError() *JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *ShareSaveTask) Do() {
+	owner, err := model.GetActiveUserByID(job.TaskProps.OwnerID)
+	if err != nil {
+		job.SetErrorMsg("Share owner not found.", err)
+		return
+	}
+
+	src, err := filesystem.NewFileSystem(&owner)
+	if err != nil {
+		job.SetErrorMsg("Failed to create filesystem.", err)
+		return
+	}
+	defer src.Recycle()
+
+	fs, err := filesystem.NewFileSystem(job.User)
+	if err != nil {
+		job.SetErrorMsg("Failed to create filesystem.", err)
+		return
+	}
+	defer fs.Recycle()
+
+	// 再次检查容量，排队期间用户容量可能已发生变化
+	job.TaskModel.SetProgress(ListingProgress)
+	_, size, _, err := fs.SavePlan(src, job.TaskProps.Dirs, job.TaskProps.Files)
+	if err != nil {
+		job.SetErrorMsg("Failed to list shared files.", err)
+		return
+	}
+	if size > job.User.GetRemainingCapacity() {
+		job.SetErrorMsg("Insufficient storage capacity.", nil)
+		return
+	}
+
+	job.TaskModel.SetProgress(TransferringProgress)
+	if err := fs.SaveFrom(context.Background(), src, job.TaskProps.Dirs, job.TaskProps.Files,
+		job.TaskProps.Dst); err != nil {
+		job.SetErrorMsg("Failed to save shared files.", err)
+	}
+}
+
+// NewShareSaveTask 新建转存分享内容任务
+func NewShareSaveTask(user *model.User, ownerID uint, dirs, files []uint, dst string) (Job, error) {
+	newTask := &ShareSaveTask{
+		User: user,
+		TaskProps: ShareSaveProps{
+			OwnerID: ownerID,
+			Dirs:    dirs,
+			Files:   files,
+			Dst:     dst,
+		},
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewShareSaveTaskFromModel 从数据库记录中恢复转存分享内容任务
+func NewShareSaveTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &ShareSaveTask{
+		User:      &user,
+		TaskModel: task,
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
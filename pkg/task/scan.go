@@ -0,0 +1,247 @@
+package task
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/antivirus"
+	"github.com/cloudreve/Cloudreve/v3/pkg/email"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// ScanTask 病毒扫描任务，同时计算文件内容哈希并检查屏蔽列表
+type ScanTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps ScanProps
+	Err       *JobError
+}
+
+// ScanProps 病毒扫描任务属性
+type ScanProps struct {
+	FileID uint `json:"file_id"`
+}
+
+// Props 获取任务属性
+func (job *ScanTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *ScanTask) Type() int {
+	return ScanTaskType
+}
+
+// Creator 获取创建者ID
+func (job *ScanTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *ScanTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *ScanTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *ScanTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *ScanTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *ScanTask) GetError() *JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *ScanTask) Do() {
+	files, err := model.GetFilesByIDs([]uint{job.TaskProps.FileID}, job.User.ID)
+	if err != nil || len(files) == 0 {
+		// 文件已被删除，无需扫描
+		return
+	}
+	file := &files[0]
+
+	scan := antivirus.Enabled()
+	if maxSize := uint64(model.GetIntSetting("antivirus_max_size", 0)); scan && maxSize > 0 && file.Size > maxSize {
+		util.Log().Debug("Skip scanning file %q as it is larger than the limit.", file.Name)
+		scan = false
+	}
+
+	fs, err := filesystem.NewFileSystem(job.User)
+	if err != nil {
+		job.SetErrorMsg("Failed to create filesystem.", err)
+		return
+	}
+	defer fs.Recycle()
+
+	job.TaskModel.SetProgress(ScanningProgress)
+	ctx := context.Background()
+	rs, err := fs.GetContent(ctx, file.ID)
+	if err != nil {
+		job.SetErrorMsg("Failed to read file.", err)
+		return
+	}
+	defer rs.Close()
+
+	// 扫描的同时计算文件内容哈希，并记录文件头与实际大小
+	sha256Hash, md5Hash, sniffer := sha256.New(), md5.New(), &filesystem.SniffWriter{}
+	reader := io.TeeReader(rs, io.MultiWriter(sha256Hash, md5Hash, sniffer))
+
+	res := &antivirus.Result{}
+	if scan {
+		res, err = antivirus.Scan(ctx, reader)
+		if err != nil {
+			job.SetErrorMsg("Failed to scan file.", err)
+			return
+		}
+	}
+
+	if _, err := io.Copy(ioutil.Discard, reader); err != nil {
+		job.SetErrorMsg("Failed to read file.", err)
+		return
+	}
+
+	hashes := map[string]string{
+		model.SHA256MetadataKey: hex.EncodeToString(sha256Hash.Sum(nil)),
+		model.MD5MetadataKey:    hex.EncodeToString(md5Hash.Sum(nil)),
+	}
+	if err := file.UpdateMetadata(hashes); err != nil {
+		util.Log().Warning("Failed to save content hash of file %q: %s", file.Name, err)
+	}
+
+	// 命中屏蔽列表的文件直接删除
+	if blocked, ok := model.MatchBlockedHash(hashes[model.SHA256MetadataKey], hashes[model.MD5MetadataKey]); ok {
+		util.Log().Warning("File %q of user %q matches blocked hash %q, rejected.", file.Name, job.User.Email, blocked.Hash)
+		if err := fs.Delete(ctx, []uint{}, []uint{file.ID}, true, false); err != nil {
+			job.SetErrorMsg("Failed to delete blocked file.", err)
+			return
+		}
+
+		if err := blocked.Reject(file); err != nil {
+			util.Log().Warning("Failed to record blocklist log: %s", err)
+		}
+		return
+	}
+
+	// 实际大小超出存储策略限制的文件直接删除
+	policy := file.GetPolicy()
+	if policy.MaxSize > 0 && sniffer.Size() > policy.MaxSize {
+		util.Log().Warning("File %q of user %q exceeds the size limit of its storage policy, rejected.", file.Name, job.User.Email)
+		if err := fs.Delete(ctx, []uint{}, []uint{file.ID}, true, false); err != nil {
+			job.SetErrorMsg("Failed to delete oversized file.", err)
+		}
+		return
+	}
+
+	// 检查文件内容与扩展名是否一致
+	if action := policy.OptionsSerialized.ContentSniff; action != "" && !res.Infected {
+		if mime, mismatch := filesystem.ContentMismatch(file.Name, sniffer.Head()); mismatch {
+			if action == model.ContentSniffReject {
+				util.Log().Warning("Content of file %q of user %q is detected as %q, rejected.", file.Name, job.User.Email, mime)
+				if err := fs.Delete(ctx, []uint{}, []uint{file.ID}, true, false); err != nil {
+					job.SetErrorMsg("Failed to delete mismatched file.", err)
+				}
+				return
+			}
+
+			res = &antivirus.Result{Infected: true, Signature: "Content mismatch: " + mime}
+		}
+	}
+
+	if !res.Infected {
+		return
+	}
+
+	util.Log().Warning("File %q of user %q is infected by %q, quarantined.", file.Name, job.User.Email, res.Signature)
+	if _, err := model.QuarantineFile(file, res.Signature); err != nil {
+		job.SetErrorMsg("Failed to quarantine file.", err)
+		return
+	}
+
+	// 通知上传者
+	title, body := email.NewQuarantineEmail("", job.User.Nick, file.Name, res.Signature)
+	if err := email.Send(job.User.Email, title, body); err != nil {
+		util.Log().Warning("Failed to send quarantine notification to %q: %s", job.User.Email, err)
+	}
+}
+
+// SubmitScanTask 启用病毒扫描、存储策略开启内容检查、屏蔽列表非空或上传时未能得到文件哈希时为新上传的文件提交扫描任务
+func SubmitScanTask(user *model.User, file *model.File) {
+	if file == nil {
+		return
+	}
+
+	hashed := file.MetadataSerialized[model.SHA256MetadataKey] != ""
+	if hashed && !antivirus.Enabled() && file.GetPolicy().OptionsSerialized.ContentSniff == "" && !model.HasBlockedHashes() {
+		return
+	}
+
+	job, err := NewScanTask(user, file.ID)
+	if err != nil {
+		util.Log().Warning("Failed to create scan task for file %q: %s", file.Name, err)
+		return
+	}
+
+	TaskPoll.Submit(job)
+}
+
+// NewScanTask 新建病毒扫描任务
+func NewScanTask(user *model.User, fileID uint) (Job, error) {
+	newTask := &ScanTask{
+		User: user,
+		TaskProps: ScanProps{
+			FileID: fileID,
+		},
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewScanTaskFromModel 从数据库记录中恢复病毒扫描任务
+func NewScanTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &ScanTask{
+		User:      &user,
+		TaskModel: task,
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
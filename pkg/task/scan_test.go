@@ -0,0 +1,136 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/antivirus"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanTask_Props(t *testing.T) {
+	asserts := assert.New(t)
+	task := &ScanTask{
+		User:      &model.User{},
+		TaskProps: ScanProps{FileID: 1},
+	}
+	asserts.Equal(`{"file_id":1}`, task.Props())
+	asserts.Equal(ScanTaskType, task.Type())
+	asserts.EqualValues(0, task.Creator())
+	asserts.Nil(task.Model())
+}
+
+func TestScanTask_SetError(t *testing.T) {
+	asserts := assert.New(t)
+	task := &ScanTask{
+		User: &model.User{},
+		TaskModel: &model.Task{
+			Model: gorm.Model{ID: 1},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	task.SetErrorMsg("error", errors.New("raw"))
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Equal("error", task.GetError().Msg)
+	asserts.Equal("raw", task.GetError().Error)
+}
+
+func TestScanTask_Do(t *testing.T) {
+	asserts := assert.New(t)
+	task := &ScanTask{
+		User:      &model.User{},
+		TaskModel: &model.Task{Model: gorm.Model{ID: 1}},
+		TaskProps: ScanProps{FileID: 1},
+	}
+
+	// 文件不存在
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	task.Do()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.Nil(task.GetError())
+}
+
+func TestNewScanTask(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		job, err := NewScanTask(&model.User{}, 1)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NotNil(job)
+		asserts.NoError(err)
+	}
+
+	// 失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		job, err := NewScanTask(&model.User{}, 1)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Nil(job)
+		asserts.Error(err)
+	}
+}
+
+func TestNewScanTaskFromModel(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 成功
+	{
+		mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		job, err := NewScanTaskFromModel(&model.Task{Props: `{"file_id":2}`})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NoError(err)
+		asserts.EqualValues(2, job.(*ScanTask).TaskProps.FileID)
+	}
+
+	// 属性无法解析
+	{
+		mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		job, err := NewScanTaskFromModel(&model.Task{Props: "{"})
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Error(err)
+		asserts.Nil(job)
+	}
+}
+
+func TestSubmitScanTask(t *testing.T) {
+	// 未启用扫描且屏蔽列表为空时不创建任务
+	antivirus.Default = nil
+	cache.Set("policy_0", model.Policy{}, 0)
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	SubmitScanTask(&model.User{}, &model.File{MetadataSerialized: map[string]string{model.SHA256MetadataKey: "hash"}})
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 缺少文件哈希时创建任务
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
+	mock.ExpectRollback()
+	SubmitScanTask(&model.User{}, &model.File{})
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// 存储策略开启内容检查时创建任务
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
+	mock.ExpectRollback()
+	SubmitScanTask(&model.User{}, &model.File{
+		MetadataSerialized: map[string]string{model.SHA256MetadataKey: "hash"},
+		Policy: model.Policy{
+			Model:             gorm.Model{ID: 1},
+			OptionsSerialized: model.PolicyOption{ContentSniff: model.ContentSniffQuarantine},
+		},
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,79 @@
+package slavetask
+
+import (
+	"context"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cluster"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// MetaTask 从机生成缩略图与元数据任务
+type MetaTask struct {
+	Err *task.JobError
+	Req *serializer.SlaveMetaReq
+}
+
+// Props 获取任务属性
+func (job *MetaTask) Props() string {
+	return ""
+}
+
+// Type 获取任务类型
+func (job *MetaTask) Type() int {
+	return 0
+}
+
+// Creator 获取创建者ID
+func (job *MetaTask) Creator() uint {
+	return 0
+}
+
+// Model 获取任务的数据库模型
+func (job *MetaTask) Model() *model.Task {
+	return nil
+}
+
+// SetStatus 设定状态
+func (job *MetaTask) SetStatus(status int) {
+}
+
+// SetError 设定任务失败信息
+func (job *MetaTask) SetError(err *task.JobError) {
+	job.Err = err
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *MetaTask) SetErrorMsg(msg string, err error) {
+	jobErr := &task.JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+
+	job.SetError(jobErr)
+	util.Log().Warning("Failed to generate metadata of %q: %s %s", job.Req.Src, msg, jobErr.Error)
+}
+
+// GetError 返回任务失败信息
+func (job *MetaTask) GetError() *task.JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *MetaTask) Do() {
+	fs, err := filesystem.NewAnonymousFileSystem()
+	if err != nil {
+		job.SetErrorMsg("Failed to initialize anonymous filesystem.", err)
+		return
+	}
+
+	res := fs.SlaveMeta(context.Background(), job.Req)
+	fs.Recycle()
+
+	if err := cluster.SlaveMetaCallback(job.Req.Callback, res); err != nil {
+		job.SetErrorMsg("Failed to push metadata to master node.", err)
+	}
+}
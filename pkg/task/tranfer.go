@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
@@ -35,6 +36,10 @@ type TransferProps struct {
 	TrimPath bool `json:"trim_path"`
 	// 负责处理中专任务的节点ID
 	NodeID uint `json:"node_id"`
+	// 转存完成后的处理规则
+	PostProcess *model.DownloadPostProcess `json:"post_process,omitempty"`
+	// 服务关闭而中断时已完成转存的目标路径，恢复执行时跳过
+	Transferred []string `json:"transferred,omitempty"`
 }
 
 // Props 获取任务属性
@@ -94,9 +99,23 @@ func (job *TransferTask) Do() {
 		return
 	}
 
-	successCount := 0
+	tracker := trackerOf(job.TaskModel)
+	tracker.SetTotal(job.totalSize())
+	ctx := tracker.Context()
+
+	successCount := len(job.TaskProps.Transferred)
 	errorList := make([]string, 0, len(job.TaskProps.Src))
+	transferred := append(make([]string, 0, len(job.TaskProps.Src)), job.TaskProps.Transferred...)
+	done := make(map[string]bool, len(job.TaskProps.Transferred))
+	for _, dst := range job.TaskProps.Transferred {
+		done[dst] = true
+	}
+
 	for _, file := range job.TaskProps.Src {
+		if tracker.Canceled() {
+			break
+		}
+
 		dst := path.Join(job.TaskProps.Dst, filepath.Base(file))
 		if job.TaskProps.TrimPath {
 			// 保留原始目录
@@ -105,6 +124,11 @@ func (job *TransferTask) Do() {
 			dst = path.Join(job.TaskProps.Dst, strings.TrimPrefix(src, trim))
 		}
 
+		if done[dst] {
+			tracker.Advance(dst, job.fileSize(file))
+			continue
+		}
+
 		if job.TaskProps.NodeID > 1 {
 			// 指定为从机中转
 
@@ -116,7 +140,7 @@ func (job *TransferTask) Do() {
 
 			// 切换为从机节点处理上传
 			fs.SwitchToSlaveHandler(node)
-			err = fs.UploadFromStream(context.Background(), &fsctx.FileStream{
+			err = fs.UploadFromStream(ctx, &fsctx.FileStream{
 				File:        nil,
 				Size:        job.TaskProps.SrcSizes[file],
 				Name:        path.Base(dst),
@@ -125,25 +149,138 @@ func (job *TransferTask) Do() {
 			}, false)
 		} else {
 			// 主机节点中转
-			err = fs.UploadFromPath(context.Background(), file, dst, 0)
+			err = fs.UploadFromPath(ctx, file, dst, 0)
 		}
 
 		if err != nil {
 			errorList = append(errorList, err.Error())
 		} else {
 			successCount++
+			transferred = append(transferred, dst)
 			job.TaskModel.SetProgress(successCount)
+			tracker.Advance(dst, job.fileSize(file))
 		}
 	}
 
+	// 服务关闭导致中断，记录已转存的文件，恢复执行时跳过
+	if tracker.Interrupted() {
+		job.TaskProps.Transferred = transferred
+		job.TaskModel.SetProps(job.Props())
+		return
+	}
+
+	// 任务被取消，删除已转存的文件
+	if tracker.Canceled() {
+		removeOutput(fs, transferred)
+		job.SetErrorMsg("Task canceled.", nil)
+		return
+	}
+
+	// 执行转存完成后的处理规则
+	if job.TaskProps.PostProcess != nil && len(transferred) > 0 {
+		errorList = append(errorList, job.postProcess(fs, transferred)...)
+	}
+
 	if len(errorList) > 0 {
 		job.SetErrorMsg("Failed to transfer one or more file(s).", fmt.Errorf(strings.Join(errorList, "\n")))
 	}
 
 }
 
+// fileSize 返回待转存文件的大小，无法获取时返回 0
+func (job *TransferTask) fileSize(file string) uint64 {
+	if size, ok := job.TaskProps.SrcSizes[file]; ok {
+		return size
+	}
+
+	if job.TaskProps.NodeID <= 1 {
+		if info, err := os.Stat(file); err == nil {
+			return uint64(info.Size())
+		}
+	}
+
+	return 0
+}
+
+// totalSize 统计待转存文件的总大小，用于估算剩余时间
+func (job *TransferTask) totalSize() uint64 {
+	var total uint64
+	for _, file := range job.TaskProps.Src {
+		total += job.fileSize(file)
+	}
+	return total
+}
+
+// postProcess 解压转存得到的压缩文件，并将结果移动到指定目录，返回处理过程中的错误
+func (job *TransferTask) postProcess(fs *filesystem.FileSystem, transferred []string) []string {
+	ctx := context.Background()
+	rules := job.TaskProps.PostProcess
+	errorList := make([]string, 0)
+	results := append([]string{}, transferred...)
+
+	if rules.Extract {
+		for _, dst := range transferred {
+			if !filesystem.IsDecompressible(dst) {
+				continue
+			}
+
+			// 解压到与压缩文件同名的目录，避免与其他文件冲突
+			extractTo := strings.TrimSuffix(dst, path.Ext(dst))
+			if exist, _ := fs.IsPathExist(extractTo); !exist {
+				if _, err := fs.CreateDirectory(ctx, extractTo); err != nil {
+					errorList = append(errorList, fmt.Sprintf("failed to extract %q: %s", dst, err))
+					continue
+				}
+			}
+
+			fs.CleanTargets()
+			if err := fs.Decompress(ctx, dst, extractTo, ""); err != nil {
+				errorList = append(errorList, fmt.Sprintf("failed to extract %q: %s", dst, err))
+				continue
+			}
+			results = append(results, extractTo)
+		}
+	}
+
+	moveTo := path.Clean("/" + rules.MoveTo)
+	if rules.MoveTo == "" || moveTo == path.Clean(job.TaskProps.Dst) {
+		return errorList
+	}
+
+	// 找到结果在下载目录下的顶层对象
+	var dirs, files []uint
+	visited := make(map[string]bool)
+	for _, result := range results {
+		rel := strings.TrimPrefix(strings.TrimPrefix(result, path.Clean(job.TaskProps.Dst)), "/")
+		top := path.Join(job.TaskProps.Dst, strings.SplitN(rel, "/", 2)[0])
+		if visited[top] {
+			continue
+		}
+		visited[top] = true
+
+		if exist, file := fs.IsFileExist(top); exist {
+			files = append(files, file.ID)
+		} else if exist, folder := fs.IsPathExist(top); exist {
+			dirs = append(dirs, folder.ID)
+		}
+	}
+
+	if exist, _ := fs.IsPathExist(moveTo); !exist {
+		if _, err := fs.CreateDirectory(ctx, moveTo); err != nil {
+			return append(errorList, fmt.Sprintf("failed to create folder %q: %s", moveTo, err))
+		}
+	}
+
+	fs.CleanTargets()
+	if err := fs.Move(ctx, dirs, files, job.TaskProps.Dst, moveTo); err != nil {
+		errorList = append(errorList, fmt.Sprintf("failed to move results to %q: %s", moveTo, err))
+	}
+
+	return errorList
+}
+
 // NewTransferTask 新建中转任务
-func NewTransferTask(user uint, src []string, dst, parent string, trim bool, node uint, sizes map[string]uint64) (Job, error) {
+func NewTransferTask(user uint, src []string, dst, parent string, trim bool, node uint, sizes map[string]uint64, post *model.DownloadPostProcess) (Job, error) {
 	creator, err := model.GetActiveUserByID(user)
 	if err != nil {
 		return nil, err
@@ -152,12 +289,13 @@ func NewTransferTask(user uint, src []string, dst, parent string, trim bool, nod
 	newTask := &TransferTask{
 		User: &creator,
 		TaskProps: TransferProps{
-			Src:      src,
-			Parent:   parent,
-			Dst:      dst,
-			TrimPath: trim,
-			NodeID:   node,
-			SrcSizes: sizes,
+			Src:         src,
+			Parent:      parent,
+			Dst:         dst,
+			TrimPath:    trim,
+			NodeID:      node,
+			SrcSizes:    sizes,
+			PostProcess: post,
 		},
 	}
 
@@ -0,0 +1,375 @@
+package task
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/email"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/pwpolicy"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// maxUserImportErrors 任务属性中最多保存的行错误数量
+const maxUserImportErrors = 1000
+
+// UserImportColumns 用户导入/导出 CSV 的列
+var UserImportColumns = []string{"email", "nickname", "group", "quota", "password", "invite"}
+
+// UserImportTask 从 CSV 批量导入用户的任务
+type UserImportTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps UserImportProps
+	Err       *JobError
+
+	groups map[string]*model.Group
+}
+
+// UserImportProps 用户导入任务属性
+type UserImportProps struct {
+	// Path 待导入的 CSV 文件路径
+	Path string `json:"path"`
+	// Processed 已处理的数据行数，被中断的任务从此处继续
+	Processed int `json:"processed"`
+	// Created 成功创建的用户数
+	Created int `json:"created"`
+	// Failed 导入失败的行数
+	Failed int `json:"failed"`
+	// Errors 导入失败的行及原因
+	Errors []UserImportError `json:"errors,omitempty"`
+}
+
+// UserImportError 导入失败的行
+type UserImportError struct {
+	Row   int    `json:"row"`
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+// userImportRow 解析后的一行导入数据
+type userImportRow struct {
+	user     model.User
+	password string
+	invite   bool
+}
+
+// Props 获取任务属性
+func (job *UserImportTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *UserImportTask) Type() int {
+	return UserImportTaskType
+}
+
+// Creator 获取创建者ID
+func (job *UserImportTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *UserImportTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *UserImportTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *UserImportTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *UserImportTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *UserImportTask) GetError() *JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *UserImportTask) Do() {
+	file, err := os.Open(job.TaskProps.Path)
+	if err != nil {
+		job.SetErrorMsg("Failed to open CSV file.", err)
+		return
+	}
+
+	rows, err := readUserImportCSV(file)
+	file.Close()
+	if err != nil {
+		job.SetErrorMsg("Failed to parse CSV file.", err)
+		return
+	}
+
+	tracker := trackerOf(job.TaskModel)
+	tracker.SetTotal(uint64(len(rows)))
+	tracker.Advance("", uint64(job.TaskProps.Processed))
+	job.TaskModel.SetProgress(InsertingProgress)
+
+	for job.TaskProps.Processed < len(rows) {
+		if tracker.Canceled() {
+			break
+		}
+
+		record := rows[job.TaskProps.Processed]
+		job.TaskProps.Processed++
+		address := record.values[0]
+
+		if err := job.importRow(record.values); err != nil {
+			job.TaskProps.Failed++
+			if len(job.TaskProps.Errors) < maxUserImportErrors {
+				job.TaskProps.Errors = append(job.TaskProps.Errors, UserImportError{
+					Row:   record.line,
+					Email: address,
+					Error: err.Error(),
+				})
+			}
+		} else {
+			job.TaskProps.Created++
+		}
+		tracker.Advance(address, 1)
+	}
+
+	if err := job.TaskModel.SetProps(job.Props()); err != nil {
+		job.SetErrorMsg("Failed to save import result.", err)
+	}
+
+	// 被中断的任务保留文件以便继续导入
+	if !tracker.Interrupted() {
+		os.Remove(job.TaskProps.Path)
+	}
+}
+
+// importRow 导入一行数据
+func (job *UserImportTask) importRow(values []string) error {
+	row, err := job.parseRow(values)
+	if err != nil {
+		return err
+	}
+
+	if _, err := model.GetUserByEmail(row.user.TenantID, row.user.Email); err == nil {
+		return errors.New("email already exists")
+	}
+
+	row.user.SetPassword(row.password)
+	if err := model.DB.Create(&row.user).Error; err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if row.invite {
+		if err := sendInviteEmail(&row.user); err != nil {
+			return fmt.Errorf("user created but failed to send invite email: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// parseRow 校验并解析一行数据，列顺序与 UserImportColumns 一致
+func (job *UserImportTask) parseRow(values []string) (*userImportRow, error) {
+	for len(values) < len(UserImportColumns) {
+		values = append(values, "")
+	}
+
+	address, err := mail.ParseAddress(values[0])
+	if err != nil || address.Address != values[0] {
+		return nil, errors.New("invalid email")
+	}
+
+	row := &userImportRow{user: model.NewUser(), password: values[4]}
+	row.user.Email = values[0]
+	row.user.Status = model.Active
+	row.user.Nick = values[1]
+	if row.user.Nick == "" {
+		row.user.Nick = strings.Split(values[0], "@")[0]
+	}
+	if len([]rune(row.user.Nick)) > 50 {
+		return nil, errors.New("nickname is too long")
+	}
+
+	group, err := job.group(values[2])
+	if err != nil {
+		return nil, err
+	}
+	row.user.GroupID = group.ID
+	// 用户归属于用户组所在的租户
+	row.user.TenantID = group.TenantID
+
+	if values[3] != "" {
+		if row.user.MaxStorage, err = strconv.ParseUint(values[3], 10, 64); err != nil {
+			return nil, errors.New("invalid quota")
+		}
+	}
+
+	if values[5] != "" {
+		if row.invite, err = strconv.ParseBool(values[5]); err != nil {
+			return nil, errors.New("invalid invite flag")
+		}
+	}
+
+	if row.password == "" {
+		if !row.invite {
+			return nil, errors.New("either password or invite is required")
+		}
+		// 受邀用户通过邮件中的链接设定密码
+		row.password = util.RandStringRunes(32)
+	} else if err := pwpolicy.Check(row.password); err != nil {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+// group 按ID或名称查找用户组，为空时使用默认用户组
+func (job *UserImportTask) group(key string) (*model.Group, error) {
+	if key == "" {
+		key = model.GetSettingByName("default_group")
+	}
+
+	if group, ok := job.groups[key]; ok {
+		return group, nil
+	}
+
+	var group model.Group
+	if id, err := strconv.ParseUint(key, 10, 32); err == nil {
+		group, err = model.GetGroupByID(uint(id))
+		if err != nil {
+			return nil, errors.New("group not found")
+		}
+	} else if err := model.DB.Where("name = ?", key).First(&group).Error; err != nil {
+		return nil, errors.New("group not found")
+	}
+
+	// 不允许导入管理员与游客
+	if group.ID == 1 || group.ID == 3 {
+		return nil, errors.New("group not allowed")
+	}
+
+	job.groups[key] = &group
+	return &group, nil
+}
+
+// sendInviteEmail 向导入的用户发送设定密码的邮件
+func sendInviteEmail(user *model.User) error {
+	secret := util.RandStringRunes(32)
+	cache.Set(fmt.Sprintf("user_reset_%d", user.ID), secret, model.GetIntSetting("user_import_invite_ttl", 604800))
+
+	controller, _ := url.Parse("/reset")
+	finalURL := model.GetSiteURL().ResolveReference(controller)
+	queries := finalURL.Query()
+	queries.Add("id", hashid.HashID(user.ID, hashid.UserID))
+	queries.Add("sign", secret)
+	finalURL.RawQuery = queries.Encode()
+
+	title, body := email.NewResetEmail("", user.Nick, finalURL.String())
+	return email.Send(user.Email, title, body)
+}
+
+type userImportRecord struct {
+	line   int
+	values []string
+}
+
+// readUserImportCSV 读取 CSV 中的数据行，首行为表头，按列名对应到 UserImportColumns
+func readUserImportCSV(r io.Reader) ([]userImportRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := index["email"]; !ok {
+		return nil, errors.New("missing column \"email\"")
+	}
+
+	var res []userImportRecord
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		record := userImportRecord{line: line, values: make([]string, len(UserImportColumns))}
+		for i, column := range UserImportColumns {
+			if j, ok := index[column]; ok && j < len(values) {
+				record.values[i] = strings.TrimSpace(values[j])
+			}
+		}
+		res = append(res, record)
+	}
+
+	return res, nil
+}
+
+// NewUserImportTask 新建用户导入任务，path 为已保存的 CSV 文件
+func NewUserImportTask(user *model.User, path string) (Job, error) {
+	newTask := &UserImportTask{
+		User:      user,
+		TaskProps: UserImportProps{Path: path},
+		groups:    make(map[string]*model.Group),
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewUserImportTaskFromModel 从数据库记录中恢复用户导入任务
+func NewUserImportTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &UserImportTask{
+		User:      &user,
+		TaskModel: task,
+		groups:    make(map[string]*model.Group),
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
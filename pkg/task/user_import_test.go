@@ -0,0 +1,84 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadUserImportCSV(t *testing.T) {
+	a := assert.New(t)
+
+	// 按表头对应列，忽略未知列
+	{
+		rows, err := readUserImportCSV(strings.NewReader("\ufeffid,Nickname,email,password\n1,Tom,tom@cloudreve.org,123456\n\n2,,jerry@cloudreve.org\n"))
+		a.NoError(err)
+		a.Len(rows, 2)
+		a.Equal(2, rows[0].line)
+		a.Equal([]string{"tom@cloudreve.org", "Tom", "", "", "123456", ""}, rows[0].values)
+		a.Equal(4, rows[1].line)
+		a.Equal("jerry@cloudreve.org", rows[1].values[0])
+	}
+
+	// 缺少 email 列
+	{
+		_, err := readUserImportCSV(strings.NewReader("nickname\nTom\n"))
+		a.Error(err)
+	}
+}
+
+func TestUserImportTask_Do(t *testing.T) {
+	a := assert.New(t)
+	cache.Set("setting_default_group", "2", 0)
+	cache.Set("setting_password_min_length", "6", 0)
+	cache.SetSettings(map[string]string{
+		"password_require_upper":  "0",
+		"password_require_lower":  "0",
+		"password_require_digit":  "0",
+		"password_require_symbol": "0",
+		"password_breach_check":   "0",
+	}, "setting_")
+
+	path := filepath.Join(t.TempDir(), "import.csv")
+	a.NoError(os.WriteFile(path, []byte("email,password,quota\n"+
+		"tom@cloudreve.org,12345678,1024\n"+
+		"invalid,12345678,\n"+
+		"jerry@cloudreve.org,,\n"+
+		"spike@cloudreve.org,123,\n"), 0644))
+
+	job := &UserImportTask{
+		User:      &model.User{},
+		TaskModel: &model.Task{Model: gorm.Model{ID: 1}},
+		TaskProps: UserImportProps{Path: path},
+		groups:    map[string]*model.Group{"2": {Model: gorm.Model{ID: 2}}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT(.+)users(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT(.+)users(.+)").WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec("INSERT(.+)folders(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)tasks(.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	job.Do()
+
+	a.NoError(mock.ExpectationsWereMet())
+	a.Nil(job.GetError())
+	a.Equal(4, job.TaskProps.Processed)
+	a.Equal(1, job.TaskProps.Created)
+	a.Equal(3, job.TaskProps.Failed)
+	a.Equal([]int{3, 4, 5}, []int{job.TaskProps.Errors[0].Row, job.TaskProps.Errors[1].Row, job.TaskProps.Errors[2].Row})
+	a.Equal("jerry@cloudreve.org", job.TaskProps.Errors[1].Email)
+	a.NoFileExists(path)
+}
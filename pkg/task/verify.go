@@ -0,0 +1,176 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+// VerifyTask 文件哈希校验任务，重新计算文件内容哈希并与保存的哈希比对
+type VerifyTask struct {
+	User      *model.User
+	TaskModel *model.Task
+	TaskProps VerifyProps
+	Err       *JobError
+}
+
+// VerifyProps 文件哈希校验任务属性
+type VerifyProps struct {
+	FileID uint `json:"file_id"`
+}
+
+// Props 获取任务属性
+func (job *VerifyTask) Props() string {
+	res, _ := json.Marshal(job.TaskProps)
+	return string(res)
+}
+
+// Type 获取任务状态
+func (job *VerifyTask) Type() int {
+	return VerifyTaskType
+}
+
+// Creator 获取创建者ID
+func (job *VerifyTask) Creator() uint {
+	return job.User.ID
+}
+
+// Model 获取任务的数据库模型
+func (job *VerifyTask) Model() *model.Task {
+	return job.TaskModel
+}
+
+// SetStatus 设定状态
+func (job *VerifyTask) SetStatus(status int) {
+	job.TaskModel.SetStatus(status)
+}
+
+// SetError 设定任务失败信息
+func (job *VerifyTask) SetError(err *JobError) {
+	job.Err = err
+	res, _ := json.Marshal(job.Err)
+	job.TaskModel.SetError(string(res))
+}
+
+// SetErrorMsg 设定任务失败信息
+func (job *VerifyTask) SetErrorMsg(msg string, err error) {
+	jobErr := &JobError{Msg: msg}
+	if err != nil {
+		jobErr.Error = err.Error()
+	}
+	job.SetError(jobErr)
+}
+
+// GetError 返回任务失败信息
+func (job *VerifyTask) GetError() *JobError {
+	return job.Err
+}
+
+// Do 开始执行任务
+func (job *VerifyTask) Do() {
+	files, err := model.GetFilesByIDs([]uint{job.TaskProps.FileID}, job.User.ID)
+	if err != nil || len(files) == 0 {
+		job.SetErrorMsg("File not exist.", err)
+		return
+	}
+	file := &files[0]
+
+	fs, err := filesystem.NewFileSystem(job.User)
+	if err != nil {
+		job.SetErrorMsg("Failed to create filesystem.", err)
+		return
+	}
+	defer fs.Recycle()
+
+	job.TaskModel.SetProgress(VerifyingProgress)
+	rs, err := fs.GetContent(context.Background(), file.ID)
+	if err != nil {
+		job.SetErrorMsg("Failed to read file.", err)
+		return
+	}
+	defer rs.Close()
+
+	checksum := filesystem.NewChecksum()
+	if _, err := io.Copy(checksum, rs); err != nil {
+		job.SetErrorMsg("Failed to read file.", err)
+		return
+	}
+
+	sums := checksum.Sums()
+	status := model.ChecksumStatusOK
+	if checksum.Size != file.Size {
+		status = model.ChecksumStatusCorrupted
+	}
+
+	// 只比对已保存的哈希，未保存时以本次计算结果为准
+	stored := 0
+	for _, key := range []string{model.MD5MetadataKey, model.SHA256MetadataKey} {
+		if expected := file.MetadataSerialized[key]; expected != "" {
+			stored++
+			if expected != sums[key] {
+				status = model.ChecksumStatusCorrupted
+			}
+		}
+	}
+
+	meta := map[string]string{
+		model.ChecksumStatusMetadataKey:   status,
+		model.ChecksumVerifiedMetadataKey: time.Now().Format(time.RFC3339),
+	}
+	if stored == 0 && status == model.ChecksumStatusOK {
+		meta[model.MD5MetadataKey] = sums[model.MD5MetadataKey]
+		meta[model.SHA256MetadataKey] = sums[model.SHA256MetadataKey]
+	}
+
+	if err := file.UpdateMetadata(meta); err != nil {
+		job.SetErrorMsg("Failed to save checksum status.", err)
+		return
+	}
+
+	if status == model.ChecksumStatusCorrupted {
+		util.Log().Warning("Content of file %q of user %q does not match stored checksum.", file.Name, job.User.Email)
+		job.SetErrorMsg("File content does not match stored checksum.", nil)
+	}
+}
+
+// NewVerifyTask 新建文件哈希校验任务
+func NewVerifyTask(user *model.User, fileID uint) (Job, error) {
+	newTask := &VerifyTask{
+		User: user,
+		TaskProps: VerifyProps{
+			FileID: fileID,
+		},
+	}
+
+	record, err := Record(newTask)
+	if err != nil {
+		return nil, err
+	}
+	newTask.TaskModel = record
+
+	return newTask, nil
+}
+
+// NewVerifyTaskFromModel 从数据库记录中恢复文件哈希校验任务
+func NewVerifyTaskFromModel(task *model.Task) (Job, error) {
+	user, err := model.GetActiveUserByID(task.UserID)
+	if err != nil {
+		return nil, err
+	}
+	newTask := &VerifyTask{
+		User:      &user,
+		TaskModel: task,
+	}
+
+	err = json.Unmarshal([]byte(task.Props), &newTask.TaskProps)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTask, nil
+}
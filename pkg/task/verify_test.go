@@ -0,0 +1,77 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyTask_Props(t *testing.T) {
+	asserts := assert.New(t)
+	task := &VerifyTask{
+		User:      &model.User{},
+		TaskProps: VerifyProps{FileID: 1},
+	}
+	asserts.Equal(`{"file_id":1}`, task.Props())
+	asserts.Equal(VerifyTaskType, task.Type())
+	asserts.EqualValues(0, task.Creator())
+	asserts.Nil(task.Model())
+}
+
+func TestVerifyTask_Do(t *testing.T) {
+	asserts := assert.New(t)
+	task := &VerifyTask{
+		User:      &model.User{},
+		TaskModel: &model.Task{Model: gorm.Model{ID: 1}},
+		TaskProps: VerifyProps{FileID: 1},
+	}
+
+	// 文件不存在
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	task.Do()
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NotNil(task.GetError())
+}
+
+func TestNewVerifyTask(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 成功
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		job, err := NewVerifyTask(&model.User{}, 1)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.NotNil(job)
+		asserts.NoError(err)
+	}
+
+	// 失败
+	{
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT(.+)").WillReturnError(errors.New("error"))
+		mock.ExpectRollback()
+		job, err := NewVerifyTask(&model.User{}, 1)
+		asserts.NoError(mock.ExpectationsWereMet())
+		asserts.Nil(job)
+		asserts.Error(err)
+	}
+}
+
+func TestNewVerifyTaskFromModel(t *testing.T) {
+	asserts := assert.New(t)
+
+	mock.ExpectQuery("SELECT(.+)").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	job, err := NewVerifyTaskFromModel(&model.Task{Props: `{"file_id":2}`})
+	asserts.NoError(mock.ExpectationsWereMet())
+	asserts.NoError(err)
+	asserts.EqualValues(2, job.(*VerifyTask).TaskProps.FileID)
+}
@@ -2,6 +2,9 @@ package task
 
 import (
 	"fmt"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 )
 
@@ -12,33 +15,105 @@ type Worker interface {
 
 // GeneralWorker 通用Worker
 type GeneralWorker struct {
+	// FailedStatus 返回任务失败后要设定的状态，为空时设为 Error；
+	// 返回 Queued 表示任务将被重新调度，此时不通知任务创建者
+	FailedStatus func(Job) int
 }
 
 // Do 执行任务
 func (worker *GeneralWorker) Do(job Job) {
 	util.Log().Debug("Start executing task.")
 	job.SetStatus(Processing)
+	tracker := startTracking(job)
+	defer stopTracking(job, tracker)
 
 	defer func() {
 		// 致命错误捕获
 		if err := recover(); err != nil {
+			if tracker.Interrupted() {
+				job.SetStatus(Queued)
+				return
+			}
+			if tracker.Canceled() {
+				job.SetStatus(Canceled)
+				return
+			}
+
 			util.Log().Debug("Failed to execute task: %s", err)
 			job.SetError(&JobError{Msg: "Fatal error.", Error: fmt.Sprintf("%s", err)})
-			job.SetStatus(Error)
+			worker.fail(job)
 		}
 	}()
 
 	// 开始执行任务
 	job.Do()
 
+	// 服务关闭时任务被中断，重新排队等待下次启动后继续执行
+	if tracker.Interrupted() {
+		util.Log().Debug("Task interrupted.")
+		job.SetStatus(Queued)
+		return
+	}
+
+	// 任务被取消
+	if tracker.Canceled() {
+		util.Log().Debug("Task canceled.")
+		job.SetStatus(Canceled)
+		return
+	}
+
 	// 任务执行失败
 	if err := job.GetError(); err != nil {
 		util.Log().Debug("Failed to execute task.")
-		job.SetStatus(Error)
+		worker.fail(job)
 		return
 	}
 
 	util.Log().Debug("Task finished.")
 	// 执行完成
 	job.SetStatus(Complete)
+	notifyCreator(job)
+}
+
+// fail 设定失败任务的状态
+func (worker *GeneralWorker) fail(job Job) {
+	status := Error
+	if worker.FailedStatus != nil {
+		status = worker.FailedStatus(job)
+	}
+
+	job.SetStatus(status)
+	if status != Queued {
+		notifyCreator(job)
+	}
+}
+
+// notifyCreator 用户发起的任务执行结束后，按偏好通知任务创建者
+func notifyCreator(job Job) {
+	var name string
+	switch job.(type) {
+	case *CompressTask:
+		name = "压缩"
+	case *DecompressTask:
+		name = "解压缩"
+	case *TransferTask:
+		name = "中转"
+	case *ExportTask:
+		name = "数据导出"
+	case *ShareSaveTask:
+		name = "转存分享"
+	default:
+		return
+	}
+
+	user, err := model.GetActiveUserByID(job.Creator())
+	if err != nil {
+		return
+	}
+
+	msg := ""
+	if jobErr := job.GetError(); jobErr != nil {
+		msg = jobErr.Msg
+	}
+	notify.TaskFinished(&user, name, msg)
 }
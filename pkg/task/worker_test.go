@@ -26,7 +26,7 @@ func (job *MockJob) Props() string {
 }
 
 func (job *MockJob) Model() *model.Task {
-	panic("implement me")
+	return nil
 }
 
 func (job *MockJob) SetStatus(status int) {
@@ -11,9 +11,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
+// SourceURLOption 非本机存储的视频可通过该选项传入带签名的原始文件 URL，ffmpeg 将直接按需读取，无需下载整个文件
+const SourceURLOption = "thumb_source_url"
+
 func init() {
 	RegisterGenerator(&FfmpegGenerator{})
 }
@@ -24,7 +28,8 @@ type FfmpegGenerator struct {
 }
 
 func (f *FfmpegGenerator) Generate(ctx context.Context, file io.Reader, src, name string, options map[string]string) (*Result, error) {
-	ffmpegOpts := model.GetSettingByNames("thumb_ffmpeg_path", "thumb_ffmpeg_exts", "thumb_ffmpeg_seek", "thumb_encode_method", "temp_path")
+	ffmpegOpts := model.GetSettingByNames("thumb_ffmpeg_path", "thumb_ffmpeg_exts", "thumb_ffmpeg_seek",
+		"thumb_ffmpeg_seek_percent", "thumb_ffprobe_path", "thumb_encode_method", "temp_path")
 
 	if f.lastRawExts != ffmpegOpts["thumb_ffmpeg_exts"] {
 		f.exts = strings.Split(ffmpegOpts["thumb_ffmpeg_exts"], ",")
@@ -41,7 +46,9 @@ func (f *FfmpegGenerator) Generate(ctx context.Context, file io.Reader, src, nam
 	)
 
 	tempInputPath := src
-	if tempInputPath == "" {
+	if tempInputPath == "" && options[SourceURLOption] != "" {
+		tempInputPath = options[SourceURLOption]
+	} else if tempInputPath == "" {
 		// If not local policy files, download to temp folder
 		tempInputPath = filepath.Join(
 			util.RelativePath(ffmpegOpts["temp_path"]),
@@ -65,10 +72,19 @@ func (f *FfmpegGenerator) Generate(ctx context.Context, file io.Reader, src, nam
 		tempInputFile.Close()
 	}
 
+	seek := ffmpegOpts["thumb_ffmpeg_seek"]
+	if percent, _ := strconv.Atoi(ffmpegOpts["thumb_ffmpeg_seek_percent"]); percent > 0 && percent < 100 {
+		if duration, err := probeDuration(ctx, ffmpegOpts["thumb_ffprobe_path"], tempInputPath); err == nil {
+			seek = strconv.FormatFloat(duration*float64(percent)/100, 'f', 2, 64)
+		} else {
+			util.Log().Debug("Failed to probe video duration, fallback to fixed seek: %s", err)
+		}
+	}
+
 	// Invoke ffmpeg
 	scaleOpt := fmt.Sprintf("scale=%s:%s:force_original_aspect_ratio=decrease", options["thumb_width"], options["thumb_height"])
 	cmd := exec.CommandContext(ctx,
-		ffmpegOpts["thumb_ffmpeg_path"], "-ss", ffmpegOpts["thumb_ffmpeg_seek"], "-i", tempInputPath,
+		ffmpegOpts["thumb_ffmpeg_path"], "-ss", seek, "-i", tempInputPath,
 		"-vf", scaleOpt, "-vframes", "1", tempOutputPath)
 
 	// Redirect IO
@@ -84,6 +100,37 @@ func (f *FfmpegGenerator) Generate(ctx context.Context, file io.Reader, src, nam
 	return &Result{Path: tempOutputPath}, nil
 }
 
+// probeDuration 使用 ffprobe 获取视频时长（秒）
+func probeDuration(ctx context.Context, ffprobe, input string) (float64, error) {
+	cmd := exec.CommandContext(ctx, ffprobe, "-v", "error", "-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1", input)
+
+	var stdOut bytes.Buffer
+	cmd.Stdout = &stdOut
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("failed to invoke ffprobe: %w", err)
+	}
+
+	duration, err := strconv.ParseFloat(strings.TrimSpace(stdOut.String()), 64)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("unknown duration %q", stdOut.String())
+	}
+
+	return duration, nil
+}
+
+// MediaDuration 使用 ffprobe 获取本地音视频文件的时长（秒）
+func MediaDuration(ctx context.Context, path string) (float64, error) {
+	return probeDuration(ctx, model.GetSettingByNameWithDefault("thumb_ffprobe_path", "ffprobe"), path)
+}
+
+// IsVideo 返回 ffmpeg 缩略图生成器是否已启用且支持该文件
+func IsVideo(name string) bool {
+	options := model.GetSettingByNames("thumb_ffmpeg_enabled", "thumb_ffmpeg_exts")
+	return model.IsTrueVal(options["thumb_ffmpeg_enabled"]) &&
+		util.IsInExtensionList(strings.Split(options["thumb_ffmpeg_exts"], ","), name)
+}
+
 func (f *FfmpegGenerator) Priority() int {
 	return 200
 }
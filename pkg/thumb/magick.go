@@ -0,0 +1,82 @@
+package thumb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gofrs/uuid"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	RegisterGenerator(&MagickGenerator{})
+}
+
+// MagickGenerator generates thumbnails for formats not covered by other generators, like HEIC/HEIF
+// and camera RAW, using ImageMagick. SVG is not enabled by default since ImageMagick follows
+// external references in it when rendering.
+type MagickGenerator struct {
+	exts        []string
+	lastRawExts string
+}
+
+func (m *MagickGenerator) Generate(ctx context.Context, file io.Reader, src, name string, options map[string]string) (*Result, error) {
+	magickOpts := model.GetSettingByNames("thumb_magick_path", "thumb_magick_exts", "thumb_encode_quality", "thumb_encode_method", "temp_path")
+
+	if m.lastRawExts != magickOpts["thumb_magick_exts"] {
+		m.exts = strings.Split(magickOpts["thumb_magick_exts"], ",")
+	}
+
+	if !util.IsInExtensionList(m.exts, name) {
+		return nil, fmt.Errorf("unsupported image format: %w", ErrPassThrough)
+	}
+
+	// Read from stdin with explicit format hint, only the first frame/page is used
+	input := "-"
+	if src != "" {
+		input = src
+	}
+	input = fmt.Sprintf("%s:%s[0]", strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")), input)
+
+	tempPath := filepath.Join(
+		util.RelativePath(magickOpts["temp_path"]),
+		"thumb",
+		fmt.Sprintf("thumb_%s.%s", uuid.Must(uuid.NewV4()).String(), magickOpts["thumb_encode_method"]),
+	)
+
+	if _, err := util.CreatNestedFile(tempPath); err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx,
+		magickOpts["thumb_magick_path"], input, "-auto-orient",
+		"-thumbnail", fmt.Sprintf("%sx%s>", options["thumb_width"], options["thumb_height"]),
+		"-quality", magickOpts["thumb_encode_quality"], tempPath)
+
+	// Redirect IO
+	var magickErr bytes.Buffer
+	if src == "" {
+		cmd.Stdin = file
+	}
+	cmd.Stderr = &magickErr
+
+	if err := cmd.Run(); err != nil {
+		util.Log().Warning("Failed to invoke ImageMagick: %s", magickErr.String())
+		return nil, fmt.Errorf("failed to invoke ImageMagick: %w", err)
+	}
+
+	return &Result{Path: tempPath}, nil
+}
+
+func (m *MagickGenerator) Priority() int {
+	return 150
+}
+
+func (m *MagickGenerator) EnableFlag() string {
+	return "thumb_magick_enabled"
+}
@@ -0,0 +1,78 @@
+package thumb
+
+import (
+	"context"
+	"fmt"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/pdf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gofrs/uuid"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func init() {
+	RegisterGenerator(&PdfGenerator{})
+}
+
+// PdfGenerator renders the first page of PDF files with pdftoppm, the rendered page is
+// then passed to next generator for resizing.
+type PdfGenerator struct{}
+
+func (p *PdfGenerator) Generate(ctx context.Context, file io.Reader, src, name string, options map[string]string) (*Result, error) {
+	pdfOpts := model.GetSettingByNames("pdf_preview_pdftoppm_path", "temp_path")
+
+	if !util.IsInExtensionList([]string{"pdf"}, name) {
+		return nil, fmt.Errorf("unsupported document format: %w", ErrPassThrough)
+	}
+
+	tempInputPath := src
+	if tempInputPath == "" {
+		// If not local policy files, download to temp folder
+		tempInputPath = filepath.Join(
+			util.RelativePath(pdfOpts["temp_path"]),
+			"thumb",
+			fmt.Sprintf("pdf_%s.pdf", uuid.Must(uuid.NewV4()).String()),
+		)
+
+		tempInputFile, err := util.CreatNestedFile(tempInputPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp file: %w", err)
+		}
+
+		defer os.Remove(tempInputPath)
+		defer tempInputFile.Close()
+
+		if _, err = io.Copy(tempInputFile, file); err != nil {
+			return nil, fmt.Errorf("failed to write input file: %w", err)
+		}
+
+		tempInputFile.Close()
+	}
+
+	tempOutputPath := filepath.Join(
+		util.RelativePath(pdfOpts["temp_path"]),
+		"thumb",
+		fmt.Sprintf("pdf_%s.jpg", uuid.Must(uuid.NewV4()).String()),
+	)
+
+	w, _ := thumbSize(options)
+	if err := pdf.RenderPage(ctx, pdfOpts["pdf_preview_pdftoppm_path"], tempInputPath, 1, int(w), tempOutputPath); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Path:     tempOutputPath,
+		Continue: true,
+		Cleanup:  []func(){func() { _ = os.Remove(tempOutputPath) }},
+	}, nil
+}
+
+func (p *PdfGenerator) Priority() int {
+	return 60
+}
+
+func (p *PdfGenerator) EnableFlag() string {
+	return "thumb_pdf_enabled"
+}
@@ -23,6 +23,8 @@ func TestGenerator(ctx context.Context, name, executable string) (string, error)
 		return testFfmpegGenerator(ctx, executable)
 	case "libreOffice":
 		return testLibreOfficeGenerator(ctx, executable)
+	case "magick":
+		return testMagickGenerator(ctx, executable)
 	default:
 		return "", ErrUnknownGenerator
 	}
@@ -72,3 +74,18 @@ func testLibreOfficeGenerator(ctx context.Context, executable string) (string, e
 
 	return output.String(), nil
 }
+
+func testMagickGenerator(ctx context.Context, executable string) (string, error) {
+	cmd := exec.CommandContext(ctx, executable, "-version")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to invoke ImageMagick executable: %w", err)
+	}
+
+	if !strings.Contains(output.String(), "ImageMagick") {
+		return "", ErrUnknownOutput
+	}
+
+	return output.String(), nil
+}
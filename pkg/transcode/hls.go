@@ -0,0 +1,270 @@
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+)
+
+const (
+	// PlaylistName 各清晰度的播放列表文件名
+	PlaylistName = "index.m3u8"
+	// PlaylistContentType HLS 播放列表的 MIME 类型
+	PlaylistContentType = "application/vnd.apple.mpegurl"
+	// SegmentContentType HLS 分片的 MIME 类型
+	SegmentContentType = "video/mp2t"
+
+	cacheFolder = "hls"
+	endList     = "#EXT-X-ENDLIST"
+
+	// inputFormats 允许 ffmpeg 识别的源文件格式。播放列表、concat 等格式会引用其他文件或地址，
+	// 用户上传的文件可借此读取服务器上的任意文件，因此不在其中
+	inputFormats = "mov,mp4,m4a,3gp,matroska,webm,avi,flv,mpegts,mpeg,ogg,asf,wav,mp3,aac,flac"
+)
+
+var (
+	ErrUnknownQuality = errors.New("unknown quality")
+	ErrTooManyJobs    = errors.New("too many transcoding jobs")
+	ErrNotReady       = errors.New("playlist is not ready yet")
+
+	segmentName = regexp.MustCompile(`^seg_\d{5}\.ts$`)
+
+	// Qualities 所有支持的清晰度
+	Qualities = []Quality{
+		{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+		{Name: "480p", Height: 480, VideoBitrate: 1400, AudioBitrate: 128},
+		{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+		{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 192},
+	}
+
+	jobs   = make(map[string]*job)
+	jobsMu sync.Mutex
+)
+
+// Quality 转码清晰度，码率单位为 kbps
+type Quality struct {
+	Name         string
+	Height       int
+	VideoBitrate int
+	AudioBitrate int
+}
+
+// Bandwidth 返回清晰度在播放列表中声明的带宽
+func (q Quality) Bandwidth() int {
+	return (q.VideoBitrate + q.AudioBitrate) * 1000
+}
+
+type job struct {
+	done chan struct{}
+	err  error
+}
+
+// EnabledQualities 返回站点设置中启用的清晰度
+func EnabledQualities() []Quality {
+	enabled := strings.Split(model.GetSettingByName("hls_qualities"), ",")
+	res := make([]Quality, 0, len(Qualities))
+	for _, q := range Qualities {
+		if util.ContainsString(enabled, q.Name) {
+			res = append(res, q)
+		}
+	}
+	return res
+}
+
+// GetQuality 返回已启用的指定名称的清晰度
+func GetQuality(name string) (Quality, error) {
+	for _, q := range EnabledQualities() {
+		if q.Name == name {
+			return q, nil
+		}
+	}
+	return Quality{}, ErrUnknownQuality
+}
+
+// IsSegmentName 返回 name 是否为合法的分片文件名
+func IsSegmentName(name string) bool {
+	return segmentName.MatchString(name)
+}
+
+// MasterPlaylist 生成包含各清晰度的主播放列表，uri 返回各清晰度播放列表的地址
+func MasterPlaylist(qualities []Quality, uri func(q Quality) string) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, q := range qualities {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,NAME=\"%s\"\n%s\n", q.Bandwidth(), q.Name, uri(q))
+	}
+	return b.String()
+}
+
+// CacheDir 返回文件指定清晰度的转码缓存目录，文件内容变化后目录随之改变
+func CacheDir(file *model.File, q Quality) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%s", file.PolicyID, file.SourceName)))
+	return filepath.Join(
+		util.RelativePath(model.GetSettingByName("temp_path")),
+		cacheFolder,
+		fmt.Sprintf("%d_%s", file.ID, hex.EncodeToString(sum[:8])),
+		q.Name,
+	)
+}
+
+// Prepare 确保 dir 下已有或正在生成 input 的 HLS 转码结果，并等待播放列表可用
+func Prepare(input, dir string, q Quality) error {
+	playlist := filepath.Join(dir, PlaylistName)
+
+	jobsMu.Lock()
+	j, running := jobs[dir]
+	if !running {
+		if isComplete(playlist) {
+			jobsMu.Unlock()
+			touch(dir)
+			return nil
+		}
+
+		max := model.GetIntSetting("hls_max_jobs", 2)
+		if max > 0 && len(jobs) >= max {
+			jobsMu.Unlock()
+			return ErrTooManyJobs
+		}
+
+		// 清理上次中断的转码结果
+		os.RemoveAll(dir)
+		j = &job{done: make(chan struct{})}
+		jobs[dir] = j
+		go j.run(input, dir, q)
+	}
+	jobsMu.Unlock()
+
+	// 等待首个分片写入播放列表
+	timeout := time.After(time.Duration(model.GetIntSetting("hls_wait_timeout", 20)) * time.Second)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-j.done:
+			return j.err
+		case <-ticker.C:
+			if hasSegment(playlist) {
+				return nil
+			}
+		case <-timeout:
+			return ErrNotReady
+		}
+	}
+}
+
+// run 调用 ffmpeg 生成 HLS 分片
+func (j *job) run(input, dir string, q Quality) {
+	defer func() {
+		jobsMu.Lock()
+		delete(jobs, dir)
+		jobsMu.Unlock()
+		close(j.done)
+	}()
+
+	if err := os.MkdirAll(dir, 0744); err != nil {
+		j.err = fmt.Errorf("failed to create cache folder: %w", err)
+		return
+	}
+
+	options := model.GetSettingByNames("hls_ffmpeg_path", "hls_segment_duration")
+	timeout := time.Duration(model.GetIntSetting("hls_job_timeout", 7200)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := append(inputArgs(input),
+		"-i", input,
+		"-vf", fmt.Sprintf("scale=-2:'min(%d,ih)'", q.Height),
+		"-c:v", "libx264", "-preset", "veryfast",
+		"-b:v", strconv.Itoa(q.VideoBitrate)+"k",
+		"-maxrate", strconv.Itoa(q.VideoBitrate)+"k",
+		"-bufsize", strconv.Itoa(q.VideoBitrate*2)+"k",
+		"-c:a", "aac", "-b:a", strconv.Itoa(q.AudioBitrate)+"k", "-ac", "2",
+		"-f", "hls",
+		"-hls_time", options["hls_segment_duration"],
+		"-hls_playlist_type", "event",
+		"-hls_segment_filename", filepath.Join(dir, "seg_%05d.ts"),
+		filepath.Join(dir, PlaylistName),
+	)
+	cmd := exec.CommandContext(ctx, options["hls_ffmpeg_path"], args...)
+
+	var stdErr bytes.Buffer
+	cmd.Stderr = &stdErr
+	if err := cmd.Run(); err != nil {
+		util.Log().Warning("Failed to invoke ffmpeg: %s", stdErr.String())
+		os.RemoveAll(dir)
+		j.err = fmt.Errorf("failed to invoke ffmpeg: %w", err)
+	}
+}
+
+// inputArgs 返回限制 ffmpeg 读取源文件时可用协议与格式的参数，
+// 本机文件只允许读取文件本身，其他存储策略只允许通过 HTTP(S) 读取签名地址
+func inputArgs(input string) []string {
+	protocols := "file"
+	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+		protocols = "http,https,tcp,tls"
+	}
+
+	return []string{"-protocol_whitelist", protocols, "-format_whitelist", inputFormats}
+}
+
+// CollectCache 删除超过 ttl 秒未被访问且不在转码中的缓存
+func CollectCache(ttl int) {
+	root := filepath.Join(util.RelativePath(model.GetSettingByName("temp_path")), cacheFolder)
+	files, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	for _, f := range files {
+		qualities, err := os.ReadDir(filepath.Join(root, f.Name()))
+		if err != nil {
+			continue
+		}
+
+		for _, q := range qualities {
+			dir := filepath.Join(root, f.Name(), q.Name())
+			info, err := q.Info()
+			if _, running := jobs[dir]; running || err != nil || time.Since(info.ModTime()).Seconds() <= float64(ttl) {
+				continue
+			}
+
+			util.Log().Debug("Delete expired HLS cache %q.", dir)
+			os.RemoveAll(dir)
+		}
+
+		// 删除空目录
+		os.Remove(filepath.Join(root, f.Name()))
+	}
+}
+
+func isComplete(playlist string) bool {
+	content, err := os.ReadFile(playlist)
+	return err == nil && bytes.Contains(content, []byte(endList))
+}
+
+func hasSegment(playlist string) bool {
+	content, err := os.ReadFile(playlist)
+	return err == nil && bytes.Contains(content, []byte("#EXTINF"))
+}
+
+// touch 更新缓存目录的修改时间，用于判断缓存是否过期
+func touch(dir string) {
+	now := time.Now()
+	os.Chtimes(dir, now, now)
+}
@@ -0,0 +1,82 @@
+package transcode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetQuality(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_hls_qualities", "360p,720p", 0)
+
+	asserts.Len(EnabledQualities(), 2)
+	q, err := GetQuality("720p")
+	asserts.NoError(err)
+	asserts.Equal(720, q.Height)
+
+	_, err = GetQuality("1080p")
+	asserts.Equal(ErrUnknownQuality, err)
+}
+
+func TestIsSegmentName(t *testing.T) {
+	asserts := assert.New(t)
+	asserts.True(IsSegmentName("seg_00001.ts"))
+	asserts.False(IsSegmentName("../seg_00001.ts"))
+	asserts.False(IsSegmentName(PlaylistName))
+}
+
+func TestMasterPlaylist(t *testing.T) {
+	asserts := assert.New(t)
+	res := MasterPlaylist(Qualities[:2], func(q Quality) string {
+		return q.Name + "/" + PlaylistName
+	})
+	asserts.Contains(res, "#EXTM3U")
+	asserts.Contains(res, "BANDWIDTH=896000,NAME=\"360p\"\n360p/index.m3u8")
+	asserts.Contains(res, "480p/index.m3u8")
+}
+
+func TestInputArgs(t *testing.T) {
+	asserts := assert.New(t)
+
+	asserts.Equal([]string{"-protocol_whitelist", "file", "-format_whitelist", inputFormats}, inputArgs("uploads/1/video.mp4"))
+	asserts.Equal([]string{"-protocol_whitelist", "http,https,tcp,tls", "-format_whitelist", inputFormats}, inputArgs("https://example.com/video.mp4"))
+	asserts.NotContains(inputFormats, "hls")
+	asserts.NotContains(inputFormats, "concat")
+}
+
+func TestCacheDir(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_temp_path", "temp", 0)
+	file := &model.File{SourceName: "a.mkv", PolicyID: 1}
+	file.ID = 1
+
+	dir := CacheDir(file, Qualities[0])
+	asserts.Equal("360p", filepath.Base(dir))
+
+	file.SourceName = "b.mkv"
+	asserts.NotEqual(dir, CacheDir(file, Qualities[0]))
+}
+
+func TestCollectCache(t *testing.T) {
+	asserts := assert.New(t)
+	cache.Set("setting_temp_path", t.TempDir(), 0)
+	file := &model.File{SourceName: "a.mkv"}
+	file.ID = 1
+
+	expired := CacheDir(file, Qualities[0])
+	fresh := CacheDir(file, Qualities[1])
+	asserts.NoError(os.MkdirAll(expired, 0744))
+	asserts.NoError(os.MkdirAll(fresh, 0744))
+	old := time.Now().Add(-time.Hour)
+	asserts.NoError(os.Chtimes(expired, old, old))
+
+	CollectCache(60)
+	asserts.NoDirExists(expired)
+	asserts.DirExists(fresh)
+}
@@ -0,0 +1,43 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	asserts := assert.New(t)
+
+	// 加密后可解密
+	{
+		cipher, err := Encrypt("secret", "access key")
+		asserts.NoError(err)
+		asserts.NotEqual("access key", cipher)
+
+		plain, err := Decrypt("secret", cipher)
+		asserts.NoError(err)
+		asserts.Equal("access key", plain)
+	}
+
+	// 相同内容每次加密结果不同
+	{
+		c1, _ := Encrypt("secret", "access key")
+		c2, _ := Encrypt("secret", "access key")
+		asserts.NotEqual(c1, c2)
+	}
+
+	// 密钥不一致
+	{
+		cipher, _ := Encrypt("secret", "access key")
+		_, err := Decrypt("another", cipher)
+		asserts.Error(err)
+	}
+
+	// 密文无效
+	{
+		_, err := Decrypt("secret", "not base64!")
+		asserts.Error(err)
+		_, err = Decrypt("secret", "YQ==")
+		asserts.Error(err)
+	}
+}
@@ -44,3 +44,15 @@ func IsEmpty(name string) (bool, error) {
 	}
 	return false, err // Either not empty or error, suits both cases
 }
+
+// CountWriter 统计写出字节数的 Writer
+type CountWriter struct {
+	W     io.Writer
+	Count uint64
+}
+
+func (w *CountWriter) Write(p []byte) (int, error) {
+	n, err := w.W.Write(p)
+	w.Count += uint64(n)
+	return n, err
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -37,3 +38,14 @@ func TestIsEmpty(t *testing.T) {
 	asserts.False(IsEmpty(""))
 	asserts.False(IsEmpty("not_exist"))
 }
+
+func TestCountWriter(t *testing.T) {
+	asserts := assert.New(t)
+	buf := &strings.Builder{}
+	w := &CountWriter{W: buf}
+
+	w.Write([]byte("hello"))
+	w.Write([]byte(" world"))
+	asserts.EqualValues(11, w.Count)
+	asserts.Equal("hello world", buf.String())
+}
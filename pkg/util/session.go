@@ -37,3 +37,8 @@ func ClearSession(c *gin.Context) {
 	s.Clear()
 	s.Save()
 }
+
+// SessionID 获取当前会话ID，会话尚未保存时为空
+func SessionID(c *gin.Context) string {
+	return sessions.Default(c).ID()
+}
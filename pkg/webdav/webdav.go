@@ -9,6 +9,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -21,6 +22,7 @@ import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 )
 
@@ -276,6 +278,15 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request, fs *
 	}
 	fs.SetTargetFile(&[]model.File{*file})
 
+	// 计入下载流量，断点续传时只计算请求的区间
+	if r.Method == http.MethodGet {
+		size := filesystem.RangeLength(r.Header.Get("Range"), file.Size)
+		if err := fs.ChargeDownload(size); err != nil {
+			return http.StatusForbidden, err
+		}
+		model.RecordFileDownload(file.ID, size)
+	}
+
 	rs, err := fs.Preview(ctx, 0, false)
 	if err != nil {
 		if err == filesystem.ErrObjectNotExist {
@@ -303,6 +314,20 @@ func (h *Handler) handleGetHeadPost(w http.ResponseWriter, r *http.Request, fs *
 			return http.StatusInternalServerError, err
 		}
 
+		// 限制从机节点的中转并发，名额已满时告知客户端排队位置
+		client, _, _ := net.SplitHostPort(r.RemoteAddr)
+		release, position, err := filesystem.AcquireProxySlot(ctx, fs.FileTarget[0].GetPolicy(), fs.User.ID,
+			filesystem.ProxyTicket(fs.User.ID, fs.FileTarget[0].ID, client), filesystem.ProxyQueueWait())
+		if err != nil {
+			if errors.Is(err, filesystem.ErrProxyQueued) {
+				w.Header().Set("Retry-After", "5")
+				w.Header().Set("X-Cr-Queue-Position", strconv.Itoa(position))
+				return http.StatusServiceUnavailable, err
+			}
+			return http.StatusInternalServerError, err
+		}
+		defer release()
+
 		r = r.Clone(context.WithValue(r.Context(), fsctx.WebDAVProxyUrlCtx, target))
 		// 忽略反向代理在传输错误时报错
 		defer func() {
@@ -333,10 +358,16 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request, fs *files
 	}
 	defer release()
 
-	ctx := r.Context()
+	ctx := context.WithValue(r.Context(), fsctx.LockTokenCtx, r.Header.Get(filesystem.LockTokenHeader))
 
 	// 尝试作为文件删除
 	if ok, file := fs.IsFileExist(reqPath); ok {
+		if err := fs.CheckLocks(ctx, nil, []uint{file.ID}); err != nil {
+			return StatusLocked, err
+		}
+		if err := fs.CheckRetention(nil, []uint{file.ID}); err != nil {
+			return http.StatusForbidden, err
+		}
 		if err := fs.Delete(ctx, []uint{}, []uint{file.ID}, false, false); err != nil {
 			return http.StatusMethodNotAllowed, err
 		}
@@ -345,6 +376,12 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request, fs *files
 
 	// 尝试作为目录删除
 	if ok, folder := fs.IsPathExist(reqPath); ok {
+		if err := fs.CheckLocks(ctx, []uint{folder.ID}, nil); err != nil {
+			return StatusLocked, err
+		}
+		if err := fs.CheckRetention([]uint{folder.ID}, nil); err != nil {
+			return http.StatusForbidden, err
+		}
 		if err := fs.Delete(ctx, []uint{folder.ID}, []uint{}, false, false); err != nil {
 			return http.StatusMethodNotAllowed, err
 		}
@@ -371,6 +408,7 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request, fs *filesyst
 	defer cancel()
 	ctx = context.WithValue(ctx, fsctx.HTTPCtx, r.Context())
 	ctx = context.WithValue(ctx, fsctx.CancelFuncCtx, cancel)
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, r.Header.Get(filesystem.LockTokenHeader))
 
 	fileSize, err := strconv.ParseUint(r.Header.Get("Content-Length"), 10, 64)
 	if err != nil {
@@ -380,43 +418,63 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request, fs *filesyst
 	filePath := path.Dir(reqPath)
 	fileData := fsctx.FileStream{
 		MimeType:    r.Header.Get("Content-Type"),
-		File:        r.Body,
+		File:        filesystem.LimitReadCloser(r.Body, fs.User.Group.OptionsSerialized.UploadSpeedLimit),
 		Size:        fileSize,
 		Name:        fileName,
 		VirtualPath: filePath,
 	}
 
+	// 目标已存在时的处理方式，可通过 If-None-Match: * 或 X-Cr-Conflict 请求头指定，默认覆盖
+	mode, err := filesystem.ParseConflictMode(r.Header.Get(ConflictHeader), filesystem.ConflictOverwrite)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+	if r.Header.Get("If-None-Match") == "*" {
+		mode = filesystem.ConflictReject
+	}
+
 	// 判断文件是否已存在
 	exist, originFile := fs.IsFileExist(reqPath)
+	if exist && mode == filesystem.ConflictReject {
+		return http.StatusPreconditionFailed, filesystem.ErrFileExisted
+	}
+
+	if exist && mode == filesystem.ConflictRename {
+		_, parent := fs.IsPathExist(filePath)
+		if fileData.Name, err = fs.AvailableName(parent, fileName); err != nil {
+			return http.StatusConflict, err
+		}
+		exist = false
+	}
+
+	var (
+		file   model.File
+		upload = fs.Upload
+	)
 	if exist {
-		// 已存在，为更新操作
+		// 已存在，为更新操作，原内容保留为历史版本
+		if originFile.UploadSessionID != nil {
+			return http.StatusConflict, filesystem.ErrFileUploadSessionExisted
+		}
 
-		// 检查此文件是否有软链接
-		fileList, err := model.RemoveFilesWithSoftLinks([]model.File{*originFile})
-		if err == nil && len(fileList) == 0 {
-			// 如果包含软连接，应重新生成新文件副本，并更新source_name
-			originFile.SourceName = fs.GenerateSavePath(ctx, &fileData)
-			fileData.Mode &= ^fsctx.Overwrite
-			fs.Use("AfterUpload", filesystem.HookUpdateSourceName)
-			fs.Use("AfterUploadCanceled", filesystem.HookUpdateSourceName)
-			fs.Use("AfterValidateFailed", filesystem.HookUpdateSourceName)
+		if err := fs.CheckLocks(ctx, nil, []uint{originFile.ID}); err != nil {
+			return StatusLocked, err
+		}
+		if err := fs.CheckRetention(nil, []uint{originFile.ID}); err != nil {
+			return http.StatusForbidden, err
 		}
 
-		fs.Use("BeforeUpload", filesystem.HookResetPolicy)
-		fs.Use("BeforeUpload", filesystem.HookValidateFile)
-		fs.Use("BeforeUpload", filesystem.HookValidateCapacityDiff)
-		fs.Use("AfterUploadCanceled", filesystem.HookCleanFileContent)
-		fs.Use("AfterUploadCanceled", filesystem.HookClearFileSize)
+		file = *originFile
+		upload = func(ctx context.Context, stream *fsctx.FileStream) error {
+			return fs.SaveNewVersion(ctx, &file, stream, fs.User.Nick)
+		}
+		fs.Use("BeforeUpload", filesystem.HookValidateFolderQuotaDiff)
 		fs.Use("AfterUploadCanceled", filesystem.HookCancelContext)
-		fs.Use("AfterUpload", filesystem.GenericAfterUpdate)
-		fs.Use("AfterValidateFailed", filesystem.HookCleanFileContent)
-		fs.Use("AfterValidateFailed", filesystem.HookClearFileSize)
-		ctx = context.WithValue(ctx, fsctx.FileModelCtx, *originFile)
-		fileData.Mode |= fsctx.Overwrite
 	} else {
 		// 给文件系统分配钩子
 		fs.Use("BeforeUpload", filesystem.HookValidateFile)
 		fs.Use("BeforeUpload", filesystem.HookValidateCapacity)
+		fs.Use("BeforeUpload", filesystem.HookValidateFolderQuota)
 		fs.Use("AfterUploadCanceled", filesystem.HookDeleteTempFile)
 		fs.Use("AfterUploadCanceled", filesystem.HookCancelContext)
 		fs.Use("AfterUpload", filesystem.GenericAfterUpload)
@@ -425,14 +483,16 @@ func (h *Handler) handlePut(w http.ResponseWriter, r *http.Request, fs *filesyst
 
 	// rclone 请求
 	fs.Use("AfterUpload", filesystem.NewWebdavAfterUploadHook(r))
+	fs.Use("AfterUpload", filesystem.HookAddUploadTraffic(fileSize))
 
 	// 执行上传
-	err = fs.Upload(ctx, &fileData)
+	err = upload(ctx, &fileData)
 	if err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
+	task.SubmitScanTask(fs.User, fileData.Model.(*model.File))
 
-	etag, err := findETag(ctx, fs, nil, reqPath, fileData.Model.(*model.File))
+	etag, err := findETag(ctx, fs, nil, path.Join(filePath, fileData.Name), fileData.Model.(*model.File))
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
@@ -499,7 +559,7 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request, fs *fil
 		return http.StatusForbidden, errDestinationEqualsSource
 	}
 
-	ctx := r.Context()
+	ctx := context.WithValue(r.Context(), fsctx.LockTokenCtx, r.Header.Get(filesystem.LockTokenHeader))
 
 	isExist, target := isPathExist(ctx, fs, src)
 
@@ -550,6 +610,22 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request, fs *fil
 	}
 	defer release()
 
+	if file, ok := target.(*model.File); ok {
+		if err := fs.CheckLocks(ctx, nil, []uint{file.ID}); err != nil {
+			return StatusLocked, err
+		}
+		if err := fs.CheckRetention(nil, []uint{file.ID}); err != nil {
+			return http.StatusForbidden, err
+		}
+	} else if folder, ok := target.(*model.Folder); ok {
+		if err := fs.CheckLocks(ctx, []uint{folder.ID}, nil); err != nil {
+			return StatusLocked, err
+		}
+		if err := fs.CheckRetention([]uint{folder.ID}, nil); err != nil {
+			return http.StatusForbidden, err
+		}
+	}
+
 	// Section 9.9.2 says that "The MOVE method on a collection must act as if
 	// a "Depth: infinity" header was used on it. A client must not submit a
 	// Depth header on a MOVE on a collection with any value but "infinity"."
@@ -828,6 +904,9 @@ func makePropstatResponse(href string, pstats []Propstat) *response {
 	return &resp
 }
 
+// ConflictHeader 指定 PUT 目标已存在时处理方式的请求头
+const ConflictHeader = "X-Cr-Conflict"
+
 const (
 	infiniteDepth = -1
 	invalidDepth  = -2
@@ -0,0 +1,86 @@
+package wopi
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+)
+
+const (
+	LockHeader    = wopiHeaderPrefix + "Lock"
+	OldLockHeader = wopiHeaderPrefix + "OldLock"
+	MethodGetLock = "GET_LOCK"
+
+	LockCachePrefix = "wopi_lock_"
+	// LockTTL WOPI 锁的有效期为 30 分钟
+	LockTTL = 1800
+)
+
+var ErrLockMismatch = errors.New("lock mismatch")
+
+func lockKey(fileID uint) string {
+	return LockCachePrefix + strconv.FormatUint(uint64(fileID), 10)
+}
+
+// GetLock returns current lock of given file, an empty string is returned if file is not locked.
+func GetLock(store cache.Driver, fileID uint) string {
+	if lock, ok := store.Get(lockKey(fileID)); ok {
+		return lock.(string)
+	}
+
+	return ""
+}
+
+// Lock locks given file. If oldLock is not empty, the file must be locked with oldLock and
+// will be unlocked and relocked with lock. Current lock is returned on ErrLockMismatch.
+func Lock(store cache.Driver, fileID uint, lock, oldLock string) (string, error) {
+	current := GetLock(store, fileID)
+	if oldLock != "" {
+		if current != oldLock {
+			return current, ErrLockMismatch
+		}
+	} else if current != "" && current != lock {
+		return current, ErrLockMismatch
+	}
+
+	return "", store.Set(lockKey(fileID), lock, LockTTL)
+}
+
+// RefreshLock resets the expiration timer of existing lock.
+func RefreshLock(store cache.Driver, fileID uint, lock string) (string, error) {
+	current := GetLock(store, fileID)
+	if current != lock {
+		return current, ErrLockMismatch
+	}
+
+	return "", store.Set(lockKey(fileID), lock, LockTTL)
+}
+
+// Unlock releases existing lock.
+func Unlock(store cache.Driver, fileID uint, lock string) (string, error) {
+	current := GetLock(store, fileID)
+	if current != lock {
+		return current, ErrLockMismatch
+	}
+
+	return "", store.Delete([]string{lockKey(fileID)}, "")
+}
+
+// CheckLock validates if file can be modified with given lock. A file that is not locked
+// can only be written when it's empty.
+func CheckLock(store cache.Driver, fileID uint, lock string, size uint64) (string, error) {
+	current := GetLock(store, fileID)
+	if current == "" {
+		if size > 0 {
+			return "", ErrLockMismatch
+		}
+		return "", nil
+	}
+
+	if current != lock {
+		return current, ErrLockMismatch
+	}
+
+	return "", nil
+}
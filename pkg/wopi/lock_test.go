@@ -0,0 +1,72 @@
+package wopi
+
+import (
+	"testing"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	a := assert.New(t)
+	store := cache.NewMemoStore()
+
+	// Lock unlocked file
+	_, err := Lock(store, 1, "lock1", "")
+	a.NoError(err)
+	a.Equal("lock1", GetLock(store, 1))
+
+	// Lock again with same lock
+	_, err = Lock(store, 1, "lock1", "")
+	a.NoError(err)
+
+	// Lock mismatch
+	current, err := Lock(store, 1, "lock2", "")
+	a.Equal(ErrLockMismatch, err)
+	a.Equal("lock1", current)
+
+	// Unlock and relock with mismatched old lock
+	current, err = Lock(store, 1, "lock2", "lock3")
+	a.Equal(ErrLockMismatch, err)
+	a.Equal("lock1", current)
+
+	// Unlock and relock
+	_, err = Lock(store, 1, "lock2", "lock1")
+	a.NoError(err)
+	a.Equal("lock2", GetLock(store, 1))
+
+	// Refresh lock
+	_, err = RefreshLock(store, 1, "lock1")
+	a.Equal(ErrLockMismatch, err)
+	_, err = RefreshLock(store, 1, "lock2")
+	a.NoError(err)
+
+	// Unlock
+	_, err = Unlock(store, 1, "lock1")
+	a.Equal(ErrLockMismatch, err)
+	_, err = Unlock(store, 1, "lock2")
+	a.NoError(err)
+	a.Equal("", GetLock(store, 1))
+}
+
+func TestCheckLock(t *testing.T) {
+	a := assert.New(t)
+	store := cache.NewMemoStore()
+
+	// Unlocked empty file can be written
+	_, err := CheckLock(store, 1, "", 0)
+	a.NoError(err)
+
+	// Unlocked non-empty file cannot be written
+	_, err = CheckLock(store, 1, "", 10)
+	a.Equal(ErrLockMismatch, err)
+
+	// Locked file
+	_, err = Lock(store, 1, "lock1", "")
+	a.NoError(err)
+	_, err = CheckLock(store, 1, "lock1", 10)
+	a.NoError(err)
+	current, err := CheckLock(store, 1, "lock2", 10)
+	a.Equal(ErrLockMismatch, err)
+	a.Equal("lock1", current)
+}
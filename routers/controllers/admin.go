@@ -6,8 +6,10 @@ import (
 	"io"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/antivirus"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2"
 	"github.com/cloudreve/Cloudreve/v3/pkg/email"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
 	"github.com/cloudreve/Cloudreve/v3/pkg/request"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/wopi"
@@ -82,11 +84,216 @@ func AdminReloadService(c *gin.Context) {
 		aria2.Init(true, cluster.Default, mq.GlobalMQ)
 	case "wopi":
 		wopi.Init()
+	case "geoip":
+		netpolicy.Init()
+	case "antivirus":
+		antivirus.Init()
 	}
 
 	c.JSON(200, serializer.Response{})
 }
 
+// AdminGetMailTemplate 获取邮件模板
+func AdminGetMailTemplate(c *gin.Context) {
+	var service admin.MailTemplateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Get()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminSaveMailTemplate 保存邮件模板
+func AdminSaveMailTemplate(c *gin.Context) {
+	var service admin.MailTemplateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Save()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminPreviewMailTemplate 预览邮件模板
+func AdminPreviewMailTemplate(c *gin.Context) {
+	var service admin.MailTemplateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Preview()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminTestMailTemplate 发送邮件模板测试邮件
+func AdminTestMailTemplate(c *gin.Context) {
+	var service admin.MailTemplateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Test(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListMailLog 列出邮件投递记录
+func AdminListMailLog(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.MailLogs()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListAuditLog 列出审计记录
+func AdminListAuditLog(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.AuditLogs()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListAnnouncement 列出公告
+func AdminListAnnouncement(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Announcements()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminCreateAnnouncement 发布公告
+func AdminCreateAnnouncement(c *gin.Context) {
+	var service admin.AnnouncementService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminDeleteAnnouncement 删除公告
+func AdminDeleteAnnouncement(c *gin.Context) {
+	var service admin.AnnouncementIDService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Delete(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListTenant 列出租户
+func AdminListTenant(c *gin.Context) {
+	var service admin.AdminListService
+	res := service.Tenants()
+	c.JSON(200, res)
+}
+
+// AdminCreateTenant 创建租户
+func AdminCreateTenant(c *gin.Context) {
+	var service admin.TenantService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminUpdateTenant 保存租户
+func AdminUpdateTenant(c *gin.Context) {
+	var (
+		id      admin.TenantIDService
+		service admin.TenantService
+	)
+	if err := c.ShouldBindUri(&id); err != nil {
+		c.JSON(200, ErrorResponse(err))
+		return
+	}
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Update(c, id.ID)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminDeleteTenant 删除租户
+func AdminDeleteTenant(c *gin.Context) {
+	var service admin.TenantIDService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Delete(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminAnalyticsStorage 容量使用报表
+func AdminAnalyticsStorage(c *gin.Context) {
+	var service admin.AnalyticsService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Storage()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminAnalyticsTraffic 流量与活跃用户报表
+func AdminAnalyticsTraffic(c *gin.Context) {
+	var service admin.AnalyticsService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Traffic()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminAnalyticsFiles 下载量最多的文件
+func AdminAnalyticsFiles(c *gin.Context) {
+	var service admin.AnalyticsService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Files()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminAnalyticsShares 分享统计报表
+func AdminAnalyticsShares(c *gin.Context) {
+	var service admin.AnalyticsService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Shares()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminAnalyticsContent 文件类型分布与容量增长报表
+func AdminAnalyticsContent(c *gin.Context) {
+	var service admin.AnalyticsService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Content()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // AdminSendTestMail 发送测试邮件
 func AdminSendTestMail(c *gin.Context) {
 	var service admin.MailTestService
@@ -275,6 +482,7 @@ func AdminGetGroup(c *gin.Context) {
 func AdminListUser(c *gin.Context) {
 	var service admin.AdminListService
 	if err := c.ShouldBindJSON(&service); err == nil {
+		service.Tenant = c.GetUint("tenant_id")
 		res := service.Users()
 		c.JSON(200, res)
 	} else {
@@ -308,7 +516,7 @@ func AdminGetUser(c *gin.Context) {
 func AdminDeleteUser(c *gin.Context) {
 	var service admin.UserBatchService
 	if err := c.ShouldBindJSON(&service); err == nil {
-		res := service.Delete()
+		res := service.Delete(c)
 		c.JSON(200, res)
 	} else {
 		c.JSON(200, ErrorResponse(err))
@@ -326,10 +534,60 @@ func AdminBanUser(c *gin.Context) {
 	}
 }
 
+// AdminImportUser 从 CSV 批量导入用户
+func AdminImportUser(c *gin.Context) {
+	var service admin.UserImportService
+	res := service.Import(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// AdminExportUser 导出用户列表
+func AdminExportUser(c *gin.Context) {
+	var service admin.UserExportService
+	res := service.Export(c)
+	if res.Code >= 0 {
+		c.JSON(200, res)
+	}
+}
+
+// AdminImpersonateUser 模拟用户登录
+func AdminImpersonateUser(c *gin.Context) {
+	var service admin.ImpersonateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Impersonate(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminApproveUser 通过用户注册审核
+func AdminApproveUser(c *gin.Context) {
+	var service admin.UserService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Approve()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminResetUser2FA 重置用户二步验证
+func AdminResetUser2FA(c *gin.Context) {
+	var service admin.UserReset2FAService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Reset(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // AdminListFile 列出文件
 func AdminListFile(c *gin.Context) {
 	var service admin.AdminListService
 	if err := c.ShouldBindJSON(&service); err == nil {
+		service.Tenant = c.GetUint("tenant_id")
 		res := service.Files()
 		c.JSON(200, res)
 	} else {
@@ -367,10 +625,22 @@ func AdminDeleteFile(c *gin.Context) {
 	}
 }
 
+// AdminUnlockFile 强制解除文件锁
+func AdminUnlockFile(c *gin.Context) {
+	var service admin.FileBatchService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Unlock(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // AdminListShare 列出分享
 func AdminListShare(c *gin.Context) {
 	var service admin.AdminListService
 	if err := c.ShouldBindJSON(&service); err == nil {
+		service.Tenant = c.GetUint("tenant_id")
 		res := service.Shares()
 		c.JSON(200, res)
 	} else {
@@ -433,6 +703,17 @@ func AdminDeleteTask(c *gin.Context) {
 	}
 }
 
+// AdminRetryTask 重试失败的常规任务
+func AdminRetryTask(c *gin.Context) {
+	var service admin.TaskBatchService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Retry(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // AdminCreateImportTask 新建文件导入任务
 func AdminCreateImportTask(c *gin.Context) {
 	var service admin.ImportTaskService
@@ -509,3 +790,225 @@ func AdminGetNode(c *gin.Context) {
 		c.JSON(200, ErrorResponse(err))
 	}
 }
+
+// AdminCrontabStatus 获取定时任务状态
+func AdminCrontabStatus(c *gin.Context) {
+	service := &admin.NoParamService{}
+	res := service.Crontab()
+	c.JSON(200, res)
+}
+
+// AdminAria2Health 获取离线下载节点健康状态
+func AdminAria2Health(c *gin.Context) {
+	service := &admin.AdminListService{}
+	res := service.Aria2Health()
+	c.JSON(200, res)
+}
+
+// AdminListQuarantine 列出病毒扫描隔离记录
+func AdminListQuarantine(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Quarantines()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminReleaseQuarantine 解除文件隔离
+func AdminReleaseQuarantine(c *gin.Context) {
+	var service admin.QuarantineService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Release(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminDeleteQuarantine 删除被隔离的文件
+func AdminDeleteQuarantine(c *gin.Context) {
+	var service admin.QuarantineService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Delete(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListBlockedHash 列出屏蔽哈希
+func AdminListBlockedHash(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.BlockedHashes()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminAddBlockedHash 添加屏蔽哈希
+func AdminAddBlockedHash(c *gin.Context) {
+	var service admin.AddBlockedHashService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Add(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminDeleteBlockedHash 移除屏蔽哈希
+func AdminDeleteBlockedHash(c *gin.Context) {
+	var service admin.BlockedHashService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Delete(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListBlocklistLog 列出屏蔽列表审计日志
+func AdminListBlocklistLog(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.BlocklistLogs()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListRetentionHold 列出保留冻结
+func AdminListRetentionHold(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.RetentionHolds()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminSetRetentionHold 设置文件或目录的保留冻结
+func AdminSetRetentionHold(c *gin.Context) {
+	var service admin.RetentionHoldSetService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Set(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminReleaseRetentionHold 解除保留冻结
+func AdminReleaseRetentionHold(c *gin.Context) {
+	var service admin.RetentionHoldService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Release(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListReport 列出分享举报
+func AdminListReport(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Reports()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminDisableReportedShare 停用被举报的分享
+func AdminDisableReportedShare(c *gin.Context) {
+	var service admin.ReportService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Disable(c, false)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminSuspendReportedUser 停用被举报的分享并封禁分享者
+func AdminSuspendReportedUser(c *gin.Context) {
+	var service admin.ReportService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Disable(c, true)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminIgnoreReport 忽略举报
+func AdminIgnoreReport(c *gin.Context) {
+	var service admin.ReportService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Ignore(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminListInviteCode 列出邀请码
+func AdminListInviteCode(c *gin.Context) {
+	var service admin.AdminListService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.InviteCodes()
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminAddInviteCode 生成邀请码
+func AdminAddInviteCode(c *gin.Context) {
+	var service admin.AddInviteCodeService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Add(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminDeleteInviteCode 删除邀请码
+func AdminDeleteInviteCode(c *gin.Context) {
+	var service admin.InviteCodeService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Delete(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminThumbStats 获取缩略图缓存占用
+func AdminThumbStats(c *gin.Context) {
+	c.JSON(200, admin.ThumbStats(c))
+}
+
+// AdminPurgeThumbs 清除缩略图缓存
+func AdminPurgeThumbs(c *gin.Context) {
+	var service admin.ThumbPurgeService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Purge(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// AdminEvictThumbs 淘汰超出缓存上限的缩略图
+func AdminEvictThumbs(c *gin.Context) {
+	c.JSON(200, admin.EvictThumbs(c))
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+	"github.com/gin-gonic/gin"
+)
+
+// AudioMeta 获取音频元数据
+func AudioMeta(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.AudioMeta(ctx, c)
+	c.JSON(200, res)
+}
+
+// AudioCover 获取音频封面
+func AudioCover(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.AudioCover(ctx, c)
+	if res.Code >= 0 {
+		c.JSON(200, res)
+	}
+}
+
+// ListAudioFolder 列出目录下的音频元数据
+func ListAudioFolder(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.AudioFolderService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.List(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// SearchAudio 搜索音乐库
+func SearchAudio(c *gin.Context) {
+	var service explorer.AudioSearchService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Search(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ListAudioAlbums 列出音乐库专辑
+func ListAudioAlbums(c *gin.Context) {
+	var service explorer.AudioAlbumService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.List(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
@@ -22,6 +22,17 @@ func RemoteCallback(c *gin.Context) {
 	}
 }
 
+// SlaveMetaCallback 从机推送缩略图与元数据
+func SlaveMetaCallback(c *gin.Context) {
+	var service callback.SlaveMetaCallbackService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Save(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // QiniuCallback 七牛上传回调
 func QiniuCallback(c *gin.Context) {
 	var callbackBody callback.UploadCallbackService
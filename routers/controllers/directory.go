@@ -16,13 +16,71 @@ func CreateDirectory(c *gin.Context) {
 	}
 }
 
+// CreateDirectoryFromTemplate 按目录模板批量创建目录
+func CreateDirectoryFromTemplate(c *gin.Context) {
+	var service explorer.FolderTemplateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // ListDirectory 列出目录下内容
 func ListDirectory(c *gin.Context) {
 	var service explorer.DirectoryService
-	if err := c.ShouldBindUri(&service); err == nil {
+	if err := c.ShouldBindUri(&service); err != nil {
+		c.JSON(200, ErrorResponse(err))
+		return
+	}
+
+	if err := c.ShouldBindQuery(&service); err == nil {
 		res := service.ListDirectory(c)
 		c.JSON(200, res)
 	} else {
 		c.JSON(200, ErrorResponse(err))
 	}
 }
+
+// GetFolderQuota 获取目录容量上限
+func GetFolderQuota(c *gin.Context) {
+	var service explorer.FolderQuotaService
+	res := service.GetQuota(c)
+	c.JSON(200, res)
+}
+
+// SetFolderQuota 设定目录容量上限
+func SetFolderQuota(c *gin.Context) {
+	var service explorer.FolderQuotaService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.SetQuota(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ListSubtree 平铺列出目录子树
+func ListSubtree(c *gin.Context) {
+	var service explorer.SubtreeService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.List(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ExportSubtree 导出目录子树
+func ExportSubtree(c *gin.Context) {
+	var service explorer.SubtreeExportService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Export(c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
@@ -377,6 +377,12 @@ func DeleteAllUploadSession(c *gin.Context) {
 	c.JSON(200, res)
 }
 
+// ListUploadSessions 列出进行中的上传会话
+func ListUploadSessions(c *gin.Context) {
+	res := explorer.ListUploadSessions(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
 // GetUploadSession 创建上传会话
 func GetUploadSession(c *gin.Context) {
 	// 创建上下文
@@ -419,3 +425,251 @@ func CreateFile(c *gin.Context) {
 		c.JSON(200, ErrorResponse(err))
 	}
 }
+
+// HLSMaster 获取视频转码主播放列表
+func HLSMaster(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.HLSMaster(ctx, c)
+	if res.Code >= 0 {
+		c.JSON(200, res)
+	}
+}
+
+// HLSSegment 获取视频转码播放列表及分片
+func HLSSegment(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.HLSService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Serve(ctx, c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ListSubtitles 列出视频的外挂字幕
+func ListSubtitles(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.ListSubtitles(ctx, c)
+	c.JSON(200, res)
+}
+
+// Subtitle 获取 WebVTT 格式的字幕
+func Subtitle(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.Subtitle(ctx, c)
+	if res.Code >= 0 {
+		c.JSON(200, res)
+	}
+}
+
+// PDFInfo 获取 PDF 文件页数
+func PDFInfo(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.PDFInfo(ctx, c)
+	c.JSON(200, res)
+}
+
+// PDFPage 获取 PDF 页面预览图
+func PDFPage(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.PDFPageService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Serve(ctx, c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// Render 渲染 Markdown 或 Jupyter Notebook 文件
+func Render(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.Render(ctx, c)
+	c.JSON(200, res)
+}
+
+// VerifyChecksum 创建文件哈希校验任务
+func VerifyChecksum(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.Verify(ctx, c)
+	c.JSON(200, res)
+}
+
+// RehydrateFile 为归档文件发起解冻
+func RehydrateFile(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.Rehydrate(ctx, c)
+	c.JSON(200, res)
+}
+
+// DeltaSignature 获取文件分块签名
+func DeltaSignature(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.DeltaSignatureService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Signature(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// DeltaPatch 提交文件增量内容
+func DeltaPatch(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.DeltaPatchService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Patch(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// LockFile 为文件加锁或刷新锁
+func LockFile(c *gin.Context) {
+	var service explorer.FileLockService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Lock(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UnlockFile 解除文件锁
+func UnlockFile(c *gin.Context) {
+	var service explorer.FileIDService
+	res := service.Unlock(c)
+	c.JSON(200, res)
+}
+
+// ListFileChanges 列出文件变更
+func ListFileChanges(c *gin.Context) {
+	var service explorer.FileChangesService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.List(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// FindDuplicates 创建重复文件分析任务
+func FindDuplicates(c *gin.Context) {
+	c.JSON(200, explorer.FindDuplicates(c))
+}
+
+// ResolveDuplicates 删除重复文件或替换为引用
+func ResolveDuplicates(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.DuplicateResolveService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Resolve(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// CreateReaderSession 创建电子书、漫画阅读会话
+func CreateReaderSession(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.CreateReaderSession(ctx, c)
+	c.JSON(200, res)
+}
+
+// ReaderEntry 获取阅读会话中的章节或页面
+func ReaderEntry(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.ReaderEntryService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Serve(ctx, c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// CreatePresignedUpload 创建预签名上传 URL
+func CreatePresignedUpload(c *gin.Context) {
+	var service explorer.PresignUploadService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// PresignedUpload 通过预签名 URL 上传文件
+func PresignedUpload(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.PresignedUploadService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Upload(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
@@ -67,3 +67,13 @@ func CurrentUser(c *gin.Context) *model.User {
 	}
 	return nil
 }
+
+// CurrentTenant 获取请求所属的租户，默认租户返回 nil
+func CurrentTenant(c *gin.Context) *model.Tenant {
+	if tenant, ok := c.Get("tenant"); ok {
+		if t, ok := tenant.(*model.Tenant); ok {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+	"github.com/gin-gonic/gin"
+)
+
+// ListMounts 列出挂载的外部存储
+func ListMounts(c *gin.Context) {
+	var service explorer.MountListService
+	res := service.Mounts(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// CreateMount 挂载外部存储
+func CreateMount(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.MountCreateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// DeleteMount 解除挂载
+func DeleteMount(c *gin.Context) {
+	var service explorer.MountService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Delete(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
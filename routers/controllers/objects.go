@@ -67,6 +67,21 @@ func Rename(c *gin.Context) {
 	}
 }
 
+// PreviewRename 预览批量重命名结果
+func PreviewRename(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.ItemRenameService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.PreviewRename(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // Rename 重命名文件或目录
 func GetProperty(c *gin.Context) {
 	// 创建上下文
@@ -82,3 +97,18 @@ func GetProperty(c *gin.Context) {
 		c.JSON(200, ErrorResponse(err))
 	}
 }
+
+// Batch 批量执行移动、重命名、删除、创建目录操作
+func Batch(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.BatchService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Execute(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateOnlyOfficeSession 创建 OnlyOffice 文档编辑会话
+func CreateOnlyOfficeSession(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.OnlyOfficeSessionService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Create(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// OnlyOfficeContent 向文档服务器输出文档内容
+func OnlyOfficeContent(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.OnlyOfficeService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Content(ctx, c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// OnlyOfficeCallback 处理文档服务器的保存回调
+func OnlyOfficeCallback(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.OnlyOfficeService
+	if err := c.ShouldBindUri(&service); err != nil {
+		c.JSON(200, gin.H{"error": 1})
+		return
+	}
+
+	if err := service.Callback(ctx, c); err != nil {
+		util.Log().Debug("OnlyOffice callback failed: %s", err)
+		c.JSON(200, gin.H{"error": 1})
+		return
+	}
+
+	c.JSON(200, gin.H{"error": 0})
+}
+
+// ListFileVersions 列出文件的历史版本
+func ListFileVersions(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.ListVersions(ctx, c)
+	c.JSON(200, res)
+}
+
+// RestoreFileVersion 将文件恢复为指定的历史版本
+func RestoreFileVersion(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileVersionService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Restore(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
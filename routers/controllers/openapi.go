@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	_ "embed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec 构建时由 go generate 生成的 OpenAPI 文档
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPISpec 获取 API 的 OpenAPI 3 描述文档
+func OpenAPISpec(c *gin.Context) {
+	c.Data(200, "application/json; charset=utf-8", openAPISpec)
+}
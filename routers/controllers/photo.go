@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+	"github.com/gin-gonic/gin"
+)
+
+// PhotoMeta 获取照片拍摄信息
+func PhotoMeta(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.FileIDService
+	res := service.PhotoMeta(ctx, c)
+	c.JSON(200, res)
+}
+
+// PhotoTimeline 获取照片时间线
+func PhotoTimeline(c *gin.Context) {
+	var service explorer.PhotoTimelineService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Timeline(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ListPhotoCameras 列出拍摄照片使用过的相机
+func ListPhotoCameras(c *gin.Context) {
+	c.JSON(200, explorer.ListPhotoCameras(c))
+}
+
+// ScanPhotos 为尚未提取拍摄信息的照片提取信息
+func ScanPhotos(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c.JSON(200, explorer.ScanPhotos(ctx, c))
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/cloudreve/Cloudreve/v3/service/share"
@@ -175,8 +176,13 @@ func GetShareDocPreview(c *gin.Context) {
 
 // ListSharedFolder 列出分享的目录下的对象
 func ListSharedFolder(c *gin.Context) {
-	var service share.Service
-	if err := c.ShouldBindUri(&service); err == nil {
+	var service share.ListService
+	if err := c.ShouldBindUri(&service); err != nil {
+		c.JSON(200, ErrorResponse(err))
+		return
+	}
+
+	if err := c.ShouldBindQuery(&service); err == nil {
 		res := service.List(c)
 		c.JSON(200, res)
 	} else {
@@ -212,6 +218,109 @@ func ArchiveShare(c *gin.Context) {
 	}
 }
 
+// ArchiveShareAll 打包下载整个分享目录
+func ArchiveShareAll(c *gin.Context) {
+	var service share.ArchiveAllService
+	res := service.Archive(c)
+	c.JSON(200, res)
+}
+
+// ShareQRCode 获取分享链接二维码
+func ShareQRCode(c *gin.Context) {
+	var service share.QRCodeService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Render(c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ShareOEmbed 获取分享的 oEmbed 信息
+func ShareOEmbed(c *gin.Context) {
+	var service share.OEmbedService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Get(c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ShareEmbedPlayer 分享嵌入播放器
+func ShareEmbedPlayer(c *gin.Context) {
+	var service share.EmbedService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Player(c)
+		if res.Code >= 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ShareEmbedContent 分享嵌入媒体内容
+func ShareEmbedContent(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service share.EmbedService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Content(ctx, c)
+		// 是否需要重定向
+		if res.Code == -301 {
+			c.Redirect(302, res.Data.(string))
+			return
+		}
+		// 是否有错误发生
+		if res.Code != 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// Gallery 浏览或下载站点展示目录中的内容
+func Gallery(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service share.GalleryService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Serve(ctx, c)
+		// 是否需要重定向
+		if res.Code == -301 {
+			c.Redirect(302, res.Data.(string))
+			return
+		}
+		// 是否有错误发生
+		if res.Code != -1 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// SaveShare 转存分享至我的文件
+func SaveShare(c *gin.Context) {
+	var service share.SaveService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Save(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // ShareThumb 获取分享目录下文件的缩略图
 func ShareThumb(c *gin.Context) {
 	var service share.Service
@@ -235,3 +344,91 @@ func GetUserShare(c *gin.Context) {
 		c.JSON(200, ErrorResponse(err))
 	}
 }
+
+// ReportShare 举报分享
+func ReportShare(c *gin.Context) {
+	var service share.ReportService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Report(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// CreateShareUploadSession 在文件收集分享中创建上传会话
+func CreateShareUploadSession(c *gin.Context) {
+	var service share.UploadSessionService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ShareUpload 文件收集分享本机分片上传
+func ShareUpload(c *gin.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service share.UploadChunkService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Upload(ctx, c)
+		c.JSON(200, res)
+		request.BlackHole(c.Request.Body)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ShareAnalytics 获取分享访问统计
+func ShareAnalytics(c *gin.Context) {
+	var service share.AnalyticsService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Summary(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ShareAnalyticsLogs 列出分享访问记录
+func ShareAnalyticsLogs(c *gin.Context) {
+	var service share.AnalyticsService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Logs(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// ListGuestUploads 列出文件收集中待审核的访客上传
+func ListGuestUploads(c *gin.Context) {
+	var service share.GuestUploadListService
+	res := service.List(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// ApproveGuestUpload 通过访客上传
+func ApproveGuestUpload(c *gin.Context) {
+	var service share.GuestUploadService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Approve(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// RejectGuestUpload 拒绝访客上传
+func RejectGuestUpload(c *gin.Context) {
+	var service share.GuestUploadService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Reject(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
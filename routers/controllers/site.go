@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"net/http"
+
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/health"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/cloudreve/Cloudreve/v3/pkg/wopi"
@@ -12,12 +15,13 @@ import (
 
 // SiteConfig 获取站点全局配置
 func SiteConfig(c *gin.Context) {
-	siteConfig := model.GetSettingByNames(
+	siteConfig := CurrentTenant(c).GetSettingByNames(
 		"siteName",
 		"login_captcha",
 		"reg_captcha",
 		"email_active",
 		"forget_captcha",
+		"share_captcha",
 		"email_active",
 		"themes",
 		"defaultTheme",
@@ -27,7 +31,10 @@ func SiteConfig(c *gin.Context) {
 		"captcha_ReCaptchaKey",
 		"captcha_type",
 		"captcha_TCaptcha_CaptchaAppId",
+		"captcha_HCaptchaKey",
+		"captcha_TurnstileKey",
 		"register_enabled",
+		"register_invite_only",
 		"show_app_promotion",
 	)
 
@@ -59,6 +66,24 @@ func Ping(c *gin.Context) {
 	})
 }
 
+// Healthz 存活检查，数据库或缓存不可用时返回 503
+func Healthz(c *gin.Context) {
+	writeHealthReport(c, health.Liveness(c.Request.Context()))
+}
+
+// Readyz 就绪检查，额外报告存储策略和离线下载节点的状态
+func Readyz(c *gin.Context) {
+	writeHealthReport(c, health.Readiness(c.Request.Context()))
+}
+
+func writeHealthReport(c *gin.Context, report *health.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
 // Captcha 获取验证码
 func Captcha(c *gin.Context) {
 	options := model.GetSettingByNames(
@@ -102,7 +127,7 @@ func Captcha(c *gin.Context) {
 
 // Manifest 获取manifest.json
 func Manifest(c *gin.Context) {
-	options := model.GetSettingByNames(
+	options := CurrentTenant(c).GetSettingByNames(
 		"siteName",
 		"siteTitle",
 		"pwa_small_icon",
@@ -201,6 +201,39 @@ func SlaveSelectTask(c *gin.Context) {
 	}
 }
 
+// SlavePauseAria2Task 暂停从机离线下载任务
+func SlavePauseAria2Task(c *gin.Context) {
+	var service serializer.SlaveAria2Call
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := aria2.SlavePause(c, &service)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// SlaveResumeAria2Task 恢复从机离线下载任务
+func SlaveResumeAria2Task(c *gin.Context) {
+	var service serializer.SlaveAria2Call
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := aria2.SlaveResume(c, &service)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// SlaveAria2SpeedLimit 设置从机离线下载任务的最大下载速度
+func SlaveAria2SpeedLimit(c *gin.Context) {
+	var service serializer.SlaveAria2Call
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := aria2.SlaveSpeedLimit(c, &service)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // SlaveCreateTransferTask 从机创建中转任务
 func SlaveCreateTransferTask(c *gin.Context) {
 	var service serializer.SlaveTransferReq
@@ -212,6 +245,17 @@ func SlaveCreateTransferTask(c *gin.Context) {
 	}
 }
 
+// SlaveGenerateMeta 从机生成缩略图与元数据
+func SlaveGenerateMeta(c *gin.Context) {
+	var service serializer.SlaveMetaReq
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := explorer.CreateMetaTask(c, &service)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // SlaveNotificationPush 处理从机发送的消息推送
 func SlaveNotificationPush(c *gin.Context) {
 	var service node.SlaveNotificationService
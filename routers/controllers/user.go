@@ -6,8 +6,10 @@ import (
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/authn"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
 	"github.com/cloudreve/Cloudreve/v3/pkg/request"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
 	"github.com/cloudreve/Cloudreve/v3/pkg/thumb"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/cloudreve/Cloudreve/v3/service/user"
@@ -18,7 +20,7 @@ import (
 // StartLoginAuthn 开始注册WebAuthn登录
 func StartLoginAuthn(c *gin.Context) {
 	userName := c.Param("username")
-	expectedUser, err := model.GetActiveUserByEmail(userName)
+	expectedUser, err := model.GetActiveUserByEmail(c.GetUint("tenant_id"), userName)
 	if err != nil {
 		c.JSON(200, serializer.Err(serializer.CodeUserNotFound, "", err))
 		return
@@ -52,12 +54,17 @@ func StartLoginAuthn(c *gin.Context) {
 // FinishLoginAuthn 完成注册WebAuthn登录
 func FinishLoginAuthn(c *gin.Context) {
 	userName := c.Param("username")
-	expectedUser, err := model.GetActiveUserByEmail(userName)
+	expectedUser, err := model.GetActiveUserByEmail(c.GetUint("tenant_id"), userName)
 	if err != nil {
 		c.JSON(200, serializer.Err(serializer.CodeUserNotFound, "", err))
 		return
 	}
 
+	if err := netpolicy.Check(&expectedUser.Group, c.ClientIP(), c.Request); err != nil {
+		c.JSON(200, serializer.Err(serializer.CodeNetworkRestricted, err.Error(), err))
+		return
+	}
+
 	sessionDataJSON := util.GetSession(c, "registration-session").([]byte)
 
 	var sessionData webauthn.SessionData
@@ -215,10 +222,45 @@ func UserActivate(c *gin.Context) {
 
 // UserSignOut 用户退出登录
 func UserSignOut(c *gin.Context) {
+	sessionstore.Forget(CurrentUser(c).ID, util.SessionID(c))
 	util.DeleteSession(c, "user_id")
+	util.DeleteSession(c, "impersonator_id")
+	util.DeleteSession(c, "impersonate_expires")
 	c.JSON(200, serializer.Response{})
 }
 
+// UserStopImpersonation 结束模拟登录
+func UserStopImpersonation(c *gin.Context) {
+	var service user.ImpersonationService
+	res := service.Stop(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// UserListSessions 列出用户的活跃会话
+func UserListSessions(c *gin.Context) {
+	var service user.DeviceService
+	res := service.List(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// UserRevokeSession 注销指定会话
+func UserRevokeSession(c *gin.Context) {
+	var service user.DeviceRevokeService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Revoke(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserRevokeAllSessions 注销当前会话以外的所有会话
+func UserRevokeAllSessions(c *gin.Context) {
+	var service user.DeviceService
+	res := service.RevokeAll(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
 // UserMe 获取当前登录的用户
 func UserMe(c *gin.Context) {
 	currUser := CurrentUser(c)
@@ -233,6 +275,17 @@ func UserStorage(c *gin.Context) {
 	c.JSON(200, res)
 }
 
+// UserTraffic 获取用户流量统计
+func UserTraffic(c *gin.Context) {
+	var service user.TrafficService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.Traffic(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // UserTasks 获取任务队列
 func UserTasks(c *gin.Context) {
 	var service user.SettingListService
@@ -244,6 +297,128 @@ func UserTasks(c *gin.Context) {
 	}
 }
 
+// UserTaskProgress 获取任务进度
+func UserTaskProgress(c *gin.Context) {
+	var service user.TaskService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Progress(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserCancelTask 取消任务
+func UserCancelTask(c *gin.Context) {
+	var service user.TaskService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Cancel(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserNotifications 列出站内通知
+func UserNotifications(c *gin.Context) {
+	var service user.NotificationListService
+	if err := c.ShouldBindQuery(&service); err == nil {
+		res := service.List(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserAnnouncements 列出站点公告
+func UserAnnouncements(c *gin.Context) {
+	var service user.AnnouncementService
+	res := service.List(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// UserReadAnnouncement 标记公告已读
+func UserReadAnnouncement(c *gin.Context) {
+	var service user.AnnouncementReadService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Read(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserReadNotifications 标记站内通知已读
+func UserReadNotifications(c *gin.Context) {
+	var service user.NotificationReadService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Read(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserCreateExport 创建用户数据导出任务
+func UserCreateExport(c *gin.Context) {
+	var service user.ExportCreateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserDownloadExport 下载用户数据导出
+func UserDownloadExport(c *gin.Context) {
+	var service user.ExportDownloadService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Download(c, CurrentUser(c))
+		if res.Code != 0 {
+			c.JSON(200, res)
+		}
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserRequestDeletion 申请注销账户
+func UserRequestDeletion(c *gin.Context) {
+	var service user.DeletionRequestService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Request(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserCancelDeletion 取消注销账户
+func UserCancelDeletion(c *gin.Context) {
+	var service user.DeletionCancelService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Cancel(c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserListInvite 列出用户生成的邀请码
+func UserListInvite(c *gin.Context) {
+	var service user.InviteCreateService
+	res := service.List(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// UserCreateInvite 生成邀请码
+func UserCreateInvite(c *gin.Context) {
+	var service user.InviteCreateService
+	res := service.Create(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
 // UserSetting 获取用户设定
 func UserSetting(c *gin.Context) {
 	var service user.SettingService
@@ -349,6 +524,10 @@ func UpdateOption(c *gin.Context) {
 			subService = &user.DeleteWebAuthn{}
 		case "theme":
 			subService = &user.ThemeChose{}
+		case "notification":
+			subService = &user.NotificationPreference{}
+		case "folder_templates":
+			subService = &user.FolderTemplateSetting{}
 		default:
 			subService = &user.ChangerNick{}
 		}
@@ -378,6 +557,28 @@ func UserInit2FA(c *gin.Context) {
 	}
 }
 
+// UserRegenerateRecoveryCodes 重新生成二步验证恢复代码
+func UserRegenerateRecoveryCodes(c *gin.Context) {
+	var service user.RecoveryCodeService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Regenerate(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UserRevokeRecoveryCodes 作废二步验证恢复代码
+func UserRevokeRecoveryCodes(c *gin.Context) {
+	var service user.RecoveryCodeService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Revoke(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
 // UserPrepareCopySession generates URL for copy session
 func UserPrepareCopySession(c *gin.Context) {
 	var service user.CopySessionService
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+	"github.com/gin-gonic/gin"
+)
+
+// ListVaults 列出保险库
+func ListVaults(c *gin.Context) {
+	var service explorer.VaultListService
+	res := service.Vaults(c, CurrentUser(c))
+	c.JSON(200, res)
+}
+
+// CreateVault 新建保险库
+func CreateVault(c *gin.Context) {
+	// 创建上下文
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var service explorer.VaultCreateService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Create(ctx, c)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UpdateVaultKey 更新保险库的包装密钥
+func UpdateVaultKey(c *gin.Context) {
+	var vault explorer.VaultService
+	if err := c.ShouldBindUri(&vault); err != nil {
+		c.JSON(200, ErrorResponse(err))
+		return
+	}
+
+	var service explorer.VaultKeyService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.UpdateKey(c, CurrentUser(c), vault.ID)
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// DeleteVault 删除保险库
+func DeleteVault(c *gin.Context) {
+	var service explorer.VaultService
+	if err := c.ShouldBindUri(&service); err == nil {
+		res := service.Delete(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
+
+// UpdateVaultFileMeta 保存保险库文件的加密元数据
+func UpdateVaultFileMeta(c *gin.Context) {
+	var service explorer.VaultFileMetaService
+	if err := c.ShouldBindJSON(&service); err == nil {
+		res := service.Update(c, CurrentUser(c))
+		c.JSON(200, res)
+	} else {
+		c.JSON(200, ErrorResponse(err))
+	}
+}
@@ -38,9 +38,12 @@ func PutFile(c *gin.Context) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	service := &explorer.FileIDService{}
-	res := service.PutContent(ctx, c)
+	var service explorer.WopiService
+	res := service.PutFile(ctx, c)
 	switch res.Code {
+	case serializer.CodeConflict:
+		c.Status(http.StatusConflict)
+		c.Header(wopi.ServerErrorHeader, res.Error)
 	case serializer.CodeFileTooLarge:
 		c.Status(http.StatusRequestEntityTooLarge)
 		c.Header(wopi.ServerErrorHeader, res.Error)
@@ -59,12 +62,33 @@ func PutFile(c *gin.Context) {
 func ModifyFile(c *gin.Context) {
 	action := c.GetHeader(wopi.OverwriteHeader)
 	switch action {
-	case wopi.MethodLock, wopi.MethodRefreshLock, wopi.MethodUnlock:
+	case wopi.MethodLock, wopi.MethodRefreshLock, wopi.MethodUnlock, wopi.MethodGetLock:
+		var service explorer.WopiService
+		current, err := service.Lock(c, action)
+		if err == wopi.ErrLockMismatch {
+			c.Header(wopi.LockHeader, current)
+			c.Status(http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			c.Header(wopi.ServerErrorHeader, err.Error())
+			return
+		}
+
+		if action == wopi.MethodGetLock {
+			c.Header(wopi.LockHeader, current)
+		}
 		c.Status(http.StatusOK)
-		return
 	case wopi.MethodRename:
 		var service explorer.WopiService
 		err := service.Rename(c)
+		if err == wopi.ErrLockMismatch {
+			c.Status(http.StatusConflict)
+			return
+		}
+
 		if err != nil {
 			c.Status(http.StatusInternalServerError)
 			c.Header(wopi.ServerErrorHeader, err.Error())
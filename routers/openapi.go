@@ -0,0 +1,26 @@
+package routers
+
+import (
+	"encoding/json"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/openapi"
+)
+
+// OpenAPISpecPath 生成的 OpenAPI 文档相对于 routers 目录的路径
+const OpenAPISpecPath = "controllers/openapi.json"
+
+// GenerateOpenAPI 根据主机模式路由表与控制器源码生成 OpenAPI 文档，root 为仓库根目录
+func GenerateOpenAPI(root string) ([]byte, error) {
+	src, err := openapi.NewSource(root, "github.com/cloudreve/Cloudreve/v3", "routers/controllers")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := openapi.Build(InitMasterRouter().Routes(), src, "/api/v3", "Cloudreve API", conf.BackendVersion)
+	res, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(res, '\n'), nil
+}
@@ -0,0 +1,24 @@
+//go:build ignore
+
+// 生成 OpenAPI 文档，在 routers 目录下执行 go generate
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/cloudreve/Cloudreve/v3/routers"
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	gin.SetMode(gin.ReleaseMode)
+	spec, err := routers.GenerateOpenAPI("..")
+	if err != nil {
+		log.Fatalf("Failed to generate OpenAPI spec: %s", err)
+	}
+
+	if err := os.WriteFile(routers.OpenAPISpecPath, spec, 0644); err != nil {
+		log.Fatalf("Failed to write OpenAPI spec: %s", err)
+	}
+}
@@ -0,0 +1,26 @@
+package routers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	asserts := assert.New(t)
+	router := InitMasterRouter()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v3/openapi.json", nil)
+	router.ServeHTTP(w, req)
+	asserts.Equal(200, w.Code)
+	asserts.True(json.Valid(w.Body.Bytes()))
+
+	// 已提交的文档与当前路由表一致
+	expected, err := GenerateOpenAPI("..")
+	asserts.NoError(err)
+	asserts.Equal(string(expected), w.Body.String(), "openapi.json is outdated, run `go generate ./routers`")
+}
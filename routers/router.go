@@ -11,10 +11,11 @@ import (
 	wopi2 "github.com/cloudreve/Cloudreve/v3/pkg/wopi"
 	"github.com/cloudreve/Cloudreve/v3/routers/controllers"
 	"github.com/gin-contrib/cors"
-	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 )
 
+//go:generate go run openapi_gen.go
+
 // InitRouter 初始化路由
 func InitRouter() *gin.Engine {
 	if conf.SystemConfig.Mode == "master" {
@@ -69,6 +70,8 @@ func InitSlaveRouter() *gin.Engine {
 		v3.POST("delete", controllers.SlaveDelete)
 		// 列出文件
 		v3.POST("list", controllers.SlaveList)
+		// 生成缩略图与元数据
+		v3.POST("meta", controllers.SlaveGenerateMeta)
 
 		// 离线下载
 		aria2 := v3.Group("aria2")
@@ -84,6 +87,12 @@ func InitSlaveRouter() *gin.Engine {
 			aria2.POST("select", controllers.SlaveSelectTask)
 			// 删除任务临时文件
 			aria2.POST("delete", controllers.SlaveDeleteTempFile)
+			// 暂停任务
+			aria2.POST("pause", controllers.SlavePauseAria2Task)
+			// 恢复任务
+			aria2.POST("resume", controllers.SlaveResumeAria2Task)
+			// 设置任务下载速度
+			aria2.POST("speed", controllers.SlaveAria2SpeedLimit)
 		}
 
 		// 异步任务
@@ -121,15 +130,23 @@ func InitMasterRouter() *gin.Engine {
 	/*
 		静态资源
 	*/
-	r.Use(gzip.Gzip(gzip.DefaultCompression, gzip.WithExcludedPaths([]string{"/api/"})))
+	r.Use(middleware.Compress())
+	// 按访问域名确定租户
+	r.Use(middleware.ResolveTenant())
 	r.Use(middleware.FrontendFileHandler())
 	r.GET("manifest.json", controllers.Manifest)
 
+	// 健康检查，供负载均衡和监控使用
+	r.GET("healthz", middleware.CacheControl(), controllers.Healthz)
+	r.GET("readyz", middleware.CacheControl(), controllers.Readyz)
+
 	v3 := r.Group("/api/v3")
 
 	/*
 		中间件
 	*/
+	// 错误消息多语言
+	v3.Use(middleware.Localize())
 	v3.Use(middleware.Session(conf.SystemConfig.SessionSecret))
 	// 跨域相关
 	InitCORS(r)
@@ -151,11 +168,24 @@ func InitMasterRouter() *gin.Engine {
 		source := r.Group("f")
 		{
 			source.GET(":id/:name",
+				middleware.NetworkPolicy(),
 				middleware.HashID(hashid.SourceLinkID),
 				middleware.ValidateSourceLink(),
 				controllers.AnonymousPermLink)
 		}
 
+		// 站点展示
+		gallery := r.Group("g")
+		{
+			gallery.GET(":id/*path",
+				middleware.NetworkPolicy(),
+				middleware.ShareAvailable(),
+				controllers.Gallery)
+		}
+
+		// API 描述文档
+		v3.GET("openapi.json", controllers.OpenAPISpec)
+
 		// 全局设置相关
 		site := v3.Group("site")
 		{
@@ -171,7 +201,11 @@ func InitMasterRouter() *gin.Engine {
 		user := v3.Group("user")
 		{
 			// 用户登录
-			user.POST("session", middleware.CaptchaRequired("login_captcha"), controllers.UserLogin)
+			user.POST("session",
+				middleware.RateLimit("login"),
+				middleware.CaptchaRequired("login_captcha"),
+				controllers.UserLogin,
+			)
 			// 用户注册
 			user.POST("",
 				middleware.IsFunctionEnabled("register_enabled"),
@@ -179,7 +213,13 @@ func InitMasterRouter() *gin.Engine {
 				controllers.UserRegister,
 			)
 			// 用二步验证户登录
-			user.POST("2fa", controllers.User2FALogin)
+			user.POST("2fa", middleware.RateLimit("login"), controllers.User2FALogin)
+			// 取消注销账户
+			user.POST("deletion/cancel",
+				middleware.RateLimit("login"),
+				middleware.CaptchaRequired("login_captcha"),
+				controllers.UserCancelDeletion,
+			)
 			// 发送密码重设邮件
 			user.POST("reset", middleware.CaptchaRequired("forget_captcha"), controllers.UserSendReset)
 			// 通过邮件里的链接重设密码
@@ -198,6 +238,7 @@ func InitMasterRouter() *gin.Engine {
 			// WebAuthn登陆
 			user.POST("authn/finish/:username",
 				middleware.IsFunctionEnabled("authn_enabled"),
+				middleware.RateLimit("login"),
 				controllers.FinishLoginAuthn,
 			)
 			// 获取用户主页展示用分享
@@ -217,7 +258,7 @@ func InitMasterRouter() *gin.Engine {
 		sign := v3.Group("")
 		sign.Use(middleware.SignRequired(auth.General))
 		{
-			file := sign.Group("file")
+			file := sign.Group("file", middleware.NetworkPolicy())
 			{
 				// 文件外链（直接输出文件数据）
 				file.GET("get/:id/:name",
@@ -244,6 +285,9 @@ func InitMasterRouter() *gin.Engine {
 			)
 		}
 
+		// 通过预签名 URL 上传文件，签名在 URL 中校验
+		v3.PUT("file/presigned/:sessionID", controllers.PresignedUpload)
+
 		// 从机的 RPC 通信
 		slave := v3.Group("slave")
 		slave.Use(middleware.SlaveRPCSignRequired(cluster.Default))
@@ -274,6 +318,13 @@ func InitMasterRouter() *gin.Engine {
 				middleware.RemoteCallbackAuth(),
 				controllers.RemoteCallback,
 			)
+			// 从机推送缩略图与元数据
+			callback.POST(
+				"meta/:id",
+				middleware.HashID(hashid.FileID),
+				middleware.SlaveMetaCallbackAuth(),
+				controllers.SlaveMetaCallback,
+			)
 			// 七牛策略上传回调
 			callback.POST(
 				"qiniu/:sessionID",
@@ -334,13 +385,19 @@ func InitMasterRouter() *gin.Engine {
 		}
 
 		// 分享相关
-		share := v3.Group("share", middleware.ShareAvailable())
+		share := v3.Group("share", middleware.NetworkPolicy(), middleware.ShareAvailable())
 		{
 			// 获取分享
-			share.GET("info/:id", controllers.GetShare)
+			share.GET("info/:id",
+				middleware.SharePasswordRateLimit(),
+				middleware.SharePasswordCaptchaRequired(),
+				controllers.GetShare,
+			)
 			// 创建文件下载会话
 			share.PUT("download/:id",
+				middleware.RateLimit("download"),
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				middleware.BeforeShareDownload(),
 				controllers.GetShareDownload,
 			)
@@ -348,6 +405,7 @@ func InitMasterRouter() *gin.Engine {
 			share.GET("preview/:id",
 				middleware.CSRFCheck(),
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				middleware.ShareCanPreview(),
 				middleware.BeforeShareDownload(),
 				controllers.PreviewShare,
@@ -355,6 +413,7 @@ func InitMasterRouter() *gin.Engine {
 			// 取得Office文档预览地址
 			share.GET("doc/:id",
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				middleware.ShareCanPreview(),
 				middleware.BeforeShareDownload(),
 				controllers.GetShareDocPreview,
@@ -362,38 +421,92 @@ func InitMasterRouter() *gin.Engine {
 			// 获取文本文件内容
 			share.GET("content/:id",
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				middleware.BeforeShareDownload(),
 				controllers.PreviewShareText,
 			)
 			// 分享目录列文件
 			share.GET("list/:id/*path",
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				controllers.ListSharedFolder,
 			)
 			// 分享目录搜索
 			share.GET("search/:id/:type/:keywords",
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				controllers.SearchSharedFolder,
 			)
 			// 归档打包下载
 			share.POST("archive/:id",
+				middleware.RateLimit("download"),
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				middleware.BeforeShareDownload(),
 				controllers.ArchiveShare,
 			)
+			// 打包下载整个分享目录
+			share.POST("archive/:id/all",
+				middleware.RateLimit("download"),
+				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
+				middleware.BeforeShareDownload(),
+				controllers.ArchiveShareAll,
+			)
 			// 获取README文本文件内容
 			share.GET("readme/:id",
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				controllers.PreviewShareReadme,
 			)
 			// 获取缩略图
 			share.GET("thumb/:id/:file",
 				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
 				middleware.ShareCanPreview(),
 				controllers.ShareThumb,
 			)
+			// 转存至我的文件
+			share.POST("save/:id",
+				middleware.AuthRequired(),
+				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
+				controllers.SaveShare,
+			)
+			// 文件收集创建上传会话
+			share.PUT("upload/:id",
+				middleware.RateLimit("share_upload"),
+				middleware.CheckShareUnlocked(),
+				middleware.ShareUploadOnly(),
+				controllers.CreateShareUploadSession,
+			)
+			// 文件收集本机分片上传
+			share.POST("upload/:id/:sessionId/:index",
+				middleware.CheckShareUnlocked(),
+				middleware.ShareUploadOnly(),
+				controllers.ShareUpload,
+			)
+			// 分享嵌入播放器
+			share.GET("embed/:id", controllers.ShareEmbedPlayer)
+			// 分享嵌入媒体内容
+			share.GET("embed/:id/content",
+				middleware.CheckShareUnlocked(),
+				middleware.ShareBrowsable(),
+				middleware.ShareCanPreview(),
+				middleware.BeforeShareDownload(),
+				controllers.ShareEmbedContent,
+			)
+			// 分享链接二维码
+			share.GET("qrcode/:id", controllers.ShareQRCode)
+			// 举报分享
+			share.POST("report/:id",
+				middleware.RateLimit("report"),
+				controllers.ReportShare,
+			)
 			// 搜索公共分享
 			v3.Group("share").GET("search", controllers.SearchShare)
+			// oEmbed
+			v3.Group("share").GET("oembed", controllers.ShareOEmbed)
 		}
 
 		wopi := v3.Group(
@@ -412,6 +525,21 @@ func InitMasterRouter() *gin.Engine {
 			wopi.POST("files/:id", middleware.WopiWriteAccess(), controllers.ModifyFile)
 		}
 
+		onlyoffice := v3.Group("onlyoffice")
+		{
+			// 获取文档内容
+			onlyoffice.GET("content/:session", controllers.OnlyOfficeContent)
+			// 文档保存回调
+			onlyoffice.POST("callback/:session", controllers.OnlyOfficeCallback)
+		}
+
+		// 电子书、漫画阅读会话
+		reader := v3.Group("reader")
+		{
+			// 获取章节或页面
+			reader.GET(":session/entry/*path", controllers.ReaderEntry)
+		}
+
 		// 需要登录保护的
 		auth := v3.Group("")
 		auth.Use(middleware.AuthRequired())
@@ -431,6 +559,8 @@ func InitMasterRouter() *gin.Engine {
 				admin.GET("groups", controllers.AdminGetGroups)
 				// 重新加载子服务
 				admin.GET("reload/:service", controllers.AdminReloadService)
+				// 获取定时任务状态
+				admin.GET("crontab", controllers.AdminCrontabStatus)
 				// 测试设置
 				test := admin.Group("test")
 				{
@@ -440,6 +570,59 @@ func InitMasterRouter() *gin.Engine {
 					test.POST("thumb", controllers.AdminTestThumbGenerator)
 				}
 
+				// 邮件模板
+				mailTemplate := admin.Group("mailTemplate")
+				{
+					// 获取模板
+					mailTemplate.POST("get", controllers.AdminGetMailTemplate)
+					// 保存模板
+					mailTemplate.PUT("", controllers.AdminSaveMailTemplate)
+					// 预览模板
+					mailTemplate.POST("preview", controllers.AdminPreviewMailTemplate)
+					// 发送测试邮件
+					mailTemplate.POST("test", controllers.AdminTestMailTemplate)
+				}
+				// 列出邮件投递记录
+				admin.POST("mailLog", controllers.AdminListMailLog)
+				// 列出审计记录
+				admin.POST("auditLog", controllers.AdminListAuditLog)
+
+				analytics := admin.Group("analytics")
+				{
+					// 容量使用
+					analytics.GET("storage", controllers.AdminAnalyticsStorage)
+					// 流量与活跃用户
+					analytics.GET("traffic", controllers.AdminAnalyticsTraffic)
+					// 下载量最多的文件
+					analytics.GET("files", controllers.AdminAnalyticsFiles)
+					// 分享统计
+					analytics.GET("shares", controllers.AdminAnalyticsShares)
+					// 文件类型分布与容量增长
+					analytics.GET("content", controllers.AdminAnalyticsContent)
+				}
+
+				announcement := admin.Group("announcement")
+				{
+					// 列出公告
+					announcement.POST("list", controllers.AdminListAnnouncement)
+					// 发布公告
+					announcement.POST("", controllers.AdminCreateAnnouncement)
+					// 删除公告
+					announcement.DELETE(":id", controllers.AdminDeleteAnnouncement)
+				}
+
+				tenant := admin.Group("tenant")
+				{
+					// 列出租户
+					tenant.GET("", controllers.AdminListTenant)
+					// 创建租户
+					tenant.POST("", controllers.AdminCreateTenant)
+					// 保存租户
+					tenant.PUT(":id", controllers.AdminUpdateTenant)
+					// 删除租户
+					tenant.DELETE(":id", controllers.AdminDeleteTenant)
+				}
+
 				// 离线下载相关
 				aria2 := admin.Group("aria2")
 				{
@@ -502,6 +685,16 @@ func InitMasterRouter() *gin.Engine {
 					user.POST("delete", controllers.AdminDeleteUser)
 					// 封禁/解封用户
 					user.PATCH("ban/:id", controllers.AdminBanUser)
+					// 通过注册审核
+					user.PATCH("approve/:id", controllers.AdminApproveUser)
+					// 重置用户二步验证
+					user.PATCH("2fa", controllers.AdminResetUser2FA)
+					// 从 CSV 导入用户
+					user.POST("import", controllers.AdminImportUser)
+					// 导出用户列表
+					user.GET("export", controllers.AdminExportUser)
+					// 模拟用户登录
+					user.POST("impersonate", controllers.AdminImpersonateUser)
 				}
 
 				file := admin.Group("file")
@@ -512,6 +705,8 @@ func InitMasterRouter() *gin.Engine {
 					file.GET("preview/:id", middleware.Sandbox(), controllers.AdminGetFile)
 					// 删除
 					file.POST("delete", controllers.AdminDeleteFile)
+					// 强制解除文件锁
+					file.POST("unlock", controllers.AdminUnlockFile)
 					// 列出用户或外部文件系统目录
 					file.GET("folders/:type/:id/*path",
 						controllers.AdminListFolders)
@@ -525,6 +720,70 @@ func InitMasterRouter() *gin.Engine {
 					share.POST("delete", controllers.AdminDeleteShare)
 				}
 
+				thumb := admin.Group("thumb")
+				{
+					// 获取缩略图缓存占用
+					thumb.GET("", controllers.AdminThumbStats)
+					// 清除缩略图缓存
+					thumb.POST("purge", controllers.AdminPurgeThumbs)
+					// 淘汰超出上限的缩略图
+					thumb.POST("evict", controllers.AdminEvictThumbs)
+				}
+
+				quarantine := admin.Group("quarantine")
+				{
+					// 列出隔离记录
+					quarantine.POST("list", controllers.AdminListQuarantine)
+					// 解除隔离
+					quarantine.PATCH(":id", controllers.AdminReleaseQuarantine)
+					// 删除被隔离的文件
+					quarantine.DELETE(":id", controllers.AdminDeleteQuarantine)
+				}
+
+				blocklist := admin.Group("blocklist")
+				{
+					// 列出屏蔽哈希
+					blocklist.POST("list", controllers.AdminListBlockedHash)
+					// 添加屏蔽哈希
+					blocklist.POST("", controllers.AdminAddBlockedHash)
+					// 移除屏蔽哈希
+					blocklist.DELETE(":id", controllers.AdminDeleteBlockedHash)
+					// 列出审计日志
+					blocklist.POST("log", controllers.AdminListBlocklistLog)
+				}
+
+				retention := admin.Group("retention")
+				{
+					// 列出保留冻结
+					retention.POST("list", controllers.AdminListRetentionHold)
+					// 设置保留冻结
+					retention.PUT("", controllers.AdminSetRetentionHold)
+					// 解除保留冻结
+					retention.DELETE(":id", controllers.AdminReleaseRetentionHold)
+				}
+
+				invite := admin.Group("invite")
+				{
+					// 列出邀请码
+					invite.POST("list", controllers.AdminListInviteCode)
+					// 生成邀请码
+					invite.POST("", controllers.AdminAddInviteCode)
+					// 删除邀请码
+					invite.DELETE(":id", controllers.AdminDeleteInviteCode)
+				}
+
+				report := admin.Group("report")
+				{
+					// 列出举报
+					report.POST("list", controllers.AdminListReport)
+					// 停用被举报的分享
+					report.PATCH("disable/:id", controllers.AdminDisableReportedShare)
+					// 停用分享并封禁分享者
+					report.PATCH("suspend/:id", controllers.AdminSuspendReportedUser)
+					// 忽略举报
+					report.PATCH("ignore/:id", controllers.AdminIgnoreReport)
+				}
+
 				download := admin.Group("download")
 				{
 					// 列出任务
@@ -539,6 +798,8 @@ func InitMasterRouter() *gin.Engine {
 					task.POST("list", controllers.AdminListTask)
 					// 删除
 					task.POST("delete", controllers.AdminDeleteTask)
+					// 重试失败任务
+					task.POST("retry", controllers.AdminRetryTask)
 					// 新建文件导入任务
 					task.POST("import", controllers.AdminCreateImportTask)
 				}
@@ -549,6 +810,8 @@ func InitMasterRouter() *gin.Engine {
 					node.POST("list", controllers.AdminListNodes)
 					// 列出从机节点
 					node.POST("aria2/test", controllers.AdminTestAria2)
+					// 离线下载节点健康状态
+					node.GET("aria2/health", controllers.AdminAria2Health)
 					// 创建/保存节点
 					node.POST("", controllers.AdminAddNode)
 					// 启用/暂停节点
@@ -566,17 +829,29 @@ func InitMasterRouter() *gin.Engine {
 			{
 				// 当前登录用户信息
 				user.GET("me", controllers.UserMe)
+				// 结束模拟登录
+				user.DELETE("impersonate", controllers.UserStopImpersonation)
 				// 存储信息
 				user.GET("storage", controllers.UserStorage)
+				// 流量统计
+				user.GET("traffic", controllers.UserTraffic)
 				// 退出登录
 				user.DELETE("session", controllers.UserSignOut)
 				// Generate temp URL for copying client-side session, used in adding accounts
 				// for mobile App.
 				user.GET("session", controllers.UserPrepareCopySession)
+				// 站内通知
+				user.GET("notification", controllers.UserNotifications)
+				// 标记站内通知已读
+				user.PATCH("notification", controllers.UserReadNotifications)
+				// 站点公告
+				user.GET("announcement", controllers.UserAnnouncements)
+				// 标记公告已读
+				user.PATCH("announcement/:id", controllers.UserReadAnnouncement)
 
 				// WebAuthn 注册相关
 				authn := user.Group("authn",
-					middleware.IsFunctionEnabled("authn_enabled"))
+					middleware.IsFunctionEnabled("authn_enabled"), middleware.NotImpersonating())
 				{
 					authn.PUT("", controllers.StartRegAuthn)
 					authn.PUT("finish", controllers.FinishRegAuthn)
@@ -587,6 +862,20 @@ func InitMasterRouter() *gin.Engine {
 				{
 					// 任务队列
 					setting.GET("tasks", controllers.UserTasks)
+					// 获取任务进度
+					setting.GET("tasks/:id", controllers.UserTaskProgress)
+					// 取消任务
+					setting.DELETE("tasks/:id", controllers.UserCancelTask)
+					// 导出用户数据
+					setting.POST("export", controllers.UserCreateExport)
+					// 下载导出的用户数据
+					setting.GET("export/:id", controllers.UserDownloadExport)
+					// 申请注销账户
+					setting.POST("deletion", middleware.NotImpersonating(), controllers.UserRequestDeletion)
+					// 列出邀请码
+					setting.GET("invites", controllers.UserListInvite)
+					// 生成邀请码
+					setting.POST("invites", controllers.UserCreateInvite)
 					// 获取当前用户设定
 					setting.GET("", controllers.UserSetting)
 					// 从文件上传头像
@@ -594,9 +883,19 @@ func InitMasterRouter() *gin.Engine {
 					// 设定为Gravatar头像
 					setting.PUT("avatar", controllers.UseGravatar)
 					// 更改用户设定
-					setting.PATCH(":option", controllers.UpdateOption)
+					setting.PATCH(":option", middleware.NotImpersonating(), controllers.UpdateOption)
 					// 获得二步验证初始化信息
-					setting.GET("2fa", controllers.UserInit2FA)
+					setting.GET("2fa", middleware.NotImpersonating(), controllers.UserInit2FA)
+					// 重新生成二步验证恢复代码
+					setting.PUT("2fa/recovery", middleware.NotImpersonating(), controllers.UserRegenerateRecoveryCodes)
+					// 作废二步验证恢复代码
+					setting.DELETE("2fa/recovery", middleware.NotImpersonating(), controllers.UserRevokeRecoveryCodes)
+					// 列出活跃会话
+					setting.GET("sessions", middleware.NotImpersonating(), controllers.UserListSessions)
+					// 注销指定会话
+					setting.DELETE("sessions/:id", middleware.NotImpersonating(), controllers.UserRevokeSession)
+					// 注销其他所有会话
+					setting.DELETE("sessions", middleware.NotImpersonating(), controllers.UserRevokeAllSessions)
 				}
 			}
 
@@ -614,25 +913,75 @@ func InitMasterRouter() *gin.Engine {
 					upload.DELETE(":sessionId", controllers.DeleteUploadSession)
 					// 删除全部上传会话
 					upload.DELETE("", controllers.DeleteAllUploadSession)
+					// 列出进行中的上传会话
+					upload.GET("", controllers.ListUploadSessions)
 				}
 				// 更新文件
 				file.PUT("update/:id", controllers.PutContent)
 				// 创建空白文件
 				file.POST("create", controllers.CreateFile)
+				// 创建预签名上传 URL
+				file.POST("presigned", controllers.CreatePresignedUpload)
 				// 创建文件下载会话
-				file.PUT("download/:id", controllers.CreateDownloadSession)
+				file.PUT("download/:id", middleware.RateLimit("download"), controllers.CreateDownloadSession)
 				// 预览文件
 				file.GET("preview/:id", middleware.Sandbox(), controllers.Preview)
 				// 获取文本文件内容
 				file.GET("content/:id", middleware.Sandbox(), controllers.PreviewText)
 				// 取得Office文档预览地址
 				file.GET("doc/:id", controllers.GetDocPreview)
+				// 创建 OnlyOffice 文档编辑会话
+				file.GET("onlyoffice/:id", controllers.CreateOnlyOfficeSession)
+				// 列出文件历史版本
+				file.GET("version/:id", controllers.ListFileVersions)
+				// 恢复文件历史版本
+				file.POST("version/:id", controllers.RestoreFileVersion)
 				// 获取缩略图
 				file.GET("thumb/:id", controllers.Thumb)
+				// 列出视频外挂字幕
+				file.GET("subtitles/:id", controllers.ListSubtitles)
+				// 获取 WebVTT 格式的字幕
+				file.GET("subtitle/:id", controllers.Subtitle)
+				// 获取音频元数据
+				file.GET("audio/:id", controllers.AudioMeta)
+				// 获取音频封面
+				file.GET("audio/:id/cover", controllers.AudioCover)
+				// 获取照片拍摄信息
+				file.GET("exif/:id", controllers.PhotoMeta)
+				// 获取视频转码主播放列表
+				file.GET("hls/:id", controllers.HLSMaster)
+				// 获取视频转码播放列表及分片
+				file.GET("hls/:id/:quality/:segment", controllers.HLSSegment)
+				// 渲染 Markdown 或 Jupyter Notebook
+				file.GET("render/:id", controllers.Render)
+				// 获取 PDF 页数
+				file.GET("pdf/:id", controllers.PDFInfo)
+				// 获取 PDF 页面预览图
+				file.GET("pdf/:id/:page", controllers.PDFPage)
+				// 创建电子书、漫画阅读会话
+				file.POST("reader/:id", controllers.CreateReaderSession)
+				// 创建文件哈希校验任务
+				file.POST("verify/:id", controllers.VerifyChecksum)
+				// 解冻归档文件
+				file.POST("rehydrate/:id", controllers.RehydrateFile)
+				// 获取文件分块签名
+				file.GET("delta/:id", controllers.DeltaSignature)
+				// 提交文件增量内容
+				file.PUT("delta/:id", controllers.DeltaPatch)
+				// 为文件加锁或刷新锁
+				file.POST("lock/:id", controllers.LockFile)
+				// 解除文件锁
+				file.DELETE("lock/:id", controllers.UnlockFile)
+				// 列出文件变更
+				file.GET("changes", controllers.ListFileChanges)
+				// 创建重复文件分析任务
+				file.POST("duplicates", controllers.FindDuplicates)
+				// 处理重复文件
+				file.POST("duplicates/resolve", controllers.ResolveDuplicates)
 				// 取得文件外链
-				file.POST("source", controllers.GetSource)
+				file.POST("source", middleware.RateLimit("download"), controllers.GetSource)
 				// 打包要下载的文件
-				file.POST("archive", controllers.Archive)
+				file.POST("archive", middleware.RateLimit("download"), controllers.Archive)
 				// 创建文件压缩任务
 				file.POST("compress", controllers.Compress)
 				// 创建文件解压缩任务
@@ -641,6 +990,28 @@ func InitMasterRouter() *gin.Engine {
 				file.GET("search/:type/:keywords", controllers.SearchFile)
 			}
 
+			// 音乐库
+			audio := auth.Group("audio")
+			{
+				// 列出目录下的音频
+				audio.GET("folder", controllers.ListAudioFolder)
+				// 搜索音乐库
+				audio.GET("search", controllers.SearchAudio)
+				// 列出专辑
+				audio.GET("albums", controllers.ListAudioAlbums)
+			}
+
+			// 照片
+			photo := auth.Group("photo")
+			{
+				// 照片时间线
+				photo.GET("timeline", controllers.PhotoTimeline)
+				// 列出使用过的相机
+				photo.GET("cameras", controllers.ListPhotoCameras)
+				// 提取照片拍摄信息
+				photo.POST("scan", controllers.ScanPhotos)
+			}
+
 			// 离线下载任务
 			aria2 := auth.Group("aria2")
 			{
@@ -663,6 +1034,8 @@ func InitMasterRouter() *gin.Engine {
 			{
 				// 创建目录
 				directory.PUT("", controllers.CreateDirectory)
+				// 按目录模板批量创建目录
+				directory.PUT("template", controllers.CreateDirectoryFromTemplate)
 				// 列出目录下内容
 				directory.GET("*path", controllers.ListDirectory)
 			}
@@ -678,10 +1051,23 @@ func InitMasterRouter() *gin.Engine {
 				object.POST("copy", controllers.Copy)
 				// 重命名对象
 				object.POST("rename", controllers.Rename)
+				// 预览批量重命名结果
+				object.POST("rename/preview", controllers.PreviewRename)
 				// 获取对象属性
 				object.GET("property/:id", controllers.GetProperty)
+				// 获取目录容量上限
+				object.GET("quota/:id", middleware.HashID(hashid.FolderID), controllers.GetFolderQuota)
+				// 设定目录容量上限
+				object.PUT("quota/:id", middleware.HashID(hashid.FolderID), controllers.SetFolderQuota)
+				// 平铺列出目录子树
+				object.GET("tree/:id", middleware.HashID(hashid.FolderID), controllers.ListSubtree)
+				// 导出目录子树
+				object.GET("tree/:id/export", middleware.HashID(hashid.FolderID), controllers.ExportSubtree)
 			}
 
+			// 批量执行文件操作
+			auth.POST("batch", controllers.Batch)
+
 			// 分享
 			share := auth.Group("share")
 			{
@@ -699,6 +1085,16 @@ func InitMasterRouter() *gin.Engine {
 				share.DELETE(":id",
 					controllers.DeleteShare,
 				)
+				// 分享访问统计
+				share.GET("analytics/:id", controllers.ShareAnalytics)
+				// 分享访问记录
+				share.GET("analytics/:id/logs", controllers.ShareAnalyticsLogs)
+				// 列出文件收集待审核的访客上传
+				share.GET("guest/:id", controllers.ListGuestUploads)
+				// 通过访客上传
+				share.PUT("guest/:id/:upload", controllers.ApproveGuestUpload)
+				// 拒绝访客上传
+				share.DELETE("guest/:id/:upload", controllers.RejectGuestUpload)
 			}
 
 			// 用户标签
@@ -713,7 +1109,7 @@ func InitMasterRouter() *gin.Engine {
 			}
 
 			// WebDAV管理相关
-			webdav := auth.Group("webdav")
+			webdav := auth.Group("webdav", middleware.NotImpersonating())
 			{
 				// 获取账号信息
 				webdav.GET("accounts", controllers.GetWebDAVAccounts)
@@ -725,6 +1121,35 @@ func InitMasterRouter() *gin.Engine {
 				webdav.PATCH("accounts", controllers.UpdateWebDAVAccounts)
 			}
 
+			// 挂载外部存储
+			mount := auth.Group("mount")
+			{
+				// 列出挂载
+				mount.GET("", controllers.ListMounts)
+				// 新建挂载
+				mount.POST("", controllers.CreateMount)
+				// 解除挂载
+				mount.DELETE(":id", controllers.DeleteMount)
+			}
+
+			// 客户端加密的保险库
+			vault := auth.Group("vault")
+			{
+				// 列出保险库
+				vault.GET("", controllers.ListVaults)
+				// 新建保险库
+				vault.POST("", controllers.CreateVault)
+				// 更新包装密钥
+				vault.PUT(":id/key", controllers.UpdateVaultKey)
+				// 删除保险库
+				vault.DELETE(":id", controllers.DeleteVault)
+				// 保存文件的加密元数据
+				vault.PATCH("file/:id",
+					middleware.HashID(hashid.FileID),
+					controllers.UpdateVaultFileMeta,
+				)
+			}
+
 		}
 
 	}
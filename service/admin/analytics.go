@@ -0,0 +1,209 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+)
+
+// AnalyticsService 用量报表服务，数据来自定时任务汇总的结果
+type AnalyticsService struct {
+	Days  int `form:"days" binding:"min=0,max=400"`
+	Limit int `form:"limit" binding:"min=0,max=100"`
+}
+
+// dateRange 返回报表的起止日期，默认统计最近 30 天
+func (service *AnalyticsService) dateRange() (string, string) {
+	days := service.Days
+	if days == 0 {
+		days = 30
+	}
+
+	now := time.Now()
+	return now.AddDate(0, 0, 1-days).Format(model.TrafficDateFormat), now.Format(model.TrafficDateFormat)
+}
+
+func (service *AnalyticsService) limit() int {
+	if service.Limit == 0 {
+		return 10
+	}
+	return service.Limit
+}
+
+// dimensionIDs 将汇总记录的维度解析为ID
+func dimensionIDs(rollups []model.UsageRollup) []uint {
+	ids := make([]uint, 0, len(rollups))
+	for _, rollup := range rollups {
+		if id, err := strconv.ParseUint(rollup.Dimension, 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// Storage 最近一次汇总的各存储策略、用户组已用容量，以及已用容量最多的用户
+func (service *AnalyticsService) Storage() serializer.Response {
+	date := model.LatestUsageRollupDate(model.RollupStoragePolicy)
+	policies := model.ListUsageRollups(model.RollupStoragePolicy, date, date)
+	groups := model.ListUsageRollups(model.RollupStorageGroup, date, date)
+	users := model.ListUsageRollups(model.RollupStorageUser, date, date)
+	if len(users) > service.limit() {
+		users = users[:service.limit()]
+	}
+
+	var (
+		policyList []model.Policy
+		groupList  []model.Group
+		userList   []model.User
+	)
+	model.DB.Where("id in (?)", dimensionIDs(policies)).Find(&policyList)
+	model.DB.Where("id in (?)", dimensionIDs(groups)).Find(&groupList)
+	model.DB.Where("id in (?)", dimensionIDs(users)).Find(&userList)
+
+	names := map[string]map[string]string{
+		"policies": {},
+		"groups":   {},
+		"users":    {},
+	}
+	for _, policy := range policyList {
+		names["policies"][strconv.FormatUint(uint64(policy.ID), 10)] = policy.Name
+	}
+	for _, group := range groupList {
+		names["groups"][strconv.FormatUint(uint64(group.ID), 10)] = group.Name
+	}
+	for _, user := range userList {
+		names["users"][strconv.FormatUint(uint64(user.ID), 10)] = user.Email
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"date":     date,
+		"policies": policies,
+		"groups":   groups,
+		"users":    users,
+		"names":    names,
+	}}
+}
+
+// Traffic 每日上传、下载量与活跃用户数
+func (service *AnalyticsService) Traffic() serializer.Response {
+	from, to := service.dateRange()
+	return serializer.Response{Data: map[string]interface{}{
+		"upload":       model.ListUsageRollups(model.RollupUpload, from, to),
+		"download":     model.ListUsageRollups(model.RollupDownload, from, to),
+		"active_users": model.ListUsageRollups(model.RollupActiveUsers, from, to),
+	}}
+}
+
+// Files 统计期间内下载量最多的文件
+func (service *AnalyticsService) Files() serializer.Response {
+	from, to := service.dateRange()
+	rollups := model.TopUsageRollups(model.RollupFileDownload, from, to, service.limit())
+
+	var files []model.File
+	model.DB.Where("id in (?)", dimensionIDs(rollups)).Find(&files)
+	fileMap := make(map[string]model.File, len(files))
+	for _, file := range files {
+		fileMap[strconv.FormatUint(uint64(file.ID), 10)] = file
+	}
+
+	items := make([]map[string]interface{}, 0, len(rollups))
+	for _, rollup := range rollups {
+		item := map[string]interface{}{
+			"id":        rollup.Dimension,
+			"traffic":   rollup.Value,
+			"downloads": rollup.Count,
+		}
+		if file, ok := fileMap[rollup.Dimension]; ok {
+			item["name"] = file.Name
+			item["size"] = file.Size
+			item["user_id"] = file.UserID
+		}
+		items = append(items, item)
+	}
+
+	return serializer.Response{Data: items}
+}
+
+// Shares 每日新建分享数，以及统计期间内浏览、下载最多的分享
+func (service *AnalyticsService) Shares() serializer.Response {
+	from, to := service.dateRange()
+	views := model.TopUsageRollups(model.RollupShareView, from, to, service.limit())
+	downloads := model.TopUsageRollups(model.RollupShareDownload, from, to, service.limit())
+
+	var shares []model.Share
+	model.DB.Where("id in (?)", dimensionIDs(append(views, downloads...))).Find(&shares)
+	shareMap := make(map[string]map[string]interface{}, len(shares))
+	for _, share := range shares {
+		shareMap[strconv.FormatUint(uint64(share.ID), 10)] = map[string]interface{}{
+			"key":         hashid.HashID(share.ID, hashid.ShareID),
+			"source_name": share.SourceName,
+			"user_id":     share.UserID,
+		}
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"created":   model.ListUsageRollups(model.RollupShareCreated, from, to),
+		"views":     views,
+		"downloads": downloads,
+		"shares":    shareMap,
+	}}
+}
+
+// Content 最近一次汇总的文件类型分布与最大的文件，以及统计期间内的容量增长
+func (service *AnalyticsService) Content() serializer.Response {
+	date := model.LatestUsageRollupDate(model.RollupTypePolicy)
+	from, to := service.dateRange()
+
+	// 按类型合计全站的文件
+	typePolicy := model.ListUsageRollups(model.RollupTypePolicy, date, date)
+	siteTypes := make(map[string]*model.UsageRollup)
+	for _, rollup := range typePolicy {
+		category := rollup.Dimension[strings.LastIndex(rollup.Dimension, ":")+1:]
+		if stat, ok := siteTypes[category]; ok {
+			stat.Value += rollup.Value
+			stat.Count += rollup.Count
+			continue
+		}
+		siteTypes[category] = &model.UsageRollup{Dimension: category, Value: rollup.Value, Count: rollup.Count}
+	}
+
+	largest := model.ListUsageRollups(model.RollupLargestFile, date, date)
+	if len(largest) > service.limit() {
+		largest = largest[:service.limit()]
+	}
+
+	var files []model.File
+	model.DB.Where("id in (?)", dimensionIDs(largest)).Find(&files)
+	fileMap := make(map[string]model.File, len(files))
+	for _, file := range files {
+		fileMap[strconv.FormatUint(uint64(file.ID), 10)] = file
+	}
+
+	largestFiles := make([]map[string]interface{}, 0, len(largest))
+	for _, rollup := range largest {
+		item := map[string]interface{}{
+			"id":   rollup.Dimension,
+			"size": rollup.Value,
+		}
+		if file, ok := fileMap[rollup.Dimension]; ok {
+			item["name"] = file.Name
+			item["user_id"] = file.UserID
+			item["policy_id"] = file.PolicyID
+		}
+		largestFiles = append(largestFiles, item)
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"date":          date,
+		"types":         siteTypes,
+		"type_policy":   typePolicy,
+		"type_user":     model.ListUsageRollups(model.RollupTypeUser, date, date),
+		"largest_files": largestFiles,
+		"growth_policy": model.ListUsageRollups(model.RollupGrowthPolicy, from, to),
+		"growth_user":   model.TopUsageRollups(model.RollupGrowthUser, from, to, service.limit()),
+	}}
+}
@@ -0,0 +1,83 @@
+package admin
+
+import (
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// AnnouncementService 发布公告服务
+type AnnouncementService struct {
+	Title     string     `json:"title" binding:"required,max=255"`
+	Content   string     `json:"content" binding:"required"`
+	Severity  string     `json:"severity" binding:"required,oneof=info warning critical"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// AnnouncementIDService 公告ID服务
+type AnnouncementIDService struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+// Create 发布公告，并通过站内通知与邮件投递给所有用户
+func (service *AnnouncementService) Create(c *gin.Context) serializer.Response {
+	if service.ExpiresAt != nil && !service.ExpiresAt.After(time.Now()) {
+		return serializer.ParamErr("Expiry time must be in the future", nil)
+	}
+
+	announcement := &model.Announcement{
+		Title:     service.Title,
+		Content:   service.Content,
+		Severity:  service.Severity,
+		ExpiresAt: service.ExpiresAt,
+	}
+	if err := announcement.Create(); err != nil {
+		return serializer.DBErr("Failed to create announcement", err)
+	}
+
+	go notify.Announce(announcement)
+	return serializer.Response{Data: announcement.ID}
+}
+
+// Delete 删除公告
+func (service *AnnouncementIDService) Delete(c *gin.Context) serializer.Response {
+	announcement, err := model.GetAnnouncementByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Announcement not exist", err)
+	}
+
+	if err := announcement.Delete(); err != nil {
+		return serializer.DBErr("Failed to delete announcement", err)
+	}
+
+	return serializer.Response{}
+}
+
+// Announcements 列出公告
+func (service *AdminListService) Announcements() serializer.Response {
+	var res []model.Announcement
+	total := 0
+
+	tx := model.DB.Model(&model.Announcement{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+	}}
+}
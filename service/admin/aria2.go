@@ -15,15 +15,26 @@ import (
 
 // Aria2TestService aria2连接测试服务
 type Aria2TestService struct {
-	Server string          `json:"server"`
-	RPC    string          `json:"rpc" binding:"required"`
-	Secret string          `json:"secret"`
-	Token  string          `json:"token"`
-	Type   model.ModelType `json:"type"`
+	Server   string          `json:"server"`
+	RPC      string          `json:"rpc" binding:"required"`
+	Secret   string          `json:"secret"`
+	Token    string          `json:"token"`
+	Type     model.ModelType `json:"type"`
+	Driver   string          `json:"driver"`
+	Username string          `json:"username"`
 }
 
 // Test 测试aria2连接
 func (service *Aria2TestService) TestMaster() serializer.Response {
+	if service.Driver != "" {
+		version, err := aria2.TestDownloaderConnection(service.Driver, service.RPC, service.Username, service.Token, 5)
+		if err != nil {
+			return serializer.ParamErr("Failed to connect to downloader: "+err.Error(), err)
+		}
+
+		return serializer.Response{Data: version}
+	}
+
 	res, err := aria2.TestRPCConnection(service.RPC, service.Token, 5)
 	if err != nil {
 		return serializer.ParamErr("Failed to connect to RPC server: "+err.Error(), err)
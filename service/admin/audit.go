@@ -0,0 +1,46 @@
+package admin
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+)
+
+// AuditLogs 列出审计记录
+func (service *AdminListService) AuditLogs() serializer.Response {
+	var res []model.AuditLog
+	total := 0
+
+	tx := model.DB.Model(&model.AuditLog{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	// 查询操作者与目标用户
+	userIDs := make([]uint, 0, len(res)*2)
+	for _, log := range res {
+		userIDs = append(userIDs, log.ActorID, log.TargetID)
+	}
+
+	var userList []model.User
+	model.DB.Where("id in (?)", userIDs).Find(&userList)
+	users := make(map[uint]model.User, len(userList))
+	for _, user := range userList {
+		users[user.ID] = user
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+		"users": users,
+	}}
+}
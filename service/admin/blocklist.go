@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// AddBlockedHashService 添加屏蔽哈希服务
+type AddBlockedHashService struct {
+	Algorithm string `json:"algorithm" binding:"required,eq=sha256|eq=md5"`
+	Hash      string `json:"hash" binding:"required,hexadecimal"`
+	Reason    string `json:"reason" binding:"max=65535"`
+}
+
+// BlockedHashService 屏蔽哈希管理服务
+type BlockedHashService struct {
+	ID uint `uri:"id" json:"id" binding:"required"`
+}
+
+// BlockedHashes 列出屏蔽哈希
+func (service *AdminListService) BlockedHashes() serializer.Response {
+	var res []model.BlockedHash
+	total := 0
+
+	tx := model.DB.Model(&model.BlockedHash{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	if len(service.Searches) > 0 {
+		search := ""
+		for k, v := range service.Searches {
+			search += k + " like '%" + v + "%' OR "
+		}
+		search = strings.TrimSuffix(search, " OR ")
+		tx = tx.Where(search)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+	}}
+}
+
+// BlocklistLogs 列出屏蔽列表审计日志
+func (service *AdminListService) BlocklistLogs() serializer.Response {
+	var res []model.BlocklistLog
+	total := 0
+
+	tx := model.DB.Model(&model.BlocklistLog{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	// 查询对应用户
+	users := make(map[uint]model.User)
+	for _, record := range res {
+		users[record.UserID] = model.User{}
+	}
+
+	userIDs := make([]uint, 0, len(users))
+	for k := range users {
+		userIDs = append(userIDs, k)
+	}
+
+	var userList []model.User
+	model.DB.Where("id in (?)", userIDs).Find(&userList)
+
+	for _, v := range userList {
+		users[v.ID] = v
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+		"users": users,
+	}}
+}
+
+// Add 添加屏蔽哈希，并标记已存在的命中文件
+func (service *AddBlockedHashService) Add(c *gin.Context, operator *model.User) serializer.Response {
+	expectedLen := 64
+	if service.Algorithm == model.HashAlgorithmMD5 {
+		expectedLen = 32
+	}
+	if len(service.Hash) != expectedLen {
+		return serializer.ParamErr("Invalid hash length", nil)
+	}
+
+	record := &model.BlockedHash{
+		Algorithm:  service.Algorithm,
+		Hash:       service.Hash,
+		Reason:     service.Reason,
+		OperatorID: operator.ID,
+	}
+	if err := record.Create(); err != nil {
+		return serializer.DBErr("Failed to add blocked hash", err)
+	}
+
+	flagged, err := record.FlagExistingFiles()
+	if err != nil {
+		return serializer.DBErr("Failed to flag existing files", err)
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"id":      record.ID,
+		"flagged": flagged,
+	}}
+}
+
+// Delete 移除屏蔽哈希
+func (service *BlockedHashService) Delete(c *gin.Context, operator *model.User) serializer.Response {
+	record, err := model.GetBlockedHashByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Blocked hash not exist", err)
+	}
+
+	if err := record.Delete(operator.ID); err != nil {
+		return serializer.DBErr("Failed to delete blocked hash", err)
+	}
+
+	return serializer.Response{}
+}
@@ -85,6 +85,15 @@ func (service *ListFolderService) List(c *gin.Context) serializer.Response {
 	}
 }
 
+// Unlock 强制解除文件锁
+func (service *FileBatchService) Unlock(c *gin.Context) serializer.Response {
+	if err := model.DeleteFileLocks(service.ID); err != nil {
+		return serializer.DBErr("Failed to delete file locks", err)
+	}
+
+	return serializer.Response{}
+}
+
 // Delete 删除文件
 func (service *FileBatchService) Delete(c *gin.Context) serializer.Response {
 	files, err := model.GetFilesByIDs(service.ID, 0)
@@ -92,9 +101,13 @@ func (service *FileBatchService) Delete(c *gin.Context) serializer.Response {
 		return serializer.DBErr("Failed to list files for deleting", err)
 	}
 
-	// 根据用户分组
+	// 根据用户分组，非默认租户的管理员只能删除本租户用户的文件
+	tenantID := c.GetUint("tenant_id")
 	userFile := make(map[uint][]model.File)
 	for i := 0; i < len(files); i++ {
+		if tenantID != 0 && !model.IsUserInTenant(files[i].UserID, tenantID) {
+			continue
+		}
 		if _, ok := userFile[files[i].UserID]; !ok {
 			userFile[files[i].UserID] = []model.File{}
 		}
@@ -158,7 +171,7 @@ func (service *AdminListService) Files() serializer.Response {
 	var res []model.File
 	total := 0
 
-	tx := model.DB.Model(&model.File{})
+	tx := service.scopeTenant(model.DB.Model(&model.File{}), "user_id")
 	if service.OrderBy != "" {
 		tx = tx.Order(service.OrderBy)
 	}
@@ -55,6 +55,11 @@ func (service *GroupService) Delete() serializer.Response {
 
 // Add 添加用户组
 func (service *AddGroupService) Add() serializer.Response {
+	// 用户组只能使用同一租户的存储策略
+	if err := model.CheckPoliciesTenant(service.Group.PolicyList, service.Group.TenantID); err != nil {
+		return serializer.ParamErr("Storage policies must belong to the tenant of the group", err)
+	}
+
 	if service.Group.ID > 0 {
 		if err := model.DB.Save(&service.Group).Error; err != nil {
 			return serializer.DBErr("Failed to save group record", err)
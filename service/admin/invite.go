@@ -0,0 +1,77 @@
+package admin
+
+import (
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// AddInviteCodeService 批量生成邀请码服务
+type AddInviteCodeService struct {
+	Num     int `json:"num" binding:"required,min=1,max=100"`
+	MaxUses int `json:"max_uses" binding:"min=0"`
+	// 有效期（小时），0 为永久有效
+	Duration int `json:"duration" binding:"min=0"`
+}
+
+// InviteCodeService 邀请码管理服务
+type InviteCodeService struct {
+	ID uint `uri:"id" json:"id" binding:"required"`
+}
+
+// InviteCodes 列出邀请码
+func (service *AdminListService) InviteCodes() serializer.Response {
+	var res []model.InviteCode
+	total := 0
+
+	tx := model.DB.Model(&model.InviteCode{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+	}}
+}
+
+// Add 生成邀请码
+func (service *AddInviteCodeService) Add(c *gin.Context, operator *model.User) serializer.Response {
+	var expires *time.Time
+	if service.Duration > 0 {
+		t := time.Now().Add(time.Duration(service.Duration) * time.Hour)
+		expires = &t
+	}
+
+	codes := make([]string, 0, service.Num)
+	for i := 0; i < service.Num; i++ {
+		code := model.NewInviteCode(operator.ID, service.MaxUses, expires)
+		if err := code.Create(); err != nil {
+			return serializer.DBErr("Failed to create invite code", err)
+		}
+		codes = append(codes, code.Code)
+	}
+
+	return serializer.Response{Data: codes}
+}
+
+// Delete 删除邀请码
+func (service *InviteCodeService) Delete(c *gin.Context) serializer.Response {
+	if err := model.DB.Where("id = ?", service.ID).Delete(&model.InviteCode{}).Error; err != nil {
+		return serializer.DBErr("Failed to delete invite code", err)
+	}
+
+	return serializer.Response{}
+}
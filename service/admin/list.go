@@ -3,6 +3,7 @@ package admin
 import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/jinzhu/gorm"
 )
 
 // AdminListService 仪表盘列条目服务
@@ -12,6 +13,16 @@ type AdminListService struct {
 	OrderBy    string            `json:"order_by"`
 	Conditions map[string]string `form:"conditions"`
 	Searches   map[string]string `form:"searches"`
+	// Tenant 请求所在租户，由控制器填入；非默认租户的管理员只能看到本租户用户的记录
+	Tenant uint `json:"-"`
+}
+
+// scopeTenant 按所有者所在租户限定查询，默认租户不做限制
+func (service *AdminListService) scopeTenant(tx *gorm.DB, column string) *gorm.DB {
+	if service.Tenant == 0 {
+		return tx
+	}
+	return tx.Scopes(model.TenantOwned(column, service.Tenant))
 }
 
 // GroupList 获取用户组列表
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"regexp"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/email"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+var langRegexp = regexp.MustCompile(`^[a-zA-Z]{2,8}(-[a-zA-Z0-9]{1,8})?$`)
+
+// MailTemplateService 邮件模板服务
+type MailTemplateService struct {
+	Name     string `json:"name" binding:"required"`
+	Lang     string `json:"lang" binding:"max=16"`
+	Subject  string `json:"subject"`
+	Template string `json:"template"`
+	To       string `json:"to" binding:"omitempty,email"`
+}
+
+func (service *MailTemplateService) validate() *serializer.Response {
+	if _, ok := email.Templates[service.Name]; !ok {
+		res := serializer.ParamErr("Unknown email template", nil)
+		return &res
+	}
+
+	if service.Lang != "" && !langRegexp.MatchString(service.Lang) {
+		res := serializer.ParamErr("Invalid language code", nil)
+		return &res
+	}
+
+	return nil
+}
+
+// render 使用示例变量渲染模板，未提供的模板内容使用已保存的版本
+func (service *MailTemplateService) render() (string, string, error) {
+	subject, body := email.Load(service.Name, service.Lang)
+	if service.Subject != "" {
+		subject = service.Subject
+	}
+	if service.Template != "" {
+		body = service.Template
+	}
+
+	vars := make(map[string]string)
+	for _, v := range email.Templates[service.Name] {
+		vars[v] = "[" + v + "]"
+	}
+
+	return email.RenderTemplate(subject, body, vars)
+}
+
+// Get 获取邮件模板
+func (service *MailTemplateService) Get() serializer.Response {
+	if res := service.validate(); res != nil {
+		return *res
+	}
+
+	subject, body := email.Load(service.Name, service.Lang)
+	return serializer.Response{Data: map[string]interface{}{
+		"subject":  subject,
+		"template": body,
+		"vars":     email.Templates[service.Name],
+	}}
+}
+
+// Save 保存邮件模板，语言版本不存在时自动创建
+func (service *MailTemplateService) Save() serializer.Response {
+	if res := service.validate(); res != nil {
+		return *res
+	}
+
+	if service.Subject == "" || service.Template == "" {
+		return serializer.ParamErr("Subject and template are required", nil)
+	}
+
+	if _, _, err := service.render(); err != nil {
+		return serializer.ParamErr("Failed to parse template: "+err.Error(), err)
+	}
+
+	values := map[string]string{
+		email.SubjectKey(service.Name, service.Lang): service.Subject,
+		email.BodyKey(service.Name, service.Lang):    service.Template,
+	}
+
+	// 记录已配置的语言
+	if service.Lang != "" {
+		langs := model.GetSettingByName("mail_template_langs")
+		exist := false
+		for _, l := range strings.Split(langs, ",") {
+			if l == service.Lang {
+				exist = true
+			}
+		}
+
+		if !exist {
+			values["mail_template_langs"] = strings.TrimPrefix(langs+","+service.Lang, ",")
+		}
+	}
+
+	tx := model.DB.Begin()
+	for k, v := range values {
+		setting := model.Setting{}
+		if err := tx.Where(model.Setting{Name: k}).
+			Assign(model.Setting{Value: v, Type: "mail_template"}).
+			FirstOrCreate(&setting).Error; err != nil {
+			tx.Rollback()
+			return serializer.Err(serializer.CodeUpdateSetting, "Failed to save template", err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return serializer.DBErr("Failed to save template", err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	cache.Deletes(keys, "setting_")
+
+	return serializer.Response{}
+}
+
+// Preview 预览邮件模板
+func (service *MailTemplateService) Preview() serializer.Response {
+	if res := service.validate(); res != nil {
+		return *res
+	}
+
+	title, body, err := service.render()
+	if err != nil {
+		return serializer.ParamErr("Failed to parse template: "+err.Error(), err)
+	}
+
+	return serializer.Response{Data: map[string]string{
+		"subject": title,
+		"body":    body,
+	}}
+}
+
+// Test 发送模板测试邮件
+func (service *MailTemplateService) Test(c *gin.Context) serializer.Response {
+	if res := service.validate(); res != nil {
+		return *res
+	}
+
+	if service.To == "" {
+		return serializer.ParamErr("Recipient is required", nil)
+	}
+
+	title, body, err := service.render()
+	if err != nil {
+		return serializer.ParamErr("Failed to parse template: "+err.Error(), err)
+	}
+
+	if err := email.Send(service.To, title, body); err != nil {
+		return serializer.Err(serializer.CodeFailedSendEmail, err.Error(), nil)
+	}
+
+	return serializer.Response{}
+}
+
+// MailLogs 列出邮件投递记录
+func (service *AdminListService) MailLogs() serializer.Response {
+	var res []model.MailLog
+	total := 0
+
+	tx := model.DB.Model(&model.MailLog{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	if len(service.Searches) > 0 {
+		search := ""
+		for k, v := range service.Searches {
+			search += k + " like '%" + v + "%' OR "
+		}
+		search = strings.TrimSuffix(search, " OR ")
+		tx = tx.Where(search)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+	}}
+}
@@ -140,3 +140,8 @@ func (service *NodeService) Get() serializer.Response {
 
 	return serializer.Response{Data: node}
 }
+
+// Aria2Health 获取离线下载节点的健康状态
+func (service *AdminListService) Aria2Health() serializer.Response {
+	return serializer.Response{Data: cluster.GetAria2Health()}
+}
@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"context"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// QuarantineService 隔离记录审核服务
+type QuarantineService struct {
+	ID uint `uri:"id" json:"id" binding:"required"`
+}
+
+// Quarantines 列出隔离记录
+func (service *AdminListService) Quarantines() serializer.Response {
+	var res []model.Quarantine
+	total := 0
+
+	tx := model.DB.Model(&model.Quarantine{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	if len(service.Searches) > 0 {
+		search := ""
+		for k, v := range service.Searches {
+			search += k + " like '%" + v + "%' OR "
+		}
+		search = strings.TrimSuffix(search, " OR ")
+		tx = tx.Where(search)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	// 查询对应用户
+	users := make(map[uint]model.User)
+	for _, record := range res {
+		users[record.UserID] = model.User{}
+	}
+
+	userIDs := make([]uint, 0, len(users))
+	for k := range users {
+		userIDs = append(userIDs, k)
+	}
+
+	var userList []model.User
+	model.DB.Where("id in (?)", userIDs).Find(&userList)
+
+	for _, v := range userList {
+		users[v.ID] = v
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+		"users": users,
+	}}
+}
+
+// pending 获取待审核的隔离记录
+func (service *QuarantineService) pending() (*model.Quarantine, serializer.Response) {
+	record, err := model.GetQuarantineByID(service.ID)
+	if err != nil {
+		return nil, serializer.Err(serializer.CodeNotFound, "Quarantine record not exist", err)
+	}
+
+	if record.Status != model.QuarantinePending {
+		return nil, serializer.ParamErr("This record has already been reviewed", nil)
+	}
+
+	return record, serializer.Response{}
+}
+
+// Release 确认文件安全，解除隔离
+func (service *QuarantineService) Release(c *gin.Context) serializer.Response {
+	record, res := service.pending()
+	if record == nil {
+		return res
+	}
+
+	if err := record.Release(); err != nil {
+		return serializer.DBErr("Failed to release file", err)
+	}
+
+	return serializer.Response{}
+}
+
+// Delete 删除被隔离的文件
+func (service *QuarantineService) Delete(c *gin.Context) serializer.Response {
+	record, res := service.pending()
+	if record == nil {
+		return res
+	}
+
+	user, err := model.GetUserByID(record.UserID)
+	if err == nil {
+		fs, err := filesystem.NewFileSystem(&user)
+		if err != nil {
+			return serializer.Err(serializer.CodeCreateFSError, "", err)
+		}
+		defer fs.Recycle()
+
+		if err := fs.Delete(context.Background(), []uint{}, []uint{record.FileID}, true, false); err != nil {
+			util.Log().Warning("Failed to delete quarantined file %d: %s", record.FileID, err)
+			return serializer.Err(serializer.CodeNotSet, "Failed to delete file", err)
+		}
+	}
+
+	if err := record.SetStatus(model.QuarantineDeleted); err != nil {
+		return serializer.DBErr("Failed to update quarantine record", err)
+	}
+
+	return serializer.Response{}
+}
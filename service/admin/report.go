@@ -0,0 +1,123 @@
+package admin
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// ReportService 举报处理服务
+type ReportService struct {
+	ID uint `uri:"id" json:"id" binding:"required"`
+}
+
+// Reports 列出举报记录
+func (service *AdminListService) Reports() serializer.Response {
+	var res []model.Report
+	total := 0
+
+	tx := model.DB.Model(&model.Report{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	// 查询对应分享，同时计算HashID
+	shares := make(map[uint]model.Share)
+	hashIDs := make(map[uint]string)
+	shareIDs := make([]uint, 0, len(res))
+	for _, report := range res {
+		if _, ok := shares[report.ShareID]; !ok {
+			shares[report.ShareID] = model.Share{}
+			hashIDs[report.ShareID] = hashid.HashID(report.ShareID, hashid.ShareID)
+			shareIDs = append(shareIDs, report.ShareID)
+		}
+	}
+
+	var shareList []model.Share
+	model.DB.Where("id in (?)", shareIDs).Find(&shareList)
+
+	// 查询分享所有者
+	users := make(map[uint]model.User)
+	userIDs := make([]uint, 0, len(shareList))
+	for _, v := range shareList {
+		shares[v.ID] = v
+		if _, ok := users[v.UserID]; !ok {
+			users[v.UserID] = model.User{}
+			userIDs = append(userIDs, v.UserID)
+		}
+	}
+
+	var userList []model.User
+	model.DB.Where("id in (?)", userIDs).Find(&userList)
+
+	for _, v := range userList {
+		users[v.ID] = v
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total":  total,
+		"items":  res,
+		"shares": shares,
+		"users":  users,
+		"ids":    hashIDs,
+	}}
+}
+
+// Disable 停用被举报的分享，suspendOwner 为真时同时封禁分享者
+func (service *ReportService) Disable(c *gin.Context, suspendOwner bool) serializer.Response {
+	report, err := model.GetReportByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Report not exist", err)
+	}
+
+	var share model.Share
+	if err := model.DB.Where("id = ?", report.ShareID).First(&share).Error; err != nil {
+		return serializer.Err(serializer.CodeShareLinkNotFound, "", err)
+	}
+
+	if suspendOwner && share.UserID == 1 {
+		return serializer.Err(serializer.CodeInvalidActionOnDefaultUser, "", nil)
+	}
+
+	if err := share.Update(map[string]interface{}{"disabled": true, "quota_disabled": false}); err != nil {
+		return serializer.DBErr("Failed to disable share", err)
+	}
+
+	if suspendOwner {
+		owner := share.Creator()
+		if owner.ID > 0 {
+			owner.SetStatus(model.Baned)
+		}
+	}
+
+	if err := model.ResolvePendingReports(share.ID, model.ReportResolved); err != nil {
+		return serializer.DBErr("Failed to update reports", err)
+	}
+
+	return serializer.Response{}
+}
+
+// Ignore 忽略举报
+func (service *ReportService) Ignore(c *gin.Context) serializer.Response {
+	report, err := model.GetReportByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Report not exist", err)
+	}
+
+	if err := report.SetStatus(model.ReportIgnored); err != nil {
+		return serializer.DBErr("Failed to update report", err)
+	}
+
+	return serializer.Response{}
+}
@@ -0,0 +1,105 @@
+package admin
+
+import (
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+)
+
+// RetentionHoldSetService 设置保留冻结服务
+type RetentionHoldSetService struct {
+	Type string `json:"type" binding:"required,eq=file|eq=dir"`
+	ID   uint   `json:"id" binding:"required"`
+	// Until 保留截止时间，为空时为无限期冻结
+	Until  *time.Time `json:"until"`
+	Reason string     `json:"reason" binding:"max=65535"`
+}
+
+// RetentionHoldService 保留冻结管理服务
+type RetentionHoldService struct {
+	ID uint `uri:"id" json:"id" binding:"required"`
+}
+
+// RetentionHolds 列出保留冻结
+func (service *AdminListService) RetentionHolds() serializer.Response {
+	var res []model.RetentionHold
+	total := 0
+
+	tx := model.DB.Model(&model.RetentionHold{})
+	if service.OrderBy != "" {
+		tx = tx.Order(service.OrderBy)
+	}
+
+	for k, v := range service.Conditions {
+		tx = tx.Where(k+" = ?", v)
+	}
+
+	// 计算总数用于分页
+	tx.Count(&total)
+
+	// 查询记录
+	tx.Limit(service.PageSize).Offset((service.Page - 1) * service.PageSize).Find(&res)
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": res,
+	}}
+}
+
+// Set 为文件或目录设置保留冻结，已有冻结时更新其截止时间和原因
+func (service *RetentionHoldSetService) Set(c *gin.Context, operator *model.User) serializer.Response {
+	if service.Until != nil && !service.Until.After(time.Now()) {
+		return serializer.ParamErr("Retention date must be in the future", nil)
+	}
+
+	isDir := service.Type == "dir"
+	var owner uint
+	if isDir {
+		var folder model.Folder
+		if err := model.DB.Where("id = ?", service.ID).First(&folder).Error; err != nil {
+			return serializer.Err(serializer.CodeNotFound, "Folder not exist", err)
+		}
+		owner = folder.OwnerID
+	} else {
+		files, err := model.GetFilesByIDs([]uint{service.ID}, 0)
+		if err != nil || len(files) == 0 {
+			return serializer.Err(serializer.CodeFileNotFound, "", err)
+		}
+		owner = files[0].UserID
+	}
+
+	hold, err := model.GetRetentionHold(service.ID, isDir)
+	if err != nil {
+		if !gorm.IsRecordNotFoundError(err) {
+			return serializer.DBErr("Failed to get retention hold", err)
+		}
+		hold = &model.RetentionHold{ObjectID: service.ID, IsDir: isDir}
+	}
+
+	hold.UserID = owner
+	hold.HoldUntil = service.Until
+	hold.Reason = service.Reason
+	hold.OperatorID = operator.ID
+	if err := hold.Save(); err != nil {
+		return serializer.DBErr("Failed to save retention hold", err)
+	}
+
+	return serializer.Response{Data: hold}
+}
+
+// Release 解除保留冻结
+func (service *RetentionHoldService) Release(c *gin.Context) serializer.Response {
+	hold, err := model.GetRetentionHoldByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Retention hold not exist", err)
+	}
+
+	if err := hold.Delete(); err != nil {
+		return serializer.DBErr("Failed to release retention hold", err)
+	}
+
+	return serializer.Response{}
+}
@@ -16,7 +16,11 @@ type ShareBatchService struct {
 
 // Delete 删除文件
 func (service *ShareBatchService) Delete(c *gin.Context) serializer.Response {
-	if err := model.DB.Where("id in (?)", service.ID).Delete(&model.Share{}).Error; err != nil {
+	tx := model.DB.Where("id in (?)", service.ID)
+	if tenantID := c.GetUint("tenant_id"); tenantID != 0 {
+		tx = tx.Scopes(model.TenantOwned("user_id", tenantID))
+	}
+	if err := model.DeleteShares(tx); err != nil {
 		return serializer.DBErr("Failed to delete share record", err)
 	}
 	return serializer.Response{}
@@ -27,7 +31,7 @@ func (service *AdminListService) Shares() serializer.Response {
 	var res []model.Share
 	total := 0
 
-	tx := model.DB.Model(&model.Share{})
+	tx := service.scopeTenant(model.DB.Model(&model.Share{}), "user_id")
 	if service.OrderBy != "" {
 		tx = tx.Order(service.OrderBy)
 	}
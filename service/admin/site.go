@@ -7,6 +7,7 @@ import (
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
 	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/crontab"
 	"github.com/cloudreve/Cloudreve/v3/pkg/email"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/thumb"
@@ -82,6 +83,11 @@ func (service *BatchSettingChangeService) Change() serializer.Response {
 	return serializer.Response{}
 }
 
+// Crontab 获取定时任务的日程及最近一次执行状态
+func (service *NoParamService) Crontab() serializer.Response {
+	return serializer.Response{Data: crontab.GetStatus()}
+}
+
 // Summary 获取站点统计概况
 func (service *NoParamService) Summary() serializer.Response {
 	// 获取版本信息
@@ -50,6 +50,18 @@ func (service *TaskBatchService) DeleteGeneral(c *gin.Context) serializer.Respon
 	return serializer.Response{}
 }
 
+// Retry 将失败或进入死信状态的常规任务重新排队
+func (service *TaskBatchService) Retry(c *gin.Context) serializer.Response {
+	if err := model.DB.Model(&model.Task{}).
+		Where("id in (?) and status in (?)", service.ID, []int{task.Error, task.DeadLetter}).
+		Updates(map[string]interface{}{"status": task.Queued, "attempts": 0, "next_run_at": nil}).Error; err != nil {
+		return serializer.DBErr("Failed to update task records", err)
+	}
+
+	task.Wake()
+	return serializer.Response{}
+}
+
 // Tasks 列出常规任务
 func (service *AdminListService) Tasks() serializer.Response {
 	var res []model.Task
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantService 创建、保存租户服务
+type TenantService struct {
+	Name   string `json:"name" binding:"required,max=255"`
+	Domain string `json:"domain" binding:"required,hostname_rfc1123,max=255"`
+	// Settings 覆盖的站点设置，只能包含 model.TenantSettings 中的设置项
+	Settings map[string]string `json:"settings"`
+}
+
+// TenantIDService 租户ID服务
+type TenantIDService struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+// Create 创建租户
+func (service *TenantService) Create(c *gin.Context) serializer.Response {
+	tenant := &model.Tenant{}
+	return service.save(tenant)
+}
+
+// Update 保存租户
+func (service *TenantService) Update(c *gin.Context, id uint) serializer.Response {
+	tenant, err := model.GetTenantByID(id)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Tenant not exist", err)
+	}
+	return service.save(tenant)
+}
+
+func (service *TenantService) save(tenant *model.Tenant) serializer.Response {
+	for name := range service.Settings {
+		if !model.IsTenantSetting(name) {
+			return serializer.ParamErr("Setting "+name+" cannot be overridden by tenant", nil)
+		}
+	}
+
+	tenant.Name = service.Name
+	tenant.Domain = service.Domain
+	tenant.SettingsSerialized = service.Settings
+	if err := model.DB.Save(tenant).Error; err != nil {
+		return serializer.DBErr("Failed to save tenant", err)
+	}
+
+	return serializer.Response{Data: tenant.ID}
+}
+
+// Delete 删除租户
+func (service *TenantIDService) Delete(c *gin.Context) serializer.Response {
+	tenant, err := model.GetTenantByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Tenant not exist", err)
+	}
+
+	if err := tenant.Delete(); err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	return serializer.Response{}
+}
+
+// Tenants 列出租户
+func (service *AdminListService) Tenants() serializer.Response {
+	tenants, err := model.ListTenants()
+	if err != nil {
+		return serializer.DBErr("Failed to list tenants", err)
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total": len(tenants),
+		"items": tenants,
+	}}
+}
@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"context"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// ThumbPurgeService 缩略图清除服务
+type ThumbPurgeService struct {
+	PolicyID uint `json:"policy_id"`
+	Rebuild  bool `json:"rebuild"`
+}
+
+// ThumbStats 获取缩略图缓存占用
+func ThumbStats(c *gin.Context) serializer.Response {
+	count, size := model.GetThumbCacheSize(0)
+	return serializer.Response{Data: map[string]interface{}{
+		"count": count,
+		"size":  size,
+		"limit": model.GetIntSetting("thumb_cache_max_size", 0),
+	}}
+}
+
+// Purge 在后台清除缩略图缓存，缩略图将在下次访问时重新生成
+func (service *ThumbPurgeService) Purge(c *gin.Context) serializer.Response {
+	if service.PolicyID > 0 {
+		if _, err := model.GetPolicyByID(service.PolicyID); err != nil {
+			return serializer.Err(serializer.CodePolicyNotExist, "", err)
+		}
+	}
+
+	go func(policyID uint, rebuild bool) {
+		purged, err := filesystem.PurgeThumbs(context.Background(), policyID, rebuild)
+		if err != nil {
+			util.Log().Warning("Failed to purge thumbnails: %s", err)
+		}
+		util.Log().Info("%d thumbnails purged.", purged)
+	}(service.PolicyID, service.Rebuild)
+
+	return serializer.Response{}
+}
+
+// EvictThumbs 立即淘汰超出缓存上限的缩略图
+func EvictThumbs(c *gin.Context) serializer.Response {
+	evicted, err := filesystem.EvictThumbs(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, "Failed to evict thumbnails", err)
+	}
+
+	return serializer.Response{Data: evicted}
+}
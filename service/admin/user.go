@@ -2,11 +2,16 @@ package admin
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
 )
 
 // AddUserService 用户添加服务
@@ -20,6 +25,12 @@ type UserService struct {
 	ID uint `uri:"id" json:"id" binding:"required"`
 }
 
+// UserReset2FAService 重置用户二步验证服务
+type UserReset2FAService struct {
+	ID       uint   `json:"id" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
 // UserBatchService 用户批量操作服务
 type UserBatchService struct {
 	ID []uint `json:"id" binding:"min=1"`
@@ -45,10 +56,48 @@ func (service *UserService) Ban() serializer.Response {
 	return serializer.Response{Data: user.Status}
 }
 
+// Approve 通过待审核用户的注册申请
+func (service *UserService) Approve() serializer.Response {
+	user, err := model.GetUserByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeUserNotFound, "", err)
+	}
+
+	if user.Status != model.PendingApproval {
+		return serializer.Err(serializer.CodeUserCannotActivate, "This user is not pending for approval", nil)
+	}
+
+	user.SetStatus(model.Active)
+	return serializer.Response{Data: user.Status}
+}
+
+// Reset 为无法登录的用户关闭二步验证并作废恢复代码，需要管理员再次验证自己的密码
+func (service *UserReset2FAService) Reset(c *gin.Context, operator *model.User) serializer.Response {
+	if ok, _ := operator.CheckPassword(service.Password); !ok {
+		return serializer.Err(serializer.CodeIncorrectPassword, "", nil)
+	}
+
+	user, err := model.GetUserByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeUserNotFound, "", err)
+	}
+
+	if err := user.ResetTwoFactor(); err != nil {
+		return serializer.DBErr("Failed to reset 2FA", err)
+	}
+
+	util.Log().Info("2FA of user %q is reset by admin %q from %s.", user.Email, operator.Email, c.ClientIP())
+	return serializer.Response{}
+}
+
 // Delete 删除用户
-func (service *UserBatchService) Delete() serializer.Response {
+func (service *UserBatchService) Delete(c *gin.Context) serializer.Response {
 	for _, uid := range service.ID {
 		user, err := model.GetUserByID(uid)
+		// 非默认租户的管理员只能删除本租户用户
+		if tenantID := c.GetUint("tenant_id"); err == nil && tenantID != 0 && user.TenantID != tenantID {
+			err = model.ErrTenantMismatch
+		}
 		if err != nil {
 			return serializer.Err(serializer.CodeUserNotFound, "", err)
 		}
@@ -59,27 +108,16 @@ func (service *UserBatchService) Delete() serializer.Response {
 		}
 
 		// 删除与此用户相关的所有资源
-
 		fs, err := filesystem.NewFileSystem(&user)
-		// 删除所有文件
-		root, err := fs.User.Root()
 		if err != nil {
-			return serializer.Err(serializer.CodeInternalSetting, "User's root folder not exist", err)
+			return serializer.Err(serializer.CodeCreateFSError, "", err)
 		}
-		fs.Delete(context.Background(), []uint{root.ID}, []uint{}, false, false)
-
-		// 删除相关任务
-		model.DB.Where("user_id = ?", uid).Delete(&model.Download{})
-		model.DB.Where("user_id = ?", uid).Delete(&model.Task{})
-
-		// 删除标签
-		model.DB.Where("user_id = ?", uid).Delete(&model.Tag{})
 
-		// 删除WebDAV账号
-		model.DB.Where("user_id = ?", uid).Delete(&model.Webdav{})
-
-		// 删除此用户
-		model.DB.Unscoped().Delete(user)
+		err = fs.PurgeUser(context.Background())
+		fs.Recycle()
+		if err != nil {
+			return serializer.Err(serializer.CodeInternalSetting, "Failed to delete user", err)
+		}
 
 	}
 	return serializer.Response{}
@@ -97,19 +135,34 @@ func (service *UserService) Get() serializer.Response {
 
 // Add 添加用户
 func (service *AddUserService) Add() serializer.Response {
+	// 用户只能属于同一租户的用户组
+	if err := model.CheckGroupTenant(service.User.GroupID, service.User.TenantID); err != nil {
+		return serializer.ParamErr("User group must belong to the tenant of the user", err)
+	}
+
 	if service.User.ID > 0 {
 
 		user, _ := model.GetUserByID(service.User.ID)
 		if service.Password != "" {
 			user.SetPassword(service.Password)
+			// 修改密码后注销该用户所有会话
+			if err := sessionstore.RevokeAll(user.ID, ""); err != nil {
+				util.Log().Warning("Failed to revoke sessions of user %q: %s", user.Email, err)
+			}
 		}
 
 		// 只更新必要字段
 		user.Nick = service.User.Nick
 		user.Email = service.User.Email
 		user.GroupID = service.User.GroupID
+		user.TenantID = service.User.TenantID
 		user.Status = service.User.Status
+		user.MaxStorage = service.User.MaxStorage
 		user.TwoFactor = service.User.TwoFactor
+		if user.TwoFactor == "" {
+			// 关闭二步验证时一并作废恢复代码
+			user.TwoFactorRecovery = ""
+		}
 
 		// 检查愚蠢操作
 		if user.ID == 1 {
@@ -140,6 +193,9 @@ func (service *AdminListService) Users() serializer.Response {
 	total := 0
 
 	tx := model.DB.Model(&model.User{})
+	if service.Tenant != 0 {
+		tx = tx.Where("tenant_id = ?", service.Tenant)
+	}
 	if service.OrderBy != "" {
 		tx = tx.Order(service.OrderBy)
 	}
@@ -170,3 +226,61 @@ func (service *AdminListService) Users() serializer.Response {
 		"items": res,
 	}}
 }
+
+// ImpersonateService 模拟用户登录服务
+type ImpersonateService struct {
+	ID       uint   `json:"id" binding:"required"`
+	Duration int    `json:"duration" binding:"min=0"`
+	Reason   string `json:"reason" binding:"required,max=255"`
+}
+
+// Impersonate 以指定用户身份登录当前会话，用于复现用户反馈的问题。模拟登录有效期有限，
+// 到期后自动恢复为管理员身份，开始与结束均会记录审计日志
+func (service *ImpersonateService) Impersonate(c *gin.Context, operator *model.User) serializer.Response {
+	user, err := model.GetActiveUserByID(service.ID)
+	// 只能模拟同一租户下的用户
+	if err == nil && user.TenantID != operator.TenantID {
+		err = model.ErrTenantMismatch
+	}
+	if err != nil {
+		return serializer.Err(serializer.CodeUserNotFound, "", err)
+	}
+
+	// 不能模拟自己或其他管理员
+	if user.ID == operator.ID || user.ID == 1 || user.Group.ID == 1 {
+		return serializer.Err(serializer.CodeNoPermissionErr, "Cannot impersonate administrators", nil)
+	}
+
+	duration := service.Duration
+	if duration == 0 {
+		duration = model.GetIntSetting("impersonate_timeout", 1800)
+	}
+	if max := model.GetIntSetting("impersonate_max_timeout", 14400); duration > max {
+		duration = max
+	}
+	expires := time.Now().Add(time.Duration(duration) * time.Second)
+
+	log := &model.AuditLog{
+		ActorID:  operator.ID,
+		TargetID: user.ID,
+		Action:   model.AuditImpersonateStart,
+		IP:       c.ClientIP(),
+		Detail:   fmt.Sprintf("duration=%ds, reason: %s", duration, service.Reason),
+	}
+	if err := log.Create(); err != nil {
+		return serializer.DBErr("Failed to write audit log", err)
+	}
+
+	util.Log().Warning("Admin %q started impersonating user %q from %s for %ds, reason: %s",
+		operator.Email, user.Email, c.ClientIP(), duration, service.Reason)
+	util.SetSession(c, map[string]interface{}{
+		"user_id":             user.ID,
+		"impersonator_id":     operator.ID,
+		"impersonate_expires": expires.Unix(),
+	})
+
+	return serializer.Response{Data: map[string]interface{}{
+		"user":    serializer.BuildUser(user),
+		"expires": expires,
+	}}
+}
@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// userImportMaxSize 导入 CSV 文件的大小上限
+	userImportMaxSize = 10 << 20
+	// userExportPageSize 导出用户时每批读取的数量
+	userExportPageSize = 500
+)
+
+// UserImportService 从 CSV 批量导入用户服务
+type UserImportService struct {
+}
+
+// UserExportService 导出用户列表服务
+type UserExportService struct {
+}
+
+// Import 保存上传的 CSV 文件并创建导入任务，逐行的导入结果记录在任务属性中
+func (service *UserImportService) Import(c *gin.Context, user *model.User) serializer.Response {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return serializer.ParamErr("Failed to read CSV file", err)
+	}
+
+	if file.Size > userImportMaxSize {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return serializer.ParamErr("Failed to read CSV file", err)
+	}
+	defer src.Close()
+
+	savePath := filepath.Join(
+		util.RelativePath(model.GetSettingByName("temp_path")),
+		"user_import",
+		fmt.Sprintf("import_%d_%d.csv", user.ID, time.Now().UnixNano()),
+	)
+	dst, err := util.CreatNestedFile(savePath)
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to save CSV file", err)
+	}
+
+	_, err = io.Copy(dst, src)
+	dst.Close()
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to save CSV file", err)
+	}
+
+	job, err := task.NewUserImportTask(user, savePath)
+	if err != nil {
+		return serializer.DBErr("Failed to create task record.", err)
+	}
+	task.TaskPoll.Submit(job)
+
+	return serializer.Response{Data: job.Model().ID}
+}
+
+// Export 以 CSV 格式导出所有用户，导出的文件可直接用于导入
+func (service *UserExportService) Export(c *gin.Context) serializer.Response {
+	var groups []model.Group
+	model.DB.Find(&groups)
+	groupNames := make(map[uint]string, len(groups))
+	for _, group := range groups {
+		groupNames[group.ID] = group.Name
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"users.csv\"")
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"id", "email", "nickname", "group", "quota", "status", "storage", "created_at"})
+
+	var lastID uint
+	for {
+		var users []model.User
+		if err := model.DB.Where("id > ?", lastID).Order("id").Limit(userExportPageSize).Find(&users).Error; err != nil {
+			// 响应已开始输出，只能中断导出
+			util.Log().Warning("Failed to export users: %s", err)
+			break
+		}
+
+		for _, user := range users {
+			writer.Write([]string{
+				strconv.FormatUint(uint64(user.ID), 10),
+				user.Email,
+				user.Nick,
+				groupNames[user.GroupID],
+				strconv.FormatUint(user.MaxStorage, 10),
+				strconv.Itoa(user.Status),
+				strconv.FormatUint(user.Storage, 10),
+				user.CreatedAt.Format(time.RFC3339),
+			})
+		}
+		writer.Flush()
+
+		if len(users) < userExportPageSize {
+			break
+		}
+		lastID = users[len(users)-1].ID
+	}
+
+	return serializer.Response{Code: -1}
+}
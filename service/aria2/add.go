@@ -1,6 +1,10 @@
 package aria2
 
 import (
+	"encoding/json"
+	"fmt"
+	"path"
+
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2"
 	"github.com/cloudreve/Cloudreve/v3/pkg/aria2/common"
@@ -15,8 +19,12 @@ import (
 
 // AddURLService 添加URL离线下载服务
 type BatchAddURLService struct {
-	URLs []string `json:"url" binding:"required"`
-	Dst  string   `json:"dst" binding:"required,min=1"`
+	URLs      []string `json:"url" binding:"required"`
+	Dst       string   `json:"dst" binding:"required,min=1"`
+	SeedRatio *float64 `json:"seed_ratio" binding:"omitempty,min=0"`
+	SeedTime  *int     `json:"seed_time" binding:"omitempty,min=0"`
+
+	PostProcess *model.DownloadPostProcess `json:"post_process"`
 }
 
 // Add 主机批量创建新的链接离线下载任务
@@ -47,8 +55,11 @@ func (service *BatchAddURLService) Add(c *gin.Context, taskType int) serializer.
 	res := make([]serializer.Response, 0, len(service.URLs))
 	for _, target := range service.URLs {
 		subService := &AddURLService{
-			URL: target,
-			Dst: service.Dst,
+			URL:         target,
+			Dst:         service.Dst,
+			SeedRatio:   service.SeedRatio,
+			SeedTime:    service.SeedTime,
+			PostProcess: service.PostProcess,
 		}
 
 		addRes := subService.Add(c, fs, taskType)
@@ -62,6 +73,12 @@ func (service *BatchAddURLService) Add(c *gin.Context, taskType int) serializer.
 type AddURLService struct {
 	URL string `json:"url" binding:"required"`
 	Dst string `json:"dst" binding:"required,min=1"`
+	// SeedRatio 下载完成后做种的分享率上限，未指定时使用用户组配置
+	SeedRatio *float64 `json:"seed_ratio" binding:"omitempty,min=0"`
+	// SeedTime 下载完成后做种的时长上限（分钟），未指定时使用用户组配置
+	SeedTime *int `json:"seed_time" binding:"omitempty,min=0"`
+	// PostProcess 转存完成后的处理规则
+	PostProcess *model.DownloadPostProcess `json:"post_process"`
 }
 
 // Add 主机创建新的链接离线下载任务
@@ -100,19 +117,22 @@ func (service *AddURLService) Add(c *gin.Context, fs *filesystem.FileSystem, tas
 		UserID: fs.User.ID,
 		Source: service.URL,
 	}
+	task.SeedRatio, task.SeedTime = service.seedingLimits(&fs.User.Group.OptionsSerialized)
+	if service.PostProcess != nil && !service.PostProcess.IsEmpty() {
+		if err := validatePostProcess(service.PostProcess); err != nil {
+			return serializer.ParamErr(err.Error(), err)
+		}
 
-	// 获取 Aria2 负载均衡器
-	lb := aria2.GetLoadBalancer()
-
-	// 获取 Aria2 实例
-	err, node := cluster.Default.BalanceNodeByFeature("aria2", lb)
-	if err != nil {
-		return serializer.Err(serializer.CodeInternalSetting, "Failed to get Aria2 instance", err)
+		rules, _ := json.Marshal(service.PostProcess)
+		task.PostProcess = string(rules)
+		task.PostProcessSerialized = *service.PostProcess
 	}
 
-	// 创建任务
-	gid, err := node.GetAria2Instance().CreateTask(task, fs.User.Group.OptionsSerialized.Aria2Options)
-	if err != nil {
+	// 选取 Aria2 节点并创建任务
+	node, gid, err := cluster.CreateAria2Task(cluster.Default, &fs.User.Group, aria2.GetLoadBalancer(), task)
+	if err == cluster.ErrNoAria2Node {
+		return serializer.Err(serializer.CodeInternalSetting, "Failed to get Aria2 instance", err)
+	} else if err != nil {
 		return serializer.Err(serializer.CodeCreateTaskError, "", err)
 	}
 
@@ -149,3 +169,31 @@ func Add(c *gin.Context, service *serializer.SlaveAria2Call) serializer.Response
 
 	return serializer.Response{Data: gid}
 }
+
+// seedingLimits 确定任务的做种上限，用户指定的值不能超过用户组配置的上限
+func (service *AddURLService) seedingLimits(group *model.GroupOption) (float64, int) {
+	ratio, minutes := group.Aria2SeedRatio, group.Aria2SeedTime
+	if service.SeedRatio != nil && *service.SeedRatio < ratio {
+		ratio = *service.SeedRatio
+	}
+	if service.SeedTime != nil && *service.SeedTime < minutes {
+		minutes = *service.SeedTime
+	}
+
+	return ratio, minutes
+}
+
+// validatePostProcess 检查处理规则中的通配符与目标目录是否合法
+func validatePostProcess(rules *model.DownloadPostProcess) error {
+	for _, pattern := range append(append([]string{}, rules.Include...), rules.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid filename pattern %q: %w", pattern, err)
+		}
+	}
+
+	if rules.MoveTo != "" && !path.IsAbs(rules.MoveTo) {
+		return fmt.Errorf("move_to must be an absolute path")
+	}
+
+	return nil
+}
@@ -157,6 +157,39 @@ func SlaveSelect(c *gin.Context, service *serializer.SlaveAria2Call) serializer.
 
 }
 
+// SlavePause 暂停从机离线下载任务
+func SlavePause(c *gin.Context, service *serializer.SlaveAria2Call) serializer.Response {
+	caller, _ := c.Get("MasterAria2Instance")
+
+	if err := caller.(common.Aria2).Pause(service.Task); err != nil {
+		return serializer.Err(serializer.CodeInternalSetting, "Failed to pause task", err)
+	}
+
+	return serializer.Response{}
+}
+
+// SlaveResume 恢复从机离线下载任务
+func SlaveResume(c *gin.Context, service *serializer.SlaveAria2Call) serializer.Response {
+	caller, _ := c.Get("MasterAria2Instance")
+
+	if err := caller.(common.Aria2).Resume(service.Task); err != nil {
+		return serializer.Err(serializer.CodeInternalSetting, "Failed to resume task", err)
+	}
+
+	return serializer.Response{}
+}
+
+// SlaveSpeedLimit 设置从机离线下载任务的最大下载速度
+func SlaveSpeedLimit(c *gin.Context, service *serializer.SlaveAria2Call) serializer.Response {
+	caller, _ := c.Get("MasterAria2Instance")
+
+	if err := caller.(common.Aria2).SetSpeedLimit(service.Task, service.SpeedLimit); err != nil {
+		return serializer.Err(serializer.CodeInternalSetting, "Failed to set speed limit", err)
+	}
+
+	return serializer.Response{}
+}
+
 // SlaveSelect 从机选取离线下载任务文件
 func SlaveDeleteTemp(c *gin.Context, service *serializer.SlaveAria2Call) serializer.Response {
 	caller, _ := c.Get("MasterAria2Instance")
@@ -0,0 +1,23 @@
+package callback
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// SlaveMetaCallbackService 从机推送缩略图与元数据服务
+type SlaveMetaCallbackService struct {
+	Data serializer.SlaveMetaResult `json:"data" binding:"required"`
+}
+
+// Save 保存从机生成的缩略图与元数据
+func (service *SlaveMetaCallbackService) Save(c *gin.Context) serializer.Response {
+	file := c.MustGet("file").(*model.File)
+	if err := filesystem.ApplySlaveMeta(file, &service.Data); err != nil {
+		return serializer.DBErr("Failed to save file metadata", err)
+	}
+
+	return serializer.Response{}
+}
@@ -2,6 +2,8 @@ package callback
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"strings"
@@ -11,7 +13,9 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver/onedrive"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver/s3"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
 	"github.com/gin-gonic/gin"
 )
 
@@ -36,6 +40,7 @@ type UploadCallbackService struct {
 	SourceName string `json:"source_name"`
 	PicInfo    string `json:"pic_info"`
 	Size       uint64 `json:"size"`
+	ContentMD5 string `json:"content_md5"`
 }
 
 // UpyunCallbackService 又拍云上传回调请求服务
@@ -61,11 +66,12 @@ type COSCallback struct {
 
 // S3Callback S3 客户端回调正文
 type S3Callback struct {
+	Etag string
 }
 
 // GetBody 返回回调正文
 func (service UpyunCallbackService) GetBody() serializer.UploadCallback {
-	res := serializer.UploadCallback{}
+	res := serializer.UploadCallback{Size: service.Size}
 	if service.Width != "" {
 		res.PicInfo = service.Width + "," + service.Height
 	}
@@ -75,9 +81,17 @@ func (service UpyunCallbackService) GetBody() serializer.UploadCallback {
 
 // GetBody 返回回调正文
 func (service UploadCallbackService) GetBody() serializer.UploadCallback {
-	return serializer.UploadCallback{
+	res := serializer.UploadCallback{
 		PicInfo: service.PicInfo,
+		Size:    service.Size,
+	}
+
+	// OSS 提供 Base64 编码的 Content-MD5
+	if md5, err := base64.StdEncoding.DecodeString(service.ContentMD5); err == nil && len(md5) > 0 {
+		res.MD5 = hex.EncodeToString(md5)
 	}
+
+	return res
 }
 
 // GetBody 返回回调正文
@@ -86,15 +100,21 @@ func (service OneDriveCallback) GetBody() serializer.UploadCallback {
 	if service.Meta.Image.Width != 0 {
 		picInfo = fmt.Sprintf("%d,%d", service.Meta.Image.Width, service.Meta.Image.Height)
 	}
-	return serializer.UploadCallback{
+	res := serializer.UploadCallback{
 		PicInfo: picInfo,
 	}
+	if service.Meta.File != nil && service.Meta.File.Hashes != nil {
+		res.SHA256 = strings.ToLower(service.Meta.File.Hashes.Sha256Hash)
+	}
+
+	return res
 }
 
 // GetBody 返回回调正文
 func (service COSCallback) GetBody() serializer.UploadCallback {
 	return serializer.UploadCallback{
 		PicInfo: "",
+		MD5:     filesystem.ETagChecksum(service.Etag),
 	}
 }
 
@@ -102,6 +122,7 @@ func (service COSCallback) GetBody() serializer.UploadCallback {
 func (service S3Callback) GetBody() serializer.UploadCallback {
 	return serializer.UploadCallback{
 		PicInfo: "",
+		MD5:     filesystem.ETagChecksum(service.Etag),
 	}
 }
 
@@ -135,19 +156,43 @@ func ProcessCallback(service CallbackProcessService, c *gin.Context) serializer.
 		LastModified: uploadSession.LastModified,
 	}
 
+	// 存储端提供了实际文件大小时，验证其与上传会话中是否一致
+	if callbackBody.Size > 0 && callbackBody.Size != uploadSession.Size {
+		fs.Handler.Delete(context.Background(), []string{uploadSession.SavePath})
+		return serializer.Err(serializer.CodeMetaMismatch, "", nil)
+	}
+
+	// 按存储策略当前的设定重新检验文件
+	fs.Use("BeforeUpload", filesystem.HookValidateFile)
+
+	// 保存存储端提供的文件哈希
+	fs.Use("AfterUpload", filesystem.HookSaveChecksum(map[string]string{
+		model.MD5MetadataKey:    callbackBody.MD5,
+		model.SHA256MetadataKey: callbackBody.SHA256,
+	}))
+
 	// 占位符未扣除容量需要校验和扣除
 	if !fs.Policy.IsUploadPlaceholderWithSize() {
 		fs.Use("AfterUpload", filesystem.HookValidateCapacity)
 		fs.Use("AfterUpload", filesystem.HookChunkUploaded)
 	}
 
-	fs.Use("AfterUpload", filesystem.HookPopPlaceholderToFile(callbackBody.PicInfo))
+	if uploadSession.Replace > 0 {
+		fs.Use("AfterUpload", filesystem.HookReplaceWithPlaceholder(uploadSession.Replace, uploadSession.Name, callbackBody.PicInfo))
+	} else {
+		fs.Use("AfterUpload", filesystem.HookPopPlaceholderToFile(callbackBody.PicInfo))
+	}
+	fs.Use("AfterUpload", filesystem.HookExtractPhotoMeta)
+	fs.Use("AfterUpload", filesystem.HookGenerateSlaveMeta)
+	fs.Use("AfterUpload", filesystem.HookAddUploadTraffic(uploadSession.Size))
 	fs.Use("AfterValidateFailed", filesystem.HookDeleteTempFile)
 	err = fs.Upload(context.Background(), &fileData)
 	if err != nil {
 		return serializer.Err(serializer.CodeUploadFailed, err.Error(), err)
 	}
 
+	task.SubmitScanTask(fs.User, fileData.Model.(*model.File))
+	notify.CheckQuota(fs.User)
 	return serializer.Response{}
 }
 
@@ -236,6 +281,7 @@ func (service *S3Callback) PreProcess(c *gin.Context) serializer.Response {
 		return serializer.Err(serializer.CodeMetaMismatch, "", err)
 	}
 
+	service.Etag = info.Etag
 	return ProcessCallback(service, c)
 }
 
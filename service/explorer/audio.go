@@ -0,0 +1,175 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// AudioFolderService 列出目录下音频元数据的服务
+type AudioFolderService struct {
+	Path string `form:"path" binding:"required,min=1,max=65535"`
+}
+
+// AudioSearchService 搜索音乐库的服务
+type AudioSearchService struct {
+	Keywords string `form:"keywords"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+}
+
+// AudioAlbumService 列出音乐库专辑的服务
+type AudioAlbumService struct {
+	Album string `form:"album"`
+}
+
+// AudioMeta 获取单个音频文件的元数据
+func (service *FileIDService) AudioMeta(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, file, res := prepareAudio(c)
+	if fs == nil {
+		return res
+	}
+	defer fs.Recycle()
+
+	var cached *model.AudioMeta
+	if meta, ok := model.GetAudioMetaByFileIDs([]uint{file.ID})[file.ID]; ok {
+		cached = &meta
+	}
+
+	meta, err := fs.AudioMeta(ctx, file, cached)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	return serializer.Response{Data: serializer.BuildAudioMeta(meta)}
+}
+
+// AudioCover 输出音频文件内嵌的封面图片
+func (service *FileIDService) AudioCover(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, file, res := prepareAudio(c)
+	if fs == nil {
+		return res
+	}
+	defer fs.Recycle()
+
+	picture, err := fs.AudioCover(ctx, file)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Cover not found", err)
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("max-age=%d", model.GetIntSetting("preview_timeout", 60)))
+	c.Data(200, picture.MIMEType, picture.Data)
+	return serializer.Response{Code: -1}
+}
+
+// List 列出目录下音频文件的元数据，尚未提取的文件每次最多提取 audio_meta_batch 个
+func (service *AudioFolderService) List(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	exist, folder := fs.IsPathExist(service.Path)
+	if !exist {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
+	children, err := folder.GetChildFiles()
+	if err != nil {
+		return serializer.Err(serializer.CodeDBError, "Failed to list files", err)
+	}
+
+	audios := make([]model.File, 0, len(children))
+	ids := make([]uint, 0, len(children))
+	for _, file := range children {
+		if filesystem.IsAudio(&file) {
+			audios = append(audios, file)
+			ids = append(ids, file.ID)
+		}
+	}
+
+	cached := model.GetAudioMetaByFileIDs(ids)
+	remain := model.GetIntSetting("audio_meta_batch", 20)
+	res := make([]serializer.AudioMeta, 0, len(audios))
+	pending := 0
+	for i := range audios {
+		// 保险库中的音频无法提取元数据
+		if audios[i].IsOpaque() {
+			continue
+		}
+
+		var meta *model.AudioMeta
+		if m, ok := cached[audios[i].ID]; ok {
+			meta = &m
+		}
+
+		if meta == nil || meta.IsStale(&audios[i]) {
+			if remain <= 0 {
+				pending++
+				continue
+			}
+
+			remain--
+			if meta, err = fs.AudioMeta(ctx, &audios[i], meta); err != nil {
+				pending++
+				continue
+			}
+		}
+
+		res = append(res, serializer.BuildAudioMeta(meta))
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"tracks":  res,
+		"pending": pending,
+	}}
+}
+
+// Search 按标题、艺术家、专辑搜索音乐库
+func (service *AudioSearchService) Search(c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	if service.Page == 0 {
+		service.Page = 1
+	}
+
+	metas, total := model.SearchAudioMeta(user.ID, service.Keywords, service.Page, 50)
+	return serializer.Response{Data: map[string]interface{}{
+		"total":  total,
+		"tracks": serializer.BuildAudioMetaList(metas),
+	}}
+}
+
+// List 列出音乐库中的专辑，指定专辑时列出其中的音频
+func (service *AudioAlbumService) List(c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	if service.Album != "" {
+		return serializer.Response{Data: serializer.BuildAudioMetaList(model.ListAudioMetaByAlbum(user.ID, service.Album))}
+	}
+
+	return serializer.Response{Data: serializer.BuildAudioAlbums(model.ListAudioAlbums(user.ID))}
+}
+
+// prepareAudio 创建文件系统并取得目标音频文件，失败时返回 nil 及错误响应
+func prepareAudio(c *gin.Context) (*filesystem.FileSystem, *model.File, serializer.Response) {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return nil, nil, serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		fs.Recycle()
+		return nil, nil, serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := fs.FileTarget[0]
+	if !filesystem.IsAudio(&file) {
+		fs.Recycle()
+		return nil, nil, serializer.ParamErr("Unsupported audio format", nil)
+	}
+
+	return fs, &file, serializer.Response{}
+}
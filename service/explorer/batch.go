@@ -0,0 +1,119 @@
+package explorer
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// 批量操作支持的动作
+const (
+	BatchActionMove      = "move"
+	BatchActionRename    = "rename"
+	BatchActionDelete    = "delete"
+	BatchActionCreateDir = "createdir"
+)
+
+// BatchOperation 批量操作中的单个操作
+type BatchOperation struct {
+	Action  string        `json:"action" binding:"required,oneof=move rename delete createdir"`
+	Src     ItemIDService `json:"src"`
+	SrcDir  string        `json:"src_dir" binding:"max=65535"`
+	Dst     string        `json:"dst" binding:"max=65535"`
+	NewName string        `json:"new_name" binding:"max=255"`
+	Path    string        `json:"path" binding:"max=65535"`
+}
+
+// BatchService 批量执行文件操作服务
+type BatchService struct {
+	Operations  []BatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+	StopOnError bool             `json:"stop_on_error"`
+}
+
+// BatchResult 单个操作的执行结果
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Action string `json:"action"`
+	Code   int    `json:"code"`
+	Msg    string `json:"msg,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Execute 使用同一文件系统按顺序执行各操作，返回每个操作的结果
+func (service *BatchService) Execute(ctx context.Context, c *gin.Context) serializer.Response {
+	// 创建文件系统
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, c.GetHeader(filesystem.LockTokenHeader))
+	results := make([]BatchResult, 0, len(service.Operations))
+	for i := range service.Operations {
+		op := &service.Operations[i]
+		res := op.execute(ctx, fs)
+		results = append(results, BatchResult{
+			Index:  i,
+			Action: op.Action,
+			Code:   res.Code,
+			Msg:    res.Msg,
+			Error:  res.Error,
+		})
+
+		if res.Code != 0 && service.StopOnError {
+			break
+		}
+	}
+
+	return serializer.Response{Data: results}
+}
+
+// execute 执行单个操作，执行前清空上一操作留下的目标对象
+func (op *BatchOperation) execute(ctx context.Context, fs *filesystem.FileSystem) serializer.Response {
+	fs.CleanTargets()
+	items := op.Src.Raw()
+
+	switch op.Action {
+	case BatchActionCreateDir:
+		if op.Path == "" {
+			return serializer.ParamErr("Path is required", nil)
+		}
+		if _, err := fs.CreateDirectory(ctx, op.Path); err != nil {
+			return serializer.Err(serializer.CodeCreateFolderFailed, err.Error(), err)
+		}
+	case BatchActionMove:
+		if op.SrcDir == "" || op.Dst == "" {
+			return serializer.ParamErr("Source and destination directory are required", nil)
+		}
+		if err := fs.Move(ctx, items.Dirs, items.Items, op.SrcDir, op.Dst); err != nil {
+			return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		}
+	case BatchActionRename:
+		if op.NewName == "" {
+			return serializer.ParamErr("New name is required", nil)
+		}
+		if len(items.Items)+len(items.Dirs) != 1 {
+			return filesystem.ErrOneObjectOnly
+		}
+		if err := fs.Rename(ctx, items.Dirs, items.Items, op.NewName); err != nil {
+			return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		}
+	case BatchActionDelete:
+		force, unlink := false, false
+		if fs.User.Group.OptionsSerialized.AdvanceDelete {
+			force = op.Src.Force
+			unlink = op.Src.UnlinkOnly
+		}
+		if err := fs.Delete(ctx, items.Dirs, items.Items, force, unlink); err != nil {
+			return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		}
+	default:
+		return serializer.ParamErr("Unknown action", nil)
+	}
+
+	return serializer.Response{}
+}
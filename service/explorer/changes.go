@@ -0,0 +1,71 @@
+package explorer
+
+import (
+	"strconv"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultFileChangeLimit 每次返回的默认变更条数
+const defaultFileChangeLimit = 500
+
+// FileChangesService 增量拉取文件变更服务
+type FileChangesService struct {
+	Since string `form:"since"`
+	Limit int    `form:"limit" binding:"min=0,max=1000"`
+}
+
+// List 列出游标之后的文件变更，未指定游标或游标已失效时返回最新游标并要求客户端全量同步
+func (service *FileChangesService) List(c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+
+	// 将暂存的变更写入日志，确保本次返回包含已完成的全部操作
+	if err := model.FlushFileChanges(); err != nil {
+		return serializer.DBErr("Failed to flush file changes", err)
+	}
+
+	oldest, latest, err := model.GetFileChangeRange()
+	if err != nil {
+		return serializer.DBErr("Failed to get change cursor", err)
+	}
+
+	if service.Since == "" {
+		return serializer.Response{Data: serializer.BuildFileChangeList(nil, latest, false, true)}
+	}
+
+	since, err := strconv.ParseUint(service.Since, 10, 64)
+	if err != nil {
+		return serializer.ParamErr("Invalid cursor", err)
+	}
+
+	// 游标之后的部分变更已被清理
+	if oldest > 0 && uint(since)+1 < oldest {
+		return serializer.Response{Data: serializer.BuildFileChangeList(nil, latest, false, true)}
+	}
+
+	limit := service.Limit
+	if limit == 0 {
+		limit = defaultFileChangeLimit
+	}
+
+	changes, err := model.ListFileChanges(user.ID, uint(since), limit+1)
+	if err != nil {
+		return serializer.DBErr("Failed to list file changes", err)
+	}
+
+	hasMore := len(changes) > limit
+	cursor := uint(since)
+	if hasMore {
+		changes = changes[:limit]
+	} else if latest > cursor {
+		// 已拉取全部变更，游标前移至日志末尾以跳过其他用户的记录
+		cursor = latest
+	}
+	if len(changes) > 0 && changes[len(changes)-1].ID > cursor {
+		cursor = changes[len(changes)-1].ID
+	}
+
+	return serializer.Response{Data: serializer.BuildFileChangeList(changes, cursor, hasMore, false)}
+}
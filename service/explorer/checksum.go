@@ -0,0 +1,56 @@
+package explorer
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/gin-gonic/gin"
+)
+
+// Verify 创建重新计算文件内容哈希以检查文件是否损坏的任务
+func (service *FileIDService) Verify(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	job, err := task.NewVerifyTask(fs.User, fs.FileTarget[0].ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateTaskError, "", err)
+	}
+	task.TaskPoll.Submit(job)
+
+	return serializer.Response{}
+}
+
+// Rehydrate 创建为归档文件发起解冻的任务，解冻完成后文件会自动转回标准存储
+func (service *FileIDService) Rehydrate(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	if file := fs.FileTarget[0]; !file.IsArchived() || file.IsRehydrating() {
+		return serializer.Err(serializer.CodeNotSet, "", filesystem.ErrNotArchived)
+	}
+
+	job, err := task.NewRehydrateTask(fs.User, []uint{fs.FileTarget[0].ID})
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateTaskError, "", err)
+	}
+	task.TaskPoll.Submit(job)
+
+	return serializer.Response{}
+}
@@ -0,0 +1,68 @@
+package explorer
+
+import (
+	"context"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/gin-gonic/gin"
+)
+
+// DuplicateResolveService 处理重复文件服务，文件ID与分析任务结果中的一致
+type DuplicateResolveService struct {
+	Keep       string   `json:"keep" binding:"required"`
+	Duplicates []string `json:"duplicates" binding:"required,min=1,max=1000"`
+	// Link 为 true 时保留重复文件的记录，改为引用 Keep 的物理文件
+	Link bool `json:"link"`
+}
+
+// FindDuplicates 创建重复文件分析任务
+func FindDuplicates(c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	job, err := task.NewDedupTask(fs.User)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateTaskError, "", err)
+	}
+	task.TaskPoll.Submit(job)
+
+	return serializer.Response{}
+}
+
+// Resolve 删除重复文件或将其替换为对保留文件的引用
+func (service *DuplicateResolveService) Resolve(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	keep, err := hashid.DecodeHashID(service.Keep, hashid.FileID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "", err)
+	}
+
+	duplicates := make([]uint, 0, len(service.Duplicates))
+	for _, hashID := range service.Duplicates {
+		id, err := hashid.DecodeHashID(hashID, hashid.FileID)
+		if err != nil {
+			return serializer.Err(serializer.CodeNotFound, "", err)
+		}
+		if id == keep {
+			return serializer.ParamErr("Duplicates must not contain the kept file", nil)
+		}
+		duplicates = append(duplicates, id)
+	}
+
+	if err := fs.ResolveDuplicates(ctx, keep, duplicates, service.Link); err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	return serializer.Response{}
+}
@@ -0,0 +1,86 @@
+package explorer
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// DeltaSignatureService 获取文件分块签名服务
+type DeltaSignatureService struct {
+	BlockSize int `form:"block_size"`
+}
+
+// DeltaPatchService 提交增量内容服务，请求体为增量指令流
+type DeltaPatchService struct {
+	Base      string `form:"base" binding:"required"`
+	BlockSize int    `form:"block_size"`
+	// SHA256 重建后完整内容的 SHA-256，用于校验
+	SHA256 string `form:"sha256"`
+}
+
+// Signature 返回文件当前内容的分块签名，供客户端计算需要上传的增量
+func (service *DeltaSignatureService) Signature(ctx context.Context, c *gin.Context) serializer.Response {
+	blockSize, err := filesystem.DeltaBlockSize(service.BlockSize)
+	if err != nil {
+		return serializer.Err(serializer.CodeParamErr, err.Error(), err)
+	}
+
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := fs.FileTarget[0]
+	sig, err := fs.Signature(ctx, &file, blockSize)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	return serializer.Response{Data: sig}
+}
+
+// Patch 以原内容和请求体中的增量重建文件，保存为文件的新版本
+func (service *DeltaPatchService) Patch(ctx context.Context, c *gin.Context) serializer.Response {
+	blockSize, err := filesystem.DeltaBlockSize(service.BlockSize)
+	if err != nil {
+		return serializer.Err(serializer.CodeParamErr, err.Error(), err)
+	}
+
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, c.GetHeader(filesystem.LockTokenHeader))
+	if err := fs.CheckLocks(ctx, nil, []uint{fs.FileTarget[0].ID}); err != nil {
+		return serializer.Err(serializer.CodeFileLocked, err.Error(), err)
+	}
+
+	// 增量请求体计入上传流量
+	if size, err := strconv.ParseUint(c.Request.Header.Get("Content-Length"), 10, 64); err == nil {
+		fs.Use("AfterUpload", filesystem.HookAddUploadTraffic(size))
+	}
+
+	file := fs.FileTarget[0]
+	body := filesystem.LimitReadCloser(c.Request.Body, fs.User.Group.OptionsSerialized.UploadSpeedLimit)
+	if err := fs.PatchFile(ctx, &file, service.Base, blockSize, body, service.SHA256); err != nil {
+		return serializer.Err(serializer.CodeUploadFailed, err.Error(), err)
+	}
+
+	return serializer.Response{Data: map[string]uint64{"size": file.Size}}
+}
@@ -2,7 +2,11 @@ package explorer
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
 
+	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/gin-gonic/gin"
@@ -10,7 +14,11 @@ import (
 
 // DirectoryService 创建新目录服务
 type DirectoryService struct {
-	Path string `uri:"path" json:"path" binding:"required,min=1,max=65535"`
+	Path    string `uri:"path" json:"path" binding:"required,min=1,max=65535"`
+	Limit   int    `form:"limit" json:"-" binding:"min=0,max=1000"`
+	Cursor  string `form:"cursor" json:"-"`
+	OrderBy string `form:"order_by" json:"-" binding:"omitempty,eq=name|eq=size|eq=date|eq=type"`
+	Order   string `form:"order" json:"-" binding:"omitempty,eq=asc|eq=desc"`
 }
 
 // ListDirectory 列出目录内容
@@ -26,21 +34,76 @@ func (service *DirectoryService) ListDirectory(c *gin.Context) serializer.Respon
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// 获取子项目
-	objects, err := fs.List(ctx, service.Path, nil)
+	// 未指定分页或排序时列出全部子项目
+	if service.Limit == 0 && service.Cursor == "" && service.OrderBy == "" {
+		objects, err := fs.List(ctx, service.Path, nil)
+		if err != nil {
+			return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		}
+
+		return serializer.Response{
+			Code: 0,
+			Data: serializer.BuildObjectList(service.parentID(fs), objects, fs.Policy),
+		}
+	}
+
+	offset, err := decodeListCursor(service.Cursor)
+	if err != nil {
+		return serializer.ParamErr("Invalid cursor", err)
+	}
+
+	opt := filesystem.ListOption{
+		OrderBy: service.OrderBy,
+		Desc:    service.Order == "desc",
+		Offset:  offset,
+		Limit:   service.Limit,
+	}
+	objects, total, err := fs.ListPage(ctx, service.Path, opt, nil)
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
 	}
 
-	var parentID uint
-	if len(fs.DirTarget) > 0 {
-		parentID = fs.DirTarget[0].ID
+	res := serializer.BuildObjectList(service.parentID(fs), objects, fs.Policy)
+	res.Total = total
+	if service.Limit > 0 && offset+service.Limit < total {
+		res.NextCursor = encodeListCursor(offset + service.Limit)
 	}
 
 	return serializer.Response{
 		Code: 0,
-		Data: serializer.BuildObjectList(parentID, objects, fs.Policy),
+		Data: res,
+	}
+}
+
+// parentID 返回列取目录的 ID
+func (service *DirectoryService) parentID(fs *filesystem.FileSystem) uint {
+	if len(fs.DirTarget) > 0 {
+		return fs.DirTarget[0].ID
+	}
+	return 0
+}
+
+// encodeListCursor 将分页偏移量编码为游标
+func encodeListCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// decodeListCursor 解析分页游标，空游标表示第一页
+func decodeListCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid offset")
 	}
+	return offset, nil
 }
 
 // CreateDirectory 创建目录
@@ -66,3 +129,82 @@ func (service *DirectoryService) CreateDirectory(c *gin.Context) serializer.Resp
 	}
 
 }
+
+// FolderTemplateService 按目录模板批量创建目录服务
+type FolderTemplateService struct {
+	// Path 创建目录结构的位置
+	Path string `json:"path" binding:"required,min=1,max=65535"`
+	// Template 使用的模板名称，未指定时使用 Structure
+	Template  string            `json:"template" binding:"required_without=Structure,max=64"`
+	Structure string            `json:"structure" binding:"max=4096"`
+	Vars      map[string]string `json:"vars"`
+}
+
+// Create 按目录模板创建目录结构
+func (service *FolderTemplateService) Create(c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	structure := service.Structure
+	if service.Template != "" {
+		template, ok := fs.User.FolderTemplate(service.Template)
+		if !ok {
+			return serializer.Err(serializer.CodeNotFound, "Folder template not exist", nil)
+		}
+		structure = template.Structure
+	}
+
+	paths, err := filesystem.ExpandFolderTemplate(structure, service.Vars)
+	if err != nil {
+		return serializer.Err(serializer.CodeParamErr, err.Error(), err)
+	}
+
+	created, err := fs.CreateFromTemplate(context.Background(), service.Path, paths)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFolderFailed, err.Error(), err)
+	}
+
+	return serializer.Response{Data: created}
+}
+
+// FolderQuotaService 设定目录容量上限服务
+type FolderQuotaService struct {
+	Quota uint64 `json:"quota"`
+}
+
+// GetQuota 获取目录的容量上限及已用容量
+func (service *FolderQuotaService) GetQuota(c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	folders, err := model.GetFoldersByIDs([]uint{c.MustGet("object_id").(uint)}, user.ID)
+	if err != nil || len(folders) == 0 {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+
+	used, err := folders[0].TotalSize()
+	if err != nil {
+		return serializer.DBErr("Failed to calculate folder size", err)
+	}
+
+	return serializer.Response{Data: map[string]uint64{
+		"quota": folders[0].Quota,
+		"used":  used,
+	}}
+}
+
+// SetQuota 设定目录的容量上限，0 表示不限制
+func (service *FolderQuotaService) SetQuota(c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	folders, err := model.GetFoldersByIDs([]uint{c.MustGet("object_id").(uint)}, user.ID)
+	if err != nil || len(folders) == 0 {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+
+	if err := folders[0].SetQuota(service.Quota); err != nil {
+		return serializer.DBErr("Failed to update folder quota", err)
+	}
+
+	return serializer.Response{}
+}
@@ -1,14 +1,19 @@
 package explorer
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha1"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"strconv"
 	"strings"
@@ -124,8 +129,20 @@ func (service *ArchiveService) DownloadArchived(ctx context.Context, c *gin.Cont
 	c.Header("Content-Type", "application/zip")
 	itemService := archiveSession.(ItemIDService)
 	items := itemService.Raw()
+	ctx = context.WithValue(ctx, fsctx.ArchiveEncodingCtx, itemService.Encoding)
+	if err := fs.CheckTraffic(0); err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	if model.GetIntSetting("archive_resume_ttl", 0) > 0 {
+		return service.downloadResumable(ctx, c, fs, items)
+	}
 	ctx = context.WithValue(ctx, fsctx.GinCtx, c)
-	err = fs.Compress(ctx, c.Writer, items.Dirs, items.Items, true)
+
+	// 打包文件大小无法预知，按实际写出的字节数计入下载流量
+	counter := &util.CountWriter{W: fs.LimitWriter(ctx, c.Writer)}
+	err = fs.Compress(ctx, counter, items.Dirs, items.Items, true)
+	fs.AddTraffic(0, counter.Count)
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, "Failed to compress file", err)
 	}
@@ -135,6 +152,53 @@ func (service *ArchiveService) DownloadArchived(ctx context.Context, c *gin.Cont
 	}
 }
 
+// downloadResumable 将打包文件保留在临时目录中，支持按范围续传
+func (service *ArchiveService) downloadResumable(ctx context.Context, c *gin.Context, fs *filesystem.FileSystem, items *ItemService) serializer.Response {
+	counter := &util.CountWriter{W: fs.LimitWriter(ctx, c.Writer)}
+	defer func() {
+		fs.AddTraffic(0, counter.Count)
+	}()
+
+	// 首次请求时边打包边输出；带有 Range 的请求需要等待打包完成
+	var w io.Writer = counter
+	if c.GetHeader("Range") != "" {
+		w = nil
+	}
+
+	archivePath, streamed, err := fs.CompressResumable(ctx, service.ID, w, items.Dirs, items.Items)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, "Failed to compress file", err)
+	}
+	if streamed {
+		return serializer.Response{}
+	}
+
+	archive, err := os.Open(archivePath)
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to open archive file", err)
+	}
+	defer archive.Close()
+
+	stat, err := archive.Stat()
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to open archive file", err)
+	}
+
+	filesystem.ServeContent(&archiveResponseWriter{ResponseWriter: c.Writer, w: counter}, c.Request,
+		"archive.zip", stat.ModTime(), uint64(stat.Size()), archive)
+	return serializer.Response{}
+}
+
+// archiveResponseWriter 经由限速、计数后写出响应正文
+type archiveResponseWriter struct {
+	http.ResponseWriter
+	w io.Writer
+}
+
+func (w *archiveResponseWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
 // Download 签名的匿名文件下载
 func (service *FileAnonymousGetService) Download(ctx context.Context, c *gin.Context) serializer.Response {
 	fs, err := filesystem.NewAnonymousFileSystem()
@@ -151,13 +215,13 @@ func (service *FileAnonymousGetService) Download(ctx context.Context, c *gin.Con
 
 	// 获取文件流
 	rs, err := fs.GetDownloadContent(ctx, 0)
-	defer rs.Close()
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
 	}
+	defer rs.Close()
 
 	// 发送文件
-	http.ServeContent(c.Writer, c.Request, service.Name, fs.FileTarget[0].UpdatedAt, rs)
+	filesystem.ServeContent(c.Writer, c.Request, service.Name, fs.FileTarget[0].UpdatedAt, fs.FileTarget[0].Size, rs)
 
 	return serializer.Response{
 		Code: 0,
@@ -221,6 +285,18 @@ func (service *FileIDService) CreateDocPreviewSession(ctx context.Context, c *gi
 		objectID = uint(0)
 	}
 
+	// 检查用户组预览限制
+	if len(fs.FileTarget) == 0 {
+		files, _ := model.GetFilesByIDs([]uint{objectID.(uint)}, fs.User.ID)
+		if len(files) == 0 {
+			return serializer.Err(serializer.CodeFileNotFound, "", nil)
+		}
+		fs.SetTargetFile(&files)
+	}
+	if err := fs.ValidatePreview(filesystem.PreviewOffice, fs.FileTarget[0].Size); err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
 	// 获取文件临时下载地址
 	downloadURL, err := fs.GetDownloadURL(ctx, objectID.(uint), "doc_preview_timeout")
 	if err != nil {
@@ -288,6 +364,7 @@ func (service *FileIDService) CreateDownloadSession(ctx context.Context, c *gin.
 	objectID, _ := c.Get("object_id")
 
 	// 获取下载地址
+	ctx = context.WithValue(ctx, fsctx.DownloadClientIPCtx, c.ClientIP())
 	downloadURL, err := fs.GetDownloadURL(ctx, objectID.(uint), "download_timeout")
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
@@ -315,6 +392,17 @@ func (service *DownloadService) Download(ctx context.Context, c *gin.Context) se
 	}
 	fs.FileTarget = []model.File{file.(model.File)}
 
+	// 执行分享等来源设定的额外限制
+	if limit, ok := cache.Get("download_limit_" + service.ID); ok {
+		sessionLimit := limit.(fsctx.DownloadLimit)
+		release, ok := filesystem.AcquireDownloadSlot(&sessionLimit)
+		if !ok {
+			return serializer.Err(serializer.CodeTooManyRequests, "Too many concurrent downloads for this share", nil)
+		}
+		defer release()
+		ctx = context.WithValue(ctx, fsctx.DownloadLimitCtx, &sessionLimit)
+	}
+
 	// 开始处理下载
 	ctx = context.WithValue(ctx, fsctx.GinCtx, c)
 	rs, err := fs.GetDownloadContent(ctx, 0)
@@ -329,10 +417,11 @@ func (service *DownloadService) Download(ctx context.Context, c *gin.Context) se
 	if fs.User.Group.OptionsSerialized.OneTimeDownload {
 		// 清理资源，删除临时文件
 		_ = cache.Deletes([]string{service.ID}, "download_")
+		_ = cache.Deletes([]string{service.ID}, "download_limit_")
 	}
 
 	// 发送文件
-	http.ServeContent(c.Writer, c.Request, fs.FileTarget[0].Name, fs.FileTarget[0].UpdatedAt, rs)
+	filesystem.ServeContent(c.Writer, c.Request, fs.FileTarget[0].Name, fs.FileTarget[0].UpdatedAt, fs.FileTarget[0].Size, rs)
 
 	return serializer.Response{
 		Code: 0,
@@ -369,6 +458,25 @@ func (service *FileIDService) PreviewContent(ctx context.Context, c *gin.Context
 		objectID = uint(0)
 	}
 
+	// 先确定目标文件，以便在打开上游内容前限制从机节点的中转并发
+	if len(fs.FileTarget) == 0 {
+		files, err := model.GetFilesByIDs([]uint{objectID.(uint)}, fs.User.ID)
+		if err != nil || len(files) == 0 {
+			return serializer.Err(serializer.CodeFileNotFound, "", err)
+		}
+		fs.SetTargetFile(&files)
+	}
+
+	release, position, err := filesystem.AcquireProxySlot(ctx, fs.FileTarget[0].GetPolicy(), fs.User.ID,
+		filesystem.ProxyTicket(fs.User.ID, fs.FileTarget[0].ID, c.ClientIP()), filesystem.ProxyQueueWait())
+	if err != nil {
+		c.Header("Retry-After", "5")
+		res := serializer.Err(serializer.CodeTooManyRequests, err.Error(), err)
+		res.Data = map[string]int{"queue_position": position}
+		return res
+	}
+	defer release()
+
 	// 获取文件预览响应
 	resp, err := fs.Preview(ctx, objectID.(uint), isText)
 	if err != nil {
@@ -389,9 +497,19 @@ func (service *FileIDService) PreviewContent(ctx context.Context, c *gin.Context
 
 	if isText {
 		c.Header("Cache-Control", "no-cache")
+
+		// 返回内容 ETag，保存时需通过 If-Match 携带以检测并发修改
+		etag, err := filesystem.ContentETag(resp.Content)
+		if err != nil {
+			return serializer.Err(serializer.CodeIOFailed, "Failed to read file", err)
+		}
+		if _, err := resp.Content.Seek(0, io.SeekStart); err != nil {
+			return serializer.Err(serializer.CodeIOFailed, "Failed to read file", err)
+		}
+		c.Header("ETag", etag)
 	}
 
-	http.ServeContent(c.Writer, c.Request, fs.FileTarget[0].Name, fs.FileTarget[0].UpdatedAt, resp.Content)
+	filesystem.ServeContent(c.Writer, c.Request, fs.FileTarget[0].Name, fs.FileTarget[0].UpdatedAt, fs.FileTarget[0].Size, resp.Content)
 
 	return serializer.Response{
 		Code: 0,
@@ -433,6 +551,48 @@ func (service *FileIDService) PutContent(ctx context.Context, c *gin.Context) se
 	}
 	fileData.Name = originFile[0].Name
 
+	// 检查用户组编辑限制
+	size := originFile[0].Size
+	if fileSize > size {
+		size = fileSize
+	}
+	if err := fs.ValidatePreview(filesystem.PreviewText, size); err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, c.GetHeader(filesystem.LockTokenHeader))
+	if err := fs.CheckLocks(ctx, nil, []uint{originFile[0].ID}); err != nil {
+		return serializer.Err(serializer.CodeFileLocked, err.Error(), err)
+	}
+
+	// 检查文件是否已被其他会话修改
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		return serializer.ParamErr("If-Match header is required", nil)
+	}
+
+	current, etag, err := currentContent(ctx, fs, &originFile[0])
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	if !filesystem.MatchETag(ifMatch, etag) {
+		yours, _ := ioutil.ReadAll(io.LimitReader(c.Request.Body, int64(model.GetIntSetting("maxEditSize", 2<<20))))
+		return serializer.Response{
+			Code: serializer.CodeEditConflict,
+			Msg:  "File has been modified by another session",
+			Data: serializer.EditConflict{
+				ETag:    etag,
+				Current: string(current),
+				Yours:   string(yours),
+			},
+		}
+	}
+
+	hash := sha1.New()
+	body := filesystem.LimitReadCloser(c.Request.Body, fs.User.Group.OptionsSerialized.UploadSpeedLimit)
+	fileData.File = teeReadCloser{Reader: io.TeeReader(body, hash), Closer: body}
+
 	// 检查此文件是否有软链接
 	fileList, err := model.RemoveFilesWithSoftLinks([]model.File{originFile[0]})
 	if err == nil && len(fileList) == 0 {
@@ -452,7 +612,9 @@ func (service *FileIDService) PutContent(ctx context.Context, c *gin.Context) se
 	fs.Use("BeforeUpload", filesystem.HookResetPolicy)
 	fs.Use("BeforeUpload", filesystem.HookValidateFile)
 	fs.Use("BeforeUpload", filesystem.HookValidateCapacityDiff)
+	fs.Use("BeforeUpload", filesystem.HookValidateFolderQuotaDiff)
 	fs.Use("AfterUpload", filesystem.GenericAfterUpdate)
+	fs.Use("AfterUpload", filesystem.HookAddUploadTraffic(fileSize))
 
 	// 执行上传
 	uploadCtx = context.WithValue(uploadCtx, fsctx.FileModelCtx, originFile[0])
@@ -461,9 +623,43 @@ func (service *FileIDService) PutContent(ctx context.Context, c *gin.Context) se
 		return serializer.Err(serializer.CodeUploadFailed, err.Error(), err)
 	}
 
+	etag = `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+	c.Header("ETag", etag)
 	return serializer.Response{
 		Code: 0,
+		Data: map[string]string{"etag": etag},
+	}
+}
+
+// currentContent 读取文件当前内容及其 ETag，超出在线编辑大小限制的文件只计算 ETag
+func currentContent(ctx context.Context, fs *filesystem.FileSystem, file *model.File) ([]byte, string, error) {
+	fs.SetTargetFile(&[]model.File{*file})
+	defer fs.CleanTargets()
+
+	rs, err := fs.GetContent(ctx, file.ID)
+	if err != nil {
+		return nil, "", err
 	}
+	defer rs.Close()
+
+	if file.Size > uint64(model.GetIntSetting("maxEditSize", 2<<20)) {
+		etag, err := filesystem.ContentETag(rs)
+		return nil, etag, err
+	}
+
+	content, err := ioutil.ReadAll(rs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	etag, err := filesystem.ContentETag(bytes.NewReader(content))
+	return content, etag, err
+}
+
+// teeReadCloser 读取时同步写入摘要的请求体
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
 }
 
 // Sources 批量获取对象的外链
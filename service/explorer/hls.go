@@ -0,0 +1,161 @@
+package explorer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/transcode"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// HLSService 视频转码 HLS 播放列表及分片服务
+type HLSService struct {
+	Quality string `uri:"quality" binding:"required"`
+	Segment string `uri:"segment" binding:"required"`
+}
+
+// HLSMaster 输出视频各清晰度的主播放列表
+func (service *FileIDService) HLSMaster(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, res := prepareHLS(c)
+	if fs == nil {
+		return res
+	}
+	defer fs.Recycle()
+
+	id := hashid.HashID(fs.FileTarget[0].ID, hashid.FileID)
+	playlist := transcode.MasterPlaylist(transcode.EnabledQualities(), func(q transcode.Quality) string {
+		return fmt.Sprintf("/api/v3/file/hls/%s/%s/%s", id, q.Name, transcode.PlaylistName)
+	})
+
+	c.Header("Cache-Control", "no-cache")
+	c.Data(200, transcode.PlaylistContentType, []byte(playlist))
+	return serializer.Response{Code: -1}
+}
+
+// Serve 输出指定清晰度的播放列表或分片，请求播放列表时按需启动转码
+func (service *HLSService) Serve(ctx context.Context, c *gin.Context) serializer.Response {
+	quality, err := transcode.GetQuality(service.Quality)
+	if err != nil {
+		return serializer.ParamErr(err.Error(), err)
+	}
+
+	isPlaylist := service.Segment == transcode.PlaylistName
+	if !isPlaylist && !transcode.IsSegmentName(service.Segment) {
+		return serializer.ParamErr("Invalid segment name", nil)
+	}
+
+	fs, res := prepareHLS(c)
+	if fs == nil {
+		return res
+	}
+	defer fs.Recycle()
+
+	file := &fs.FileTarget[0]
+	dir := transcode.CacheDir(file, quality)
+	if !isPlaylist {
+		if !util.Exists(filepath.Join(dir, service.Segment)) {
+			return serializer.Err(serializer.CodeNotFound, "Segment not found", nil)
+		}
+
+		c.Header("Content-Type", transcode.SegmentContentType)
+		c.Header("Cache-Control", "private, max-age=86400")
+		http.ServeFile(c.Writer, c.Request, filepath.Join(dir, service.Segment))
+		return serializer.Response{Code: -1}
+	}
+
+	input, err := hlsInput(ctx, fs, file)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	if err := transcode.Prepare(input, dir, quality); err != nil {
+		if err == transcode.ErrTooManyJobs || err == transcode.ErrNotReady {
+			c.Header("Retry-After", "5")
+			return serializer.Err(serializer.CodeTooManyRequests, err.Error(), err)
+		}
+		return serializer.Err(serializer.CodeIOFailed, "Failed to transcode video", err)
+	}
+
+	c.Header("Content-Type", transcode.PlaylistContentType)
+	c.Header("Cache-Control", "no-cache")
+	http.ServeFile(c.Writer, c.Request, filepath.Join(dir, transcode.PlaylistName))
+	return serializer.Response{Code: -1}
+}
+
+// prepareHLS 检查转码权限并创建指向目标文件的文件系统，失败时返回 nil 及错误响应
+func prepareHLS(c *gin.Context) (*filesystem.FileSystem, serializer.Response) {
+	if !model.IsTrueVal(model.GetSettingByName("hls_enabled")) {
+		return nil, serializer.Err(serializer.CodeFeatureNotEnabled, "Video transcoding is not enabled", nil)
+	}
+
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return nil, serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+
+	if !fs.User.Group.OptionsSerialized.HLS {
+		fs.Recycle()
+		return nil, serializer.Err(serializer.CodeGroupNotAllowed, "", nil)
+	}
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		fs.Recycle()
+		return nil, serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := &fs.FileTarget[0]
+	if !util.IsInExtensionList(strings.Split(model.GetSettingByName("hls_exts"), ","), file.Name) {
+		fs.Recycle()
+		return nil, serializer.Err(serializer.CodeParamErr, "Unsupported video format", nil)
+	}
+
+	if err := fs.ValidatePreview(filesystem.PreviewVideo, file.Size); err != nil {
+		fs.Recycle()
+		return nil, serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	if file.IsQuarantined() {
+		fs.Recycle()
+		return nil, serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileQuarantined)
+	}
+
+	if file.IsBlocked() {
+		fs.Recycle()
+		return nil, serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileBlocked)
+	}
+
+	if file.IsOpaque() {
+		fs.Recycle()
+		return nil, serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileOpaque)
+	}
+
+	return fs, serializer.Response{}
+}
+
+// hlsInput 返回 ffmpeg 读取源文件的路径，本机存储直接读取物理文件，
+// 其他存储策略（包括从机）读取签名后的源文件地址
+func hlsInput(ctx context.Context, fs *filesystem.FileSystem, file *model.File) (string, error) {
+	if file.GetPolicy().Type == "local" {
+		return util.RelativePath(file.SourceName), nil
+	}
+
+	source, err := fs.SignURL(ctx, file, int64(model.GetIntSetting("hls_job_timeout", 7200)), false)
+	if err != nil {
+		return "", err
+	}
+
+	sourceURL, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+	return model.GetSiteURL().ResolveReference(sourceURL).String(), nil
+}
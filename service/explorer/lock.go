@@ -0,0 +1,62 @@
+package explorer
+
+import (
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// FileLockService 文件加锁服务
+type FileLockService struct {
+	// TTL 锁的有效期（秒），未指定时使用站点默认值
+	TTL  int    `json:"ttl" binding:"min=0"`
+	Note string `json:"note" binding:"max=255"`
+}
+
+// Lock 为文件加锁，请求头中携带当前锁令牌时刷新锁的有效期
+func (service *FileLockService) Lock(c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	lock, err := fs.LockFile(&fs.FileTarget[0], c.GetHeader(filesystem.LockTokenHeader), service.TTL, service.Note)
+	if err != nil {
+		if lock != nil {
+			return serializer.Response{
+				Code: serializer.CodeFileLocked,
+				Msg:  err.Error(),
+				Data: serializer.BuildFileLock(lock),
+			}
+		}
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	res := serializer.BuildFileLock(lock)
+	res.Token = lock.Token
+	return serializer.Response{Data: res}
+}
+
+// Unlock 使用请求头中的锁令牌解除文件锁
+func (service *FileIDService) Unlock(c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	if err := fs.UnlockFile(&fs.FileTarget[0], c.GetHeader(filesystem.LockTokenHeader), false); err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	return serializer.Response{}
+}
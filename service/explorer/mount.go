@@ -0,0 +1,175 @@
+package explorer
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// 可挂载的外部存储类型
+const (
+	MountTypeS3       = "s3"
+	MountTypeOneDrive = "onedrive"
+)
+
+// MountListService 列出挂载服务
+type MountListService struct {
+}
+
+// MountService 挂载管理服务
+type MountService struct {
+	ID uint `uri:"id" binding:"required,min=1"`
+}
+
+// MountCreateService 挂载外部存储服务
+type MountCreateService struct {
+	// Path 挂载点所在的目录
+	Path string `json:"path" binding:"required,min=1,max=65535"`
+	// Name 挂载点目录名称，不能与已有目录重名
+	Name string `json:"name" binding:"required,min=1,max=255"`
+	Type string `json:"type" binding:"required,oneof=s3 onedrive"`
+	// Server S3 为 Endpoint，OneDrive 为 Graph API 地址，可留空使用国际版
+	Server string `json:"server" binding:"max=255"`
+	// Bucket S3 为存储桶名称，OneDrive 为应用的客户端 ID
+	Bucket string `json:"bucket" binding:"required,max=255"`
+	Region string `json:"region" binding:"max=255"`
+	// AccessKey S3 为 Access Key，OneDrive 为 Refresh Token
+	AccessKey string `json:"access_key" binding:"required,max=65535"`
+	// SecretKey S3 为 Secret Key，OneDrive 为应用的客户端密码
+	SecretKey string `json:"secret_key" binding:"required,max=65535"`
+	PathStyle bool   `json:"path_style"`
+	// Prefix 文件在外部存储中的存放路径前缀，为空时使用 cloudreve
+	Prefix string `json:"prefix" binding:"max=255"`
+}
+
+// Mounts 列出用户的挂载
+func (service *MountListService) Mounts(c *gin.Context, user *model.User) serializer.Response {
+	mounts, err := model.GetMountsByUID(user.ID)
+	if err != nil {
+		return serializer.DBErr("Failed to list mounts", err)
+	}
+
+	res := make([]serializer.Mount, 0, len(mounts))
+	for _, mount := range mounts {
+		policy, err := model.GetPolicyByID(mount.PolicyID)
+		if err != nil {
+			continue
+		}
+		res = append(res, serializer.BuildMount(&mount, &policy))
+	}
+
+	return serializer.Response{Data: res}
+}
+
+// Create 在指定目录下新建挂载点目录，并将外部存储挂载至此
+func (service *MountCreateService) Create(ctx context.Context, c *gin.Context) serializer.Response {
+	// 创建文件系统
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if !fs.User.Group.OptionsSerialized.Mount {
+		return serializer.Err(serializer.CodeGroupNotAllowed, "", nil)
+	}
+
+	exist, parent := fs.IsPathExist(service.Path)
+	if !exist {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
+	// 挂载点不能位于其他挂载中
+	if mount, err := model.GetMountOfFolder(parent); err == nil && mount.ID > 0 {
+		return serializer.ParamErr("Cannot mount inside another mount", nil)
+	}
+
+	policy := service.policy(fs.User)
+	if policy.Server != "" {
+		if err := netpolicy.CheckPublicEndpoint(policy.Server); err != nil {
+			return serializer.ParamErr("Endpoint must be a public address", err)
+		}
+	}
+
+	fs.Policy = &policy
+	if err := fs.DispatchHandler(); err != nil {
+		return serializer.ParamErr("Invalid storage configuration", err)
+	}
+
+	folder, err := fs.CreateDirectory(ctx, path.Join(service.Path, service.Name))
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFolderFailed, err.Error(), err)
+	}
+
+	mount := &model.Mount{
+		Name:     service.Name,
+		UserID:   fs.User.ID,
+		FolderID: folder.ID,
+	}
+	if err := mount.Create(&policy); err != nil {
+		// 回滚新建的挂载点目录
+		if err := model.DeleteFolderByIDs([]uint{folder.ID}); err != nil {
+			util.Log().Warning("Failed to delete mount point folder %d: %s", folder.ID, err)
+		}
+		return serializer.DBErr("Failed to create mount", err)
+	}
+
+	return serializer.Response{Data: serializer.BuildMount(mount, &policy)}
+}
+
+// policy 生成挂载专用的存储策略
+func (service *MountCreateService) policy(user *model.User) model.Policy {
+	prefix := strings.Trim(service.Prefix, "/")
+	if prefix == "" {
+		prefix = "cloudreve"
+	}
+
+	policy := model.Policy{
+		Name:         service.Name,
+		Type:         service.Type,
+		Server:       service.Server,
+		BucketName:   service.Bucket,
+		IsPrivate:    true,
+		AccessKey:    service.AccessKey,
+		SecretKey:    service.SecretKey,
+		DirNameRule:  prefix + "/{path}",
+		FileNameRule: "{originname}",
+		OptionsSerialized: model.PolicyOption{
+			Region:           service.Region,
+			S3ForcePathStyle: service.PathStyle,
+			ChunkSize:        25 << 20,
+			MountOwner:       user.ID,
+		},
+	}
+
+	if service.Type == MountTypeOneDrive {
+		if policy.Server == "" {
+			policy.Server = "https://graph.microsoft.com/v1.0"
+		}
+		policy.BaseURL = "https://login.microsoftonline.com/common/oauth2/v2.0"
+		policy.OptionsSerialized.OdDriver = "me/drive"
+	}
+
+	return policy
+}
+
+// Delete 解除挂载，挂载点目录下的文件记录将被移除，外部存储中的文件不受影响
+func (service *MountService) Delete(c *gin.Context, user *model.User) serializer.Response {
+	mount, err := model.GetMountByID(service.ID, user.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Mount not exist", err)
+	}
+
+	if err := mount.Delete(); err != nil {
+		return serializer.DBErr("Failed to delete mount", err)
+	}
+
+	return serializer.Response{}
+}
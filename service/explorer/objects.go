@@ -13,6 +13,7 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/task"
@@ -25,12 +26,15 @@ type ItemMoveService struct {
 	SrcDir string        `json:"src_dir" binding:"required,min=1,max=65535"`
 	Src    ItemIDService `json:"src"`
 	Dst    string        `json:"dst" binding:"required,min=1,max=65535"`
+	// Async 为 true 时通过任务逐个处理对象，对象数超过站点设定时也会使用任务
+	Async bool `json:"async"`
 }
 
 // ItemRenameService 处理多文件/目录重命名
 type ItemRenameService struct {
-	Src     ItemIDService `json:"src"`
-	NewName string        `json:"new_name" binding:"required,min=1,max=255"`
+	Src     ItemIDService          `json:"src"`
+	NewName string                 `json:"new_name" binding:"required_without=Rule,max=255"`
+	Rule    *filesystem.RenameRule `json:"rule"`
 }
 
 // ItemService 处理多文件/目录相关服务
@@ -46,13 +50,16 @@ type ItemIDService struct {
 	Source     *ItemService
 	Force      bool `json:"force"`
 	UnlinkOnly bool `json:"unlink"`
+	// Encoding 打包下载时文件名的编码
+	Encoding string `json:"encoding" binding:"omitempty,oneof=utf-8 gbk"`
 }
 
 // ItemCompressService 文件压缩任务服务
 type ItemCompressService struct {
-	Src  ItemIDService `json:"src"`
-	Dst  string        `json:"dst" binding:"required,min=1,max=65535"`
-	Name string        `json:"name" binding:"required,min=1,max=255"`
+	Src      ItemIDService `json:"src"`
+	Dst      string        `json:"dst" binding:"required,min=1,max=65535"`
+	Name     string        `json:"name" binding:"required,min=1,max=255"`
+	Encoding string        `json:"encoding" binding:"omitempty,oneof=utf-8 gbk"`
 }
 
 // ItemDecompressService 文件解压缩任务服务
@@ -131,17 +138,7 @@ func (service *ItemDecompressService) CreateDecompressTask(c *gin.Context) seria
 	}
 
 	// 支持的压缩格式后缀
-	var (
-		suffixes = []string{".zip", ".gz", ".xz", ".tar", ".rar"}
-		matched  bool
-	)
-	for _, suffix := range suffixes {
-		if strings.HasSuffix(file.Name, suffix) {
-			matched = true
-			break
-		}
-	}
-	if !matched {
+	if !filesystem.IsDecompressible(file.Name) {
 		return serializer.Err(serializer.CodeUnsupportedArchiveType, "", nil)
 	}
 
@@ -224,7 +221,7 @@ func (service *ItemCompressService) CreateCompressTask(c *gin.Context) serialize
 
 	// 创建任务
 	job, err := task.NewCompressTask(fs.User, path.Join(service.Dst, service.Name), service.Src.Raw().Dirs,
-		service.Src.Raw().Items)
+		service.Src.Raw().Items, service.Encoding)
 	if err != nil {
 		return serializer.Err(serializer.CodeCreateTaskError, "", err)
 	}
@@ -248,8 +245,24 @@ func (service *ItemIDService) Archive(ctx context.Context, c *gin.Context) seria
 		return serializer.Err(serializer.CodeGroupNotAllowed, "", nil)
 	}
 
-	// 创建打包下载会话
+	// 检查用户组打包大小限制
+	if limit := fs.User.Group.OptionsSerialized.ArchiveSize; limit > 0 {
+		items := service.Raw()
+		size, err := selectionSize(fs.User.ID, items.Dirs, items.Items)
+		if err != nil {
+			return serializer.DBErr("Failed to list selected files", err)
+		}
+
+		if size > limit {
+			return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+		}
+	}
+
+	// 创建打包下载会话，可续传时会话需保留至续传期限结束
 	ttl := model.GetIntSetting("archive_timeout", 30)
+	if resumeTTL := model.GetIntSetting("archive_resume_ttl", 0); resumeTTL > ttl {
+		ttl = resumeTTL
+	}
 	downloadSessionID := util.RandStringRunes(16)
 	cache.Set("archive_"+downloadSessionID, *service, ttl)
 	cache.Set("archive_user_"+downloadSessionID, *fs.User, ttl)
@@ -282,6 +295,7 @@ func (service *ItemIDService) Delete(ctx context.Context, c *gin.Context) serial
 
 	// 删除对象
 	items := service.Raw()
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, c.GetHeader(filesystem.LockTokenHeader))
 	err = fs.Delete(ctx, items.Dirs, items.Items, force, unlink)
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
@@ -302,8 +316,16 @@ func (service *ItemMoveService) Move(ctx context.Context, c *gin.Context) serial
 	}
 	defer fs.Recycle()
 
-	// 移动对象
+	// 移动对象，交由任务处理时同样需要在提交前检查文件锁
 	items := service.Src.Raw()
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, c.GetHeader(filesystem.LockTokenHeader))
+	if service.useTask() {
+		if err := fs.CheckLocks(ctx, items.Dirs, items.Items); err != nil {
+			return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		}
+		return service.submitTask(fs, false, c.GetHeader(filesystem.LockTokenHeader))
+	}
+
 	err = fs.Move(ctx, items.Dirs, items.Items, service.SrcDir, service.Dst)
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
@@ -317,8 +339,8 @@ func (service *ItemMoveService) Move(ctx context.Context, c *gin.Context) serial
 
 // Copy 复制对象
 func (service *ItemMoveService) Copy(ctx context.Context, c *gin.Context) serializer.Response {
-	// 复制操作只能对一个目录或文件对象进行操作
-	if len(service.Src.Items)+len(service.Src.Dirs) > 1 {
+	// 同步复制只能对一个目录或文件对象进行操作
+	if len(service.Src.Items)+len(service.Src.Dirs) > 1 && !service.useTask() {
 		return filesystem.ErrOneObjectOnly
 	}
 
@@ -329,6 +351,10 @@ func (service *ItemMoveService) Copy(ctx context.Context, c *gin.Context) serial
 	}
 	defer fs.Recycle()
 
+	if service.useTask() {
+		return service.submitTask(fs, true, "")
+	}
+
 	// 复制对象
 	err = fs.Copy(ctx, service.Src.Raw().Dirs, service.Src.Raw().Items, service.SrcDir, service.Dst)
 	if err != nil {
@@ -341,8 +367,36 @@ func (service *ItemMoveService) Copy(ctx context.Context, c *gin.Context) serial
 
 }
 
-// Rename 重命名对象
+// useTask 返回是否需要通过任务执行移动、复制
+func (service *ItemMoveService) useTask() bool {
+	return service.Async || len(service.Src.Items)+len(service.Src.Dirs) > model.GetIntSetting("relocate_sync_max", 100)
+}
+
+// submitTask 创建批量移动、复制任务
+func (service *ItemMoveService) submitTask(fs *filesystem.FileSystem, isCopy bool, lockToken string) serializer.Response {
+	if exist, _ := fs.IsPathExist(service.SrcDir); !exist {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+	if exist, _ := fs.IsPathExist(service.Dst); !exist {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
+	items := service.Src.Raw()
+	job, err := task.NewRelocateTask(fs.User, isCopy, service.SrcDir, service.Dst, items.Dirs, items.Items, lockToken)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateTaskError, "", err)
+	}
+	task.TaskPoll.Submit(job)
+
+	return serializer.Response{Data: map[string]interface{}{"async": true}}
+}
+
+// Rename 重命名对象，指定重命名规则时批量重命名多个对象
 func (service *ItemRenameService) Rename(ctx context.Context, c *gin.Context) serializer.Response {
+	if service.Rule != nil {
+		return service.batchRename(ctx, c, false)
+	}
+
 	// 重命名作只能对一个目录或文件对象进行操作
 	if len(service.Src.Items)+len(service.Src.Dirs) > 1 {
 		return filesystem.ErrOneObjectOnly
@@ -356,6 +410,7 @@ func (service *ItemRenameService) Rename(ctx context.Context, c *gin.Context) se
 	defer fs.Recycle()
 
 	// 重命名对象
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, c.GetHeader(filesystem.LockTokenHeader))
 	err = fs.Rename(ctx, service.Src.Raw().Dirs, service.Src.Raw().Items, service.NewName)
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
@@ -366,6 +421,66 @@ func (service *ItemRenameService) Rename(ctx context.Context, c *gin.Context) se
 	}
 }
 
+// PreviewRename 预览按规则批量重命名的结果，不修改任何对象
+func (service *ItemRenameService) PreviewRename(ctx context.Context, c *gin.Context) serializer.Response {
+	if service.Rule == nil {
+		return serializer.ParamErr("Rename rule is required", nil)
+	}
+
+	return service.batchRename(ctx, c, true)
+}
+
+// batchRename 按规则批量重命名对象，dryRun 为 true 时只返回重命名结果
+func (service *ItemRenameService) batchRename(ctx context.Context, c *gin.Context, dryRun bool) serializer.Response {
+	if len(service.Src.Items)+len(service.Src.Dirs) == 0 {
+		return serializer.ParamErr("No object to rename", nil)
+	}
+
+	// 创建文件系统
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	ctx = context.WithValue(ctx, fsctx.LockTokenCtx, c.GetHeader(filesystem.LockTokenHeader))
+
+	var plans []filesystem.RenamePlan
+	if dryRun {
+		plans, err = fs.PlanRename(ctx, service.Src.Raw().Dirs, service.Src.Raw().Items, service.Rule)
+	} else {
+		plans, err = fs.BatchRename(ctx, service.Src.Raw().Dirs, service.Src.Raw().Items, service.Rule)
+	}
+
+	if err != nil && plans == nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	res := make([]serializer.RenamePreview, 0, len(plans))
+	for _, plan := range plans {
+		preview := serializer.RenamePreview{
+			ID:       hashid.HashID(plan.ID, hashid.FileID),
+			Type:     "file",
+			OldName:  plan.OldName,
+			NewName:  plan.NewName,
+			Conflict: plan.Conflict,
+		}
+		if plan.IsDir {
+			preview.ID = hashid.HashID(plan.ID, hashid.FolderID)
+			preview.Type = "dir"
+		}
+		res = append(res, preview)
+	}
+
+	if err != nil {
+		appErr := serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		appErr.Data = res
+		return appErr
+	}
+
+	return serializer.Response{Data: res}
+}
+
 // GetProperty 获取对象的属性
 func (service *ItemPropertyService) GetProperty(ctx context.Context, c *gin.Context) serializer.Response {
 	userCtx, _ := c.Get("user")
@@ -390,6 +505,12 @@ func (service *ItemPropertyService) GetProperty(ctx context.Context, c *gin.Cont
 		props.UpdatedAt = file[0].UpdatedAt
 		props.Policy = file[0].GetPolicy().Name
 		props.Size = file[0].Size
+		props.MD5 = file[0].MetadataSerialized[model.MD5MetadataKey]
+		props.SHA256 = file[0].MetadataSerialized[model.SHA256MetadataKey]
+		props.ChecksumStatus = file[0].MetadataSerialized[model.ChecksumStatusMetadataKey]
+		if locks, err := model.GetActiveFileLocks([]uint{file[0].ID}); err == nil && len(locks) > 0 {
+			props.Lock = serializer.BuildFileLock(&locks[0])
+		}
 
 		// 查找父目录
 		if service.TraceRoot {
@@ -440,11 +561,9 @@ func (service *ItemPropertyService) GetProperty(ctx context.Context, c *gin.Cont
 			return serializer.DBErr("Failed to list child files", err)
 		}
 
-		// 统计子文件个数和大小
+		// 统计子文件个数，大小使用目录的缓存结果
 		props.ChildFileNum = len(files)
-		for i := 0; i < len(files); i++ {
-			props.Size += files[i].Size
-		}
+		props.Size = folder[0].Size
 
 		// 查找父目录
 		if service.TraceRoot {
@@ -465,3 +584,33 @@ func (service *ItemPropertyService) GetProperty(ctx context.Context, c *gin.Cont
 		Data: props,
 	}
 }
+
+// selectionSize 计算所选文件及目录下所有文件的总大小
+func selectionSize(uid uint, dirs, items []uint) (uint64, error) {
+	var size uint64
+	if len(items) > 0 {
+		files, err := model.GetFilesByIDs(items, uid)
+		if err != nil {
+			return 0, err
+		}
+		for _, file := range files {
+			size += file.Size
+		}
+	}
+
+	if len(dirs) > 0 {
+		folders, err := model.GetRecursiveChildFolder(dirs, uid, true)
+		if err != nil {
+			return 0, err
+		}
+		files, err := model.GetChildFilesOfFolders(&folders)
+		if err != nil {
+			return 0, err
+		}
+		for _, file := range files {
+			size += file.Size
+		}
+	}
+
+	return size, nil
+}
@@ -0,0 +1,268 @@
+package explorer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/email"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/onlyoffice"
+	"github.com/cloudreve/Cloudreve/v3/pkg/request"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+)
+
+// OnlyOfficeSessionService 创建 OnlyOffice 文档会话的服务
+type OnlyOfficeSessionService struct {
+	Action string `form:"action" binding:"omitempty,eq=view|eq=edit"`
+}
+
+// OnlyOfficeService 文档服务器访问文档会话的服务
+type OnlyOfficeService struct {
+	SessionID string `uri:"session" binding:"required"`
+}
+
+// Create 为当前用户的文件创建 OnlyOffice 编辑器配置
+func (service *OnlyOfficeSessionService) Create(ctx context.Context, c *gin.Context) serializer.Response {
+	if !onlyoffice.Enabled() {
+		return serializer.Err(serializer.CodeFeatureNotEnabled, "OnlyOffice is not enabled", nil)
+	}
+
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+	file := &fs.FileTarget[0]
+
+	maxSize := model.GetIntSetting("maxEditSize", 0)
+	if maxSize > 0 && file.Size > uint64(maxSize) {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	if err := fs.ValidatePreview(filesystem.PreviewOffice, file.Size); err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	action := onlyoffice.ActionEdit
+	if service.Action == string(onlyoffice.ActionView) {
+		action = onlyoffice.ActionView
+	}
+
+	// 创建文档会话
+	sessionID := uuid.Must(uuid.NewV4()).String()
+	session := onlyoffice.SessionCache{
+		FileID: file.ID,
+		UserID: fs.User.ID,
+		Action: action,
+		Key:    onlyoffice.DocumentKey(file),
+	}
+	ttl := model.GetIntSetting("onlyoffice_session_timeout", 36000)
+	if err := cache.Set(onlyoffice.SessionCachePrefix+sessionID, session, ttl); err != nil {
+		return serializer.Err(serializer.CodeCacheOperation, "Failed to create document session", err)
+	}
+
+	base := model.GetSiteURL()
+	contentURL := base.ResolveReference(&url.URL{Path: "/api/v3/onlyoffice/content/" + sessionID})
+	callbackURL := base.ResolveReference(&url.URL{Path: "/api/v3/onlyoffice/callback/" + sessionID})
+	config, err := onlyoffice.NewConfig(file, fs.User, action, contentURL.String(), callbackURL.String(),
+		email.ParseLang(c.GetHeader("Accept-Language")), hashid.HashID(fs.User.ID, hashid.UserID))
+	if err != nil {
+		return serializer.Err(serializer.CodeParamErr, err.Error(), err)
+	}
+
+	return serializer.Response{
+		Data: serializer.OnlyOfficeSession{
+			Endpoint: onlyoffice.APIURL(),
+			Config:   config,
+		},
+	}
+}
+
+// Content 向文档服务器输出文档内容
+func (service *OnlyOfficeService) Content(ctx context.Context, c *gin.Context) serializer.Response {
+	if err := onlyoffice.VerifyHeader(c.GetHeader("Authorization"), model.GetSettingByName("onlyoffice_secret")); err != nil {
+		return serializer.Err(serializer.CodeInvalidSign, "", err)
+	}
+
+	fs, _, err := service.prepareFs()
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, err.Error(), err)
+	}
+	defer fs.Recycle()
+
+	resp, err := fs.Preview(ctx, fs.FileTarget[0].ID, true)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	if resp.Redirect {
+		c.Redirect(http.StatusFound, resp.URL)
+		return serializer.Response{Code: -1}
+	}
+
+	defer resp.Content.Close()
+	c.Header("Cache-Control", "no-cache")
+	http.ServeContent(c.Writer, c.Request, fs.FileTarget[0].Name, fs.FileTarget[0].UpdatedAt, resp.Content)
+	return serializer.Response{Code: -1}
+}
+
+// Callback 处理文档服务器的保存回调，编辑后的文档保存为文件的新版本
+func (service *OnlyOfficeService) Callback(ctx context.Context, c *gin.Context) error {
+	body, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	callback, err := onlyoffice.ParseCallback(body, c.GetHeader("Authorization"), model.GetSettingByName("onlyoffice_secret"))
+	if err != nil {
+		return err
+	}
+
+	if !callback.ShouldSave() {
+		return nil
+	}
+
+	fs, session, err := service.prepareFs()
+	if err != nil {
+		return err
+	}
+	defer fs.Recycle()
+
+	if session.Action != onlyoffice.ActionEdit {
+		return fmt.Errorf("read-only session")
+	}
+
+	if callback.Key != session.Key {
+		return fmt.Errorf("document key mismatch")
+	}
+	file := &fs.FileTarget[0]
+	if err := filesystem.CheckFileLocks([]uint{file.ID}, ""); err != nil {
+		return err
+	}
+
+	if err := onlyoffice.CheckDocumentURL(callback.URL); err != nil {
+		return err
+	}
+
+	resp := request.NewClient().Request("GET", callback.URL, nil, request.WithContext(ctx)).CheckHTTPResponse(200)
+	if resp.Err != nil {
+		return resp.Err
+	}
+	defer resp.Response.Body.Close()
+
+	stream := &fsctx.FileStream{
+		File: resp.Response.Body,
+		Size: uint64(resp.Response.ContentLength),
+	}
+
+	// 未知长度时读入内存
+	if resp.Response.ContentLength < 0 {
+		maxSize := int64(model.GetIntSetting("maxEditSize", 52428800))
+		content, err := ioutil.ReadAll(io.LimitReader(resp.Response.Body, maxSize+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(content)) > maxSize {
+			return filesystem.ErrFileSizeTooBig
+		}
+		stream.File = ioutil.NopCloser(bytes.NewReader(content))
+		stream.Size = uint64(len(content))
+	}
+
+	author := fs.User.Nick
+	if len(callback.Users) > 0 {
+		if uid, err := hashid.DecodeHashID(callback.Users[0], hashid.UserID); err == nil && uid != fs.User.ID {
+			if editor, err := model.GetActiveUserByID(uid); err == nil {
+				author = editor.Nick
+			}
+		}
+	}
+
+	if err := fs.SaveNewVersion(ctx, file, stream, author); err != nil {
+		util.Log().Warning("Failed to save OnlyOffice document %d: %s", file.ID, err)
+		return err
+	}
+
+	return nil
+}
+
+func (service *OnlyOfficeService) prepareFs() (*filesystem.FileSystem, *onlyoffice.SessionCache, error) {
+	sessionRaw, exist := cache.Get(onlyoffice.SessionCachePrefix + service.SessionID)
+	if !exist {
+		return nil, nil, fmt.Errorf("document session not found")
+	}
+	session := sessionRaw.(onlyoffice.SessionCache)
+
+	user, err := model.GetActiveUserByID(session.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	fs, err := filesystem.NewFileSystem(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := fs.SetTargetFileByIDs([]uint{session.FileID}); err != nil {
+		fs.Recycle()
+		return nil, nil, fmt.Errorf("failed to find file: %w", err)
+	}
+
+	return fs, &session, nil
+}
+
+// ListVersions 列出文件的历史版本
+func (service *FileIDService) ListVersions(ctx context.Context, c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	file, err := model.GetFilesByIDs([]uint{c.MustGet("object_id").(uint)}, user.ID)
+	if err != nil || len(file) == 0 {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	return serializer.Response{Data: serializer.BuildFileVersions(model.ListFileVersions(file[0].ID))}
+}
+
+// FileVersionService 文件历史版本操作服务
+type FileVersionService struct {
+	Version uint `json:"version" binding:"required"`
+}
+
+// Restore 将文件恢复为指定的历史版本
+func (service *FileVersionService) Restore(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	version, err := model.GetFileVersion(service.Version, fs.FileTarget[0].ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Version not found", err)
+	}
+
+	if err := fs.RestoreVersion(ctx, &fs.FileTarget[0], version); err != nil {
+		return serializer.Err(serializer.CodeUploadFailed, err.Error(), err)
+	}
+
+	return serializer.Response{}
+}
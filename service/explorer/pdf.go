@@ -0,0 +1,128 @@
+package explorer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/pdf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// PDFPageService PDF 页面预览图服务
+type PDFPageService struct {
+	Page int `uri:"page" binding:"required,min=1"`
+}
+
+// PDFInfo 获取 PDF 文件的页数
+func (service *FileIDService) PDFInfo(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, input, res := preparePDF(ctx, c)
+	if fs == nil {
+		return res
+	}
+	defer fs.Recycle()
+
+	pages, err := pdf.PageCount(ctx, model.GetSettingByName("pdf_preview_pdfinfo_path"), input)
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to parse PDF file", err)
+	}
+
+	return serializer.Response{Data: map[string]int{"pages": pages}}
+}
+
+// Serve 输出 PDF 指定页面的预览图
+func (service *PDFPageService) Serve(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, input, res := preparePDF(ctx, c)
+	if fs == nil {
+		return res
+	}
+	defer fs.Recycle()
+
+	page, err := pdf.Page(ctx, input, pdf.CacheDir(&fs.FileTarget[0]), service.Page)
+	if err == pdf.ErrPageOutOfRange {
+		return serializer.Err(serializer.CodeNotFound, err.Error(), err)
+	} else if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to render PDF page", err)
+	}
+
+	c.Header("Content-Type", pdf.PageContentType)
+	c.Header("Cache-Control", "private, max-age=86400")
+	http.ServeFile(c.Writer, c.Request, page)
+	return serializer.Response{Code: -1}
+}
+
+// preparePDF 检查预览权限并返回可供 poppler 读取的源文件路径，本机存储直接读取物理文件，
+// 其他存储策略下载至缓存目录。失败时返回 nil 及错误响应
+func preparePDF(ctx context.Context, c *gin.Context) (*filesystem.FileSystem, string, serializer.Response) {
+	if !model.IsTrueVal(model.GetSettingByName("pdf_preview_enabled")) {
+		return nil, "", serializer.Err(serializer.CodeFeatureNotEnabled, "Server-side PDF preview is not enabled", nil)
+	}
+
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return nil, "", serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		fs.Recycle()
+		return nil, "", serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := &fs.FileTarget[0]
+	if !util.IsInExtensionList([]string{"pdf"}, file.Name) {
+		fs.Recycle()
+		return nil, "", serializer.ParamErr("Not a PDF file", nil)
+	}
+
+	if max := model.GetIntSetting("pdf_preview_max_size", 0); max > 0 && file.Size > uint64(max) {
+		fs.Recycle()
+		return nil, "", serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	if file.IsQuarantined() {
+		fs.Recycle()
+		return nil, "", serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileQuarantined)
+	}
+
+	if file.IsBlocked() {
+		fs.Recycle()
+		return nil, "", serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileBlocked)
+	}
+
+	if file.IsOpaque() {
+		fs.Recycle()
+		return nil, "", serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileOpaque)
+	}
+
+	if file.GetPolicy().Type == "local" {
+		return fs, util.RelativePath(file.SourceName), serializer.Response{}
+	}
+
+	input, err := pdf.Source(pdf.CacheDir(file), func(dst string) error {
+		rs, err := fs.GetContent(ctx, file.ID)
+		if err != nil {
+			return err
+		}
+		defer rs.Close()
+
+		out, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, rs)
+		return err
+	})
+	if err != nil {
+		fs.Recycle()
+		return nil, "", serializer.Err(serializer.CodeNotSet, "", err)
+	}
+
+	return fs, input, serializer.Response{}
+}
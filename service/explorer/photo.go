@@ -0,0 +1,130 @@
+package explorer
+
+import (
+	"context"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// 时间线分组对应的日期格式
+var photoGroupLayouts = map[string]string{
+	"day":   "2006-01-02",
+	"month": "2006-01",
+	"year":  "2006",
+}
+
+// PhotoTimelineService 照片时间线服务
+type PhotoTimelineService struct {
+	Page         int     `form:"page" binding:"omitempty,min=1"`
+	Group        string  `form:"group" binding:"omitempty,eq=day|eq=month|eq=year"`
+	CameraMake   string  `form:"make"`
+	CameraModel  string  `form:"model"`
+	MinLat       float64 `form:"min_lat" binding:"omitempty,min=-90,max=90"`
+	MaxLat       float64 `form:"max_lat" binding:"omitempty,min=-90,max=90"`
+	MinLng       float64 `form:"min_lng" binding:"omitempty,min=-180,max=180"`
+	MaxLng       float64 `form:"max_lng" binding:"omitempty,min=-180,max=180"`
+	WithLocation bool    `form:"with_location"`
+}
+
+// PhotoMeta 获取单张照片的拍摄信息
+func (service *FileIDService) PhotoMeta(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := fs.FileTarget[0]
+	if !filesystem.IsPhoto(&file) {
+		return serializer.ParamErr("Unsupported image format", nil)
+	}
+
+	var cached *model.PhotoMeta
+	if meta, err := model.GetPhotoMetaByFileID(file.ID); err == nil {
+		cached = meta
+	}
+
+	meta, err := fs.PhotoMeta(ctx, &file, cached)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	return serializer.Response{Data: serializer.BuildPhotoMeta(meta)}
+}
+
+// Timeline 按拍摄日期分组列出照片
+func (service *PhotoTimelineService) Timeline(c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	if service.Page == 0 {
+		service.Page = 1
+	}
+	if service.Group == "" {
+		service.Group = "day"
+	}
+
+	metas, total := model.ListPhotoMeta(user.ID, &model.PhotoFilter{
+		CameraMake:   service.CameraMake,
+		CameraModel:  service.CameraModel,
+		MinLat:       service.MinLat,
+		MaxLat:       service.MaxLat,
+		MinLng:       service.MinLng,
+		MaxLng:       service.MaxLng,
+		WithLocation: service.WithLocation,
+	}, service.Page, model.GetIntSetting("photo_timeline_page_size", 100))
+
+	return serializer.Response{Data: map[string]interface{}{
+		"total":  total,
+		"groups": serializer.BuildPhotoTimeline(metas, photoGroupLayouts[service.Group]),
+	}}
+}
+
+// ListPhotoCameras 列出用户使用过的相机
+func ListPhotoCameras(c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	cameras := model.ListPhotoCameras(user.ID)
+
+	res := make([]map[string]interface{}, 0, len(cameras))
+	for _, camera := range cameras {
+		res = append(res, map[string]interface{}{
+			"make":  camera.CameraMake,
+			"model": camera.CameraModel,
+			"count": camera.Count,
+		})
+	}
+	return serializer.Response{Data: res}
+}
+
+// ScanPhotos 为尚未提取拍摄信息的图片提取信息，每次最多处理 photo_meta_batch 张
+func ScanPhotos(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	batch := model.GetIntSetting("photo_meta_batch", 50)
+	files, err := model.GetFilesWithoutPhotoMeta(fs.User.ID, strings.Split(model.GetSettingByName("photo_meta_exts"), ","), batch)
+	if err != nil {
+		return serializer.DBErr("Failed to list photos", err)
+	}
+
+	processed := 0
+	for i := range files {
+		if _, err := fs.PhotoMeta(ctx, &files[i], nil); err == nil {
+			processed++
+		}
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"processed": processed,
+		"more":      len(files) == batch,
+	}}
+}
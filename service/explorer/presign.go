@@ -0,0 +1,205 @@
+package explorer
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"path"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// presignedUploadPrefix 预签名上传会话的缓存键前缀
+	presignedUploadPrefix = "presigned_upload_"
+	// presignedClaimPrefix 已被领取的预签名上传会话，保证同一会话只被一个请求使用
+	presignedClaimPrefix = "presigned_claim_"
+)
+
+// PresignedUpload 预签名上传会话，绑定目标目录、文件名及大小上限
+type PresignedUpload struct {
+	UID      uint
+	FolderID uint
+	Name     string
+	MaxSize  uint64
+}
+
+func init() {
+	gob.Register(PresignedUpload{})
+}
+
+// PresignUploadService 创建预签名上传 URL 服务
+type PresignUploadService struct {
+	Path    string `json:"path" binding:"required,min=1,max=65535"`
+	Name    string `json:"name" binding:"required,min=1,max=255"`
+	MaxSize uint64 `json:"max_size" binding:"required,min=1"`
+	// Expires 有效期（秒），为 0 时使用站点默认值，不能超过站点设定的上限
+	Expires int `json:"expires" binding:"min=0"`
+}
+
+// PresignedUploadService 通过预签名 URL 上传文件服务
+type PresignedUploadService struct {
+	ID string `uri:"sessionID" binding:"required"`
+}
+
+// Create 创建一次性的预签名上传 URL，持有者无需其他凭证即可上传一个文件
+func (service *PresignUploadService) Create(c *gin.Context) serializer.Response {
+	// 创建文件系统
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	ctx := context.Background()
+	if !fs.ValidateLegalName(ctx, service.Name) {
+		return serializer.Err(serializer.CodeIllegalObjectName, "", nil)
+	}
+	if !fs.ValidateExtension(ctx, service.Name) {
+		return serializer.Err(serializer.CodeFileTypeNotAllowed, "", nil)
+	}
+	if !fs.ValidateFileSize(ctx, service.MaxSize) {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	exist, folder := fs.IsPathExist(service.Path)
+	if !exist {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
+	ttl := model.GetIntSetting("presigned_upload_timeout", 3600)
+	if service.Expires > 0 {
+		if service.Expires > model.GetIntSetting("presigned_upload_max_timeout", 604800) {
+			return serializer.ParamErr("Expiration is too long", nil)
+		}
+		ttl = service.Expires
+	}
+
+	sessionID := util.RandStringRunes(32)
+	cache.Set(presignedUploadPrefix+sessionID, PresignedUpload{
+		UID:      fs.User.ID,
+		FolderID: folder.ID,
+		Name:     service.Name,
+		MaxSize:  service.MaxSize,
+	}, ttl)
+
+	signURL, err := auth.SignURI(auth.General, fmt.Sprintf("/api/v3/file/presigned/%s", sessionID), int64(ttl))
+	if err != nil {
+		return serializer.Err(serializer.CodeEncryptError, "Failed to sign upload URL", err)
+	}
+
+	return serializer.Response{Data: serializer.PresignedUploadURL{
+		URL:     signURL.String(),
+		Expires: time.Now().Add(time.Duration(ttl) * time.Second).Unix(),
+	}}
+}
+
+// Upload 使用预签名 URL 上传文件，会话在使用后立即失效
+func (service *PresignedUploadService) Upload(ctx context.Context, c *gin.Context) serializer.Response {
+	if err := auth.CheckURI(auth.General, c.Request.URL); err != nil {
+		return serializer.Err(serializer.CodeCredentialInvalid, err.Error(), err)
+	}
+
+	sessionRaw, ok := cache.Get(presignedUploadPrefix + service.ID)
+	if !ok {
+		return serializer.Err(serializer.CodeUploadSessionExpired, "Upload URL expired or already used", nil)
+	}
+
+	// 在写入任何数据前领取会话，并发的请求中只有一个可以继续
+	claimed, err := cache.SetNX(presignedClaimPrefix+service.ID, true, model.GetIntSetting("presigned_upload_max_timeout", 604800))
+	if err != nil {
+		return serializer.Err(serializer.CodeCacheOperation, "Failed to claim upload session", err)
+	}
+	if !claimed {
+		return serializer.Err(serializer.CodeUploadSessionExpired, "Upload URL expired or already used", nil)
+	}
+	cache.Deletes([]string{service.ID}, presignedUploadPrefix)
+	session := sessionRaw.(PresignedUpload)
+
+	if c.Request.ContentLength < 0 {
+		return serializer.ParamErr("Content-Length is required", nil)
+	}
+	size := uint64(c.Request.ContentLength)
+	if size > session.MaxSize {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	user, err := model.GetActiveUserByID(session.UID)
+	if err != nil {
+		return serializer.Err(serializer.CodeCheckLogin, "User not found", err)
+	}
+
+	// 创建文件系统
+	fs, err := filesystem.NewFileSystem(&user)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	// 目标目录可能已被移动，按 ID 重新定位
+	folders, err := model.GetFoldersByIDs([]uint{session.FolderID}, user.ID)
+	if err != nil || len(folders) == 0 {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+	folder := &folders[0]
+	if err := folder.TraceRoot(); err != nil {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+
+	// 不覆盖已有文件，重名时自动重命名
+	name, err := fs.AvailableName(folder, session.Name)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ctx = context.WithValue(ctx, fsctx.HTTPCtx, c.Request.Context())
+	ctx = context.WithValue(ctx, fsctx.CancelFuncCtx, cancel)
+
+	fileData := &fsctx.FileStream{
+		MimeType:    c.Request.Header.Get("Content-Type"),
+		File:        filesystem.LimitReadCloser(c.Request.Body, user.Group.OptionsSerialized.UploadSpeedLimit),
+		Size:        size,
+		Name:        name,
+		VirtualPath: path.Join(folder.Position, folder.Name),
+	}
+
+	// 给文件系统分配钩子
+	fs.Use("BeforeUpload", filesystem.HookValidateFile)
+	fs.Use("BeforeUpload", filesystem.HookValidateCapacity)
+	fs.Use("BeforeUpload", filesystem.HookValidateFolderQuota)
+	fs.Use("AfterUploadCanceled", filesystem.HookDeleteTempFile)
+	fs.Use("AfterUploadCanceled", filesystem.HookCancelContext)
+	fs.Use("AfterUpload", filesystem.GenericAfterUpload)
+	fs.Use("AfterUpload", filesystem.HookAddUploadTraffic(size))
+	fs.Use("AfterValidateFailed", filesystem.HookDeleteTempFile)
+
+	// 执行上传
+	if err := fs.Upload(ctx, fileData); err != nil {
+		return serializer.Err(serializer.CodeUploadFailed, err.Error(), err)
+	}
+
+	file := fileData.Model.(*model.File)
+	task.SubmitScanTask(fs.User, file)
+
+	return serializer.Response{Data: serializer.Object{
+		ID:         hashid.HashID(file.ID, hashid.FileID),
+		Name:       file.Name,
+		Path:       fileData.VirtualPath,
+		Size:       file.Size,
+		Type:       "file",
+		Date:       file.UpdatedAt,
+		CreateDate: file.CreatedAt,
+	}}
+}
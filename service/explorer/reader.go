@@ -0,0 +1,153 @@
+package explorer
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/reader"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+)
+
+// ReaderEntryService 读取阅读会话中条目的服务
+type ReaderEntryService struct {
+	SessionID string `uri:"session" binding:"required"`
+	Path      string `uri:"path" binding:"required"`
+}
+
+// CreateReaderSession 为 EPUB、CBZ、CBR 文件创建阅读会话，返回章节或页面列表
+func (service *FileIDService) CreateReaderSession(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.SetTargetFileByIDs([]uint{c.MustGet("object_id").(uint)}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := &fs.FileTarget[0]
+	format := reader.Format(file.Name)
+	if format == "" {
+		return serializer.ParamErr("Unsupported book format", nil)
+	}
+
+	if max := model.GetIntSetting("reader_max_size", 0); max > 0 && file.Size > uint64(max) {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	if file.IsQuarantined() {
+		return serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileQuarantined)
+	}
+
+	if file.IsBlocked() {
+		return serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileBlocked)
+	}
+
+	if file.IsOpaque() {
+		return serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileOpaque)
+	}
+
+	// 本机存储直接读取物理文件，其他存储策略下载至缓存目录
+	archive := util.RelativePath(file.SourceName)
+	if file.GetPolicy().Type != "local" {
+		archive, err = reader.Source(reader.CacheDir(file), format, func(dst string) error {
+			rs, err := fs.GetContent(ctx, file.ID)
+			if err != nil {
+				return err
+			}
+			defer rs.Close()
+
+			out, err := os.Create(dst)
+			if err != nil {
+				return err
+			}
+			defer out.Close()
+
+			_, err = io.Copy(out, rs)
+			return err
+		})
+		if err != nil {
+			return serializer.Err(serializer.CodeNotSet, "", err)
+		}
+	}
+
+	manifest, err := reader.Parse(archive, format)
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to parse book file", err)
+	}
+
+	sessionID := uuid.Must(uuid.NewV4()).String()
+	session := reader.SessionCache{
+		FileID:  file.ID,
+		UserID:  fs.User.ID,
+		Format:  format,
+		Archive: archive,
+	}
+	if err := cache.Set(reader.SessionCachePrefix+sessionID, session, model.GetIntSetting("reader_session_ttl", 3600)); err != nil {
+		return serializer.Err(serializer.CodeCacheOperation, "Failed to create reader session", err)
+	}
+
+	base := model.GetSiteURL()
+	res := serializer.ReaderSession{
+		Session: sessionID,
+		Format:  manifest.Format,
+		Title:   manifest.Title,
+		Entries: make([]serializer.ReaderEntry, 0, len(manifest.Entries)),
+	}
+	for _, entry := range manifest.Entries {
+		entryURL := base.ResolveReference(&url.URL{Path: "/api/v3/reader/" + sessionID + "/entry/" + entry})
+		res.Entries = append(res.Entries, serializer.ReaderEntry{Name: entry, URL: entryURL.String()})
+	}
+
+	return serializer.Response{Data: res}
+}
+
+// Serve 输出阅读会话中的章节、样式表或图片
+func (service *ReaderEntryService) Serve(ctx context.Context, c *gin.Context) serializer.Response {
+	sessionRaw, exist := cache.Get(reader.SessionCachePrefix + service.SessionID)
+	if !exist {
+		return serializer.Err(serializer.CodeNotFound, "Reader session not found", nil)
+	}
+	session := sessionRaw.(reader.SessionCache)
+
+	// 文件被删除后会话随之失效
+	if files, err := model.GetFilesByIDs([]uint{session.FileID}, session.UserID); err != nil || len(files) == 0 {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+service.Path), "/")
+	if !util.Exists(session.Archive) {
+		return serializer.Err(serializer.CodeNotFound, "Reader session expired", nil)
+	}
+
+	c.Header("Content-Type", reader.ContentType(name))
+	c.Header("Content-Security-Policy", "sandbox")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, max-age=3600")
+	if err := reader.Open(session.Archive, session.Format, name, c.Writer); err != nil {
+		if c.Writer.Written() {
+			util.Log().Debug("Failed to read entry %q from book: %s", name, err)
+			return serializer.Response{Code: -1}
+		}
+
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Cache-Control")
+		if err == reader.ErrEntryNotFound {
+			return serializer.Err(serializer.CodeNotFound, err.Error(), err)
+		}
+		return serializer.Err(serializer.CodeIOFailed, "Failed to read book entry", err)
+	}
+
+	return serializer.Response{Code: -1}
+}
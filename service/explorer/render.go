@@ -0,0 +1,121 @@
+package explorer
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/render"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	renderCachePrefix = "render_"
+	// 单次渲染最多解析的相对路径图片数量
+	maxRenderAssets = 50
+)
+
+// Render 将 Markdown 或 Jupyter Notebook 文件渲染为安全的 HTML
+func (service *FileIDService) Render(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	fileID := c.MustGet("object_id").(uint)
+	if err := fs.SetTargetFileByIDs([]uint{fileID}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := fs.FileTarget[0]
+	if file.IsOpaque() {
+		return serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileOpaque)
+	}
+
+	isNotebook := util.IsInExtensionList([]string{"ipynb"}, file.Name)
+	if !isNotebook && !util.IsInExtensionList(strings.Split(model.GetSettingByName("render_markdown_exts"), ","), file.Name) {
+		return serializer.ParamErr("Unsupported file format", nil)
+	}
+
+	maxSize := model.GetIntSetting("render_max_size", 2097152)
+	if file.Size > uint64(maxSize) {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	// 文件内容变化后缓存随之失效
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d", file.SourceName, file.Size, file.UpdatedAt.Unix())))
+	cacheKey := fmt.Sprintf("%s%d_%s", renderCachePrefix, file.ID, hex.EncodeToString(sum[:8]))
+	if rendered, ok := cache.Get(cacheKey); ok {
+		return serializer.Response{Data: rendered}
+	}
+
+	rs, err := fs.GetContent(ctx, fileID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+	defer rs.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(rs, int64(maxSize)))
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to read file", err)
+	}
+
+	resolve := assetResolver(fs, &file)
+	var rendered string
+	if isNotebook {
+		if rendered, err = render.Notebook(content, resolve); err != nil {
+			return serializer.ParamErr(err.Error(), err)
+		}
+	} else {
+		rendered = render.Markdown(string(content), resolve)
+	}
+
+	_ = cache.Set(cacheKey, rendered, model.GetIntSetting("render_cache_ttl", 3600))
+	return serializer.Response{Data: rendered}
+}
+
+// assetResolver 将相对路径的图片解析为同一目录（及其子目录）下文件的预览地址
+func assetResolver(fs *filesystem.FileSystem, file *model.File) render.Resolver {
+	folders, err := model.GetFoldersByIDs([]uint{file.FolderID}, fs.User.ID)
+	if err != nil || len(folders) == 0 || folders[0].TraceRoot() != nil {
+		return nil
+	}
+
+	base := path.Join(folders[0].Position, folders[0].Name)
+	resolved := 0
+	return func(src string) string {
+		if resolved >= maxRenderAssets || strings.HasPrefix(src, "/") {
+			return ""
+		}
+
+		// 去除查询参数及锚点
+		target, err := url.PathUnescape(strings.SplitN(strings.SplitN(src, "?", 2)[0], "#", 2)[0])
+		if err != nil || target == "" {
+			return ""
+		}
+
+		full := path.Join(base, target)
+		if !strings.HasPrefix(full, strings.TrimSuffix(base, "/")+"/") {
+			return ""
+		}
+
+		resolved++
+		if exist, asset := fs.IsFileExist(full); exist {
+			return "/api/v3/file/preview/" + hashid.HashID(asset.ID, hashid.FileID)
+		}
+		return ""
+	}
+}
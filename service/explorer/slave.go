@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
@@ -88,8 +89,14 @@ func (service *SlaveDownloadService) ServeFile(ctx context.Context, c *gin.Conte
 		c.Header("Content-Disposition", "attachment; filename=\""+url.PathEscape(fs.FileTarget[0].Name)+"\"")
 	}
 
+	// 使用物理文件的修改时间，以便客户端进行条件请求与断点续传
+	modTime, size := time.Now(), uint64(0)
+	if info, err := os.Stat(util.RelativePath(file.SourceName)); err == nil {
+		modTime, size = info.ModTime(), uint64(info.Size())
+	}
+
 	// 发送文件
-	http.ServeContent(c.Writer, c.Request, fs.FileTarget[0].Name, time.Now(), rs)
+	filesystem.ServeContent(c.Writer, c.Request, fs.FileTarget[0].Name, modTime, size, rs)
 
 	return serializer.Response{}
 }
@@ -167,6 +174,16 @@ func CreateTransferTask(c *gin.Context, req *serializer.SlaveTransferReq) serial
 	return serializer.ParamErr("未知的主机节点ID", nil)
 }
 
+// CreateMetaTask 创建从机缩略图与元数据生成任务，结果由任务推送至主机
+func CreateMetaTask(c *gin.Context, req *serializer.SlaveMetaReq) serializer.Response {
+	if !util.Exists(util.RelativePath(req.Src)) {
+		return serializer.Err(serializer.CodeFileNotFound, "", nil)
+	}
+
+	task.TaskPoll.Submit(&slavetask.MetaTask{Req: req})
+	return serializer.Response{}
+}
+
 // SlaveListService 从机上传会话服务
 type SlaveCreateUploadSessionService struct {
 	Session   serializer.UploadSession `json:"session" binding:"required"`
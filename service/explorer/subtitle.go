@@ -0,0 +1,100 @@
+package explorer
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/subtitle"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSubtitles 列出视频所在目录下的外挂字幕
+func (service *FileIDService) ListSubtitles(ctx context.Context, c *gin.Context) serializer.Response {
+	user := c.MustGet("user").(*model.User)
+	video, err := model.GetFilesByIDs([]uint{c.MustGet("object_id").(uint)}, user.ID)
+	if err != nil || len(video) == 0 {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	folders, err := model.GetFoldersByIDs([]uint{video[0].FolderID}, user.ID)
+	if err != nil || len(folders) == 0 {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+
+	siblings, err := folders[0].GetChildFiles()
+	if err != nil {
+		return serializer.Err(serializer.CodeDBError, "Failed to list files", err)
+	}
+
+	res := make([]serializer.Subtitle, 0)
+	for _, file := range siblings {
+		track, ok := subtitle.Match(video[0].Name, file.Name)
+		if !ok {
+			continue
+		}
+
+		id := hashid.HashID(file.ID, hashid.FileID)
+		res = append(res, serializer.Subtitle{
+			ID:     id,
+			Name:   track.Name,
+			Lang:   track.Lang,
+			Format: track.Format,
+			URL:    "/api/v3/file/subtitle/" + id,
+		})
+	}
+
+	return serializer.Response{Data: res}
+}
+
+// Subtitle 输出转换为 WebVTT 格式的字幕内容
+func (service *FileIDService) Subtitle(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	fileID := c.MustGet("object_id").(uint)
+	if err := fs.SetTargetFileByIDs([]uint{fileID}); err != nil {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	if fs.FileTarget[0].IsOpaque() {
+		return serializer.Err(serializer.CodeNotSet, "", filesystem.ErrFileOpaque)
+	}
+
+	format := subtitle.Format(fs.FileTarget[0].Name)
+	if format == "" {
+		return serializer.ParamErr(subtitle.ErrUnsupportedFormat.Error(), nil)
+	}
+
+	maxSize := model.GetIntSetting("subtitle_max_size", 5242880)
+	if fs.FileTarget[0].Size > uint64(maxSize) {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	rs, err := fs.GetContent(ctx, fileID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+	defer rs.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(rs, int64(maxSize)))
+	if err != nil {
+		return serializer.Err(serializer.CodeIOFailed, "Failed to read subtitle", err)
+	}
+
+	vtt, err := subtitle.ToVTT(format, content)
+	if err != nil {
+		return serializer.ParamErr(err.Error(), err)
+	}
+
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Data(200, subtitle.ContentType, vtt)
+	return serializer.Response{Code: -1}
+}
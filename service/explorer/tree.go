@@ -0,0 +1,165 @@
+package explorer
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// treeExportPageSize 导出子树时每批读取的对象数量
+const treeExportPageSize = 1000
+
+// SubtreeService 平铺列出目录子树服务
+type SubtreeService struct {
+	Limit  int    `form:"limit" binding:"min=0,max=5000"`
+	Cursor string `form:"cursor"`
+}
+
+// SubtreeExportService 导出目录子树服务
+type SubtreeExportService struct {
+	Format string `form:"format" binding:"omitempty,eq=csv|eq=json"`
+}
+
+// subtreeRoot 查找并补全待列取子树的根目录
+func subtreeRoot(c *gin.Context, fs *filesystem.FileSystem) (*model.Folder, error) {
+	folders, err := model.GetFoldersByIDs([]uint{c.MustGet("object_id").(uint)}, fs.User.ID)
+	if err != nil || len(folders) == 0 {
+		return nil, filesystem.ErrPathNotExist
+	}
+
+	if err := folders[0].TraceRoot(); err != nil {
+		return nil, filesystem.ErrPathNotExist
+	}
+
+	return &folders[0], nil
+}
+
+// List 分页平铺列出目录子树下的所有目录和文件
+func (service *SubtreeService) List(c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	cursor, err := filesystem.ParseTreeCursor(service.Cursor)
+	if err != nil {
+		return serializer.ParamErr("Invalid cursor", err)
+	}
+
+	root, err := subtreeRoot(c, fs)
+	if err != nil {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+
+	limit := service.Limit
+	if limit == 0 {
+		limit = treeExportPageSize
+	}
+
+	objects, next, err := fs.ListTree(context.Background(), root, cursor, limit)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	res := serializer.SubtreeList{Objects: objects}
+	if next != nil {
+		res.NextCursor = next.String()
+	}
+
+	return serializer.Response{Data: res}
+}
+
+// Export 将目录子树下的所有目录和文件导出为 CSV 或 JSON
+func (service *SubtreeExportService) Export(c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	root, err := subtreeRoot(c, fs)
+	if err != nil {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+
+	format := service.Format
+	if format == "" {
+		format = "csv"
+	}
+
+	name := root.Name
+	if root.ParentID == nil {
+		name = "root"
+	}
+
+	ctx := context.Background()
+	c.Header("Content-Disposition", "attachment; filename=\""+url.PathEscape(name+"."+format)+"\"")
+	if format == "json" {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	}
+
+	var (
+		csvWriter *csv.Writer
+		written   int
+	)
+	if format == "csv" {
+		csvWriter = csv.NewWriter(c.Writer)
+		csvWriter.Write([]string{"type", "path", "name", "size", "updated_at", "created_at"})
+	} else {
+		c.Writer.WriteString("[")
+	}
+
+	cursor := &filesystem.TreeCursor{}
+	for cursor != nil {
+		var objects []serializer.Object
+		objects, cursor, err = fs.ListTree(ctx, root, *cursor, treeExportPageSize)
+		if err != nil {
+			// 响应已开始输出，只能中断导出
+			util.Log().Warning("Failed to export folder tree: %s", err)
+			break
+		}
+
+		for _, object := range objects {
+			if csvWriter != nil {
+				csvWriter.Write([]string{
+					object.Type,
+					object.Path,
+					object.Name,
+					strconv.FormatUint(object.Size, 10),
+					object.Date.Format(time.RFC3339),
+					object.CreateDate.Format(time.RFC3339),
+				})
+				continue
+			}
+
+			if written > 0 {
+				c.Writer.WriteString(",")
+			}
+			data, _ := json.Marshal(object)
+			c.Writer.Write(data)
+			written++
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+		}
+	}
+
+	if csvWriter == nil {
+		c.Writer.WriteString("]")
+	}
+
+	return serializer.Response{Code: -1}
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/driver/local"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/gin-gonic/gin"
 	"io/ioutil"
@@ -27,6 +29,8 @@ type CreateUploadSessionService struct {
 	PolicyID     string `json:"policy_id" binding:"required"`
 	LastModified int64  `json:"last_modified"`
 	MimeType     string `json:"mime_type"`
+	// Conflict 目标文件已存在时的处理方式，默认拒绝上传
+	Conflict string `json:"conflict" binding:"omitempty,eq=reject|eq=rename|eq=overwrite"`
 }
 
 // Create 创建新的上传会话
@@ -43,6 +47,11 @@ func (service *CreateUploadSessionService) Create(ctx context.Context, c *gin.Co
 		return serializer.Err(serializer.CodePolicyNotExist, "", err)
 	}
 
+	// 上传至挂载的外部存储时使用挂载的存储策略
+	if err := fs.SwitchToMountOf(service.Path); err != nil {
+		return serializer.Err(serializer.CodePolicyNotExist, "", err)
+	}
+
 	if fs.Policy.ID != rawID {
 		return serializer.Err(serializer.CodePolicyNotAllowed, "存储策略发生变化，请刷新文件列表并重新添加此任务", nil)
 	}
@@ -58,6 +67,7 @@ func (service *CreateUploadSessionService) Create(ctx context.Context, c *gin.Co
 		lastModified := time.UnixMilli(service.LastModified)
 		file.LastModified = &lastModified
 	}
+	ctx = context.WithValue(ctx, fsctx.ConflictModeCtx, service.Conflict)
 	credential, err := fs.CreateUploadSession(ctx, file)
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
@@ -77,6 +87,16 @@ type UploadService struct {
 
 // LocalUpload 处理本机文件分片上传
 func (service *UploadService) LocalUpload(ctx context.Context, c *gin.Context) serializer.Response {
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodePolicyNotAllowed, err.Error(), err)
+	}
+
+	return service.LocalUploadTo(ctx, c, fs)
+}
+
+// LocalUploadTo 使用给定的文件系统处理本机文件分片上传，上传会话须属于该文件系统的用户
+func (service *UploadService) LocalUploadTo(ctx context.Context, c *gin.Context, fs *filesystem.FileSystem) serializer.Response {
 	uploadSessionRaw, ok := cache.Get(filesystem.UploadSessionCachePrefix + service.ID)
 	if !ok {
 		return serializer.Err(serializer.CodeUploadSessionExpired, "", nil)
@@ -84,11 +104,6 @@ func (service *UploadService) LocalUpload(ctx context.Context, c *gin.Context) s
 
 	uploadSession := uploadSessionRaw.(serializer.UploadSession)
 
-	fs, err := filesystem.NewFileSystemFromContext(c)
-	if err != nil {
-		return serializer.Err(serializer.CodePolicyNotAllowed, err.Error(), err)
-	}
-
 	if uploadSession.UID != fs.User.ID {
 		return serializer.Err(serializer.CodeUploadSessionExpired, "", nil)
 	}
@@ -175,9 +190,16 @@ func processChunkUpload(ctx context.Context, c *gin.Context, fs *filesystem.File
 		mode |= fsctx.Overwrite
 	}
 
+	// 按顺序上传的分片可继续计算文件哈希
+	checksum := filesystem.ChunkChecksum(session.Key, index)
+	reader := filesystem.LimitReadCloser(c.Request.Body, session.SpeedLimit)
+	if checksum != nil {
+		reader = filesystem.NewChecksumReader(reader, checksum)
+	}
+
 	fileData := fsctx.FileStream{
 		MimeType:     c.Request.Header.Get("Content-Type"),
-		File:         c.Request.Body,
+		File:         reader,
 		Size:         fileSize,
 		Name:         session.Name,
 		VirtualPath:  session.VirtualPath,
@@ -194,15 +216,23 @@ func processChunkUpload(ctx context.Context, c *gin.Context, fs *filesystem.File
 
 	if file != nil {
 		fs.Use("BeforeUpload", filesystem.HookValidateCapacity)
+		fs.Use("AfterUpload", filesystem.HookChunkChecksum(session.Key, index, checksum, isLastChunk))
 		fs.Use("AfterUpload", filesystem.HookChunkUploaded)
 		fs.Use("AfterValidateFailed", filesystem.HookChunkUploadFailed)
 		if isLastChunk {
-			fs.Use("AfterUpload", filesystem.HookPopPlaceholderToFile(""))
+			if session.Replace > 0 {
+				fs.Use("AfterUpload", filesystem.HookReplaceWithPlaceholder(session.Replace, session.Name, ""))
+			} else {
+				fs.Use("AfterUpload", filesystem.HookPopPlaceholderToFile(""))
+			}
 			fs.Use("AfterUpload", filesystem.HookDeleteUploadSession(session.Key))
+			fs.Use("AfterUpload", filesystem.HookExtractPhotoMeta)
+			fs.Use("AfterUpload", filesystem.HookAddUploadTraffic(session.Size))
 		}
 	} else {
+		fs.Use("AfterUpload", filesystem.HookChunkChecksum(session.Key, index, checksum, isLastChunk))
 		if isLastChunk {
-			fs.Use("AfterUpload", filesystem.SlaveAfterUpload(session))
+			fs.Use("AfterUpload", filesystem.SlaveAfterUpload(session, checksum))
 			fs.Use("AfterUpload", filesystem.HookDeleteUploadSession(session.Key))
 		}
 	}
@@ -214,6 +244,12 @@ func processChunkUpload(ctx context.Context, c *gin.Context, fs *filesystem.File
 		return serializer.Err(serializer.CodeUploadFailed, err.Error(), err)
 	}
 
+	if file != nil && isLastChunk {
+		file = fileData.Model.(*model.File)
+		task.SubmitScanTask(fs.User, file)
+		notify.CheckQuota(fs.User)
+	}
+
 	return serializer.Response{}
 }
 
@@ -267,6 +303,41 @@ func (service *UploadSessionService) SlaveDelete(ctx context.Context, c *gin.Con
 	return serializer.Response{}
 }
 
+// ListUploadSessions 列出当前用户进行中的上传会话
+func ListUploadSessions(c *gin.Context, user *model.User) serializer.Response {
+	files := model.GetUploadPlaceholderFiles(user.ID)
+	res := make([]serializer.UploadSessionItem, 0, len(files))
+	for _, file := range files {
+		item := serializer.UploadSessionItem{
+			ID:         *file.UploadSessionID,
+			Name:       file.Name,
+			Size:       file.Size,
+			Uploaded:   int64(file.Size),
+			CreateDate: file.CreatedAt,
+			LastActive: file.UpdatedAt,
+			Expired:    true,
+		}
+
+		policy := file.GetPolicy()
+		item.PolicyType = policy.Type
+		if policy.IsUploadPlaceholderWithSize() {
+			item.Uploaded = -1
+		}
+
+		if sessionRaw, ok := cache.Get(filesystem.UploadSessionCachePrefix + *file.UploadSessionID); ok {
+			session := sessionRaw.(serializer.UploadSession)
+			item.Expired = false
+			item.Name = session.Name
+			item.Path = session.VirtualPath
+			item.Size = session.Size
+		}
+
+		res = append(res, item)
+	}
+
+	return serializer.Response{Data: res}
+}
+
 // DeleteAllUploadSession 删除当前用户的全部上传绘会话
 func DeleteAllUploadSession(ctx context.Context, c *gin.Context) serializer.Response {
 	// 创建文件系统
@@ -0,0 +1,155 @@
+package explorer
+
+import (
+	"context"
+	"path"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// VaultListService 列出保险库服务
+type VaultListService struct {
+}
+
+// VaultService 保险库管理服务
+type VaultService struct {
+	ID uint `uri:"id" binding:"required,min=1"`
+}
+
+// VaultCreateService 新建保险库服务，密钥由客户端生成并包装，服务端只做保存
+type VaultCreateService struct {
+	// Path 保险库目录所在的目录
+	Path string `json:"path" binding:"required,min=1,max=65535"`
+	// Name 保险库目录名称，不能与已有目录重名
+	Name       string `json:"name" binding:"required,min=1,max=255"`
+	WrappedKey string `json:"wrapped_key" binding:"required,max=4096"`
+	KeyParams  string `json:"key_params" binding:"max=4096"`
+}
+
+// VaultKeyService 更新保险库包装密钥服务
+type VaultKeyService struct {
+	WrappedKey string `json:"wrapped_key" binding:"required,max=4096"`
+	KeyParams  string `json:"key_params" binding:"max=4096"`
+}
+
+// VaultFileMetaService 保存保险库文件加密元数据服务，如加密后的文件名参数
+type VaultFileMetaService struct {
+	Metadata map[string]string `json:"metadata" binding:"required,max=16,dive,max=4096"`
+}
+
+// Vaults 列出用户的保险库
+func (service *VaultListService) Vaults(c *gin.Context, user *model.User) serializer.Response {
+	vaults, err := model.GetVaultsByUID(user.ID)
+	if err != nil {
+		return serializer.DBErr("Failed to list vaults", err)
+	}
+
+	res := make([]serializer.Vault, 0, len(vaults))
+	for i := range vaults {
+		res = append(res, serializer.BuildVault(&vaults[i]))
+	}
+
+	return serializer.Response{Data: res}
+}
+
+// Create 在指定目录下新建保险库目录
+func (service *VaultCreateService) Create(ctx context.Context, c *gin.Context) serializer.Response {
+	// 创建文件系统
+	fs, err := filesystem.NewFileSystemFromContext(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	exist, parent := fs.IsPathExist(service.Path)
+	if !exist {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
+	// 保险库不能嵌套
+	if vault, err := model.GetVaultOfFolder(parent); err == nil && vault.ID > 0 {
+		return serializer.ParamErr("Cannot create vault inside another vault", nil)
+	}
+
+	// 已有目录中的文件未经加密，保险库只能使用新目录
+	fullPath := path.Join(service.Path, service.Name)
+	if exist, _ := fs.IsPathExist(fullPath); exist {
+		return serializer.Err(serializer.CodeObjectExist, "", nil)
+	}
+
+	folder, err := fs.CreateDirectory(ctx, fullPath)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFolderFailed, err.Error(), err)
+	}
+
+	vault := &model.Vault{
+		UserID:     fs.User.ID,
+		FolderID:   folder.ID,
+		WrappedKey: service.WrappedKey,
+		KeyParams:  service.KeyParams,
+	}
+	if err := vault.Create(); err != nil {
+		return serializer.DBErr("Failed to create vault", err)
+	}
+
+	return serializer.Response{Data: serializer.BuildVault(vault)}
+}
+
+// UpdateKey 更新保险库的包装密钥，用于客户端更换口令
+func (service *VaultKeyService) UpdateKey(c *gin.Context, user *model.User, id uint) serializer.Response {
+	vault, err := model.GetVaultByID(id, user.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Vault not exist", err)
+	}
+
+	if err := vault.UpdateKey(service.WrappedKey, service.KeyParams); err != nil {
+		return serializer.DBErr("Failed to update vault", err)
+	}
+
+	return serializer.Response{Data: serializer.BuildVault(vault)}
+}
+
+// Delete 删除保险库，目录中已加密的文件保持不变
+func (service *VaultService) Delete(c *gin.Context, user *model.User) serializer.Response {
+	vault, err := model.GetVaultByID(service.ID, user.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Vault not exist", err)
+	}
+
+	if err := vault.Delete(); err != nil {
+		return serializer.DBErr("Failed to delete vault", err)
+	}
+
+	return serializer.Response{}
+}
+
+// Update 保存保险库文件的加密元数据
+func (service *VaultFileMetaService) Update(c *gin.Context, user *model.User) serializer.Response {
+	objectID, _ := c.Get("object_id")
+	files, err := model.GetFilesByIDs([]uint{objectID.(uint)}, user.ID)
+	if err != nil || len(files) == 0 {
+		return serializer.Err(serializer.CodeFileNotFound, "", err)
+	}
+
+	file := &files[0]
+	if !file.IsOpaque() {
+		return serializer.ParamErr("File is not in a vault", nil)
+	}
+
+	meta := make(map[string]string, len(service.Metadata))
+	for k, v := range service.Metadata {
+		if k == "" || len(k) > 64 {
+			return serializer.ParamErr("Invalid metadata key", nil)
+		}
+		meta[model.VaultMetadataPrefix+k] = v
+	}
+
+	if err := file.UpdateMetadata(meta); err != nil {
+		return serializer.DBErr("Failed to update file metadata", err)
+	}
+
+	return serializer.Response{Data: file.VaultMetadata()}
+}
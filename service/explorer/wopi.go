@@ -1,16 +1,20 @@
 package explorer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/cloudreve/Cloudreve/v3/middleware"
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/cloudreve/Cloudreve/v3/pkg/wopi"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
 	"time"
 )
 
@@ -25,9 +29,75 @@ func (service *WopiService) Rename(c *gin.Context) error {
 
 	defer fs.Recycle()
 
+	if current := wopi.GetLock(cache.Store, fs.FileTarget[0].ID); current != "" && current != c.GetHeader(wopi.LockHeader) {
+		c.Header(wopi.LockHeader, current)
+		return wopi.ErrLockMismatch
+	}
+
 	return fs.Rename(c, []uint{}, []uint{c.MustGet("object_id").(uint)}, c.GetHeader(wopi.RenameRequestHeader))
 }
 
+// PutFile saves request body as a new version of the file, the file must be locked
+// with the lock provided in request header.
+func (service *WopiService) PutFile(ctx context.Context, c *gin.Context) serializer.Response {
+	fileSize, err := strconv.ParseUint(c.Request.Header.Get("Content-Length"), 10, 64)
+	if err != nil {
+		return serializer.ParamErr("Invalid content-length value", err)
+	}
+
+	fs, _, err := service.prepareFs(c)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, err.Error(), err)
+	}
+
+	defer fs.Recycle()
+
+	file := &fs.FileTarget[0]
+	if current, err := wopi.CheckLock(cache.Store, file.ID, c.GetHeader(wopi.LockHeader), file.Size); err != nil {
+		c.Header(wopi.LockHeader, current)
+		return serializer.Err(serializer.CodeConflict, err.Error(), err)
+	}
+
+	if err := filesystem.CheckFileLocks([]uint{file.ID}, ""); err != nil {
+		return serializer.Err(serializer.CodeFileLocked, err.Error(), err)
+	}
+
+	maxSize := model.GetIntSetting("maxEditSize", 0)
+	if maxSize > 0 && fileSize > uint64(maxSize) {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	uploadCtx := context.WithValue(ctx, fsctx.GinCtx, c)
+	err = fs.SaveNewVersion(uploadCtx, file, &fsctx.FileStream{
+		MimeType: c.Request.Header.Get("Content-Type"),
+		File:     c.Request.Body,
+		Size:     fileSize,
+	}, fs.User.Nick)
+	if err != nil {
+		return serializer.Err(serializer.CodeUploadFailed, err.Error(), err)
+	}
+
+	return serializer.Response{}
+}
+
+// Lock locks, refreshes or releases the lock of the file according to given method.
+// Current lock is returned on mismatch.
+func (service *WopiService) Lock(c *gin.Context, method string) (string, error) {
+	session := c.MustGet(middleware.WopiSessionCtx).(*wopi.SessionCache)
+	lock := c.GetHeader(wopi.LockHeader)
+
+	switch method {
+	case wopi.MethodLock:
+		return wopi.Lock(cache.Store, session.FileID, lock, c.GetHeader(wopi.OldLockHeader))
+	case wopi.MethodRefreshLock:
+		return wopi.RefreshLock(cache.Store, session.FileID, lock)
+	case wopi.MethodUnlock:
+		return wopi.Unlock(cache.Store, session.FileID, lock)
+	default:
+		return wopi.GetLock(cache.Store, session.FileID), nil
+	}
+}
+
 func (service *WopiService) GetFile(c *gin.Context) error {
 	fs, _, err := service.prepareFs(c)
 	if err != nil {
@@ -108,6 +178,9 @@ func (service *WopiService) FileInfo(c *gin.Context) (*serializer.WopiFileInfo,
 		info.UserCanRename = true
 		info.UserCanReview = true
 		info.UserCanWrite = true
+		info.SupportsLocks = true
+		info.SupportsGetLock = true
+		info.SupportsExtendedLockLength = true
 		info.ReadOnly = false
 		info.BreadcrumbFolderName = parent[0].Name
 		info.BreadcrumbFolderUrl = parentUrl.String()
@@ -0,0 +1,107 @@
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/conf"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// recordEvent 记录分享访问事件，客户端 IP 仅保存带密钥的摘要
+func recordEvent(c *gin.Context, share *model.Share, typ, fileName string) {
+	if !model.IsTrueVal(model.GetSettingByName("share_analytics_enabled")) {
+		return
+	}
+
+	ip := c.ClientIP()
+	mac := hmac.New(sha256.New, []byte(conf.SystemConfig.SessionSecret))
+	mac.Write([]byte(ip))
+
+	referrer := c.Request.Referer()
+	if len(referrer) > 1024 {
+		referrer = referrer[:1024]
+	}
+
+	country, _ := netpolicy.Country(ip, c.Request)
+	event := &model.ShareEvent{
+		ShareID:  share.ID,
+		Type:     typ,
+		FileName: fileName,
+		Referrer: referrer,
+		IPHash:   hex.EncodeToString(mac.Sum(nil))[:32],
+		Country:  country,
+	}
+	if err := event.Create(); err != nil {
+		util.Log().Debug("Failed to record share event: %s", err)
+	}
+}
+
+// AnalyticsService 分享访问统计服务
+type AnalyticsService struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=365"`
+	Page int `form:"page" binding:"omitempty,min=1"`
+}
+
+// ownedShare 获取当前用户创建的分享
+func ownedShare(c *gin.Context, user *model.User) (*model.Share, bool) {
+	share := model.GetShareByHashID(c.Param("id"))
+	if share == nil || share.UserID != user.ID {
+		return nil, false
+	}
+	return share, true
+}
+
+// Summary 返回分享的每日统计与排行
+func (service *AnalyticsService) Summary(c *gin.Context, user *model.User) serializer.Response {
+	share, ok := ownedShare(c, user)
+	if !ok {
+		return serializer.Err(serializer.CodeShareLinkNotFound, "", nil)
+	}
+
+	days := service.Days
+	if days == 0 {
+		days = 30
+	}
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1-days)
+
+	daily, err := model.GetShareDailyStats(share.ID, since)
+	if err != nil {
+		return serializer.DBErr("Failed to query share analytics", err)
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"views":     share.Views,
+		"downloads": share.Downloads,
+		"daily":     daily,
+		"files":     model.GetShareEventTop(share.ID, model.ShareEventDownload, "file_name", since, 10),
+		"referrers": model.GetShareEventTop(share.ID, model.ShareEventView, "referrer", since, 10),
+		"countries": model.GetShareEventTop(share.ID, model.ShareEventView, "country", since, 10),
+		"retention": model.GetIntSetting("share_event_retention", 90),
+	}}
+}
+
+// Logs 列出分享的原始访问记录
+func (service *AnalyticsService) Logs(c *gin.Context, user *model.User) serializer.Response {
+	share, ok := ownedShare(c, user)
+	if !ok {
+		return serializer.Err(serializer.CodeShareLinkNotFound, "", nil)
+	}
+
+	page := service.Page
+	if page == 0 {
+		page = 1
+	}
+	events, total := model.ListShareEvents(share.ID, page, 50)
+	return serializer.Response{Data: map[string]interface{}{
+		"total": total,
+		"items": events,
+	}}
+}
@@ -0,0 +1,200 @@
+package share
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+	"github.com/gin-gonic/gin"
+)
+
+// 可嵌入的媒体类型
+const (
+	EmbedImage = "image"
+	EmbedVideo = "video"
+	EmbedAudio = "audio"
+)
+
+var embedExts = map[string][]string{
+	EmbedImage: {"jpg", "jpeg", "png", "gif", "webp", "bmp"},
+	EmbedVideo: {"mp4", "webm", "ogv", "m4v", "mov"},
+	EmbedAudio: {"mp3", "wav", "ogg", "oga", "flac", "m4a", "aac"},
+}
+
+var embedPlayer = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>html,body{margin:0;height:100%;background:#000;display:flex;align-items:center;justify-content:center}
+img,video,audio{max-width:100%;max-height:100%}</style></head>
+<body>{{if eq .Type "image"}}<img src="{{.Src}}" alt="{{.Title}}">{{else if eq .Type "video"}}<video src="{{.Src}}" controls playsinline preload="metadata"></video>{{else}}<audio src="{{.Src}}" controls preload="metadata"></audio>{{end}}</body></html>`))
+
+// OEmbedService oEmbed 服务
+type OEmbedService struct {
+	URL       string `form:"url" binding:"required,max=2048"`
+	MaxWidth  int    `form:"maxwidth" binding:"min=0"`
+	MaxHeight int    `form:"maxheight" binding:"min=0"`
+	Format    string `form:"format" binding:"omitempty,eq=json"`
+}
+
+// EmbedService 嵌入播放器服务
+type EmbedService struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// embedType 返回分享可被嵌入的媒体类型，不可嵌入时返回空值。
+// 仅允许未加密、开启预览的单文件分享被嵌入
+func embedType(share *model.Share) string {
+	if !model.IsTrueVal(model.GetSettingByName("share_embed_enabled")) {
+		return ""
+	}
+
+	if share.IsDir || share.Password != "" || !share.PreviewEnabled || share.IsUploadOnly() {
+		return ""
+	}
+
+	for typ, exts := range embedExts {
+		if util.IsInExtensionList(exts, share.SourceName) {
+			return typ
+		}
+	}
+
+	return ""
+}
+
+// embedToken 返回分享的嵌入凭证，凭证只能用于访问嵌入播放器和媒体内容，
+// 有效期为 share_embed_token_ttl 秒
+func embedToken(share *model.Share) string {
+	ttl := time.Duration(model.GetIntSetting("share_embed_token_ttl", 604800)) * time.Second
+	return auth.General.Sign(fmt.Sprintf("share/embed/%d", share.ID), time.Now().Add(ttl).Unix())
+}
+
+// embedURL 返回分享的嵌入地址，content 为 true 时返回媒体内容地址
+func embedURL(share *model.Share, content bool) string {
+	uri := "/api/v3/share/embed/" + share.Key()
+	if content {
+		uri += "/content"
+	}
+	embedPath, _ := url.Parse(uri)
+	res := model.GetSiteURL().ResolveReference(embedPath)
+	res.RawQuery = url.Values{"token": {embedToken(share)}}.Encode()
+	return res.String()
+}
+
+// Get 根据分享链接返回 oEmbed 响应
+func (service *OEmbedService) Get(c *gin.Context) serializer.Response {
+	target, err := url.Parse(service.URL)
+	if err != nil || !strings.HasPrefix(target.Path, "/s/") {
+		return serializer.Err(serializer.CodeNotFound, "", nil)
+	}
+
+	share := model.GetShareByHashID(path.Base(target.Path))
+	if share == nil || !share.IsAvailable() || !share.InTenant(c.GetUint("tenant_id")) {
+		return serializer.Err(serializer.CodeNotFound, "", nil)
+	}
+
+	typ := embedType(share)
+	if typ == "" {
+		return serializer.Err(serializer.CodeNotFound, "", nil)
+	}
+
+	width, height := 640, 360
+	if typ == EmbedAudio {
+		height = 80
+	}
+	if service.MaxWidth > 0 && width > service.MaxWidth {
+		height = height * service.MaxWidth / width
+		width = service.MaxWidth
+	}
+	if service.MaxHeight > 0 && height > service.MaxHeight {
+		width = width * service.MaxHeight / height
+		height = service.MaxHeight
+	}
+
+	res := gin.H{
+		"version":       "1.0",
+		"title":         share.SourceName,
+		"provider_name": model.GetSettingByName("siteName"),
+		"provider_url":  model.GetSiteURL().String(),
+		"width":         width,
+		"height":        height,
+	}
+	if typ == EmbedImage {
+		res["type"] = "photo"
+		res["url"] = embedURL(share, true)
+	} else {
+		res["type"] = "video"
+		res["html"] = fmt.Sprintf(
+			`<iframe src="%s" width="%d" height="%d" frameborder="0" allowfullscreen></iframe>`,
+			template.HTMLEscapeString(embedURL(share, false)), width, height,
+		)
+	}
+
+	c.JSON(http.StatusOK, res)
+	return serializer.Response{Code: -1}
+}
+
+// check 检查嵌入凭证，返回分享可嵌入的媒体类型
+func (service *EmbedService) check(share *model.Share) (string, error) {
+	typ := embedType(share)
+	if typ == "" {
+		return "", serializer.NewError(serializer.CodeDisabledSharePreview, "", nil)
+	}
+
+	if err := auth.General.Check(fmt.Sprintf("share/embed/%d", share.ID), service.Token); err != nil {
+		return "", serializer.NewError(serializer.CodeInvalidSign, "", err)
+	}
+
+	return typ, nil
+}
+
+// Player 输出嵌入播放器页面
+func (service *EmbedService) Player(c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+
+	typ, err := service.check(share)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, "", err)
+	}
+
+	// 允许被任意站点以 iframe 形式嵌入
+	c.Header("Content-Security-Policy", "frame-ancestors *")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := embedPlayer.Execute(c.Writer, map[string]string{
+		"Title": share.SourceName,
+		"Type":  typ,
+		"Src":   embedURL(share, true),
+	}); err != nil {
+		util.Log().Warning("Failed to render embed player: %s", err)
+	}
+
+	return serializer.Response{Code: -1}
+}
+
+// Content 输出嵌入的媒体内容
+func (service *EmbedService) Content(ctx context.Context, c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+
+	if _, err := service.check(share); err != nil {
+		return serializer.Err(serializer.CodeNotSet, "", err)
+	}
+
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Cross-Origin-Resource-Policy", "cross-origin")
+
+	ctx = context.WithValue(ctx, fsctx.FileModelCtx, share.Source())
+	subService := explorer.FileIDService{}
+	return subService.PreviewContent(ctx, c, false)
+}
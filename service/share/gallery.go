@@ -0,0 +1,259 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// galleryIndex 站点展示目录中作为首页的文件名
+const galleryIndex = "index.html"
+
+// galleryCSP 用户上传的内容在隔离的源中运行，不能读取站点的 Cookie 或调用站点接口
+const galleryCSP = "sandbox allow-scripts allow-forms allow-popups"
+
+var galleryListing = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width,initial-scale=1"><title>{{.Title}}</title>
+<style>body{font-family:sans-serif;max-width:960px;margin:0 auto;padding:16px}table{width:100%;border-collapse:collapse}
+td{padding:6px 8px;border-bottom:1px solid #eee}td.meta{white-space:nowrap;color:#666}
+img{width:48px;height:48px;object-fit:cover;vertical-align:middle}</style></head>
+<body><h1>{{.Title}}</h1><table>
+{{if .Parent}}<tr><td></td><td><a href="../">../</a></td><td></td><td></td></tr>{{end}}
+{{range .Items}}<tr><td>{{if .Thumb}}<img src="{{.Href}}?thumb=1" loading="lazy" alt="">{{end}}</td><td><a href="{{.Href}}">{{.Name}}</a></td><td class="meta">{{.Size}}</td><td class="meta">{{.Date}}</td></tr>
+{{end}}</table></body></html>`))
+
+// galleryItem 目录列表中的一项
+type galleryItem struct {
+	Name  string
+	Href  string
+	Thumb bool
+	Size  string
+	Date  string
+}
+
+// GalleryService 站点展示服务
+type GalleryService struct {
+	// Thumb 获取文件的缩略图
+	Thumb bool `form:"thumb"`
+	// Download 以附件形式下载文件，而不是在浏览器中打开
+	Download bool `form:"download"`
+}
+
+// Serve 按路径输出站点展示目录的列表、首页或文件内容
+func (service *GalleryService) Serve(ctx context.Context, c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+	if !share.IsGallery() || !share.IsDir {
+		return serializer.Err(serializer.CodeShareLinkNotFound, "", nil)
+	}
+
+	// 创建文件系统
+	fs, err := filesystem.NewFileSystem(share.Creator())
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	// 重设根目录
+	fs.Root = share.SourceFolder()
+	fs.Root.Name = "/"
+
+	rawPath := c.Param("path")
+	fullPath := path.Clean("/" + rawPath)
+	if exist, folder := fs.IsPathExist(fullPath); exist {
+		// 目录地址以 / 结尾，保证列表中的相对链接正确
+		if !strings.HasSuffix(rawPath, "/") {
+			return serializer.Response{Code: -301, Data: "./" + url.PathEscape(path.Base(fullPath)) + "/"}
+		}
+
+		return service.folder(ctx, c, fs, share, fullPath, folder)
+	}
+
+	exist, file := fs.IsFileExist(fullPath)
+	if !exist {
+		return serializer.Err(serializer.CodeFileNotFound, "", nil)
+	}
+	fs.SetTargetFile(&[]model.File{*file})
+
+	if service.Thumb {
+		return service.thumb(ctx, c, fs)
+	}
+
+	return service.file(ctx, c, fs, share)
+}
+
+// folder 输出目录首页或列表
+func (service *GalleryService) folder(ctx context.Context, c *gin.Context, fs *filesystem.FileSystem,
+	share *model.Share, fullPath string, folder *model.Folder) serializer.Response {
+	if share.IndexPage {
+		if exist, index := fs.IsChildFileExist(folder, galleryIndex); exist {
+			fs.SetTargetFile(&[]model.File{*index})
+			rs, err := fs.GetDownloadContent(ctx, 0)
+			if err != nil {
+				return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+			}
+			defer rs.Close()
+
+			galleryHeaders(c)
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			filesystem.ServeContent(c.Writer, c.Request, index.Name, index.UpdatedAt, index.Size, rs)
+			return serializer.Response{Code: -1}
+		}
+	}
+
+	objects, err := fs.List(ctx, fullPath, nil)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	items := make([]galleryItem, 0, len(objects))
+	for _, object := range objects {
+		item := galleryItem{
+			Name: object.Name,
+			Href: "./" + url.PathEscape(object.Name),
+			Date: object.Date.Format("2006-01-02 15:04"),
+		}
+		if object.Type == "dir" {
+			item.Name += "/"
+			item.Href += "/"
+		} else {
+			item.Thumb = object.Thumb
+			item.Size = gallerySize(object.Size)
+		}
+		items = append(items, item)
+	}
+
+	title := share.SourceName
+	if fullPath != "/" {
+		title = path.Join(share.SourceName, fullPath)
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := galleryListing.Execute(c.Writer, map[string]interface{}{
+		"Title":  title,
+		"Parent": fullPath != "/",
+		"Items":  items,
+	}); err != nil {
+		util.Log().Warning("Failed to render gallery listing: %s", err)
+	}
+
+	return serializer.Response{Code: -1}
+}
+
+// thumb 输出文件的缩略图
+func (service *GalleryService) thumb(ctx context.Context, c *gin.Context, fs *filesystem.FileSystem) serializer.Response {
+	resp, err := fs.GetThumb(ctx, 0)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, "Failed to get thumb", err)
+	}
+
+	if resp.Redirect {
+		c.Header("Cache-Control", fmt.Sprintf("max-age=%d", resp.MaxAge))
+		c.Redirect(http.StatusFound, resp.URL)
+		return serializer.Response{Code: -1}
+	}
+
+	defer resp.Content.Close()
+	galleryHeaders(c)
+	http.ServeContent(c.Writer, c.Request, "thumb.png", fs.FileTarget[0].UpdatedAt, resp.Content)
+	return serializer.Response{Code: -1}
+}
+
+// file 在浏览器中打开文件，未开启预览或要求下载时重定向到下载地址
+func (service *GalleryService) file(ctx context.Context, c *gin.Context, fs *filesystem.FileSystem,
+	share *model.Share) serializer.Response {
+	file := &fs.FileTarget[0]
+	preview := share.PreviewEnabled && !service.Download
+
+	// 在计入流量、打开上游内容前限制从机节点的中转并发
+	if preview {
+		release, position, err := filesystem.AcquireProxySlot(ctx, file.GetPolicy(), fs.User.ID,
+			filesystem.ProxyTicket(fs.User.ID, file.ID, c.ClientIP()), filesystem.ProxyQueueWait())
+		if err != nil {
+			c.Header("Retry-After", "5")
+			res := serializer.Err(serializer.CodeTooManyRequests, err.Error(), err)
+			res.Data = map[string]int{"queue_position": position}
+			return res
+		}
+		defer release()
+	}
+
+	// 检查并计入分享流量
+	if err := share.AddTraffic(file.Size); err != nil {
+		if errors.Is(err, model.ErrShareTrafficExceeded) {
+			return serializer.Err(serializer.CodeShareTrafficExceeded, "Traffic limit of this share is exceeded", nil)
+		}
+		return serializer.DBErr("Failed to update share record", err)
+	}
+
+	userCtx, _ := c.Get("user")
+	share.DownloadBy(userCtx.(*model.User), c)
+	recordEvent(c, share, model.ShareEventDownload, file.Name)
+
+	// 附加分享的下载限制
+	ctx = context.WithValue(ctx, fsctx.DownloadLimitCtx, &fsctx.DownloadLimit{
+		ShareID:       share.ID,
+		SpeedLimit:    share.SpeedLimit,
+		MaxConcurrent: share.MaxConcurrent,
+	})
+
+	if preview {
+		resp, err := fs.Preview(ctx, 0, false)
+		if err != nil {
+			return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		}
+
+		if resp.Redirect {
+			c.Header("Cache-Control", fmt.Sprintf("max-age=%d", resp.MaxAge))
+			return serializer.Response{Code: -301, Data: resp.URL}
+		}
+
+		defer resp.Content.Close()
+		galleryHeaders(c)
+		filesystem.ServeContent(c.Writer, c.Request, file.Name, file.UpdatedAt, file.Size, resp.Content)
+		return serializer.Response{Code: -1}
+	}
+
+	ctx = context.WithValue(ctx, fsctx.DownloadClientIPCtx, c.ClientIP())
+	downloadURL, err := fs.GetDownloadURL(ctx, 0, "download_timeout")
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	return serializer.Response{Code: -301, Data: downloadURL}
+}
+
+// galleryHeaders 为在站点源下直接输出的用户内容设置隔离策略，
+// 避免其中的 HTML、SVG 等以站点身份执行脚本
+func galleryHeaders(c *gin.Context) {
+	c.Header("Content-Security-Policy", galleryCSP)
+	c.Header("X-Content-Type-Options", "nosniff")
+}
+
+// gallerySize 返回便于阅读的文件大小
+func gallerySize(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,89 @@
+package share
+
+import (
+	"context"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// GuestUploadListService 列出文件收集中待审核的访客上传服务
+type GuestUploadListService struct {
+}
+
+// GuestUploadService 审核访客上传服务
+type GuestUploadService struct {
+	Upload uint `uri:"upload" binding:"required,min=1"`
+}
+
+// List 列出待审核的访客上传
+func (service *GuestUploadListService) List(c *gin.Context, user *model.User) serializer.Response {
+	share, ok := ownedShare(c, user)
+	if !ok {
+		return serializer.Err(serializer.CodeShareLinkNotFound, "", nil)
+	}
+
+	records, err := model.GetGuestUploadsByShare(share.ID, model.GuestUploadPending)
+	if err != nil {
+		return serializer.DBErr("Failed to list guest uploads", err)
+	}
+
+	return serializer.Response{Data: serializer.BuildGuestUploads(records)}
+}
+
+// pending 获取当前用户分享中等待审核的访客上传
+func (service *GuestUploadService) pending(c *gin.Context, user *model.User) (*model.GuestUpload, serializer.Response) {
+	share, ok := ownedShare(c, user)
+	if !ok {
+		return nil, serializer.Err(serializer.CodeShareLinkNotFound, "", nil)
+	}
+
+	record, err := model.GetGuestUploadByID(service.Upload, share.ID)
+	if err != nil {
+		return nil, serializer.Err(serializer.CodeNotFound, "Guest upload not exist", err)
+	}
+
+	return record, serializer.Response{}
+}
+
+// Approve 通过访客上传，文件放入文件收集目录
+func (service *GuestUploadService) Approve(c *gin.Context, user *model.User) serializer.Response {
+	record, res := service.pending(c, user)
+	if record == nil {
+		return res
+	}
+
+	if err := record.Approve(); err != nil {
+		switch err {
+		case model.ErrGuestUploadConflict:
+			return serializer.Err(serializer.CodeObjectExist, "", err)
+		case model.ErrGuestUploadCapacity:
+			return serializer.Err(serializer.CodeInsufficientCapacity, "", err)
+		}
+		return serializer.DBErr("Failed to approve guest upload", err)
+	}
+
+	return serializer.Response{}
+}
+
+// Reject 拒绝访客上传并删除文件
+func (service *GuestUploadService) Reject(c *gin.Context, user *model.User) serializer.Response {
+	record, res := service.pending(c, user)
+	if record == nil {
+		return res
+	}
+
+	fs, err := filesystem.NewFileSystem(user)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if err := fs.RejectGuestUploads(context.Background(), []model.GuestUpload{*record}); err != nil {
+		return serializer.Err(serializer.CodeNotSet, "Failed to reject guest upload", err)
+	}
+
+	return serializer.Response{}
+}
@@ -0,0 +1,122 @@
+package share
+
+import (
+	"errors"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+)
+
+// multiItemSource 校验多项分享所选的对象，所有对象须位于同一目录下，
+// 返回该目录 ID 作为分享的源目录，以及用于展示和搜索的分享名称
+func multiItemSource(user *model.User, raw *explorer.ItemService) (uint, string, *model.ShareItems, error) {
+	if len(raw.Dirs)+len(raw.Items) == 0 {
+		return 0, "", nil, errors.New("no item selected")
+	}
+
+	var (
+		folders []model.Folder
+		files   []model.File
+	)
+	if len(raw.Dirs) > 0 {
+		folders, _ = model.GetFoldersByIDs(raw.Dirs, user.ID)
+	}
+	if len(raw.Items) > 0 {
+		files, _ = model.GetFilesByIDs(raw.Items, user.ID)
+	}
+	if len(folders) != len(raw.Dirs) || len(files) != len(raw.Items) {
+		return 0, "", nil, errors.New("some of the items do not exist")
+	}
+
+	var (
+		parent uint
+		names  = make([]string, 0, len(folders)+len(files))
+	)
+	checkParent := func(id uint) bool {
+		if parent == 0 {
+			parent = id
+		}
+		return parent == id
+	}
+	for _, folder := range folders {
+		if folder.ParentID == nil || !checkParent(*folder.ParentID) {
+			return 0, "", nil, errors.New("items must be in the same folder")
+		}
+		names = append(names, folder.Name)
+	}
+	for _, file := range files {
+		if !checkParent(file.FolderID) {
+			return 0, "", nil, errors.New("items must be in the same folder")
+		}
+		names = append(names, file.Name)
+	}
+
+	name := []rune(strings.Join(names, ", "))
+	if len(name) > 255 {
+		name = name[:255]
+	}
+
+	return parent, string(name), &model.ShareItems{Dirs: raw.Dirs, Files: raw.Items}, nil
+}
+
+// filterRootObjects 过滤多项分享根目录下未被选中的对象
+func filterRootObjects(share *model.Share, objects []serializer.Object) []serializer.Object {
+	names := share.ItemNames()
+	res := make([]serializer.Object, 0, len(names))
+	for _, object := range objects {
+		if names[object.Name] {
+			res = append(res, object)
+		}
+	}
+	return res
+}
+
+// filterSearchResult 过滤多项分享根目录下搜索结果中不属于所选对象的文件，
+// files 为搜索命中的文件记录
+func filterSearchResult(share *model.Share, files []model.File, objects []serializer.Object) []serializer.Object {
+	items := share.SelectedItems()
+	allowedFolders := make(map[uint]bool)
+	if len(items.Dirs) > 0 {
+		folders, _ := model.GetRecursiveChildFolder(items.Dirs, share.UserID, true)
+		for _, folder := range folders {
+			allowedFolders[folder.ID] = true
+		}
+	}
+
+	allowed := make(map[string]bool, len(files))
+	for _, file := range files {
+		if allowedFolders[file.FolderID] || util.ContainsUint(items.Files, file.ID) {
+			allowed[hashid.HashID(file.ID, hashid.FileID)] = true
+		}
+	}
+
+	res := make([]serializer.Object, 0, len(allowed))
+	for _, object := range objects {
+		if allowed[object.ID] {
+			res = append(res, object)
+		}
+	}
+	return res
+}
+
+// filterSelected 过滤多项分享根目录下未被选中的目录和文件，dirs 和 items 为对象的 HashID
+func filterSelected(share *model.Share, dirs, items []string) ([]string, []string) {
+	selected := share.SelectedItems()
+	resDirs := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if id, err := hashid.DecodeHashID(dir, hashid.FolderID); err == nil && util.ContainsUint(selected.Dirs, id) {
+			resDirs = append(resDirs, dir)
+		}
+	}
+	resItems := make([]string, 0, len(items))
+	for _, item := range items {
+		if id, err := hashid.DecodeHashID(item, hashid.FileID); err == nil && util.ContainsUint(selected.Files, id) {
+			resItems = append(resItems, item)
+		}
+	}
+	return resDirs, resItems
+}
@@ -1,28 +1,52 @@
 package share
 
 import (
-	"net/url"
+	"context"
+	"strconv"
 	"time"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
 	"github.com/gin-gonic/gin"
 )
 
 // ShareCreateService 创建新分享服务
 type ShareCreateService struct {
-	SourceID        string `json:"id" binding:"required"`
+	SourceID        string `json:"id" binding:"required_without=Items"`
 	IsDir           bool   `json:"is_dir"`
 	Password        string `json:"password" binding:"max=255"`
 	RemainDownloads int    `json:"downloads"`
 	Expire          int    `json:"expire"`
 	Preview         bool   `json:"preview"`
+	Slug            string `json:"slug" binding:"max=64"`
+	SpeedLimit      int    `json:"speed_limit" binding:"min=0"`
+	MaxConcurrent   int    `json:"max_concurrent" binding:"min=0"`
+	TrafficLimit    uint64 `json:"traffic_limit"`
+	Type            int    `json:"type" binding:"min=0,max=2"`
+	UploadMaxSize   uint64 `json:"upload_max_size"`
+	UploadExts      string `json:"upload_exts" binding:"max=255"`
+	// Moderated 文件收集上传的文件需经审核
+	Moderated bool `json:"moderated"`
+	// StartsAt 分享开始生效的时间戳，为 0 时立即生效
+	StartsAt int64 `json:"starts_at" binding:"min=0"`
+	// EndsAt 分享到期的时间戳，不为 0 时代替 Expire
+	EndsAt int64 `json:"ends_at" binding:"min=0"`
+	// ExpireAction 到期后的处理，参见 model.ShareExpireKeep 等
+	ExpireAction int `json:"expire_action" binding:"min=0,max=3"`
+	// IndexPage 站点展示目录中存在 index.html 时作为首页
+	IndexPage bool `json:"index_page"`
+
+	// Items 不为空时创建多项分享，忽略 SourceID 与 IsDir
+	Items *explorer.ItemIDService `json:"items"`
 }
 
 // ShareUpdateService 分享更新服务
 type ShareUpdateService struct {
-	Prop  string `json:"prop" binding:"required,eq=password|eq=preview_enabled"`
+	Prop  string `json:"prop" binding:"required,eq=password|eq=preview_enabled|eq=slug|eq=speed_limit|eq=max_concurrent|eq=traffic_limit|eq=starts_at|eq=expires|eq=expire_action|eq=index_page"`
 	Value string `json:"value" binding:"max=255"`
 }
 
@@ -37,6 +61,15 @@ func (service *Service) Delete(c *gin.Context, user *model.User) serializer.Resp
 		return serializer.DBErr("Failed to delete share record", err)
 	}
 
+	// 删除尚未审核的访客上传
+	if share.Moderated {
+		if records, err := model.GetGuestUploadsByShare(share.ID, model.GuestUploadPending); err == nil && len(records) > 0 {
+			if err := filesystem.PurgeGuestUploads(context.Background(), records); err != nil {
+				util.Log().Warning("Failed to purge guest uploads of share %d: %s", share.ID, err)
+			}
+		}
+	}
+
 	return serializer.Response{}
 }
 
@@ -51,10 +84,74 @@ func (service *ShareUpdateService) Update(c *gin.Context) serializer.Response {
 		if err != nil {
 			return serializer.DBErr("Failed to update share record", err)
 		}
-	case "preview_enabled":
+	case "preview_enabled", "index_page":
 		value := service.Value == "true"
-		err := share.Update(map[string]interface{}{"preview_enabled": value})
+		err := share.Update(map[string]interface{}{service.Prop: value})
+		if err != nil {
+			return serializer.DBErr("Failed to update share record", err)
+		}
+		return serializer.Response{
+			Data: value,
+		}
+	case "slug":
+		// 留空时移除自定义链接，恢复使用随机链接
+		var slug *string
+		if service.Value != "" {
+			value, err := checkSlug(service.Value, share.ID)
+			if err != nil {
+				return serializer.Err(serializer.CodeInvalidShareSlug, err.Error(), err)
+			}
+			slug = &value
+		}
+
+		if err := share.Update(map[string]interface{}{"slug": slug}); err != nil {
+			return serializer.DBErr("Failed to update share record", err)
+		}
+		return serializer.Response{
+			Data: share.Key(),
+		}
+	case "speed_limit", "max_concurrent", "traffic_limit":
+		value, err := strconv.ParseUint(service.Value, 10, 63)
 		if err != nil {
+			return serializer.ParamErr("Invalid limit value", err)
+		}
+		if err := share.Update(map[string]interface{}{service.Prop: value}); err != nil {
+			return serializer.DBErr("Failed to update share record", err)
+		}
+		return serializer.Response{
+			Data: value,
+		}
+	case "starts_at", "expires":
+		// 值为时间戳，0 表示移除限制
+		value, err := strconv.ParseInt(service.Value, 10, 64)
+		if err != nil || value < 0 {
+			return serializer.ParamErr("Invalid timestamp", err)
+		}
+
+		props := map[string]interface{}{service.Prop: nil}
+		if value > 0 {
+			props[service.Prop] = time.Unix(value, 0)
+		}
+		if service.Prop == "expires" {
+			if value > 0 && time.Unix(value, 0).Before(time.Now()) {
+				return serializer.ParamErr("Expire time must be in the future", nil)
+			}
+			// 修改到期时间后重新执行到期处理
+			props["expire_handled"] = false
+		}
+
+		if err := share.Update(props); err != nil {
+			return serializer.DBErr("Failed to update share record", err)
+		}
+		return serializer.Response{
+			Data: value,
+		}
+	case "expire_action":
+		value, err := strconv.Atoi(service.Value)
+		if err != nil || value < model.ShareExpireKeep || value > model.ShareExpireNotify {
+			return serializer.ParamErr("Invalid expire action", err)
+		}
+		if err := share.Update(map[string]interface{}{"expire_action": value}); err != nil {
 			return serializer.DBErr("Failed to update share record", err)
 		}
 		return serializer.Response{
@@ -80,9 +177,13 @@ func (service *ShareCreateService) Create(c *gin.Context) serializer.Response {
 	var (
 		sourceID   uint
 		sourceName string
+		items      *model.ShareItems
 		err        error
 	)
-	if service.IsDir {
+	if service.Items != nil {
+		sourceID, sourceName, items, err = multiItemSource(user, service.Items.Raw())
+		service.IsDir = true
+	} else if service.IsDir {
 		sourceID, err = hashid.DecodeHashID(service.SourceID, hashid.FolderID)
 	} else {
 		sourceID, err = hashid.DecodeHashID(service.SourceID, hashid.FileID)
@@ -91,16 +192,16 @@ func (service *ShareCreateService) Create(c *gin.Context) serializer.Response {
 		return serializer.Err(serializer.CodeNotFound, "", nil)
 	}
 
-	// 对象是否存在
+	// 对象是否存在，多项分享所选对象已在 multiItemSource 中校验
 	exist := true
-	if service.IsDir {
+	if items == nil && service.IsDir {
 		folder, err := model.GetFoldersByIDs([]uint{sourceID}, user.ID)
 		if err != nil || len(folder) == 0 {
 			exist = false
 		} else {
 			sourceName = folder[0].Name
 		}
-	} else {
+	} else if items == nil {
 		file, err := model.GetFilesByIDs([]uint{sourceID}, user.ID)
 		if err != nil || len(file) == 0 {
 			exist = false
@@ -112,6 +213,21 @@ func (service *ShareCreateService) Create(c *gin.Context) serializer.Response {
 		return serializer.Err(serializer.CodeNotFound, "", nil)
 	}
 
+	// 文件收集只能用于目录
+	if service.Type == model.ShareTypeUpload && (!service.IsDir || items != nil) {
+		return serializer.ParamErr("File request can only be created for folders", nil)
+	}
+
+	// 站点展示只能用于未加密的整个目录
+	if service.Type == model.ShareTypeGallery {
+		if !user.Group.OptionsSerialized.Gallery {
+			return serializer.Err(serializer.CodeGroupNotAllowed, "", nil)
+		}
+		if !service.IsDir || items != nil || service.Password != "" {
+			return serializer.ParamErr("Gallery can only be created for folders without password", nil)
+		}
+	}
+
 	newShare := model.Share{
 		Password:        service.Password,
 		IsDir:           service.IsDir,
@@ -120,31 +236,69 @@ func (service *ShareCreateService) Create(c *gin.Context) serializer.Response {
 		RemainDownloads: -1,
 		PreviewEnabled:  service.Preview,
 		SourceName:      sourceName,
+		SpeedLimit:      service.SpeedLimit,
+		MaxConcurrent:   service.MaxConcurrent,
+		TrafficLimit:    service.TrafficLimit,
+		Type:            service.Type,
+		UploadMaxSize:   service.UploadMaxSize,
+		UploadExts:      service.UploadExts,
+		Moderated:       service.Moderated && service.Type == model.ShareTypeUpload,
+		ExpireAction:    service.ExpireAction,
+		IndexPage:       service.IndexPage && service.Type == model.ShareTypeGallery,
+	}
+
+	if items != nil {
+		newShare.SetItems(*items)
 	}
 
-	// 如果开启了自动过期
-	if service.RemainDownloads > 0 {
+	if service.Slug != "" {
+		slug, err := checkSlug(service.Slug, 0)
+		if err != nil {
+			return serializer.Err(serializer.CodeInvalidShareSlug, err.Error(), err)
+		}
+		newShare.Slug = &slug
+	}
+
+	// 如果开启了自动过期，文件收集与站点展示不限制下载次数，只按时间过期
+	if service.Type == model.ShareTypeUpload || service.Type == model.ShareTypeGallery {
+		if service.Expire > 0 {
+			expires := time.Now().Add(time.Duration(service.Expire) * time.Second)
+			newShare.Expires = &expires
+		}
+	} else if service.RemainDownloads > 0 {
 		expires := time.Now().Add(time.Duration(service.Expire) * time.Second)
 		newShare.RemainDownloads = service.RemainDownloads
 		newShare.Expires = &expires
 	}
 
+	// 指定了生效时段时按时段开放
+	if service.StartsAt > 0 {
+		startsAt := time.Unix(service.StartsAt, 0)
+		newShare.StartsAt = &startsAt
+	}
+	if service.EndsAt > 0 {
+		endsAt := time.Unix(service.EndsAt, 0)
+		if endsAt.Before(time.Now()) || (newShare.StartsAt != nil && !endsAt.After(*newShare.StartsAt)) {
+			return serializer.ParamErr("End time must be in the future and after start time", nil)
+		}
+		newShare.Expires = &endsAt
+	}
+
 	// 创建分享
-	id, err := newShare.Create()
-	if err != nil {
+	if _, err := newShare.Create(); err != nil {
 		return serializer.DBErr("Failed to create share link record", err)
 	}
 
-	// 获取分享的唯一id
-	uid := hashid.HashID(id, hashid.ShareID)
 	// 最终得到分享链接
-	siteURL := model.GetSiteURL()
-	sharePath, _ := url.Parse("/s/" + uid)
-	shareURL := siteURL.ResolveReference(sharePath)
-
+	if newShare.IsGallery() {
+		return serializer.Response{
+			Code: 0,
+			Data: newShare.GalleryURL().String(),
+		}
+	}
 	return serializer.Response{
 		Code: 0,
-		Data: shareURL.String(),
+		Data: newShare.URL().String(),
 	}
 
 }
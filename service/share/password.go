@@ -0,0 +1,91 @@
+package share
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/notify"
+	"github.com/cloudreve/Cloudreve/v3/pkg/ratelimit"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// passwordGuard 分享密码的暴力破解防护，分别按 分享+IP 与 分享 统计失败次数
+type passwordGuard struct {
+	share    *model.Share
+	client   *ratelimit.Backoff
+	global   *ratelimit.Backoff
+	notifyAt int
+}
+
+func newPasswordGuard(c *gin.Context, share *model.Share) *passwordGuard {
+	options := model.GetSettingByNames(
+		"share_password_backoff_after",
+		"share_password_backoff_base",
+		"share_password_lockout",
+		"share_password_share_max",
+		"share_password_notify_after",
+		"share_password_window",
+	)
+	atoi := func(key string) int {
+		v, _ := strconv.Atoi(options[key])
+		return v
+	}
+
+	lockout := time.Duration(atoi("share_password_lockout")) * time.Second
+	window := time.Duration(atoi("share_password_window")) * time.Second
+	return &passwordGuard{
+		share: share,
+		client: &ratelimit.Backoff{
+			Key:    fmt.Sprintf("share_pwd_%d_%s", share.ID, c.ClientIP()),
+			Free:   atoi("share_password_backoff_after"),
+			Base:   time.Duration(atoi("share_password_backoff_base")) * time.Second,
+			Max:    lockout,
+			Window: window,
+		},
+		global: &ratelimit.Backoff{
+			Key:    fmt.Sprintf("share_pwd_%d", share.ID),
+			Free:   atoi("share_password_share_max"),
+			Base:   lockout,
+			Max:    lockout,
+			Window: window,
+		},
+		notifyAt: atoi("share_password_notify_after"),
+	}
+}
+
+// locked 返回当前客户端是否被禁止尝试密码
+func (g *passwordGuard) locked() (time.Duration, bool) {
+	if remain, ok := g.global.Locked(); ok {
+		return remain, true
+	}
+	return g.client.Locked()
+}
+
+// fail 记录一次失败的尝试，失败次数达到阈值时通知分享者
+func (g *passwordGuard) fail() {
+	g.client.Fail()
+	failures, _ := g.global.Fail()
+	if g.notifyAt > 0 && failures == g.notifyAt {
+		notify.Send(g.share.Creator(), model.NotifyShareSecurity, "分享密码多次输入错误",
+			fmt.Sprintf("您的分享 %s 在短时间内被输错密码 %d 次，可能正遭受暴力破解，建议更换密码或取消分享。",
+				g.share.SourceName, failures))
+	}
+}
+
+// succeed 密码正确时清除当前客户端的失败记录
+func (g *passwordGuard) succeed() {
+	g.client.Reset()
+}
+
+// lockedResponse 返回锁定期间的错误响应
+func lockedResponse(c *gin.Context, remain time.Duration) serializer.Response {
+	retryAfter := int(math.Ceil(remain.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	res := serializer.Err(serializer.CodeTooManyRequests, "Too many incorrect password attempts, please try again later", nil)
+	res.Data = map[string]int{"retry_after": retryAfter}
+	return res
+}
@@ -0,0 +1,61 @@
+package share
+
+import (
+	"net/http"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/qrcode"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// QRCodeService 分享链接二维码服务
+type QRCodeService struct {
+	Format   string `form:"format" binding:"omitempty,oneof=png svg"`
+	Size     int    `form:"size" binding:"omitempty,min=64,max=1024"`
+	Password bool   `form:"password"`
+}
+
+// Render 输出分享链接的二维码图片，分享者本人可选择在链接中附带分享密码
+func (service *QRCodeService) Render(c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+	userCtx, _ := c.Get("user")
+	user := userCtx.(*model.User)
+
+	shareURL := share.URL()
+	if service.Password && share.Password != "" && share.UserID == user.ID {
+		query := shareURL.Query()
+		query.Set("password", share.Password)
+		shareURL.RawQuery = query.Encode()
+	}
+
+	size := service.Size
+	if size == 0 {
+		size = qrcode.DefaultSize
+	}
+
+	var (
+		content     []byte
+		err         error
+		contentType = "image/png"
+	)
+	if service.Format == "svg" {
+		content, err = qrcode.SVG(shareURL.String(), size)
+		contentType = "image/svg+xml"
+	} else {
+		content, err = qrcode.PNG(shareURL.String(), size)
+	}
+	if err != nil {
+		return serializer.Err(serializer.CodeInternalSetting, "Failed to render QR code", err)
+	}
+
+	// 附带密码的二维码不允许被缓存
+	if shareURL.RawQuery != "" {
+		c.Header("Cache-Control", "no-store")
+	} else {
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
+	c.Data(http.StatusOK, contentType, content)
+	return serializer.Response{Code: -1}
+}
@@ -0,0 +1,53 @@
+package share
+
+import (
+	"fmt"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// ReportService 举报分享服务
+type ReportService struct {
+	Reason      int    `json:"reason" binding:"min=0,max=4"`
+	Description string `json:"description" binding:"max=1024"`
+}
+
+// Report 举报分享，同一来源在限定时间内对同一分享只能举报一次
+func (service *ReportService) Report(c *gin.Context) serializer.Response {
+	if !model.IsTrueVal(model.GetSettingByName("share_report_enabled")) {
+		return serializer.Err(serializer.CodeFeatureNotEnabled, "Share report is not enabled", nil)
+	}
+
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+	userCtx, _ := c.Get("user")
+	user := userCtx.(*model.User)
+
+	identity := "ip_" + c.ClientIP()
+	if !user.IsAnonymous() {
+		identity = fmt.Sprintf("uid_%d", user.ID)
+	}
+
+	throttleKey := fmt.Sprintf("share_report_%d_%s", share.ID, identity)
+	if _, ok := cache.Get(throttleKey); ok {
+		return serializer.Err(serializer.CodeTooManyRequests, "You have already reported this share", nil)
+	}
+
+	report := &model.Report{
+		ShareID:     share.ID,
+		Reason:      service.Reason,
+		Description: service.Description,
+		ReporterID:  user.ID,
+		ReporterIP:  c.ClientIP(),
+		Status:      model.ReportPending,
+	}
+	if _, err := report.Create(); err != nil {
+		return serializer.DBErr("Failed to create report", err)
+	}
+
+	cache.Set(throttleKey, true, model.GetIntSetting("share_report_interval", 86400))
+	return serializer.Response{}
+}
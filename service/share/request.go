@@ -0,0 +1,125 @@
+package share
+
+import (
+	"context"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem/fsctx"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/service/explorer"
+	"github.com/gin-gonic/gin"
+)
+
+const uploadSessionSharePrefix = "share_upload_"
+
+// UploadSessionService 文件收集创建上传会话服务
+type UploadSessionService struct {
+	Size         uint64 `json:"size" binding:"min=0"`
+	Name         string `json:"name" binding:"required"`
+	LastModified int64  `json:"last_modified"`
+	MimeType     string `json:"mime_type"`
+	Uploader     string `json:"uploader" binding:"max=64"`
+}
+
+// UploadChunkService 文件收集分片上传服务
+type UploadChunkService struct {
+	explorer.UploadService
+}
+
+// ownerFileSystem 以分享者身份创建文件系统
+func ownerFileSystem(share *model.Share) (*filesystem.FileSystem, error) {
+	owner := share.Creator()
+	if owner.ID == 0 || owner.Status != model.Active {
+		return nil, serializer.NewError(serializer.CodeShareLinkNotFound, "", nil)
+	}
+
+	return filesystem.NewFileSystem(owner)
+}
+
+// Create 在文件收集目录中创建上传会话，文件计入分享者的容量
+func (service *UploadSessionService) Create(c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+
+	if !share.UploadSizeAllowed(service.Size) {
+		return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+	}
+
+	if !share.UploadTypeAllowed(service.Name) {
+		return serializer.Err(serializer.CodeFileTypeNotAllowed, "", nil)
+	}
+
+	fs, err := ownerFileSystem(share)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	folder := share.SourceFolder()
+	if folder.ID == 0 {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+	if err := folder.TraceRoot(); err != nil {
+		return serializer.Err(serializer.CodeParentNotExist, "", err)
+	}
+
+	uploader := strings.TrimSpace(service.Uploader)
+	if uploader == "" {
+		uploader = "anonymous"
+	}
+
+	file := &fsctx.FileStream{
+		Size:        service.Size,
+		Name:        service.Name,
+		VirtualPath: path.Join(folder.Position, folder.Name),
+		File:        ioutil.NopCloser(strings.NewReader("")),
+		MimeType:    service.MimeType,
+		Metadata: map[string]string{
+			model.UploaderNameMetadataKey: uploader,
+			model.UploadShareMetadataKey:  strconv.FormatUint(uint64(share.ID), 10),
+		},
+	}
+	if share.Moderated {
+		file.Metadata[model.GuestPendingMetadataKey] = "1"
+	}
+	if service.LastModified > 0 {
+		lastModified := time.UnixMilli(service.LastModified)
+		file.LastModified = &lastModified
+	}
+
+	credential, err := fs.CreateUploadSession(context.Background(), file)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+	}
+
+	// 记录上传会话所属的分享，供分片上传时校验
+	cache.Set(uploadSessionSharePrefix+credential.SessionID, share.ID,
+		model.GetIntSetting("upload_session_timeout", 86400))
+
+	return serializer.Response{Data: credential}
+}
+
+// Upload 处理文件收集的本机分片上传
+func (service *UploadChunkService) Upload(ctx context.Context, c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+
+	shareID, ok := cache.Get(uploadSessionSharePrefix + service.ID)
+	if !ok || shareID.(uint) != share.ID {
+		return serializer.Err(serializer.CodeUploadSessionExpired, "", nil)
+	}
+
+	fs, err := ownerFileSystem(share)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+
+	return service.LocalUploadTo(ctx, c, fs)
+}
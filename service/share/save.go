@@ -0,0 +1,95 @@
+package share
+
+import (
+	"context"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/gin-gonic/gin"
+)
+
+// SaveService 转存分享服务
+type SaveService struct {
+	Path string `json:"path" binding:"max=65535"`
+	Dst  string `json:"dst" binding:"required,min=1,max=65535"`
+}
+
+// Save 将分享的文件或目录转存至当前用户的 dst 目录下。文件数量较少且无需跨存储策略复制时直接完成，
+// 否则创建后台任务
+func (service *SaveService) Save(c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+	userCtx, _ := c.Get("user")
+	user := userCtx.(*model.User)
+
+	if share.UserID == user.ID {
+		return serializer.Err(serializer.CodeSaveOwnShare, "", nil)
+	}
+
+	src, err := ownerFileSystem(share)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer src.Recycle()
+
+	// 找到要转存的对象
+	var dirs, files []uint
+	switch {
+	case !share.IsDir:
+		files = []uint{share.SourceID}
+	case service.Path == "" || service.Path == "/":
+		if share.IsMultiItem() {
+			items := share.SelectedItems()
+			dirs, files = items.Dirs, items.Files
+		} else {
+			dirs = []uint{share.SourceID}
+		}
+	case !share.AllowPath(service.Path):
+		return serializer.Err(serializer.CodeFileNotFound, "", nil)
+	default:
+		src.Root = share.SourceFolder()
+		if exist, file := src.IsFileExist(service.Path); exist {
+			files = []uint{file.ID}
+		} else if exist, folder := src.IsPathExist(service.Path); exist {
+			dirs = []uint{folder.ID}
+		} else {
+			return serializer.Err(serializer.CodeFileNotFound, "", nil)
+		}
+		src.Root = nil
+	}
+
+	fs, err := filesystem.NewFileSystem(user)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateFSError, "", err)
+	}
+	defer fs.Recycle()
+
+	if exist, _ := fs.IsPathExist(service.Dst); !exist {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
+	count, size, crossPolicy, err := fs.SavePlan(src, dirs, files)
+	if err != nil {
+		return serializer.DBErr("Failed to list shared files", err)
+	}
+	if size > user.GetRemainingCapacity() {
+		return serializer.Err(serializer.CodeInsufficientCapacity, "", nil)
+	}
+
+	if !crossPolicy && count <= model.GetIntSetting("share_save_sync_max", 100) {
+		if err := fs.SaveFrom(context.Background(), src, dirs, files, service.Dst); err != nil {
+			return serializer.Err(serializer.CodeNotSet, err.Error(), err)
+		}
+		return serializer.Response{Data: map[string]interface{}{"async": false}}
+	}
+
+	job, err := task.NewShareSaveTask(user, share.UserID, dirs, files, service.Dst)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateTaskError, "", err)
+	}
+	task.TaskPoll.Submit(job)
+
+	return serializer.Response{Data: map[string]interface{}{"async": true}}
+}
@@ -0,0 +1,42 @@
+package share
+
+import (
+	"regexp"
+	"strings"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}[a-z0-9]$`)
+
+// checkSlug 校验并规范化自定义分享链接，shareID 为正在修改的分享，新建时为 0
+func checkSlug(slug string, shareID uint) (string, error) {
+	if !model.IsTrueVal(model.GetSettingByName("share_slug_enabled")) {
+		return "", serializer.NewError(serializer.CodeFeatureNotEnabled, "Custom share link is not enabled", nil)
+	}
+
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) {
+		return "", serializer.NewError(serializer.CodeInvalidShareSlug,
+			"Custom share link must be 3-64 characters of letters, digits and hyphens", nil)
+	}
+
+	for _, reserved := range strings.Split(model.GetSettingByName("share_slug_reserved"), ",") {
+		if strings.TrimSpace(reserved) == slug {
+			return "", serializer.NewError(serializer.CodeInvalidShareSlug, "Custom share link is reserved", nil)
+		}
+	}
+
+	// 能被解码为 HashID 的链接会与随机链接冲突
+	if v, err := hashid.HashDecode(slug); err == nil && len(v) > 0 {
+		return "", serializer.NewError(serializer.CodeInvalidShareSlug, "Custom share link is reserved", nil)
+	}
+
+	if model.IsShareSlugUsed(slug, shareID) {
+		return "", serializer.NewError(serializer.CodeConflict, "Custom share link is already in use", nil)
+	}
+
+	return slug, nil
+}
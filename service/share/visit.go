@@ -2,9 +2,12 @@ package share
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
+	"sort"
+	"strings"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
@@ -33,6 +36,15 @@ type Service struct {
 	Path string `form:"path" uri:"path" binding:"max=65535"`
 }
 
+// ListService 分享目录列文件服务，可按名称筛选当前目录下的对象并排序
+type ListService struct {
+	Service
+	Keywords string `form:"keywords" binding:"max=255"`
+	Type     string `form:"type" binding:"omitempty,oneof=file dir"`
+	OrderBy  string `form:"order_by" binding:"omitempty,oneof=name size date create_date"`
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
 // ArchiveService 分享归档下载服务
 type ArchiveService struct {
 	Path  string   `json:"path" binding:"required,max=65535"`
@@ -91,7 +103,7 @@ func (service *ShareUserGetService) Get(c *gin.Context) serializer.Response {
 // Search 搜索公共分享
 func (service *ShareListService) Search(c *gin.Context) serializer.Response {
 	// 列出分享
-	shares, total := model.SearchShares(int(service.Page), 18, service.OrderBy+" "+
+	shares, total := model.SearchShares(c.GetUint("tenant_id"), int(service.Page), 18, service.OrderBy+" "+
 		service.Order, service.Keywords)
 	// 列出分享对应的文件
 	for i := 0; i < len(shares); i++ {
@@ -125,16 +137,25 @@ func (service *ShareGetService) Get(c *gin.Context) serializer.Response {
 		sessionKey := fmt.Sprintf("share_unlock_%d", share.ID)
 		unlocked = util.GetSession(c, sessionKey) != nil
 		if !unlocked && service.Password != "" {
-			// 如果未解锁，且指定了密码，则尝试解锁
+			// 如果未解锁，且指定了密码，则尝试解锁；多次失败后暂时禁止尝试
+			guard := newPasswordGuard(c, share)
+			if remain, locked := guard.locked(); locked {
+				return lockedResponse(c, remain)
+			}
+
 			if service.Password == share.Password {
 				unlocked = true
+				guard.succeed()
 				util.SetSession(c, map[string]interface{}{sessionKey: true})
+			} else {
+				guard.fail()
 			}
 		}
 	}
 
 	if unlocked {
 		share.Viewed()
+		recordEvent(c, share, model.ShareEventView, "")
 	}
 
 	return serializer.Response{
@@ -167,6 +188,9 @@ func (service *Service) CreateDownloadSession(c *gin.Context) serializer.Respons
 
 	// 重设根目录
 	if share.IsDir {
+		if !share.AllowPath(service.Path) {
+			return serializer.Err(serializer.CodeFileNotFound, "", nil)
+		}
 		fs.Root = &fs.DirTarget[0]
 
 		// 找到目标文件
@@ -176,12 +200,33 @@ func (service *Service) CreateDownloadSession(c *gin.Context) serializer.Respons
 		}
 	}
 
+	// 检查并计入分享流量，流量按签发的下载地址计算
+	if len(fs.FileTarget) > 0 {
+		size := fs.FileTarget[0].Size
+		if err := share.AddTraffic(size); err != nil {
+			if errors.Is(err, model.ErrShareTrafficExceeded) {
+				return serializer.Err(serializer.CodeShareTrafficExceeded, "Traffic limit of this share is exceeded", nil)
+			}
+			return serializer.DBErr("Failed to update share record", err)
+		}
+	}
+
+	// 附加分享的下载限制
+	ctx = context.WithValue(ctx, fsctx.DownloadLimitCtx, &fsctx.DownloadLimit{
+		ShareID:       share.ID,
+		SpeedLimit:    share.SpeedLimit,
+		MaxConcurrent: share.MaxConcurrent,
+	})
+
 	// 取得下载地址
+	ctx = context.WithValue(ctx, fsctx.DownloadClientIPCtx, c.ClientIP())
 	downloadURL, err := fs.GetDownloadURL(ctx, 0, "download_timeout")
 	if err != nil {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
 	}
 
+	recordEvent(c, share, model.ShareEventDownload, fs.FileTarget[0].Name)
+
 	return serializer.Response{
 		Code: 0,
 		Data: downloadURL,
@@ -196,6 +241,9 @@ func (service *Service) PreviewContent(ctx context.Context, c *gin.Context, isTe
 
 	// 用于调下层service
 	if share.IsDir {
+		if !share.AllowPath(service.Path) {
+			return serializer.Err(serializer.CodeFileNotFound, "", nil)
+		}
 		ctx = context.WithValue(ctx, fsctx.FolderModelCtx, share.Source())
 		ctx = context.WithValue(ctx, fsctx.PathCtx, service.Path)
 	} else {
@@ -214,6 +262,9 @@ func (service *Service) CreateDocPreviewSession(c *gin.Context) serializer.Respo
 	// 用于调下层service
 	ctx := context.Background()
 	if share.IsDir {
+		if !share.AllowPath(service.Path) {
+			return serializer.Err(serializer.CodeFileNotFound, "", nil)
+		}
 		ctx = context.WithValue(ctx, fsctx.FolderModelCtx, share.Source())
 		ctx = context.WithValue(ctx, fsctx.PathCtx, service.Path)
 	} else {
@@ -225,7 +276,7 @@ func (service *Service) CreateDocPreviewSession(c *gin.Context) serializer.Respo
 }
 
 // List 列出分享的目录下的对象
-func (service *Service) List(c *gin.Context) serializer.Response {
+func (service *ListService) List(c *gin.Context) serializer.Response {
 	shareCtx, _ := c.Get("share")
 	share := shareCtx.(*model.Share)
 
@@ -237,6 +288,10 @@ func (service *Service) List(c *gin.Context) serializer.Response {
 		return serializer.ParamErr("Invalid path", nil)
 	}
 
+	if !share.AllowPath(service.Path) {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
 	// 创建文件系统
 	fs, err := filesystem.NewFileSystem(share.Creator())
 	if err != nil {
@@ -261,10 +316,58 @@ func (service *Service) List(c *gin.Context) serializer.Response {
 		return serializer.Err(serializer.CodeNotSet, err.Error(), err)
 	}
 
+	// 多项分享的根目录只列出所选对象
+	if share.IsMultiItem() && path.Clean(service.Path) == "/" {
+		objects = filterRootObjects(share, objects)
+	}
+
 	return serializer.Response{
 		Code: 0,
-		Data: serializer.BuildObjectList(0, objects, nil),
+		Data: serializer.BuildObjectList(0, service.filter(objects), nil),
+	}
+}
+
+// filter 按关键字、类型筛选对象并排序，目录总是排在文件之前
+func (service *ListService) filter(objects []serializer.Object) []serializer.Object {
+	keywords := strings.ToLower(strings.TrimSpace(service.Keywords))
+	res := make([]serializer.Object, 0, len(objects))
+	for _, object := range objects {
+		if keywords != "" && !strings.Contains(strings.ToLower(object.Name), keywords) {
+			continue
+		}
+		if service.Type != "" && object.Type != service.Type {
+			continue
+		}
+		res = append(res, object)
+	}
+
+	if service.OrderBy == "" {
+		return res
 	}
+
+	less := func(a, b *serializer.Object) bool {
+		switch service.OrderBy {
+		case "size":
+			return a.Size < b.Size
+		case "date":
+			return a.Date.Before(b.Date)
+		case "create_date":
+			return a.CreateDate.Before(b.CreateDate)
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Type != res[j].Type {
+			return res[i].Type == "dir"
+		}
+		if service.Order == "desc" {
+			return less(&res[j], &res[i])
+		}
+		return less(&res[i], &res[j])
+	})
+
+	return res
 }
 
 // Thumb 获取被分享文件的缩略图
@@ -288,7 +391,7 @@ func (service *Service) Thumb(c *gin.Context) serializer.Response {
 
 	// 找到缩略图的父目录
 	exist, parent := fs.IsPathExist(service.Path)
-	if !exist {
+	if !exist || !share.AllowPath(service.Path) {
 		return serializer.Err(serializer.CodeParentNotExist, "", nil)
 	}
 
@@ -300,6 +403,12 @@ func (service *Service) Thumb(c *gin.Context) serializer.Response {
 		return serializer.Err(serializer.CodeNotFound, "", err)
 	}
 
+	// 多项分享根目录下只能获取所选文件的缩略图
+	if share.IsMultiItem() && parent.ID == share.SourceID &&
+		!util.ContainsUint(share.SelectedItems().Files, fileID) {
+		return serializer.Err(serializer.CodeNotFound, "", nil)
+	}
+
 	// 获取缩略图
 	resp, err := fs.GetThumb(ctx, uint(fileID))
 	if err != nil {
@@ -347,10 +456,15 @@ func (service *ArchiveService) Archive(c *gin.Context) serializer.Response {
 
 	// 找到要打包文件的父目录
 	exist, parent := fs.IsPathExist(service.Path)
-	if !exist {
+	if !exist || !share.AllowPath(service.Path) {
 		return serializer.Err(serializer.CodeParentNotExist, "", nil)
 	}
 
+	// 多项分享根目录下只能打包所选对象
+	if share.IsMultiItem() && parent.ID == share.SourceID {
+		service.Dirs, service.Items = filterSelected(share, service.Dirs, service.Items)
+	}
+
 	// 限制操作范围为父目录下
 	ctx := context.WithValue(context.Background(), fsctx.LimitParentCtx, parent)
 
@@ -367,6 +481,95 @@ func (service *ArchiveService) Archive(c *gin.Context) serializer.Response {
 	return subService.Archive(ctx, c)
 }
 
+// ArchiveAllService 分享目录整体打包下载服务
+type ArchiveAllService struct {
+}
+
+// Archive 为分享目录下的所有内容创建打包下载
+func (service *ArchiveAllService) Archive(c *gin.Context) serializer.Response {
+	shareCtx, _ := c.Get("share")
+	share := shareCtx.(*model.Share)
+	userCtx, _ := c.Get("user")
+	user := userCtx.(*model.User)
+
+	if !share.IsDir {
+		return serializer.ParamErr("This share cannot be batch downloaded", nil)
+	}
+
+	root := share.SourceFolder()
+	if root.ID == 0 {
+		return serializer.Err(serializer.CodeParentNotExist, "", nil)
+	}
+
+	var (
+		childFolders []model.Folder
+		childFiles   []model.File
+		err          error
+	)
+	if share.IsMultiItem() {
+		// 多项分享只打包所选对象
+		items := share.SelectedItems()
+		if len(items.Dirs) > 0 {
+			childFolders, err = model.GetFoldersByIDs(items.Dirs, root.OwnerID)
+		}
+		if err == nil && len(items.Files) > 0 {
+			childFiles, err = model.GetFilesByIDs(items.Files, root.OwnerID)
+		}
+	} else {
+		childFolders, err = root.GetChildFolder()
+		if err == nil {
+			childFiles, err = root.GetChildFiles()
+		}
+	}
+	if err != nil {
+		return serializer.DBErr("Failed to list shared files", err)
+	}
+
+	// 检查用户组打包大小限制
+	if limit := user.Group.OptionsSerialized.ShareArchiveSize; limit > 0 {
+		var size uint64
+		for _, file := range childFiles {
+			size += file.Size
+		}
+
+		if len(childFolders) > 0 {
+			folderIDs := make([]uint, 0, len(childFolders))
+			for _, folder := range childFolders {
+				folderIDs = append(folderIDs, folder.ID)
+			}
+			folders, err := model.GetRecursiveChildFolder(folderIDs, root.OwnerID, true)
+			if err != nil {
+				return serializer.DBErr("Failed to list shared folders", err)
+			}
+			files, err := model.GetChildFilesOfFolders(&folders)
+			if err != nil {
+				return serializer.DBErr("Failed to list shared files", err)
+			}
+			for _, file := range files {
+				size += file.Size
+			}
+		}
+
+		if size > limit {
+			return serializer.Err(serializer.CodeFileTooLarge, "", nil)
+		}
+	}
+
+	subService := ArchiveService{
+		Path:  "/",
+		Dirs:  make([]string, 0, len(childFolders)),
+		Items: make([]string, 0, len(childFiles)),
+	}
+	for _, folder := range childFolders {
+		subService.Dirs = append(subService.Dirs, hashid.HashID(folder.ID, hashid.FolderID))
+	}
+	for _, file := range childFiles {
+		subService.Items = append(subService.Items, hashid.HashID(file.ID, hashid.FileID))
+	}
+
+	return subService.Archive(c)
+}
+
 // SearchService 对分享的目录进行搜索
 type SearchService struct {
 	explorer.ItemSearchService
@@ -401,7 +604,7 @@ func (service *SearchService) Search(c *gin.Context) serializer.Response {
 	fs.Root.Name = "/"
 	if service.Path != "" {
 		ok, parent := fs.IsPathExist(service.Path)
-		if !ok {
+		if !ok || !share.AllowPath(service.Path) {
 			return serializer.Err(serializer.CodeParentNotExist, "Cannot find parent folder", nil)
 		}
 
@@ -411,5 +614,13 @@ func (service *SearchService) Search(c *gin.Context) serializer.Response {
 	// 分享Key上下文
 	ctx = context.WithValue(ctx, fsctx.ShareKeyCtx, hashid.HashID(share.ID, hashid.ShareID))
 
-	return service.SearchKeywords(c, fs, "%"+service.Keywords+"%")
+	res := service.SearchKeywords(c, fs, "%"+service.Keywords+"%")
+
+	// 多项分享的根目录下只返回所选对象中的结果
+	if res.Code == 0 && share.IsMultiItem() && fs.Root.ID == share.SourceID {
+		data := res.Data.(map[string]interface{})
+		data["objects"] = filterSearchResult(share, fs.FileTarget, data["objects"].([]serializer.Object))
+	}
+
+	return res
 }
@@ -0,0 +1,62 @@
+package user
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// AnnouncementService 站点公告服务
+type AnnouncementService struct {
+}
+
+// AnnouncementReadService 标记公告已读服务
+type AnnouncementReadService struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+// List 列出所有有效公告及当前用户的已读状态，供前端轮询
+func (service *AnnouncementService) List(c *gin.Context, user *model.User) serializer.Response {
+	announcements := model.ListActiveAnnouncements()
+	ids := make([]uint, 0, len(announcements))
+	for _, announcement := range announcements {
+		ids = append(ids, announcement.ID)
+	}
+	read := model.ReadAnnouncementIDs(user.ID, ids)
+
+	unread := 0
+	items := make([]map[string]interface{}, 0, len(announcements))
+	for _, announcement := range announcements {
+		if !read[announcement.ID] {
+			unread++
+		}
+		items = append(items, map[string]interface{}{
+			"id":         announcement.ID,
+			"title":      announcement.Title,
+			"content":    announcement.Content,
+			"severity":   announcement.Severity,
+			"expires_at": announcement.ExpiresAt,
+			"created_at": announcement.CreatedAt,
+			"read":       read[announcement.ID],
+		})
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"unread": unread,
+		"items":  items,
+	}}
+}
+
+// Read 将公告标记为已读
+func (service *AnnouncementReadService) Read(c *gin.Context, user *model.User) serializer.Response {
+	announcement, err := model.GetAnnouncementByID(service.ID)
+	if err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Announcement not exist", err)
+	}
+
+	if err := model.MarkAnnouncementRead(user.ID, announcement.ID); err != nil {
+		return serializer.DBErr("Failed to update announcement", err)
+	}
+
+	return serializer.Response{}
+}
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// DeletionRequestService 申请注销账户服务
+type DeletionRequestService struct {
+	Password string `json:"password" binding:"required,min=4,max=64"`
+}
+
+// DeletionCancelService 取消注销账户服务
+type DeletionCancelService struct {
+	UserName string `json:"userName" binding:"required,email"`
+	Password string `json:"Password" binding:"required,min=4,max=64"`
+}
+
+// Request 停用当前账户，宽限期结束后由定时任务删除账户下的所有数据
+func (service *DeletionRequestService) Request(c *gin.Context, user *model.User) serializer.Response {
+	if user.ID == 1 {
+		return serializer.Err(serializer.CodeInvalidActionOnDefaultUser, "", nil)
+	}
+
+	if ok, _ := user.CheckPassword(service.Password); !ok {
+		return serializer.Err(serializer.CodeIncorrectPassword, "", nil)
+	}
+
+	grace := time.Duration(model.GetIntSetting("account_deletion_grace", 14)) * 24 * time.Hour
+	if err := user.ScheduleDeletion(time.Now().Add(grace)); err != nil {
+		return serializer.DBErr("Failed to schedule account deletion", err)
+	}
+
+	// 注销所有已登录的会话
+	if err := sessionstore.RevokeAll(user.ID, ""); err != nil {
+		util.Log().Warning("Failed to revoke sessions of user %q: %s", user.Email, err)
+	}
+	util.DeleteSession(c, "user_id")
+
+	return serializer.Response{Data: user.DeleteAt}
+}
+
+// Cancel 在宽限期内取消注销，恢复账户
+func (service *DeletionCancelService) Cancel(c *gin.Context) serializer.Response {
+	expectedUser, err := model.GetUserByEmail(c.GetUint("tenant_id"), service.UserName)
+	if err != nil {
+		return serializer.Err(serializer.CodeCredentialInvalid, "Wrong password or email address", err)
+	}
+	if authOK, _ := expectedUser.CheckPassword(service.Password); !authOK {
+		return serializer.Err(serializer.CodeCredentialInvalid, "Wrong password or email address", nil)
+	}
+	if expectedUser.Status != model.PendingDeletion {
+		return serializer.ParamErr("This account is not scheduled for deletion", nil)
+	}
+
+	if err := expectedUser.CancelDeletion(); err != nil {
+		return serializer.DBErr("Failed to cancel account deletion", err)
+	}
+
+	return serializer.Response{}
+}
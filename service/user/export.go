@@ -0,0 +1,67 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportCreateService 创建用户数据导出任务服务
+type ExportCreateService struct {
+	IncludeContent bool `json:"include_content"`
+}
+
+// ExportDownloadService 下载用户数据导出服务
+type ExportDownloadService struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+// Create 创建用户数据导出任务，同一时间只能有一个进行中的导出
+func (service *ExportCreateService) Create(c *gin.Context, user *model.User) serializer.Response {
+	ongoing := 0
+	model.DB.Model(&model.Task{}).
+		Where("user_id = ? and type = ? and status in (?)", user.ID, task.ExportTaskType, []int{task.Queued, task.Processing}).
+		Count(&ongoing)
+	if ongoing > 0 {
+		return serializer.Err(serializer.CodeConflict, "An export task is already in progress", nil)
+	}
+
+	job, err := task.NewExportTask(user, service.IncludeContent)
+	if err != nil {
+		return serializer.Err(serializer.CodeCreateTaskError, "", err)
+	}
+	task.TaskPoll.Submit(job)
+
+	return serializer.Response{}
+}
+
+// Download 下载已完成的用户数据导出
+func (service *ExportDownloadService) Download(c *gin.Context, user *model.User) serializer.Response {
+	record, err := model.GetTasksByID(service.ID)
+	if err != nil || record.UserID != user.ID || record.Type != task.ExportTaskType || record.Status != task.Complete {
+		return serializer.Err(serializer.CodeNotFound, "Export not exist", err)
+	}
+
+	var props task.ExportProps
+	if err := json.Unmarshal([]byte(record.Props), &props); err != nil || props.Path == "" {
+		return serializer.Err(serializer.CodeNotFound, "Export not exist", err)
+	}
+
+	ttl := time.Duration(model.GetIntSetting("export_ttl", 604800)) * time.Second
+	if time.Now().After(record.UpdatedAt.Add(ttl)) {
+		return serializer.Err(serializer.CodeNotFound, "Export is expired", nil)
+	}
+
+	if _, err := os.Stat(props.Path); err != nil {
+		return serializer.Err(serializer.CodeNotFound, "Export is expired", err)
+	}
+
+	c.FileAttachment(props.Path, fmt.Sprintf("cloudreve_export_%d.zip", record.ID))
+	return serializer.Response{}
+}
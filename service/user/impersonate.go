@@ -0,0 +1,23 @@
+package user
+
+import (
+	"github.com/cloudreve/Cloudreve/v3/middleware"
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationService 模拟登录服务
+type ImpersonationService struct {
+}
+
+// Stop 结束模拟登录，恢复为管理员身份
+func (service *ImpersonationService) Stop(c *gin.Context, user *model.User) serializer.Response {
+	impersonator, ok := c.Get("impersonator")
+	if !ok {
+		return serializer.Err(serializer.CodeNoPermissionErr, "Not in an impersonation session", nil)
+	}
+
+	middleware.EndImpersonation(c, impersonator, user.ID, "stopped")
+	return serializer.Response{}
+}
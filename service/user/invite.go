@@ -0,0 +1,38 @@
+package user
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// InviteCreateService 用户生成邀请码服务
+type InviteCreateService struct {
+}
+
+// List 列出当前用户生成的邀请码
+func (service *InviteCreateService) List(c *gin.Context, user *model.User) serializer.Response {
+	return serializer.Response{Data: map[string]interface{}{
+		"max":   model.GetIntSetting("invite_user_max", 0),
+		"codes": model.ListInviteCodes(user.ID),
+	}}
+}
+
+// Create 生成新的邀请码，每个用户可生成的数量由站点设定限制
+func (service *InviteCreateService) Create(c *gin.Context, user *model.User) serializer.Response {
+	max := model.GetIntSetting("invite_user_max", 0)
+	if max <= 0 {
+		return serializer.Err(serializer.CodeFeatureNotEnabled, "User invitation is not enabled", nil)
+	}
+
+	if len(model.ListInviteCodes(user.ID)) >= max {
+		return serializer.Err(serializer.CodeGroupNotAllowed, "Invite code limit exceeded", nil)
+	}
+
+	code := model.NewInviteCode(user.ID, model.GetIntSetting("invite_default_uses", 1), nil)
+	if err := code.Create(); err != nil {
+		return serializer.DBErr("Failed to create invite code", err)
+	}
+
+	return serializer.Response{Data: code}
+}
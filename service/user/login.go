@@ -7,7 +7,10 @@ import (
 	"github.com/cloudreve/Cloudreve/v3/pkg/cache"
 	"github.com/cloudreve/Cloudreve/v3/pkg/email"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/netpolicy"
+	"github.com/cloudreve/Cloudreve/v3/pkg/pwpolicy"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gofrs/uuid"
@@ -54,11 +57,23 @@ func (service *UserResetService) Reset(c *gin.Context) serializer.Response {
 		return serializer.Err(serializer.CodeUserNotFound, "User not found", nil)
 	}
 
+	if err := pwpolicy.Check(service.Password); err != nil {
+		return serializer.Err(serializer.CodePasswordTooWeak, err.Error(), err)
+	}
+
 	user.SetPassword(service.Password)
-	if err := user.Update(map[string]interface{}{"password": user.Password}); err != nil {
+	if err := user.Update(map[string]interface{}{
+		"password":            user.Password,
+		"password_changed_at": user.PasswordChangedAt,
+	}); err != nil {
 		return serializer.DBErr("Failed to reset password", err)
 	}
 
+	// 注销所有已登录的会话
+	if err := sessionstore.RevokeAll(user.ID, ""); err != nil {
+		util.Log().Warning("Failed to revoke sessions of user %q: %s", user.Email, err)
+	}
+
 	cache.Deletes([]string{fmt.Sprintf("%d", uid)}, "user_reset_")
 	return serializer.Response{}
 }
@@ -66,7 +81,7 @@ func (service *UserResetService) Reset(c *gin.Context) serializer.Response {
 // Reset 发送密码重设邮件
 func (service *UserResetEmailService) Reset(c *gin.Context) serializer.Response {
 	// 查找用户
-	if user, err := model.GetUserByEmail(service.UserName); err == nil {
+	if user, err := model.GetUserByEmail(c.GetUint("tenant_id"), service.UserName); err == nil {
 
 		if user.Status == model.Baned || user.Status == model.OveruseBaned {
 			return serializer.Err(serializer.CodeUserBaned, "This user is banned", nil)
@@ -87,7 +102,7 @@ func (service *UserResetEmailService) Reset(c *gin.Context) serializer.Response
 		finalURL.RawQuery = queries.Encode()
 
 		// 发送密码重设邮件
-		title, body := email.NewResetEmail(user.Nick, finalURL.String())
+		title, body := email.NewResetEmail(email.ParseLang(c.GetHeader("Accept-Language")), user.Nick, finalURL.String())
 		if err := email.Send(user.Email, title, body); err != nil {
 			return serializer.Err(serializer.CodeFailedSendEmail, "Failed to send email", err)
 		}
@@ -106,8 +121,8 @@ func (service *Enable2FA) Login(c *gin.Context) serializer.Response {
 			return serializer.Err(serializer.CodeUserNotFound, "User not found", nil)
 		}
 
-		// 验证二步验证代码
-		if !totp.Validate(service.Code, expectedUser.TwoFactor) {
+		// 验证二步验证代码，无效时尝试作为恢复代码使用
+		if !totp.Validate(service.Code, expectedUser.TwoFactor) && !expectedUser.UseRecoveryCode(service.Code) {
 			return serializer.Err(serializer.Code2FACodeErr, "2FA code not correct", nil)
 		}
 
@@ -125,7 +140,7 @@ func (service *Enable2FA) Login(c *gin.Context) serializer.Response {
 
 // Login 用户登录函数
 func (service *UserLoginService) Login(c *gin.Context) serializer.Response {
-	expectedUser, err := model.GetUserByEmail(service.UserName)
+	expectedUser, err := model.GetUserByEmail(c.GetUint("tenant_id"), service.UserName)
 	// 一系列校验
 	if err != nil {
 		return serializer.Err(serializer.CodeCredentialInvalid, "Wrong password or email address", err)
@@ -139,6 +154,15 @@ func (service *UserLoginService) Login(c *gin.Context) serializer.Response {
 	if expectedUser.Status == model.NotActivicated {
 		return serializer.Err(serializer.CodeUserNotActivated, "This account is not activated", nil)
 	}
+	if expectedUser.Status == model.PendingDeletion {
+		return serializer.Err(serializer.CodeUserPendingDeletion, "This account is scheduled for deletion", nil)
+	}
+	if expectedUser.Status == model.PendingApproval {
+		return serializer.Err(serializer.CodeUserPendingApproval, "This account is pending for admin approval", nil)
+	}
+	if err := netpolicy.Check(&expectedUser.Group, c.ClientIP(), c.Request); err != nil {
+		return serializer.Err(serializer.CodeNetworkRestricted, err.Error(), err)
+	}
 
 	if expectedUser.TwoFactor != "" {
 		// 需要二步验证
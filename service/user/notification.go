@@ -0,0 +1,53 @@
+package user
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationPreference 通知偏好设定
+type NotificationPreference struct {
+	Type    string `json:"type" binding:"required,oneof=share_downloaded task_finished quota_warning announcement share_security"`
+	Channel string `json:"channel" binding:"required,oneof=email in_app"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Update 更新通知偏好
+func (service *NotificationPreference) Update(c *gin.Context, user *model.User) serializer.Response {
+	user.SetNotifyPref(service.Type, service.Channel, service.Enabled)
+	if err := user.UpdateOptions(); err != nil {
+		return serializer.DBErr("Failed to update user preferences", err)
+	}
+
+	return serializer.Response{Data: user.NotifyPrefs()}
+}
+
+// NotificationListService 站内通知列表服务
+type NotificationListService struct {
+	Page int `form:"page" binding:"required,min=1"`
+}
+
+// List 列出站内通知
+func (service *NotificationListService) List(c *gin.Context, user *model.User) serializer.Response {
+	notifications, total := model.ListNotifications(user.ID, service.Page, 10)
+	return serializer.Response{Data: map[string]interface{}{
+		"total":  total,
+		"unread": model.CountUnreadNotifications(user.ID),
+		"items":  notifications,
+	}}
+}
+
+// NotificationReadService 标记站内通知已读服务
+type NotificationReadService struct {
+	IDs []uint `json:"ids"`
+}
+
+// Read 将给定通知标记为已读，未指定时标记全部通知
+func (service *NotificationReadService) Read(c *gin.Context, user *model.User) serializer.Response {
+	if err := model.MarkNotificationsRead(user.ID, service.IDs); err != nil {
+		return serializer.DBErr("Failed to update notifications", err)
+	}
+
+	return serializer.Response{}
+}
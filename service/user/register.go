@@ -2,12 +2,14 @@ package user
 
 import (
 	"net/url"
+	"strconv"
 	"strings"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
 	"github.com/cloudreve/Cloudreve/v3/pkg/auth"
 	"github.com/cloudreve/Cloudreve/v3/pkg/email"
 	"github.com/cloudreve/Cloudreve/v3/pkg/hashid"
+	"github.com/cloudreve/Cloudreve/v3/pkg/pwpolicy"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
 	"github.com/gin-gonic/gin"
 )
@@ -15,34 +17,70 @@ import (
 // UserRegisterService 管理用户注册的服务
 type UserRegisterService struct {
 	//TODO 细致调整验证规则
-	UserName string `form:"userName" json:"userName" binding:"required,email"`
-	Password string `form:"Password" json:"Password" binding:"required,min=4,max=64"`
+	UserName   string `form:"userName" json:"userName" binding:"required,email"`
+	Password   string `form:"Password" json:"Password" binding:"required,min=4,max=64"`
+	InviteCode string `form:"inviteCode" json:"inviteCode" binding:"max=32"`
 }
 
 // Register 新用户注册
 func (service *UserRegisterService) Register(c *gin.Context) serializer.Response {
 	// 相关设定
-	options := model.GetSettingByNames("email_active")
+	options := model.GetSettingByNames("email_active", "register_invite_only", "register_approval")
 
 	// 相关设定
 	isEmailRequired := model.IsTrueVal(options["email_active"])
+	isInviteOnly := model.IsTrueVal(options["register_invite_only"])
+	isApprovalRequired := model.IsTrueVal(options["register_approval"])
 	defaultGroup := model.GetIntSetting("default_group", 2)
 
+	// 租户可指定其用户组作为新用户的默认用户组
+	tenantID := c.GetUint("tenant_id")
+	if tenantID > 0 {
+		tenant, _ := c.Get("tenant")
+		groupID, err := strconv.Atoi(tenant.(*model.Tenant).GetSettingByName("default_group"))
+		if err != nil {
+			return serializer.Err(serializer.CodeInternalSetting, "Invalid default group of tenant", err)
+		}
+		if err := model.CheckGroupTenant(uint(groupID), tenantID); err != nil {
+			return serializer.Err(serializer.CodeInternalSetting, "Invalid default group of tenant", err)
+		}
+		defaultGroup = groupID
+	}
+
+	// 检查密码策略
+	if err := pwpolicy.Check(service.Password); err != nil {
+		return serializer.Err(serializer.CodePasswordTooWeak, err.Error(), err)
+	}
+
+	// 检查邀请码
+	var invite *model.InviteCode
+	if isInviteOnly {
+		code, err := model.GetInviteCodeByCode(service.InviteCode)
+		if service.InviteCode == "" || err != nil || !code.IsAvailable() {
+			return serializer.Err(serializer.CodeInvalidInviteCode, "Invalid invite code", err)
+		}
+		invite = code
+	}
+
 	// 创建新的用户对象
 	user := model.NewUser()
 	user.Email = service.UserName
 	user.Nick = strings.Split(service.UserName, "@")[0]
 	user.SetPassword(service.Password)
 	user.Status = model.Active
+	if isApprovalRequired {
+		user.Status = model.PendingApproval
+	}
 	if isEmailRequired {
 		user.Status = model.NotActivicated
 	}
 	user.GroupID = uint(defaultGroup)
+	user.TenantID = tenantID
 	userNotActivated := false
 	// 创建用户
 	if err := model.DB.Create(&user).Error; err != nil {
 		//检查已存在使用者是否尚未激活
-		expectedUser, err := model.GetUserByEmail(service.UserName)
+		expectedUser, err := model.GetUserByEmail(tenantID, service.UserName)
 		if expectedUser.Status == model.NotActivicated {
 			userNotActivated = true
 			user = expectedUser
@@ -51,6 +89,12 @@ func (service *UserRegisterService) Register(c *gin.Context) serializer.Response
 		}
 	}
 
+	// 消耗邀请码，次数已被并发用尽时撤销注册
+	if invite != nil && !userNotActivated && !invite.Use() {
+		model.DB.Unscoped().Delete(&user)
+		return serializer.Err(serializer.CodeInvalidInviteCode, "Invalid invite code", nil)
+	}
+
 	// 发送激活邮件
 	if isEmailRequired {
 
@@ -75,7 +119,7 @@ func (service *UserRegisterService) Register(c *gin.Context) serializer.Response
 		finalURL.RawQuery = queries.Encode()
 
 		// 返送激活邮件
-		title, body := email.NewActivationEmail(user.Email,
+		title, body := email.NewActivationEmail(email.ParseLang(c.GetHeader("Accept-Language")), user.Email,
 			finalURL.String(),
 		)
 		if err := email.Send(user.Email, title, body); err != nil {
@@ -89,6 +133,10 @@ func (service *UserRegisterService) Register(c *gin.Context) serializer.Response
 		}
 	}
 
+	if isApprovalRequired {
+		return serializer.Err(serializer.CodeUserPendingApproval, "Registration is pending for admin approval", nil)
+	}
+
 	return serializer.Response{}
 }
 
@@ -106,7 +154,11 @@ func (service *SettingService) Activate(c *gin.Context) serializer.Response {
 		return serializer.Err(serializer.CodeUserCannotActivate, "This user cannot be activated", nil)
 	}
 
-	// 激活用户
+	// 激活用户，需要审核时转入待审核状态
+	if model.IsTrueVal(model.GetSettingByName("register_approval")) {
+		user.SetStatus(model.PendingApproval)
+		return serializer.Err(serializer.CodeUserPendingApproval, "Registration is pending for admin approval", nil)
+	}
 	user.SetStatus(model.Active)
 
 	return serializer.Response{Data: user.Email}
@@ -0,0 +1,56 @@
+package user
+
+import (
+	"sort"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
+	"github.com/cloudreve/Cloudreve/v3/pkg/util"
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceService 登录会话与设备管理服务
+type DeviceService struct {
+}
+
+// DeviceRevokeService 撤销单个会话服务
+type DeviceRevokeService struct {
+	ID string `uri:"id" binding:"required"`
+}
+
+// List 列出当前用户的活跃会话和 WebDAV 账号活动
+func (service *DeviceService) List(c *gin.Context, user *model.User) serializer.Response {
+	activities := sessionstore.List(user.ID)
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].LastActive.After(activities[j].LastActive)
+	})
+
+	return serializer.Response{Data: map[string]interface{}{
+		"sessions": serializer.BuildActiveSessions(activities, util.SessionID(c)),
+		"webdav":   serializer.BuildWebDAVActivities(model.ListWebDAVAccounts(user.ID)),
+	}}
+}
+
+// RevokeAll 注销当前会话以外的所有登录会话
+func (service *DeviceService) RevokeAll(c *gin.Context, user *model.User) serializer.Response {
+	if err := sessionstore.RevokeAll(user.ID, util.SessionID(c)); err != nil {
+		return serializer.Err(serializer.CodeCacheOperation, "Failed to revoke sessions", err)
+	}
+
+	return serializer.Response{}
+}
+
+// Revoke 注销指定的登录会话
+func (service *DeviceRevokeService) Revoke(c *gin.Context, user *model.User) serializer.Response {
+	found, err := sessionstore.Revoke(user.ID, service.ID)
+	if !found {
+		return serializer.Err(serializer.CodeNotFound, "Session not exist", nil)
+	}
+
+	if err != nil {
+		return serializer.Err(serializer.CodeCacheOperation, "Failed to revoke session", err)
+	}
+
+	return serializer.Response{}
+}
@@ -10,7 +10,9 @@ import (
 	"strings"
 
 	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/pwpolicy"
 	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/sessionstore"
 	"github.com/cloudreve/Cloudreve/v3/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/totp"
@@ -32,7 +34,7 @@ type AvatarService struct {
 
 // SettingUpdateService 设定更改服务
 type SettingUpdateService struct {
-	Option string `uri:"option" binding:"required,eq=nick|eq=theme|eq=homepage|eq=vip|eq=qq|eq=policy|eq=password|eq=2fa|eq=authn"`
+	Option string `uri:"option" binding:"required,eq=nick|eq=theme|eq=homepage|eq=vip|eq=qq|eq=policy|eq=password|eq=2fa|eq=authn|eq=notification|eq=folder_templates"`
 }
 
 // OptionsChangeHandler 属性更改接口
@@ -66,6 +68,11 @@ type Enable2FA struct {
 	Code string `json:"code" binding:"required"`
 }
 
+// RecoveryCodeService 管理二步验证恢复代码
+type RecoveryCodeService struct {
+	Code string `json:"code" binding:"required"`
+}
+
 // DeleteWebAuthn 删除WebAuthn凭证
 type DeleteWebAuthn struct {
 	ID string `json:"id" binding:"required"`
@@ -109,15 +116,67 @@ func (service *Enable2FA) Update(c *gin.Context, user *model.User) serializer.Re
 			return serializer.DBErr("Failed to update user preferences", err)
 		}
 
-	} else {
-		// 关闭2FA
-		if !totp.Validate(service.Code, user.TwoFactor) {
-			return serializer.ParamErr("Incorrect 2FA code", nil)
+		// 生成恢复代码，仅在此时返回明文
+		codes, err := user.GenerateRecoveryCodes()
+		if err != nil {
+			return serializer.DBErr("Failed to generate recovery codes", err)
 		}
 
-		if err := user.Update(map[string]interface{}{"two_factor": ""}); err != nil {
-			return serializer.DBErr("Failed to update user preferences", err)
-		}
+		util.DeleteSession(c, "2fa_init")
+		return serializer.Response{Data: map[string]interface{}{
+			"recovery_codes": codes,
+		}}
+	}
+
+	// 关闭2FA
+	if !totp.Validate(service.Code, user.TwoFactor) {
+		return serializer.ParamErr("Incorrect 2FA code", nil)
+	}
+
+	if err := user.ResetTwoFactor(); err != nil {
+		return serializer.DBErr("Failed to update user preferences", err)
+	}
+
+	return serializer.Response{}
+}
+
+// Regenerate 重新生成二步验证恢复代码
+func (service *RecoveryCodeService) Regenerate(c *gin.Context, user *model.User) serializer.Response {
+	if resp := service.validate(user); resp.Code != 0 {
+		return resp
+	}
+
+	codes, err := user.GenerateRecoveryCodes()
+	if err != nil {
+		return serializer.DBErr("Failed to generate recovery codes", err)
+	}
+
+	return serializer.Response{Data: map[string]interface{}{
+		"recovery_codes": codes,
+	}}
+}
+
+// Revoke 作废所有二步验证恢复代码
+func (service *RecoveryCodeService) Revoke(c *gin.Context, user *model.User) serializer.Response {
+	if resp := service.validate(user); resp.Code != 0 {
+		return resp
+	}
+
+	if err := user.RevokeRecoveryCodes(); err != nil {
+		return serializer.DBErr("Failed to revoke recovery codes", err)
+	}
+
+	return serializer.Response{}
+}
+
+// validate 管理恢复代码前需要已开启二步验证，并验证当前代码
+func (service *RecoveryCodeService) validate(user *model.User) serializer.Response {
+	if user.TwoFactor == "" {
+		return serializer.Err(serializer.CodeFeatureNotEnabled, "2FA is not enabled", nil)
+	}
+
+	if !totp.Validate(service.Code, user.TwoFactor) {
+		return serializer.Err(serializer.Code2FACodeErr, "Incorrect 2FA code", nil)
 	}
 
 	return serializer.Response{}
@@ -144,12 +203,28 @@ func (service *PasswordChange) Update(c *gin.Context, user *model.User) serializ
 		return serializer.Err(serializer.CodeIncorrectPassword, "", nil)
 	}
 
+	// 检查密码策略
+	if service.New == service.Old {
+		return serializer.Err(serializer.CodePasswordTooWeak, "", pwpolicy.ErrPasswordNotChanged)
+	}
+	if err := pwpolicy.Check(service.New); err != nil {
+		return serializer.Err(serializer.CodePasswordTooWeak, err.Error(), err)
+	}
+
 	// 更改为新密码
 	user.SetPassword(service.New)
-	if err := user.Update(map[string]interface{}{"password": user.Password}); err != nil {
+	if err := user.Update(map[string]interface{}{
+		"password":            user.Password,
+		"password_changed_at": user.PasswordChangedAt,
+	}); err != nil {
 		return serializer.DBErr("Failed to update password", err)
 	}
 
+	// 注销其他设备上的会话
+	if err := sessionstore.RevokeAll(user.ID, util.SessionID(c)); err != nil {
+		util.Log().Warning("Failed to revoke sessions of user %q: %s", user.Email, err)
+	}
+
 	return serializer.Response{}
 }
 
@@ -248,9 +323,15 @@ func (service *SettingService) Settings(c *gin.Context, user *model.User) serial
 			"uid":          user.ID,
 			"homepage":     !user.OptionsSerialized.ProfileOff,
 			"two_factor":   user.TwoFactor != "",
+			"recovery":     user.RecoveryCodeCount(),
 			"prefer_theme": user.OptionsSerialized.PreferredTheme,
 			"themes":       model.GetSettingByName("themes"),
 			"authn":        serializer.BuildWebAuthnList(user.WebAuthnCredentials()),
+			"notification": user.NotifyPrefs(),
+			"folder_templates": map[string]interface{}{
+				"user":  user.OptionsSerialized.FolderTemplates,
+				"group": user.Group.OptionsSerialized.FolderTemplates,
+			},
 		},
 	}
 }
@@ -0,0 +1,47 @@
+package user
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/cloudreve/Cloudreve/v3/pkg/task"
+	"github.com/gin-gonic/gin"
+)
+
+// TaskService 单个任务操作服务
+type TaskService struct {
+	ID uint `uri:"id" binding:"required"`
+}
+
+// Progress 获取任务状态及执行中任务的细粒度进度
+func (service *TaskService) Progress(c *gin.Context, user *model.User) serializer.Response {
+	record, err := model.GetTasksByID(service.ID)
+	if err != nil || record.UserID != user.ID {
+		return serializer.Err(serializer.CodeNotFound, "Task not exist", err)
+	}
+
+	res := map[string]interface{}{
+		"status":   record.Status,
+		"type":     record.Type,
+		"progress": record.Progress,
+		"attempts": record.Attempts,
+	}
+	if progress, ok := task.GetProgress(record.ID); ok {
+		res["detail"] = progress
+	}
+
+	return serializer.Response{Data: res}
+}
+
+// Cancel 取消排队中或执行中的任务
+func (service *TaskService) Cancel(c *gin.Context, user *model.User) serializer.Response {
+	record, err := model.GetTasksByID(service.ID)
+	if err != nil || record.UserID != user.ID {
+		return serializer.Err(serializer.CodeNotFound, "Task not exist", err)
+	}
+
+	if err := task.Cancel(record); err != nil {
+		return serializer.Err(serializer.CodeConflict, "Task cannot be canceled", err)
+	}
+
+	return serializer.Response{}
+}
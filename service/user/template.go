@@ -0,0 +1,36 @@
+package user
+
+import (
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/filesystem"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// FolderTemplateSetting 设定用户自定义的目录模板
+type FolderTemplateSetting struct {
+	Templates []model.FolderTemplate `json:"templates" binding:"max=20,dive"`
+}
+
+// Update 更新用户的目录模板
+func (service *FolderTemplateSetting) Update(c *gin.Context, user *model.User) serializer.Response {
+	names := make(map[string]bool, len(service.Templates))
+	for _, template := range service.Templates {
+		if names[template.Name] {
+			return serializer.ParamErr("Duplicated template name "+template.Name, nil)
+		}
+		names[template.Name] = true
+
+		// 变量在创建时才给定，此处仅检查模板语法
+		if err := filesystem.ValidateFolderTemplate(template.Structure); err != nil {
+			return serializer.Err(serializer.CodeParamErr, err.Error(), err)
+		}
+	}
+
+	user.OptionsSerialized.FolderTemplates = service.Templates
+	if err := user.UpdateOptions(); err != nil {
+		return serializer.DBErr("Failed to update user preferences", err)
+	}
+
+	return serializer.Response{Data: user.OptionsSerialized.FolderTemplates}
+}
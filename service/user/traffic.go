@@ -0,0 +1,31 @@
+package user
+
+import (
+	"time"
+
+	model "github.com/cloudreve/Cloudreve/v3/models"
+	"github.com/cloudreve/Cloudreve/v3/pkg/serializer"
+	"github.com/gin-gonic/gin"
+)
+
+// TrafficService 用户流量统计服务
+type TrafficService struct {
+	Days int `form:"days" binding:"min=0,max=366"`
+}
+
+// Traffic 返回用户本月的流量使用情况及最近若干天的每日流量
+func (service *TrafficService) Traffic(c *gin.Context, user *model.User) serializer.Response {
+	days := service.Days
+	if days == 0 {
+		days = 30
+	}
+
+	now := time.Now()
+	upload, download := model.GetMonthlyTraffic(user.ID, now)
+	daily, err := model.ListTraffic(user.ID, now.AddDate(0, 0, 1-days))
+	if err != nil {
+		return serializer.DBErr("Failed to list traffic records", err)
+	}
+
+	return serializer.BuildUserTrafficResponse(user, upload, download, daily)
+}